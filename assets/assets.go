@@ -0,0 +1,15 @@
+// Package assets carries the application icon for the desktop integrations (the tray)
+package assets
+
+import _ "embed"
+
+// Icon is the application icon in .ico form, holding PNG images from 16 to 256 pixels, for
+// Windows
+//
+//go:embed icon.ico
+var Icon []byte
+
+// IconPNG is the 64 pixel image of the icon, for the trays of Linux and macOS
+//
+//go:embed icon-64.png
+var IconPNG []byte
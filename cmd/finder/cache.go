@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/i18n"
+)
+
+// pathMappings collects repeated -map flags
+type pathMappings []db.PathMapping
+
+func (m *pathMappings) String() string {
+	specs := make([]string, len(*m))
+	for i, mapping := range *m {
+		specs[i] = mapping.From + "=" + mapping.To
+	}
+	return strings.Join(specs, ",")
+}
+
+func (m *pathMappings) Set(spec string) error {
+	mapping, err := db.ParsePathMapping(spec)
+	if err != nil {
+		return err
+	}
+	*m = append(*m, mapping)
+	return nil
+}
+
+// useCache points the cache at the database of the settings, or at memory for -no-cache.
+// Previews of an in-memory cache are not written to disk either.
+func useCache(dsn string, noCache bool) {
+	if noCache {
+		dsn = db.MemoryDSN
+	}
+	db.SetDSN(dsn)
+	archive.SetPreviewCache(dsn != db.MemoryDSN)
+}
+
+// runCacheCommand handles the `finder cache` subcommands
+func runCacheCommand(args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  finder cache stats                          Show cache size and entries per scan root")
+		fmt.Fprintln(os.Stderr, "  finder cache gc                             Drop entries of files that no longer exist")
+		fmt.Fprintln(os.Stderr, "  finder cache forget <root>                  Drop every entry of a scan root")
+		fmt.Fprintln(os.Stderr, "  finder cache export <file>                  Write hashes, previews, visual hashes and ignored groups to a file")
+		fmt.Fprintln(os.Stderr, "  finder cache import [-map from=to] <file>   Merge an exported cache into the local one")
+		fmt.Fprintln(os.Stderr, "  finder cache migrate [-to N]                Migrate the cache schema (back to N before running an older finder)")
+		fmt.Fprintln(os.Stderr, "  finder cache suppressed                     List the suppressed content hashes")
+		fmt.Fprintln(os.Stderr, "  finder cache suppress [-note t] <file>...   Never report copies of these identical files again")
+		fmt.Fprintln(os.Stderr, "  finder cache unsuppress <hash>              Report copies of a suppressed content hash again")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	var o cacheOptions
+	fs := cacheFlags(args[0], &o)
+	fs.Parse(args[1:])
+	operands := 1
+	if args[0] == "stats" || args[0] == "gc" || args[0] == "migrate" || args[0] == "suppressed" {
+		operands = 0
+	}
+	if args[0] == "suppress" {
+		if fs.NArg() < 2 {
+			usage()
+		}
+	} else if fs.NArg() != operands {
+		usage()
+	}
+	arg := fs.Arg(0) // File or root, depending on the subcommand
+
+	// The cache may live on a database server named in the settings
+	appConfig, err := config.LoadConfig()
+	if appConfig == nil || (err != nil && !errors.Is(err, os.ErrNotExist)) {
+		fatal(exitInvalidConfig, i18n.T("❌ Could not read the settings: %v", err))
+	}
+	useCache(appConfig.CacheDSN, false)
+
+	// Migrating opens the database as it is, without bringing it to this build's schema first
+	if args[0] == "migrate" {
+		from, err := db.MigrateCache(o.to)
+		if err != nil {
+			log.Fatal(i18n.T("❌ Migration failed: %v", err))
+		}
+		if from == o.to {
+			log.Print(i18n.T("✅ The cache is already at schema version %d", o.to))
+		} else {
+			log.Print(i18n.T("🔄 Cache schema migrated from version %d to %d", from, o.to))
+		}
+		return
+	}
+
+	cache, err := db.NewCache()
+	if err != nil {
+		log.Fatal(i18n.T("❌ Could not open cache: %v", err))
+	}
+	defer cache.Close()
+
+	switch args[0] {
+	case "stats":
+		stats, err := cache.Stats()
+		if err != nil {
+			log.Fatal(i18n.T("❌ Could not read cache: %v", err))
+		}
+		fmt.Print(i18n.T("🗄️  %s (%s, schema version %d)\n", stats.Path, formatBytes(stats.SizeBytes), stats.Schema))
+		for _, r := range stats.Roots {
+			root := r.Root
+			if root == "" {
+				root = i18n.T("(unattributed)")
+			}
+			fmt.Printf("   %s\n      %s\n", root, i18n.T("%d hashes, %d previews, %d visual hashes, %d preview overrides, %d folder listings, %d archive contents",
+				r.FileHashes, r.Previews, r.VisualHashes, r.Overrides, r.DirListings, r.Contents))
+		}
+		fmt.Print(i18n.T("   %d ignored groups, %d suppressed hashes, %d name keys, %d cached scan results\n",
+			stats.IgnoredGroups, stats.Suppressed, stats.NameKeys, stats.ScanResults))
+	case "gc":
+		result, err := cache.GC()
+		if err != nil {
+			log.Fatal(i18n.T("❌ Garbage collection failed: %v", err))
+		}
+		for _, dir := range result.Offline {
+			log.Print(i18n.T("⚠️  Not reachable, entries kept: %s", dir))
+		}
+		log.Print(i18n.T("🧹 Removed %d stale cache entries", result.Total))
+	case "forget":
+		n, err := cache.ForgetRoot(arg)
+		if err != nil {
+			log.Fatal(i18n.T("❌ Could not forget %s: %v", arg, err))
+		}
+		log.Print(i18n.T("🧹 Removed %d cache entries of %s", n, arg))
+	case "export":
+		out, err := os.Create(arg)
+		if err != nil {
+			log.Fatal(i18n.T("❌ Could not create %s: %v", arg, err))
+		}
+		if err := cache.Export(out); err != nil {
+			out.Close()
+			log.Fatal(i18n.T("❌ Export failed: %v", err))
+		}
+		if err := out.Close(); err != nil {
+			log.Fatal(i18n.T("❌ Export failed: %v", err))
+		}
+		log.Print(i18n.T("📤 Cache exported to %s", arg))
+	case "import":
+		in, err := os.Open(arg)
+		if err != nil {
+			log.Fatal(i18n.T("❌ Could not open %s: %v", arg, err))
+		}
+		defer in.Close()
+		stats, err := cache.Import(in, o.mappings)
+		if err != nil {
+			log.Fatal(i18n.T("❌ Import failed: %v", err))
+		}
+		log.Print(i18n.T("📥 Imported %d file hashes, %d previews, %d visual hashes and %d ignored groups from %s",
+			stats.FileHashes, stats.Previews, stats.VisualHashes, stats.IgnoredGroups, arg))
+	case "suppressed":
+		hashes := cache.ListSuppressedHashes()
+		if len(hashes) == 0 {
+			fmt.Println(i18n.T("No content is suppressed."))
+			return
+		}
+		for _, h := range hashes {
+			fmt.Printf("%s  %s  %s\n", h.Hash, h.CreatedAt, h.Note)
+		}
+	case "suppress":
+		hash, ok := hashing.GroupContentHash(cache, fs.Args())
+		if !ok {
+			log.Fatal(i18n.T("❌ The files do not have identical contents"))
+		}
+		cache.AddSuppressedHash(hash, o.note)
+		log.Print(i18n.T("🔕 Suppressed content hash %s (%d copies)", hash, fs.NArg()))
+	case "unsuppress":
+		if !cache.IsHashSuppressed(arg) {
+			log.Fatal(i18n.T("❌ Content hash %s is not suppressed", arg))
+		}
+		cache.RemoveSuppressedHash(arg)
+		log.Print(i18n.T("🔔 Content hash %s is reported again", arg))
+	default:
+		usage()
+	}
+}
+
+// cacheSubcommands are the subcommands of `finder cache`
+var cacheSubcommands = []string{"stats", "gc", "forget", "export", "import", "migrate", "suppressed", "suppress", "unsuppress"}
+
+// cacheOptions are the flags of the `finder cache` subcommands
+type cacheOptions struct {
+	mappings pathMappings
+	to       int
+	note     string
+}
+
+// cacheFlags defines the flags of `finder cache <sub>` on a new flag set
+func cacheFlags(sub string, o *cacheOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("cache "+sub, flag.ExitOnError)
+	switch sub {
+	case "import":
+		fs.Var(&o.mappings, "map", "Rewrite a path prefix while importing, e.g. /mnt/nas/models=/volume1/models (repeatable)")
+	case "migrate":
+		fs.IntVar(&o.to, "to", db.LatestSchema(), "Schema version to migrate to, also an older one for going back to an earlier finder")
+	case "suppress":
+		fs.StringVar(&o.note, "note", "", "Why these copies are kept, shown by `finder cache suppressed`")
+	}
+	return fs
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/importer"
+	"archive-duplicate-finder/internal/manifest"
+	"archive-duplicate-finder/internal/reporter"
+)
+
+// command is a `finder` subcommand, as the completion scripts and the man page describe it
+type command struct {
+	name    string
+	summary string
+	usage   string                         // Operands after the flags
+	subs    []string                       // Subcommands of its own (cache stats, config set...)
+	flags   func(sub string) *flag.FlagSet // nil when it takes no flags
+}
+
+// commands lists the subcommands; their flags come from the same definitions the commands parse
+var commands = []command{
+	{name: "cache", summary: "Show, clean, export and import the cache", usage: "<subcommand> [flags] [file or root]", subs: cacheSubcommands,
+		flags: func(sub string) *flag.FlagSet { return cacheFlags(sub, new(cacheOptions)) }},
+	{name: "completion", summary: "Print the shell completion script", usage: "bash|zsh|fish|powershell", subs: completionShells},
+	{name: "config", summary: "Write, show and change the settings file", usage: "<subcommand> [flags] [key value]", subs: configSubcommands,
+		flags: func(sub string) *flag.FlagSet { return configFlags(sub, new(string), new(bool)) }},
+	{name: "diff", summary: "List the archives of a folder that already exist in a library", usage: "-source <folder> -library <folder> [flags]",
+		flags: func(string) *flag.FlagSet { return diffFlags(new(diffOptions)) }},
+	{name: "extract", summary: "Extract entries from an archive", usage: "[-dest <folder>] <archive> <entry or folder>...",
+		flags: func(string) *flag.FlagSet { return extractFlags(new(extractOptions)) }},
+	{name: "here", summary: "Scan a folder and open the dashboard on it; install adds it to the context menu of folders", usage: "[flags] <folder> | install | uninstall", subs: hereSubcommands,
+		flags: func(sub string) *flag.FlagSet { return hereFlags(sub, new(hereOptions)) }},
+	{name: "import", summary: "Review and clean up the duplicates found by rdfind, fdupes or czkawka", usage: "-format <tool> [-json <report.json>] [-web] <results file>",
+		flags: func(string) *flag.FlagSet { return importFlags(new(importOptions)) }},
+	{name: "manifest", summary: "Write or verify the checksums of the archives of a folder", usage: "[-format sha256|md5|sfv] [-verify] <folder>",
+		flags: func(string) *flag.FlagSet { return manifestFlags(new(manifestOptions)) }},
+	{name: "man", summary: "Print the manual page (roff)"},
+	{name: "mcp", summary: "Relay an assistant's MCP messages between stdin/stdout and a running dashboard", usage: "[-url <address>] [-token <token>]",
+		flags: func(string) *flag.FlagSet { return mcpFlags(new(mcpOptions)) }},
+	{name: "prune", summary: "Remove the backup archives that are exact copies of primary ones", usage: "-primary <folder> -backup <folder> [flags]",
+		flags: func(string) *flag.FlagSet { return pruneFlags(new(pruneOptions)) }},
+	{name: "purge", summary: "Delete files quarantined long enough ago for good", usage: "[-older-than 30d] [flags]",
+		flags: func(string) *flag.FlagSet { return quarantineFlags("purge", new(quarantineOptions)) }},
+	{name: "quarantine", summary: "List quarantined files and restore them", usage: "<subcommand> [flags] [id...]", subs: quarantineSubcommands,
+		flags: func(sub string) *flag.FlagSet { return quarantineFlags(sub, new(quarantineOptions)) }},
+	{name: "review", summary: "Review duplicate groups in the terminal", usage: "[-dir <folder> | -report <report.json>] [flags]",
+		flags: func(string) *flag.FlagSet { return reviewFlags(new(reviewOptions)) }},
+	{name: "serve", summary: "Serve the dashboard and the API without a CLI scan", usage: "[-headless] [flags]",
+		flags: func(string) *flag.FlagSet { return serveFlags(new(serveOptions)) }},
+	{name: "tray", summary: "Serve the dashboard from an icon in the system tray", usage: "[-port n] [-scan=false]",
+		flags: func(string) *flag.FlagSet { return trayFlags(new(trayOptions)) }},
+}
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Flags completed with folders or files rather than free text
+var (
+	dirFlags  = map[string]bool{"dir": true, "trash": true, "organize": true, "source": true, "library": true, "dest": true, "ui-dir": true, "quarantine": true, "primary": true, "backup": true}
+	fileFlags = map[string]bool{"json": true, "pdf": true, "script": true, "excludes": true, "file": true, "config": true, "ops-log": true, "report": true}
+)
+
+// flagChoices are the values of flags that take one of a fixed set
+func flagChoices() map[string][]string {
+	return map[string][]string{
+		"mode":                 {"all", "size", "name"},
+		"delete":               {"oldest", "contents"},
+		"output":               {outputText, outputNDJSON},
+		"lang":                 i18n.Languages(),
+		"phonetic":             {"soundex", "metaphone"},
+		"verify-before-delete": {"stat", "hash"},
+		"ref-format":           {"text", "json"},
+		"rename":               {"suggest", "apply"},
+		"group-by":             {reporter.GroupByDir},
+		"format":               append(slices.Clone(importer.Formats), manifest.Formats...),
+	}
+}
+
+// scanFlagSet holds the flags of a scan, for the completion scripts and the man page
+func scanFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("finder", flag.ContinueOnError)
+	defineScanFlags(fs, new(Config))
+	return fs
+}
+
+// flagSpec is a flag as the completion scripts offer it
+type flagSpec struct {
+	name    string
+	summary string // First part of the usage, short enough for a completion menu
+	value   bool   // Takes a value (-name value); booleans do not
+}
+
+func flagSpecs(fs *flag.FlagSet) []flagSpec {
+	if fs == nil {
+		return nil
+	}
+	var specs []flagSpec
+	fs.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		specs = append(specs, flagSpec{name: f.Name, summary: shortUsage(f.Usage), value: !ok || !boolFlag.IsBoolFlag()})
+	})
+	return specs
+}
+
+var parenthetical = regexp.MustCompile(` \([^)]*\)`)
+
+// shortUsage drops the parentheticals and everything after the first sentence of a usage
+func shortUsage(usage string) string {
+	usage = parenthetical.ReplaceAllString(usage, "")
+	if i := strings.IndexAny(usage, ":;."); i > 0 {
+		usage = usage[:i]
+	}
+	if len(usage) > 70 {
+		usage = usage[:67] + "..."
+	}
+	return strings.TrimSpace(usage)
+}
+
+// programName is the name the finder was run as, which the scripts complete
+func programName() string {
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+}
+
+// runCompletionCommand handles `finder completion <shell>`
+func runCompletionCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: finder completion bash|zsh|fish|powershell")
+		fmt.Fprintln(os.Stderr, "  bash:       source <(finder completion bash)")
+		fmt.Fprintln(os.Stderr, "  zsh:        finder completion zsh > \"${fpath[1]}/_finder\"")
+		fmt.Fprintln(os.Stderr, "  fish:       finder completion fish > ~/.config/fish/completions/finder.fish")
+		fmt.Fprintln(os.Stderr, "  powershell: finder completion powershell | Out-String | Invoke-Expression")
+		os.Exit(2)
+	}
+	prog := programName()
+	switch args[0] {
+	case "bash":
+		writeBashCompletion(os.Stdout, prog)
+	case "zsh":
+		writeZshCompletion(os.Stdout, prog)
+	case "fish":
+		writeFishCompletion(os.Stdout, prog)
+	case "powershell":
+		writePowerShellCompletion(os.Stdout, prog)
+	default:
+		fmt.Fprintf(os.Stderr, "❌ Unknown shell %q (available: %s)\n", args[0], strings.Join(completionShells, ", "))
+		os.Exit(2)
+	}
+}
+
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return names
+}
+
+func dashed(specs []flagSpec) string {
+	names := make([]string, len(specs))
+	for i, s := range specs {
+		names[i] = "-" + s.name
+	}
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer, prog string) {
+	fn := "_" + shellIdent(prog)
+	var dirs, files []string
+	for _, name := range slices.Sorted(maps.Keys(dirFlags)) {
+		dirs = append(dirs, "-"+name, "--"+name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(fileFlags)) {
+		files = append(files, "-"+name, "--"+name)
+	}
+
+	fmt.Fprintf(w, "# bash completion for %s\n", prog)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `    local cmd="${COMP_WORDS[1]}" sub="${COMP_WORDS[2]}" flags=""`)
+	fmt.Fprintln(w, `    case "$prev" in`)
+	fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -d -- \"$cur\")); return ;;\n", strings.Join(dirs, "|"))
+	fmt.Fprintf(w, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(files, "|"))
+	choices := flagChoices()
+	for _, name := range slices.Sorted(maps.Keys(choices)) {
+		fmt.Fprintf(w, "        -%s|--%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", name, name, strings.Join(choices[name], " "))
+	}
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `    case "$cmd" in`)
+	for _, c := range commands {
+		fmt.Fprintf(w, "        %s)\n", c.name)
+		if len(c.subs) > 0 {
+			fmt.Fprintf(w, "            if [[ $COMP_CWORD -eq 2 ]]; then COMPREPLY=($(compgen -W %q -- \"$cur\")); return; fi\n", strings.Join(c.subs, " "))
+			if c.flags != nil {
+				fmt.Fprintln(w, `            case "$sub" in`)
+				for _, sub := range c.subs {
+					if specs := flagSpecs(c.flags(sub)); len(specs) > 0 {
+						fmt.Fprintf(w, "                %s) flags=%q ;;\n", sub, dashed(specs))
+					}
+				}
+				fmt.Fprintln(w, `            esac`)
+			}
+		} else if c.flags != nil {
+			fmt.Fprintf(w, "            flags=%q\n", dashed(flagSpecs(c.flags(""))))
+		}
+		fmt.Fprintln(w, "            ;;")
+	}
+	fmt.Fprintln(w, `        *)`)
+	fmt.Fprintf(w, "            if [[ $COMP_CWORD -eq 1 && \"$cur\" != -* ]]; then COMPREPLY=($(compgen -W %q -- \"$cur\")); return; fi\n", strings.Join(commandNames(), " "))
+	fmt.Fprintf(w, "            flags=%q\n", dashed(flagSpecs(scanFlagSet())))
+	fmt.Fprintln(w, `            ;;`)
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `    if [[ "$cur" == -* ]]; then COMPREPLY=($(compgen -W "$flags" -- "$cur")); fi`)
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -o default -F %s %s\n", fn, prog)
+}
+
+func writeZshCompletion(w io.Writer, prog string) {
+	fn := "_" + shellIdent(prog)
+	arguments := func(indent string, specs []flagSpec, operands string) {
+		fmt.Fprintf(w, "%s_arguments", indent)
+		for _, s := range specs {
+			fmt.Fprintf(w, " \\\n%s  %s", indent, zshQuote(zshFlagSpec(s)))
+		}
+		fmt.Fprintf(w, " \\\n%s  %s\n", indent, zshQuote(operands))
+	}
+
+	fmt.Fprintf(w, "#compdef %s\n\n", prog)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprintln(w, "  local -a commands")
+	fmt.Fprintln(w, "  commands=(")
+	for _, c := range commands {
+		fmt.Fprintf(w, "    %s\n", zshQuote(c.name+":"+c.summary))
+	}
+	fmt.Fprintln(w, "  )")
+	fmt.Fprintln(w, "  case $words[2] in")
+	for _, c := range commands {
+		fmt.Fprintf(w, "    %s)\n", c.name)
+		if len(c.subs) > 0 {
+			fmt.Fprintf(w, "      if (( CURRENT == 3 )); then compadd -- %s; return; fi\n", strings.Join(c.subs, " "))
+			if c.flags != nil {
+				fmt.Fprintln(w, "      case $words[3] in")
+				for _, sub := range c.subs {
+					fmt.Fprintf(w, "        %s)\n", sub)
+					fmt.Fprintln(w, "          shift 2 words; (( CURRENT -= 2 ))")
+					arguments("          ", flagSpecs(c.flags(sub)), "*:file:_files")
+					fmt.Fprintln(w, "          ;;")
+				}
+				fmt.Fprintln(w, "      esac")
+			}
+		} else if c.flags != nil {
+			fmt.Fprintln(w, "      shift words; (( CURRENT-- ))")
+			arguments("      ", flagSpecs(c.flags("")), "*:file:_files")
+		}
+		fmt.Fprintln(w, "      ;;")
+	}
+	fmt.Fprintln(w, "    *)")
+	fmt.Fprintln(w, "      if (( CURRENT == 2 )) && [[ $PREFIX != -* ]]; then _describe command commands; return; fi")
+	arguments("      ", flagSpecs(scanFlagSet()), "*::")
+	fmt.Fprintln(w, "      ;;")
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "if [[ $funcstack[1] == %s ]]; then\n  %s \"$@\"\nelse\n  compdef %s %s\nfi\n", fn, fn, fn, prog)
+}
+
+// zshFlagSpec is the _arguments specification of a flag: -name[summary]:value:action
+func zshFlagSpec(s flagSpec) string {
+	summary := strings.NewReplacer("[", "(", "]", ")", ":", " ").Replace(s.summary)
+	spec := fmt.Sprintf("*-%s[%s]", s.name, summary)
+	if !s.value {
+		return spec
+	}
+	switch {
+	case dirFlags[s.name]:
+		return spec + ":folder:_files -/"
+	case fileFlags[s.name]:
+		return spec + ":file:_files"
+	case flagChoices()[s.name] != nil:
+		return spec + ":value:(" + strings.Join(flagChoices()[s.name], " ") + ")"
+	}
+	return spec + ":value: "
+}
+
+func writeFishCompletion(w io.Writer, prog string) {
+	names := strings.Join(commandNames(), " ")
+	complete := func(condition string, s flagSpec) {
+		line := fmt.Sprintf("complete -c %s -n %s -o %s", prog, fishQuote(condition), s.name)
+		if s.value {
+			switch {
+			case dirFlags[s.name]:
+				line += " -r -f -a '(__fish_complete_directories)'"
+			case fileFlags[s.name]:
+				line += " -r -F"
+			case flagChoices()[s.name] != nil:
+				line += " -r -f -a " + fishQuote(strings.Join(flagChoices()[s.name], " "))
+			default:
+				line += " -r -f"
+			}
+		}
+		fmt.Fprintln(w, line+" -d "+fishQuote(s.summary))
+	}
+
+	fmt.Fprintf(w, "# fish completion for %s\n", prog)
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -f -a %s -d %s\n", prog, c.name, fishQuote(c.summary))
+	}
+	for _, s := range flagSpecs(scanFlagSet()) {
+		complete("not __fish_seen_subcommand_from "+names, s)
+	}
+	for _, c := range commands {
+		if len(c.subs) > 0 {
+			subs := strings.Join(c.subs, " ")
+			fmt.Fprintf(w, "complete -c %s -n %s -f -a %s\n", prog,
+				fishQuote("__fish_seen_subcommand_from "+c.name+"; and not __fish_seen_subcommand_from "+subs), fishQuote(subs))
+			if c.flags == nil {
+				continue
+			}
+			for _, sub := range c.subs {
+				for _, s := range flagSpecs(c.flags(sub)) {
+					complete("__fish_seen_subcommand_from "+c.name+"; and __fish_seen_subcommand_from "+sub, s)
+				}
+			}
+		} else if c.flags != nil {
+			for _, s := range flagSpecs(c.flags("")) {
+				complete("__fish_seen_subcommand_from "+c.name, s)
+			}
+		}
+	}
+}
+
+func writePowerShellCompletion(w io.Writer, prog string) {
+	list := func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = "'" + strings.ReplaceAll(item, "'", "''") + "'"
+		}
+		return "@(" + strings.Join(quoted, ", ") + ")"
+	}
+	flagList := func(specs []flagSpec) string {
+		names := make([]string, len(specs))
+		for i, s := range specs {
+			names[i] = "-" + s.name
+		}
+		return list(names)
+	}
+
+	fmt.Fprintf(w, "# PowerShell completion for %s\n", prog)
+	fmt.Fprintf(w, "Register-ArgumentCompleter -Native -CommandName '%s', '%s.exe' -ScriptBlock {\n", prog, prog)
+	fmt.Fprintln(w, "    param($wordToComplete, $commandAst, $cursorPosition)")
+	fmt.Fprintln(w, "    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })")
+	fmt.Fprintln(w, "    if ($wordToComplete) { $words = $words[0..($words.Count - 2)] }")
+	fmt.Fprintln(w, "    $cmd = if ($words.Count -gt 1) { $words[1] } else { '' }")
+	fmt.Fprintln(w, "    $sub = if ($words.Count -gt 2) { $words[2] } else { '' }")
+	fmt.Fprintln(w, "    $prev = $words[-1]")
+	fmt.Fprintln(w, "    $candidates = switch -regex ($prev) {")
+	choices := flagChoices()
+	for _, name := range slices.Sorted(maps.Keys(choices)) {
+		fmt.Fprintf(w, "        '^--?%s$' { %s; break }\n", regexp.QuoteMeta(name), list(choices[name]))
+	}
+	fmt.Fprintln(w, "        default {")
+	fmt.Fprintln(w, "            switch ($cmd) {")
+	for _, c := range commands {
+		fmt.Fprintf(w, "                '%s' {\n", c.name)
+		if len(c.subs) > 0 {
+			fmt.Fprintf(w, "                    if ($words.Count -eq 2) { %s }\n", list(c.subs))
+			if c.flags != nil {
+				fmt.Fprintln(w, "                    else { switch ($sub) {")
+				for _, sub := range c.subs {
+					if specs := flagSpecs(c.flags(sub)); len(specs) > 0 {
+						fmt.Fprintf(w, "                        '%s' { %s }\n", sub, flagList(specs))
+					}
+				}
+				fmt.Fprintln(w, "                    } }")
+			}
+		} else if c.flags != nil {
+			fmt.Fprintf(w, "                    %s\n", flagList(flagSpecs(c.flags(""))))
+		}
+		fmt.Fprintln(w, "                }")
+	}
+	fmt.Fprintln(w, "                default {")
+	fmt.Fprintf(w, "                    if ($words.Count -eq 1 -and $wordToComplete -notlike '-*') { %s }\n", list(commandNames()))
+	fmt.Fprintf(w, "                    else { %s }\n", flagList(flagSpecs(scanFlagSet())))
+	fmt.Fprintln(w, "                }")
+	fmt.Fprintln(w, "            }")
+	fmt.Fprintln(w, "        }")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {")
+	fmt.Fprintln(w, "        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "}")
+}
+
+// shellIdent turns a program name into a function name (archive-finder -> archive_finder)
+func shellIdent(name string) string {
+	return regexp.MustCompile(`[^A-Za-z0-9_]`).ReplaceAllString(name, "_")
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
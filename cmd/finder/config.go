@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/i18n"
+)
+
+// configPathArg finds -config in the scan flags before they are parsed, so the settings it
+// points at are the ones every later load (language, limits, saved directory) reads
+func configPathArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// applySettings fills every scan flag not given on the command line from the saved settings.
+// The cleanup mode is left out: deleting stays a decision of each run.
+func applySettings(c *Config, s *config.AppConfig) {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if !set["dir"] && s.Directory != "" {
+		c.Directory = s.Directory
+	}
+	if !set["trash"] && s.TrashPath != "" {
+		c.TrashPath = s.TrashPath
+	}
+	if !set["quarantine"] && s.QuarantinePath != "" {
+		c.QuarantinePath = s.QuarantinePath
+	}
+	if !set["visual-rate-mb"] {
+		c.VisualRateMB = s.VisualRateMB
+	}
+	if !set["memory-mb"] {
+		c.MemoryMB = s.MemoryBudgetMB
+	}
+	if !set["trash-retention"] {
+		c.TrashDays = s.TrashRetentionDays
+	}
+	if !set["delete-if-trash-fails"] {
+		c.TrashOrDelete = s.DeleteIfTrashFails
+	}
+	if !set["verify-before-delete"] {
+		c.Recheck = s.VerifyBeforeDelete
+	}
+	if !set["threshold"] && s.ThresholdName > 0 {
+		c.ThresholdName = s.ThresholdName
+	}
+	if !set["threshold-visual"] {
+		c.ThresholdVisual = s.ThresholdVisual
+	}
+	if !set["min-content-overlap"] {
+		c.MinContentOverlap = s.MinContentOverlap
+	}
+	if !set["recursive"] {
+		c.Recursive = s.Recursive
+	}
+	if !set["ref"] {
+		c.LeaveRef = s.LeaveRef
+	}
+	if !set["phonetic"] {
+		c.Phonetic = s.Phonetic
+	}
+	if !set["scorers"] {
+		c.Scorers = s.Scorers
+	}
+	// Profiles given on the command line are laid over the saved ones
+	profiles := make(map[string]string, len(s.Profiles)+len(c.Profiles))
+	for ext, method := range s.Profiles {
+		profiles[ext] = method
+	}
+	for ext, method := range c.Profiles {
+		profiles[ext] = method
+	}
+	c.Profiles = profiles
+	if !set["verify"] {
+		c.Verify = s.Verify
+	}
+	if !set["verify-content"] {
+		c.VerifyContent = s.VerifyContent
+	}
+	if !set["contents"] {
+		c.Contents = s.Contents
+	}
+	if !set["network"] {
+		c.Network = s.NetworkShare
+	}
+	if !set["loose"] {
+		c.Loose = s.LooseFiles
+	}
+	if !set["confirm-above"] {
+		c.ConfirmAbove = time.Duration(s.ConfirmAbove) * time.Minute
+	}
+	if !set["port"] && s.Port > 0 {
+		c.Port = s.Port
+	}
+}
+
+// runConfigCommand handles the `finder config` subcommands
+func runConfigCommand(args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  finder config init [-config file] [-force]        Write the default settings")
+		fmt.Fprintln(os.Stderr, "  finder config show [-config file]                 Print the settings in effect")
+		fmt.Fprintln(os.Stderr, "  finder config set [-config file] <key> <value>    Change one setting (lists and maps as JSON)")
+		fmt.Fprintln(os.Stderr, "Settings: "+strings.Join(config.Keys(), ", "))
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	var path string
+	var force bool
+	fs := configFlags(args[0], &path, &force)
+	fs.Parse(args[1:])
+	if path != "" {
+		config.SetPath(path)
+	}
+
+	switch args[0] {
+	case "init":
+		if fs.NArg() != 0 {
+			usage()
+		}
+		if _, err := os.Stat(config.GetConfigPath()); err == nil && !force {
+			log.Fatal(i18n.T("❌ %s already exists (use -force to overwrite it)", config.GetConfigPath()))
+		}
+		// Overwritten whole: references and environment overrides of the old file do not apply
+		if err := os.Remove(config.GetConfigPath()); err != nil && !os.IsNotExist(err) {
+			log.Fatal(i18n.T("❌ Could not write the settings: %v", err))
+		}
+		if err := config.SaveConfig(config.Default()); err != nil {
+			log.Fatal(i18n.T("❌ Could not write the settings: %v", err))
+		}
+		log.Print(i18n.T("📝 Settings written to %s", config.GetConfigPath()))
+	case "show":
+		if fs.NArg() != 0 {
+			usage()
+		}
+		cfg := loadSettings()
+		cfg.AuthHash = ""
+		data, _ := json.MarshalIndent(cfg, "", "  ")
+		log.Print("📂 " + config.GetConfigPath())
+		fmt.Println(string(data))
+	case "set":
+		if fs.NArg() != 2 {
+			usage()
+		}
+		cfg := loadSettings()
+		if err := cfg.Set(fs.Arg(0), fs.Arg(1)); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if err := cfg.Validate(); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		if err := config.SaveConfig(cfg); err != nil {
+			log.Fatal(i18n.T("❌ Could not write the settings: %v", err))
+		}
+		log.Print(i18n.T("✅ %s set in %s", fs.Arg(0), config.GetConfigPath()))
+		if name := "ADF_" + strings.ToUpper(fs.Arg(0)); os.Getenv(name) != "" {
+			log.Print(i18n.T("⚠️  %s is set and overrides this setting", name))
+		}
+	default:
+		usage()
+	}
+}
+
+// loadSettings reads the settings, defaults included when there is no file yet
+func loadSettings() *config.AppConfig {
+	cfg, err := config.LoadConfig()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(i18n.T("❌ Could not read the settings: %v", err))
+	}
+	return cfg
+}
+
+// configSubcommands are the subcommands of `finder config`
+var configSubcommands = []string{"init", "show", "set"}
+
+// configFlags defines the flags of `finder config <sub>` on a new flag set
+func configFlags(sub string, path *string, force *bool) *flag.FlagSet {
+	fs := flag.NewFlagSet("config "+sub, flag.ExitOnError)
+	fs.StringVar(path, "config", "", "Settings file (default: archive-finder-settings.json in the user config folder)")
+	if sub == "init" {
+		fs.BoolVar(force, "force", false, "Overwrite existing settings")
+	}
+	return fs
+}
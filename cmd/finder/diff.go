@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/estimate"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/protect"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/similarity"
+	"archive-duplicate-finder/internal/vfs"
+	"archive-duplicate-finder/internal/visual"
+)
+
+// diffOptions are the flags of `finder diff`
+type diffOptions struct {
+	source       string
+	library      string
+	recursive    bool
+	threshold    int
+	phonetic     string
+	useVisual    bool
+	maxDistance  int
+	loose        bool
+	jsonFile     string
+	scriptFile   string
+	trashPath    string
+	protectFlags stringList
+	confirmAbove time.Duration
+}
+
+// runDiffCommand handles `finder diff`: which archives of a source folder already exist in a library
+func runDiffCommand(args []string) {
+	var o diffOptions
+	fs := diffFlags(&o)
+	fs.Parse(args)
+
+	if o.source == "" || o.library == "" || fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: finder diff -source <folder> -library <folder> [options]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	if o.threshold < 0 || o.threshold > 100 {
+		log.Fatal("❌ Threshold must be between 0 and 100")
+	}
+	if o.maxDistance < 0 || o.maxDistance > 64 {
+		log.Fatal("❌ Visual threshold must be between 0 and 64")
+	}
+	if !similarity.IsValidPhonetic(o.phonetic) {
+		log.Fatal(i18n.T("❌ Unknown phonetic algorithm %q", o.phonetic))
+	}
+
+	appConfig, _ := config.LoadConfig()
+	if appConfig == nil {
+		appConfig = config.Default()
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["confirm-above"] {
+		o.confirmAbove = time.Duration(appConfig.ConfirmAbove) * time.Minute
+	}
+	archive.SetLimits(appConfig.ArchiveLimits())
+	scanner.SetLooseFiles(o.loose || appConfig.LooseFiles)
+	useCache(appConfig.CacheDSN, false)
+	vfs.SetS3Config(appConfig.S3)
+	vfs.SetSFTPConfig(appConfig.SFTP)
+	vfs.SetWebDAVConfig(appConfig.WebDAV)
+	protected, err := protect.New(append(append([]string(nil), appConfig.Protected...), o.protectFlags...))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	cache, err := db.NewCache()
+	if err != nil {
+		log.Print(i18n.T("⚠️  Cache not available: %v", err))
+		cache = nil
+	} else {
+		defer cache.Close()
+	}
+
+	startTime := time.Now()
+	sourceFiles := scanForDiff(o.source, o.recursive, cache)
+	libraryFiles := scanForDiff(o.library, o.recursive, cache)
+	log.Print(i18n.T("✅ Found %d archives in the source and %d in the library", len(sourceFiles), len(libraryFiles)))
+
+	diff := reporter.DiffReport{
+		Source:       o.source,
+		Library:      o.library,
+		SourceFiles:  len(sourceFiles),
+		LibraryFiles: len(libraryFiles),
+		Matches:      []reporter.DiffMatch{},
+		Unique:       []reporter.FileInfo{},
+	}
+	matched := make(map[string]bool)
+	addMatch := func(src, lib scanner.ArchiveFile, reason string, score float64) {
+		info := reporter.NewFileInfo(src)
+		info.Protected = protected.Match(src.Path)
+		diff.Matches = append(diff.Matches, reporter.DiffMatch{Source: info, Library: reporter.NewFileInfo(lib), Reason: reason, Score: score})
+		matched[src.Path] = true
+		if reason == reporter.MatchIdentical {
+			diff.RedundantBytes += src.Size
+		}
+	}
+
+	// 1. Same size and content hash
+	log.Println(i18n.T("🔍 Comparing sizes and content hashes..."))
+	matchIdentical(sourceFiles, libraryFiles, cache, func(src, lib scanner.ArchiveFile) {
+		addMatch(src, lib, reporter.MatchIdentical, 100)
+	})
+
+	// 2. Name similarity
+	if o.threshold < 100 {
+		log.Print(i18n.T("🔍 Comparing names (threshold %d%%)...", o.threshold))
+		names := make([]string, len(libraryFiles))
+		for i, f := range libraryFiles {
+			names[i] = f.Name
+		}
+		index := similarity.NewNameIndex(names, similarity.Options{Threshold: o.threshold, Phonetic: o.phonetic})
+		for _, src := range sourceFiles {
+			if matched[src.Path] {
+				continue
+			}
+			if i, score := index.Closest(src.Name); i >= 0 {
+				addMatch(src, libraryFiles[i], reporter.MatchSimilarName, score)
+			}
+		}
+	}
+
+	// 3. Preview images
+	if o.useVisual {
+		if cache == nil {
+			log.Println(i18n.T("⚠️  Visual matching needs the cache, skipped"))
+		} else {
+			var pending []scanner.ArchiveFile
+			for _, src := range sourceFiles {
+				if !matched[src.Path] {
+					pending = append(pending, src)
+				}
+			}
+			if len(pending) > 0 && !confirmVisual(append(pending, libraryFiles...), cache, o.confirmAbove) {
+				log.Println(i18n.T("⏹️  Preview images not compared. Raise -confirm-above to compare them."))
+			} else if len(pending) > 0 {
+				log.Println(i18n.T("🖼️  Comparing preview images..."))
+				visual.ProcessVisualHashes(context.Background(), append(pending, libraryFiles...), cache, false, nil)
+				for _, src := range pending {
+					if lib, dist, ok := closestVisual(cache, src, libraryFiles, o.maxDistance); ok {
+						addMatch(src, lib, reporter.MatchVisual, (1-float64(dist)/64)*100)
+					}
+				}
+			}
+		}
+	}
+
+	for _, src := range sourceFiles {
+		if !matched[src.Path] {
+			diff.Unique = append(diff.Unique, reporter.NewFileInfo(src))
+		}
+	}
+	diff.AnalysisDuration = time.Since(startTime).Seconds()
+	diff.Timestamp = time.Now().Format(time.RFC3339)
+
+	fmt.Println()
+	reporter.PrintDiff(diff)
+
+	if o.jsonFile != "" {
+		if err := reporter.ExportDiffJSON(diff, o.jsonFile); err != nil {
+			log.Print(i18n.T("❌ Could not write JSON report: %v", err))
+		} else {
+			log.Print(i18n.T("💾 Diff report exported to %s", o.jsonFile))
+		}
+	}
+	if o.scriptFile != "" {
+		err := reporter.ExportDiffScript(diff, o.scriptFile, reporter.ScriptOptions{
+			Shell:     reporter.ScriptShell(o.scriptFile),
+			TrashPath: o.trashPath,
+		})
+		if err != nil {
+			log.Print(i18n.T("❌ Could not write cleanup script: %v", err))
+		} else {
+			log.Print(i18n.T("📜 Cleanup script written: %s (review it before running)", o.scriptFile))
+		}
+	}
+}
+
+// scanForDiff lists the archives of one side of the diff, with split archives collapsed
+func scanForDiff(dir string, recursive bool, cache *db.Cache) []scanner.ArchiveFile {
+	if _, err := os.Stat(dir); os.IsNotExist(err) && !vfs.IsRemote(dir) {
+		log.Fatal(i18n.T("❌ Directory does not exist: %s", dir))
+	}
+	log.Print(i18n.T("🔍 Scanning %s...", dir))
+	recoverStaged(dir, recursive)
+	if cache != nil {
+		cache.SetRoot(dir)
+	}
+	files, err := scanner.ScanDirectory(dir, recursive)
+	if err != nil {
+		log.Fatal(i18n.T("❌ Failed to scan directory: %v", err))
+	}
+	return scanner.CollapseVolumeSets(files)
+}
+
+// matchIdentical calls onMatch with every source archive and a library archive of the same
+// size and content hash. Sampled hashes are compared first, full ones only when the samples match.
+func matchIdentical(sourceFiles, libraryFiles []scanner.ArchiveFile, cache *db.Cache, onMatch func(src, lib scanner.ArchiveFile)) {
+	sampled := func(p string) (string, error) { return hashing.SampleHash(cache, p) }
+	full := func(p string) (string, error) { return hashing.FileHash(cache, p) }
+	librarySizes := scanner.GroupBySize(libraryFiles)
+	for _, src := range sourceFiles {
+		candidates := librarySizes[src.Size]
+		if len(candidates) == 0 {
+			continue
+		}
+		srcSample, ok := diffContentHash(src, sampled)
+		if !ok {
+			continue
+		}
+		for _, lib := range candidates {
+			if libSample, ok := diffContentHash(lib, sampled); !ok || libSample != srcSample {
+				continue
+			}
+			srcHash, ok := diffContentHash(src, full)
+			if !ok {
+				break
+			}
+			if libHash, ok := diffContentHash(lib, full); ok && libHash == srcHash {
+				onMatch(src, lib)
+				break
+			}
+		}
+	}
+}
+
+// diffContentHash hashes every volume of an archive; false when a part cannot be read
+func diffContentHash(f scanner.ArchiveFile, hash func(path string) (string, error)) (string, bool) {
+	var hashes []string
+	for _, p := range f.AllPaths() {
+		h, err := hash(p)
+		if err != nil {
+			return "", false
+		}
+		hashes = append(hashes, h)
+	}
+	return strings.Join(hashes, ","), true
+}
+
+// closestVisual returns the library archive whose preview hash is nearest to the source one,
+// if it is at most maxDistance bits away
+func closestVisual(cache *db.Cache, src scanner.ArchiveFile, library []scanner.ArchiveFile, maxDistance int) (scanner.ArchiveFile, int, bool) {
+	srcHash, ok := cache.GetVisualHash(src.Path, src.ModTime.Format(time.RFC3339))
+	if !ok {
+		return scanner.ArchiveFile{}, 0, false
+	}
+	var best scanner.ArchiveFile
+	bestDist := -1
+	for _, lib := range library {
+		libHash, ok := cache.GetVisualHash(lib.Path, lib.ModTime.Format(time.RFC3339))
+		if !ok {
+			continue
+		}
+		if dist := archive.CalculateHammingDistance(srcHash, libHash); dist <= maxDistance && (bestDist < 0 || dist < bestDist) {
+			best, bestDist = lib, dist
+		}
+	}
+	return best, bestDist, bestDist >= 0
+}
+
+// confirmVisual asks before fingerprinting the previews of files projected to take longer than
+// the limit
+func confirmVisual(files []scanner.ArchiveFile, cache *db.Cache, limit time.Duration) bool {
+	e := estimate.New(files, cache)
+	if limit <= 0 || e.Visual() <= limit {
+		return true
+	}
+	return confirmLong(i18n.T("⚠️  Comparing preview images is projected to take %s (limit: %v). Continue? (y/N): ", estimate.FormatDuration(e.Visual()), limit))
+}
+
+// diffFlags defines the flags of `finder diff` on a new flag set, storing their values in o
+func diffFlags(o *diffOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.StringVar(&o.source, "source", "", "Folder with the new archives (e.g. downloads)")
+	fs.StringVar(&o.library, "library", "", "Library the source is compared against")
+	fs.BoolVar(&o.recursive, "recursive", true, "Scan subdirectories recursively")
+	fs.IntVar(&o.threshold, "threshold", 70, "Name similarity percentage (0-100) that counts as already in the library (100 disables fuzzy names)")
+	fs.StringVar(&o.phonetic, "phonetic", "", "Phonetic name matching for typo'd names: 'soundex' or 'metaphone'")
+	fs.BoolVar(&o.useVisual, "visual", false, "Also compare preview images (slow: opens every archive)")
+	fs.DurationVar(&o.confirmAbove, "confirm-above", estimate.DefaultConfirmAbove, "Ask for confirmation when comparing preview images is projected to take longer than this (0 disables)")
+	fs.IntVar(&o.maxDistance, "threshold-visual", visual.HammingThreshold, "Largest Hamming distance (0-64) between previews that counts as already in the library, with -visual")
+	fs.BoolVar(&o.loose, "loose", false, "Loose-file mode: also compare images and any other file, not only archives")
+	fs.StringVar(&o.jsonFile, "json", "", "Output JSON file path")
+	fs.StringVar(&o.scriptFile, "script", "", "Write a script that removes the source archives already in the library (.sh or .ps1)")
+	fs.StringVar(&o.trashPath, "trash", "", "Make the script move archives to this folder instead of deleting them")
+	fs.Var(&o.protectFlags, "protect", "Never remove source files matching this glob, or anything inside a matching folder (repeatable)")
+	return fs
+}
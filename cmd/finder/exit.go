@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log"
+	"os"
+	"sync/atomic"
+
+	"archive-duplicate-finder/internal/reporter"
+)
+
+// Exit codes of a scan, for scripts and scheduled jobs (subcommands exit 1 on any error)
+const (
+	exitClean         = 0 // No duplicates found
+	exitDuplicates    = 1 // Duplicate groups found
+	exitErrors        = 2 // The scan failed, or a cleanup action or report could not be completed
+	exitInvalidConfig = 3 // Invalid flags or settings
+)
+
+// failures counts the errors the run carried on after (see noteFailure)
+var failures atomic.Int64
+
+// flushOutput writes out what is left of stdout before exiting (see usePlainOutput)
+var flushOutput = func() {}
+
+// noteFailure records an error the run carried on after, so that it exits with exitErrors
+func noteFailure() {
+	failures.Add(1)
+}
+
+// fatal logs message and exits with code
+func fatal(code int, message string) {
+	log.Print(message)
+	exit(code)
+}
+
+func exit(code int) {
+	flushOutput()
+	os.Exit(code)
+}
+
+// resultCode is the exit code of a finished scan: errors first, then whether the report holds
+// duplicates (probable series of similar names do not count)
+func resultCode(report reporter.Report) int {
+	if failures.Load() > 0 {
+		return exitErrors
+	}
+	if len(report.SizeGroups) > 0 || len(report.VisualGroups) > 0 {
+		return exitDuplicates
+	}
+	for _, g := range report.SimilarGroups {
+		if !g.Series {
+			return exitDuplicates
+		}
+	}
+	return exitClean
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/vfs"
+)
+
+// extractOptions are the flags of `finder extract`
+type extractOptions struct {
+	dest string
+}
+
+// runExtractCommand handles `finder extract`: pull some entries out of an archive, e.g. the files
+// worth keeping from a copy that is about to be deleted
+func runExtractCommand(args []string) {
+	var o extractOptions
+	fs := extractFlags(&o)
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "Usage: finder extract [-dest <folder>] <archive> <entry or folder>...")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	if vfs.IsRemote(o.dest) {
+		log.Fatal("❌ -dest must be a local folder")
+	}
+
+	appConfig, _ := config.LoadConfig()
+	if appConfig == nil {
+		appConfig = config.Default()
+	}
+	archive.SetLimits(appConfig.ArchiveLimits())
+	vfs.SetS3Config(appConfig.S3)
+	vfs.SetSFTPConfig(appConfig.SFTP)
+	vfs.SetWebDAVConfig(appConfig.WebDAV)
+
+	archivePath := fs.Arg(0)
+	results, err := archive.ExtractEntries(archivePath, fs.Args()[1:], o.dest)
+	if err != nil && results == nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+			fmt.Printf("  ❌ %s: %s\n", r.InternalPath, r.Error)
+		} else {
+			fmt.Printf("  📄 %s -> %s (%s)\n", r.InternalPath, r.Dest, formatBytes(r.Size))
+		}
+	}
+	if err != nil {
+		log.Print(i18n.T("⚠️  Extraction stopped: %v", err))
+	}
+	log.Print(i18n.T("📤 Extracted %d of %d entries from %s", len(results)-failed, len(results), archivePath))
+	if failed > 0 || err != nil {
+		os.Exit(1)
+	}
+}
+
+// extractFlags defines the flags of `finder extract` on a new flag set, storing their values in o
+func extractFlags(o *extractOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	fs.StringVar(&o.dest, "dest", ".", "Folder the entries are written to (folders inside the archive are kept)")
+	return fs
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/shellmenu"
+	"archive-duplicate-finder/internal/vfs"
+	"archive-duplicate-finder/pkg/client"
+)
+
+// hereSubcommands are the subcommands of `finder here`, besides the folder to scan
+var hereSubcommands = []string{"install", "uninstall"}
+
+// hereOptions are the flags of `finder here`
+type hereOptions struct {
+	port  int
+	token string
+}
+
+// hereFlags defines the flags of `finder here` (or `finder here <sub>`) on a new flag set
+func hereFlags(sub string, o *hereOptions) *flag.FlagSet {
+	name := "here"
+	if sub == "install" || sub == "uninstall" {
+		name += " " + sub
+	}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	if sub == "uninstall" {
+		return fs
+	}
+	fs.String("config", "", "Settings file (default: $ADF_CONFIG, then archive-finder-settings.json in the user config folder)")
+	fs.IntVar(&o.port, "port", 0, "Port of the dashboard (default: the saved port, 8080)")
+	fs.StringVar(&o.token, "token", "", "Access token of a running dashboard that asks for one")
+	return fs
+}
+
+// runHereCommand handles `finder here`: scan one folder with the saved settings and open the
+// dashboard on it, as the context menu of the file manager does (see `finder here install`).
+// A dashboard already running scans it; otherwise one is started and serves until stopped.
+func runHereCommand(args []string) {
+	if len(args) > 0 && (args[0] == "install" || args[0] == "uninstall") {
+		runHereInstall(args[0], args[1:])
+		return
+	}
+	var o hereOptions
+	fs := hereFlags("", &o)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  finder here [-port n] [-token <token>] <folder>   Scan a folder and open the dashboard on it")
+		fmt.Fprintln(os.Stderr, "  finder here install [-port n] [-token <token>]   Add the entry to the context menu of folders")
+		fmt.Fprintln(os.Stderr, "  finder here uninstall                            Remove it")
+		exit(exitInvalidConfig)
+	}
+	dir := fs.Arg(0)
+	if !vfs.IsRemote(dir) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			fatal(exitInvalidConfig, fmt.Sprintf("❌ %v", err))
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			fatal(exitInvalidConfig, i18n.T("❌ Directory does not exist: %s", dir))
+		}
+		dir = abs
+	}
+
+	port := o.port
+	if port == 0 {
+		if appConfig, _ := config.LoadConfig(); appConfig != nil {
+			port = appConfig.Port
+		}
+	}
+	if port == 0 {
+		port = config.Default().Port
+	}
+	page := fmt.Sprintf("http://localhost:%d/?dir=%s", port, url.QueryEscape(dir))
+
+	// A dashboard already running (the tray, a service) takes the scan
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.New(fmt.Sprintf("http://localhost:%d", port), o.token).ScanFolder(ctx, dir)
+	var apiErr *client.Error
+	switch {
+	case err == nil:
+		log.Print(i18n.T("🔍 Scanning %s in the running dashboard", dir))
+		openBrowser(page)
+		return
+	case errors.As(err, &apiErr) && apiErr.Status == 401:
+		fatal(exitInvalidConfig, i18n.T("❌ The dashboard asks for its access token: add -token <token>"))
+	case errors.As(err, &apiErr):
+		fatal(exitErrors, fmt.Sprintf("❌ %s", apiErr.Message))
+	}
+
+	srv, cache, listenErr := startServer(serveOptions{headless: true, port: port})
+	if cache != nil {
+		defer cache.Close()
+	}
+	if err := srv.ScanFolder(dir); err != nil {
+		fatal(exitInvalidConfig, fmt.Sprintf("❌ %v", err))
+	}
+	log.Print(i18n.T("📂 Scanning directory: %s", dir))
+	go func() {
+		time.Sleep(1 * time.Second) // Give server a moment to bind
+		log.Print(i18n.T("🌍 Opening dashboard at %s ...", page))
+		openBrowser(page)
+	}()
+	waitServer(cache, listenErr)
+}
+
+// runHereInstall handles `finder here install` and `finder here uninstall`: add or remove the
+// context menu entry running `finder here` on a folder, with the -port and -token given
+func runHereInstall(sub string, args []string) {
+	var o hereOptions
+	fs := hereFlags(sub, &o)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, "Usage: finder here %s [flags]\n", sub)
+		fs.PrintDefaults()
+		exit(exitInvalidConfig)
+	}
+
+	var files []string
+	var err error
+	if sub == "uninstall" {
+		files, err = shellmenu.Uninstall()
+	} else {
+		exe, exeErr := os.Executable()
+		if exeErr != nil {
+			fatal(exitErrors, fmt.Sprintf("❌ %v", exeErr))
+		}
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		command := []string{exe, "here"}
+		if o.port > 0 {
+			command = append(command, "-port", strconv.Itoa(o.port))
+		}
+		if o.token != "" {
+			command = append(command, "-token", o.token)
+		}
+		files, err = shellmenu.Install(command)
+	}
+	for _, f := range files {
+		fmt.Printf("  📄 %s\n", f)
+	}
+	if err != nil {
+		fatal(exitErrors, fmt.Sprintf("❌ %v", err))
+	}
+	if sub == "uninstall" {
+		fmt.Println(i18n.T("✅ Context menu entry removed"))
+	} else {
+		fmt.Print(i18n.T("✅ \"%s\" added to the context menu of folders\n", shellmenu.Label))
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/importer"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/protect"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/vfs"
+	"archive-duplicate-finder/internal/web"
+)
+
+// importOptions are the flags of `finder import`
+type importOptions struct {
+	format       string
+	jsonFile     string
+	web          bool
+	port         int
+	protectFlags stringList
+}
+
+// importFlags defines the flags of `finder import` on a new flag set, storing their values in o
+func importFlags(o *importOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	fs.StringVar(&o.format, "format", "", "Tool that wrote the results: "+strings.Join(importer.Formats, ", "))
+	fs.StringVar(&o.jsonFile, "json", "", "Write the imported groups as a JSON report (for finder review -report)")
+	fs.BoolVar(&o.web, "web", false, "Open the imported groups in the dashboard to review and clean them up")
+	fs.IntVar(&o.port, "port", 0, "Port of the dashboard (default: the saved port, 8080)")
+	fs.Var(&o.protectFlags, "protect", "Never suggest files matching this glob or folder for deletion (repeatable)")
+	return fs
+}
+
+// runImportCommand handles `finder import`: duplicates found by rdfind, fdupes or czkawka become
+// a report of identical groups, reviewed and cleaned up like those of a scan
+func runImportCommand(args []string) {
+	var o importOptions
+	fs := importFlags(&o)
+	fs.Parse(args)
+	if o.format == "" || fs.NArg() != 1 || (o.jsonFile == "" && !o.web) {
+		fmt.Fprintln(os.Stderr, "Usage: finder import -format rdfind|fdupes|czkawka [-json report.json] [-web] <results file>")
+		fs.PrintDefaults()
+		exit(exitInvalidConfig)
+	}
+
+	appConfig, _ := config.LoadConfig()
+	if appConfig == nil {
+		appConfig = config.Default()
+	}
+	useCache(appConfig.CacheDSN, false)
+	vfs.SetS3Config(appConfig.S3)
+	vfs.SetSFTPConfig(appConfig.SFTP)
+	vfs.SetWebDAVConfig(appConfig.WebDAV)
+	protected, err := protect.New(append(append([]string(nil), appConfig.Protected...), o.protectFlags...))
+	if err != nil {
+		fatal(exitInvalidConfig, fmt.Sprintf("❌ %v", err))
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fatal(exitErrors, i18n.T("❌ Could not open %s: %v", fs.Arg(0), err))
+	}
+	groups, err := importer.Parse(o.format, f)
+	f.Close()
+	if err != nil {
+		fatal(exitInvalidConfig, i18n.T("❌ Could not import %s: %v", fs.Arg(0), err))
+	}
+
+	report, missing := importer.Report(groups)
+	for _, p := range missing {
+		log.Print(i18n.T("⚠️  Listed but not found: %s", p))
+	}
+	report = reporter.WithProtected(report, protected.Match)
+
+	cache, err := db.NewCache()
+	if err != nil {
+		log.Print(i18n.T("⚠️  Cache not available: %v", err))
+		cache = nil
+	} else {
+		defer cache.Close()
+		report = reporter.WithGroupIDs(report, cache.GroupIDs)
+	}
+
+	var reclaimable int64
+	for _, g := range report.SizeGroups {
+		reclaimable += g.Size * int64(len(g.Files)-1)
+	}
+	log.Print(i18n.T("📥 Imported %d groups of identical files (%d files, %s reclaimable) from %s", len(report.SizeGroups), report.TotalFiles, formatBytes(reclaimable), fs.Arg(0)))
+
+	if o.jsonFile != "" {
+		if err := reporter.ExportJSON(report, o.jsonFile); err != nil {
+			fatal(exitErrors, i18n.T("❌ Could not write JSON report: %v", err))
+		}
+		log.Print(i18n.T("💾 JSON report written: %s", o.jsonFile))
+	}
+	if !o.web {
+		return
+	}
+
+	// The dashboard shows the imported groups until a scan replaces them
+	port := o.port
+	if port == 0 {
+		port = appConfig.Port
+	}
+	if port == 0 {
+		port = config.Default().Port
+	}
+	journal.SetOperationsLog(cache, appConfig.OperationsLog)
+	hooks.Set(appConfig.Hooks)
+	var files []reporter.FileInfo
+	for _, g := range report.SizeGroups {
+		files = append(files, g.Files...)
+	}
+	srv := web.NewServer(port, &report, appConfig.TrashPath, appConfig.LeaveRef, nil, nil, files, cache, appConfig.Directory, appConfig)
+	srv.SetExtraProtection(o.protectFlags)
+	srv.SetQuarantine(appConfig.QuarantinePath)
+	listenErr := make(chan error, 1)
+	go func() { listenErr <- srv.Start() }()
+	url := fmt.Sprintf("http://localhost:%d", port)
+	log.Print(i18n.T("🌍 Opening dashboard at %s ...", url))
+	openBrowser(url)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-listenErr:
+		log.Print(i18n.T("❌ Web server error: %v", err))
+		if cache != nil {
+			cache.Close()
+		}
+		exit(exitErrors)
+	case <-ctx.Done():
+		log.Print(i18n.T("👋 Shutting down"))
+	}
+}
@@ -6,56 +6,300 @@ package main
  */
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"archive-duplicate-finder/internal/archive"
 	"archive-duplicate-finder/internal/config"
 	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/estimate"
+	"archive-duplicate-finder/internal/events"
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/iotune"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/membudget"
+	"archive-duplicate-finder/internal/notify"
+	"archive-duplicate-finder/internal/organize"
+	"archive-duplicate-finder/internal/paths"
+	"archive-duplicate-finder/internal/profile"
+	"archive-duplicate-finder/internal/progress"
+	"archive-duplicate-finder/internal/protect"
+	"archive-duplicate-finder/internal/quarantine"
+	"archive-duplicate-finder/internal/refnote"
 	"archive-duplicate-finder/internal/reporter"
 	"archive-duplicate-finder/internal/scanner"
 	"archive-duplicate-finder/internal/similarity"
 	"archive-duplicate-finder/internal/stl"
+	"archive-duplicate-finder/internal/trash"
+	"archive-duplicate-finder/internal/txn"
+	"archive-duplicate-finder/internal/vfs"
 	"archive-duplicate-finder/internal/visual"
 	"archive-duplicate-finder/internal/web"
 )
 
 type Config struct {
-	Directory   string
-	Threshold   int
-	Mode        string
-	Verbose     bool
-	Recursive   bool
-	OutputFile  string
-	PDFFile     string
-	DeleteMode  string // "oldest" or "contents"
-	AutoDelete  bool
-	Interactive bool
-	TrashPath   string // Folder to move duplicates to
-	LeaveRef    bool   // Leave a .txt link to the original
-	Web         bool   // Start web dashboard
-	Port        int    // Web server port
-	Debug       bool   // Enable detailed debug logging
-	RunStep3    bool   // Explicitly run Step 3 (Similarity Check)
-	Version     bool   // Show version and exit
-	Info        bool   // Show author and info and exit
+	Directory         string
+	ThresholdName     int // Name similarity percentage of Step 3 and the same-size name pass
+	ThresholdVisual   int // Largest Hamming distance between similar previews and covers
+	MinContentOverlap int // Share of entries archives of the manifest profile have in common
+	Mode              string
+	Verbose           bool
+	Recursive         bool
+	OutputFile        string
+	PDFFile           string
+	Thumbnails        bool   // Embed preview thumbnails in the JSON and PDF exports
+	ScriptFile        string // Cleanup plan as a shell (.sh) or PowerShell (.ps1) script
+	ExcludesFile      string // Files to remove as an rsync exclude or rclone filter list
+	DeleteMode        string // "oldest" or "contents"
+	AutoDelete        bool
+	Interactive       bool
+	TrashPath         string // Folder to move duplicates to
+	TrashDays         int    // Empty trash day folders older than this many days (0 keeps them)
+	TrashOrDelete     bool   // Permanently delete files that cannot be moved to the trash
+	Recheck           string // Check files again right before removing them: "stat" or "hash" (see hashing.Recheck)
+	QuarantinePath    string // Quarantine removed files there instead (see the quarantine package)
+	OpsLog            string // Extra JSONL copy of the operations log
+	LeaveRef          bool   // Leave a .txt link to the original
+	RefFormat         string // Reference note format: "text" or "json"
+	RefTemplate       string // Template of text reference notes, from the settings
+	Web               bool   // Start web dashboard
+	Port              int    // Web server port
+	Debug             bool   // Enable detailed debug logging
+	RunStep3          bool   // Explicitly run Step 3 (Similarity Check)
+	Version           bool   // Show version and exit
+	Info              bool   // Show author and info and exit
+	Phonetic          string // Phonetic name matching: "", "soundex" or "metaphone"
+	MaxCluster        int    // Similar-name clusters with more files are split at stricter thresholds (0 = default)
+	ScorerSpec        string // Similarity scorers and weights, e.g. "name=0.7,token=0.3"
+	Scorers           map[string]float64
+	ProfileSpec       string            // Step 2 methods per extension, e.g. ".zip=manifest,.cbz=cover"
+	Profiles          map[string]string // Parsed ProfileSpec, over the saved profiles
+	Sweep             string            // Threshold sweep "start:end:step"
+	SweepValues       []int
+	Verify            bool          // Check archive integrity and report corrupt files separately
+	VerifyContent     bool          // Step 2 confirms same-size archives by content and reports identical copies whatever their names
+	Contents          bool          // Read every archive's directory for its entry count and uncompressed size
+	ConfirmAbove      time.Duration // Ask before analyses projected to take longer (0 disables)
+	Limits            archive.Limits
+	MaxUncompressedMB int64 // -max-uncompressed-mb and -max-entry-mb, turned into Limits
+	MaxEntryMB        int64
+	VisualRateMB      int64          // Previews read per second by the visual analysis, in MB (0 = unlimited)
+	MemoryMB          int64          // Memory budget of extraction buffers, previews and models, in MB (0 = unlimited)
+	Digest            bool           // Print a per-directory summary instead of per-group detail
+	GroupBy           string         // "dir": roll the duplicates up per directory (see reporter.GroupByDirs)
+	Network           bool           // Optimize the scan for SMB/NFS mounts (batched listings, parallel stats, cached folders)
+	Loose             bool           // Loose-file mode: scan images and any other file too (see scanner.SetLooseFiles)
+	NoCache           bool           // Keep the cache in memory and previews off disk for this run
+	VerifySample      float64        // Percentage of automatically resolved groups whose kept file is re-verified after cleanup
+	CleanupRun        string         // Journal run of this invocation's cleanup actions
+	Protect           stringList     // -protect patterns, added to the configured ones
+	Protected         *protect.Rules // Files and folders never deleted
+	OrganizeDir       string         // Canonical library the kept files of resolved groups are moved into
+	Layout            string         // Path template of kept files under OrganizeDir
+	Link              bool           // Replace removed copies with links to the kept file
+	Rename            string         // Canonical names for variants: "suggest" (dry run) or "apply"
+	Language          string         // Language of the messages and reports (see the i18n package)
+	Plain             bool           // No emoji, box drawing or progress bars (automatic when stdout is redirected)
+	Output            string         // "text", or "ndjson" to stream events to stdout
+	Events            *events.Stream // NDJSON event stream (nil unless -output ndjson)
+	ConfigFile        string         // Settings file given with -config
+	UIDir             string         // Dashboard files to serve instead of the embedded ones
+}
+
+// Output formats of the scan
+const (
+	outputText   = "text"
+	outputNDJSON = "ndjson"
+)
+
+// stringList collects a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
 }
 
 func main() {
+	// -config points every load and save of the settings at another file
+	if path := configPathArg(os.Args[1:]); path != "" {
+		config.SetPath(path)
+	}
+
+	// Files of earlier versions move to the per-user folders before anything reads them
+	moves, migrateErr := paths.Migrate()
+
+	// Subcommands take the language from the settings or the locale; the scan also has -lang
+	selectLanguage("")
+	for _, m := range moves {
+		log.Print(i18n.T("📦 Moved %s to %s", m.From, m.To))
+	}
+	if migrateErr != nil {
+		log.Print(i18n.T("⚠️  Could not move the files of an earlier version (still using them): %v", migrateErr))
+	}
+
+	// Subcommands are dispatched before the scan flags are parsed. Those printing plain
+	// lines (and the server's log) switch to plain output when redirected; the review needs a
+	// terminal anyway.
+	if len(os.Args) > 1 && slices.Contains([]string{"cache", "diff", "extract", "here", "import", "manifest", "prune", "purge", "quarantine", "serve", "tray"}, os.Args[1]) && wantsPlainOutput(false, false) {
+		usePlainOutput()
+		defer flushOutput()
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		log.SetFlags(0)
+		runCacheCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		log.SetFlags(log.Ldate | log.Ltime)
+		runDiffCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "extract" {
+		log.SetFlags(0)
+		runExtractCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "here" {
+		log.SetFlags(log.Ldate | log.Ltime)
+		runHereCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		log.SetFlags(0)
+		runImportCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "manifest" {
+		log.SetFlags(0)
+		runManifestCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		log.SetFlags(0)
+		runMCPCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "review" {
+		log.SetFlags(0)
+		runReviewCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		log.SetFlags(log.Ldate | log.Ltime)
+		runServeCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "tray" {
+		log.SetFlags(log.Ldate | log.Ltime)
+		runTrayCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "quarantine" {
+		log.SetFlags(0)
+		runQuarantineCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		log.SetFlags(0)
+		runPruneCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "purge" {
+		log.SetFlags(0)
+		runPurgeCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		log.SetFlags(0)
+		runConfigCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "completion" {
+		runCompletionCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "man" {
+		runManCommand(os.Args[2:])
+		return
+	}
+
 	// 1. Load Persistent Config
-	appConfig, _ := config.LoadConfig()
+	appConfig, err := config.LoadConfig()
 
 	// 2. Parse command line flags (can override appConfig)
 	flagConfig := parseFlags()
+	if appConfig == nil || (err != nil && flagConfig.ConfigFile != "") {
+		fatal(exitInvalidConfig, i18n.T("❌ Could not read the settings: %v", err))
+	}
+
+	// 3. Saved settings fill in the flags not given
+	applySettings(&flagConfig, appConfig)
 
 	// Configure logger with timestamps
 	log.SetFlags(log.Ldate | log.Ltime)
+	if flagConfig.Language != "" {
+		selectLanguage(flagConfig.Language)
+	}
+
+	// Archive limits: explicit flags win, then the saved configuration
+	limitFlags := false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "workers", "timeout", "max-uncompressed-mb", "max-entry-mb", "max-ratio":
+			limitFlags = true
+		}
+	})
+	limits := appConfig.ArchiveLimits()
+	if limitFlags {
+		limits = flagConfig.Limits
+		if limits.Workers == 0 {
+			limits.Workers = appConfig.Workers
+		}
+	}
+	archive.SetLimits(limits)
+	archive.SetPreviewCommands(appConfig.PreviewConverters)
+	visual.SetReadRate(flagConfig.VisualRateMB << 20)
+	membudget.SetBudget(flagConfig.MemoryMB << 20)
+	useCache(appConfig.CacheDSN, flagConfig.NoCache)
+	vfs.SetS3Config(appConfig.S3)
+	vfs.SetSFTPConfig(appConfig.SFTP)
+	vfs.SetWebDAVConfig(appConfig.WebDAV)
+
+	protected, err := protect.New(append(append([]string(nil), appConfig.Protected...), flagConfig.Protect...))
+	if err != nil {
+		fatal(exitInvalidConfig, "❌ "+err.Error())
+	}
+	flagConfig.Protected = protected
+
+	// The note template only lives in the settings; -ref-format overrides the saved format
+	flagConfig.RefTemplate = appConfig.RefTemplate
+	refFormatFlag := false
+	flag.Visit(func(f *flag.Flag) { refFormatFlag = refFormatFlag || f.Name == "ref-format" })
+	if !refFormatFlag && appConfig.RefFormat != "" {
+		flagConfig.RefFormat = appConfig.RefFormat
+	}
+	if flagConfig.LeaveRef {
+		if err := refnote.Validate(flagConfig.RefFormat, flagConfig.RefTemplate); err != nil {
+			fatal(exitInvalidConfig, "❌ "+err.Error())
+		}
+	}
 
 	// Determine if we should run a CLI scan immediately
 	isExplicitScan := false
@@ -69,60 +313,188 @@ func main() {
 
 	// If no flags at all and no saved directory, we MUST start in web setup mode
 	if visitCount == 0 && appConfig.Directory == "" {
-		log.Println("🌐 No configuration found. Starting web setup mode...")
+		log.Println(i18n.T("🌐 No configuration found. Starting the web setup wizard..."))
 		startWebServer(flagConfig, nil, nil, nil, appConfig, nil, nil)
 		// Block indefinitely
 		select {}
 	}
 
-	// If no flags but we HAVE a saved config, load it into flagConfig and start web
+	// If no flags but we HAVE a saved config (already applied above), start web on it
 	if visitCount == 0 && appConfig.Directory != "" {
-		log.Printf("📂 Loading saved configuration: %s", appConfig.Directory)
-		flagConfig.Directory = appConfig.Directory
-		flagConfig.TrashPath = appConfig.TrashPath
-		flagConfig.Threshold = appConfig.Threshold
-		flagConfig.Recursive = appConfig.Recursive
-		flagConfig.LeaveRef = appConfig.LeaveRef
+		log.Print(i18n.T("📂 Loading saved configuration: %s", appConfig.Directory))
 		flagConfig.Web = true // Default to web if launched without args
 	}
 
-	// Validate directory
-	if _, err := os.Stat(flagConfig.Directory); os.IsNotExist(err) {
+	// Validate directory (remote stores are checked when they are listed)
+	if _, err := os.Stat(flagConfig.Directory); os.IsNotExist(err) && !vfs.IsRemote(flagConfig.Directory) {
 		if isExplicitScan {
-			log.Fatalf("❌ Directory does not exist: %s", flagConfig.Directory)
+			fatal(exitInvalidConfig, i18n.T("❌ Directory does not exist: %s", flagConfig.Directory))
 		} else {
-			log.Printf("⚠️ Saved directory no longer exists: %s. Starting web setup...", flagConfig.Directory)
+			log.Print(i18n.T("⚠️ Saved directory no longer exists: %s. Starting web setup...", flagConfig.Directory))
 			startWebServer(flagConfig, nil, nil, nil, appConfig, nil, nil)
 			// Block indefinitely
 			select {}
 		}
 	}
 
+	// Digest and NDJSON modes keep stdout for their output alone so it can be piped; progress
+	// goes to stderr
+	resultOut := os.Stdout
+	if flagConfig.Digest || flagConfig.Output == outputNDJSON {
+		os.Stdout = os.Stderr
+	}
+	if flagConfig.Output == outputNDJSON {
+		flagConfig.Events = events.NewStream(resultOut)
+		journal.SetObserver(flagConfig.Events.Action)
+	}
+	plainFlag := false
+	flag.Visit(func(f *flag.Flag) { plainFlag = plainFlag || f.Name == "plain" })
+	if wantsPlainOutput(plainFlag, flagConfig.Plain) {
+		usePlainOutput()
+		defer flushOutput()
+	}
+
 	log.Printf("🔍 Archive Duplicate Finder")
 	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	log.Printf("📂 Scanning directory: %s", flagConfig.Directory)
-	log.Printf("🎯 Similarity threshold: %d%%", flagConfig.Threshold)
-	log.Printf("🔧 Mode: %s", flagConfig.Mode)
+	log.Print(i18n.T("📂 Scanning directory: %s", flagConfig.Directory))
+	log.Print(i18n.T("🎯 Similarity threshold: %d%%", flagConfig.ThresholdName))
+	log.Print(i18n.T("🔧 Mode: %s", flagConfig.Mode))
+	flagConfig.Events.Started(flagConfig.Directory)
+	if flagConfig.Phonetic != "" {
+		log.Print(i18n.T("🗣️  Phonetic matching: %s", flagConfig.Phonetic))
+	}
 	if flagConfig.Debug {
-		log.Printf("🐛 DEBUG MODE: Enabled (Detailed Tracing)")
+		log.Print(i18n.T("🐛 DEBUG MODE: Enabled (Detailed Tracing)"))
+	}
+	if limits.Workers == 0 {
+		// No worker count anywhere: size the archive pool by a quick read benchmark of the disk
+		iotune.Tune(flagConfig.Directory)
+		archive.SetLimits(limits)
 	}
 	if flagConfig.DeleteMode != "" {
-		log.Printf("🗑️  Cleanup Mode: %s (Auto: %v)", flagConfig.DeleteMode, flagConfig.AutoDelete)
+		log.Print(i18n.T("🗑️  Cleanup Mode: %s (Auto: %v)", flagConfig.DeleteMode, flagConfig.AutoDelete))
+		flagConfig.CleanupRun = journal.NewRun()
+	}
+	if flagConfig.OrganizeDir != "" {
+		log.Print(i18n.T("🗂️  Organizing kept files into: %s (%s)", flagConfig.OrganizeDir, flagConfig.Layout))
+		if flagConfig.CleanupRun == "" {
+			flagConfig.CleanupRun = journal.NewRun()
+		}
+	}
+	if flagConfig.CleanupRun != "" && flagConfig.TrashPath != "" && flagConfig.QuarantinePath == "" {
+		emptyTrash(flagConfig.TrashPath, flagConfig.TrashDays)
 	}
+	recoverStaged(flagConfig.Directory, flagConfig.Recursive)
 	fmt.Printf("\n")
 
 	startTime := time.Now()
 
-	// Step 1: Scan for archive files
-	log.Println("📦 Step 1: Scanning for archive files...")
-	files, err := scanner.ScanDirectory(flagConfig.Directory, flagConfig.Recursive)
+	// Initialize Cache
+	cache, err := db.NewCache()
 	if err != nil {
-		log.Fatalf("❌ Failed to scan directory: %v", err)
+		log.Print(i18n.T("⚠️  Could not initialize cache: %v", err))
+	} else {
+		defer cache.Close()
+		cache.SetRoot(flagConfig.Directory)
+		// Copies of one archive share the extraction of their previews
+		archive.SetContentKey(func(path string) string { return hashing.CachedFileHash(cache, path) })
+		if flagConfig.NoCache {
+			log.Print(i18n.T("🧠 In-memory cache: nothing is written to disk for this run"))
+		}
+	}
+	if flagConfig.OpsLog == "" {
+		flagConfig.OpsLog = appConfig.OperationsLog
+	}
+	journal.SetOperationsLog(cache, flagConfig.OpsLog)
+	hooks.Set(appConfig.Hooks)
+
+	// Step 1: Scan for archive files (progress is measured against the size found by the previous scan)
+	log.Println(i18n.T("📦 Step 1: Scanning for archive files..."))
+	var scanPhase reporter.PhaseProgress
+	scanTracker := progress.New(estimate.PreviousScanBytes(cache, flagConfig.Directory), func(p reporter.PhaseProgress) {
+		scanPhase = p
+	})
+	lastPrint := time.Time{}
+	printScan := func(n int, bytes int64) {
+		line := "\r" + i18n.T("📂 Scanning: %d archives (%s)", n, formatBytes(bytes))
+		if scanPhase.TotalBytes > 0 {
+			line += " " + progress.Bar(scanPhase)
+		}
+		fmt.Print(line + "   ")
+	}
+	var scannedBytes int64
+	onScanned := func(n int, bytes int64) {
+		scannedBytes = bytes
+		scanTracker.Set(bytes)
+		if time.Since(lastPrint) > 100*time.Millisecond {
+			lastPrint = time.Now()
+			printScan(n, bytes)
+		}
+	}
+	var files []scanner.ArchiveFile
+	scanner.SetLooseFiles(flagConfig.Loose)
+	if flagConfig.Network {
+		opts := scanner.NetworkOptions{}
+		if cache != nil {
+			opts.Listings = cache
+		}
+		files, err = scanner.ScanNetworkShare(flagConfig.Directory, flagConfig.Recursive, opts, onScanned)
+	} else {
+		files, err = scanner.ScanDirectoryWithProgress(flagConfig.Directory, flagConfig.Recursive, onScanned)
+	}
+	if err != nil {
+		fatal(exitErrors, i18n.T("❌ Failed to scan directory: %v", err))
+	}
+	scanTracker.Finish()
+	if !lastPrint.IsZero() {
+		printScan(len(files), scannedBytes)
+		fmt.Println()
+	}
+	estimate.RecordScan(cache, flagConfig.Directory, scannedBytes, time.Since(startTime))
+	// Split archives are analyzed as one unit (parts summed, opened through the first volume)
+	files = scanner.CollapseVolumeSets(files)
+	// Comics and EPUBs report their page count as their contents; -contents reads the directory
+	// of every archive for its entry count and uncompressed size
+	if flagConfig.Contents {
+		var store scanner.ContentStore
+		if cache != nil {
+			store = cache
+		}
+		total := 0
+		for _, f := range files {
+			if f.Type == "archive" || f.Type == "book" {
+				total++
+			}
+		}
+		var mu sync.Mutex
+		lastContents := time.Time{}
+		scanner.EnrichContents(files, store, func(done int) {
+			mu.Lock()
+			defer mu.Unlock()
+			if done == total || time.Since(lastContents) > 100*time.Millisecond {
+				lastContents = time.Now()
+				fmt.Print(i18n.T("\r📑 Reading contents: %d/%d archives   ", done, total))
+			}
+		})
+		if total > 0 {
+			fmt.Println()
+		}
+	} else {
+		scanner.CountBookPages(files)
 	}
 
-	log.Printf("✅ Found %d archive files", len(files))
+	if flagConfig.Loose {
+		log.Print(i18n.T("✅ Found %d files", len(files)))
+	} else {
+		log.Print(i18n.T("✅ Found %d archive files", len(files)))
+	}
 	scanner.PrintFileStats(files)
 	fmt.Println()
+	if flagConfig.Events != nil {
+		for _, f := range files {
+			flagConfig.Events.File(reporter.NewFileInfo(f))
+		}
+	}
 
 	// Summary / Report Prep
 	elapsed := time.Since(startTime)
@@ -132,38 +504,77 @@ func main() {
 		Timestamp:        time.Now().Format("2006-01-02 15:04:05"),
 		Status:           "analyzing",
 	}
+	baseReport.SetPhase(progress.PhaseScan, scanPhase)
 
-	// Initialize Cache
-	cache, err := db.NewCache()
-	// var fingerprint string
-	if err != nil {
-		log.Printf("⚠️  Could not initialize cache: %v", err)
-	} else {
-		defer cache.Close()
-		// fingerprint = cache.CalculateFingerprint(files)
+	// Integrity check: corrupt archives are reported apart instead of being treated as unique files
+	if flagConfig.Verify {
+		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		log.Println(i18n.T("🩺 Verifying archive integrity..."))
+		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		files, baseReport.CorruptFiles = verifyArchives(files, flagConfig, &baseReport)
+		baseReport.CorruptCount = len(baseReport.CorruptFiles)
+		for _, c := range baseReport.CorruptFiles {
+			flagConfig.Events.Corrupt(c)
+		}
+	}
+
+	// Threshold sweep replaces the regular analysis
+	if len(flagConfig.SweepValues) > 0 {
+		runThresholdSweep(files, flagConfig, cache)
+		return
+	}
+
+	// Pre-scan estimate: confirm before kicking off a long Step 3 from a terminal
+	runsStep3Now := (flagConfig.Mode == "all" || flagConfig.Mode == "name") && (flagConfig.RunStep3 || flagConfig.Interactive)
+	if !confirmEstimate(estimate.New(files, cache), runsStep3Now, flagConfig) {
+		log.Println(i18n.T("⏹️  Run cancelled. Narrow the directory or raise -confirm-above to proceed."))
+		return
 	}
 
 	// Step 2: Identical Size
-	sizeGroups := scanner.GroupBySize(files)
+	// Each file type goes to the method of its profile; the rest to the size and name pass
+	byMethod := profile.Split(profile.Resolve(flagConfig.Profiles), files)
 	var finalSizeGroups []reporter.SizeGroup
 	if flagConfig.Mode == "all" || flagConfig.Mode == "size" {
 		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Println("🔄 Step 2: Analyzing identical sizes...")
+		log.Println(i18n.T("🔄 Step 2: Analyzing identical sizes..."))
 		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		finalSizeGroups = analyzeSameSizeDifferentName(sizeGroups, flagConfig.Threshold, flagConfig.Verbose, flagConfig)
+		if len(byMethod[profile.Name]) > 1 || len(byMethod[profile.Name]) == len(files) {
+			finalSizeGroups = analyzeSameSizeDifferentName(scanner.GroupBySize(byMethod[profile.Name]), flagConfig.ThresholdName, flagConfig.Verbose, flagConfig, cache, &baseReport)
+		}
+		if len(byMethod[profile.Content]) > 1 {
+			contentConfig := flagConfig
+			contentConfig.VerifyContent = true
+			finalSizeGroups = append(finalSizeGroups, analyzeSameSizeDifferentName(scanner.GroupBySize(byMethod[profile.Content]), flagConfig.ThresholdName, flagConfig.Verbose, contentConfig, cache, &baseReport)...)
+		}
+		finalSizeGroups = append(finalSizeGroups, analyzeProfiles(byMethod, flagConfig, cache)...)
+		finalSizeGroups = append(finalSizeGroups, analyzeCompressedPayloads(files, flagConfig, cache, &baseReport)...)
+		if flagConfig.AutoDelete && flagConfig.CleanupRun != "" {
+			verifyCleanupSample(flagConfig)
+		}
 
 		if flagConfig.PDFFile != "" {
 			report2 := baseReport
 			report2.SizeGroups = finalSizeGroups
+			if flagConfig.Thumbnails {
+				report2 = visual.AttachPreviews(report2, cache, true)
+			}
 			pdfName := "Step2_Size_" + flagConfig.PDFFile
-			fmt.Printf("\n📄 [BETA] Generating Step 2 PDF: %s\n", pdfName)
-			reporter.ExportPDF(report2, pdfName)
+			fmt.Print(i18n.T("\n📄 [BETA] Generating Step 2 PDF: %s\n", pdfName))
+			tracker := cliTracker("📄 Exporting", int64(len(report2.SizeGroups)), &baseReport, progress.PhaseExport, true)
+			reporter.ExportPDFWithProgress(report2, pdfName, func(done, total int) {
+				tracker.Set(int64(done))
+			})
+			tracker.Finish()
+			fmt.Println()
 		}
 	}
 
 	// Build initial report for web (will be updated)
 	finalReport := &baseReport
 	finalReport.SizeGroups = finalSizeGroups
+	sendNotification(appConfig, notify.EventScanFinished, flagConfig.Directory, finalReport)
+	hooks.Run(hooks.Event{Event: hooks.EventPostScan, Directory: flagConfig.Directory, Report: finalReport})
 
 	var runStep3Trigger func()
 	var runVisualTrigger func()
@@ -171,36 +582,47 @@ func main() {
 	// Step 3 Logic
 	var finalSimilarGroups []reporter.SimilarityGroup
 	runStep3Job := func() []reporter.SimilarityGroup {
-		log.Printf("🚀 Optimized Clustering Engine: Active (O(N) Speed)")
+		log.Print(i18n.T("🚀 Optimized Clustering Engine: Active (O(N) Speed)"))
 
+		tracker := cliTracker("🔍 Similar Names", totalSize(files), finalReport, progress.PhaseStep3, !flagConfig.Web)
 		onProgress := func(p float64) {
-			finalReport.Progress = p
-			if !flagConfig.Web {
-				fmt.Printf("\r🔍 Similar Names: [%-20s] %.1f%%", strings.Repeat("=", int(p/5)), p)
-			}
+			tracker.SetFraction(p)
 		}
 
-		simGroups := similarity.FindSimilarGroups(files, flagConfig.Threshold, flagConfig.Debug, onProgress)
+		clusterStart := time.Now()
+		simGroups, metrics := similarity.FindSimilarGroupsWithMetrics(files, similarity.Options{
+			Threshold:      flagConfig.ThresholdName,
+			Debug:          flagConfig.Debug,
+			Phonetic:       flagConfig.Phonetic,
+			Cache:          cache,
+			Scorers:        flagConfig.Scorers,
+			MaxClusterSize: flagConfig.MaxCluster,
+		}, onProgress)
+		tracker.Finish()
+		estimate.Record(cache, estimate.PhaseStep3, len(files), time.Since(clusterStart))
+		finalReport.ClusterMetrics = &metrics
 
 		if !flagConfig.Web {
 			fmt.Println()
 		}
+		printClusterMetrics(metrics)
 
 		var results []reporter.SimilarityGroup
 		for _, g := range simGroups {
 			var fileInfos []reporter.FileInfo
-			for _, f := range g.Files {
-				fileInfos = append(fileInfos, reporter.FileInfo{
-					Name:    f.Name,
-					Path:    f.Path,
-					Size:    f.Size,
-					Type:    f.Type,
-					ModTime: f.ModTime.Format(time.RFC3339),
-				})
+			for i, f := range g.Files {
+				info := reporter.NewFileInfo(f)
+				score := g.Scores[i]
+				info.Score = &score
+				fileInfos = append(fileInfos, info)
+			}
+			if reporter.Suppressed(fileInfos, suppressedIn(cache)) {
+				continue
 			}
 			results = append(results, reporter.SimilarityGroup{
 				BaseName: g.BaseName,
 				Files:    fileInfos,
+				Series:   g.Series,
 			})
 		}
 		return results
@@ -208,11 +630,11 @@ func main() {
 
 	runStep3Trigger = func() {
 		if finalReport.Status == "analyzing_step3" {
-			log.Println("ℹ️  Step 3 is already running.")
+			log.Println(i18n.T("ℹ️  Step 3 is already running."))
 			return
 		}
 
-		log.Println("📝 Step 3: Similar name analysis STARTED (Clustering Mode)...")
+		log.Println(i18n.T("📝 Step 3: Similar name analysis STARTED (Clustering Mode)..."))
 		step3Start := time.Now()
 		finalReport.Status = "analyzing_step3"
 		finalReport.Progress = 0
@@ -224,19 +646,29 @@ func main() {
 		finalReport.AnalysisDuration += time.Since(step3Start).Seconds()
 		finalReport.Status = "finished"
 
-		log.Printf("✅ Step 3 analysis FINISHED. Found %d similarity clusters.", len(results))
+		log.Print(i18n.T("✅ Step 3 analysis FINISHED. Found %d similarity clusters.", len(results)))
+		flagConfig.Events.SimilarityGroups(reporter.KindSimilar, results)
+		sendNotification(appConfig, notify.EventStep3Finished, flagConfig.Directory, finalReport)
 
-		if !flagConfig.Web {
+		if !flagConfig.Web && !flagConfig.Digest {
 			for i, g := range results {
 				if i >= 10 && !flagConfig.Verbose {
 					if i == 10 {
-						fmt.Println("... (Use --verbose to see all groups)")
+						fmt.Println(i18n.T("... (Use --verbose to see all groups)"))
 					}
 					continue
 				}
-				fmt.Printf("🔍 Cluster: '%s' (%d files)\n", g.BaseName, len(g.Files))
+				if g.Series {
+					fmt.Print(i18n.T("📚 Cluster: '%s' (%d files) — probable series, not duplicates\n", g.BaseName, len(g.Files)))
+				} else {
+					fmt.Print(i18n.T("🔍 Cluster: '%s' (%d files)\n", g.BaseName, len(g.Files)))
+				}
 				for _, f := range g.Files {
-					fmt.Printf("  • %s (%s)\n", f.Name, formatBytes(f.Size))
+					if flagConfig.Verbose {
+						fmt.Print(i18n.T("  • %s (%s) — %.1f%% match\n", f.Name, formatBytes(f.Size), f.Similarity()))
+					} else {
+						fmt.Printf("  • %s (%s)\n", f.Name, formatBytes(f.Size))
+					}
 				}
 				fmt.Println()
 			}
@@ -245,24 +677,24 @@ func main() {
 
 	runVisualTrigger = func() {
 		if finalReport.Status == "analyzing_visual" {
-			log.Println("ℹ️  Visual analysis is already running.")
+			log.Println(i18n.T("ℹ️  Visual analysis is already running."))
 			return
 		}
 
-		log.Println("🎨 Step 4: Visual Fingerprinting STARTED (Incremental Mode)...")
+		log.Println(i18n.T("🎨 Step 4: Visual Fingerprinting STARTED (Incremental Mode)..."))
 		finalReport.Status = "analyzing_visual"
 		finalReport.Progress = 0
 
+		pending := estimate.PendingVisual(files, cache)
+		hashStart := time.Now()
+		visualTracker := cliTracker("🌆 Visual Hashing", totalSize(files), finalReport, progress.PhaseVisual, !flagConfig.Web)
+
 		hashDone := make(chan bool)
 		go func() {
 			onVisualProgress := func(p float64) {
-				finalReport.Progress = p
-				if !flagConfig.Web {
-					fmt.Printf("\r🌆 Visual Hashing: [%-20s] %.1f%%",
-						strings.Repeat("=", int(p/5)), p)
-				}
+				visualTracker.SetFraction(p)
 			}
-			visual.ProcessVisualHashes(files, cache, flagConfig.Debug, onVisualProgress)
+			visual.ProcessVisualHashes(context.Background(), files, cache, flagConfig.Debug, onVisualProgress)
 			hashDone <- true
 		}()
 
@@ -270,7 +702,7 @@ func main() {
 		defer ticker.Stop()
 
 		updateVisualGroups := func() {
-			visualGroups := visual.FindVisualDuplicates(files, cache, flagConfig.Threshold)
+			visualGroups := visual.FindVisualDuplicates(files, cache, flagConfig.ThresholdVisual)
 			var reporterVisualGroups []reporter.SimilarityGroup
 			for _, vg := range visualGroups {
 				var fileInfos []reporter.FileInfo
@@ -284,6 +716,9 @@ func main() {
 						PHash:   f.PHash,
 					})
 				}
+				if reporter.Suppressed(fileInfos, suppressedIn(cache)) {
+					continue
+				}
 				reporterVisualGroups = append(reporterVisualGroups, reporter.SimilarityGroup{
 					BaseName: vg.BaseName,
 					Files:    fileInfos,
@@ -307,15 +742,31 @@ func main() {
 			}
 		}
 
+		visualTracker.Finish()
+		estimate.Record(cache, estimate.PhaseVisual, pending, time.Since(hashStart))
+		flagConfig.Events.SimilarityGroups(reporter.KindVisual, finalReport.VisualGroups)
+
+		// Renders saved next to their archive are hashed directly and related to its preview
+		finalReport.PreviewLinks = visual.LinkPreviews(context.Background(), files, visual.ImagesBeside(files), cache)
+		if len(finalReport.PreviewLinks) > 0 {
+			log.Print(i18n.T("🖼️  %d loose images match the preview of an archive in their folder", len(finalReport.PreviewLinks)))
+			if !flagConfig.Digest && !flagConfig.Web {
+				for _, l := range finalReport.PreviewLinks {
+					fmt.Print(i18n.T("  🔗 %s ↔ %s (distance %d)\n", l.Archive.Name, l.Image.Name, l.Distance))
+				}
+			}
+		}
+
 		finalReport.Status = "finished"
-		log.Printf("✅ Visual analysis FINISHED. Found %d visual duplicate groups total.", finalReport.VisualCount)
+		log.Print(i18n.T("✅ Visual analysis FINISHED. Found %d visual duplicate groups total.", finalReport.VisualCount))
+		sendNotification(appConfig, notify.EventVisualFinished, flagConfig.Directory, finalReport)
 	}
 
 	if flagConfig.Mode == "all" || flagConfig.Mode == "name" {
 		if flagConfig.Interactive {
 			// Interactive mode force
 			fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-			log.Println("📝 Step 3: Similar name analysis (Interactive Mode)")
+			log.Println(i18n.T("📝 Step 3: Similar name analysis (Interactive Mode)"))
 			fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 			finalSimilarGroups = runStep3Job()
 			finalReport.SimilarGroups = finalSimilarGroups
@@ -326,57 +777,166 @@ func main() {
 			if flagConfig.RunStep3 {
 				fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 				if flagConfig.Web {
-					log.Println("📝 Step 3: Similar name analysis started in BACKGROUND...")
+					log.Println(i18n.T("📝 Step 3: Similar name analysis started in BACKGROUND..."))
 					fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 					go runStep3Trigger()
-					fmt.Println("ℹ️  You can check the dashboard while Step 3 works.")
+					fmt.Println(i18n.T("ℹ️  You can check the dashboard while Step 3 works."))
 				} else {
-					log.Println("📝 Step 3: Similar name analysis started...")
+					log.Println(i18n.T("📝 Step 3: Similar name analysis started..."))
 					fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 					runStep3Trigger()
 				}
 			} else {
-				log.Println("ℹ️  Step 3 (Similarity Check) skipped. Use --check-similar or Dashboard to run it.")
+				log.Println(i18n.T("ℹ️  Step 3 (Similarity Check) skipped. Use --check-similar or Dashboard to run it."))
 			}
 		}
 	} else {
 		finalReport.Status = "finished"
 	}
 
-	// Start web dashboard
-	if flagConfig.Web {
-		// Convert scanner.ArchiveFile to reporter.FileInfo for the dashboard
-		var allFileInfos []reporter.FileInfo
-		for _, f := range files {
-			allFileInfos = append(allFileInfos, reporter.FileInfo{
-				Name:    f.Name,
-				Path:    f.Path,
-				Size:    f.Size,
-				Type:    f.Type,
-				ModTime: f.ModTime.Format(time.RFC3339),
+	if flagged := archive.SuspiciousArchives(); len(flagged) > 0 {
+		log.Print(i18n.T("🧨 %d archives look like zip bombs; their oversized entries were skipped:", len(flagged)))
+		for _, path := range slices.Sorted(maps.Keys(flagged)) {
+			fmt.Printf("  ⚠️  %s: %s\n", path, flagged[path])
+		}
+	}
+
+	// Suppressed content stays out of every export, whichever step found it
+	*finalReport = reporter.WithoutSuppressed(*finalReport, suppressedIn(cache))
+
+	// Groups keep their ID across re-scans, for ignore flags and exports
+	if cache != nil {
+		*finalReport = reporter.WithGroupIDs(*finalReport, cache.GroupIDs)
+	}
+
+	// Protected files are kept by the script and digest plans
+	planReport := reporter.WithSuspicious(reporter.WithProtected(*finalReport, flagConfig.Protected.Match), archive.SuspiciousReason)
+	scanned := make([]reporter.FileInfo, len(files))
+	for i, f := range files {
+		scanned[i] = reporter.NewFileInfo(f)
+	}
+	if cache != nil {
+		// Totals of the run, for the trends of the overview page
+		if err := cache.RecordRun(flagConfig.Directory, reporter.Summarize(planReport, scanned, flagConfig.DeleteMode)); err != nil {
+			log.Print(i18n.T("⚠️ Could not record the run in the scan history: %v", err))
+		}
+	}
+	if flagConfig.GroupBy == reporter.GroupByDir {
+		planReport.ByDir = reporter.GroupByDirs(planReport, scanned, flagConfig.Directory, flagConfig.DeleteMode)
+	}
+
+	// Full report for other tools (and the review subcommand), groups pointing at their previews
+	if flagConfig.OutputFile != "" {
+		exported := visual.AttachPreviews(planReport, cache, flagConfig.Thumbnails)
+		if cache != nil {
+			reviews := cache.GroupReviews()
+			exported = reporter.WithReviews(exported, func(id string) (string, string) {
+				return reviews[id].Status, reviews[id].Note
 			})
 		}
+		if err := reporter.ExportJSON(exported, flagConfig.OutputFile); err != nil {
+			log.Print(i18n.T("❌ Could not write JSON report: %v", err))
+			noteFailure()
+		} else {
+			log.Print(i18n.T("💾 JSON report written: %s", flagConfig.OutputFile))
+		}
+	}
+
+	// Cleanup plan for admins who run changes through their own process
+	if flagConfig.ScriptFile != "" {
+		tracker := cliTracker("📜 Exporting", 1, finalReport, progress.PhaseExport, false)
+		err := reporter.ExportScript(planReport, flagConfig.ScriptFile, reporter.ScriptOptions{
+			Shell:       reporter.ScriptShell(flagConfig.ScriptFile),
+			DeleteMode:  flagConfig.DeleteMode,
+			TrashPath:   flagConfig.TrashPath,
+			LeaveRef:    flagConfig.LeaveRef,
+			RefFormat:   flagConfig.RefFormat,
+			RefTemplate: flagConfig.RefTemplate,
+		})
+		if err != nil {
+			log.Print(i18n.T("❌ Could not write cleanup script: %v", err))
+			noteFailure()
+		} else {
+			tracker.Finish()
+			log.Print(i18n.T("📜 Cleanup script written: %s (review it before running)", flagConfig.ScriptFile))
+		}
+	}
+
+	// Duplicates left out of the next backup run instead of deleted from the source
+	if flagConfig.ExcludesFile != "" {
+		err := reporter.ExportExcludes(planReport, flagConfig.ExcludesFile, reporter.ExcludeOptions{
+			Format:     reporter.ExcludeFormat(flagConfig.ExcludesFile),
+			Root:       flagConfig.Directory,
+			DeleteMode: flagConfig.DeleteMode,
+		})
+		if err != nil {
+			log.Print(i18n.T("❌ Could not write exclusion list: %v", err))
+			noteFailure()
+		} else {
+			log.Print(i18n.T("🚫 Exclusion list written: %s", flagConfig.ExcludesFile))
+		}
+	}
+
+	// Move-and-merge: the library is reorganized around the kept files
+	if flagConfig.OrganizeDir != "" {
+		runOrganize(planReport, flagConfig, cache)
+	}
+
+	// Rename suggestions: variants converge on "<base name> <version>.<ext>"
+	if flagConfig.Rename != "" {
+		runRename(planReport, flagConfig)
+	}
+
+	if flagConfig.Digest {
+		reporter.WriteDigest(resultOut, planReport, reporter.DigestOptions{
+			Root:         flagConfig.Directory,
+			DeleteMode:   flagConfig.DeleteMode,
+			MaxDirs:      20,
+			MaxOffenders: 5,
+		})
+	}
+	if flagConfig.GroupBy == reporter.GroupByDir {
+		fmt.Fprintln(resultOut)
+		reporter.WriteDirGroups(resultOut, planReport.ByDir, flagConfig.Directory, 20)
+	}
 
-		startWebServer(flagConfig, finalReport, allFileInfos, cache, appConfig, runStep3Trigger, runVisualTrigger)
+	// Start web dashboard
+	if flagConfig.Web {
+		// Convert scanner.ArchiveFile to reporter.FileInfo for the dashboard
+		startWebServer(flagConfig, finalReport, scanned, cache, appConfig, runStep3Trigger, runVisualTrigger)
 	}
 
 	elapsedTotal := time.Since(startTime)
-	log.Printf("📈 Total processing time: %.2fs", elapsedTotal.Seconds())
+	log.Print(i18n.T("📈 Total processing time: %.2fs", elapsedTotal.Seconds()))
+	flagConfig.Events.Finished(*finalReport, elapsedTotal)
 
 	// If web server is running, block indefinitely
 	if flagConfig.Web {
-		log.Println("📡 Dashboard is ACTIVE. Press Ctrl+C to shutdown.")
+		log.Println(i18n.T("📡 Dashboard is ACTIVE. Press Ctrl+C to shutdown."))
 		select {}
 	}
+	exit(resultCode(*finalReport))
+}
+
+// sendNotification reports a finished phase to the configured targets. It blocks so that a
+// CLI run does not exit before the messages are out.
+func sendNotification(appConfig *config.AppConfig, event, dir string, report *reporter.Report) {
+	if len(appConfig.Notifications) == 0 {
+		return
+	}
+	notify.Dispatch(appConfig.Notifications, notify.Event{Name: event, Directory: dir, Report: *report})
 }
 
 func startWebServer(config Config, report *reporter.Report, allFiles []reporter.FileInfo, cache *db.Cache, appConfig *config.AppConfig, runStep3 func(), runVisual func()) {
 	// Set triggers for on-demand analysis if needed
 	srv := web.NewServer(config.Port, report, config.TrashPath, config.LeaveRef, runStep3, runVisual, allFiles, cache, config.Directory, appConfig)
 	srv.SetDebug(config.Debug)
+	srv.SetExtraProtection(config.Protect)
+	srv.SetQuarantine(config.QuarantinePath)
+	srv.SetUIDir(config.UIDir)
 	go func() {
 		if err := srv.Start(); err != nil {
-			log.Printf("❌ Web server error: %v", err)
+			log.Print(i18n.T("❌ Web server error: %v", err))
 		}
 	}()
 
@@ -384,34 +944,101 @@ func startWebServer(config Config, report *reporter.Report, allFiles []reporter.
 	go func() {
 		time.Sleep(1 * time.Second) // Give server a moment to bind
 		url := fmt.Sprintf("http://localhost:%d", config.Port)
-		log.Printf("🌍 Opening dashboard at %s ...", url)
+		log.Print(i18n.T("🌍 Opening dashboard at %s ...", url))
 		openBrowser(url)
 	}()
 }
 
+// selectLanguage sets the language of the messages. An empty language falls back to the
+// settings, then to the locale.
+func selectLanguage(lang string) {
+	if lang == "" {
+		if cfg, _ := config.LoadConfig(); cfg != nil {
+			lang = cfg.Language
+		}
+	}
+	if err := i18n.SetLanguage(lang); err != nil {
+		fatal(exitInvalidConfig, "❌ "+err.Error())
+	}
+}
+
+// defineScanFlags defines the flags of a scan on fs, storing their values in config
+func defineScanFlags(fs *flag.FlagSet, config *Config) {
+	fs.StringVar(&config.Directory, "dir", ".", "Directory to scan for archive files")
+	fs.IntVar(&config.ThresholdName, "threshold", 70, "Name similarity threshold percentage (0-100)")
+	fs.IntVar(&config.ThresholdVisual, "threshold-visual", visual.HammingThreshold, "Largest Hamming distance (0-64) between previews that count as visually similar")
+	fs.IntVar(&config.MinContentOverlap, "min-content-overlap", 100, "Share of entries (1-100%) archives compared by manifest must have in common (100 = the same list)")
+	fs.StringVar(&config.Mode, "mode", "all", "Analysis mode: 'all', 'size', or 'name'")
+	fs.BoolVar(&config.Verbose, "verbose", false, "Enable verbose output")
+	fs.BoolVar(&config.Recursive, "recursive", true, "Scan subdirectories recursively")
+	fs.StringVar(&config.OutputFile, "json", "", "Output JSON file path")
+	fs.StringVar(&config.PDFFile, "pdf", "", "Output PDF report path")
+	fs.BoolVar(&config.Thumbnails, "thumbs", false, "Embed preview thumbnails (base64 JPEG) in the JSON and PDF reports")
+	fs.StringVar(&config.ScriptFile, "script", "", "Write the cleanup plan as a reviewable script instead of touching files (.sh or .ps1)")
+	fs.StringVar(&config.ExcludesFile, "excludes", "", "Write the files to remove as an rsync --exclude-from list (an rclone --filter-from list when the name contains rclone or filter)")
+	fs.StringVar(&config.DeleteMode, "delete", "", "Cleanup mode: 'oldest' or 'contents'")
+	fs.BoolVar(&config.AutoDelete, "yes", false, "Auto-confirm deletion without asking")
+	fs.Float64Var(&config.VerifySample, "verify-sample", 5, "Percentage of automatically resolved groups whose kept file is re-verified against the cleanup journal (0 disables)")
+	fs.BoolVar(&config.Interactive, "interactive", false, "Choose which file to delete manually")
+	fs.StringVar(&config.TrashPath, "trash", "", "Folder to move duplicates to (instead of deleting)")
+	fs.StringVar(&config.QuarantinePath, "quarantine", "", "Move duplicates into this quarantine folder, with a manifest, to restore them or purge them later (takes over from -trash)")
+	fs.BoolVar(&config.TrashOrDelete, "delete-if-trash-fails", false, "Permanently delete files that cannot be moved to the trash (by default they are kept)")
+	fs.StringVar(&config.Recheck, "verify-before-delete", "", "Check each file right before removing it and keep it if it changed since the scan: 'stat' (size and mod time) or 'hash' (also its contents)")
+	fs.StringVar(&config.Language, "lang", "", "Language of the messages and reports: "+strings.Join(i18n.Languages(), ", ")+" (defaults to language in the settings, then the locale)")
+	fs.StringVar(&config.OpsLog, "ops-log", "", "Also append every delete, move, rename and ignore to this JSONL file (defaults to operations_log in the settings)")
+	fs.IntVar(&config.TrashDays, "trash-retention", 0, "Empty the trash's per-day folders older than this many days before cleaning up (0 keeps them)")
+	fs.BoolVar(&config.LeaveRef, "ref", false, "Leave a .txt file pointing to the preserved original")
+	fs.StringVar(&config.RefFormat, "ref-format", refnote.FormatText, "Format of the -ref note: 'text' (.duplicate.txt) or 'json' (.duplicate.json sidecar with hashes and similarity)")
+	fs.BoolVar(&config.Web, "web", false, "Start web dashboard after analysis")
+	fs.IntVar(&config.Port, "port", 8080, "Web server port")
+	fs.BoolVar(&config.Plain, "plain", false, "Plain output for logs and legacy terminals: no emoji, box drawing or progress bars (default when stdout is redirected; -plain=false keeps them)")
+	fs.StringVar(&config.UIDir, "ui-dir", "", "Serve the dashboard from this folder (e.g. ui/out while working on the UI) instead of the copy in the binary")
+	fs.StringVar(&config.ConfigFile, "config", "", "Settings file to read (and save from the dashboard) instead of archive-finder-settings.json in the user config folder; flags win over it")
+	fs.StringVar(&config.Output, "output", outputText, "Output format: 'text', or 'ndjson' to stream one JSON object per event (file scanned, group found, action taken) to stdout")
+	fs.BoolVar(&config.Debug, "debug", false, "Enable detailed debug logging for troubleshooting")
+	fs.BoolVar(&config.RunStep3, "check-similar", false, "Explicitly run Step 3 (Similarity Check). Default is on-demand.")
+	fs.BoolVar(&config.Version, "version", false, "Show version information and exit")
+	fs.BoolVar(&config.Info, "info", false, "Show project information, author and license")
+	fs.StringVar(&config.Phonetic, "phonetic", "", "Phonetic name matching for typo'd names: 'soundex' or 'metaphone'")
+	fs.IntVar(&config.MaxCluster, "max-cluster", 0, "Split similar-name clusters of more files than this at stricter thresholds (default 100)")
+	fs.StringVar(&config.Sweep, "sweep", "", "Report cluster counts for a threshold range 'start:end:step' (e.g. 60:90:5) and exit")
+	fs.BoolVar(&config.VerifyContent, "verify-content", false, "Step 2: confirm same-size archives by content (partial, then full hash) and report identical copies whatever their names")
+	fs.BoolVar(&config.Verify, "verify", false, "Check archive integrity (CRC / read test) and report corrupt archives separately")
+	fs.BoolVar(&config.Contents, "contents", false, "Read every archive's directory to record its entry count and uncompressed size (cached; used by -delete contents)")
+	fs.DurationVar(&config.ConfirmAbove, "confirm-above", estimate.DefaultConfirmAbove, "Ask for confirmation when Step 3 is projected to take longer than this (0 disables)")
+	fs.IntVar(&config.Limits.Workers, "workers", 0, "Archive operations allowed to run at the same time (0 benchmarks the disk: more on SSDs, fewer on spinning disks)")
+	fs.DurationVar(&config.Limits.Timeout, "timeout", archive.DefaultLimits.Timeout, "Give up on a single archive after this long (0 disables)")
+	fs.Int64Var(&config.MaxUncompressedMB, "max-uncompressed-mb", archive.DefaultLimits.MaxUncompressed>>20, "Maximum MB decompressed from a single archive (zip-bomb protection, 0 disables)")
+	fs.Int64Var(&config.MaxEntryMB, "max-entry-mb", archive.DefaultLimits.MaxEntrySize>>20, "Skip archive entries larger than this many MB and flag the archive as suspicious (0 disables)")
+	fs.Int64Var(&config.VisualRateMB, "visual-rate-mb", 0, "Read at most this many MB of previews per second in the visual analysis, to leave the disks to others (0 = unlimited)")
+	fs.Int64Var(&config.MemoryMB, "memory-mb", 0, "Keep archive entries, decoded previews and parsed models under this many MB at once: operations wait or go through temporary files beyond it (0 = unlimited)")
+	fs.Float64Var(&config.Limits.MaxRatio, "max-ratio", archive.DefaultLimits.MaxRatio, "Skip archive entries expanding more than this ratio (uncompressed:compressed) and flag the archive as suspicious (0 disables)")
+	fs.BoolVar(&config.Digest, "digest", false, "Print a compact per-directory summary (groups, reclaimable space, worst offenders) instead of per-group detail")
+	fs.StringVar(&config.GroupBy, "group-by", "", "Roll the duplicates up by 'dir': redundant and reclaimable bytes of every directory, subdirectories included, to find whole folders to delete (also in the JSON report)")
+	fs.BoolVar(&config.Loose, "loose", false, "Loose-file mode: also scan images and any other file, compared by content (and by image when visual analysis runs), not only archives")
+	fs.BoolVar(&config.NoCache, "no-cache", false, "Run without writing the cache database or preview files to disk: everything is cached in memory for this run only (same as cache_dsn ':memory:')")
+	fs.BoolVar(&config.Network, "network", false, "Network share mode for SMB/NFS mounts: batched directory reads, parallel stats and cached folder listings")
+	fs.Var(&config.Protect, "protect", "Never delete files matching this glob, or anything inside a matching folder (repeatable)")
+	fs.StringVar(&config.OrganizeDir, "organize", "", "Move the kept file of every identical group into this library folder and remove the other copies (see -layout, -link)")
+	fs.StringVar(&config.Layout, "layout", organize.DefaultLayout, "Path of kept files under -organize, e.g. '{initial}/{token1}/{name}' (tokens: name, stem, ext, type, initial, tokenN, parent, year)")
+	fs.BoolVar(&config.Link, "link", false, "With -organize, replace the removed copies with links to the kept file")
+	fs.StringVar(&config.Rename, "rename", "", "Canonical names for variants of similar-name groups: 'suggest' lists them, 'apply' renames the files (runs Step 3)")
+	fs.StringVar(&config.ProfileSpec, "profiles", "", "Step 2 method per extension, e.g. '.zip=manifest,.cbz=cover' (available: "+strings.Join(profile.Methods, ", ")+"; .stl and .obj default to geometry)")
+	fs.StringVar(&config.ScorerSpec, "scorers", "", "Similarity scorers with weights, e.g. 'name=0.7,token=0.3' (available: "+strings.Join(similarity.ScorerNames(), ", ")+")")
+
+}
+
 func parseFlags() Config {
 	config := Config{}
-
-	flag.StringVar(&config.Directory, "dir", ".", "Directory to scan for archive files")
-	flag.IntVar(&config.Threshold, "threshold", 70, "Similarity threshold percentage (0-100)")
-	flag.StringVar(&config.Mode, "mode", "all", "Analysis mode: 'all', 'size', or 'name'")
-	flag.BoolVar(&config.Verbose, "verbose", false, "Enable verbose output")
-	flag.BoolVar(&config.Recursive, "recursive", true, "Scan subdirectories recursively")
-	flag.StringVar(&config.OutputFile, "json", "", "Output JSON file path")
-	flag.StringVar(&config.PDFFile, "pdf", "", "Output PDF report path")
-	flag.StringVar(&config.DeleteMode, "delete", "", "Cleanup mode: 'oldest' or 'contents'")
-	flag.BoolVar(&config.AutoDelete, "yes", false, "Auto-confirm deletion without asking")
-	flag.BoolVar(&config.Interactive, "interactive", false, "Choose which file to delete manually")
-	flag.StringVar(&config.TrashPath, "trash", "", "Folder to move duplicates to (instead of deleting)")
-	flag.BoolVar(&config.LeaveRef, "ref", false, "Leave a .txt file pointing to the preserved original")
-	flag.BoolVar(&config.Web, "web", false, "Start web dashboard after analysis")
-	flag.IntVar(&config.Port, "port", 8080, "Web server port")
-	flag.BoolVar(&config.Debug, "debug", false, "Enable detailed debug logging for troubleshooting")
-	flag.BoolVar(&config.RunStep3, "check-similar", false, "Explicitly run Step 3 (Similarity Check). Default is on-demand.")
-	flag.BoolVar(&config.Version, "version", false, "Show version information and exit")
-	flag.BoolVar(&config.Info, "info", false, "Show project information, author and license")
-
-	flag.Parse()
+	defineScanFlags(flag.CommandLine, &config)
+
+	// Bad flags exit with exitInvalidConfig rather than the flag package's 2
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err == flag.ErrHelp {
+		exit(exitClean)
+	} else if err != nil {
+		exit(exitInvalidConfig)
+	}
 
 	if config.Version {
 		fmt.Println("Archive Duplicate Finder v1.8.0")
@@ -429,101 +1056,471 @@ func parseFlags() Config {
 		os.Exit(0)
 	}
 
-	// Validate threshold
-	if config.Threshold < 0 || config.Threshold > 100 {
-		log.Fatal("❌ Threshold must be between 0 and 100")
+	// Validate thresholds
+	if config.ThresholdName < 0 || config.ThresholdName > 100 {
+		fatal(exitInvalidConfig, "❌ Threshold must be between 0 and 100")
+	}
+	if config.ThresholdVisual < 0 || config.ThresholdVisual > 64 {
+		fatal(exitInvalidConfig, "❌ Visual threshold must be between 0 and 64")
+	}
+	if config.MinContentOverlap < 1 || config.MinContentOverlap > 100 {
+		fatal(exitInvalidConfig, "❌ Content overlap must be between 1 and 100")
 	}
 
 	// Validate mode
 	if config.Mode != "all" && config.Mode != "size" && config.Mode != "name" {
-		log.Fatal("❌ Mode must be 'all', 'size', or 'name'")
+		fatal(exitInvalidConfig, "❌ Mode must be 'all', 'size', or 'name'")
+	}
+
+	// Validate phonetic algorithm
+	if !similarity.IsValidPhonetic(config.Phonetic) {
+		fatal(exitInvalidConfig, "❌ Phonetic must be 'soundex' or 'metaphone'")
+	}
+
+	if !hashing.IsValidRecheck(config.Recheck) {
+		fatal(exitInvalidConfig, "❌ -verify-before-delete must be 'stat' or 'hash'")
+	}
+
+	if config.VerifySample < 0 || config.VerifySample > 100 {
+		fatal(exitInvalidConfig, "❌ -verify-sample must be a percentage between 0 and 100")
+	}
+
+	// Validate scorers
+	if config.ScorerSpec != "" {
+		scorers, err := similarity.ParseScorerWeights(config.ScorerSpec)
+		if err != nil {
+			fatal(exitInvalidConfig, i18n.T("❌ Invalid scorers: %v", err))
+		}
+		config.Scorers = scorers
+	}
+
+	// Validate analysis profiles
+	if config.ProfileSpec != "" {
+		profiles, err := profile.Parse(config.ProfileSpec)
+		if err != nil {
+			fatal(exitInvalidConfig, i18n.T("❌ Invalid profiles: %v", err))
+		}
+		config.Profiles = profiles
+	}
+
+	// Validate sweep
+	if config.Sweep != "" {
+		values, err := similarity.ParseSweep(config.Sweep)
+		if err != nil {
+			fatal(exitInvalidConfig, i18n.T("❌ Invalid sweep: %v", err))
+		}
+		config.SweepValues = values
+	}
+
+	// Validate archive limits
+	if config.Limits.Workers < 0 {
+		fatal(exitInvalidConfig, "❌ Workers cannot be negative")
+	}
+	if config.Limits.Timeout < 0 || config.MaxUncompressedMB < 0 || config.MaxEntryMB < 0 || config.Limits.MaxRatio < 0 || config.VisualRateMB < 0 || config.MemoryMB < 0 {
+		fatal(exitInvalidConfig, "❌ Timeout, max-uncompressed-mb, max-entry-mb, max-ratio, visual-rate-mb and memory-mb cannot be negative")
+	}
+	config.Limits.MaxUncompressed = config.MaxUncompressedMB << 20
+	config.Limits.MaxEntrySize = config.MaxEntryMB << 20
+
+	if config.Output != outputText && config.Output != outputNDJSON {
+		fatal(exitInvalidConfig, "❌ -output must be 'text' or 'ndjson'")
+	}
+	if config.Output == outputNDJSON && config.Digest {
+		fatal(exitInvalidConfig, "❌ -output ndjson cannot be combined with -digest")
+	}
+	if config.GroupBy != "" && config.GroupBy != reporter.GroupByDir {
+		fatal(exitInvalidConfig, "❌ -group-by must be 'dir'")
+	}
+	if config.Output == outputNDJSON && config.GroupBy != "" {
+		fatal(exitInvalidConfig, "❌ -output ndjson cannot be combined with -group-by")
 	}
 
 	// Validate delete mode
 	if config.DeleteMode != "" && config.DeleteMode != "oldest" && config.DeleteMode != "contents" {
-		log.Fatal("❌ Delete mode must be 'oldest' or 'contents'")
+		fatal(exitInvalidConfig, "❌ Delete mode must be 'oldest' or 'contents'")
+	}
+
+	// Validate rename suggestions (they need the similar-name groups of Step 3)
+	if config.Rename != "" {
+		if config.Rename != "suggest" && config.Rename != "apply" {
+			fatal(exitInvalidConfig, "❌ -rename must be 'suggest' or 'apply'")
+		}
+		if config.Web || config.Mode == "size" {
+			fatal(exitInvalidConfig, "❌ -rename needs the similar-name analysis and cannot be combined with -web (use the dashboard API) or -mode size")
+		}
+		config.RunStep3 = true
+	}
+
+	// Validate move-and-merge
+	if config.OrganizeDir != "" {
+		if config.Interactive {
+			fatal(exitInvalidConfig, "❌ -organize cannot be combined with -interactive")
+		}
+		if err := organize.ValidateLayout(config.Layout); err != nil {
+			fatal(exitInvalidConfig, i18n.T("❌ Invalid layout: %v", err))
+		}
 	}
 
 	return config
 }
 
-func analyzeSameSizeDifferentName(sizeGroups map[int64][]scanner.ArchiveFile, threshold int, verbose bool, config Config) []reporter.SizeGroup {
+func analyzeSameSizeDifferentName(sizeGroups map[int64][]scanner.ArchiveFile, threshold int, verbose bool, config Config, cache *db.Cache, report *reporter.Report) []reporter.SizeGroup {
 	var results []reporter.SizeGroup
 	groupCount := 0
 	totalFiles := 0
 
+	// Progress is tracked for the dashboard only; the per-group output is the CLI feedback here
+	var totalBytes int64
+	for size, group := range sizeGroups {
+		if len(group) > 1 {
+			totalBytes += size * int64(len(group))
+		}
+	}
+	tracker := cliTracker("🔄 Identical sizes", totalBytes, report, progress.PhaseStep2, false)
+	defer tracker.Finish()
+
 	for size, group := range sizeGroups {
 		if len(group) < 2 {
 			continue // Skip groups with only one file
 		}
+		tracker.Add(size * int64(len(group)))
+
+		// Content verification splits the size group into confirmed copies, whatever their names
+		batches := [][]scanner.ArchiveFile{group}
+		if config.VerifyContent {
+			batches = nil
+			for _, g := range hashing.VerifyContents(cache, group) {
+				batches = append(batches, g.Files)
+			}
+		}
+		for _, group := range batches {
+			// Skip content the user has explicitly accepted as duplicated
+			paths := make([]string, len(group))
+			for i, f := range group {
+				paths[i] = f.Path
+			}
+			groupFiles := make([]reporter.FileInfo, len(group))
+			for i, f := range group {
+				groupFiles[i] = reporter.FileInfo{Path: f.Path}
+			}
+			groupHash := reporter.CalculateGroupHash(groupFiles)
+			if hashing.IsSuppressed(cache, paths) {
+				if verbose {
+					fmt.Print(i18n.T("  🔕 Skipping suppressed content (%d copies of %s)\n", len(group), group[0].Name))
+				}
+				continue
+			}
 
-		groupCount++
-		totalFiles += len(group)
-
-		fmt.Printf("📦 Group %d (Size: %s)\n", groupCount, formatBytes(size))
-
-		var currentGroup reporter.SizeGroup
-		currentGroup.Size = size
-
-		// Compare all pairs in the group
-		for i := 0; i < len(group); i++ {
-			f := group[i]
-			currentGroup.Files = append(currentGroup.Files, reporter.FileInfo{
-				Name:    f.Name,
-				Path:    f.Path,
-				Size:    f.Size,
-				Type:    f.Type,
-				ModTime: f.ModTime.Format(time.RFC3339),
-			})
-
-			for j := i + 1; j < len(group); j++ {
-				file1 := group[i]
-				file2 := group[j]
+			groupCount++
+			totalFiles += len(group)
 
-				// Calculate name similarity
-				sim := similarity.CalculateNameSimilarity(file1.Name, file2.Name, config.Debug)
+			if !config.Digest {
+				fmt.Print(i18n.T("📦 Group %d (Size: %s)\n", groupCount, formatBytes(size)))
+			}
 
-				// Skip if they are different parts of the same multi-volume set
-				is1, base1, p1 := file1.IsMultiVolumePart()
-				is2, base2, p2 := file2.IsMultiVolumePart()
-				if is1 && is2 && base1 == base2 && p1 != p2 {
-					if verbose {
-						fmt.Printf("  ⏩ Skipping multi-volume set parts: %s vs %s\n", file1.Name, file2.Name)
+			var currentGroup reporter.SizeGroup
+			currentGroup.Size = size
+			currentGroup.Verified = config.VerifyContent
+			for _, f := range group {
+				currentGroup.Files = append(currentGroup.Files, reporter.NewFileInfo(f))
+			}
+			config.Events.SizeGroup(currentGroup) // Before the cleanup actions it leads to
+
+			// Compare all pairs in the group
+			for i := 0; i < len(group); i++ {
+				for j := i + 1; j < len(group); j++ {
+					file1 := group[i]
+					file2 := group[j]
+
+					// Calculate name similarity; phonetic matching scores typo'd names
+					sim := similarity.CalculateNameSimilarity(file1.Name, file2.Name, config.Debug)
+					if config.Phonetic != "" {
+						sim = similarity.CalculateNormalizedSimilarity(file1.Name, file2.Name, similarity.Options{
+							Threshold: threshold,
+							Debug:     config.Debug,
+							Phonetic:  config.Phonetic,
+						})
 					}
-					continue
-				}
-
-				if sim >= float64(threshold) {
-					fmt.Printf("  📄 %s (Mod: %v)\n", file1.Name, file1.ModTime.Format("2006-01-02 15:04"))
-					fmt.Printf("  📄 %s (Mod: %v)\n", file2.Name, file2.ModTime.Format("2006-01-02 15:04"))
-					fmt.Printf("  📊 Name similarity: %.1f%%\n", sim)
 
-					if sim > 90 {
-						fmt.Println("  ⚠️  HIGH PROBABILITY: Likely renamed duplicate")
-					} else if sim > 75 {
-						fmt.Println("  ⚠️  MEDIUM PROBABILITY: Possible variant or version")
+					// Skip if they are different parts of the same multi-volume set
+					is1, base1, p1 := file1.IsMultiVolumePart()
+					is2, base2, p2 := file2.IsMultiVolumePart()
+					if is1 && is2 && base1 == base2 && p1 != p2 {
+						if verbose {
+							fmt.Print(i18n.T("  ⏩ Skipping multi-volume set parts: %s vs %s\n", file1.Name, file2.Name))
+						}
+						continue
 					}
 
-					// Cleanup logic
-					if config.DeleteMode != "" || config.Interactive {
-						handleCleanup(file1, file2, config)
+					// Confirmed copies are duplicates whatever their names
+					if sim >= float64(threshold) || config.VerifyContent {
+						if !config.Digest {
+							fmt.Print(i18n.T("  📄 %s (Mod: %v)\n", file1.Name, file1.ModTime.Format("2006-01-02 15:04")))
+							fmt.Print(i18n.T("  📄 %s (Mod: %v)\n", file2.Name, file2.ModTime.Format("2006-01-02 15:04")))
+							fmt.Print(i18n.T("  📊 Name similarity: %.1f%%\n", sim))
+
+							if config.VerifyContent {
+								fmt.Println(i18n.T("  🔐 CONFIRMED: Identical contents"))
+							} else if sim > 90 {
+								fmt.Println(i18n.T("  ⚠️  HIGH PROBABILITY: Likely renamed duplicate"))
+							} else if sim > 75 {
+								fmt.Println(i18n.T("  ⚠️  MEDIUM PROBABILITY: Possible variant or version"))
+							}
+						}
+
+						// Cleanup logic
+						if (config.DeleteMode != "" || config.Interactive) && config.OrganizeDir == "" {
+							handleCleanup(file1, file2, refnote.Note{GroupHash: groupHash, Similarity: sim}, config, cache)
+						}
+
+						if !config.Digest {
+							fmt.Println()
+						}
 					}
-
-					fmt.Println()
 				}
 			}
+			results = append(results, currentGroup)
 		}
-		results = append(results, currentGroup)
 	}
 
 	if groupCount == 0 {
-		fmt.Println("✅ No files with identical size and different names found")
+		fmt.Println(i18n.T("✅ No files with identical size and different names found"))
 	} else {
-		fmt.Printf("📊 Found %d groups with %d total files\n", groupCount, totalFiles)
+		fmt.Print(i18n.T("📊 Found %d groups with %d total files\n", groupCount, totalFiles))
+	}
+	return results
+}
+
+// analyzeProfiles runs the methods other than size and name on the file types routed to them
+func analyzeProfiles(byMethod map[string][]scanner.ArchiveFile, config Config, cache *db.Cache) []reporter.SizeGroup {
+	var results []reporter.SizeGroup
+	for _, method := range []string{profile.Manifest, profile.Geometry, profile.Cover} {
+		if len(byMethod[method]) < 2 {
+			continue
+		}
+		log.Print(i18n.T("🧬 Comparing %d files by %s...", len(byMethod[method]), method))
+		groups := profile.Analyze(context.Background(), cache, method, byMethod[method], profile.Options{
+			MinOverlap:     config.MinContentOverlap,
+			VisualDistance: config.ThresholdVisual,
+		})
+		found := 0
+		for _, g := range groups {
+			if reporter.Suppressed(g.Files, suppressedIn(cache)) {
+				continue
+			}
+			found++
+			config.Events.SizeGroup(g)
+			if !config.Digest {
+				fmt.Print(i18n.T("🧬 Same %s (%s)\n", method, formatBytes(g.Size)))
+				for _, f := range g.Files {
+					fmt.Printf("  📄 %s (%s)\n", f.Name, formatBytes(f.Size))
+				}
+				fmt.Println()
+			}
+			results = append(results, g)
+		}
+		if found > 0 {
+			fmt.Print(i18n.T("📊 Found %d groups with the same %s\n", found, method))
+		}
+	}
+	return results
+}
+
+// analyzeCompressedPayloads finds compressed archives holding the same decompressed payload
+// ("backup.tar.gz" and "backup.tar.zst"), which differ in size and escape the size groups
+func analyzeCompressedPayloads(files []scanner.ArchiveFile, config Config, cache *db.Cache, report *reporter.Report) []reporter.SizeGroup {
+	compressed := 0
+	for _, f := range files {
+		if archive.IsCompressed(f.Path) {
+			compressed++
+		}
+	}
+	if compressed < 2 {
+		return nil
+	}
+
+	tracker := cliTracker("🗜️  Decompressing", int64(compressed), report, progress.PhasePayload, !config.Digest)
+	groups := hashing.GroupByPayload(cache, files, func(done int) {
+		tracker.Set(int64(done))
+	})
+	tracker.Finish()
+	fmt.Println()
+
+	var results []reporter.SizeGroup
+	for _, g := range groups {
+		group := reporter.SizeGroup{Size: g.PayloadSize, Payload: true}
+		if !config.Digest {
+			fmt.Print(i18n.T("🗜️  Same decompressed contents (%s)\n", formatBytes(g.PayloadSize)))
+		}
+		for _, f := range g.Files {
+			if !config.Digest {
+				fmt.Printf("  📄 %s (%s)\n", f.Name, formatBytes(f.Size))
+			}
+			group.Files = append(group.Files, reporter.NewFileInfo(f))
+		}
+		if !config.Digest {
+			fmt.Println()
+		}
+		config.Events.SizeGroup(group)
+		results = append(results, group)
+	}
+	if len(results) > 0 {
+		fmt.Print(i18n.T("📊 Found %d groups of compressed archives with the same payload\n", len(results)))
 	}
 	return results
 }
 
+// suppressedIn reports whether paths are copies of one content suppressed in the cache, for
+// reporter.Suppressed
+func suppressedIn(cache *db.Cache) func(paths []string) bool {
+	return func(paths []string) bool { return hashing.IsSuppressed(cache, paths) }
+}
+
+// cliTracker tracks a phase of the report and, when show is set, draws its progress bar with ETA
+func cliTracker(label string, totalBytes int64, report *reporter.Report, phase string, show bool) *progress.Tracker {
+	var mu sync.Mutex
+	lastPrint := time.Time{}
+	return progress.New(totalBytes, func(p reporter.PhaseProgress) {
+		mu.Lock()
+		defer mu.Unlock()
+		report.SetPhase(phase, p)
+		report.Progress = p.Progress
+		if show && (p.Finished || time.Since(lastPrint) > 100*time.Millisecond) {
+			lastPrint = time.Now()
+			fmt.Printf("\r%s: %s   ", i18n.T(label), progress.Bar(p))
+		}
+	})
+}
+
+func totalSize(files []scanner.ArchiveFile) int64 {
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	return total
+}
+
+// confirmEstimate prints the projected cost of the run and, when Step 3 is about to start from a
+// terminal and exceeds the -confirm-above limit, asks the user whether to continue
+func confirmEstimate(e estimate.Estimate, runsStep3Now bool, config Config) bool {
+	log.Print(i18n.T("📊 Estimate: %d archives, %s total", e.Archives, formatBytes(e.TotalBytes)))
+	fmt.Print(i18n.T("  • Step 3 (similar names): %s\n", estimate.FormatDuration(e.Step3())))
+	fmt.Print(i18n.T("  • Visual analysis: %s (%d files not yet fingerprinted)\n", estimate.FormatDuration(e.Visual()), e.VisualPending))
+	if !e.Benchmarked {
+		fmt.Println(i18n.T("  ℹ️  Based on default rates; estimates are calibrated after the first run"))
+	}
+	fmt.Println()
+
+	if !runsStep3Now || config.ConfirmAbove <= 0 || e.Step3() <= config.ConfirmAbove {
+		return true
+	}
+	return confirmLong(i18n.T("⚠️  Step 3 is projected to take %s (limit: %v). Continue? (y/N): ", estimate.FormatDuration(e.Step3()), config.ConfirmAbove))
+}
+
+// confirmLong asks the question about a long analysis from a terminal and reports the answer
+func confirmLong(question string) bool {
+	// Unattended runs (pipes, schedulers) cannot answer; let them proceed
+	if stat, err := os.Stdin.Stat(); err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return true
+	}
+
+	fmt.Print(question)
+	var response string
+	fmt.Scanln(&response)
+	return i18n.IsYes(response)
+}
+
+// verifyArchives runs the integrity check and splits files into readable ones and corrupt archives
+func verifyArchives(files []scanner.ArchiveFile, config Config, report *reporter.Report) ([]scanner.ArchiveFile, []reporter.CorruptFile) {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	sizes := make(map[string]int64, len(files))
+	var totalBytes int64
+	for _, f := range files {
+		sizes[f.Path] = f.Size
+		totalBytes += f.Size
+	}
+	tracker := cliTracker("🩺 Integrity", totalBytes, report, progress.PhaseVerify, true)
+	failed := archive.VerifyArchives(paths, func(path string) {
+		tracker.Add(sizes[path])
+	})
+	tracker.Finish()
+	fmt.Println()
+
+	var healthy []scanner.ArchiveFile
+	var corrupt []reporter.CorruptFile
+	for _, f := range files {
+		err, bad := failed[f.Path]
+		if !bad {
+			healthy = append(healthy, f)
+			continue
+		}
+		corrupt = append(corrupt, reporter.CorruptFile{
+			FileInfo: reporter.NewFileInfo(f),
+			Error:    err.Error(),
+		})
+	}
+
+	if len(corrupt) == 0 {
+		fmt.Println(i18n.T("✅ All archives passed the integrity check"))
+	} else {
+		fmt.Print(i18n.T("💔 Found %d unreadable/corrupt archives (excluded from duplicate analysis):\n", len(corrupt)))
+		for _, c := range corrupt {
+			fmt.Printf("  • %s (%s)\n", c.Path, formatBytes(c.Size))
+			if config.Verbose {
+				fmt.Printf("    ↳ %s\n", c.Error)
+			}
+		}
+	}
+	fmt.Println()
+	return healthy, corrupt
+}
+
+// printClusterMetrics summarizes the quality of the Step 3 clusters and the suggested threshold
+func printClusterMetrics(m reporter.ClusterMetrics) {
+	if c := m.Cache; c != nil && c.Hit {
+		log.Print(i18n.T("♻️  Similarity cache hit: no file changed, %d clusters reused", c.Reused))
+	} else if c != nil {
+		log.Print(i18n.T("♻️  Similarity cache: %d clusters reused, %d rebuilt (%d files changed)", c.Reused, c.Rebuilt, c.ChangedFiles))
+	}
+	if m.Clusters == 0 {
+		return
+	}
+	log.Print(i18n.T("📐 Cluster quality: cohesion %.1f%%, separation %.1f%%, silhouette %.2f", m.Cohesion, m.Separation, m.Silhouette))
+	if m.SuggestedThreshold > 0 {
+		log.Print(i18n.T("💡 %s; try -threshold %d", m.Advice, m.SuggestedThreshold))
+	}
+}
+
+// runThresholdSweep generates Step 3 candidates once and prints the clusters found at each threshold
+func runThresholdSweep(files []scanner.ArchiveFile, config Config, cache *db.Cache) {
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	log.Print(i18n.T("📐 Threshold sweep: %s", config.Sweep))
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	onProgress := func(p float64) {
+		fmt.Print(i18n.T("\r🔍 Sweeping: [%-20s] %.1f%%", strings.Repeat("=", int(p/5)), p))
+	}
+	results := similarity.SweepThresholds(files, similarity.Options{
+		Debug:          config.Debug,
+		Phonetic:       config.Phonetic,
+		Cache:          cache,
+		Scorers:        config.Scorers,
+		MaxClusterSize: config.MaxCluster,
+	}, config.SweepValues, onProgress)
+	fmt.Println()
+	fmt.Println()
+
+	fmt.Printf("  %-10s %-8s %-8s %s\n", i18n.T("Threshold"), i18n.T("Groups"), i18n.T("Files"), i18n.T("Flagged"))
+	for _, r := range results {
+		fmt.Printf("  %-10s %-8d %-8d %s\n", fmt.Sprintf("%d%%", r.Threshold), r.Groups, r.Files, formatBytes(r.FlaggedBytes))
+	}
+	fmt.Println()
+	fmt.Println(i18n.T("ℹ️  Flagged = bytes in each cluster except its largest file."))
+}
+
 func compareSTLContents(contents1, contents2 map[string][]byte, verbose bool) {
 	// Find common files
 	allFiles := make(map[string]bool)
@@ -539,19 +1536,19 @@ func compareSTLContents(contents1, contents2 map[string][]byte, verbose bool) {
 		data2, exists2 := contents2[filename]
 
 		if !exists1 {
-			fmt.Printf("    ❌ %s - ONLY IN ARCHIVE 2\n", filename)
+			fmt.Print(i18n.T("    ❌ %s - ONLY IN ARCHIVE 2\n", filename))
 			continue
 		}
 
 		if !exists2 {
-			fmt.Printf("    ❌ %s - ONLY IN ARCHIVE 1\n", filename)
+			fmt.Print(i18n.T("    ❌ %s - ONLY IN ARCHIVE 1\n", filename))
 			continue
 		}
 
 		// Check if it's an STL file
 		if !stl.IsSTLFile(filename) {
 			if verbose {
-				fmt.Printf("    ℹ️  %s - Not an STL file (skipped)\n", filename)
+				fmt.Print(i18n.T("    ℹ️  %s - Not an STL file (skipped)\n", filename))
 			}
 			continue
 		}
@@ -560,49 +1557,74 @@ func compareSTLContents(contents1, contents2 map[string][]byte, verbose bool) {
 		identical, diff := stl.CompareSTL(data1, data2)
 
 		if identical {
-			fmt.Printf("    ✅ %s - IDENTICAL\n", filename)
+			fmt.Print(i18n.T("    ✅ %s - IDENTICAL\n", filename))
 		} else {
-			fmt.Printf("    ⚠️  %s - MODIFIED\n", filename)
+			fmt.Print(i18n.T("    ⚠️  %s - MODIFIED\n", filename))
 			if verbose && diff != nil {
-				fmt.Printf("       • Vertices: %d → %d (%+d)\n",
-					diff.Vertices1, diff.Vertices2, diff.Vertices2-diff.Vertices1)
-				fmt.Printf("       • Triangles: %d → %d (%+d)\n",
-					diff.Triangles1, diff.Triangles2, diff.Triangles2-diff.Triangles1)
+				fmt.Print(i18n.T("       • Vertices: %d → %d (%+d)\n",
+					diff.Vertices1, diff.Vertices2, diff.Vertices2-diff.Vertices1))
+				fmt.Print(i18n.T("       • Triangles: %d → %d (%+d)\n",
+					diff.Triangles1, diff.Triangles2, diff.Triangles2-diff.Triangles1))
 				if diff.Description != "" {
-					fmt.Printf("       • Changes: %s\n", diff.Description)
+					fmt.Print(i18n.T("       • Changes: %s\n", diff.Description))
 				}
 			}
 		}
 	}
 }
 
-func handleCleanup(f1, f2 scanner.ArchiveFile, config Config) {
-	// Skip if either file is a multi-volume part (part1, part2, etc.)
-	if isMultiVolumePart(f1.Name) || isMultiVolumePart(f2.Name) {
+// handleCleanup removes one of two duplicates; match carries the group hash and name similarity
+// that found them, for the reference note
+func handleCleanup(f1, f2 scanner.ArchiveFile, match refnote.Note, config Config, cache *db.Cache) {
+	// Skip if either file is a stray multi-volume part (part1, part2, etc.)
+	// Complete sets are collapsed into a single entry and handled as a whole
+	if isStrayVolume(f1) || isStrayVolume(f2) {
 		if config.Verbose {
-			fmt.Printf("  ℹ️  Skipping cleanup: Multi-volume parts detected (%s or %s)\n", f1.Name, f2.Name)
+			fmt.Print(i18n.T("  ℹ️  Skipping cleanup: Multi-volume parts detected (%s or %s)\n", f1.Name, f2.Name))
+		}
+		return
+	}
+
+	// Protected files anchor the group: they are kept, and only an unprotected copy can go
+	protected1, protected2 := isProtected(f1, config), isProtected(f2, config)
+	if protected1 && protected2 {
+		if config.Verbose {
+			fmt.Print(i18n.T("  🛡️  Skipping cleanup: both files are protected (%s, %s)\n", f1.Name, f2.Name))
 		}
 		return
 	}
 
 	if config.Interactive {
-		fmt.Printf("  🤔 Interactive choice Required:\n")
-		fmt.Printf("     [1] Delete: %s (%s, %v)\n", f1.Name, formatBytes(f1.Size), f1.ModTime.Format("2006-01-02"))
-		fmt.Printf("     [2] Delete: %s (%s, %v)\n", f2.Name, formatBytes(f2.Size), f2.ModTime.Format("2006-01-02"))
-		fmt.Printf("     [k] Keep both files\n")
-		fmt.Printf("     Choice (1/2/k): ")
+		fmt.Print(i18n.T("  🤔 Interactive choice Required:\n"))
+		for i, f := range []scanner.ArchiveFile{f1, f2} {
+			if isProtected(f, config) {
+				fmt.Print(i18n.T("     [%d] 🛡️  Protected: %s (%s, %v)\n", i+1, f.Name, formatBytes(f.Size), f.ModTime.Format("2006-01-02")))
+			} else {
+				fmt.Print(i18n.T("     [%d] Delete: %s (%s, %v)\n", i+1, f.Name, formatBytes(f.Size), f.ModTime.Format("2006-01-02")))
+			}
+		}
+		fmt.Print(i18n.T("     [k] Keep both files\n"))
+		fmt.Print(i18n.T("     Choice (1/2/k): "))
 
 		var choice string
 		fmt.Scanln(&choice)
 		switch strings.ToLower(choice) {
 		case "1":
-			performFileAction(f1, f2, config)
+			if protected1 {
+				fmt.Println(i18n.T("     🛡️  Protected file, keeping both."))
+				return
+			}
+			performFileAction(f1, f2, match, config, cache)
 		case "2":
-			performFileAction(f2, f1, config)
+			if protected2 {
+				fmt.Println(i18n.T("     🛡️  Protected file, keeping both."))
+				return
+			}
+			performFileAction(f2, f1, match, config, cache)
 		case "k":
-			fmt.Println("     ✅ Keeping both files.")
+			fmt.Println(i18n.T("     ✅ Keeping both files."))
 		default:
-			fmt.Println("     ⏭️  Skipping (invalid choice)")
+			fmt.Println(i18n.T("     ⏭️  Skipping (invalid choice)"))
 		}
 		return
 	}
@@ -619,7 +1641,7 @@ func handleCleanup(f1, f2 scanner.ArchiveFile, config Config) {
 			reason = fmt.Sprintf("is older (%v < %v)", f2.ModTime.Format("2006-01-02"), f1.ModTime.Format("2006-01-02"))
 		}
 	} else if config.DeleteMode == "contents" {
-		// Least contents: smaller FileCount or smaller Size
+		// Least contents: smaller FileCount, then smaller uncompressed size, then smaller Size
 		if f1.FileCount > 0 && f2.FileCount > 0 {
 			if f1.FileCount < f2.FileCount {
 				toDelete = f1
@@ -630,7 +1652,17 @@ func handleCleanup(f1, f2 scanner.ArchiveFile, config Config) {
 			}
 		}
 
-		// If FileCount is same or not available, check size
+		if toDelete.Path == "" && f1.UncompressedSize > 0 && f2.UncompressedSize > 0 {
+			if f1.UncompressedSize < f2.UncompressedSize {
+				toDelete = f1
+				reason = fmt.Sprintf("holds less data (%s < %s)", formatBytes(f1.UncompressedSize), formatBytes(f2.UncompressedSize))
+			} else if f2.UncompressedSize < f1.UncompressedSize {
+				toDelete = f2
+				reason = fmt.Sprintf("holds less data (%s < %s)", formatBytes(f2.UncompressedSize), formatBytes(f1.UncompressedSize))
+			}
+		}
+
+		// If the contents are the same or not available, check size
 		if toDelete.Path == "" {
 			if f1.Size < f2.Size {
 				toDelete = f1
@@ -642,12 +1674,19 @@ func handleCleanup(f1, f2 scanner.ArchiveFile, config Config) {
 		}
 	}
 
+	// The delete mode never overrules a protection
+	if protected1 {
+		toDelete, reason = f2, fmt.Sprintf("duplicates protected file %s", f1.Name)
+	} else if protected2 {
+		toDelete, reason = f1, fmt.Sprintf("duplicates protected file %s", f2.Name)
+	}
+
 	if toDelete.Path == "" {
-		fmt.Println("  ℹ️  No clear candidate for deletion.")
+		fmt.Println(i18n.T("  ℹ️  No clear candidate for deletion."))
 		return
 	}
 
-	fmt.Printf("  🗑️  Candidate for deletion: %s (%s)\n", toDelete.Name, reason)
+	fmt.Print(i18n.T("  🗑️  Candidate for deletion: %s (%s)\n", toDelete.Name, reason))
 
 	// Identify preserved file
 	preserved := f1
@@ -656,62 +1695,228 @@ func handleCleanup(f1, f2 scanner.ArchiveFile, config Config) {
 	}
 
 	if config.AutoDelete {
-		performFileAction(toDelete, preserved, config)
+		performFileAction(toDelete, preserved, match, config, cache)
 	} else {
-		fmt.Printf("     Delete/Move this file? (y/N): ")
+		fmt.Print(i18n.T("     Delete/Move this file? (y/N): "))
 		var response string
 		fmt.Scanln(&response)
-		if strings.ToLower(response) == "y" {
-			performFileAction(toDelete, preserved, config)
+		if i18n.IsYes(response) {
+			performFileAction(toDelete, preserved, match, config, cache)
 		}
 	}
 }
 
-func performFileAction(target, preserved scanner.ArchiveFile, config Config) {
-	if config.TrashPath != "" {
-		// Ensure trash directory exists
-		if _, err := os.Stat(config.TrashPath); os.IsNotExist(err) {
-			os.MkdirAll(config.TrashPath, 0755)
-		}
+// performFileAction removes target, a duplicate of preserved found as described by match
+func performFileAction(target, preserved scanner.ArchiveFile, match refnote.Note, config Config, cache *db.Cache) {
+	if isProtected(target, config) {
+		fmt.Print(i18n.T("     🛡️  Refusing to remove protected file: %s\n", target.Path))
+		return
+	}
+
+	// A file edited, replaced or re-downloaded since the scan is no longer the duplicate it was
+	if err := hashing.Recheck(cache, config.Recheck, target.AllPaths(), target.Size, target.ModTime); err != nil {
+		fmt.Print(i18n.T("     ⚠️  %v, file kept\n", err))
+		return
+	}
+	removal := hooks.Event{Event: hooks.EventPreDelete, Run: config.CleanupRun, Path: target.Path, Paths: target.AllPaths(), Size: target.Size, Kept: preserved.Path}
+	if err := hooks.Run(removal); err != nil {
+		fmt.Print(i18n.T("     ⚠️  Kept by the pre_delete hook: %v\n", err))
+		return
+	}
 
-		destPath := filepath.Join(config.TrashPath, target.Name)
-		err := os.Rename(target.Path, destPath)
+	// Unattended decisions journal the hash of the kept copy so a sample can be re-verified later
+	auto := config.AutoDelete && !config.Interactive
+	keptHash := ""
+	if auto {
+		hash, err := hashing.FileHash(cache, preserved.Path)
 		if err != nil {
-			fmt.Printf("     ❌ Error moving to trash: %v (Attempting delete instead)\n", err)
-			deleteFile(target.Path)
-		} else {
-			fmt.Printf("     ✅ Moved to trash: %s\n", destPath)
+			fmt.Print(i18n.T("     ❌ Could not read the kept file, skipping: %v\n", err))
+			noteFailure()
+			return
 		}
-	} else {
-		deleteFile(target.Path)
+		keptHash = hash
 	}
 
-	// Create reference link if requested
+	// The note is filled in before the duplicate is gone, while its hash can still be read
+	note := match
+	note.Removed, note.RemovedSize, note.Kept, note.KeptSize, note.Action = target.Path, target.Size, preserved.Path, preserved.Size, "deleted"
 	if config.LeaveRef {
-		refPath := target.Path + ".duplicate.txt"
-		content := fmt.Sprintf("Archive Duplicate Finder\n-----------------------\nAction: Removed as duplicate\nDate: %s\nOriginal kept: %s\nOriginal size: %s\n",
-			time.Now().Format("2006-01-02 15:04:05"),
-			preserved.Path,
-			formatBytes(preserved.Size))
+		note.KeptSHA256 = keptHash
+		if note.KeptSHA256 == "" {
+			note.KeptSHA256, _ = hashing.FileHash(cache, preserved.Path)
+		}
+		note.RemovedSHA256, _ = hashing.FileHash(cache, target.Path)
+	}
+
+	// Multi-volume sets are removed as a whole: when a part cannot go, the parts already
+	// removed are put back
+	tx := txn.New(func(p string) (string, error) { return hashing.FileHash(cache, p) })
+	var entries []*journal.Entry
+	for _, path := range target.AllPaths() {
+		entry := &journal.Entry{Run: config.CleanupRun, Action: journal.ActionDelete, Path: path, Kept: preserved.Path, KeptSHA256: keptHash, Auto: auto}
+		if info, err := os.Stat(path); err == nil {
+			entry.Size = info.Size()
+		}
+		entry.SHA256, _ = hashing.FileHash(cache, path)
+		entries = append(entries, entry)
+		tx.Require(path, "")
+		var undo func() error
+		switch {
+		case config.QuarantinePath != "":
+			tx.Step(func() error {
+				item, err := quarantine.Move(config.QuarantinePath, config.CleanupRun, path, quarantine.Item{Size: entry.Size, SHA256: entry.SHA256, Kept: preserved.Path}, copyProgress)
+				if err != nil {
+					return fmt.Errorf("could not move %s to quarantine: %w", path, err)
+				}
+				fmt.Print(i18n.T("     ✅ Quarantined: %s\n", item.Path))
+				entry.Action, entry.Dest = journal.ActionQuarantine, item.Path
+				if note.Trash == "" {
+					note.Action, note.Trash = "quarantined", item.Path
+				}
+				undo = func() error {
+					_, err := quarantine.Restore(config.QuarantinePath, []string{item.ID})
+					return err
+				}
+				return nil
+			}, func() error { return undo() })
+		case config.TrashPath != "":
+			tx.Step(func() error {
+				destPath, err := trash.Move(config.TrashPath, path, copyProgress)
+				if err != nil && !config.TrashOrDelete {
+					return fmt.Errorf("could not move %s to the trash: %w", path, err)
+				} else if err != nil {
+					fmt.Print(i18n.T("     ❌ Error moving to trash: %v (Attempting delete instead)\n", err))
+					if undo, err = tx.Stage(path); err != nil {
+						return err
+					}
+					fmt.Println(i18n.T("     ✅ File deleted successfully."))
+					return nil
+				}
+				fmt.Print(i18n.T("     ✅ Moved to trash: %s\n", destPath))
+				entry.Action, entry.Dest = journal.ActionTrash, destPath
+				if note.Trash == "" {
+					note.Action, note.Trash = "trashed", destPath
+				}
+				undo = func() error { return fsutil.MoveFile(destPath, path, nil) }
+				return nil
+			}, func() error { return undo() })
+		default:
+			tx.Step(func() (err error) {
+				if undo, err = tx.Stage(path); err != nil {
+					return err
+				}
+				fmt.Println(i18n.T("     ✅ File deleted successfully."))
+				return nil
+			}, func() error { return undo() })
+		}
+	}
+	failed := false
+	if err := tx.Run(); err != nil {
+		fmt.Print(i18n.T("     ❌ Archive kept: %v\n", err))
+		noteFailure()
+		failed = true
+	} else {
+		for _, entry := range entries {
+			if err := journal.Append(*entry); err != nil {
+				fmt.Print(i18n.T("     ⚠️  Could not write the cleanup journal: %v\n", err))
+			}
+		}
+		hooks.Run(removal.PostDelete(entries))
+	}
 
-		err := os.WriteFile(refPath, []byte(content), 0644)
+	// Create reference link if requested
+	if config.LeaveRef && !failed {
+		note.Date = time.Now()
+		refPath, err := refnote.Write(note, config.RefFormat, config.RefTemplate)
 		if err != nil {
-			fmt.Printf("     ⚠️  Could not create reference file: %v\n", err)
+			fmt.Print(i18n.T("     ⚠️  Could not create reference file: %v\n", err))
 		} else {
-			fmt.Printf("     📝 Reference note created: %s\n", filepath.Base(refPath))
+			fmt.Print(i18n.T("     📝 Reference note created: %s\n", filepath.Base(refPath)))
 		}
 	}
 }
 
-func deleteFile(path string) {
-	err := os.Remove(path)
+// isProtected reports whether any part of an archive matches a protection rule
+func isProtected(f scanner.ArchiveFile, config Config) bool {
+	for _, path := range f.AllPaths() {
+		if config.Protected.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// emptyTrash applies the trash retention before new files are moved there
+func emptyTrash(dir string, days int) {
+	res, err := trash.Purge(dir, days)
 	if err != nil {
-		fmt.Printf("     ❌ Error deleting file: %v\n", err)
+		log.Print(i18n.T("⚠️  Could not empty old trash folders: %v", err))
+	}
+	if res.Folders > 0 {
+		log.Print(i18n.T("🧹 Emptied %d trash folders older than %d days (%d files, %s)", res.Folders, days, res.Files, formatBytes(res.Bytes)))
+	}
+}
+
+// recoverStaged puts back the files a group resolved by a run that never ended left set aside
+// in dir
+func recoverStaged(dir string, recursive bool) {
+	if vfs.IsRemote(dir) {
+		return
+	}
+	restored, stuck := txn.Recover(dir, recursive, time.Hour)
+	for _, p := range restored {
+		log.Print(i18n.T("♻️  Put back %s, set aside by a cleanup that did not finish", p))
+	}
+	for _, err := range stuck {
+		log.Printf("⚠️  %v", err)
+	}
+}
+
+// copyProgress shows the copy of a file into a trash on another filesystem
+func copyProgress(done, total int64) {
+	fmt.Print(i18n.T("\r     📦 Copying to trash: %s / %s", formatBytes(done), formatBytes(total)))
+	if done >= total {
+		fmt.Println()
+	}
+}
+
+// verifyCleanupSample re-reads the kept file of a random sample of this run's unattended
+// decisions and checks it against the hash journaled when the duplicate was removed
+func verifyCleanupSample(config Config) {
+	results, err := journal.VerifySample(config.CleanupRun, config.VerifySample)
+	if err != nil {
+		log.Print(i18n.T("⚠️  Sample verification skipped: %v", err))
+		return
+	}
+	if len(results) == 0 {
+		return
+	}
+
+	failed := 0
+	for _, r := range results {
+		switch r.Result {
+		case journal.ResultOK:
+			if config.Verbose {
+				log.Print(i18n.T("  ✅ Verified: %s", r.Kept))
+			}
+		default:
+			failed++
+			log.Print(i18n.T("  ❌ Verification %s: %s (%s)", r.Result, r.Kept, r.Detail))
+		}
+	}
+	if failed > 0 {
+		log.Print(i18n.T("❌ Sample verification: %d of %d kept files do not match the journal (%s)", failed, len(results), journal.Path()))
+		noteFailure()
 	} else {
-		fmt.Println("     ✅ File deleted successfully.")
+		log.Print(i18n.T("🔬 Sample verification: %d kept files re-read byte for byte, all match the journal", len(results)))
 	}
 }
 
+// isStrayVolume reports whether f is a single part of a split archive whose set was not found complete
+func isStrayVolume(f scanner.ArchiveFile) bool {
+	return len(f.Volumes) == 0 && isMultiVolumePart(f.Name)
+}
+
 func isMultiVolumePart(filename string) bool {
 	filename = strings.ToLower(filename)
 
@@ -766,6 +1971,6 @@ func openBrowser(url string) {
 		err = fmt.Errorf("unsupported platform")
 	}
 	if err != nil {
-		log.Printf("⚠️  Could not open browser: %v", err)
+		log.Print(i18n.T("⚠️  Could not open browser: %v", err))
 	}
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"archive-duplicate-finder/internal/paths"
+)
+
+// runManCommand handles `finder man`: the manual page in roff, for
+// `finder man > /usr/local/share/man/man1/finder.1` or `finder man | man -l -`
+func runManCommand(args []string) {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: finder man > finder.1")
+		os.Exit(2)
+	}
+	writeManPage(os.Stdout, programName())
+}
+
+// writeManPage writes the manual page. It has no date so that packaged copies are reproducible.
+func writeManPage(w io.Writer, prog string) {
+	upper := strings.ToUpper(prog)
+	fmt.Fprintf(w, ".TH %s 1 \"\" \"%s\" \"User Commands\"\n", roff(upper), roff(prog))
+
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintf(w, "%s \\- find duplicate and similar archives (ZIP, RAR, 7Z, CBZ...) and clean them up\n", roff(prog))
+
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B %s\n[\\fB\\-dir\\fR \\fIfolder\\fR] [\\fIflags\\fR]\n", roff(prog))
+	for _, c := range commands {
+		fmt.Fprintf(w, ".br\n.B %s %s\n", roff(prog), roff(c.name))
+		if c.usage != "" {
+			fmt.Fprintln(w, roff(c.usage))
+		}
+	}
+
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintf(w, "%s scans a folder (local, SMB, SFTP, WebDAV or S3) for archives with the same or similar contents and names, ", roff(prog))
+	fmt.Fprintln(w, "reports the duplicate groups in the terminal, as JSON, PDF or a cleanup script, and can move the copies to a trash folder or delete them.")
+	fmt.Fprintln(w, ".PP")
+	fmt.Fprintln(w, "Without flags it opens the web dashboard on the saved settings, or its setup wizard on a first run.")
+	fmt.Fprintln(w, "Flags override the settings file for that run.")
+
+	fmt.Fprintln(w, ".SH OPTIONS")
+	manFlags(w, scanFlagSet())
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, c := range commands {
+		fmt.Fprintf(w, ".SS %s\n", roff(c.name))
+		fmt.Fprintln(w, roff(c.summary)+".")
+		switch {
+		case c.flags == nil && len(c.subs) > 0:
+			fmt.Fprintln(w, ".PP")
+			fmt.Fprintf(w, "One of: %s.\n", roff(strings.Join(c.subs, ", ")))
+		case len(c.subs) > 0:
+			for _, sub := range c.subs {
+				fmt.Fprintf(w, ".TP\n.B %s %s\n", roff(c.name), roff(sub))
+				if fs := c.flags(sub); flagCount(fs) > 0 {
+					fmt.Fprintln(w, ".RS")
+					manFlags(w, fs)
+					fmt.Fprintln(w, ".RE")
+				}
+			}
+		case c.flags != nil:
+			manFlags(w, c.flags(""))
+		}
+	}
+
+	fmt.Fprintln(w, ".SH EXIT STATUS")
+	for _, status := range []struct {
+		code int
+		text string
+	}{
+		{exitClean, "No duplicates found."},
+		{exitDuplicates, "Duplicate groups found."},
+		{exitErrors, "The scan failed, or a cleanup action or report could not be completed."},
+		{exitInvalidConfig, "Invalid flags or settings."},
+	} {
+		fmt.Fprintf(w, ".TP\n.B %d\n%s\n", status.code, roff(status.text))
+	}
+
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	for _, env := range [][2]string{
+		{"ADF_CONFIG", "Settings file to use instead of the default one (JSON, YAML or TOML by extension)."},
+		{"ADF_<KEY>", "Overrides the setting <key> of the settings file, e.g. ADF_THRESHOLD_NAME=80 or ADF_TRASH_PATH=/srv/trash."},
+		{"XDG_CONFIG_HOME, XDG_DATA_HOME, XDG_CACHE_HOME", "Base folders of the settings, the cache database and journal, and the previews."},
+		{"LANG, LC_ALL", "Language of the messages when the settings do not choose one."},
+	} {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roff(env[0]), roff(env[1]))
+	}
+
+	fmt.Fprintln(w, ".SH FILES")
+	for _, file := range [][2]string{
+		{paths.Settings(), "Settings."},
+		{paths.Cache(), "Cache database: archive hashes, ignored groups and the operations log."},
+		{paths.Journal(), "Journal of the moves and deletions, for undo."},
+		{paths.CacheDir(), "Extracted previews; can be deleted at any time."},
+	} {
+		fmt.Fprintf(w, ".TP\n.I %s\n%s\n", roff(file[0]), roff(file[1]))
+	}
+
+	fmt.Fprintln(w, ".SH SEE ALSO")
+	fmt.Fprintf(w, "%s completion bash|zsh|fish|powershell\n", roff(prog))
+}
+
+// manFlags writes the flags of fs as a tagged list, with the value names of their usage
+func manFlags(w io.Writer, fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		fmt.Fprintf(w, ".TP\n.B \\-%s", roff(f.Name))
+		if name != "" {
+			fmt.Fprintf(w, " \\fI%s\\fR", roff(name))
+		}
+		fmt.Fprintln(w)
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w, roff(usage))
+	})
+}
+
+func flagCount(fs *flag.FlagSet) int {
+	n := 0
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n
+}
+
+// roff escapes text for a man page: backslashes, hyphens (which would otherwise print as
+// typographic dashes) and a leading dot or quote, which roff would read as a request
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/manifest"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+)
+
+// manifestOptions are the flags of `finder manifest`
+type manifestOptions struct {
+	format string
+	file   string
+	verify bool
+}
+
+// manifestFlags defines the flags of `finder manifest` on a new flag set, storing their values in o
+func manifestFlags(o *manifestOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	fs.StringVar(&o.format, "format", manifest.FormatSHA256, "Checksum format: "+strings.Join(manifest.Formats, ", "))
+	fs.StringVar(&o.file, "file", "", "Manifest file; listed paths are relative to its folder (default: SHA256SUMS, MD5SUMS or checksums.sfv in the folder)")
+	fs.BoolVar(&o.verify, "verify", false, "Only check the files listed in the manifest, without updating it")
+	return fs
+}
+
+// runManifestCommand handles `finder manifest`: list the checksum of every archive of a folder,
+// typically once a cleanup leaves only the kept copies. When the manifest already exists, its
+// files are read again and checked first, so later runs report archives that rotted or vanished.
+func runManifestCommand(args []string) {
+	var o manifestOptions
+	fs := manifestFlags(&o)
+	fs.Parse(args)
+	if fs.NArg() != 1 || !manifest.Valid(o.format) {
+		fmt.Fprintln(os.Stderr, "Usage: finder manifest [-format sha256|md5|sfv] [-file <manifest>] [-verify] <folder>")
+		fs.PrintDefaults()
+		exit(exitInvalidConfig)
+	}
+	dir := fs.Arg(0)
+	if vfs.IsRemote(dir) {
+		fatal(exitInvalidConfig, i18n.T("❌ Manifests are written for local folders only"))
+	}
+	file := o.file
+	if file == "" {
+		file = filepath.Join(dir, manifest.FileName(o.format))
+	}
+	root := filepath.Dir(file)
+
+	appConfig, _ := config.LoadConfig()
+	if appConfig == nil {
+		appConfig = config.Default()
+	}
+	archive.SetLimits(appConfig.ArchiveLimits())
+	workers := archive.GetLimits().Workers
+
+	// Checking the manifest already there
+	var entries []manifest.Entry
+	failed := false
+	if _, err := os.Stat(file); err == nil {
+		entries, err = manifest.Read(file, o.format)
+		if err != nil {
+			fatal(exitErrors, i18n.T("❌ Could not read manifest: %v", err))
+		}
+		log.Print(i18n.T("🔍 Verifying %d files of %s...", len(entries), file))
+		res := manifest.Check(root, o.format, entries, workers)
+		for _, p := range res.Failed {
+			fmt.Print(i18n.T("  ❌ FAILED   %s\n", p))
+		}
+		for _, p := range res.Missing {
+			fmt.Print(i18n.T("  ⚠️  MISSING  %s\n", p))
+		}
+		log.Print(i18n.T("🧾 %d verified, %d failed, %d missing", res.Verified, len(res.Failed), len(res.Missing)))
+		failed = len(res.Failed) > 0 || (o.verify && len(res.Missing) > 0)
+
+		// Files gone since (removed duplicates) leave the manifest; failed ones keep the
+		// checksum they had, so every later run reports them again
+		gone := make(map[string]bool, len(res.Missing))
+		for _, p := range res.Missing {
+			gone[p] = true
+		}
+		kept := entries[:0]
+		for _, e := range entries {
+			if !gone[e.Path] {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	} else if o.verify {
+		fatal(exitInvalidConfig, i18n.T("❌ Could not read manifest: %v", err))
+	}
+	if o.verify {
+		if failed {
+			exit(exitErrors)
+		}
+		return
+	}
+
+	// Adding the archives not listed yet
+	files, err := scanner.ScanDirectory(dir, true)
+	if err != nil {
+		fatal(exitErrors, i18n.T("❌ Error scanning directory: %v", err))
+	}
+	listed := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		listed[e.Path] = true
+	}
+	var added []string
+	for _, f := range files {
+		for _, p := range f.AllPaths() {
+			rel, err := filepath.Rel(root, p)
+			if err == nil && !listed[filepath.ToSlash(rel)] {
+				added = append(added, p)
+			}
+		}
+	}
+	sum := func(p string) (string, error) { return manifest.Sum(o.format, p) }
+	if o.format == manifest.FormatSHA256 {
+		// The hashes of the scan serve while the files are unchanged
+		useCache(appConfig.CacheDSN, false)
+		if cache, err := db.NewCache(); err == nil {
+			defer cache.Close()
+			sum = func(p string) (string, error) { return hashing.FileHash(cache, p) }
+		}
+	}
+	newEntries, unreadable := manifest.Add(root, added, workers, sum)
+	for _, p := range unreadable {
+		log.Print(i18n.T("⚠️  Could not read %s", p))
+	}
+	entries = append(entries, newEntries...)
+
+	if err := manifest.Write(file, o.format, entries); err != nil {
+		fatal(exitErrors, i18n.T("❌ Could not write manifest: %v", err))
+	}
+	log.Print(i18n.T("🧾 Manifest written: %s (%d files, %d new)", file, len(entries), len(newEntries)))
+	if failed || len(unreadable) > 0 {
+		exit(exitErrors)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/pkg/client"
+)
+
+// mcpOptions are the flags of `finder mcp`
+type mcpOptions struct {
+	url   string
+	token string
+}
+
+// mcpFlags defines the flags of `finder mcp` on a new flag set, storing their values in o
+func mcpFlags(o *mcpOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("mcp", flag.ExitOnError)
+	fs.StringVar(&o.url, "url", "", "Address of the running dashboard (default: http://localhost and the saved port)")
+	fs.StringVar(&o.token, "token", "", "Dashboard access token, when the dashboard asks for one")
+	return fs
+}
+
+// runMCPCommand handles `finder mcp`: a stdio bridge for assistants that start their MCP
+// servers as programs. Every line read on stdin is a message relayed to the assistant
+// endpoint of a running dashboard, and every answer is written as a line on stdout.
+func runMCPCommand(args []string) {
+	var o mcpOptions
+	fs := mcpFlags(&o)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: finder mcp [-url http://localhost:8080] [-token <token>]")
+		fs.PrintDefaults()
+		exit(exitInvalidConfig)
+	}
+	if o.url == "" {
+		appConfig, _ := config.LoadConfig()
+		if appConfig == nil {
+			appConfig = config.Default()
+		}
+		o.url = fmt.Sprintf("http://localhost:%d", appConfig.Port)
+	}
+
+	c := client.New(o.url, o.token)
+	c.Name = "assistant"
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 64<<10), 16<<20)
+	out := json.NewEncoder(os.Stdout)
+	for in.Scan() {
+		line := in.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		answer, err := c.MCP(context.Background(), json.RawMessage(line))
+		if err != nil {
+			// The assistant still gets an answer to its request, or it would wait forever
+			log.Printf("❌ %s: %v", o.url, err)
+			var msg struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if json.Unmarshal(line, &msg) != nil || len(msg.ID) == 0 {
+				continue
+			}
+			answer, _ = json.Marshal(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      msg.ID,
+				"error":   map[string]any{"code": -32603, "message": err.Error()},
+			})
+		}
+		if answer != nil {
+			out.Encode(answer)
+		}
+	}
+	if err := in.Err(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
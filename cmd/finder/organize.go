@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/organize"
+	"archive-duplicate-finder/internal/reporter"
+)
+
+// runOrganize moves the kept file of every resolved group into the canonical library and
+// removes or links the other copies. The plan is shown first and confirmed unless -yes is set.
+func runOrganize(report reporter.Report, config Config, cache *db.Cache) {
+	groups := reporter.ResolveGroups(report, config.DeleteMode)
+	if len(groups) == 0 {
+		log.Println(i18n.T("🗂️  Nothing to organize: no resolved duplicate groups"))
+		return
+	}
+
+	opts := organize.Options{
+		Root:      config.OrganizeDir,
+		Layout:    config.Layout,
+		Rest:      organize.RestDelete,
+		TrashPath: config.TrashPath,
+		Run:       config.CleanupRun,
+		Auto:      config.AutoDelete,
+	}
+	if config.Link {
+		opts.Rest = organize.RestLink
+	} else if config.TrashPath != "" {
+		opts.Rest = organize.RestTrash
+	}
+	if err := opts.Validate(); err != nil {
+		log.Print(i18n.T("❌ Cannot organize: %v", err))
+		noteFailure()
+		return
+	}
+
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	log.Print(i18n.T("🗂️  Move and merge: %d groups into %s", len(groups), config.OrganizeDir))
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	dry := opts
+	dry.DryRun = true
+	printOrganizeResults(organize.Apply(groups, dry, cache), opts.Rest, true)
+
+	if short := organize.Preflight(groups, opts); len(short) > 0 {
+		for _, s := range short {
+			log.Print(i18n.T("❌ Cannot organize: %v", s))
+		}
+		noteFailure()
+		return
+	}
+
+	if !config.AutoDelete {
+		fmt.Print(i18n.T("     Apply this plan? (y/N): "))
+		var response string
+		fmt.Scanln(&response)
+		if !i18n.IsYes(response) {
+			fmt.Println(i18n.T("     ⏭️  Library left untouched."))
+			return
+		}
+	}
+
+	results := organize.Apply(groups, opts, cache)
+	printOrganizeResults(results, opts.Rest, false)
+	if config.AutoDelete {
+		verifyCleanupSample(config)
+	}
+}
+
+func printOrganizeResults(results []organize.Result, rest string, planned bool) {
+	done := map[string]string{organize.RestDelete: i18n.T("deleted"), organize.RestTrash: i18n.T("moved to the trash"), organize.RestLink: i18n.T("replaced by links")}[rest]
+	moved, removed, failed := 0, 0, 0
+	for _, r := range results {
+		fmt.Printf("  📦 %s\n", r.Group)
+		if r.Dest != r.Kept {
+			fmt.Printf("     ➡️  %s\n        → %s\n", r.Kept, r.Dest)
+			moved++
+		} else {
+			fmt.Print(i18n.T("     📌 %s (stays)\n", r.Kept))
+		}
+		for _, p := range r.Removed {
+			fmt.Printf("     🗑️  %s (%s)\n", p, done)
+		}
+		removed += len(r.Removed)
+		for _, p := range r.Differs {
+			fmt.Print(i18n.T("     ⚠️  %s has different contents, left untouched\n", p))
+		}
+		if r.Error != "" {
+			fmt.Printf("     ❌ %s\n", r.Error)
+			noteFailure()
+			failed++
+		}
+	}
+	verb := i18n.T("Organized")
+	if planned {
+		verb = i18n.T("Planned")
+	}
+	fmt.Print(i18n.T("📊 %s: %d kept files moved, %d copies %s, %d groups with errors\n\n", verb, moved, removed, done, failed))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"archive-duplicate-finder/internal/tui"
+)
+
+// wantsPlainOutput decides on plain output: -plain when given, otherwise whenever stdout is
+// redirected to a file or a pipe (cron, schedulers, CI logs)
+func wantsPlainOutput(flagSet bool, plain bool) bool {
+	if flagSet {
+		return plain
+	}
+	return !tui.IsTerminal(os.Stdout)
+}
+
+// usePlainOutput filters stdout and the log through tui.PlainWriter: no emoji, no box drawing
+// and no carriage-return progress bars. flushOutput must be called before exiting.
+func usePlainOutput() {
+	log.SetOutput(tui.NewPlainWriter(os.Stderr))
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan struct{})
+	go func() {
+		io.Copy(tui.NewPlainWriter(stdout), r)
+		close(done)
+	}()
+	var once sync.Once
+	flushOutput = func() {
+		once.Do(func() {
+			os.Stdout = stdout
+			w.Close()
+			<-done
+		})
+	}
+}
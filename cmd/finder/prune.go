@@ -0,0 +1,293 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/protect"
+	"archive-duplicate-finder/internal/refnote"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+)
+
+// pruneOptions are the flags of `finder prune`
+type pruneOptions struct {
+	primary        string
+	backup         string
+	recursive      bool
+	trashPath      string
+	quarantinePath string
+	yes            bool
+	dryRun         bool
+	jsonFile       string
+	scriptFile     string
+	protectFlags   stringList
+}
+
+// pruneFlags defines the flags of `finder prune` on a new flag set, storing their values in o
+func pruneFlags(o *pruneOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	fs.StringVar(&o.primary, "primary", "", "Folder holding the originals; nothing in it is ever touched")
+	fs.StringVar(&o.backup, "backup", "", "Backup folder whose exact copies of primary archives are pruned")
+	fs.BoolVar(&o.recursive, "recursive", true, "Scan subdirectories recursively")
+	fs.StringVar(&o.trashPath, "trash", "", "Move pruned backup archives to this folder instead of deleting them")
+	fs.StringVar(&o.quarantinePath, "quarantine", "", "Quarantine pruned backup archives in this folder (see finder quarantine)")
+	fs.BoolVar(&o.yes, "yes", false, "Prune without asking for confirmation")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "List what would be pruned without touching anything")
+	fs.StringVar(&o.jsonFile, "json", "", "Write the matches as a JSON diff report")
+	fs.StringVar(&o.scriptFile, "script", "", "Write a script that prunes the backup copies (.sh or .ps1) instead of pruning them")
+	fs.Var(&o.protectFlags, "protect", "Never prune backup files matching this glob, or anything inside a matching folder (repeatable)")
+	return fs
+}
+
+// runPruneCommand handles `finder prune`: remove the backup archives that are exact copies of
+// archives in the primary folder. Unlike a scan, which may keep either copy of a group, the
+// two sides are not symmetric: only backup files are candidates, and each is read again and
+// compared with its primary right before it is removed.
+func runPruneCommand(args []string) {
+	var o pruneOptions
+	fs := pruneFlags(&o)
+	fs.Parse(args)
+	if o.primary == "" || o.backup == "" || fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: finder prune -primary <folder> -backup <folder> [-trash <folder> | -quarantine <folder>] [-yes] [-dry-run]")
+		fs.PrintDefaults()
+		exit(exitInvalidConfig)
+	}
+	if overlaps(o.primary, o.backup) {
+		fatal(exitInvalidConfig, i18n.T("❌ The primary and backup folders must not contain each other"))
+	}
+	if o.trashPath != "" && o.quarantinePath != "" {
+		fatal(exitInvalidConfig, i18n.T("❌ Use either -trash or -quarantine"))
+	}
+
+	appConfig, _ := config.LoadConfig()
+	if appConfig == nil {
+		appConfig = config.Default()
+	}
+	archive.SetLimits(appConfig.ArchiveLimits())
+	useCache(appConfig.CacheDSN, false)
+	vfs.SetS3Config(appConfig.S3)
+	vfs.SetSFTPConfig(appConfig.SFTP)
+	vfs.SetWebDAVConfig(appConfig.WebDAV)
+	protected, err := protect.New(append(append([]string(nil), appConfig.Protected...), o.protectFlags...))
+	if err != nil {
+		fatal(exitInvalidConfig, fmt.Sprintf("❌ %v", err))
+	}
+
+	cache, err := db.NewCache()
+	if err != nil {
+		log.Print(i18n.T("⚠️  Cache not available: %v", err))
+		cache = nil
+	} else {
+		defer cache.Close()
+	}
+
+	startTime := time.Now()
+	backupFiles := scanForDiff(o.backup, o.recursive, cache)
+	primaryFiles := scanForDiff(o.primary, o.recursive, cache)
+	log.Print(i18n.T("✅ Found %d archives in the backup and %d in the primary folder", len(backupFiles), len(primaryFiles)))
+
+	log.Println(i18n.T("🔍 Comparing sizes and content hashes..."))
+	diff := reporter.DiffReport{
+		Source:       o.backup,
+		Library:      o.primary,
+		SourceFiles:  len(backupFiles),
+		LibraryFiles: len(primaryFiles),
+		Matches:      []reporter.DiffMatch{},
+		Unique:       []reporter.FileInfo{},
+	}
+	type pair struct{ backup, primary scanner.ArchiveFile }
+	var pairs []pair
+	matchIdentical(backupFiles, primaryFiles, cache, func(b, p scanner.ArchiveFile) {
+		// A hard link of the primary copy frees nothing and would be the primary itself
+		if sameFile(b.Path, p.Path) {
+			return
+		}
+		info := reporter.NewFileInfo(b)
+		info.Protected = protected.Match(b.Path)
+		diff.Matches = append(diff.Matches, reporter.DiffMatch{Source: info, Library: reporter.NewFileInfo(p), Reason: reporter.MatchIdentical, Score: 100})
+		if !info.Protected {
+			diff.RedundantBytes += b.Size
+			pairs = append(pairs, pair{b, p})
+		}
+	})
+	diff.AnalysisDuration = time.Since(startTime).Seconds()
+	diff.Timestamp = time.Now().Format(time.RFC3339)
+
+	fmt.Println()
+	for _, m := range diff.Matches {
+		if m.Source.Protected {
+			fmt.Print(i18n.T("  🛡️  %s (protected, kept)\n", m.Source.Path))
+			continue
+		}
+		fmt.Printf("  🗑️  %s  (%s)\n      = %s\n", m.Source.Path, formatBytes(m.Source.Size), m.Library.Path)
+	}
+	fmt.Print(i18n.T("📊 %d backup archives are exact copies of primary ones (%s to reclaim)\n\n", len(pairs), formatBytes(diff.RedundantBytes)))
+
+	if o.jsonFile != "" {
+		if err := reporter.ExportDiffJSON(diff, o.jsonFile); err != nil {
+			log.Print(i18n.T("❌ Could not write JSON report: %v", err))
+			noteFailure()
+		} else {
+			log.Print(i18n.T("💾 Diff report exported to %s", o.jsonFile))
+		}
+	}
+	if o.scriptFile != "" {
+		err := reporter.ExportDiffScript(diff, o.scriptFile, reporter.ScriptOptions{
+			Shell:     reporter.ScriptShell(o.scriptFile),
+			TrashPath: o.trashPath,
+		})
+		if err != nil {
+			log.Print(i18n.T("❌ Could not write cleanup script: %v", err))
+			noteFailure()
+		} else {
+			log.Print(i18n.T("📜 Cleanup script written: %s (review it before running)", o.scriptFile))
+		}
+	}
+	if len(pairs) == 0 || o.dryRun || o.scriptFile != "" {
+		exitPrune()
+		return
+	}
+
+	// A trash or quarantine on another disk must take every pruned file
+	if dest := o.quarantinePath + o.trashPath; dest != "" {
+		var transfers []fsutil.Transfer
+		for _, p := range pairs {
+			for _, path := range p.backup.AllPaths() {
+				if info, err := os.Stat(path); err == nil {
+					transfers = append(transfers, fsutil.Transfer{Path: path, Dir: dest, Size: info.Size()})
+				}
+			}
+		}
+		if short := fsutil.Preflight(transfers); len(short) > 0 {
+			for _, s := range short {
+				log.Print(i18n.T("❌ Cannot prune: %v", s))
+			}
+			exit(exitErrors)
+		}
+	}
+
+	if !o.yes {
+		fmt.Print(i18n.T("     Prune these %d backup archives? (y/N): ", len(pairs)))
+		var response string
+		fmt.Scanln(&response)
+		if !i18n.IsYes(response) {
+			fmt.Println(i18n.T("     ⏭️  Backup left untouched."))
+			return
+		}
+	}
+
+	cfg := Config{
+		AutoDelete:     o.yes,
+		TrashPath:      o.trashPath,
+		QuarantinePath: o.quarantinePath,
+		CleanupRun:     journal.NewRun(),
+		Protected:      protected,
+	}
+	journal.SetOperationsLog(cache, appConfig.OperationsLog)
+	hooks.Set(appConfig.Hooks)
+	pruned, skipped := 0, 0
+	var freed int64
+	for _, p := range pairs {
+		fmt.Printf("  🗑️  %s\n", p.backup.Path)
+		// The cached hashes matched; both sides are read again before anything is removed
+		if !sameContents(p.backup, p.primary) {
+			fmt.Print(i18n.T("     ⚠️  No longer identical to %s, kept\n", p.primary.Path))
+			skipped++
+			continue
+		}
+		performFileAction(p.backup, p.primary, refnote.Note{}, cfg, cache)
+		if _, err := os.Stat(p.backup.Path); os.IsNotExist(err) {
+			pruned++
+			freed += p.backup.Size
+		}
+	}
+	fmt.Print(i18n.T("📊 Pruned %d backup archives (%s), %d skipped\n", pruned, formatBytes(freed), skipped))
+	exitPrune()
+}
+
+// exitPrune exits with exitErrors when an action failed
+func exitPrune() {
+	if failures.Load() > 0 {
+		exit(exitErrors)
+	}
+}
+
+// sameContents reads every volume of both archives whole, bypassing the cache
+func sameContents(a, b scanner.ArchiveFile) bool {
+	full := func(p string) (string, error) { return hashing.FileSHA256(p) }
+	ha, ok := diffContentHash(a, full)
+	if !ok {
+		return false
+	}
+	hb, ok := diffContentHash(b, full)
+	return ok && ha == hb
+}
+
+// overlaps reports whether one folder is the other or lies inside it, through symbolic links
+// and bind mounts too
+func overlaps(a, b string) bool {
+	if vfs.IsRemote(a) || vfs.IsRemote(b) {
+		return a == b || strings.HasPrefix(a, strings.TrimSuffix(b, "/")+"/") || strings.HasPrefix(b, strings.TrimSuffix(a, "/")+"/")
+	}
+	absA, errA := resolvePath(a)
+	absB, errB := resolvePath(b)
+	if errA != nil || errB != nil {
+		return true
+	}
+	inside := func(dir, p string) bool {
+		rel, err := filepath.Rel(dir, p)
+		if err == nil && (rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))) {
+			return true
+		}
+		// A bind mount shows the same folder under another path: look for it among the parents
+		for {
+			if sameFile(dir, p) {
+				return true
+			}
+			parent := filepath.Dir(p)
+			if parent == p {
+				return false
+			}
+			p = parent
+		}
+	}
+	return inside(absA, absB) || inside(absB, absA)
+}
+
+// resolvePath returns the absolute path of a folder with its symbolic links followed
+func resolvePath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+// sameFile reports whether two local paths are the same file or folder, as hard links and bind
+// mounts are
+func sameFile(a, b string) bool {
+	if vfs.IsRemote(a) || vfs.IsRemote(b) {
+		return false
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
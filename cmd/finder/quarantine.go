@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/quarantine"
+)
+
+// quarantineSubcommands are the subcommands of `finder quarantine`
+var quarantineSubcommands = []string{"list", "restore"}
+
+// quarantineOptions are the flags of `finder quarantine` and `finder purge`
+type quarantineOptions struct {
+	dir       string
+	batch     string
+	olderThan string
+	dryRun    bool
+}
+
+// quarantineFlags defines the flags of `finder quarantine <sub>` (or `finder purge` for "purge")
+// on a new flag set
+func quarantineFlags(sub string, o *quarantineOptions) *flag.FlagSet {
+	name := "quarantine " + sub
+	if sub == "purge" {
+		name = "purge"
+	}
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&o.dir, "dir", "", "Quarantine folder (defaults to the saved quarantine folder)")
+	switch sub {
+	case "restore":
+		fs.StringVar(&o.batch, "batch", "", "Restore every file of this batch (the cleanup run or day it was quarantined in)")
+	case "purge":
+		fs.StringVar(&o.olderThan, "older-than", "30d", "Delete files quarantined longer ago than this: days (30d), weeks (2w) or a duration (12h)")
+		fs.BoolVar(&o.dryRun, "dry-run", false, "List what would be deleted without deleting it")
+	}
+	return fs
+}
+
+// runQuarantineCommand handles `finder quarantine`: list the quarantined files and put some back
+func runQuarantineCommand(args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage:")
+		fmt.Fprintln(os.Stderr, "  finder quarantine list [-dir <folder>]                          List the quarantined files")
+		fmt.Fprintln(os.Stderr, "  finder quarantine restore [-dir <folder>] [-batch <b>] <id>...   Move files back where they were")
+		fmt.Fprintln(os.Stderr, "  finder purge [-dir <folder>] [-older-than 30d] [-dry-run]       Delete old quarantined files for good")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+	var o quarantineOptions
+	fs := quarantineFlags(args[0], &o)
+	fs.Parse(args[1:])
+	dir := quarantineDir(o.dir)
+
+	switch args[0] {
+	case "list":
+		if fs.NArg() != 0 {
+			usage()
+		}
+		items, err := quarantine.List(dir)
+		if err != nil {
+			log.Fatal(i18n.T("❌ Could not read the quarantine: %v", err))
+		}
+		if len(items) == 0 {
+			fmt.Println(i18n.T("The quarantine is empty."))
+			return
+		}
+		var total int64
+		for _, item := range items {
+			total += item.Size
+			fmt.Printf("%s  %s  %10s  %s\n", item.ID, item.Time, formatBytes(item.Size), item.Original)
+		}
+		fmt.Print(i18n.T("🔒 %d quarantined files, %s\n", len(items), formatBytes(total)))
+	case "restore":
+		ids := fs.Args()
+		if o.batch != "" {
+			items, err := quarantine.List(dir)
+			if err != nil {
+				log.Fatal(i18n.T("❌ Could not read the quarantine: %v", err))
+			}
+			for _, item := range items {
+				if item.Batch == o.batch {
+					ids = append(ids, item.ID)
+				}
+			}
+		}
+		if len(ids) == 0 {
+			usage()
+		}
+		defer openOperationsLog()()
+		restored, err := quarantine.Restore(dir, ids)
+		for _, item := range restored {
+			fmt.Print(i18n.T("  ♻️  Restored %s\n", item.Original))
+			journalQuarantine(journal.ActionRestore, item)
+		}
+		if err != nil {
+			log.Print(i18n.T("❌ Some files were not restored: %v", err))
+			exit(1)
+		}
+	default:
+		usage()
+	}
+}
+
+// runPurgeCommand handles `finder purge`: delete the files quarantined long enough ago for good
+func runPurgeCommand(args []string) {
+	var o quarantineOptions
+	fs := quarantineFlags("purge", &o)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: finder purge [-dir <folder>] [-older-than 30d] [-dry-run]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	age, err := quarantine.ParseAge(o.olderThan)
+	if err != nil {
+		fatal(exitInvalidConfig, i18n.T("❌ -older-than: %v", err))
+	}
+	dir := quarantineDir(o.dir)
+
+	if o.dryRun {
+		items, err := quarantine.Expired(dir, age)
+		if err != nil {
+			log.Fatal(i18n.T("❌ Could not read the quarantine: %v", err))
+		}
+		var total int64
+		for _, item := range items {
+			total += item.Size
+			fmt.Printf("  %s  %s\n", item.Time, item.Original)
+		}
+		fmt.Print(i18n.T("🔥 Would delete %d quarantined files (%s)\n", len(items), formatBytes(total)))
+		return
+	}
+
+	defer openOperationsLog()()
+	purged, err := quarantine.Purge(dir, age)
+	var total int64
+	for _, item := range purged {
+		total += item.Size
+		journalQuarantine(journal.ActionPurge, item)
+	}
+	fmt.Print(i18n.T("🔥 Deleted %d quarantined files for good (%s)\n", len(purged), formatBytes(total)))
+	if err != nil {
+		log.Print(i18n.T("❌ Some files could not be deleted: %v", err))
+		exit(1)
+	}
+}
+
+// quarantineDir returns the quarantine folder of -dir or the settings, exiting when there is none
+func quarantineDir(flagDir string) string {
+	if flagDir != "" {
+		return flagDir
+	}
+	appConfig, err := config.LoadConfig()
+	if appConfig == nil || (err != nil && !errors.Is(err, os.ErrNotExist)) {
+		fatal(exitInvalidConfig, i18n.T("❌ Could not read the settings: %v", err))
+	}
+	if appConfig.QuarantinePath == "" {
+		fatal(exitInvalidConfig, i18n.T("❌ No quarantine folder: pass -dir or set quarantine_path"))
+	}
+	return appConfig.QuarantinePath
+}
+
+// openOperationsLog copies the journal entries of restores and purges into the operations log of
+// the settings, as the cleanups do; the returned function closes the cache
+func openOperationsLog() func() {
+	appConfig, _ := config.LoadConfig()
+	if appConfig == nil {
+		appConfig = config.Default()
+	}
+	useCache(appConfig.CacheDSN, false)
+	cache, err := db.NewCache()
+	if err != nil {
+		journal.SetOperationsLog(nil, appConfig.OperationsLog)
+		return func() {}
+	}
+	journal.SetOperationsLog(cache, appConfig.OperationsLog)
+	return func() { cache.Close() }
+}
+
+// journalQuarantine records a restore or purge of a quarantined file in the journal
+func journalQuarantine(action string, item quarantine.Item) {
+	entry := journal.Entry{Action: action, Path: item.Original, Dest: item.Path, Size: item.Size, SHA256: item.SHA256, Kept: item.Kept, GroupID: item.GroupID}
+	if err := journal.Append(entry); err != nil {
+		log.Print(i18n.T("⚠️  Could not write the cleanup journal: %v", err))
+	}
+}
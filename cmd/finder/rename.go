@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/organize"
+	"archive-duplicate-finder/internal/reporter"
+)
+
+// runRename lists the canonical names suggested for variants and, with -rename apply, renames
+// the files after confirmation (unless -yes is set)
+func runRename(report reporter.Report, config Config) {
+	renames := organize.ApplyRenames(organize.SuggestRenames(report.SimilarGroups), true, "", "")
+
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	log.Print(i18n.T("✏️  Rename suggestions: %d", len(renames)))
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	if len(renames) == 0 {
+		fmt.Println(i18n.T("✅ Variant names are already consistent"))
+		return
+	}
+	printRenames(renames)
+	if config.Rename != "apply" {
+		fmt.Println(i18n.T("ℹ️  Dry run. Use -rename apply to rename the files."))
+		return
+	}
+
+	if !config.AutoDelete {
+		fmt.Print(i18n.T("     Rename these files? (y/N): "))
+		var response string
+		fmt.Scanln(&response)
+		if !i18n.IsYes(response) {
+			fmt.Println(i18n.T("     ⏭️  Names left untouched."))
+			return
+		}
+	}
+
+	run := config.CleanupRun
+	if run == "" {
+		run = journal.NewRun()
+	}
+	var pending []organize.Rename
+	for _, r := range renames {
+		if r.Error == "" {
+			pending = append(pending, r)
+		}
+	}
+	results := organize.ApplyRenames(pending, false, run, "")
+	done := 0
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Printf("  ❌ %s: %s\n", r.Path, r.Error)
+			noteFailure()
+		} else {
+			done++
+		}
+	}
+	log.Print(i18n.T("✏️  Renamed %d files", done))
+}
+
+func printRenames(renames []organize.Rename) {
+	group := ""
+	for _, r := range renames {
+		if r.Group != group {
+			group = r.Group
+			fmt.Printf("  📝 %s\n", group)
+		}
+		fmt.Printf("     %s\n        → %s\n", r.Path, r.Suggested)
+		if r.Error != "" {
+			fmt.Printf("        ⚠️  %s\n", r.Error)
+		}
+	}
+}
@@ -0,0 +1,604 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/protect"
+	"archive-duplicate-finder/internal/refnote"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/trash"
+	"archive-duplicate-finder/internal/tui"
+	"archive-duplicate-finder/internal/vfs"
+)
+
+// Kinds of groups shown by `finder review`
+const (
+	reviewIdentical = "identical"
+	reviewSimilar   = "similar names"
+	reviewVisual    = "similar previews"
+)
+
+// minColumn is the narrowest file column; fewer files are shown side by side on small terminals
+const minColumn = 28
+
+// reviewGroup is one duplicate group to decide on
+type reviewGroup struct {
+	id    string // Stable group ID (see reporter.WithGroupIDs), when known
+	kind  string
+	title string
+	files []reporter.FileInfo
+}
+
+// reviewer holds the state of the review screen
+type reviewer struct {
+	term     *tui.Terminal
+	groups   []reviewGroup
+	group    int // Current group
+	file     int // Selected file of the current group
+	scroll   int // First contents line shown
+	contents bool
+	listings map[string][]string // Contents lines per archive, read on first display
+	status   string
+	pending  func() // Action waiting for the y/n answer
+	question string
+
+	cache     *db.Cache
+	trashPath string
+	orDelete  bool
+	recheck   string
+	leaveRef  bool
+	refFormat string
+	refTmpl   string
+	ignoreTTL time.Duration
+	confirm   bool
+	run       string
+	removed   int
+	freed     int64
+	ignored   int
+}
+
+// reviewOptions are the flags of `finder review`
+type reviewOptions struct {
+	dir          string
+	reportFile   string
+	recursive    bool
+	trashPath    string
+	leaveRef     bool
+	yes          bool
+	loose        bool
+	protectFlags stringList
+}
+
+// runReviewCommand handles `finder review`: a full-screen terminal UI that pages through the
+// duplicate groups and keeps, removes or ignores files with single keystrokes
+func runReviewCommand(args []string) {
+	var o reviewOptions
+	fs := reviewFlags(&o)
+	fs.Parse(args)
+
+	appConfig, _ := config.LoadConfig()
+	if appConfig == nil {
+		appConfig = config.Default()
+	}
+	archive.SetLimits(appConfig.ArchiveLimits())
+	scanner.SetLooseFiles(o.loose || appConfig.LooseFiles)
+	useCache(appConfig.CacheDSN, false)
+	vfs.SetS3Config(appConfig.S3)
+	vfs.SetSFTPConfig(appConfig.SFTP)
+	vfs.SetWebDAVConfig(appConfig.WebDAV)
+	protected, err := protect.New(append(append([]string(nil), appConfig.Protected...), o.protectFlags...))
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if o.dir == "" {
+		o.dir = appConfig.Directory
+	}
+	if o.trashPath == "" {
+		o.trashPath = appConfig.TrashPath
+	}
+	if o.reportFile == "" && o.dir == "" {
+		fmt.Fprintln(os.Stderr, "Usage: finder review [-dir <folder> | -report <report.json>] [options]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	cache, err := db.NewCache()
+	if err != nil {
+		log.Print(i18n.T("⚠️  Cache not available: %v", err))
+		cache = nil
+	} else {
+		defer cache.Close()
+	}
+	journal.SetOperationsLog(cache, appConfig.OperationsLog)
+	hooks.Set(appConfig.Hooks)
+
+	var groups []reviewGroup
+	if o.reportFile != "" {
+		groups, err = reviewGroupsFromReport(o.reportFile, cache)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	} else {
+		groups = reviewGroupsFromScan(o.dir, o.recursive, cache)
+	}
+	for _, g := range groups {
+		for i := range g.files {
+			g.files[i].Protected = protected.Match(g.files[i].Path)
+		}
+	}
+	if len(groups) == 0 {
+		log.Println(i18n.T("✅ No duplicate groups to review"))
+		return
+	}
+
+	t, err := tui.Open()
+	if err != nil {
+		log.Fatal(i18n.T("❌ finder review: %v", err))
+	}
+	r := &reviewer{
+		term:      t,
+		groups:    groups,
+		listings:  make(map[string][]string),
+		cache:     cache,
+		trashPath: o.trashPath,
+		orDelete:  appConfig.DeleteIfTrashFails,
+		recheck:   appConfig.VerifyBeforeDelete,
+		leaveRef:  o.leaveRef,
+		refFormat: appConfig.RefFormat,
+		refTmpl:   appConfig.RefTemplate,
+		ignoreTTL: time.Duration(appConfig.IgnoreTTLDays) * 24 * time.Hour,
+		confirm:   !o.yes,
+		run:       journal.NewRun(),
+	}
+	r.loop()
+	t.Close()
+
+	log.Print(i18n.T("📋 Review finished: %d files removed (%s), %d groups ignored, %d groups left", r.removed, formatBytes(r.freed), r.ignored, len(r.groups)))
+}
+
+// reviewGroupsFromReport loads the groups of a JSON report, leaving out ignored ones
+func reviewGroupsFromReport(path string, cache *db.Cache) ([]reviewGroup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report reporter.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("%s is not a JSON report: %w", path, err)
+	}
+
+	var groups []reviewGroup
+	add := func(id, kind, title string, files []reporter.FileInfo) {
+		if len(files) < 2 || (cache != nil && cache.IsGroupIgnored(reporter.CalculateGroupHash(files), id)) {
+			return
+		}
+		groups = append(groups, reviewGroup{id: id, kind: kind, title: title, files: files})
+	}
+	for _, g := range report.SizeGroups {
+		add(g.ID, reviewIdentical, i18n.T("%s each", formatBytes(g.Size)), g.Files)
+	}
+	for _, g := range report.SimilarGroups {
+		add(g.ID, reviewSimilar, g.BaseName, g.Files)
+	}
+	for _, g := range report.VisualGroups {
+		add(g.ID, reviewVisual, g.BaseName, g.Files)
+	}
+	return groups, nil
+}
+
+// reviewGroupsFromScan finds the archives of dir with identical contents
+func reviewGroupsFromScan(dir string, recursive bool, cache *db.Cache) []reviewGroup {
+	if _, err := os.Stat(dir); os.IsNotExist(err) && !vfs.IsRemote(dir) {
+		log.Fatal(i18n.T("❌ Directory does not exist: %s", dir))
+	}
+	log.Print(i18n.T("🔍 Scanning %s...", dir))
+	if cache != nil {
+		cache.SetRoot(dir)
+	}
+	files, err := scanner.ScanDirectory(dir, recursive)
+	if err != nil {
+		log.Fatal(i18n.T("❌ Failed to scan directory: %v", err))
+	}
+	files = scanner.CollapseVolumeSets(files)
+
+	log.Println(i18n.T("🔐 Hashing archives of the same size..."))
+	var found []reporter.SizeGroup
+	for size, sameSize := range scanner.GroupBySize(files) {
+		if len(sameSize) < 2 {
+			continue
+		}
+		for _, same := range hashing.VerifyContents(cache, sameSize) {
+			if cache != nil && cache.IsHashSuppressed(same.Hash) {
+				continue
+			}
+			g := reporter.SizeGroup{Size: size, Verified: true}
+			for _, f := range same.Files {
+				g.Files = append(g.Files, reporter.NewFileInfo(f))
+			}
+			found = append(found, g)
+		}
+	}
+	if cache != nil {
+		found = reporter.WithGroupIDs(reporter.Report{SizeGroups: found}, cache.GroupIDs).SizeGroups
+	}
+
+	var groups []reviewGroup
+	for _, g := range found {
+		if cache != nil && cache.IsGroupIgnored(reporter.CalculateGroupHash(g.Files), g.ID) {
+			continue
+		}
+		groups = append(groups, reviewGroup{id: g.ID, kind: reviewIdentical, title: i18n.T("%s each", formatBytes(g.Size)), files: g.Files})
+	}
+	// Largest savings first
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].files[0].Size*int64(len(groups[i].files)) > groups[j].files[0].Size*int64(len(groups[j].files))
+	})
+	return groups
+}
+
+func (r *reviewer) loop() {
+	for len(r.groups) > 0 {
+		r.draw()
+		key, err := r.term.ReadKey()
+		if err != nil {
+			return
+		}
+		if r.pending != nil {
+			action := r.pending
+			r.pending, r.question = nil, ""
+			if i18n.IsYes(key) {
+				action()
+			} else {
+				r.status = i18n.T("Canceled")
+			}
+			continue
+		}
+		if !r.handle(key) {
+			return
+		}
+	}
+}
+
+// handle applies one key press; false quits
+func (r *reviewer) handle(key string) bool {
+	g := &r.groups[r.group]
+	r.status = ""
+	switch key {
+	case "q", tui.KeyEscape, tui.KeyCtrlC:
+		return false
+	case tui.KeyRight, "n", " ":
+		r.moveGroup(1)
+	case tui.KeyLeft, "p":
+		r.moveGroup(-1)
+	case tui.KeyTab:
+		r.file = (r.file + 1) % len(g.files)
+	case tui.KeyDown:
+		r.scroll++
+	case tui.KeyUp:
+		r.scroll = max(r.scroll-1, 0)
+	case tui.KeyPageDown:
+		r.scroll += 10
+	case tui.KeyPageUp:
+		r.scroll = max(r.scroll-10, 0)
+	case "c":
+		r.contents = !r.contents
+		r.scroll = 0
+	case "k", tui.KeyEnter:
+		r.keepSelected()
+	case "d":
+		r.deleteSelected()
+	case "i":
+		r.ignoreGroup()
+	default:
+		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+			if n := int(key[0] - '1'); n < len(g.files) {
+				r.file = n
+			}
+		}
+	}
+	return true
+}
+
+func (r *reviewer) moveGroup(delta int) {
+	r.group = (r.group + delta + len(r.groups)) % len(r.groups)
+	r.file, r.scroll = 0, 0
+}
+
+// ask runs action once the user answers y, or right away with -yes
+func (r *reviewer) ask(question string, action func()) {
+	if !r.confirm {
+		action()
+		return
+	}
+	r.question, r.pending = question+i18n.T(" (y/N)"), action
+}
+
+// keepSelected removes every other copy of the group
+func (r *reviewer) keepSelected() {
+	g := r.groups[r.group]
+	kept := g.files[r.file]
+	var remove []reporter.FileInfo
+	var size int64
+	for i, f := range g.files {
+		if i != r.file && !f.Protected {
+			remove = append(remove, f)
+			size += f.Size
+		}
+	}
+	if len(remove) == 0 {
+		r.status = i18n.T("🛡️  The other files are protected")
+		return
+	}
+	r.ask(i18n.T("Keep %s and remove %d other files (%s)?", kept.Name, len(remove), formatBytes(size)), func() {
+		for _, f := range remove {
+			r.remove(f, kept)
+		}
+	})
+}
+
+// deleteSelected removes the selected file, pointing its reference note to another copy
+func (r *reviewer) deleteSelected() {
+	g := r.groups[r.group]
+	target := g.files[r.file]
+	if target.Protected {
+		r.status = i18n.T("🛡️  Protected file, not removed")
+		return
+	}
+	var kept reporter.FileInfo
+	for i, f := range g.files {
+		if i != r.file && (kept.Path == "" || f.Protected) {
+			kept = f
+		}
+	}
+	r.ask(i18n.T("Remove %s (%s)?", target.Name, formatBytes(target.Size)), func() {
+		r.remove(target, kept)
+	})
+}
+
+// ignoreGroup hides the group from future reports, like "mark as good" in the dashboard
+func (r *reviewer) ignoreGroup() {
+	g := r.groups[r.group]
+	if r.cache != nil {
+		paths := make([]string, len(g.files))
+		for i, f := range g.files {
+			paths[i] = f.Path
+		}
+		hash := reporter.CalculateGroupHash(g.files)
+		r.cache.AddIgnoredGroup(hash, g.id, paths, r.ignoreTTL)
+		r.status = i18n.T("👍 Group ignored")
+		if err := journal.AppendGroup(journal.ActionIgnore, "", g.id, hash, g.files); err != nil {
+			r.status = i18n.T("⚠️  Could not write the cleanup journal: %v", err)
+		}
+	} else {
+		r.status = i18n.T("👍 Group skipped for this session (no cache to remember it)")
+	}
+	r.ignored++
+	r.dropGroup()
+}
+
+// remove deletes or trashes one file (all volumes of a set) and drops it from its group
+func (r *reviewer) remove(f, kept reporter.FileInfo) {
+	g := &r.groups[r.group]
+	paths := []string{f.Path}
+	if len(f.Volumes) > 0 {
+		paths = f.Volumes
+	}
+	// The report may be older than the files it lists
+	modTime, _ := time.Parse(time.RFC3339, f.ModTime)
+	if err := hashing.Recheck(r.cache, r.recheck, paths, f.Size, modTime); err != nil {
+		r.status = i18n.T("⚠️  %v, file kept", err)
+		return
+	}
+	removal := hooks.Event{Event: hooks.EventPreDelete, Run: r.run, Path: f.Path, Paths: paths, Size: f.Size, Kept: kept.Path, GroupID: g.id}
+	if err := hooks.Run(removal); err != nil {
+		r.status = i18n.T("⚠️  Kept by the pre_delete hook: %v", err)
+		return
+	}
+	note := refnote.Note{Removed: f.Path, RemovedSize: f.Size, Kept: kept.Path, KeptSize: kept.Size, GroupHash: reporter.CalculateGroupHash(g.files), Similarity: f.Similarity(), Action: "deleted"}
+	var removed []*journal.Entry
+	for _, path := range paths {
+		entry := &journal.Entry{Run: r.run, Action: journal.ActionDelete, Path: path, Kept: kept.Path, GroupID: g.id}
+		if info, err := os.Stat(path); err == nil {
+			entry.Size = info.Size()
+		}
+		entry.SHA256, _ = hashing.FileHash(r.cache, path)
+		if r.trashPath != "" {
+			dest, err := trash.Move(r.trashPath, path, nil)
+			if err != nil && !r.orDelete {
+				r.status = i18n.T("❌ Could not move %s to the trash: %v", filepath.Base(path), err)
+				return
+			}
+			if err == nil {
+				entry.Action, entry.Dest = journal.ActionTrash, dest
+				if note.Trash == "" {
+					note.Action, note.Trash = "trashed", dest
+				}
+			}
+		}
+		if entry.Action == journal.ActionDelete {
+			if err := os.Remove(path); err != nil {
+				r.status = i18n.T("❌ Could not delete %s: %v", filepath.Base(path), err)
+				return
+			}
+		}
+		if err := journal.Append(*entry); err != nil {
+			r.status = i18n.T("⚠️  Could not write the cleanup journal: %v", err)
+		}
+		removed = append(removed, entry)
+	}
+	hooks.Run(removal.PostDelete(removed))
+	if r.leaveRef && kept.Path != "" {
+		note.Date = time.Now()
+		if _, err := refnote.Write(note, r.refFormat, r.refTmpl); err != nil {
+			r.status = i18n.T("⚠️  Could not create reference note: %v", err)
+		}
+	}
+
+	r.removed++
+	r.freed += f.Size
+	if r.status == "" {
+		r.status = i18n.T("🗑️  Removed %s", f.Name)
+	}
+	for i, other := range g.files {
+		if other.Path == f.Path {
+			g.files = append(g.files[:i:i], g.files[i+1:]...)
+			break
+		}
+	}
+	r.file = min(r.file, len(g.files)-1)
+	if len(g.files) < 2 {
+		r.dropGroup()
+	}
+}
+
+// dropGroup removes the current group once it is resolved
+func (r *reviewer) dropGroup() {
+	r.groups = append(r.groups[:r.group:r.group], r.groups[r.group+1:]...)
+	if r.group >= len(r.groups) {
+		r.group = 0
+	}
+	r.file, r.scroll = 0, 0
+}
+
+func (r *reviewer) draw() {
+	width, height := r.term.Size()
+	g := r.groups[r.group]
+
+	// As many files side by side as fit, sliding to keep the selected one visible
+	visible := max(1, min(len(g.files), (width+3)/(minColumn+3)))
+	first := max(0, min(r.file-visible/2, len(g.files)-visible))
+	files := g.files[first : first+visible]
+	colWidth := (width - 3*(visible-1)) / visible
+	selected := r.file - first
+
+	var savings int64
+	for _, f := range g.files[1:] {
+		savings += f.Size
+	}
+	lines := []string{
+		tui.Bold(tui.Fit(i18n.T(" 📋 Group %d/%d · %s · %s · %d files · up to %s reclaimable", r.group+1, len(r.groups), i18n.T(g.kind), g.title, len(g.files), formatBytes(savings)), width)),
+		strings.Repeat("─", width),
+	}
+	column := func(cell func(i int, f reporter.FileInfo) string) string {
+		cells := make([]string, len(files))
+		for i, f := range files {
+			cells[i] = cell(first+i, f)
+		}
+		return tui.Columns(cells, colWidth, -1)
+	}
+	header := make([]string, len(files))
+	for i, f := range files {
+		header[i] = fmt.Sprintf("[%d] %s", first+i+1, f.Name)
+	}
+	lines = append(lines,
+		tui.Columns(header, colWidth, selected),
+		column(func(_ int, f reporter.FileInfo) string { return filepath.Dir(f.Path) }),
+		column(func(_ int, f reporter.FileInfo) string {
+			return fmt.Sprintf("%s · %s", formatBytes(f.Size), strings.Replace(f.ModTime, "T", " ", 1))
+		}),
+		column(func(_ int, f reporter.FileInfo) string {
+			switch {
+			case f.FileCount > 0 && f.UncompressedSize > 0:
+				return i18n.T("%d entries · %s unpacked", f.FileCount, formatBytes(f.UncompressedSize))
+			case f.FileCount > 0:
+				return i18n.T("%d entries", f.FileCount)
+			}
+			return ""
+		}),
+		column(func(_ int, f reporter.FileInfo) string {
+			var flags []string
+			if f.Protected {
+				flags = append(flags, i18n.T("🛡️ protected"))
+			}
+			if f.Score != nil {
+				flags = append(flags, i18n.T("%.0f%% similar", *f.Score))
+			}
+			if f.Suspicious != "" {
+				flags = append(flags, "⚠️ "+f.Suspicious)
+			}
+			return strings.Join(flags, " · ")
+		}),
+		strings.Repeat("─", width),
+	)
+
+	// Contents of the visible archives, side by side
+	room := height - len(lines) - 3
+	if r.contents {
+		listings := make([][]string, len(files))
+		longest := 0
+		for i, f := range files {
+			listings[i] = r.listing(f.Path)
+			longest = max(longest, len(listings[i]))
+		}
+		r.scroll = max(0, min(r.scroll, longest-room))
+		for row := r.scroll; row < r.scroll+room && row < longest; row++ {
+			lines = append(lines, column(func(i int, _ reporter.FileInfo) string {
+				if l := listings[i-first]; row < len(l) {
+					return l[row]
+				}
+				return ""
+			}))
+		}
+	} else {
+		lines = append(lines, i18n.T(" Press c to list the contents of the archives side by side."))
+	}
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+
+	status := r.status
+	if r.question != "" {
+		status = tui.Bold(r.question)
+	}
+	lines = append(lines, " "+status,
+		tui.Fit(i18n.T(" ←/→ group · tab/1-9 file · k keep selected · d delete selected · i ignore group · c contents · ↑/↓ scroll · q quit"), width))
+	r.term.Draw(lines)
+}
+
+// listing returns the contents lines of an archive, reading it the first time
+func (r *reviewer) listing(path string) []string {
+	if l, ok := r.listings[path]; ok {
+		return l
+	}
+	entries, err := archive.ListArchiveFiles(path)
+	var lines []string
+	if err != nil {
+		lines = []string{"⚠️ " + err.Error()}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	for _, e := range entries {
+		lines = append(lines, fmt.Sprintf("%9s  %s", formatBytes(e.Size), e.Path))
+	}
+	r.listings[path] = lines
+	return lines
+}
+
+// reviewFlags defines the flags of `finder review` on a new flag set, storing their values in o
+func reviewFlags(o *reviewOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	fs.StringVar(&o.dir, "dir", "", "Directory to scan for identical archives (defaults to the saved directory)")
+	fs.StringVar(&o.reportFile, "report", "", "Review the groups of a JSON report (-json) instead of scanning, including similar-name and visual groups")
+	fs.BoolVar(&o.recursive, "recursive", true, "Scan subdirectories recursively")
+	fs.StringVar(&o.trashPath, "trash", "", "Folder to move removed files to (defaults to the saved trash folder)")
+	fs.BoolVar(&o.leaveRef, "ref", false, "Leave a reference note pointing to the kept file")
+	fs.BoolVar(&o.yes, "yes", false, "Remove files without asking for confirmation")
+	fs.BoolVar(&o.loose, "loose", false, "Loose-file mode: also scan images and any other file (on when saved in the settings)")
+	fs.Var(&o.protectFlags, "protect", "Never remove files matching this glob, or anything inside a matching folder (repeatable)")
+	return fs
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/iotune"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/membudget"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+	"archive-duplicate-finder/internal/visual"
+	"archive-duplicate-finder/internal/web"
+)
+
+// serveOptions are the flags of `finder serve`
+type serveOptions struct {
+	headless bool
+	port     int
+	scan     bool
+	debug    bool
+	uiDir    string
+	noCache  bool
+}
+
+// runServeCommand starts the dashboard and its API on the saved settings without a CLI scan,
+// for services and containers: `finder serve -headless`
+func runServeCommand(args []string) {
+	var o serveOptions
+	fs := serveFlags(&o)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: finder serve [-headless] [-config file] [-port n] [-scan=false]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	srv, cache, listenErr := startServer(o)
+	if cache != nil {
+		defer cache.Close()
+	}
+	if !o.headless {
+		go func() {
+			time.Sleep(1 * time.Second) // Give server a moment to bind
+			log.Print(i18n.T("🌍 Opening dashboard at %s ...", srv.URL()))
+			openBrowser(srv.URL())
+		}()
+	}
+
+	waitServer(cache, listenErr)
+}
+
+// waitServer serves until the process is asked to stop or the server fails. Containers stop
+// with SIGTERM: the cache database is closed before leaving.
+func waitServer(cache *db.Cache, listenErr chan error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-listenErr:
+		log.Print(i18n.T("❌ Web server error: %v", err))
+		if cache != nil {
+			cache.Close()
+		}
+		exit(exitErrors)
+	case <-ctx.Done():
+		log.Print(i18n.T("👋 Shutting down"))
+	}
+}
+
+// startServer starts the dashboard and its API on the saved settings, and the scan of the
+// saved directory unless o says otherwise. The caller closes the cache (nil when unavailable);
+// listenErr receives the error that stopped the server.
+func startServer(o serveOptions) (srv *web.Server, cache *db.Cache, listenErr chan error) {
+	// Settings come from the file and the ADF_* environment alone; none is required
+	appConfig, err := config.LoadConfig()
+	if appConfig == nil || (err != nil && !errors.Is(err, os.ErrNotExist)) {
+		fatal(exitInvalidConfig, i18n.T("❌ Could not read the settings: %v", err))
+	}
+	if o.port > 0 {
+		appConfig.Port = o.port
+	}
+	if appConfig.Port == 0 {
+		appConfig.Port = config.Default().Port
+	}
+	if appConfig.Workers == 0 && appConfig.Directory != "" {
+		iotune.Tune(appConfig.Directory)
+	}
+	archive.SetLimits(appConfig.ArchiveLimits())
+	archive.SetPreviewCommands(appConfig.PreviewConverters)
+	visual.SetReadRate(appConfig.VisualRateMB << 20)
+	membudget.SetBudget(appConfig.MemoryBudgetMB << 20)
+	scanner.SetLooseFiles(appConfig.LooseFiles)
+	useCache(appConfig.CacheDSN, o.noCache)
+	vfs.SetS3Config(appConfig.S3)
+	vfs.SetSFTPConfig(appConfig.SFTP)
+	vfs.SetWebDAVConfig(appConfig.WebDAV)
+
+	cache, err = db.NewCache()
+	if err != nil {
+		log.Print(i18n.T("⚠️  Cache not available: %v", err))
+		cache = nil
+	} else {
+		cache.SetRoot(appConfig.Directory)
+		// Copies of one archive share the extraction of their previews
+		archive.SetContentKey(func(path string) string { return hashing.CachedFileHash(cache, path) })
+	}
+	journal.SetOperationsLog(cache, appConfig.OperationsLog)
+	hooks.Set(appConfig.Hooks)
+
+	srv = web.NewServer(appConfig.Port, nil, appConfig.TrashPath, appConfig.LeaveRef, nil, nil, nil, cache, appConfig.Directory, appConfig)
+	srv.SetDebug(o.debug)
+	srv.SetUIDir(o.uiDir)
+	listenErr = make(chan error, 1)
+	go func() { listenErr <- srv.Start() }()
+
+	if appConfig.Directory == "" {
+		log.Print(i18n.T("🌐 No configuration found. Starting the web setup wizard..."))
+	} else if o.scan && srv.StartScan() {
+		log.Print(i18n.T("📂 Loading saved configuration: %s", appConfig.Directory))
+	}
+	return srv, cache, listenErr
+}
+
+// serveFlags defines the flags of `finder serve` on a new flag set, storing their values in o
+func serveFlags(o *serveOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.String("config", "", "Settings file (default: $ADF_CONFIG, then archive-finder-settings.json in the user config folder)")
+	fs.BoolVar(&o.headless, "headless", false, "Do not open a browser (services, containers)")
+	fs.IntVar(&o.port, "port", 0, "Port of the dashboard and the API (default: the saved port, 8080)")
+	fs.BoolVar(&o.scan, "scan", true, "Scan the saved directory on start")
+	fs.BoolVar(&o.debug, "debug", false, "Log every request")
+	fs.BoolVar(&o.noCache, "no-cache", false, "Keep the cache in memory and do not write previews to disk (nothing survives a restart)")
+	fs.StringVar(&o.uiDir, "ui-dir", "", "Serve the dashboard from this folder (e.g. ui/out while working on the UI) instead of the copy in the binary")
+	return fs
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/jobs"
+	"archive-duplicate-finder/internal/web"
+)
+
+// trayOptions are the flags of `finder tray`
+type trayOptions struct {
+	port int
+	scan bool
+}
+
+// trayFlags defines the flags of `finder tray` on a new flag set, storing their values in o
+func trayFlags(o *trayOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("tray", flag.ExitOnError)
+	fs.String("config", "", "Settings file (default: $ADF_CONFIG, then archive-finder-settings.json in the user config folder)")
+	fs.IntVar(&o.port, "port", 0, "Port of the dashboard and the API (default: the saved port, 8080)")
+	fs.BoolVar(&o.scan, "scan", true, "Scan the saved directory on start")
+	return fs
+}
+
+// runTrayCommand handles `finder tray`: the server of `finder serve -headless`, with an icon in
+// the system tray showing what it is doing and what it found, and a menu to open the
+// dashboard, start a scan and pause the analyses running in the background
+func runTrayCommand(args []string) {
+	var o trayOptions
+	fs := trayFlags(&o)
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "Usage: finder tray [-config file] [-port n] [-scan=false]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	if !trayAvailable {
+		fatal(exitInvalidConfig, i18n.T("❌ This build has no tray support (macOS builds need cgo); use finder serve instead"))
+	}
+
+	srv, cache, listenErr := startServer(serveOptions{headless: true, port: o.port, scan: o.scan})
+	err := runTray(srv, listenErr)
+	if cache != nil {
+		cache.Close()
+	}
+	if err != nil {
+		log.Print(i18n.T("❌ Web server error: %v", err))
+		exit(exitErrors)
+	}
+}
+
+// trayJobLine describes the job at work for the tray menu
+func trayJobLine(st web.Status) string {
+	switch {
+	case !st.Configured:
+		return i18n.T("Not set up yet: open the dashboard")
+	case st.Job == nil && st.Scanned:
+		return i18n.T("Idle")
+	case st.Job == nil:
+		return i18n.T("No scan yet")
+	}
+	name := st.Job.Kind
+	switch st.Job.Kind {
+	case "scan":
+		name = i18n.T("Scan")
+	case "step3":
+		name = i18n.T("Similar names")
+	case "visual":
+		name = i18n.T("Visual analysis")
+	}
+	switch st.Job.Status {
+	case jobs.StatusQueued:
+		return i18n.T("%s: queued", name)
+	case jobs.StatusPaused:
+		return i18n.T("%s: paused at %.0f%%", name, st.Job.Progress)
+	case jobs.StatusCanceling:
+		return i18n.T("%s: stopping", name)
+	}
+	return fmt.Sprintf("%s: %.0f%%", name, st.Job.Progress)
+}
+
+// trayReportLine sums up the report for the tray menu
+func trayReportLine(st web.Status) string {
+	if !st.Scanned {
+		return i18n.T("No duplicates found yet")
+	}
+	return i18n.T("%d identical (%s reclaimable), %d similar, %d visual", st.Duplicates, formatBytes(st.Reclaimable), st.Similar, st.Visual)
+}
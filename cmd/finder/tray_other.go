@@ -0,0 +1,13 @@
+//go:build !(windows || (linux && !android) || (darwin && cgo && !ios))
+
+package main
+
+import "archive-duplicate-finder/internal/web"
+
+// trayAvailable reports whether this build can show a tray icon
+const trayAvailable = false
+
+// runTray is never called: runTrayCommand stops first
+func runTray(srv *web.Server, listenErr <-chan error) error {
+	return nil
+}
@@ -0,0 +1,131 @@
+//go:build windows || (linux && !android) || (darwin && cgo && !ios)
+
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"archive-duplicate-finder/assets"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/web"
+
+	"fyne.io/systray"
+)
+
+// trayAvailable reports whether this build can show a tray icon
+const trayAvailable = true
+
+// trayRefresh is how often the tray reads the status of the server
+const trayRefresh = 2 * time.Second
+
+// runTray shows the tray icon and its menu until Quit is chosen, the process is asked to stop
+// or the server fails, whose error it returns
+func runTray(srv *web.Server, listenErr <-chan error) error {
+	failed := make(chan error, 1)
+	systray.Run(func() {
+		menu := newTrayMenu(srv)
+		go menu.run(srv, listenErr, failed)
+	}, nil)
+	select {
+	case err := <-failed:
+		return err
+	default:
+		return nil
+	}
+}
+
+// trayMenu is the menu of the tray icon: two lines of status, then the actions
+type trayMenu struct {
+	job, report             *systray.MenuItem
+	open, scan, pause, quit *systray.MenuItem
+}
+
+// newTrayMenu sets the icon up and builds its menu
+func newTrayMenu(srv *web.Server) trayMenu {
+	if runtime.GOOS == "windows" {
+		systray.SetIcon(assets.Icon)
+	} else {
+		systray.SetIcon(assets.IconPNG)
+	}
+	systray.SetTooltip("Archive Duplicate Finder")
+
+	var m trayMenu
+	m.job = systray.AddMenuItem("", "")
+	m.job.Disable()
+	m.report = systray.AddMenuItem("", "")
+	m.report.Disable()
+	systray.AddSeparator()
+	m.open = systray.AddMenuItem(i18n.T("Open dashboard"), srv.URL())
+	m.scan = systray.AddMenuItem(i18n.T("Scan now"), "")
+	m.pause = systray.AddMenuItem(i18n.T("Pause analysis"), "")
+	systray.AddSeparator()
+	m.quit = systray.AddMenuItem(i18n.T("Quit"), "")
+	return m
+}
+
+// run keeps the menu up to date and answers its clicks until the tray quits. The error that
+// stopped the server, if that is why, goes to failed.
+func (m trayMenu) run(srv *web.Server, listenErr <-chan error, failed chan<- error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(trayRefresh)
+	defer ticker.Stop()
+	for {
+		st := srv.Status()
+		m.update(st)
+		select {
+		case <-ticker.C:
+		case <-m.open.ClickedCh:
+			openBrowser(srv.URL())
+		case <-m.scan.ClickedCh:
+			srv.StartScan()
+		case <-m.pause.ClickedCh:
+			if st.Paused > 0 {
+				srv.ResumeAnalysis()
+			} else {
+				srv.PauseAnalysis()
+			}
+		case <-m.quit.ClickedCh:
+			log.Print(i18n.T("👋 Shutting down"))
+			systray.Quit()
+			return
+		case <-ctx.Done():
+			log.Print(i18n.T("👋 Shutting down"))
+			systray.Quit()
+			return
+		case err := <-listenErr:
+			failed <- err
+			systray.Quit()
+			return
+		}
+	}
+}
+
+// update shows st in the menu. A scan can be started unless one is already on its way, and
+// the pause item resumes when something is paused.
+func (m trayMenu) update(st web.Status) {
+	m.job.SetTitle(trayJobLine(st))
+	m.report.SetTitle(trayReportLine(st))
+	systray.SetTooltip("Archive Duplicate Finder\n" + trayJobLine(st))
+	if st.Configured && (st.Job == nil || st.Job.Kind != "scan") {
+		m.scan.Enable()
+	} else {
+		m.scan.Disable()
+	}
+	if st.Paused > 0 {
+		m.pause.SetTitle(i18n.T("Resume analysis"))
+	} else {
+		m.pause.SetTitle(i18n.T("Pause analysis"))
+	}
+	if st.Paused > 0 || st.Pausable > 0 {
+		m.pause.Enable()
+	} else {
+		m.pause.Disable()
+	}
+}
@@ -0,0 +1,205 @@
+package archive
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// bookFormats maps comic book and e-book extensions to the container they really are
+var bookFormats = map[string]string{
+	".cbz":  ".zip",
+	".cbr":  ".rar",
+	".cb7":  ".7z",
+	".epub": ".zip",
+}
+
+// IsBook reports whether a file is a comic book archive (CBZ/CBR/CB7) or an EPUB
+func IsBook(archivePath string) bool {
+	_, ok := bookFormats[strings.ToLower(filepath.Ext(archivePath))]
+	return ok
+}
+
+func isEPUB(archivePath string) bool {
+	return strings.EqualFold(filepath.Ext(archivePath), ".epub")
+}
+
+// PageCount returns the number of pages of a comic (its images) or of an EPUB (its spine
+// documents)
+func PageCount(archivePath string) (int, error) {
+	files, err := ListArchiveFiles(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	if isEPUB(archivePath) {
+		if pkg, _, err := readEPUBPackage(archivePath); err == nil && len(pkg.Spine) > 0 {
+			return len(pkg.Spine), nil
+		}
+		count := 0
+		for _, f := range files {
+			if ext := strings.ToLower(path.Ext(f.Path)); ext == ".xhtml" || ext == ".html" || ext == ".htm" {
+				count++
+			}
+		}
+		return count, nil
+	}
+	return len(comicPages(files)), nil
+}
+
+// comicPages returns the images of a comic in reading order
+func comicPages(files []PreviewInfo) []PreviewInfo {
+	var pages []PreviewInfo
+	for _, f := range files {
+		if isImageFile(f.Path) && !strings.HasPrefix(path.Base(f.Path), ".") {
+			pages = append(pages, f)
+		}
+	}
+	sort.SliceStable(pages, func(i, j int) bool { return naturalLess(pages[i].Path, pages[j].Path) })
+	return pages
+}
+
+// bookCoverPath picks the cover of a comic (an image named "cover", otherwise the first page)
+// or of an EPUB (the cover declared in its package document)
+func bookCoverPath(archivePath string, files []PreviewInfo) (string, error) {
+	if isEPUB(archivePath) {
+		if cover, err := epubCover(archivePath, files); err == nil {
+			return cover, nil
+		}
+	}
+
+	pages := comicPages(files)
+	if len(pages) == 0 {
+		return "", fmt.Errorf("no preview found")
+	}
+	for _, p := range pages {
+		if strings.Contains(strings.ToLower(path.Base(p.Path)), "cover") {
+			return p.Path, nil
+		}
+	}
+	return pages[0].Path, nil
+}
+
+// epubPackage is the part of an EPUB package document (OPF) used here
+type epubPackage struct {
+	Meta []struct {
+		Name    string `xml:"name,attr"`
+		Content string `xml:"content,attr"`
+	} `xml:"metadata>meta"`
+	Items []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// readEPUBPackage finds the package document through META-INF/container.xml and parses it.
+// It also returns the folder of the package, which manifest paths are relative to.
+func readEPUBPackage(archivePath string) (*epubPackage, string, error) {
+	data, err := GetFileFromArchive(archivePath, "META-INF/container.xml")
+	if err != nil {
+		return nil, "", err
+	}
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil, "", fmt.Errorf("invalid EPUB container")
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	data, err = GetFileFromArchive(archivePath, opfPath)
+	if err != nil {
+		return nil, "", err
+	}
+	var pkg epubPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return nil, "", fmt.Errorf("invalid EPUB package: %w", err)
+	}
+	return &pkg, path.Dir(opfPath), nil
+}
+
+func epubCover(archivePath string, files []PreviewInfo) (string, error) {
+	pkg, dir, err := readEPUBPackage(archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	coverID := ""
+	for _, m := range pkg.Meta {
+		if m.Name == "cover" {
+			coverID = m.Content
+		}
+	}
+	var href string
+	for _, item := range pkg.Items {
+		isImage := strings.HasPrefix(item.MediaType, "image/")
+		// EPUB 3 flags the cover in the manifest, EPUB 2 points to it from the metadata
+		if isImage && strings.Contains(" "+item.Properties+" ", " cover-image ") {
+			href = item.Href
+			break
+		}
+		if isImage && item.ID == coverID && coverID != "" {
+			href = item.Href
+		}
+	}
+	if href == "" {
+		return "", fmt.Errorf("no cover declared")
+	}
+
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	cover := path.Clean(path.Join(dir, href))
+	for _, f := range files {
+		if f.Path == cover {
+			return cover, nil
+		}
+	}
+	return "", fmt.Errorf("declared cover %s not found", cover)
+}
+
+// naturalLess orders names with their numbers compared by value ("page2" before "page10")
+func naturalLess(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			na, nb := leadingDigits(a), leadingDigits(b)
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			a, b = a[len(na):], b[len(nb):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
@@ -0,0 +1,257 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/vfs"
+	"archive/tar"
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Single-file compressed archives (.gz, .bz2, .xz, .zst) are read as a stream. Their payload is
+// either one file or a tarball, whose entries are then listed like those of any other archive.
+
+// tarballAliases maps the short tarball extensions to the compression they use
+var tarballAliases = map[string]string{
+	".tgz":  ".gz",
+	".tbz":  ".bz2",
+	".tbz2": ".bz2",
+	".txz":  ".xz",
+	".tzst": ".zst",
+}
+
+// IsCompressed reports whether a file is a single-file compressed archive or a plain tarball,
+// whose payload (the decompressed stream) can be compared across compression formats
+func IsCompressed(archivePath string) bool {
+	switch Format(archivePath) {
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		return true
+	}
+	return false
+}
+
+// OpenPayload returns the decompressed stream of a compressed archive (a plain tarball is its
+// own payload). Reading past the uncompressed size limit fails with ErrTooLarge.
+func OpenPayload(archivePath string) (io.ReadCloser, error) {
+	f, err := vfs.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader
+	closers := []io.Closer{f}
+	input := bufio.NewReader(f)
+	switch Format(archivePath) {
+	case ".tar":
+		r = input
+	case ".gz":
+		gz, err := gzip.NewReader(input)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+		}
+		r, closers = gz, append(closers, gz)
+	case ".bz2":
+		r = bzip2.NewReader(input)
+	case ".xz":
+		xr, err := xz.NewReader(input)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to open xz stream: %w", err)
+		}
+		r = xr
+	case ".zst":
+		zr, err := zstd.NewReader(input)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to open zstd stream: %w", err)
+		}
+		rc := zr.IOReadCloser()
+		r, closers = rc, append(closers, rc)
+	default:
+		f.Close()
+		return nil, fmt.Errorf("unsupported archive format: %s", Format(archivePath))
+	}
+
+	return &payloadReader{path: archivePath, r: watch(archivePath, r), budget: newSizeBudget(archivePath), packed: f.Size(), closers: closers}, nil
+}
+
+// payloadReader is a decompressed stream bounded by the uncompressed size limit. Compressed
+// streams declare no size up front, so the ratio limit is checked as the payload is read.
+type payloadReader struct {
+	path     string
+	r        io.Reader
+	budget   *sizeBudget
+	packed   int64 // Size of the compressed file
+	unpacked int64
+	closers  []io.Closer
+}
+
+func (p *payloadReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.unpacked += int64(n)
+	if ratio := GetLimits().MaxRatio; ratio > 0 && p.packed > 0 && p.unpacked > ratioMinSize && float64(p.unpacked) > ratio*float64(p.packed) {
+		markSuspicious(p.path, fmt.Sprintf("payload expands beyond %.0f:1, the ratio limit", ratio))
+		return n, ErrSuspicious
+	}
+	if p.budget.remaining >= 0 {
+		if p.budget.remaining -= int64(n); p.budget.remaining < 0 {
+			return n, fmt.Errorf("%w (%d MB)", ErrTooLarge, GetLimits().MaxUncompressed>>20)
+		}
+	}
+	return n, err
+}
+
+func (p *payloadReader) Close() error {
+	// Decoders first, then the file they read from
+	for i := len(p.closers) - 1; i >= 0; i-- {
+		p.closers[i].Close()
+	}
+	return nil
+}
+
+// payloadName is the name of the single file wrapped by a compressed archive
+func payloadName(archivePath string) string {
+	base := path.Base(strings.ReplaceAll(archivePath, "\\", "/"))
+	ext := path.Ext(base)
+	if _, ok := tarballAliases[strings.ToLower(ext)]; ok {
+		return strings.TrimSuffix(base, ext) + ".tar"
+	}
+	return strings.TrimSuffix(base, ext)
+}
+
+// walkStream calls fn for every entry of a compressed archive: the tarball entries when the
+// payload is a tarball, otherwise the payload itself with an unknown (-1) size
+func walkStream(archivePath string, fn func(name string, size int64, r io.Reader) error) error {
+	payload, err := OpenPayload(archivePath)
+	if err != nil {
+		return err
+	}
+	defer payload.Close()
+
+	// Tarballs are recognized by their header rather than their name ("backup.gz")
+	br := bufio.NewReader(payload)
+	if head, _ := br.Peek(262); len(head) < 262 || string(head[257:262]) != "ustar" {
+		return fn(payloadName(archivePath), -1, br)
+	}
+
+	tr := tar.NewReader(br)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar header: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !allowEntry(archivePath, header.Name, header.Size, 0) {
+			continue
+		}
+		if err := fn(header.Name, header.Size, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func listFilesStream(archivePath string) ([]PreviewInfo, error) {
+	var files []PreviewInfo
+	err := walkStream(archivePath, func(name string, size int64, r io.Reader) error {
+		if size < 0 {
+			// A bare payload only tells its size once decompressed
+			n, err := io.Copy(io.Discard, r)
+			if err != nil {
+				return err
+			}
+			size = n
+		}
+		files = append(files, PreviewInfo{Path: name, Size: size})
+		return nil
+	})
+	return files, err
+}
+
+// extractStream extracts files from a compressed archive or tarball
+func extractStream(archivePath string) (map[string][]byte, error) {
+	contents := make(map[string][]byte)
+	budget := newSizeBudget(archivePath)
+	err := walkStream(archivePath, func(name string, size int64, r io.Reader) error {
+		data, err := budget.readAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", name, err)
+		}
+		contents[name] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// errFound stops a walk once the wanted entry has been read
+var errFound = errors.New("found")
+
+func getFileStream(archivePath, filename string) ([]byte, error) {
+	var data []byte
+	err := walkStream(archivePath, func(name string, size int64, r io.Reader) (err error) {
+		if name != filename {
+			return nil
+		}
+		if data, err = readAll(archivePath, r); err != nil {
+			return err
+		}
+		return errFound
+	})
+	if err == errFound {
+		return data, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("file not found in archive")
+}
+
+func findLargestFileWithFilterStream(archivePath string, filter func(string) bool) ([]byte, string, error) {
+	var largestData []byte
+	var largestName string
+	err := walkStream(archivePath, func(name string, size int64, r io.Reader) error {
+		if !filter(name) || (size >= 0 && size <= int64(len(largestData))) {
+			return nil
+		}
+		data, err := readAll(archivePath, r)
+		if err == nil && len(data) > len(largestData) {
+			largestData, largestName = data, name
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if largestData == nil {
+		return nil, "", fmt.Errorf("no matching file found")
+	}
+	return largestData, largestName, nil
+}
+
+// verifyStream decompresses the whole archive: gzip, bzip2, xz and zstd check their own CRCs
+// at the end of the stream
+func verifyStream(archivePath string) error {
+	payload, err := OpenPayload(archivePath)
+	if err != nil {
+		return err
+	}
+	defer payload.Close()
+	// A payload stopped by the ratio limit is flagged as suspicious, not as corrupt
+	if _, err := io.Copy(io.Discard, payload); err != nil && !errors.Is(err, ErrSuspicious) {
+		return fmt.Errorf("failed to decompress: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Extracted is the outcome of pulling one entry out of an archive
+type Extracted struct {
+	InternalPath string `json:"internal_path"`
+	Dest         string `json:"dest,omitempty"` // Where the entry was written
+	Size         int64  `json:"size,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ExtractEntries writes the named entries of an archive (or every entry under a named folder)
+// into dest, keeping their folders inside the archive. Existing files are never overwritten: a
+// taken name gets a " (2)" suffix. Entries whose path would escape dest are refused.
+// Extraction is an explicit request, so it is not cut short by the per-archive timeout; the
+// uncompressed size limit still applies.
+func ExtractEntries(archivePath string, internalPaths []string, dest string) (results []Extracted, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️  Archive Recovery: Panic while extracting from %s: %v", archivePath, r)
+			err = fmt.Errorf("archive reader panic: %v", r)
+		}
+	}()
+
+	if len(internalPaths) == 0 {
+		return nil, fmt.Errorf("no entries to extract")
+	}
+	files, err := ListArchiveFiles(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Folders select everything below them
+	wanted := make(map[string]bool)
+	for _, p := range internalPaths {
+		prefix := strings.Trim(strings.ReplaceAll(p, "\\", "/"), "/") + "/"
+		found := false
+		for _, f := range files {
+			if f.Path == p || strings.HasPrefix(strings.ReplaceAll(f.Path, "\\", "/"), prefix) {
+				wanted[f.Path], found = true, true
+			}
+		}
+		if !found {
+			results = append(results, Extracted{InternalPath: p, Error: "not found in archive"})
+		}
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+
+	budget := newSizeBudget(archivePath)
+	err = forEachEntry(archivePath, wanted, func(name string, r io.Reader) error {
+		delete(wanted, name)
+		result := Extracted{InternalPath: name}
+		target, err := extractTarget(dest, name)
+		if err == nil {
+			result.Dest = freeName(target)
+			result.Size, err = writeEntry(result.Dest, r, budget)
+		}
+		if err != nil {
+			result.Dest, result.Size, result.Error = "", 0, err.Error()
+		}
+		results = append(results, result)
+		if errors.Is(err, ErrTooLarge) {
+			return err
+		}
+		return nil
+	})
+	// Entries the archive reader skipped (safety limits) or never reached (a broken header)
+	reason := "not extracted"
+	if SuspiciousReason(archivePath) != "" {
+		reason = ErrSuspicious.Error()
+	}
+	for name := range wanted {
+		results = append(results, Extracted{InternalPath: name, Error: reason})
+	}
+	return results, err
+}
+
+// extractTarget is where an entry lands inside dest, refusing absolute paths and ".." escapes
+func extractTarget(dest, name string) (string, error) {
+	p := strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(p) || filepath.VolumeName(p) != "" || slices.Contains(strings.Split(p, "/"), "..") {
+		return "", fmt.Errorf("unsafe entry path: %s", name)
+	}
+	target := filepath.Join(dest, filepath.FromSlash(path.Clean(p)))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// writeEntry streams an entry to disk within the size budget of the extraction
+func writeEntry(target string, r io.Reader, budget *sizeBudget) (int64, error) {
+	var written int64
+	r = watch(budget.path, r)
+	err := fsutil.WriteAtomic(target, 0644, func(w io.Writer) (err error) {
+		if budget.remaining < 0 {
+			written, err = io.Copy(w, r)
+			return err
+		}
+		written, err = io.Copy(w, io.LimitReader(r, budget.remaining+1))
+		if err != nil {
+			return err
+		}
+		if written > budget.remaining {
+			return fmt.Errorf("%w (%d MB)", ErrTooLarge, GetLimits().MaxUncompressed>>20)
+		}
+		budget.remaining -= written
+		return nil
+	})
+	return written, err
+}
+
+// freeName returns path, or "name (2).ext", "name (3).ext"... if it is taken
+func freeName(p string) string {
+	ext := filepath.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+	candidate := p
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
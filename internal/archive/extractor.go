@@ -1,28 +1,74 @@
 package archive
 
 import (
-	"archive/zip"
 	"bytes"
 	"fmt"
 	"io"
 	"log"
 	"path/filepath"
 	"strings"
-
-	"github.com/bodgit/sevenzip"
-	"github.com/nwaples/rardecode/v2"
 )
 
 // PreviewInfo represents information about a previewable file inside an archive
 type PreviewInfo struct {
-	Path string `json:"path"`
-	Size int64  `json:"size"`
+	Path           string `json:"path"`
+	Size           int64  `json:"size"`
+	CompressedSize int64  `json:"compressed_size,omitempty"` // Stored size, when the format records it per entry
+	CRC32          uint32 `json:"crc32,omitempty"`           // Checksum of the contents, when the format records it
+}
+
+// Format returns the container extension (".zip", ".rar", ".7z", ...) used to pick a reader.
+// Split sets such as "name.7z.001" are opened through their first volume, so they map to
+// the inner archive extension; comic books and EPUBs map to the container they are, ".img"
+// disc images to ".iso" and short tarball extensions (".tgz") to their compression.
+func Format(archivePath string) string {
+	lower := strings.ToLower(archivePath)
+	ext := filepath.Ext(lower)
+	if inner, ok := bookFormats[ext]; ok {
+		return inner
+	}
+	if ext == ".img" {
+		return ".iso"
+	}
+	if compression, ok := tarballAliases[ext]; ok {
+		return compression
+	}
+	if isNumericExt(ext) {
+		if inner := filepath.Ext(strings.TrimSuffix(lower, ext)); inner == ".7z" || inner == ".rar" || inner == ".zip" {
+			return inner
+		}
+	}
+	return ext
+}
+
+func isNumericExt(ext string) bool {
+	if len(ext) < 2 {
+		return false
+	}
+	for _, c := range ext[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // ExtractArchive extracts all files from an archive and returns them as a map
 // Key: filename, Value: file contents
 func ExtractArchive(archivePath string) (map[string][]byte, error) {
-	ext := strings.ToLower(filepath.Ext(archivePath))
+	var contents map[string][]byte
+	err := guard(archivePath, func() (err error) {
+		contents, err = extractArchive(archivePath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+func extractArchive(archivePath string) (map[string][]byte, error) {
+	ext := Format(archivePath)
 
 	switch ext {
 	case ".zip":
@@ -31,35 +77,68 @@ func ExtractArchive(archivePath string) (map[string][]byte, error) {
 		return extractRAR(archivePath)
 	case ".7z":
 		return extract7Z(archivePath)
+	case ".iso":
+		return extractISO(archivePath)
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		return extractStream(archivePath)
 	default:
 		return nil, fmt.Errorf("unsupported archive format: %s", ext)
 	}
 }
 
-// ListPreviewsInArchive returns a list of all files that can be used as previews
-func ListPreviewsInArchive(archivePath string) ([]PreviewInfo, error) {
-	ext := strings.ToLower(filepath.Ext(archivePath))
-	var files []PreviewInfo
-	var err error
+// ListArchiveFiles returns every (non-directory) entry of an archive without extracting it
+func ListArchiveFiles(archivePath string) ([]PreviewInfo, error) {
+	ext := Format(archivePath)
 
 	switch ext {
 	case ".zip":
-		files, err = listFilesZIP(archivePath)
+		return listFilesZIP(archivePath)
 	case ".rar":
-		files, err = listFilesRAR(archivePath)
+		return listFilesRAR(archivePath)
 	case ".7z":
-		files, err = listFiles7Z(archivePath)
+		return listFiles7Z(archivePath)
+	case ".iso":
+		return listFilesISO(archivePath)
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		return listFilesStream(archivePath)
 	default:
 		return nil, fmt.Errorf("unsupported archive format: %s", ext)
 	}
+}
+
+// ContentSummary returns the number of entries of an archive, their total uncompressed size and
+// their paths. Comics and EPUBs are counted in pages.
+func ContentSummary(archivePath string) (int, int64, []string, error) {
+	files, err := ListArchiveFiles(archivePath)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	var total int64
+	entries := make([]string, len(files))
+	for i, f := range files {
+		total += f.Size
+		entries[i] = f.Path
+	}
+	count := len(files)
+	if IsBook(archivePath) {
+		if pages, err := PageCount(archivePath); err == nil {
+			count = pages
+		}
+	}
+	return count, total, entries, nil
+}
 
+// ListPreviewsInArchive returns a list of all files that can be used as previews, including
+// the entries a preview provider renders
+func ListPreviewsInArchive(archivePath string) ([]PreviewInfo, error) {
+	files, err := ListArchiveFiles(archivePath)
 	if err != nil {
 		return nil, err
 	}
 
 	var previews []PreviewInfo
 	for _, f := range files {
-		if isImageFile(f.Path) || isModelFile(f.Path) || isVideoFile(f.Path) {
+		if isImageFile(f.Path) || isModelFile(f.Path) || isVideoFile(f.Path) || IsRendered(f.Path) {
 			previews = append(previews, f)
 		}
 	}
@@ -75,7 +154,20 @@ func FindFirstImageInArchive(archivePath string) ([]byte, string, error) {
 // FindLargestImageInArchive returns the contents of the largest image file in the archive
 // This is useful for finding high-quality render previews
 func FindLargestImageInArchive(archivePath string) ([]byte, string, error) {
-	ext := strings.ToLower(filepath.Ext(archivePath))
+	var data []byte
+	var name string
+	err := guard(archivePath, func() (err error) {
+		data, name, err = findLargestImage(archivePath)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return data, name, nil
+}
+
+func findLargestImage(archivePath string) ([]byte, string, error) {
+	ext := Format(archivePath)
 
 	switch ext {
 	case ".zip":
@@ -84,19 +176,24 @@ func FindLargestImageInArchive(archivePath string) ([]byte, string, error) {
 		return findLargestImageRAR(archivePath)
 	case ".7z":
 		return findLargestImage7Z(archivePath)
+	case ".iso":
+		return findLargestFileWithFilterISO(archivePath, isImageFile)
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		return findLargestFileWithFilterStream(archivePath, isImageFile)
 	default:
 		return nil, "", fmt.Errorf("unsupported archive format: %s", ext)
 	}
 }
 
-// FindPreviewInArchive returns preview content and filename from archive efficiently
+// FindPreviewInArchive returns preview content and filename from archive efficiently. An entry
+// a preview provider renders comes back as its PNG image.
 func FindPreviewInArchive(archivePath string) ([]byte, string, error) {
 	filename, err := FindPreviewPathInArchive(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
 
-	data, err := GetFileFromArchive(archivePath, filename)
+	data, err := PreviewFile(archivePath, filename)
 	if err != nil {
 		return nil, "", err
 	}
@@ -106,6 +203,16 @@ func FindPreviewInArchive(archivePath string) ([]byte, string, error) {
 
 // FindPreviewPathInArchive returns the internal path of the best preview candidate
 func FindPreviewPathInArchive(archivePath string) (string, error) {
+	op := "preview"
+	if IsBook(archivePath) {
+		op = "cover"
+	}
+	return coalesce(flightKey(op, archivePath, ""), func() (string, error) {
+		return findPreviewPath(archivePath)
+	})
+}
+
+func findPreviewPath(archivePath string) (string, error) {
 	previews, err := ListPreviewsInArchive(archivePath)
 	if err != nil {
 		return "", err
@@ -114,20 +221,32 @@ func FindPreviewPathInArchive(archivePath string) (string, error) {
 		return "", fmt.Errorf("no preview found")
 	}
 
-	// 1. Find largest image
-	var bestImage string
-	var maxImgSize int64
+	// Comics and books are previewed with their cover
+	if IsBook(archivePath) {
+		if cover, err := bookCoverPath(archivePath, previews); err == nil {
+			return cover, nil
+		}
+	}
+
+	// 1. Best ranked image (render-like names and shapes beat texture sheets and bases)
+	if ranked := RankPreviewImages(archivePath, previews); len(ranked) > 0 {
+		return ranked[0].Path, nil
+	}
+
+	// 2. Largest entry a preview provider renders (e.g. a .blend scene)
+	var bestRendered string
+	var maxRenderedSize int64
 	for _, f := range previews {
-		if isImageFile(f.Path) && f.Size > maxImgSize {
-			bestImage = f.Path
-			maxImgSize = f.Size
+		if IsRendered(f.Path) && f.Size > maxRenderedSize {
+			bestRendered = f.Path
+			maxRenderedSize = f.Size
 		}
 	}
-	if bestImage != "" {
-		return bestImage, nil
+	if bestRendered != "" {
+		return bestRendered, nil
 	}
 
-	// 2. Find largest video
+	// 3. Find largest video
 	var bestVideo string
 	var maxVidSize int64
 	for _, f := range previews {
@@ -140,14 +259,14 @@ func FindPreviewPathInArchive(archivePath string) (string, error) {
 		return bestVideo, nil
 	}
 
-	// 3. Find Model with keywords
+	// 4. Find Model with keywords
 	for _, f := range previews {
 		if isModelFile(f.Path) && hasKeyword(f.Path) {
 			return f.Path, nil
 		}
 	}
 
-	// 4. Find largest Model
+	// 5. Find largest Model
 	var bestModel string
 	var maxModelSize int64
 	for _, f := range previews {
@@ -220,7 +339,7 @@ func hasKeyword(filename string) bool {
 }
 
 func findKeywordSTLZIP(archivePath string) ([]byte, string, error) {
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -229,11 +348,14 @@ func findKeywordSTLZIP(archivePath string) ([]byte, string, error) {
 	for _, file := range reader.File {
 		name := strings.ReplaceAll(file.Name, "\\", "/")
 		if !file.FileInfo().IsDir() && isSTLFile(name) && hasKeyword(name) {
+			if !reader.allow(archivePath, file) {
+				continue
+			}
 			rc, err := file.Open()
 			if err != nil {
 				continue
 			}
-			data, err := io.ReadAll(rc)
+			data, err := readAll(archivePath, rc)
 			rc.Close()
 			if err == nil && len(data) > 0 {
 				return data, file.Name, nil
@@ -244,7 +366,7 @@ func findKeywordSTLZIP(archivePath string) ([]byte, string, error) {
 }
 
 func findLargestSTLZIP(archivePath string) ([]byte, string, error) {
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -258,11 +380,14 @@ func findLargestSTLZIP(archivePath string) ([]byte, string, error) {
 		name := strings.ReplaceAll(file.Name, "\\", "/")
 		if !file.FileInfo().IsDir() && isSTLFile(name) {
 			if file.UncompressedSize64 > largestSize {
+				if !reader.allow(archivePath, file) {
+					continue
+				}
 				rc, err := file.Open()
 				if err != nil {
 					continue
 				}
-				data, err := io.ReadAll(rc)
+				data, err := readAll(archivePath, rc)
 				rc.Close()
 				if err == nil && len(data) > 0 {
 					largestData = data
@@ -280,7 +405,7 @@ func findLargestSTLZIP(archivePath string) ([]byte, string, error) {
 }
 
 func findKeywordSTLRAR(archivePath string) ([]byte, string, error) {
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -296,8 +421,8 @@ func findKeywordSTLRAR(archivePath string) ([]byte, string, error) {
 		}
 
 		name := strings.ReplaceAll(header.Name, "\\", "/")
-		if !header.IsDir && isSTLFile(name) && hasKeyword(name) {
-			data, err := io.ReadAll(reader)
+		if !header.IsDir && isSTLFile(name) && hasKeyword(name) && reader.allow(archivePath, header) {
+			data, err := readAll(archivePath, reader)
 			if err == nil && len(data) > 0 {
 				return data, header.Name, nil
 			}
@@ -307,7 +432,7 @@ func findKeywordSTLRAR(archivePath string) ([]byte, string, error) {
 }
 
 func findLargestSTLRAR(archivePath string) ([]byte, string, error) {
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -328,8 +453,8 @@ func findLargestSTLRAR(archivePath string) ([]byte, string, error) {
 
 		name := strings.ReplaceAll(header.Name, "\\", "/")
 		if !header.IsDir && isSTLFile(name) {
-			if header.UnPackedSize > largestSize {
-				data, err := io.ReadAll(reader)
+			if header.UnPackedSize > largestSize && reader.allow(archivePath, header) {
+				data, err := readAll(archivePath, reader)
 				if err == nil && len(data) > 0 {
 					largestData = data
 					largestName = header.Name
@@ -346,7 +471,7 @@ func findLargestSTLRAR(archivePath string) ([]byte, string, error) {
 }
 
 func findKeywordSTL7Z(archivePath string) ([]byte, string, error) {
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -355,11 +480,14 @@ func findKeywordSTL7Z(archivePath string) ([]byte, string, error) {
 	for _, file := range reader.File {
 		name := strings.ReplaceAll(file.Name, "\\", "/")
 		if !file.FileInfo().IsDir() && isSTLFile(name) && hasKeyword(name) {
+			if !reader.allow(archivePath, file) {
+				continue
+			}
 			rc, err := file.Open()
 			if err != nil {
 				continue
 			}
-			data, err := io.ReadAll(rc)
+			data, err := readAll(archivePath, rc)
 			rc.Close()
 			if err == nil && len(data) > 0 {
 				return data, file.Name, nil
@@ -370,7 +498,7 @@ func findKeywordSTL7Z(archivePath string) ([]byte, string, error) {
 }
 
 func findLargestSTL7Z(archivePath string) ([]byte, string, error) {
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -384,11 +512,14 @@ func findLargestSTL7Z(archivePath string) ([]byte, string, error) {
 		name := strings.ReplaceAll(file.Name, "\\", "/")
 		if !file.FileInfo().IsDir() && isSTLFile(name) {
 			if file.UncompressedSize > largestSize {
+				if !reader.allow(archivePath, file) {
+					continue
+				}
 				rc, err := file.Open()
 				if err != nil {
 					continue
 				}
-				data, err := io.ReadAll(rc)
+				data, err := readAll(archivePath, rc)
 				rc.Close()
 				if err == nil && len(data) > 0 {
 					largestData = data
@@ -425,7 +556,20 @@ func isVideoFile(filename string) bool {
 
 // FindLargestVideoInArchive returns the contents of the largest video file in the archive
 func FindLargestVideoInArchive(archivePath string) ([]byte, string, error) {
-	ext := strings.ToLower(filepath.Ext(archivePath))
+	var data []byte
+	var name string
+	err := guard(archivePath, func() (err error) {
+		data, name, err = findLargestVideo(archivePath)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return data, name, nil
+}
+
+func findLargestVideo(archivePath string) ([]byte, string, error) {
+	ext := Format(archivePath)
 
 	switch ext {
 	case ".zip":
@@ -434,13 +578,17 @@ func FindLargestVideoInArchive(archivePath string) ([]byte, string, error) {
 		return findLargestFileWithFilterRAR(archivePath, isVideoFile)
 	case ".7z":
 		return findLargestFileWithFilter7Z(archivePath, isVideoFile)
+	case ".iso":
+		return findLargestFileWithFilterISO(archivePath, isVideoFile)
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		return findLargestFileWithFilterStream(archivePath, isVideoFile)
 	default:
 		return nil, "", fmt.Errorf("unsupported archive format: %s", ext)
 	}
 }
 
 func findLargestFileWithFilter(archivePath string, filter func(string) bool) ([]byte, string, error) {
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -453,11 +601,14 @@ func findLargestFileWithFilter(archivePath string, filter func(string) bool) ([]
 	for _, file := range reader.File {
 		if !file.FileInfo().IsDir() && filter(file.Name) {
 			if file.UncompressedSize64 > uint64(largestSize) {
+				if !reader.allow(archivePath, file) {
+					continue
+				}
 				rc, err := file.Open()
 				if err != nil {
 					continue
 				}
-				data, err := io.ReadAll(rc)
+				data, err := readAll(archivePath, rc)
 				rc.Close()
 				if err == nil && len(data) > 0 {
 					largestData = data
@@ -475,7 +626,7 @@ func findLargestFileWithFilter(archivePath string, filter func(string) bool) ([]
 }
 
 func findLargestFileWithFilterRAR(archivePath string, filter func(string) bool) ([]byte, string, error) {
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -495,8 +646,8 @@ func findLargestFileWithFilterRAR(archivePath string, filter func(string) bool)
 		}
 
 		if !header.IsDir && filter(header.Name) {
-			if header.UnPackedSize > largestSize {
-				data, err := io.ReadAll(reader)
+			if header.UnPackedSize > largestSize && reader.allow(archivePath, header) {
+				data, err := readAll(archivePath, reader)
 				if err == nil && len(data) > 0 {
 					largestData = data
 					largestName = header.Name
@@ -513,7 +664,7 @@ func findLargestFileWithFilterRAR(archivePath string, filter func(string) bool)
 }
 
 func findLargestFileWithFilter7Z(archivePath string, filter func(string) bool) ([]byte, string, error) {
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -526,11 +677,14 @@ func findLargestFileWithFilter7Z(archivePath string, filter func(string) bool) (
 	for _, file := range reader.File {
 		if !file.FileInfo().IsDir() && filter(file.Name) {
 			if int64(file.UncompressedSize) > largestSize {
+				if !reader.allow(archivePath, file) {
+					continue
+				}
 				rc, err := file.Open()
 				if err != nil {
 					continue
 				}
-				data, err := io.ReadAll(rc)
+				data, err := readAll(archivePath, rc)
 				rc.Close()
 				if err == nil && len(data) > 0 {
 					largestData = data
@@ -548,7 +702,7 @@ func findLargestFileWithFilter7Z(archivePath string, filter func(string) bool) (
 }
 
 func findLargestImageZIP(archivePath string) ([]byte, string, error) {
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -562,11 +716,14 @@ func findLargestImageZIP(archivePath string) ([]byte, string, error) {
 		if !file.FileInfo().IsDir() && isImageFile(file.Name) {
 			// Check if this image is larger than the current largest
 			if file.UncompressedSize64 > uint64(largestSize) {
+				if !reader.allow(archivePath, file) {
+					continue
+				}
 				rc, err := file.Open()
 				if err != nil {
 					continue
 				}
-				data, err := io.ReadAll(rc)
+				data, err := readAll(archivePath, rc)
 				rc.Close()
 				if err == nil && len(data) > 0 {
 					largestData = data
@@ -585,7 +742,7 @@ func findLargestImageZIP(archivePath string) ([]byte, string, error) {
 
 // Keep old function for backwards compatibility
 func findFirstImageZIP(archivePath string) ([]byte, string, error) {
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -593,11 +750,14 @@ func findFirstImageZIP(archivePath string) ([]byte, string, error) {
 
 	for _, file := range reader.File {
 		if !file.FileInfo().IsDir() && isImageFile(file.Name) {
+			if !reader.allow(archivePath, file) {
+				continue
+			}
 			rc, err := file.Open()
 			if err != nil {
 				continue
 			}
-			data, err := io.ReadAll(rc)
+			data, err := readAll(archivePath, rc)
 			rc.Close()
 			if err == nil {
 				return data, file.Name, nil
@@ -614,7 +774,7 @@ func findLargestImageRAR(archivePath string) (largestData []byte, largestName st
 			err = fmt.Errorf("rar reader panic: %v", r)
 		}
 	}()
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -632,8 +792,8 @@ func findLargestImageRAR(archivePath string) (largestData []byte, largestName st
 		}
 
 		if !header.IsDir && isImageFile(header.Name) {
-			if header.UnPackedSize > largestSize {
-				data, err := io.ReadAll(reader)
+			if header.UnPackedSize > largestSize && reader.allow(archivePath, header) {
+				data, err := readAll(archivePath, reader)
 				if err == nil && len(data) > 0 {
 					largestData = data
 					largestName = header.Name
@@ -657,7 +817,7 @@ func findFirstImageRAR(archivePath string) (data []byte, name string, err error)
 			err = fmt.Errorf("rar reader panic: %v", r)
 		}
 	}()
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -672,8 +832,8 @@ func findFirstImageRAR(archivePath string) (data []byte, name string, err error)
 			return nil, "", err
 		}
 
-		if !header.IsDir && isImageFile(header.Name) {
-			data, err = io.ReadAll(reader)
+		if !header.IsDir && isImageFile(header.Name) && reader.allow(archivePath, header) {
+			data, err = readAll(archivePath, reader)
 			if err == nil {
 				return data, header.Name, nil
 			}
@@ -683,7 +843,7 @@ func findFirstImageRAR(archivePath string) (data []byte, name string, err error)
 }
 
 func findLargestImage7Z(archivePath string) ([]byte, string, error) {
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -696,11 +856,14 @@ func findLargestImage7Z(archivePath string) ([]byte, string, error) {
 	for _, file := range reader.File {
 		if !file.FileInfo().IsDir() && isImageFile(file.Name) {
 			if int64(file.UncompressedSize) > largestSize {
+				if !reader.allow(archivePath, file) {
+					continue
+				}
 				rc, err := file.Open()
 				if err != nil {
 					continue
 				}
-				data, err := io.ReadAll(rc)
+				data, err := readAll(archivePath, rc)
 				rc.Close()
 				if err == nil && len(data) > 0 {
 					largestData = data
@@ -719,7 +882,7 @@ func findLargestImage7Z(archivePath string) ([]byte, string, error) {
 
 // Keep old function for backwards compatibility
 func findFirstImage7Z(archivePath string) ([]byte, string, error) {
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, "", err
 	}
@@ -727,11 +890,14 @@ func findFirstImage7Z(archivePath string) ([]byte, string, error) {
 
 	for _, file := range reader.File {
 		if !file.FileInfo().IsDir() && isImageFile(file.Name) {
+			if !reader.allow(archivePath, file) {
+				continue
+			}
 			rc, err := file.Open()
 			if err != nil {
 				continue
 			}
-			data, err := io.ReadAll(rc)
+			data, err := readAll(archivePath, rc)
 			rc.Close()
 			if err == nil {
 				return data, file.Name, nil
@@ -744,16 +910,17 @@ func findFirstImage7Z(archivePath string) ([]byte, string, error) {
 // extractZIP extracts files from a ZIP archive
 func extractZIP(archivePath string) (map[string][]byte, error) {
 	contents := make(map[string][]byte)
+	budget := newSizeBudget(archivePath)
 
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open ZIP: %w", err)
 	}
 	defer reader.Close()
 
 	for _, file := range reader.File {
-		// Skip directories
-		if file.FileInfo().IsDir() {
+		// Skip directories and entries over the safety limits
+		if file.FileInfo().IsDir() || !reader.allow(archivePath, file) {
 			continue
 		}
 
@@ -764,7 +931,7 @@ func extractZIP(archivePath string) (map[string][]byte, error) {
 		}
 
 		// Read contents
-		data, err := io.ReadAll(rc)
+		data, err := budget.readAll(rc)
 		rc.Close()
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file %s: %w", file.Name, err)
@@ -785,8 +952,9 @@ func extractRAR(archivePath string) (contents map[string][]byte, err error) {
 		}
 	}()
 	contents = make(map[string][]byte)
+	budget := newSizeBudget(archivePath)
 
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open RAR: %w", err)
 	}
@@ -801,13 +969,13 @@ func extractRAR(archivePath string) (contents map[string][]byte, err error) {
 			return nil, fmt.Errorf("failed to read RAR header: %w", err)
 		}
 
-		// Skip directories
-		if header.IsDir {
+		// Skip directories and entries over the safety limits
+		if header.IsDir || !reader.allow(archivePath, header) {
 			continue
 		}
 
 		// Read contents
-		data, err := io.ReadAll(reader)
+		data, err := budget.readAll(reader)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file %s: %w", header.Name, err)
 		}
@@ -821,16 +989,17 @@ func extractRAR(archivePath string) (contents map[string][]byte, err error) {
 // extract7Z extracts files from a 7Z archive
 func extract7Z(archivePath string) (map[string][]byte, error) {
 	contents := make(map[string][]byte)
+	budget := newSizeBudget(archivePath)
 
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open 7Z: %w", err)
 	}
 	defer reader.Close()
 
 	for _, file := range reader.File {
-		// Skip directories
-		if file.FileInfo().IsDir() {
+		// Skip directories and entries over the safety limits
+		if file.FileInfo().IsDir() || !reader.allow(archivePath, file) {
 			continue
 		}
 
@@ -841,7 +1010,7 @@ func extract7Z(archivePath string) (map[string][]byte, error) {
 		}
 
 		// Read contents
-		data, err := io.ReadAll(rc)
+		data, err := budget.readAll(rc)
 		rc.Close()
 		if err != nil {
 			return nil, fmt.Errorf("failed to read file %s: %w", file.Name, err)
@@ -883,9 +1052,24 @@ func CompareArchiveContents(archive1, archive2 string) (common, unique1, unique2
 	return common, unique1, unique2, nil
 }
 
-// GetFileFromArchive extracts a specific file from an archive efficiently
+// GetFileFromArchive extracts a specific file from an archive efficiently. Callers asking for
+// the same entry at the same time share one extraction and its data, which must not be modified.
 func GetFileFromArchive(archivePath, filename string) ([]byte, error) {
-	ext := strings.ToLower(filepath.Ext(archivePath))
+	return coalesce(flightKey("entry", archivePath, filename), func() ([]byte, error) {
+		var data []byte
+		err := guard(archivePath, func() (err error) {
+			data, err = getFile(archivePath, filename)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	})
+}
+
+func getFile(archivePath, filename string) ([]byte, error) {
+	ext := Format(archivePath)
 
 	switch ext {
 	case ".zip":
@@ -894,13 +1078,17 @@ func GetFileFromArchive(archivePath, filename string) ([]byte, error) {
 		return getFileRAR(archivePath, filename)
 	case ".7z":
 		return getFile7Z(archivePath, filename)
+	case ".iso":
+		return getFileISO(archivePath, filename)
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		return getFileStream(archivePath, filename)
 	default:
 		return nil, fmt.Errorf("unsupported archive format for extraction: %s", ext)
 	}
 }
 
 func getFileZIP(archivePath, filename string) ([]byte, error) {
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, err
 	}
@@ -908,19 +1096,22 @@ func getFileZIP(archivePath, filename string) ([]byte, error) {
 
 	for _, f := range reader.File {
 		if f.Name == filename {
+			if !reader.allow(archivePath, f) {
+				return nil, ErrSuspicious
+			}
 			rc, err := f.Open()
 			if err != nil {
 				return nil, err
 			}
 			defer rc.Close()
-			return io.ReadAll(rc)
+			return readAll(archivePath, rc)
 		}
 	}
 	return nil, fmt.Errorf("file not found in ZIP")
 }
 
 func getFileRAR(archivePath, filename string) ([]byte, error) {
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, err
 	}
@@ -935,14 +1126,17 @@ func getFileRAR(archivePath, filename string) ([]byte, error) {
 			return nil, err
 		}
 		if header.Name == filename {
-			return io.ReadAll(reader)
+			if !reader.allow(archivePath, header) {
+				return nil, ErrSuspicious
+			}
+			return readAll(archivePath, reader)
 		}
 	}
 	return nil, fmt.Errorf("file not found in RAR")
 }
 
 func getFile7Z(archivePath, filename string) ([]byte, error) {
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, err
 	}
@@ -950,12 +1144,15 @@ func getFile7Z(archivePath, filename string) ([]byte, error) {
 
 	for _, f := range reader.File {
 		if f.Name == filename {
+			if !reader.allow(archivePath, f) {
+				return nil, ErrSuspicious
+			}
 			rc, err := f.Open()
 			if err != nil {
 				return nil, err
 			}
 			defer rc.Close()
-			return io.ReadAll(rc)
+			return readAll(archivePath, rc)
 		}
 	}
 	return nil, fmt.Errorf("file not found in 7Z")
@@ -977,7 +1174,7 @@ func AreFilesIdentical(data1, data2 []byte) bool {
 }
 
 func listFilesZIP(archivePath string) ([]PreviewInfo, error) {
-	reader, err := zip.OpenReader(archivePath)
+	reader, err := openZIP(archivePath)
 	if err != nil {
 		return nil, err
 	}
@@ -987,8 +1184,10 @@ func listFilesZIP(archivePath string) ([]PreviewInfo, error) {
 	for _, f := range reader.File {
 		if !f.FileInfo().IsDir() {
 			files = append(files, PreviewInfo{
-				Path: f.Name,
-				Size: int64(f.UncompressedSize64),
+				Path:           f.Name,
+				Size:           int64(f.UncompressedSize64),
+				CompressedSize: int64(f.CompressedSize64),
+				CRC32:          f.CRC32,
 			})
 		}
 	}
@@ -1003,7 +1202,7 @@ func listFilesRAR(archivePath string) (files []PreviewInfo, err error) {
 		}
 	}()
 
-	reader, err := rardecode.OpenReader(archivePath)
+	reader, err := openRAR(archivePath)
 	if err != nil {
 		return nil, err
 	}
@@ -1019,8 +1218,9 @@ func listFilesRAR(archivePath string) (files []PreviewInfo, err error) {
 		}
 		if !header.IsDir {
 			files = append(files, PreviewInfo{
-				Path: header.Name,
-				Size: header.UnPackedSize,
+				Path:           header.Name,
+				Size:           header.UnPackedSize,
+				CompressedSize: header.PackedSize,
 			})
 		}
 	}
@@ -1028,7 +1228,7 @@ func listFilesRAR(archivePath string) (files []PreviewInfo, err error) {
 }
 
 func listFiles7Z(archivePath string) ([]PreviewInfo, error) {
-	reader, err := sevenzip.OpenReader(archivePath)
+	reader, err := open7Z(archivePath)
 	if err != nil {
 		return nil, err
 	}
@@ -1038,8 +1238,9 @@ func listFiles7Z(archivePath string) ([]PreviewInfo, error) {
 	for _, f := range reader.File {
 		if !f.FileInfo().IsDir() {
 			files = append(files, PreviewInfo{
-				Path: f.Name,
-				Size: int64(f.UncompressedSize),
+				Path:  f.Name,
+				Size:  int64(f.UncompressedSize),
+				CRC32: f.CRC32,
 			})
 		}
 	}
@@ -0,0 +1,46 @@
+package archive
+
+import (
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Previews, thumbnails and visual hashes often need the same entry at the same time: the
+// dashboard asks for the preview the visual analysis is extracting, or for the previews of a
+// group of copies of one archive. Overlapping requests for an entry share one extraction.
+var flights singleflight.Group
+
+var contentKey atomic.Pointer[func(string) string]
+
+// SetContentKey sets how the content hash of an archive is found (e.g. in the hash cache, without
+// reading the file), so that extractions from byte-identical archives share work too. The
+// function returns "" when the hash is not known; nil turns the lookup off.
+func SetContentKey(fn func(archivePath string) string) {
+	if fn == nil {
+		contentKey.Store(nil)
+		return
+	}
+	contentKey.Store(&fn)
+}
+
+// flightKey identifies the archive an operation works on: by content when its hash is known,
+// otherwise by path
+func flightKey(op, archivePath, internalPath string) string {
+	id := "path:" + archivePath
+	if fn := contentKey.Load(); fn != nil {
+		if hash := (*fn)(archivePath); hash != "" {
+			id = "sha256:" + hash
+		}
+	}
+	return op + "\x00" + id + "\x00" + internalPath
+}
+
+// coalesce runs fn once for all the callers asking for the same key at the same time. The
+// result is shared: callers must not modify it.
+func coalesce[T any](key string, fn func() (T, error)) (T, error) {
+	v, err, _ := flights.Do(key, func() (any, error) {
+		return fn()
+	})
+	return v.(T), err
+}
@@ -0,0 +1,605 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/vfs"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf16"
+)
+
+// Disc images are read in place: ISO 9660 (with Joliet or Rock Ridge names when present) and
+// UDF, the file system of DVD and most modern images. Entries point to their extents in the
+// image, so listing an image only reads its directories.
+
+const (
+	discSector  = 2048
+	maxDirSize  = 16 << 20 // A directory larger than this is a corrupt or hostile image
+	maxDirDepth = 64
+)
+
+var errNotDiscImage = errors.New("not an ISO 9660 or UDF image")
+
+type discImage struct {
+	vfs.File
+	Files []*discFile
+
+	// UDF partitions: partition reference -> first sector
+	partitions []int64
+}
+
+// discFile is a regular file of a disc image
+type discFile struct {
+	Name    string // Slash-separated path inside the image
+	Size    int64
+	extents []discExtent
+	data    []byte // Contents embedded in a UDF file entry
+}
+
+// discExtent is a run of the file's bytes; a negative offset is a sparse (zero-filled) run
+type discExtent struct {
+	offset, length int64
+}
+
+func openISO(archivePath string) (*discImage, error) {
+	f, err := vfs.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	img := &discImage{File: f}
+
+	// Bridge images carry both file systems: UDF has the long names and the large files
+	if udfErr := img.readUDF(); udfErr != nil {
+		img.Files = nil
+		if err := img.readISO9660(); err != nil {
+			f.Close()
+			if errors.Is(err, errNotDiscImage) && !errors.Is(udfErr, errNotDiscImage) {
+				return nil, udfErr
+			}
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// Open returns a reader over the file's contents. A read past the end of a truncated image is
+// reported as io.ErrUnexpectedEOF.
+func (img *discImage) Open(f *discFile) io.Reader {
+	if f.data != nil {
+		return bytes.NewReader(f.data)
+	}
+	readers := make([]io.Reader, 0, len(f.extents))
+	for _, e := range f.extents {
+		if e.offset < 0 {
+			readers = append(readers, io.LimitReader(zeroReader{}, e.length))
+		} else {
+			readers = append(readers, io.NewSectionReader(img.File, e.offset, e.length))
+		}
+	}
+	return &exactReader{r: io.MultiReader(readers...), remaining: f.Size}
+}
+
+func (img *discImage) readSectors(offset, length int64) ([]byte, error) {
+	if length > maxDirSize || offset < 0 || offset+length > img.Size() {
+		return nil, fmt.Errorf("invalid extent at %d (%d bytes)", offset, length)
+	}
+	buf := make([]byte, length)
+	if _, err := img.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// --- ISO 9660 ---
+
+func (img *discImage) readISO9660() error {
+	var primary, joliet []byte
+	for sector := int64(16); sector < 16+64; sector++ {
+		vd := make([]byte, discSector)
+		if _, err := img.ReadAt(vd, sector*discSector); err != nil || string(vd[1:6]) != "CD001" || vd[0] == 255 {
+			break // 255 is the set terminator
+		}
+		switch vd[0] {
+		case 1:
+			primary = vd[156:190]
+		case 2:
+			// Joliet is a supplementary descriptor announcing UCS-2 names
+			if esc := string(vd[88:91]); esc == "%/@" || esc == "%/C" || esc == "%/E" {
+				joliet = vd[156:190]
+			}
+		}
+	}
+
+	root, isJoliet := primary, false
+	if joliet != nil {
+		root, isJoliet = joliet, true
+	}
+	if root == nil {
+		return errNotDiscImage
+	}
+	extent, size := int64(binary.LittleEndian.Uint32(root[2:])), int64(binary.LittleEndian.Uint32(root[10:]))
+	return img.walkISODir(extent, size, "", isJoliet, map[int64]bool{}, 0)
+}
+
+func (img *discImage) walkISODir(extent, size int64, prefix string, joliet bool, visited map[int64]bool, depth int) error {
+	if depth > maxDirDepth || visited[extent] {
+		return nil
+	}
+	visited[extent] = true
+	data, err := img.readSectors(extent*discSector, size)
+	if err != nil {
+		return err
+	}
+
+	var multi *discFile // File whose records span several extents
+	for off := 0; off < len(data); {
+		recLen := int(data[off])
+		if recLen == 0 { // Records never cross sectors: the rest of this one is padding
+			off = (off/discSector + 1) * discSector
+			continue
+		}
+		if recLen < 34 || off+recLen > len(data) {
+			break
+		}
+		rec := data[off : off+recLen]
+		off += recLen
+
+		nameLen := int(rec[32])
+		if 33+nameLen > recLen || (nameLen == 1 && rec[33] <= 1) { // "." and ".."
+			continue
+		}
+		name := isoName(rec[33:33+nameLen], joliet)
+		if !joliet {
+			if rr := rockRidgeName(rec[min(33+nameLen+1-nameLen%2, recLen):]); rr != "" {
+				name = rr
+			}
+		}
+		name = path.Join(prefix, strings.ReplaceAll(name, "/", "_"))
+		start := int64(binary.LittleEndian.Uint32(rec[2:])) * discSector
+		length := int64(binary.LittleEndian.Uint32(rec[10:]))
+		flags := rec[25]
+
+		if flags&0x02 != 0 {
+			if err := img.walkISODir(start/discSector, length, name, joliet, visited, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if multi != nil && multi.Name == name {
+			multi.extents = append(multi.extents, discExtent{start, length})
+			multi.Size += length
+		} else {
+			multi = &discFile{Name: name, Size: length, extents: []discExtent{{start, length}}}
+			img.Files = append(img.Files, multi)
+		}
+		if flags&0x80 == 0 {
+			multi = nil
+		}
+	}
+	return nil
+}
+
+// isoName decodes a file identifier and drops its ";1" version suffix
+func isoName(id []byte, joliet bool) string {
+	var name string
+	if joliet {
+		name = decodeUTF16BE(id)
+	} else {
+		name = string(id)
+	}
+	if i := strings.IndexByte(name, ';'); i >= 0 {
+		name = name[:i]
+	}
+	if !joliet {
+		name = strings.TrimSuffix(name, ".") // "README." has no extension
+	}
+	return name
+}
+
+// rockRidgeName returns the POSIX name stored in the NM entries of a system use area
+func rockRidgeName(su []byte) string {
+	var name []byte
+	for len(su) >= 4 {
+		l := int(su[2])
+		if l < 4 || l > len(su) {
+			break
+		}
+		if string(su[:2]) == "NM" && l >= 5 && su[4]&0x06 == 0 {
+			name = append(name, su[5:l]...)
+		}
+		if string(su[:2]) == "ST" {
+			break
+		}
+		su = su[l:]
+	}
+	return string(name)
+}
+
+// --- UDF ---
+
+// udfAD is a long allocation descriptor: an extent inside a partition
+type udfAD struct {
+	length    uint32
+	block     uint32
+	partition uint16
+}
+
+func parseLongAD(b []byte) udfAD {
+	return udfAD{
+		length:    binary.LittleEndian.Uint32(b) & 0x3FFFFFFF,
+		block:     binary.LittleEndian.Uint32(b[4:]),
+		partition: binary.LittleEndian.Uint16(b[8:]),
+	}
+}
+
+func (img *discImage) readUDF() error {
+	// The volume recognition sequence announces UDF with an NSR descriptor
+	nsr := false
+	for sector := int64(16); sector < 16+64; sector++ {
+		id := make([]byte, 6)
+		if _, err := img.ReadAt(id, sector*discSector); err != nil {
+			break
+		}
+		if s := string(id[1:6]); s == "NSR02" || s == "NSR03" {
+			nsr = true
+			break
+		} else if s == "TEA01" {
+			break
+		}
+	}
+	if !nsr {
+		return errNotDiscImage
+	}
+
+	anchor, err := img.readSectors(256*discSector, discSector)
+	if err != nil || binary.LittleEndian.Uint16(anchor) != 2 {
+		return fmt.Errorf("UDF anchor not found")
+	}
+	vdsLength := int64(binary.LittleEndian.Uint32(anchor[16:]))
+	vdsStart := int64(binary.LittleEndian.Uint32(anchor[20:]))
+
+	starts := make(map[uint16]int64) // partition number -> first sector
+	var partitionNumbers []uint16    // partition reference -> partition number
+	var fsd udfAD
+	for i := int64(0); i < vdsLength/discSector; i++ {
+		d, err := img.readSectors((vdsStart+i)*discSector, discSector)
+		if err != nil {
+			return err
+		}
+		tag := binary.LittleEndian.Uint16(d)
+		if tag == 8 { // Terminating descriptor
+			break
+		}
+		switch tag {
+		case 5: // Partition descriptor
+			starts[binary.LittleEndian.Uint16(d[22:])] = int64(binary.LittleEndian.Uint32(d[188:]))
+		case 6: // Logical volume descriptor
+			if blockSize := binary.LittleEndian.Uint32(d[212:]); blockSize != discSector {
+				return fmt.Errorf("unsupported UDF block size %d", blockSize)
+			}
+			fsd = parseLongAD(d[248:])
+			partitionNumbers = nil
+			maps := d[440:]
+			for n := binary.LittleEndian.Uint32(d[268:]); n > 0 && len(maps) >= 2 && int(maps[1]) >= 2 && int(maps[1]) <= len(maps); n-- {
+				if maps[0] == 1 {
+					partitionNumbers = append(partitionNumbers, binary.LittleEndian.Uint16(maps[4:]))
+				} else {
+					// Metadata and sparing partitions (Blu-ray, UDF 2.5+) are not supported
+					partitionNumbers = append(partitionNumbers, 0xFFFF)
+				}
+				maps = maps[maps[1]:]
+			}
+		}
+	}
+
+	img.partitions = make([]int64, len(partitionNumbers))
+	for ref, number := range partitionNumbers {
+		start, ok := starts[number]
+		if !ok {
+			start = -1
+		}
+		img.partitions[ref] = start
+	}
+
+	d, err := img.readBlock(fsd)
+	if err != nil || binary.LittleEndian.Uint16(d) != 256 {
+		return fmt.Errorf("UDF file set not found")
+	}
+	return img.walkUDFDir(parseLongAD(d[400:]), "", map[int64]bool{}, 0)
+}
+
+// blockOffset returns the position in the image of a block of a partition
+func (img *discImage) blockOffset(partition uint16, block uint32) (int64, error) {
+	if int(partition) >= len(img.partitions) || img.partitions[partition] < 0 {
+		return 0, fmt.Errorf("unsupported UDF partition %d", partition)
+	}
+	return (img.partitions[partition] + int64(block)) * discSector, nil
+}
+
+func (img *discImage) readBlock(ad udfAD) ([]byte, error) {
+	offset, err := img.blockOffset(ad.partition, ad.block)
+	if err != nil {
+		return nil, err
+	}
+	return img.readSectors(offset, discSector)
+}
+
+// readFileEntry reads a (extended) file entry and the allocation of its contents
+func (img *discImage) readFileEntry(icb udfAD, name string) (*discFile, error) {
+	d, err := img.readBlock(icb)
+	if err != nil {
+		return nil, err
+	}
+	var lengths, base int
+	switch binary.LittleEndian.Uint16(d) {
+	case 261: // File entry
+		lengths, base = 168, 176
+	case 266: // Extended file entry
+		lengths, base = 208, 216
+	default:
+		return nil, fmt.Errorf("invalid UDF file entry for %s", name)
+	}
+	f := &discFile{Name: name, Size: int64(binary.LittleEndian.Uint64(d[56:]))}
+
+	start := base + int(binary.LittleEndian.Uint32(d[lengths:]))
+	end := start + int(binary.LittleEndian.Uint32(d[lengths+4:]))
+	if start > end || end > len(d) {
+		return nil, fmt.Errorf("invalid UDF allocation for %s", name)
+	}
+	ads := d[start:end]
+
+	var adSize int
+	switch binary.LittleEndian.Uint16(d[34:]) & 7 {
+	case 0:
+		adSize = 8
+	case 1:
+		adSize = 16
+	case 3: // Contents embedded in the entry
+		if f.Size > int64(len(ads)) {
+			return nil, fmt.Errorf("invalid UDF embedded data for %s", name)
+		}
+		f.data = ads[:f.Size]
+		return f, nil
+	default:
+		return nil, fmt.Errorf("unsupported UDF allocation for %s", name)
+	}
+
+	remaining := f.Size
+	for ; len(ads) >= adSize && remaining > 0; ads = ads[adSize:] {
+		raw := binary.LittleEndian.Uint32(ads)
+		ad := udfAD{length: raw & 0x3FFFFFFF, block: binary.LittleEndian.Uint32(ads[4:]), partition: icb.partition}
+		if adSize == 16 {
+			ad.partition = binary.LittleEndian.Uint16(ads[8:])
+		}
+		if ad.length == 0 {
+			break
+		}
+		length := min(int64(ad.length), remaining)
+		switch raw >> 30 {
+		case 0: // Recorded
+			offset, err := img.blockOffset(ad.partition, ad.block)
+			if err != nil {
+				return nil, err
+			}
+			f.extents = append(f.extents, discExtent{offset, length})
+		case 1, 2: // Allocated or not, but unrecorded: reads as zeros
+			f.extents = append(f.extents, discExtent{-1, length})
+		default:
+			return nil, fmt.Errorf("fragmented UDF allocation for %s is not supported", name)
+		}
+		remaining -= length
+	}
+	return f, nil
+}
+
+func (img *discImage) walkUDFDir(icb udfAD, prefix string, visited map[int64]bool, depth int) error {
+	key := int64(icb.partition)<<32 | int64(icb.block)
+	if depth > maxDirDepth || visited[key] {
+		return nil
+	}
+	visited[key] = true
+
+	dir, err := img.readFileEntry(icb, prefix)
+	if err != nil {
+		return err
+	}
+	if dir.Size > maxDirSize {
+		return fmt.Errorf("UDF directory %s is too large", prefix)
+	}
+	data, err := io.ReadAll(img.Open(dir))
+	if err != nil {
+		return err
+	}
+
+	// File identifier descriptors, each padded to 4 bytes
+	for off := 0; off+38 <= len(data); {
+		fid := data[off:]
+		if binary.LittleEndian.Uint16(fid) != 257 {
+			break
+		}
+		characteristics := fid[18]
+		nameLen := int(fid[19])
+		implLen := int(binary.LittleEndian.Uint16(fid[36:]))
+		recLen := (38 + implLen + nameLen + 3) &^ 3
+		if 38+implLen+nameLen > len(fid) {
+			break
+		}
+		off += recLen
+
+		if characteristics&0x0C != 0 { // Deleted entries and the parent link
+			continue
+		}
+		name := path.Join(prefix, strings.ReplaceAll(udfName(fid[38+implLen:38+implLen+nameLen]), "/", "_"))
+		childICB := parseLongAD(fid[20:])
+		if characteristics&0x02 != 0 {
+			if err := img.walkUDFDir(childICB, name, visited, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		f, err := img.readFileEntry(childICB, name)
+		if err != nil {
+			return err
+		}
+		img.Files = append(img.Files, f)
+	}
+	return nil
+}
+
+// udfName decodes an OSTA compressed unicode identifier
+func udfName(id []byte) string {
+	if len(id) == 0 {
+		return ""
+	}
+	switch id[0] {
+	case 8:
+		runes := make([]rune, len(id)-1)
+		for i, b := range id[1:] {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	case 16:
+		return decodeUTF16BE(id[1:])
+	}
+	return string(id[1:])
+}
+
+func decodeUTF16BE(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// exactReader fails with io.ErrUnexpectedEOF when the underlying reader ends early
+type exactReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (r *exactReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	if err == io.EOF && r.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// --- Archive operations ---
+
+func listFilesISO(archivePath string) ([]PreviewInfo, error) {
+	img, err := openISO(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer img.Close()
+
+	files := make([]PreviewInfo, len(img.Files))
+	for i, f := range img.Files {
+		files[i] = PreviewInfo{Path: f.Name, Size: f.Size}
+	}
+	return files, nil
+}
+
+// extractISO extracts files from a disc image
+func extractISO(archivePath string) (map[string][]byte, error) {
+	contents := make(map[string][]byte)
+	budget := newSizeBudget(archivePath)
+
+	img, err := openISO(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open disc image: %w", err)
+	}
+	defer img.Close()
+
+	for _, f := range img.Files {
+		if !img.allow(archivePath, f) {
+			continue
+		}
+		data, err := budget.readAll(img.Open(f))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", f.Name, err)
+		}
+		contents[f.Name] = data
+	}
+	return contents, nil
+}
+
+func getFileISO(archivePath, filename string) ([]byte, error) {
+	img, err := openISO(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer img.Close()
+
+	for _, f := range img.Files {
+		if f.Name == filename {
+			if !img.allow(archivePath, f) {
+				return nil, ErrSuspicious
+			}
+			return readAll(archivePath, img.Open(f))
+		}
+	}
+	return nil, fmt.Errorf("file not found in disc image")
+}
+
+func findLargestFileWithFilterISO(archivePath string, filter func(string) bool) ([]byte, string, error) {
+	img, err := openISO(archivePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer img.Close()
+
+	// Extents are addressable, so only the largest match is read
+	var largest *discFile
+	for _, f := range img.Files {
+		if filter(f.Name) && f.Size > 0 && (largest == nil || f.Size > largest.Size) && img.allow(archivePath, f) {
+			largest = f
+		}
+	}
+	if largest == nil {
+		return nil, "", fmt.Errorf("no matching file found")
+	}
+	data, err := readAll(archivePath, img.Open(largest))
+	if err != nil {
+		return nil, "", err
+	}
+	return data, largest.Name, nil
+}
+
+// verifyISO reads every file to the end: images carry no checksums, but a truncated image
+// fails on the files past its end. ".img" files that are not optical images (raw disk dumps)
+// are not verified.
+func verifyISO(archivePath string) error {
+	img, err := openISO(archivePath)
+	if errors.Is(err, errNotDiscImage) && !strings.EqualFold(path.Ext(archivePath), ".iso") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open disc image: %w", err)
+	}
+	defer img.Close()
+
+	for _, f := range img.Files {
+		if !img.allow(archivePath, f) {
+			continue
+		}
+		if _, err := io.Copy(io.Discard, watch(archivePath, img.Open(f))); err != nil {
+			return fmt.Errorf("failed to read file %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
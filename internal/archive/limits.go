@@ -0,0 +1,236 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/membudget"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Limits bounds the resources archive operations may use
+type Limits struct {
+	Workers         int           // Archive operations allowed to run at the same time
+	Timeout         time.Duration // Per-archive time limit (0 disables)
+	MaxUncompressed int64         // Bytes that may be decompressed from a single archive (0 disables)
+	MaxEntrySize    int64         // Largest single entry that is decompressed (0 disables)
+	MaxRatio        float64       // Uncompressed-to-compressed ratio above which an entry is skipped (0 disables)
+}
+
+// DefaultLimits keeps a single pathological archive from stalling or exhausting the machine
+var DefaultLimits = Limits{
+	Workers:         4,
+	Timeout:         5 * time.Minute,
+	MaxUncompressed: 8 << 30, // 8 GB
+	MaxEntrySize:    4 << 30, // 4 GB
+	MaxRatio:        1000,
+}
+
+var (
+	ErrTimeout  = errors.New("archive operation timed out")
+	ErrTooLarge = errors.New("archive exceeds the uncompressed size limit")
+	// ErrSuspicious is returned for an entry skipped by the zip bomb safeguards
+	ErrSuspicious = errors.New("entry exceeds the archive safety limits")
+)
+
+var (
+	limitsMu    sync.RWMutex
+	limits      = DefaultLimits
+	slots       = make(chan struct{}, DefaultLimits.Workers)
+	autoWorkers int // Pool size measured for the disk (see SetAutoWorkers); 0 when unknown
+)
+
+// SetAutoWorkers sets the worker count SetLimits uses when none is given, as measured for the
+// library's disk by the iotune package; 0 goes back to the default
+func SetAutoWorkers(n int) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	autoWorkers = n
+}
+
+// SetLimits replaces the global limits. Non-positive worker counts fall back to the measured
+// count (SetAutoWorkers), then to the default.
+func SetLimits(l Limits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if l.Workers <= 0 {
+		l.Workers = autoWorkers
+	}
+	if l.Workers <= 0 {
+		l.Workers = DefaultLimits.Workers
+	}
+	if l.Workers != cap(slots) {
+		// Operations already running keep (and release) the previous pool
+		slots = make(chan struct{}, l.Workers)
+	}
+	limits = l
+}
+
+// GetLimits returns the limits currently in effect
+func GetLimits() Limits {
+	limitsMu.RLock()
+	defer limitsMu.RUnlock()
+	return limits
+}
+
+// guard runs an archive operation inside the global worker pool, within the per-archive
+// timeout. The time starts once the operation has a pool slot, so archives queued behind busy
+// workers are not given up before they start. The readers offer no cancellation: once the
+// caller gives up, the entries of the archive read through watch fail with ErrTimeout, which
+// ends the operation and frees its slot.
+func guard(archivePath string, op func() error) error {
+	limitsMu.RLock()
+	l, pool := limits, slots
+	limitsMu.RUnlock()
+
+	pool <- struct{}{}
+	running := startOperation(archivePath)
+	done := make(chan error, 1)
+	go func() {
+		defer func() { <-pool }()
+		defer running.end()
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("⚠️  Archive Recovery: Panic while reading %s: %v", archivePath, r)
+				done <- fmt.Errorf("archive reader panic: %v", r)
+			}
+		}()
+		done <- op()
+	}()
+
+	if l.Timeout <= 0 {
+		return <-done
+	}
+
+	timer := time.NewTimer(l.Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		running.giveUp()
+		log.Printf("⏱️  Gave up on %s after %v", filepath.Base(archivePath), l.Timeout)
+		return fmt.Errorf("%w after %v", ErrTimeout, l.Timeout)
+	}
+}
+
+// operations counts, per archive, the guarded operations whose caller still waits for them and
+// those it gave up on. Entry readers cannot tell which operation they belong to, so an archive's
+// reads only stop once no caller waits for any of its operations.
+var (
+	operationsMu sync.Mutex
+	operations   = map[string]*archiveOperations{}
+)
+
+type archiveOperations struct {
+	waited, abandoned int
+}
+
+// operation is one guarded operation on an archive
+type operation struct {
+	path      string
+	abandoned bool
+}
+
+func startOperation(archivePath string) *operation {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	ops := operations[archivePath]
+	if ops == nil {
+		ops = &archiveOperations{}
+		operations[archivePath] = ops
+	}
+	ops.waited++
+	return &operation{path: archivePath}
+}
+
+// giveUp records that the caller of the operation stopped waiting for it
+func (o *operation) giveUp() {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	ops := operations[o.path]
+	ops.waited--
+	ops.abandoned++
+	o.abandoned = true
+}
+
+// end records that the operation returned
+func (o *operation) end() {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	ops := operations[o.path]
+	if o.abandoned {
+		ops.abandoned--
+	} else {
+		ops.waited--
+	}
+	if ops.waited == 0 && ops.abandoned == 0 {
+		delete(operations, o.path)
+	}
+}
+
+// givenUp reports whether every caller of the operations running on an archive gave up
+func givenUp(archivePath string) bool {
+	operationsMu.Lock()
+	defer operationsMu.Unlock()
+	ops := operations[archivePath]
+	return ops != nil && ops.waited == 0 && ops.abandoned > 0
+}
+
+// watch wraps a reader of an archive's contents so that it fails with ErrTimeout once nobody
+// waits for the archive any more (see guard)
+func watch(archivePath string, r io.Reader) io.Reader {
+	return &watchedReader{path: archivePath, r: r}
+}
+
+type watchedReader struct {
+	path string
+	r    io.Reader
+}
+
+func (w *watchedReader) Read(b []byte) (int, error) {
+	if givenUp(w.path) {
+		return 0, ErrTimeout
+	}
+	return w.r.Read(b)
+}
+
+// sizeBudget tracks how many bytes one operation may still decompress from an archive
+type sizeBudget struct {
+	path      string
+	remaining int64 // < 0 means unlimited
+}
+
+func newSizeBudget(archivePath string) *sizeBudget {
+	max := GetLimits().MaxUncompressed
+	if max <= 0 {
+		return &sizeBudget{path: archivePath, remaining: -1}
+	}
+	return &sizeBudget{path: archivePath, remaining: max}
+}
+
+// readAll reads one entry, refusing to decompress past the remaining budget. The buffer counts
+// against the memory budget while it is read (see the membudget package).
+func (b *sizeBudget) readAll(r io.Reader) ([]byte, error) {
+	r = watch(b.path, r)
+	if b.remaining < 0 {
+		return membudget.ReadAll(r)
+	}
+	data, err := membudget.ReadAll(io.LimitReader(r, b.remaining+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > b.remaining {
+		return nil, fmt.Errorf("%w (%d MB)", ErrTooLarge, GetLimits().MaxUncompressed>>20)
+	}
+	b.remaining -= int64(len(data))
+	return data, nil
+}
+
+// readAll reads a single entry of an archive within the uncompressed size limit
+func readAll(archivePath string, r io.Reader) ([]byte, error) {
+	return newSizeBudget(archivePath).readAll(r)
+}
@@ -0,0 +1,80 @@
+package archive
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withLimits runs the test under l, restoring the limits in effect afterwards
+func withLimits(t *testing.T, l Limits) {
+	t.Helper()
+	prev := GetLimits()
+	SetLimits(l)
+	t.Cleanup(func() { SetLimits(prev) })
+}
+
+// endless is an entry that never ends, as a reader stuck on a pathological archive
+type endless struct{}
+
+func (endless) Read(b []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return len(b), nil
+}
+
+func TestGuardTimeStartsWithSlot(t *testing.T) {
+	withLimits(t, Limits{Workers: 1, Timeout: 200 * time.Millisecond})
+
+	// The second operation waits 150ms for the slot, then needs 150ms of its own: within
+	// its timeout once it started
+	errs := make(chan error, 2)
+	for _, path := range []string{"/lib/a.zip", "/lib/b.zip"} {
+		go func() {
+			errs <- guard(path, func() error {
+				time.Sleep(150 * time.Millisecond)
+				return nil
+			})
+		}()
+	}
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("queued operation: %v", err)
+		}
+	}
+}
+
+func TestGuardStopsAbandonedReads(t *testing.T) {
+	withLimits(t, Limits{Workers: 1, Timeout: 50 * time.Millisecond})
+
+	ended := make(chan error, 1)
+	err := guard("/lib/bomb.zip", func() error {
+		_, err := io.Copy(io.Discard, watch("/lib/bomb.zip", endless{}))
+		ended <- err
+		return err
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("guard = %v, want ErrTimeout", err)
+	}
+	select {
+	case err := <-ended:
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("abandoned read ended with %v, want ErrTimeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the abandoned read keeps going")
+	}
+
+	// Its slot is free again, and the archive can be read once more
+	data, err := func() (data []byte, err error) {
+		err = guard("/lib/bomb.zip", func() error {
+			data, err = readAll("/lib/bomb.zip", strings.NewReader("ok"))
+			return err
+		})
+		return data, err
+	}()
+	if err != nil || string(data) != "ok" {
+		t.Errorf("next read = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,97 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/vfs"
+	"archive/zip"
+	"io"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/nwaples/rardecode/v2"
+)
+
+// Archive readers open local files directly (which also follows multi-volume sets) and read
+// remote objects through the vfs package: ZIP and 7Z with ranged reads of their directories,
+// RAR as a single stream.
+
+type zipArchive struct {
+	*zip.Reader
+	io.Closer
+}
+
+type rarArchive struct {
+	*rardecode.Reader
+	io.Closer
+}
+
+type sevenZipArchive struct {
+	*sevenzip.Reader
+	io.Closer
+	bomb bool // Contents expand beyond the ratio limit as a whole
+}
+
+func openZIP(archivePath string) (*zipArchive, error) {
+	if !vfs.IsRemote(archivePath) {
+		rc, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		return &zipArchive{Reader: &rc.Reader, Closer: rc}, nil
+	}
+
+	f, err := vfs.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	r, err := zip.NewReader(f, f.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &zipArchive{Reader: r, Closer: f}, nil
+}
+
+func openRAR(archivePath string) (*rarArchive, error) {
+	if !vfs.IsRemote(archivePath) {
+		rc, err := rardecode.OpenReader(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		return &rarArchive{Reader: &rc.Reader, Closer: rc}, nil
+	}
+
+	f, err := vfs.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	r, err := rardecode.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &rarArchive{Reader: r, Closer: f}, nil
+}
+
+func open7Z(archivePath string) (*sevenZipArchive, error) {
+	if !vfs.IsRemote(archivePath) {
+		rc, err := sevenzip.OpenReader(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		a := &sevenZipArchive{Reader: &rc.Reader, Closer: rc}
+		a.checkSolidRatio(archivePath, archiveSize(archivePath))
+		return a, nil
+	}
+
+	f, err := vfs.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	r, err := sevenzip.NewReader(f, f.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	a := &sevenZipArchive{Reader: r, Closer: f}
+	a.checkSolidRatio(archivePath, f.Size())
+	return a, nil
+}
@@ -0,0 +1,297 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// PreviewCandidate is an image of an archive with the score the preview heuristics gave it
+type PreviewCandidate struct {
+	Path    string   `json:"path"`
+	Size    int64    `json:"size"`
+	Width   int      `json:"width,omitempty"`
+	Height  int      `json:"height,omitempty"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"` // Why the score went up or down, e.g. "name: render"
+}
+
+const (
+	previewHeadBytes  = 64 << 10 // Enough for the dimensions of JPEG, PNG and WebP files
+	previewMeasureTop = 8        // Only the largest images are opened to read their dimensions
+)
+
+var (
+	// Words in a file name that suggest the promotional render
+	previewNameHints = []string{"render", "preview", "promo", "cover", "showcase", "poster", "beauty", "hero", "main", "front"}
+	// Folders that usually hold renders
+	previewFolderHints = []string{"preview", "previews", "render", "renders", "images", "pictures", "promo"}
+	// Tokens of PBR maps and texture sheets, never a good preview
+	textureMapTokens = []string{"normal", "nrm", "nor", "rough", "roughness", "metallic", "metalness", "spec", "specular",
+		"gloss", "disp", "displacement", "height", "ao", "occlusion", "bump", "albedo", "diffuse", "basecolor", "emissive",
+		"opacity", "mask", "uv", "atlas", "texture", "textures", "tex"}
+	// Tokens of printable bases and supports sold alongside a model
+	accessoryTokens = []string{"base", "bases", "support", "supports", "plate", "sheet"}
+)
+
+// RankPreviewsInArchive lists an archive and ranks its images as preview candidates
+func RankPreviewsInArchive(archivePath string) ([]PreviewCandidate, error) {
+	files, err := ListArchiveFiles(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	return RankPreviewImages(archivePath, files), nil
+}
+
+// RankPreviewImages scores the images of an archive as preview candidates, best first.
+// The largest image is only a starting point: render-like names and folders, sensible aspect
+// ratios and square power-of-two textures move candidates up or down.
+func RankPreviewImages(archivePath string, files []PreviewInfo) []PreviewCandidate {
+	var candidates []PreviewCandidate
+	var maxSize int64
+	for _, f := range files {
+		if isImageFile(f.Path) {
+			candidates = append(candidates, PreviewCandidate{Path: f.Path, Size: f.Size})
+			maxSize = max(maxSize, f.Size)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	// Dimensions of the largest images only: every measurement costs a partial read
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Size > candidates[j].Size })
+	measure := make(map[string]bool)
+	for i := 0; i < len(candidates) && i < previewMeasureTop; i++ {
+		measure[candidates[i].Path] = true
+	}
+	heads, _ := ReadEntryHeads(archivePath, measure, previewHeadBytes)
+
+	for i := range candidates {
+		c := &candidates[i]
+		if maxSize > 0 {
+			c.Score = 20 * float64(c.Size) / float64(maxSize)
+		}
+		if head, ok := heads[c.Path]; ok {
+			if cfg, _, err := image.DecodeConfig(bytes.NewReader(head)); err == nil {
+				c.Width, c.Height = cfg.Width, cfg.Height
+			}
+		}
+		scorePreview(c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	return candidates
+}
+
+func scorePreview(c *PreviewCandidate) {
+	lower := strings.ToLower(strings.ReplaceAll(c.Path, "\\", "/"))
+	dir, base := path.Split(lower)
+	tokens := previewTokens(strings.TrimSuffix(base, path.Ext(base)))
+	folders := previewTokens(dir)
+
+	adjust := func(points float64, reason string) {
+		c.Score += points
+		c.Reasons = append(c.Reasons, reason)
+	}
+
+	if hint := firstTokenPrefix(tokens, previewNameHints); hint != "" {
+		adjust(30, "name: "+hint)
+	}
+	if hint := firstToken(folders, previewFolderHints); hint != "" {
+		adjust(20, "folder: "+hint)
+	}
+	if hint := firstToken(tokens, textureMapTokens); hint != "" {
+		adjust(-60, "texture map: "+hint)
+	} else if hint := firstToken(folders, textureMapTokens); hint != "" {
+		adjust(-40, "texture folder: "+hint)
+	}
+	if hint := firstToken(tokens, accessoryTokens); hint != "" {
+		adjust(-25, "accessory: "+hint)
+	}
+
+	if c.Width > 0 && c.Height > 0 {
+		ratio := float64(c.Width) / float64(c.Height)
+		switch {
+		case c.Width == c.Height && isPowerOfTwo(c.Width) && c.Width >= 512:
+			adjust(-20, "square power-of-two (texture size)")
+		case ratio >= 0.75 && ratio <= 2.0:
+			adjust(15, "aspect ratio")
+		case ratio > 2.5 || ratio < 0.5:
+			adjust(-15, "extreme aspect ratio")
+		}
+		if c.Width < 200 || c.Height < 200 {
+			adjust(-20, "thumbnail size")
+		}
+	}
+}
+
+// previewTokens splits a name into lowercase alphanumeric words
+func previewTokens(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+}
+
+func firstToken(tokens, words []string) string {
+	for _, t := range tokens {
+		for _, w := range words {
+			if t == w {
+				return w
+			}
+		}
+	}
+	return ""
+}
+
+// firstTokenPrefix matches words at the start of a token, so "render" also finds "render02"
+func firstTokenPrefix(tokens, words []string) string {
+	for _, t := range tokens {
+		for _, w := range words {
+			if strings.HasPrefix(t, w) {
+				return w
+			}
+		}
+	}
+	return ""
+}
+
+func isPowerOfTwo(n int) bool {
+	return n > 0 && n&(n-1) == 0
+}
+
+// ReadEntryHeads returns the first limit bytes of the named entries of an archive, reading no
+// further into each entry than needed
+func ReadEntryHeads(archivePath string, names map[string]bool, limit int64) (map[string][]byte, error) {
+	if len(names) == 0 {
+		return map[string][]byte{}, nil
+	}
+	var heads map[string][]byte
+	err := guard(archivePath, func() (err error) {
+		heads, err = readEntryHeads(archivePath, names, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return heads, nil
+}
+
+func readEntryHeads(archivePath string, names map[string]bool, limit int64) (map[string][]byte, error) {
+	heads := make(map[string][]byte)
+	err := forEachEntry(archivePath, names, func(name string, r io.Reader) error {
+		if data, err := io.ReadAll(io.LimitReader(r, limit)); err == nil {
+			heads[name] = data
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return heads, nil
+}
+
+// forEachEntry calls fn with the contents of every named entry of an archive, in archive order,
+// and stops reading sequential formats once all of them were seen. An error from fn stops the walk.
+func forEachEntry(archivePath string, names map[string]bool, fn func(name string, r io.Reader) error) error {
+	read := fn
+	fn = func(name string, r io.Reader) error { return read(name, watch(archivePath, r)) }
+	switch Format(archivePath) {
+	case ".zip":
+		reader, err := openZIP(archivePath)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		for _, f := range reader.File {
+			if names[f.Name] && reader.allow(archivePath, f) {
+				rc, err := f.Open()
+				if err != nil {
+					continue
+				}
+				err = fn(f.Name, rc)
+				rc.Close()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case ".rar":
+		reader, err := openRAR(archivePath)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		for seen := 0; seen < len(names); {
+			header, err := reader.Next()
+			if err != nil {
+				break
+			}
+			if names[header.Name] {
+				seen++
+				if !reader.allow(archivePath, header) {
+					continue
+				}
+				if err := fn(header.Name, reader); err != nil {
+					return err
+				}
+			}
+		}
+	case ".7z":
+		reader, err := open7Z(archivePath)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		for _, f := range reader.File {
+			if names[f.Name] && reader.allow(archivePath, f) {
+				rc, err := f.Open()
+				if err != nil {
+					continue
+				}
+				err = fn(f.Name, rc)
+				rc.Close()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	case ".iso":
+		img, err := openISO(archivePath)
+		if err != nil {
+			return err
+		}
+		defer img.Close()
+		for _, f := range img.Files {
+			if names[f.Name] && img.allow(archivePath, f) {
+				if err := fn(f.Name, img.Open(f)); err != nil {
+					return err
+				}
+			}
+		}
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		seen := 0
+		err := walkStream(archivePath, func(name string, size int64, r io.Reader) error {
+			if !names[name] {
+				return nil
+			}
+			if err := fn(name, r); err != nil {
+				return err
+			}
+			if seen++; seen == len(names) {
+				return errFound
+			}
+			return nil
+		})
+		if err != nil && err != errFound {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported archive format: %s", Format(archivePath))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/paths"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrNoPreviewCache is returned by CachePreview while the preview cache is off
+var ErrNoPreviewCache = errors.New("the preview cache is off")
+
+var noPreviewCache atomic.Bool
+
+// SetPreviewCache switches the preview cache on or off. While it is off nothing is written to
+// the preview folder: previews are extracted into memory every time they are shown.
+func SetPreviewCache(on bool) {
+	noPreviewCache.Store(!on)
+}
+
+// PreviewCacheEnabled reports whether previews are cached on disk
+func PreviewCacheEnabled() bool {
+	return !noPreviewCache.Load()
+}
+
+// PreviewCacheDir holds files extracted from archives for previews
+func PreviewCacheDir() string {
+	return filepath.Join(paths.CacheDir(), "previews")
+}
+
+// PreviewCachePath returns where the extracted copy of an archive entry is cached; entries a
+// preview provider renders are cached as their PNG image
+func PreviewCachePath(archivePath, internalPath string) string {
+	cacheKey := fmt.Sprintf("%x_%s", archivePath, internalPath)
+	cacheKey = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, cacheKey)
+	ext := strings.ToLower(filepath.Ext(internalPath))
+	if IsRendered(internalPath) {
+		ext += ".png"
+	}
+	return filepath.Join(PreviewCacheDir(), cacheKey+ext)
+}
+
+// CachePreview extracts an archive entry into the preview cache, unless a valid copy is
+// already there, and returns the path of the cached copy
+func CachePreview(archivePath, internalPath string) (string, error) {
+	if !PreviewCacheEnabled() {
+		return "", ErrNoPreviewCache
+	}
+	cachePath := PreviewCachePath(archivePath, internalPath)
+	if fsutil.LooksValid(cachePath) {
+		return cachePath, nil
+	}
+
+	// Only one of the callers filling the same cache file writes it
+	return coalesce("cache\x00"+cachePath, func() (string, error) {
+		if fsutil.LooksValid(cachePath) {
+			return cachePath, nil
+		}
+		data, err := PreviewFile(archivePath, internalPath)
+		if err != nil {
+			return "", err
+		}
+		if !fsutil.MatchesSignature(data, filepath.Ext(cachePath)) {
+			return "", fmt.Errorf("%s is not a valid %s file", internalPath, filepath.Ext(cachePath))
+		}
+		os.MkdirAll(PreviewCacheDir(), 0755)
+		if err := fsutil.WriteFileAtomic(cachePath, data, 0644); err != nil {
+			return "", err
+		}
+		return cachePath, nil
+	})
+}
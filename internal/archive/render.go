@@ -0,0 +1,240 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PreviewProvider renders preview images of archive entries the built-in previews cannot show,
+// such as Blender scenes or Photoshop documents. Images, videos and STL/OBJ models are always
+// shown as they are and never handed to a provider.
+type PreviewProvider interface {
+	// Handles reports whether the provider renders the entry, e.g. by its extension
+	Handles(internalPath string) bool
+	// Render returns a PNG image of the entry, given its contents
+	Render(ctx context.Context, internalPath string, data []byte) ([]byte, error)
+}
+
+// PreviewCommand is an external converter run for the entries with one of its extensions, e.g.
+// {"extensions": [".psd"], "command": ["magick", "{input}[0]", "{output}"]}. In the arguments,
+// {input} is the entry extracted to a temporary file under its own name, {output} the PNG to
+// write and {outdir} its folder; when {output} is missing, the first PNG or JPEG written to
+// {outdir} is taken instead.
+type PreviewCommand struct {
+	Extensions []string `json:"extensions"`
+	Command    []string `json:"command"`                   // Program and arguments, run without a shell
+	Timeout    int      `json:"timeout_seconds,omitempty"` // Per entry; 0 allows a minute
+}
+
+// defaultRenderTimeout bounds a preview command that sets no timeout
+const defaultRenderTimeout = time.Minute
+
+var (
+	providersMu sync.RWMutex
+	providers   []PreviewProvider // Registered in code, see RegisterPreviewProvider
+	commands    []PreviewProvider // From the settings, see SetPreviewCommands
+)
+
+// RegisterPreviewProvider adds a provider. Providers are asked in registration order, after the
+// commands of the settings.
+func RegisterPreviewProvider(p PreviewProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers = append(providers, p)
+}
+
+// SetPreviewCommands replaces the converters configured in the settings (preview_converters)
+func SetPreviewCommands(cmds []PreviewCommand) {
+	list := make([]PreviewProvider, 0, len(cmds))
+	for _, c := range cmds {
+		list = append(list, c)
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	commands = list
+}
+
+// ValidatePreviewCommands checks the converters of the settings
+func ValidatePreviewCommands(cmds []PreviewCommand) error {
+	for i, c := range cmds {
+		if len(c.Extensions) == 0 {
+			return fmt.Errorf("preview converter %d: extensions are required", i+1)
+		}
+		for _, ext := range c.Extensions {
+			if !strings.HasPrefix(ext, ".") {
+				return fmt.Errorf("preview converter %d: extension %q must start with a dot", i+1, ext)
+			}
+		}
+		if len(c.Command) == 0 || c.Command[0] == "" {
+			return fmt.Errorf("preview converter %d: command is required", i+1)
+		}
+		if !strings.Contains(strings.Join(c.Command[1:], " "), "{input}") {
+			return fmt.Errorf("preview converter %d: the arguments must contain {input}", i+1)
+		}
+		if c.Timeout < 0 {
+			return fmt.Errorf("preview converter %d: timeout_seconds cannot be negative", i+1)
+		}
+	}
+	return nil
+}
+
+// renderer returns the provider rendering entries like internalPath, nil for entries shown as
+// they are
+func renderer(internalPath string) PreviewProvider {
+	if isImageFile(internalPath) || isVideoFile(internalPath) || isModelFile(internalPath) {
+		return nil
+	}
+	lower := strings.ToLower(internalPath)
+	if strings.Contains(lower, "__macosx") || strings.Contains(lower, "@eadir") {
+		return nil
+	}
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	for _, list := range [][]PreviewProvider{commands, providers} {
+		for _, p := range list {
+			if p.Handles(internalPath) {
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// IsRendered reports whether entries like internalPath are previewed as the PNG a provider
+// renders of them
+func IsRendered(internalPath string) bool {
+	return renderer(internalPath) != nil
+}
+
+// PreviewFile returns what is shown as the preview of an archive entry: the entry itself, or
+// the PNG image a provider renders of it
+func PreviewFile(archivePath, internalPath string) ([]byte, error) {
+	data, err := GetFileFromArchive(archivePath, internalPath)
+	if err != nil {
+		return nil, err
+	}
+	p := renderer(internalPath)
+	if p == nil {
+		return data, nil
+	}
+	return coalesce(flightKey("render", archivePath, internalPath), func() ([]byte, error) {
+		img, err := p.Render(context.Background(), internalPath, data)
+		if err != nil {
+			return nil, fmt.Errorf("could not render %s: %w", internalPath, err)
+		}
+		return img, nil
+	})
+}
+
+// Handles matches the entry's extension
+func (c PreviewCommand) Handles(internalPath string) bool {
+	ext := filepath.Ext(internalPath)
+	for _, e := range c.Extensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render runs the command on a temporary copy of the entry
+func (c PreviewCommand) Render(ctx context.Context, internalPath string, data []byte) ([]byte, error) {
+	timeout := defaultRenderTimeout
+	if c.Timeout > 0 {
+		timeout = time.Duration(c.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "adf-render-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	in := filepath.Join(dir, "in", filepath.Base(filepath.FromSlash(internalPath)))
+	out := filepath.Join(dir, "out", "preview.png")
+	if err := os.MkdirAll(filepath.Dir(in), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(in, data, 0600); err != nil {
+		return nil, err
+	}
+
+	args := make([]string, len(c.Command))
+	r := strings.NewReplacer("{input}", in, "{output}", out, "{outdir}", filepath.Dir(out))
+	for i, a := range c.Command {
+		args[i] = r.Replace(a)
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second // Children left holding stderr do not outlive the timeout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s timed out after %s", c.Command[0], timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", c.Command[0], err, lastLine(msg))
+		}
+		return nil, fmt.Errorf("%s: %w", c.Command[0], err)
+	}
+	return readRendered(filepath.Dir(out), out)
+}
+
+// readRendered reads the image a command wrote, as PNG
+func readRendered(dir, out string) ([]byte, error) {
+	path := out
+	if _, err := os.Stat(out); err != nil {
+		entries, _ := os.ReadDir(dir)
+		path = ""
+		for _, e := range entries {
+			switch strings.ToLower(filepath.Ext(e.Name())) {
+			case ".png", ".jpg", ".jpeg":
+				path = filepath.Join(dir, e.Name())
+			}
+			if path != "" {
+				break
+			}
+		}
+		if path == "" {
+			return nil, errors.New("the command wrote no image")
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		return data, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("the command wrote no readable image: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func lastLine(s string) string {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[i+1:])
+	}
+	return s
+}
@@ -0,0 +1,113 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/vfs"
+	"archive/zip"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bodgit/sevenzip"
+	"github.com/nwaples/rardecode/v2"
+)
+
+// Zip bombs are caught before anything is decompressed: entries whose declared size or
+// compression ratio is out of bounds are skipped, and their archive is remembered as suspicious
+// so reports can flag it.
+
+// ratioMinSize keeps small, highly compressible files (text, empty bitmaps) out of the ratio check
+const ratioMinSize = 1 << 20
+
+var suspicious = struct {
+	sync.Mutex
+	reasons map[string]string
+}{reasons: make(map[string]string)}
+
+// markSuspicious records why an archive looks malicious; the first reason is kept
+func markSuspicious(archivePath, reason string) {
+	suspicious.Lock()
+	defer suspicious.Unlock()
+	if _, ok := suspicious.reasons[archivePath]; !ok {
+		log.Printf("🧨 Suspicious archive %s: %s", filepath.Base(archivePath), reason)
+		suspicious.reasons[archivePath] = reason
+	}
+}
+
+// SuspiciousReason returns why an archive was flagged as a possible zip bomb ("" if it was not)
+func SuspiciousReason(archivePath string) string {
+	suspicious.Lock()
+	defer suspicious.Unlock()
+	return suspicious.reasons[archivePath]
+}
+
+// SuspiciousArchives returns every archive flagged so far with its reason
+func SuspiciousArchives() map[string]string {
+	suspicious.Lock()
+	defer suspicious.Unlock()
+	flagged := make(map[string]string, len(suspicious.reasons))
+	for path, reason := range suspicious.reasons {
+		flagged[path] = reason
+	}
+	return flagged
+}
+
+// allowEntry reports whether an entry may be decompressed. A compressed size of 0 means the
+// format does not record it, which skips the ratio check.
+func allowEntry(archivePath, name string, size, compressed int64) bool {
+	l := GetLimits()
+	if l.MaxEntrySize > 0 && size > l.MaxEntrySize {
+		markSuspicious(archivePath, fmt.Sprintf("%s unpacks to %d MB, above the %d MB entry limit", name, size>>20, l.MaxEntrySize>>20))
+		return false
+	}
+	if l.MaxRatio > 0 && compressed > 0 && size > ratioMinSize && float64(size)/float64(compressed) > l.MaxRatio {
+		markSuspicious(archivePath, fmt.Sprintf("%s expands %.0f:1, above the %.0f:1 ratio limit", name, float64(size)/float64(compressed), l.MaxRatio))
+		return false
+	}
+	return true
+}
+
+func (a *zipArchive) allow(archivePath string, f *zip.File) bool {
+	return allowEntry(archivePath, f.Name, int64(f.UncompressedSize64), int64(f.CompressedSize64))
+}
+
+func (a *rarArchive) allow(archivePath string, h *rardecode.FileHeader) bool {
+	return allowEntry(archivePath, h.Name, h.UnPackedSize, h.PackedSize)
+}
+
+// Disc images are not compressed, but sparse UDF files can declare any size
+func (img *discImage) allow(archivePath string, f *discFile) bool {
+	return allowEntry(archivePath, f.Name, f.Size, 0)
+}
+
+// 7Z compresses entries together, so its ratio is checked for the whole archive when it is opened
+func (a *sevenZipArchive) allow(archivePath string, f *sevenzip.File) bool {
+	return !a.bomb && allowEntry(archivePath, f.Name, int64(f.UncompressedSize), 0)
+}
+
+// checkSolidRatio flags a 7Z archive whose contents expand beyond the ratio limit as a whole.
+// Split sets are left out: only their first volume's size is known here.
+func (a *sevenZipArchive) checkSolidRatio(archivePath string, packed int64) {
+	l := GetLimits()
+	if l.MaxRatio <= 0 || packed <= 0 || isNumericExt(strings.ToLower(filepath.Ext(archivePath))) {
+		return
+	}
+	var unpacked int64
+	for _, f := range a.File {
+		unpacked += int64(f.UncompressedSize)
+	}
+	if unpacked > ratioMinSize && float64(unpacked)/float64(packed) > l.MaxRatio {
+		a.bomb = true
+		markSuspicious(archivePath, fmt.Sprintf("contents expand %.0f:1, above the %.0f:1 ratio limit", float64(unpacked)/float64(packed), l.MaxRatio))
+	}
+}
+
+// archiveSize is the size of an archive file, 0 if it cannot be read
+func archiveSize(archivePath string) int64 {
+	info, err := vfs.Stat(archivePath)
+	if err != nil {
+		return 0
+	}
+	return info.Size
+}
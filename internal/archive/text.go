@@ -0,0 +1,184 @@
+package archive
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// TextPreview is the beginning of a text file found in an archive
+type TextPreview struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Text      string `json:"text"`
+	Truncated bool   `json:"truncated"` // Only the first bytes of the file were read
+}
+
+// DefaultTextPreviewBytes is how much of a text file is read unless the caller asks otherwise
+const DefaultTextPreviewBytes = 16 << 10
+
+var (
+	textExtensions = map[string]bool{".txt": true, ".md": true, ".markdown": true, ".nfo": true, ".diz": true,
+		".rst": true, ".text": true, ".me": true, ".1st": true}
+	// Names of text files, best first, that describe what an archive holds
+	textNameHints = []string{"readme", "read me", "description", "info", "about", "instructions", "notes", "changelog", "license", "licence"}
+)
+
+func isTextFile(filename string) bool {
+	lower := strings.ToLower(path.Base(strings.ReplaceAll(filename, "\\", "/")))
+	if textExtensions[path.Ext(lower)] {
+		return true
+	}
+	// README, LICENSE and friends often come without an extension
+	return path.Ext(lower) == "" && (strings.HasPrefix(lower, "readme") || strings.HasPrefix(lower, "license") || strings.HasPrefix(lower, "licence"))
+}
+
+// RankTextFiles orders the text files of an archive by how likely they describe its contents:
+// READMEs and descriptions before notes and licenses, shallow before nested, then larger first
+func RankTextFiles(files []PreviewInfo) []PreviewInfo {
+	var texts []PreviewInfo
+	for _, f := range files {
+		if isTextFile(f.Path) && !strings.HasPrefix(path.Base(f.Path), ".") && f.Size > 0 {
+			texts = append(texts, f)
+		}
+	}
+
+	rank := func(f PreviewInfo) int {
+		name := strings.ToLower(path.Base(strings.ReplaceAll(f.Path, "\\", "/")))
+		for i, hint := range textNameHints {
+			if strings.Contains(name, hint) {
+				return i
+			}
+		}
+		return len(textNameHints)
+	}
+	depth := func(f PreviewInfo) int { return strings.Count(strings.ReplaceAll(f.Path, "\\", "/"), "/") }
+
+	sort.SliceStable(texts, func(i, j int) bool {
+		if ri, rj := rank(texts[i]), rank(texts[j]); ri != rj {
+			return ri < rj
+		}
+		if di, dj := depth(texts[i]), depth(texts[j]); di != dj {
+			return di < dj
+		}
+		return texts[i].Size > texts[j].Size
+	})
+	return texts
+}
+
+// FindTextInArchive returns the entry of an archive most likely to describe it (a README,
+// description or NFO)
+func FindTextInArchive(archivePath string) (string, error) {
+	files, err := ListArchiveFiles(archivePath)
+	if err != nil {
+		return "", err
+	}
+	texts := RankTextFiles(files)
+	if len(texts) == 0 {
+		return "", fmt.Errorf("no text file found")
+	}
+	return texts[0].Path, nil
+}
+
+// ReadTextFromArchive returns the first limit bytes of a text entry, decoded to UTF-8. An empty
+// internal path picks the most relevant text file of the archive.
+func ReadTextFromArchive(archivePath, internalPath string, limit int64) (*TextPreview, error) {
+	files, err := ListArchiveFiles(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *PreviewInfo
+	if internalPath == "" {
+		if texts := RankTextFiles(files); len(texts) > 0 {
+			entry = &texts[0]
+		}
+	} else {
+		for i := range files {
+			if files[i].Path == internalPath {
+				entry = &files[i]
+				break
+			}
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("no text file found")
+	}
+	if !isTextFile(entry.Path) {
+		return nil, fmt.Errorf("not a text file: %s", entry.Path)
+	}
+
+	heads, err := ReadEntryHeads(archivePath, map[string]bool{entry.Path: true}, limit)
+	if err != nil {
+		return nil, err
+	}
+	head, ok := heads[entry.Path]
+	if !ok {
+		return nil, fmt.Errorf("failed to read %s", entry.Path)
+	}
+
+	text, err := decodeText(head, strings.EqualFold(path.Ext(entry.Path), ".nfo") || strings.EqualFold(path.Ext(entry.Path), ".diz"))
+	if err != nil {
+		return nil, err
+	}
+	return &TextPreview{
+		Path:      entry.Path,
+		Size:      entry.Size,
+		Text:      text,
+		Truncated: entry.Size > int64(len(head)),
+	}, nil
+}
+
+// decodeText turns the bytes of a text file into UTF-8: BOMs are honored, invalid UTF-8 is read
+// as Windows-1252, or as the DOS code page for NFO art
+func decodeText(data []byte, dos bool) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16(data[2:], data[0] == 0xFE), nil
+	}
+
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("not a text file")
+	}
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	// A read cut in the middle of a character is still UTF-8
+	if end := len(data) - utf8.UTFMax; end > 0 && utf8.Valid(data[:end]) {
+		for cut := len(data); cut > end; cut-- {
+			if utf8.Valid(data[:cut]) {
+				return string(data[:cut]), nil
+			}
+		}
+	}
+
+	decoder := charmap.Windows1252.NewDecoder()
+	if dos {
+		decoder = charmap.CodePage437.NewDecoder()
+	}
+	text, err := decoder.Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,206 @@
+package archive
+
+import (
+	"archive-duplicate-finder/internal/vfs"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TreeNode is a file or folder of an archive. Folders carry the totals of everything below them.
+type TreeNode struct {
+	Name           string      `json:"name"`
+	Path           string      `json:"path"` // Entry path inside the archive ("" for the root)
+	Dir            bool        `json:"dir,omitempty"`
+	Size           int64       `json:"size"`
+	CompressedSize int64       `json:"compressed_size,omitempty"`
+	CRC32          string      `json:"crc32,omitempty"` // Hexadecimal, when the format records it
+	Files          int         `json:"files,omitempty"` // Folders: number of files below
+	Diff           string      `json:"diff,omitempty"`  // Set by DiffTrees: "same", "changed" or "only"
+	Children       []*TreeNode `json:"children,omitempty"`
+}
+
+// treeCacheSize is how many listed archives are kept in memory
+const treeCacheSize = 32
+
+type treeCacheEntry struct {
+	size    int64
+	modTime time.Time
+	tree    *TreeNode
+}
+
+// treeCache remembers recent trees: the file browser lists the same archive again on every
+// expand, and RAR and tarball listings read the whole file
+var treeCache = struct {
+	sync.Mutex
+	entries map[string]treeCacheEntry
+	order   []string // Oldest first
+}{entries: make(map[string]treeCacheEntry)}
+
+// ListArchiveTree returns the entries of an archive as a folder hierarchy. Trees are cached in
+// memory until the archive changes size or modification time; callers get their own copy.
+func ListArchiveTree(archivePath string) (*TreeNode, error) {
+	info, err := vfs.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	treeCache.Lock()
+	cached, ok := treeCache.entries[archivePath]
+	treeCache.Unlock()
+	if ok && cached.size == info.Size && cached.modTime.Equal(info.ModTime) {
+		return cached.tree.clone(), nil
+	}
+
+	files, err := ListArchiveFiles(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	tree := BuildTree(files)
+
+	treeCache.Lock()
+	if _, exists := treeCache.entries[archivePath]; !exists {
+		treeCache.order = append(treeCache.order, archivePath)
+		if len(treeCache.order) > treeCacheSize {
+			delete(treeCache.entries, treeCache.order[0])
+			treeCache.order = treeCache.order[1:]
+		}
+	}
+	treeCache.entries[archivePath] = treeCacheEntry{size: info.Size, modTime: info.ModTime, tree: tree}
+	treeCache.Unlock()
+	return tree.clone(), nil
+}
+
+// BuildTree turns a flat entry listing into a folder hierarchy, folders first and names in
+// natural order
+func BuildTree(files []PreviewInfo) *TreeNode {
+	root := &TreeNode{Dir: true}
+	folders := map[string]*TreeNode{"": root}
+
+	var folder func(p string) *TreeNode
+	folder = func(p string) *TreeNode {
+		if node, ok := folders[p]; ok {
+			return node
+		}
+		parent, name := "", p
+		if i := strings.LastIndex(p, "/"); i >= 0 {
+			parent, name = p[:i], p[i+1:]
+		}
+		node := &TreeNode{Name: name, Path: p, Dir: true}
+		folders[p] = node
+		up := folder(parent)
+		up.Children = append(up.Children, node)
+		return node
+	}
+
+	for _, f := range files {
+		p := strings.Trim(strings.ReplaceAll(f.Path, "\\", "/"), "/")
+		if p == "" {
+			continue
+		}
+		parent, name := "", p
+		if i := strings.LastIndex(p, "/"); i >= 0 {
+			parent, name = p[:i], p[i+1:]
+		}
+		node := &TreeNode{Name: name, Path: f.Path, Size: f.Size, CompressedSize: f.CompressedSize}
+		if f.CRC32 != 0 {
+			node.CRC32 = fmt.Sprintf("%08x", f.CRC32)
+		}
+		up := folder(parent)
+		up.Children = append(up.Children, node)
+	}
+
+	root.total()
+	return root
+}
+
+// total sums the sizes and file counts of a folder and sorts its children
+func (n *TreeNode) total() {
+	if !n.Dir {
+		return
+	}
+	n.Size, n.CompressedSize, n.Files = 0, 0, 0
+	for _, c := range n.Children {
+		c.total()
+		n.Size += c.Size
+		n.CompressedSize += c.CompressedSize
+		if c.Dir {
+			n.Files += c.Files
+		} else {
+			n.Files++
+		}
+	}
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		a, b := n.Children[i], n.Children[j]
+		if a.Dir != b.Dir {
+			return a.Dir
+		}
+		return naturalLess(a.Name, b.Name)
+	})
+}
+
+func (n *TreeNode) clone() *TreeNode {
+	c := *n
+	if n.Children != nil {
+		c.Children = make([]*TreeNode, len(n.Children))
+		for i, child := range n.Children {
+			c.Children[i] = child.clone()
+		}
+	}
+	return &c
+}
+
+// DiffTrees marks the files of two trees: "same" when the other tree holds the same path with
+// the same size (and CRC, when both have one), "changed" when it differs and "only" when the
+// other tree lacks it. Folders are "same" only when everything below them is.
+func DiffTrees(a, b *TreeNode) {
+	filesA, filesB := a.fileIndex(), b.fileIndex()
+	mark := func(files, other map[string]*TreeNode) {
+		for p, f := range files {
+			o, ok := other[p]
+			switch {
+			case !ok:
+				f.Diff = "only"
+			case f.Size != o.Size || (f.CRC32 != "" && o.CRC32 != "" && f.CRC32 != o.CRC32):
+				f.Diff = "changed"
+			default:
+				f.Diff = "same"
+			}
+		}
+	}
+	mark(filesA, filesB)
+	mark(filesB, filesA)
+	a.markFolders()
+	b.markFolders()
+}
+
+func (n *TreeNode) fileIndex() map[string]*TreeNode {
+	index := make(map[string]*TreeNode)
+	var walk func(*TreeNode)
+	walk = func(n *TreeNode) {
+		for _, c := range n.Children {
+			if c.Dir {
+				walk(c)
+			} else {
+				index[strings.Trim(strings.ReplaceAll(c.Path, "\\", "/"), "/")] = c
+			}
+		}
+	}
+	walk(n)
+	return index
+}
+
+func (n *TreeNode) markFolders() {
+	if !n.Dir {
+		return
+	}
+	n.Diff = "same"
+	for _, c := range n.Children {
+		c.markFolders()
+		if c.Diff != "same" {
+			n.Diff = "changed"
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// VerifyArchive reads every entry of an archive to the end so that checksum errors surface.
+// ZIP and 7Z entries are CRC-checked by their readers; RAR is checked by decoding every entry
+// and disc images by reading every file, compressed streams by decompressing them.
+// Non-archive files (models, videos) are not verified and return nil.
+func VerifyArchive(archivePath string) error {
+	return guard(archivePath, func() error {
+		return verifyArchive(archivePath)
+	})
+}
+
+func verifyArchive(archivePath string) error {
+	switch Format(archivePath) {
+	case ".zip":
+		return verifyZIP(archivePath)
+	case ".rar":
+		return verifyRAR(archivePath)
+	case ".7z":
+		return verify7Z(archivePath)
+	case ".iso":
+		return verifyISO(archivePath)
+	case ".tar", ".gz", ".bz2", ".xz", ".zst":
+		return verifyStream(archivePath)
+	default:
+		return nil
+	}
+}
+
+// VerifyArchives checks the given archives in parallel and returns the error of every
+// unreadable one, keyed by path. onDone is called after each archive has been checked.
+func VerifyArchives(paths []string, onDone func(path string)) map[string]error {
+	failed := make(map[string]error)
+	if len(paths) == 0 {
+		return failed
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+
+	for w := 0; w < GetLimits().Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				err := VerifyArchive(path)
+
+				mu.Lock()
+				if err != nil {
+					failed[path] = err
+				}
+				if onDone != nil {
+					onDone(path)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	return failed
+}
+
+func verifyZIP(archivePath string) error {
+	reader, err := openZIP(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open ZIP: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || !reader.allow(archivePath, file) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open file %s: %w", file.Name, err)
+		}
+		_, err = io.Copy(io.Discard, watch(archivePath, rc))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+func verifyRAR(archivePath string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️  RAR Recovery: Panic in verifyRAR for %s: %v", archivePath, r)
+			err = fmt.Errorf("rar reader panic: %v", r)
+		}
+	}()
+
+	reader, err := openRAR(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open RAR: %w", err)
+	}
+	defer reader.Close()
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read RAR header: %w", err)
+		}
+		if header.IsDir || !reader.allow(archivePath, header) {
+			continue
+		}
+		if _, err := io.Copy(io.Discard, watch(archivePath, reader)); err != nil {
+			return fmt.Errorf("failed to read file %s: %w", header.Name, err)
+		}
+	}
+}
+
+func verify7Z(archivePath string) error {
+	reader, err := open7Z(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open 7Z: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() || !reader.allow(archivePath, file) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open file %s: %w", file.Name, err)
+		}
+		_, err = io.Copy(io.Discard, watch(archivePath, rc))
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read file %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
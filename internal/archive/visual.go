@@ -1,6 +1,7 @@
 package archive
 
 import (
+	"archive-duplicate-finder/internal/membudget"
 	"bytes"
 	"fmt"
 	"image"
@@ -14,6 +15,8 @@ import (
 
 // GeneratePHash generates a perceptual hash for the given image data
 func GeneratePHash(data []byte) (uint64, error) {
+	release := membudget.Acquire(membudget.ImageBytes(data))
+	defer release()
 	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return 0, fmt.Errorf("failed to decode image: %w", err)
@@ -29,6 +32,8 @@ func GeneratePHash(data []byte) (uint64, error) {
 
 // GenerateDHash generates a difference hash for the given image data
 func GenerateDHash(data []byte) (uint64, error) {
+	release := membudget.Acquire(membudget.ImageBytes(data))
+	defer release()
 	img, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return 0, fmt.Errorf("failed to decode image: %w", err)
@@ -1,52 +1,202 @@
 package config
 
 import (
-	"encoding/json"
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/notify"
+	"archive-duplicate-finder/internal/paths"
+	"archive-duplicate-finder/internal/vfs"
+	"archive-duplicate-finder/internal/visual"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+)
+
+// What the assistant tools may do (assistant_access)
+const (
+	AssistantRead       = "read"       // List and inspect duplicate groups only
+	AssistantQuarantine = "quarantine" // Also resolve groups by quarantining the other copies
 )
 
 type AppConfig struct {
-	Directory  string `json:"directory"`
-	TrashPath  string `json:"trash_path"`
-	Threshold  int    `json:"threshold"`
-	Recursive  bool   `json:"recursive"`
-	LeaveRef   bool   `json:"leave_ref"`
-	DeleteMode string `json:"delete_mode"`
-	Port       int    `json:"port"`
+	Directory     string             `json:"directory"`
+	TrashPath     string             `json:"trash_path"`
+	Recursive     bool               `json:"recursive"`
+	LeaveRef      bool               `json:"leave_ref"`
+	RefFormat     string             `json:"ref_format,omitempty"`   // Reference note format: "text" (default) or "json"
+	RefTemplate   string             `json:"ref_template,omitempty"` // Go template of text notes (see the refnote package)
+	DeleteMode    string             `json:"delete_mode"`
+	Port          int                `json:"port"`
+	Phonetic      string             `json:"phonetic"`              // "", "soundex" or "metaphone"
+	Scorers       map[string]float64 `json:"scorers"`               // Similarity scorer weights, e.g. {"name": 0.7, "token": 0.3}
+	Verify        bool               `json:"verify"`                // Run the archive integrity check after scanning
+	VerifyContent bool               `json:"verify_content"`        // Step 2 confirms same-size archives by content hash
+	Profiles      map[string]string  `json:"profiles,omitempty"`    // Step 2 method per extension, e.g. {".zip": "manifest"} (see the profile package)
+	Contents      bool               `json:"enrich_contents"`       // Record the entry count and uncompressed size of every archive
+	ConfirmAbove  int                `json:"confirm_above_minutes"` // Ask before analyses projected to take longer (0 disables)
+	NetworkShare  bool               `json:"network_share"`         // Scan with batched listings and parallel stats (SMB/NFS mounts)
+	LooseFiles    bool               `json:"loose_files"`           // Also scan images and any other file, not only archives (see scanner.SetLooseFiles)
+
+	// How close two files must be to count as duplicates, per kind of comparison
+	ThresholdName     int `json:"threshold_name"`      // Name similarity percentage of Step 3, the same-size name pass and the download check (0-100)
+	ThresholdVisual   int `json:"threshold_visual"`    // Largest Hamming distance between two previews of the visual analysis and cover profile (0-64)
+	MinContentOverlap int `json:"min_content_overlap"` // Share of entries two archives of the manifest profile have in common (1-100, 100 = the same list)
+
+	IgnoreTTLDays      int    `json:"ignore_ttl_days"`                // Groups marked as good re-surface after this many days (0 = never)
+	TrashRetentionDays int    `json:"trash_retention_days"`           // Trash day folders older than this are emptied (0 = keep forever)
+	DeleteIfTrashFails bool   `json:"delete_if_trash_fails"`          // Permanently delete files that cannot be moved to the trash
+	VerifyBeforeDelete string `json:"verify_before_delete,omitempty"` // Check files again right before removing them: "stat" or "hash" (see hashing.Recheck)
+
+	QuarantinePath string `json:"quarantine_path,omitempty"` // Cleanups move files here with a manifest, to restore or purge later (takes over from the trash)
+
+	OperationsLog string `json:"operations_log,omitempty"` // JSONL file every delete, move, rename and ignore is also appended to
+	Language      string `json:"language,omitempty"`       // Language of the CLI messages and reports ("en", "es"); empty follows the locale
+
+	Protected []string `json:"protected,omitempty"` // Files and folders never suggested for deletion (globs, see the protect package)
+
+	// Archive operation limits; 0 keeps the built-in default
+	Workers           int     `json:"workers"` // 0 sizes the pool by a read benchmark of the scan directory (see the iotune package)
+	ArchiveTimeout    int     `json:"archive_timeout_seconds"`
+	MaxUncompressedMB int64   `json:"max_uncompressed_mb"`
+	MaxEntryMB        int64   `json:"max_entry_mb"`          // Largest single entry that is decompressed
+	MaxRatio          float64 `json:"max_compression_ratio"` // Entries expanding more than this are skipped as zip bombs
+
+	VisualRateMB   int64 `json:"visual_rate_mb,omitempty"`   // Previews read per second by the visual analysis, in MB (0 = unlimited)
+	PreviewWorkers int   `json:"preview_workers,omitempty"`  // Previews the dashboard extracts at the same time (0 = as many as workers)
+	MemoryBudgetMB int64 `json:"memory_budget_mb,omitempty"` // Extraction buffers, decoded previews and parsed models held at once, in MB (0 = unlimited)
+
+	PreviewConverters []archive.PreviewCommand `json:"preview_converters,omitempty"` // External commands rendering previews of other entry types, e.g. .blend or .psd
+	Hooks             []hooks.Hook             `json:"hooks,omitempty"`              // Commands run before and after removals and after scans, given the event as JSON
+
+	AuthHash        string `json:"auth_hash,omitempty"`        // SHA-256 of the dashboard access token; empty leaves the dashboard open
+	AssistantAccess string `json:"assistant_access,omitempty"` // What the assistant tools (POST /api/v1/mcp) may do: "read" (default) or "quarantine"
+
+	CacheDSN string `json:"cache_dsn,omitempty"` // Postgres or MySQL server holding the cache instead of the local file (see db.SetDSN)
+
+	// Connection settings for remote scan directories
+	S3     vfs.S3Config     `json:"s3,omitempty"`     // s3://bucket/prefix
+	SFTP   vfs.SFTPConfig   `json:"sftp,omitempty"`   // sftp://user@host/dir
+	WebDAV vfs.WebDAVConfig `json:"webdav,omitempty"` // webdav://host/dir, webdavs://host/dir
+
+	Notifications []notify.Target `json:"notifications,omitempty"` // Where to report finished analyses
+}
+
+// Default returns the settings of a fresh install
+func Default() *AppConfig {
+	return &AppConfig{
+		ThresholdName:     70,
+		ThresholdVisual:   visual.HammingThreshold,
+		MinContentOverlap: 100,
+		Recursive:         true,
+		Port:              8080,
+		ConfirmAbove:      30,
+	}
+}
+
+// HashToken returns the stored form of a dashboard access token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
+// CheckToken reports whether token unlocks the dashboard. Always true when no token is set.
+func (c *AppConfig) CheckToken(token string) bool {
+	if c.AuthHash == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(token)), []byte(c.AuthHash)) == 1
+}
+
+// ArchiveLimits returns the configured archive limits, falling back to the defaults for unset values
+func (c *AppConfig) ArchiveLimits() archive.Limits {
+	l := archive.DefaultLimits
+	l.Workers = c.Workers // 0 lets archive.SetLimits use the count measured for the disk
+	if c.ArchiveTimeout > 0 {
+		l.Timeout = time.Duration(c.ArchiveTimeout) * time.Second
+	}
+	if c.MaxUncompressedMB > 0 {
+		l.MaxUncompressed = c.MaxUncompressedMB << 20
+	}
+	if c.MaxEntryMB > 0 {
+		l.MaxEntrySize = c.MaxEntryMB << 20
+	}
+	if c.MaxRatio > 0 {
+		l.MaxRatio = c.MaxRatio
+	}
+	return l
+}
+
+// customPath replaces the settings file next to the executable (see SetPath)
+var customPath string
+
+// SetPath makes every later load and save use the settings file at path (e.g. -config)
+func SetPath(path string) {
+	customPath = path
+}
+
+// GetConfigPath returns the settings file: the one given to SetPath, then $ADF_CONFIG, then
+// archive-finder-settings.json in the user config folder (see paths.Settings)
 func GetConfigPath() string {
-	exePath, err := os.Executable()
-	if err != nil {
-		return "archive-finder-settings.json"
+	if customPath != "" {
+		return customPath
+	}
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		return path
 	}
-	return filepath.Join(filepath.Dir(exePath), "archive-finder-settings.json")
+	return paths.Settings()
 }
 
+// LoadConfig reads the settings file (JSON, YAML or TOML by its extension), expands its
+// ${ENV} references, applies the ADF_<KEY> environment overrides and validates the result.
+// Without a file it returns the defaults with the overrides, along with the read error.
 func LoadConfig() (*AppConfig, error) {
 	path := GetConfigPath()
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return &AppConfig{
-			Threshold: 70,
-			Recursive: true,
-			Port:      8080,
-		}, err
+	// Settings missing from the file (e.g. a hand-written one) keep their default
+	cfg := Default()
+	data, readErr := os.ReadFile(path)
+	if readErr == nil {
+		if err := decode(path, data, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
 	}
-
-	var cfg AppConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		if readErr == nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
 		return nil, err
 	}
-	return &cfg, nil
+	return cfg, readErr
 }
 
+// SaveConfig writes the settings in the format of the settings file. Settings that an ADF_<KEY>
+// variable gives keep the value of the file, so the environment is never written into it. A
+// file with ${ENV} references is not saved over, since they would be replaced by their value.
 func SaveConfig(cfg *AppConfig) error {
 	path := GetConfigPath()
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	if current, err := os.ReadFile(path); err == nil {
+		if envReference.Match(current) {
+			return fmt.Errorf("%s uses ${NAME} references, which saving would replace with their values: change it by hand", path)
+		}
+		if cfg, err = withoutEnv(path, current, cfg); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	} else if cfg, err = withoutEnv(path, nil, cfg); err != nil {
+		return err
+	}
+	data, err := encode(path, cfg)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
 }
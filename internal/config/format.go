@@ -0,0 +1,374 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the settings file, chosen by its extension (.json, .yaml/.yml, .toml)
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// envPrefix names the environment overrides: ADF_THRESHOLD_NAME, ADF_TRASH_PATH, ADF_S3...
+const envPrefix = "ADF_"
+
+// formatOf returns the format of the settings file at path; unknown extensions are read as JSON
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+	return FormatJSON
+}
+
+// envReference matches ${NAME} and ${NAME:-default}
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// expandEnv replaces ${NAME} with the value of the environment variable NAME, or with the
+// default of ${NAME:-default} when it is unset or empty. A variable without a default
+// must be set.
+func expandEnv(data []byte) ([]byte, error) {
+	var missing error
+	out := envReference.ReplaceAllFunc(data, func(ref []byte) []byte {
+		m := envReference.FindSubmatch(ref)
+		if value := os.Getenv(string(m[1])); value != "" {
+			return []byte(value)
+		}
+		if len(m[2]) > 0 {
+			return m[2][2:]
+		}
+		if missing == nil {
+			line := bytes.Count(data[:bytes.Index(data, ref)], []byte("\n")) + 1
+			missing = fmt.Errorf("line %d: environment variable %s is not set (use ${%s:-default} for a fallback)", line, m[1], m[1])
+		}
+		return ref
+	})
+	return out, missing
+}
+
+// decode reads the settings file data in the format of path onto cfg. Settings missing from
+// the file keep the value cfg already has.
+func decode(path string, data []byte, cfg *AppConfig) error {
+	data, err := expandEnv(data)
+	if err != nil {
+		return err
+	}
+
+	// YAML and TOML are turned into JSON, so that every format reads the same keys
+	switch formatOf(path) {
+	case FormatYAML:
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return errors.New(strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+		if doc == nil {
+			return nil // Empty file
+		}
+		if data, err = json.Marshal(stringKeys(doc)); err != nil {
+			return err
+		}
+	case FormatTOML:
+		doc, err := parseTOML(data)
+		if err != nil {
+			return err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return err
+		}
+	}
+
+	return DecodeJSON(data, cfg)
+}
+
+// DecodeJSON reads settings sent as JSON (e.g. to the dashboard API) onto cfg the way a
+// settings file is read: settings of older versions are renamed and unknown ones refused.
+// Settings missing from data keep the value cfg already has.
+func DecodeJSON(data []byte, cfg *AppConfig) error {
+	data, err := renameKeys(data)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return describeJSONError(data, err)
+	}
+	return nil
+}
+
+// renameKeys moves the settings of older versions in a JSON object to their current name,
+// unless the object has both. Data without them is returned as it is.
+func renameKeys(data []byte) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if json.Unmarshal(data, &doc) != nil {
+		return data, nil // Not an object: decoding tells what is wrong
+	}
+	renamed := false
+	for old, key := range renamedKeys {
+		value, ok := doc[old]
+		if !ok {
+			continue
+		}
+		if _, ok := doc[key]; !ok {
+			doc[key] = value
+		}
+		delete(doc, old)
+		renamed = true
+	}
+	if !renamed {
+		return data, nil
+	}
+	return json.Marshal(doc)
+}
+
+// stringKeys converts the maps YAML decodes with non-string keys (e.g. `1: x`) so that they
+// can be written as JSON
+func stringKeys(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = stringKeys(item)
+		}
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = stringKeys(item)
+		}
+		return m
+	case []any:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+	}
+	return v
+}
+
+// describeJSONError names the setting or the line at fault instead of the offsets and Go
+// types of encoding/json
+func describeJSONError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		line := bytes.Count(data[:min(int(syntaxErr.Offset), len(data))], []byte("\n")) + 1
+		return fmt.Errorf("line %d: %v", line, syntaxErr)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Errorf("the settings must be an object of key/value pairs, not %s", typeErr.Value)
+		}
+		return fmt.Errorf("%s expects %s, not %s", typeErr.Field, describeType(typeErr.Type.Kind().String()), typeErr.Value)
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return fmt.Errorf("unknown setting %s (run `finder config show` for the available ones)", name)
+	}
+	return err
+}
+
+func describeType(kind string) string {
+	switch kind {
+	case "string":
+		return "text"
+	case "bool":
+		return "true or false"
+	case "int", "int64":
+		return "a whole number"
+	case "float64":
+		return "a number"
+	case "slice":
+		return "a list"
+	case "map", "struct":
+		return "a table of key/value pairs"
+	}
+	return kind
+}
+
+// encode writes cfg in the format of path, with its settings in the order of AppConfig
+func encode(path string, cfg *AppConfig) ([]byte, error) {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil || formatOf(path) == FormatJSON {
+		return data, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	doc, err := readOrdered(dec)
+	if err != nil {
+		return nil, err
+	}
+	if formatOf(path) == FormatTOML {
+		return encodeTOML(doc.([]field)), nil
+	}
+	node, err := yamlNode(doc)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+// field is one key of an object read by readOrdered
+type field struct {
+	key   string
+	value any
+}
+
+// readOrdered decodes the next JSON value keeping the order of object keys: objects become
+// []field, arrays []any, and numbers json.Number
+func readOrdered(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		fields := []field{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field{key.(string), value})
+		}
+		_, err = dec.Token()
+		return fields, err
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			item, err := readOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err = dec.Token()
+		return items, err
+	}
+	return tok, nil
+}
+
+func yamlNode(v any) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case []field:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range v {
+			value, err := yamlNode(f.value)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.key}, value)
+		}
+		return node, nil
+	case []any:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			value, err := yamlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		return node, nil
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(v.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}, nil
+	}
+	node := &yaml.Node{}
+	return node, node.Encode(v)
+}
+
+// withoutEnv returns cfg with the settings an ADF_<KEY> variable gives set back to their value
+// in the settings file data (the default without one), unless cfg changed them since.
+func withoutEnv(path string, data []byte, cfg *AppConfig) (*AppConfig, error) {
+	file := Default()
+	if data != nil {
+		if err := decode(path, data, file); err != nil {
+			return nil, err
+		}
+	}
+	env := *file
+	if err := applyEnv(&env); err != nil {
+		return nil, err
+	}
+
+	saved := *cfg
+	for _, key := range Keys() {
+		if !envSets(key) {
+			continue
+		}
+		value, loaded := settingOf(&saved, key), settingOf(&env, key)
+		if reflect.DeepEqual(value.Interface(), loaded.Interface()) {
+			value.Set(settingOf(file, key))
+		}
+	}
+	return &saved, nil
+}
+
+// envSets reports whether an ADF_<KEY> variable, of the setting or of its older name, is set
+func envSets(key string) bool {
+	if _, ok := os.LookupEnv(envPrefix + strings.ToUpper(key)); ok {
+		return true
+	}
+	for old, renamed := range renamedKeys {
+		if _, ok := os.LookupEnv(envPrefix + strings.ToUpper(old)); ok && renamed == key {
+			return true
+		}
+	}
+	return false
+}
+
+// settingOf returns the field of cfg holding the setting key
+func settingOf(cfg *AppConfig, key string) reflect.Value {
+	v := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonKey(v.Type().Field(i)) == key {
+			return v.Field(i)
+		}
+	}
+	panic("unknown setting " + key)
+}
+
+// applyEnv overrides the settings with the ADF_<KEY> environment variables, e.g.
+// ADF_THRESHOLD_NAME=80 or ADF_PROTECTED='["/archive/keep/**"]', as `finder config set` would.
+// The variables of renamed settings come first, so that the current name wins.
+func applyEnv(cfg *AppConfig) error {
+	var keys []string
+	for old := range renamedKeys {
+		keys = append(keys, old)
+	}
+	sort.Strings(keys)
+	for _, key := range append(keys, Keys()...) {
+		name := envPrefix + strings.ToUpper(key)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := cfg.Set(key, value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readOnlyKeys are managed elsewhere and cannot be set by name
+var readOnlyKeys = map[string]bool{
+	"auth_hash": true, // The setup wizard and login hash the access token
+}
+
+// renamedKeys maps the settings of older versions to the ones that replaced them, which they
+// still set
+var renamedKeys = map[string]string{
+	"threshold": "threshold_name", // Split into threshold_name, threshold_visual and min_content_overlap
+}
+
+// Keys lists the names of the settings that Set accepts, as in the settings file
+func Keys() []string {
+	var keys []string
+	t := reflect.TypeOf(AppConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		if key := jsonKey(t.Field(i)); key != "" && !readOnlyKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Set changes one setting by its name in the settings file. Strings are taken as written,
+// booleans and numbers are parsed, and lists, maps and objects are given as JSON.
+func (c *AppConfig) Set(key, value string) error {
+	if renamed, ok := renamedKeys[key]; ok {
+		key = renamed
+	}
+	if readOnlyKeys[key] {
+		return fmt.Errorf("%s cannot be set directly", key)
+	}
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonKey(v.Type().Field(i)) != key {
+			continue
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s expects true or false", key)
+			}
+			field.SetBool(b)
+		case reflect.Int, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s expects a whole number", key)
+			}
+			field.SetInt(n)
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s expects a number", key)
+			}
+			field.SetFloat(f)
+		default:
+			target := reflect.New(field.Type())
+			if err := json.Unmarshal([]byte(value), target.Interface()); err != nil {
+				return fmt.Errorf("%s expects JSON: %v", key, err)
+			}
+			field.Set(target.Elem())
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown setting %q (available: %s)", key, strings.Join(Keys(), ", "))
+}
+
+// jsonKey returns the name of a field in the settings file
+func jsonKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package config
+
+import "archive-duplicate-finder/internal/notify"
+
+// SecretPlaceholder stands for a stored password or token in the settings sent to the
+// dashboard, which sends it back unchanged when the secret is kept
+const SecretPlaceholder = "********"
+
+// Redacted returns a copy of the settings to show over the network: without the access token
+// hash, and with every password and token that is set replaced by SecretPlaceholder
+func (c *AppConfig) Redacted() *AppConfig {
+	r := *c
+	r.AuthHash = ""
+	for _, secret := range r.secrets() {
+		if *secret != "" {
+			*secret = SecretPlaceholder
+		}
+	}
+	r.Notifications = append([]notify.Target(nil), c.Notifications...)
+	for i := range r.Notifications {
+		for _, secret := range targetSecrets(&r.Notifications[i]) {
+			if *secret != "" {
+				*secret = SecretPlaceholder
+			}
+		}
+	}
+	return &r
+}
+
+// KeepSecrets sets the passwords and tokens that came back as SecretPlaceholder or empty to
+// their value in prev, the settings they were shown from. A notification keeps the secrets
+// of the one of prev with the same type and destination.
+func (c *AppConfig) KeepSecrets(prev *AppConfig) {
+	keep := func(secret, stored *string) {
+		if *secret == "" || *secret == SecretPlaceholder {
+			*secret = *stored
+		}
+	}
+	mine, theirs := c.secrets(), prev.secrets()
+	for i := range mine {
+		keep(mine[i], theirs[i])
+	}
+	for i := range c.Notifications {
+		t := &c.Notifications[i]
+		for j := range prev.Notifications {
+			p := &prev.Notifications[j]
+			if t.Type == p.Type && t.URL == p.URL && t.Host == p.Host && t.Username == p.Username && t.ChatID == p.ChatID {
+				mine, theirs := targetSecrets(t), targetSecrets(p)
+				for k := range mine {
+					keep(mine[k], theirs[k])
+				}
+				break
+			}
+		}
+		for _, secret := range targetSecrets(t) {
+			if *secret == SecretPlaceholder {
+				*secret = "" // New destination: there is nothing to keep
+			}
+		}
+	}
+}
+
+// secrets are the passwords and tokens of the remote stores
+func (c *AppConfig) secrets() []*string {
+	return []*string{&c.S3.SecretKey, &c.S3.SessionToken, &c.SFTP.Password, &c.WebDAV.Password}
+}
+
+func targetSecrets(t *notify.Target) []*string {
+	return []*string{&t.Token, &t.Password}
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// parseTOML reads a TOML document into maps, []any, strings, int64, float64, bool and, for
+// dates, time.Time
+func parseTOML(data []byte) (map[string]any, error) {
+	doc := make(map[string]any)
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("line %d: %s", parseErr.Position.Line, parseErr.Message)
+		}
+		return nil, errors.New(strings.TrimPrefix(err.Error(), "toml: "))
+	}
+	return doc, nil
+}
+
+// encodeTOML writes the settings read by readOrdered. Unset (null) settings are left out,
+// as TOML has no null.
+func encodeTOML(doc []field) []byte {
+	var sb strings.Builder
+	writeTOMLTable(&sb, nil, doc)
+	return []byte(strings.TrimPrefix(sb.String(), "\n"))
+}
+
+func writeTOMLTable(sb *strings.Builder, path []string, fields []field) {
+	// Plain values come first: every key after a [header] belongs to that table
+	for _, f := range fields {
+		if f.value == nil || isTable(f.value) || isTableArray(f.value) {
+			continue
+		}
+		sb.WriteString(tomlKey(f.key) + " = ")
+		writeTOMLValue(sb, f.value)
+		sb.WriteByte('\n')
+	}
+	for _, f := range fields {
+		sub := append(append([]string(nil), path...), f.key)
+		switch {
+		case isTable(f.value):
+			sb.WriteString("\n[" + tomlPath(sub) + "]\n")
+			writeTOMLTable(sb, sub, f.value.([]field))
+		case isTableArray(f.value):
+			for _, item := range f.value.([]any) {
+				sb.WriteString("\n[[" + tomlPath(sub) + "]]\n")
+				writeTOMLTable(sb, sub, item.([]field))
+			}
+		}
+	}
+}
+
+func writeTOMLValue(sb *strings.Builder, v any) {
+	switch v := v.(type) {
+	case string:
+		sb.WriteString(tomlString(v))
+	case json.Number:
+		sb.WriteString(v.String())
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case []any:
+		sb.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeTOMLValue(sb, item)
+		}
+		sb.WriteByte(']')
+	case []field: // Tables inside plain arrays
+		sb.WriteByte('{')
+		for i, f := range v {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(tomlKey(f.key) + " = ")
+			writeTOMLValue(sb, f.value)
+		}
+		sb.WriteByte('}')
+	}
+}
+
+func isTable(v any) bool {
+	_, ok := v.([]field)
+	return ok
+}
+
+// isTableArray reports whether v is a non-empty list of tables, written as [[key]] sections
+func isTableArray(v any) bool {
+	items, ok := v.([]any)
+	if !ok || len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if !isTable(item) {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlPath(keys []string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = tomlKey(k)
+	}
+	return strings.Join(quoted, ".")
+}
+
+func tomlKey(k string) string {
+	for i := 0; i < len(k); i++ {
+		if !isBareKeyChar(k[i]) {
+			return tomlString(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
+
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7F {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
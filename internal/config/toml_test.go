@@ -0,0 +1,83 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/notify"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string // JSON of the document, or the start of the error after "error: "
+	}{
+		{"keys and tables", "a = 1\n[t]\nb = \"x\" # comment\n", `{"a":1,"t":{"b":"x"}}`},
+		{"dotted and quoted keys", "t.\"a b\" = true\n", `{"t":{"a b":true}}`},
+		{"arrays of tables", "[[n]]\ntype = \"ntfy\"\n[[n]]\ntype = \"smtp\"\n", `{"n":[{"type":"ntfy"},{"type":"smtp"}]}`},
+		{"inline tables", "p = { \".zip\" = \"manifest\" }\n", `{"p":{".zip":"manifest"}}`},
+		{"multi-line strings", "a = \"\"\"\none\ntwo\"\"\"\nb = '''C:\\raw'''\n", `{"a":"one\ntwo","b":"C:\\raw"}`},
+		{"hex, octal and underscores", "a = 0x1F\nb = 0o17\nc = 1_000\nd = 2.5e3\n", `{"a":31,"b":15,"c":1000,"d":2500}`},
+		{"dates", "d = 2026-10-15T07:32:00Z\n", `{"d":"2026-10-15T07:32:00Z"}`},
+		{"escapes", "a = \"tab\\tquote\\\" \\u00e9\"\n", `{"a":"tab\tquote\" é"}`},
+		{"missing value", "a =\n", "error: line 1"},
+		{"duplicate key", "a = 1\na = 2\n", "error: line 2"},
+		{"bad table name", "a = 1\n[t b]\n", "error: line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseTOML([]byte(tt.toml))
+			if prefix, ok := strings.CutPrefix(tt.want, "error: "); ok {
+				if err == nil || !strings.HasPrefix(err.Error(), prefix) {
+					t.Fatalf("got error %v, want one starting with %q", err, prefix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(doc)
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOMLRoundTrip(t *testing.T) {
+	full := Default()
+	full.Directory = `D:\Models "new"`
+	full.TrashPath = "/srv/trash\nwith a newline"
+	full.RefTemplate = "{{.Name}}\tmoved\x01"
+	full.Scorers = map[string]float64{"name": 0.7, "token": 0.3}
+	full.Profiles = map[string]string{".zip": "manifest", ".stl": "geometry"}
+	full.Protected = []string{"originals/**", "*.keep"}
+	full.MaxRatio = 150.5
+	full.MaxUncompressedMB = 1 << 40
+	full.S3.Endpoint = "http://localhost:9000"
+	full.Hooks = []hooks.Hook{{Event: "pre_delete", Command: []string{"/bin/echo", "a b"}, Timeout: 5}}
+	full.Notifications = []notify.Target{
+		{Type: "smtp", Host: "mail", Port: 587, To: []string{"a@x", "b@x"}},
+		{Type: "ntfy", URL: "https://ntfy.sh/t"},
+	}
+
+	for name, cfg := range map[string]*AppConfig{"defaults": Default(), "every kind of value": full} {
+		t.Run(name, func(t *testing.T) {
+			data, err := encode("settings.toml", cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := &AppConfig{}
+			if err := decode("settings.toml", data, got); err != nil {
+				t.Fatalf("%v in\n%s", err, data)
+			}
+			if !reflect.DeepEqual(got, cfg) {
+				t.Errorf("read back\n%+v\nwant\n%+v\nfrom\n%s", got, cfg, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/notify"
+	"archive-duplicate-finder/internal/profile"
+	"archive-duplicate-finder/internal/protect"
+	"archive-duplicate-finder/internal/refnote"
+	"archive-duplicate-finder/internal/similarity"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validate checks the settings as the dashboard's settings form does, naming the setting at
+// fault in its error
+func (c *AppConfig) Validate() error {
+	if c.ThresholdName < 0 || c.ThresholdName > 100 {
+		return fmt.Errorf("threshold_name must be between 0 and 100, not %d", c.ThresholdName)
+	}
+	if c.ThresholdVisual < 0 || c.ThresholdVisual > 64 {
+		return fmt.Errorf("threshold_visual must be between 0 and 64 (bits of the preview hash), not %d", c.ThresholdVisual)
+	}
+	if c.MinContentOverlap < 1 || c.MinContentOverlap > 100 {
+		return fmt.Errorf("min_content_overlap must be between 1 and 100, not %d", c.MinContentOverlap)
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, not %d", c.Port)
+	}
+	if c.DeleteMode != "" && c.DeleteMode != "oldest" && c.DeleteMode != "contents" {
+		return fmt.Errorf("delete_mode must be 'oldest' or 'contents', not %q", c.DeleteMode)
+	}
+	if c.AssistantAccess != "" && c.AssistantAccess != AssistantRead && c.AssistantAccess != AssistantQuarantine {
+		return fmt.Errorf("assistant_access must be 'read' or 'quarantine', not %q", c.AssistantAccess)
+	}
+	for _, limit := range []struct {
+		key string
+		n   int64
+	}{
+		{"confirm_above_minutes", int64(c.ConfirmAbove)},
+		{"ignore_ttl_days", int64(c.IgnoreTTLDays)},
+		{"trash_retention_days", int64(c.TrashRetentionDays)},
+		{"workers", int64(c.Workers)},
+		{"archive_timeout_seconds", int64(c.ArchiveTimeout)},
+		{"max_uncompressed_mb", c.MaxUncompressedMB},
+		{"max_entry_mb", c.MaxEntryMB},
+		{"visual_rate_mb", c.VisualRateMB},
+		{"preview_workers", int64(c.PreviewWorkers)},
+		{"memory_budget_mb", c.MemoryBudgetMB},
+	} {
+		if limit.n < 0 {
+			return fmt.Errorf("%s cannot be negative", limit.key)
+		}
+	}
+	if c.MaxRatio < 0 {
+		return errors.New("max_compression_ratio cannot be negative")
+	}
+	if !hashing.IsValidRecheck(c.VerifyBeforeDelete) {
+		return errors.New("verify_before_delete must be 'stat' or 'hash'")
+	}
+	if !similarity.IsValidPhonetic(c.Phonetic) {
+		return errors.New("phonetic must be 'soundex' or 'metaphone'")
+	}
+	if err := similarity.ValidateScorers(c.Scorers); err != nil {
+		return fmt.Errorf("scorers: %w", err)
+	}
+	if err := profile.Validate(c.Profiles); err != nil {
+		return fmt.Errorf("profiles: %w", err)
+	}
+	if err := notify.Validate(c.Notifications); err != nil {
+		return err
+	}
+	if _, err := protect.New(c.Protected); err != nil {
+		return err
+	}
+	if err := archive.ValidatePreviewCommands(c.PreviewConverters); err != nil {
+		return err
+	}
+	if err := hooks.Validate(c.Hooks); err != nil {
+		return err
+	}
+	if err := refnote.Validate(c.RefFormat, c.RefTemplate); err != nil {
+		return err
+	}
+	if err := db.ValidateDSN(c.CacheDSN); err != nil {
+		return fmt.Errorf("cache_dsn: %w", err)
+	}
+	if !i18n.IsSupported(c.Language) {
+		return fmt.Errorf("language must be one of %s", strings.Join(i18n.Languages(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// Dashboard actions recorded in the audit trail
+const (
+	AuditDelete     = "delete"
+	AuditIgnore     = "ignore"
+	AuditUnignore   = "unignore"
+	AuditSuppress   = "suppress"
+	AuditUnsuppress = "unsuppress"
+	AuditReview     = "review"
+	AuditQuarantine = "quarantine"
+	AuditRestore    = "restore"
+	AuditPurge      = "purge"
+)
+
+// AuditEntry records who performed a dashboard action
+type AuditEntry struct {
+	ID     int64  `json:"id"`
+	Time   string `json:"time"`
+	Actor  string `json:"actor"`           // Name the client identified itself with, or "anonymous"
+	Token  string `json:"token,omitempty"` // Fingerprint of the access token used, never the token itself
+	Client string `json:"client,omitempty"`
+	Action string `json:"action"`
+	Target string `json:"target"` // File, group ID or hash the action applied to
+	Detail string `json:"detail,omitempty"`
+}
+
+// AuditFilter narrows AuditTrail; empty fields match everything
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Limit  int // Newest entries first; 0 returns all of them
+}
+
+// RecordAudit appends an entry to the audit trail
+func (c *Cache) RecordAudit(e AuditEntry) error {
+	if e.Time == "" {
+		e.Time = time.Now().Format(time.RFC3339)
+	}
+	_, err := c.db.Exec("INSERT INTO audit_trail (time, actor, token, client, action, target, detail) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		e.Time, e.Actor, e.Token, e.Client, e.Action, e.Target, e.Detail)
+	return err
+}
+
+// AuditTrail returns the recorded actions, newest first
+func (c *Cache) AuditTrail(filter AuditFilter) []AuditEntry {
+	var where []string
+	var args []interface{}
+	if filter.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+	query := "SELECT id, time, actor, token, client, action, target, detail FROM audit_trail"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var result []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.ID, &e.Time, &e.Actor, &e.Token, &e.Client, &e.Action, &e.Target, &e.Detail); err == nil {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -1,7 +1,7 @@
 package db
 
 import (
-	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/paths"
 	"archive-duplicate-finder/internal/scanner"
 	"crypto/sha256"
 	"database/sql"
@@ -9,106 +9,216 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
 
 type Cache struct {
-	db *sql.DB
+	db    *store
+	path  string   // Database file, or the DSN of the server without its password
+	stmts sync.Map // Query -> *sql.Stmt, prepared on first use
+
+	rootMu sync.RWMutex
+	root   string // Scan root new entries are attributed to
+}
+
+// SuppressedHash is a content hash the user accepts to exist in several places
+type SuppressedHash struct {
+	Hash      string `json:"hash"`
+	Note      string `json:"note"`
+	CreatedAt string `json:"created_at"`
 }
 
+// IgnoredGroup is a group the user marked as good. Groups ignored before members were recorded
+// have no Files.
+type IgnoredGroup struct {
+	Hash      string   `json:"hash"`
+	GroupID   string   `json:"group_id,omitempty"` // Stable ID (see GroupIDs): the group stays ignored while it keeps it
+	Files     []string `json:"files"`
+	CreatedAt string   `json:"created_at"`
+	ExpiresAt string   `json:"expires_at,omitempty"` // Empty when the group never re-surfaces on its own
+	Expired   bool     `json:"expired"`
+}
+
+// NameKeys holds the normalized form of a file name used by the clustering engine
+type NameKeys struct {
+	Canonical string // Canonical key (lowercase, noise words removed)
+	Tokens    string // Token fingerprint: sorted unique tokens separated by spaces
+}
+
+// NewCache opens the cache database, creating it or migrating it to the schema of this build
 func NewCache() (*Cache, error) {
-	configDir, err := os.UserConfigDir()
+	db, err := open()
 	if err != nil {
-		configDir = "."
+		return nil, err
+	}
+	if err := migrate(db, LatestSchema(), false); err != nil {
+		db.Close()
+		return nil, err
+	}
+	path := paths.Cache()
+	if dsn != "" {
+		path = redactDSN(dsn) // Also MemoryDSN, for cache stats
 	}
-	dbPath := filepath.Join(configDir, "archive-finder-cache.db")
+	return &Cache{db: db, path: path}, nil
+}
+
+// Connection settings of the cache database. In WAL mode previews and visual hashes are read
+// while others are written; a connection finding the database locked waits for the writer
+// rather than failing, and transactions take the write lock up front so two of them cannot
+// deadlock upgrading theirs.
+const (
+	connectionPragmas = "_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_txlock=immediate"
+	maxConnections    = 4
+)
+
+// memoryDatabase is the cache of MemoryDSN: an SQLite database shared by the connections of
+// the process, gone with the last of them
+const memoryDatabase = "file:/archive-duplicate-finder?vfs=memdb&"
 
-	db, err := sql.Open("sqlite", dbPath)
+// open opens the cache database as it is: the local file, the server of the DSN or memory
+func open() (*store, error) {
+	d, source, err := splitDSN(dsn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
-
-	// Create tables
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS file_metadata (
-			path TEXT PRIMARY KEY,
-			size INTEGER,
-			mod_time TEXT
-		)`,
-		`CREATE TABLE IF NOT EXISTS scan_cache (
-			fingerprint TEXT PRIMARY KEY,
-			results_json TEXT
-		)`,
-		`CREATE TABLE IF NOT EXISTS preview_cache (
-			path TEXT PRIMARY KEY,
-			internal_path TEXT,
-			mod_time TEXT
-		)`,
-		`CREATE TABLE IF NOT EXISTS visual_cache (
-			path TEXT PRIMARY KEY,
-			phash INTEGER,
-			mod_time TEXT
-		)`,
-		`CREATE TABLE IF NOT EXISTS ignored_groups (
-			hash TEXT PRIMARY KEY
-		)`,
-	}
-
-	for _, q := range queries {
-		if _, err := db.Exec(q); err != nil {
-			return nil, fmt.Errorf("failed to create table: %w", err)
+	if d != sqliteDialect {
+		db, err := sql.Open(d.name, source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open database: %w", err)
+		}
+		if err := db.Ping(); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to connect to %s: %w", redactDSN(dsn), err)
 		}
+		return &store{db: db, dialect: d}, nil
 	}
 
-	return &Cache{db: db}, nil
+	if source == "" {
+		source = paths.Cache()
+		if err := os.MkdirAll(filepath.Dir(source), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create the data folder: %w", err)
+		}
+		source += "?"
+	}
+	db, err := sql.Open("sqlite", source+connectionPragmas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(maxConnections)
+	db.SetMaxIdleConns(maxConnections)
+	return &store{db: db, dialect: sqliteDialect}, nil
 }
 
 func (c *Cache) Close() error {
+	c.stmts.Range(func(_, stmt any) bool {
+		stmt.(*sql.Stmt).Close()
+		return true
+	})
 	return c.db.Close()
 }
 
+// prepared returns the statement of a query, prepared once for the lifetime of the cache
+func (c *Cache) prepared(query string) (*sql.Stmt, error) {
+	if stmt, ok := c.stmts.Load(query); ok {
+		return stmt.(*sql.Stmt), nil
+	}
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	if other, loaded := c.stmts.LoadOrStore(query, stmt); loaded {
+		stmt.Close()
+		return other.(*sql.Stmt), nil
+	}
+	return stmt, nil
+}
+
+// queryRow runs a single-row query of the hot paths (one lookup per file) as a prepared statement
+func (c *Cache) queryRow(query string, args ...any) *sql.Row {
+	stmt, err := c.prepared(query)
+	if err != nil {
+		return c.db.QueryRow(query, args...) // Reports the error on Scan
+	}
+	return stmt.QueryRow(args...)
+}
+
+// exec runs a statement of the hot paths as a prepared statement
+func (c *Cache) exec(query string, args ...any) (sql.Result, error) {
+	stmt, err := c.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Exec(args...)
+}
+
+// CalculateFingerprint identifies a set of files by their paths, sizes and modification times
 func (c *Cache) CalculateFingerprint(files []scanner.ArchiveFile) string {
-	// Sort files by path to ensure consistent hash
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].Path < files[j].Path
+	// Sort a copy by path so the hash does not depend on the scan order
+	sorted := slices.Clone(files)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
 	})
 
 	h := sha256.New()
-	for _, f := range files {
-		h.Write([]byte(f.Path))
-		h.Write([]byte(f.ModTime.String()))
+	for _, f := range sorted {
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", f.Path, f.Size, f.ModTime.UnixNano())
 	}
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-func (c *Cache) GetSimilarities(fingerprint string) ([]reporter.SimilarityGroup, bool) {
-	var jsonStr string
-	err := c.db.QueryRow("SELECT results_json FROM scan_cache WHERE fingerprint = ?", fingerprint).Scan(&jsonStr)
-	if err != nil {
-		return nil, false
-	}
+// GetClusters returns the Step 3 clusters saved for the current root with the settings (see
+// PutClusters), and whether they were built from exactly the files of fingerprint
+func (c *Cache) GetClusters(fingerprint, settings string) (data []byte, exact bool, ok bool) {
+	c.rootMu.RLock()
+	root := c.root
+	c.rootMu.RUnlock()
 
-	var groups []reporter.SimilarityGroup
-	if err := json.Unmarshal([]byte(jsonStr), &groups); err != nil {
-		return nil, false
+	var key, jsonStr string
+	err := c.db.QueryRow("SELECT fingerprint, results_json FROM scan_cache WHERE root = ? AND settings = ?", root, settings).Scan(&key, &jsonStr)
+	if err != nil {
+		return nil, false, false
 	}
-	return groups, true
+	return []byte(jsonStr), key == clusterKey(fingerprint, settings), true
 }
 
-func (c *Cache) PutSimilarities(fingerprint string, groups []reporter.SimilarityGroup) {
-	data, err := json.Marshal(groups)
+// PutClusters saves the Step 3 clusters of the current root, keyed by the fingerprint of the
+// files and the settings they were built with (algorithm version, threshold...). Only the
+// latest clusters of a root and settings are kept.
+func (c *Cache) PutClusters(fingerprint, settings string, data []byte) {
+	c.rootMu.RLock()
+	root := c.root
+	c.rootMu.RUnlock()
+
+	tx, err := c.db.Begin()
 	if err != nil {
 		return
 	}
-	_, _ = c.db.Exec("INSERT OR REPLACE INTO scan_cache (fingerprint, results_json) VALUES (?, ?)", fingerprint, string(data))
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM scan_cache WHERE root = ? AND settings = ?", root, settings); err != nil {
+		return
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO scan_cache (fingerprint, results_json, root, settings) VALUES (?, ?, ?, ?)",
+		clusterKey(fingerprint, settings), string(data), root, settings); err != nil {
+		return
+	}
+	_ = tx.Commit()
+}
+
+// clusterKey is the scan_cache key of the clusters of a set of files built with some settings
+func clusterKey(fingerprint, settings string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fingerprint+"\x00"+settings)))
 }
 
 func (c *Cache) GetPreviewPath(path string, modTime string) (string, bool) {
 	var internalPath string
 	var cachedModTime string
-	err := c.db.QueryRow("SELECT internal_path, mod_time FROM preview_cache WHERE path = ?", path).Scan(&internalPath, &cachedModTime)
+	err := c.queryRow("SELECT internal_path, mod_time FROM preview_cache WHERE path = ?", path).Scan(&internalPath, &cachedModTime)
 	if err != nil || cachedModTime != modTime {
 		return "", false
 	}
@@ -116,29 +226,375 @@ func (c *Cache) GetPreviewPath(path string, modTime string) (string, bool) {
 }
 
 func (c *Cache) PutPreviewPath(path string, internalPath string, modTime string) {
-	_, _ = c.db.Exec("INSERT OR REPLACE INTO preview_cache (path, internal_path, mod_time) VALUES (?, ?, ?)", path, internalPath, modTime)
+	_, _ = c.exec("INSERT OR REPLACE INTO preview_cache (path, internal_path, mod_time, root) VALUES (?, ?, ?, ?)", path, internalPath, modTime, c.rootOf(path))
+}
+
+// GetPreviewOverride returns the preview the user picked for an archive
+func (c *Cache) GetPreviewOverride(path string) (string, bool) {
+	var internalPath string
+	err := c.queryRow("SELECT internal_path FROM preview_overrides WHERE path = ?", path).Scan(&internalPath)
+	return internalPath, err == nil
+}
+
+func (c *Cache) PutPreviewOverride(path string, internalPath string) {
+	_, _ = c.db.Exec("INSERT OR REPLACE INTO preview_overrides (path, internal_path, root) VALUES (?, ?, ?)", path, internalPath, c.rootOf(path))
+}
+
+func (c *Cache) RemovePreviewOverride(path string) {
+	_, _ = c.db.Exec("DELETE FROM preview_overrides WHERE path = ?", path)
 }
 
 func (c *Cache) GetVisualHash(path string, modTime string) (uint64, bool) {
 	var phash int64
 	var cachedModTime string
-	err := c.db.QueryRow("SELECT phash, mod_time FROM visual_cache WHERE path = ?", path).Scan(&phash, &cachedModTime)
+	err := c.queryRow("SELECT phash, mod_time FROM visual_cache WHERE path = ?", path).Scan(&phash, &cachedModTime)
 	if err != nil || cachedModTime != modTime {
 		return 0, false
 	}
 	return uint64(phash), true
 }
 
+// VisualHash is the preview hash of a file, for PutVisualHashBatch
+type VisualHash struct {
+	Path    string
+	PHash   uint64
+	ModTime string
+}
+
+// PutVisualHashBatch stores many visual hashes in a single transaction
+func (c *Cache) PutVisualHashBatch(entries []VisualHash) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO visual_cache (path, phash, mod_time, root) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, e := range entries {
+		if _, err := stmt.Exec(e.Path, int64(e.PHash), e.ModTime, c.rootOf(e.Path)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 func (c *Cache) PutVisualHash(path string, phash uint64, modTime string) {
-	_, _ = c.db.Exec("INSERT OR REPLACE INTO visual_cache (path, phash, mod_time) VALUES (?, ?, ?)", path, int64(phash), modTime)
+	_, _ = c.exec("INSERT OR REPLACE INTO visual_cache (path, phash, mod_time, root) VALUES (?, ?, ?, ?)", path, int64(phash), modTime, c.rootOf(path))
 }
 
-func (c *Cache) AddIgnoredGroup(hash string) {
-	_, _ = c.db.Exec("INSERT OR REPLACE INTO ignored_groups (hash) VALUES (?)", hash)
+// AddIgnoredGroup hides a group from future reports. The members are kept so the group can be
+// listed and recognized after some of them are deleted, and its stable ID (if known) so it is
+// recognized after gaining a member; ttl 0 ignores it until un-ignored.
+func (c *Cache) AddIgnoredGroup(hash, groupID string, files []string, ttl time.Duration) {
+	data, err := json.Marshal(files)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	expires := ""
+	if ttl > 0 {
+		expires = now.Add(ttl).UTC().Format(time.RFC3339)
+	}
+	_, _ = c.db.Exec("INSERT OR REPLACE INTO ignored_groups (hash, group_id, files_json, created_at, expires_at) VALUES (?, ?, ?, ?, ?)",
+		hash, groupID, string(data), now.Format(time.RFC3339), expires)
 }
 
-func (c *Cache) IsGroupIgnored(hash string) bool {
+// IsGroupIgnored reports whether a group with exactly these members, or with this stable ID,
+// is ignored and not expired. groupID may be empty.
+func (c *Cache) IsGroupIgnored(hash, groupID string) bool {
 	var exists int
-	err := c.db.QueryRow("SELECT 1 FROM ignored_groups WHERE hash = ?", hash).Scan(&exists)
+	err := c.db.QueryRow("SELECT 1 FROM ignored_groups WHERE (hash = ? OR (group_id != '' AND group_id = ?)) AND (expires_at = '' OR expires_at > ?)",
+		hash, groupID, time.Now().UTC().Format(time.RFC3339)).Scan(&exists)
 	return err == nil
 }
+
+// ListIgnoredGroups returns the ignored groups, newest first, including expired ones
+func (c *Cache) ListIgnoredGroups() []IgnoredGroup {
+	rows, err := c.db.Query("SELECT hash, group_id, files_json, created_at, expires_at FROM ignored_groups ORDER BY created_at DESC")
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	var result []IgnoredGroup
+	for rows.Next() {
+		var g IgnoredGroup
+		var filesJSON string
+		if err := rows.Scan(&g.Hash, &g.GroupID, &filesJSON, &g.CreatedAt, &g.ExpiresAt); err != nil {
+			continue
+		}
+		_ = json.Unmarshal([]byte(filesJSON), &g.Files)
+		g.Expired = g.ExpiresAt != "" && g.ExpiresAt <= now
+		result = append(result, g)
+	}
+	return result
+}
+
+func (c *Cache) RemoveIgnoredGroup(hash string) bool {
+	res, err := c.db.Exec("DELETE FROM ignored_groups WHERE hash = ?", hash)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n > 0
+}
+
+// nameKeysChunk bounds the names looked up per query, below the 999 parameters older SQLite
+// builds accept
+const nameKeysChunk = 500
+
+// GetNameKeys returns the cached normalized keys for the given names, as computed by the given
+// version of the normalizer. Names not in the cache, or cached by another version, are omitted.
+func (c *Cache) GetNameKeys(names []string, version int) map[string]NameKeys {
+	result := make(map[string]NameKeys, len(names))
+	for start := 0; start < len(names); start += nameKeysChunk {
+		chunk := names[start:min(start+nameKeysChunk, len(names))]
+		args := make([]any, 0, len(chunk)+1)
+		args = append(args, version)
+		for _, name := range chunk {
+			args = append(args, name)
+		}
+		query := "SELECT name, canonical, tokens FROM name_cache WHERE version = ? AND name IN (?" + strings.Repeat(", ?", len(chunk)-1) + ")"
+		rows, err := c.db.Query(query, args...)
+		if err != nil {
+			return result
+		}
+		for rows.Next() {
+			var name string
+			var keys NameKeys
+			if err := rows.Scan(&name, &keys.Canonical, &keys.Tokens); err != nil {
+				continue
+			}
+			result[name] = keys
+		}
+		rows.Close()
+	}
+	return result
+}
+
+// PutNameKeys stores normalized keys for many names in a single transaction, recording the
+// version of the normalizer that computed them
+func (c *Cache) PutNameKeys(entries map[string]NameKeys, version int) {
+	if len(entries) == 0 {
+		return
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return
+	}
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO name_cache (name, canonical, tokens, version) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+	defer stmt.Close()
+
+	for name, keys := range entries {
+		_, _ = stmt.Exec(name, keys.Canonical, keys.Tokens, version)
+	}
+	_ = tx.Commit()
+}
+
+func (c *Cache) GetFileHash(path string, size int64, modTime string) (string, bool) {
+	var hash string
+	var cachedSize int64
+	var cachedModTime string
+	err := c.queryRow("SELECT sha256, size, mod_time FROM file_hashes WHERE path = ?", path).Scan(&hash, &cachedSize, &cachedModTime)
+	if err != nil || cachedSize != size || cachedModTime != modTime {
+		return "", false
+	}
+	return hash, true
+}
+
+func (c *Cache) PutFileHash(path string, size int64, modTime string, hash string) {
+	_, _ = c.exec("INSERT OR REPLACE INTO file_hashes (path, size, mod_time, sha256, root) VALUES (?, ?, ?, ?, ?)", path, size, modTime, hash, c.rootOf(path))
+}
+
+// FilesWithHash returns the paths and sizes of the files whose cached SHA-256 is hash
+func (c *Cache) FilesWithHash(hash string) map[string]int64 {
+	result := make(map[string]int64)
+	rows, err := c.db.Query("SELECT path, size FROM file_hashes WHERE sha256 = ?", strings.ToLower(hash))
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		var size int64
+		if rows.Scan(&path, &size) == nil {
+			result[path] = size
+		}
+	}
+	return result
+}
+
+// GetSampleHash returns the cached sampled hash (head, middle and tail) of a file
+func (c *Cache) GetSampleHash(path string, size int64, modTime string) (string, bool) {
+	var hash, cachedModTime string
+	var cachedSize int64
+	err := c.queryRow("SELECT sample, size, mod_time FROM sample_hashes WHERE path = ?", path).Scan(&hash, &cachedSize, &cachedModTime)
+	if err != nil || cachedSize != size || cachedModTime != modTime {
+		return "", false
+	}
+	return hash, true
+}
+
+func (c *Cache) PutSampleHash(path string, size int64, modTime string, hash string) {
+	_, _ = c.exec("INSERT OR REPLACE INTO sample_hashes (path, size, mod_time, sample, root) VALUES (?, ?, ?, ?, ?)", path, size, modTime, hash, c.rootOf(path))
+}
+
+// GetProfileKey returns the cached comparison key of a file for an analysis profile method
+// ("manifest", "geometry")
+func (c *Cache) GetProfileKey(path, method string, size int64, modTime string) (string, bool) {
+	var key, cachedModTime string
+	var cachedSize int64
+	err := c.queryRow("SELECT key, size, mod_time FROM profile_keys WHERE path = ? AND method = ?", path, method).Scan(&key, &cachedSize, &cachedModTime)
+	if err != nil || cachedSize != size || cachedModTime != modTime {
+		return "", false
+	}
+	return key, true
+}
+
+func (c *Cache) PutProfileKey(path, method string, size int64, modTime string, key string) {
+	_, _ = c.exec("INSERT OR REPLACE INTO profile_keys (path, method, size, mod_time, key, root) VALUES (?, ?, ?, ?, ?, ?)", path, method, size, modTime, key, c.rootOf(path))
+}
+
+// GetPayloadHash returns the hash and size of the decompressed payload of a compressed archive
+func (c *Cache) GetPayloadHash(path string, size int64, modTime string) (string, int64, bool) {
+	var hash, cachedModTime string
+	var cachedSize, payloadSize int64
+	err := c.queryRow("SELECT sha256, size, mod_time, payload_size FROM payload_hashes WHERE path = ?", path).Scan(&hash, &cachedSize, &cachedModTime, &payloadSize)
+	if err != nil || cachedSize != size || cachedModTime != modTime {
+		return "", 0, false
+	}
+	return hash, payloadSize, true
+}
+
+func (c *Cache) PutPayloadHash(path string, size int64, modTime string, hash string, payloadSize int64) {
+	_, _ = c.exec("INSERT OR REPLACE INTO payload_hashes (path, size, mod_time, sha256, payload_size, root) VALUES (?, ?, ?, ?, ?, ?)", path, size, modTime, hash, payloadSize, c.rootOf(path))
+}
+
+// GetContents returns the cached entry count, uncompressed size and entries of an archive
+func (c *Cache) GetContents(path string, size int64, modTime string) (scanner.ContentSummary, bool) {
+	var summary scanner.ContentSummary
+	var cachedSize int64
+	var cachedModTime, entries string
+	err := c.queryRow("SELECT size, mod_time, file_count, uncompressed_size, entries FROM archive_contents WHERE path = ?", path).Scan(&cachedSize, &cachedModTime, &summary.FileCount, &summary.UncompressedSize, &entries)
+	if err != nil || cachedSize != size || cachedModTime != modTime {
+		return scanner.ContentSummary{}, false
+	}
+	if entries != "" {
+		summary.Entries = strings.Split(entries, "\n")
+	}
+	return summary, true
+}
+
+func (c *Cache) PutContents(path string, size int64, modTime string, summary scanner.ContentSummary) {
+	_, _ = c.exec("INSERT OR REPLACE INTO archive_contents (path, size, mod_time, file_count, uncompressed_size, entries, root) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		path, size, modTime, summary.FileCount, summary.UncompressedSize, strings.Join(summary.Entries, "\n"), c.rootOf(path))
+}
+
+// SearchContents returns the cached entries containing query, whatever the case, by archive path
+func (c *Cache) SearchContents(query string) map[string][]string {
+	result := make(map[string][]string)
+	query = strings.ToLower(query)
+	pattern := "%" + strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(query) + "%"
+	rows, err := c.db.Query("SELECT path, entries FROM archive_contents WHERE LOWER(entries) LIKE ? ESCAPE '!'", pattern)
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path, entries string
+		if rows.Scan(&path, &entries) != nil {
+			continue
+		}
+		for _, e := range strings.Split(entries, "\n") {
+			if strings.Contains(strings.ToLower(e), query) {
+				result[path] = append(result[path], e)
+			}
+		}
+	}
+	return result
+}
+
+func (c *Cache) AddSuppressedHash(hash string, note string) {
+	_, _ = c.db.Exec("INSERT OR REPLACE INTO suppressed_hashes (hash, note, created_at) VALUES (?, ?, ?)", hash, note, time.Now().Format(time.RFC3339))
+}
+
+func (c *Cache) RemoveSuppressedHash(hash string) {
+	_, _ = c.db.Exec("DELETE FROM suppressed_hashes WHERE hash = ?", hash)
+}
+
+func (c *Cache) IsHashSuppressed(hash string) bool {
+	var exists int
+	err := c.db.QueryRow("SELECT 1 FROM suppressed_hashes WHERE hash = ?", hash).Scan(&exists)
+	return err == nil
+}
+
+// HasSuppressedHashes lets callers skip content hashing entirely when nothing is suppressed
+func (c *Cache) HasSuppressedHashes() bool {
+	var exists int
+	err := c.db.QueryRow("SELECT 1 FROM suppressed_hashes LIMIT 1").Scan(&exists)
+	return err == nil
+}
+
+func (c *Cache) ListSuppressedHashes() []SuppressedHash {
+	rows, err := c.db.Query("SELECT hash, note, created_at FROM suppressed_hashes ORDER BY created_at DESC")
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var result []SuppressedHash
+	for rows.Next() {
+		var h SuppressedHash
+		if err := rows.Scan(&h.Hash, &h.Note, &h.CreatedAt); err == nil {
+			result = append(result, h)
+		}
+	}
+	return result
+}
+
+// GetBenchmark returns the size (files processed) and duration of the last run of a phase
+func (c *Cache) GetBenchmark(phase string) (units int64, seconds float64, ok bool) {
+	err := c.db.QueryRow("SELECT units, seconds FROM benchmarks WHERE phase = ?", phase).Scan(&units, &seconds)
+	if err != nil || units <= 0 {
+		return 0, 0, false
+	}
+	return units, seconds, true
+}
+
+func (c *Cache) PutBenchmark(phase string, units int64, seconds float64) {
+	_, _ = c.db.Exec("INSERT OR REPLACE INTO benchmarks (phase, units, seconds) VALUES (?, ?, ?)", phase, units, seconds)
+}
+
+// GetDirListing returns the cached listing of a directory if it was stored for the same modification time
+func (c *Cache) GetDirListing(dir string, modTime time.Time) (scanner.DirListing, bool) {
+	var cachedModTime, listingJSON string
+	err := c.db.QueryRow("SELECT mod_time, listing_json FROM dir_listings WHERE dir = ?", dir).Scan(&cachedModTime, &listingJSON)
+	if err != nil || cachedModTime != modTime.Format(time.RFC3339Nano) {
+		return scanner.DirListing{}, false
+	}
+	var listing scanner.DirListing
+	if err := json.Unmarshal([]byte(listingJSON), &listing); err != nil {
+		return scanner.DirListing{}, false
+	}
+	return listing, true
+}
+
+func (c *Cache) PutDirListing(dir string, modTime time.Time, listing scanner.DirListing) {
+	data, err := json.Marshal(listing)
+	if err != nil {
+		return
+	}
+	_, _ = c.db.Exec("INSERT OR REPLACE INTO dir_listings (dir, mod_time, listing_json, root) VALUES (?, ?, ?, ?)", dir, modTime.Format(time.RFC3339Nano), string(data), c.rootOf(dir))
+}
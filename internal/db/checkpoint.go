@@ -0,0 +1,77 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// VisualCheckpoint is the saved state of a visual analysis that has not finished, so that it
+// resumes after a restart. The hashes themselves are in the visual cache already.
+type VisualCheckpoint struct {
+	Root      string        `json:"root"`
+	Started   string        `json:"started"`
+	Updated   string        `json:"updated"`
+	Total     int           `json:"total"` // Files to hash
+	Done      int           `json:"done"`
+	Remaining int           `json:"remaining"`
+	Paused    bool          `json:"paused"` // Paused when saved; it resumes paused too
+	Errors    []VisualError `json:"errors"` // Files whose preview could not be hashed, skipped on resume
+}
+
+// VisualError is a file the visual analysis failed on
+type VisualError struct {
+	Path    string `json:"path"`
+	ModTime string `json:"mod_time"` // A file changed since is tried again
+	Error   string `json:"error"`
+}
+
+// VisualCheckpoint returns the checkpoint of the visual analysis of root, if one is unfinished
+func (c *Cache) VisualCheckpoint(root string) (VisualCheckpoint, bool) {
+	var cp VisualCheckpoint
+	var paused int
+	var errs string
+	err := c.db.QueryRow("SELECT root, started, updated, total, done, paused, errors FROM visual_checkpoints WHERE root = ?", normalizeRoot(root)).
+		Scan(&cp.Root, &cp.Started, &cp.Updated, &cp.Total, &cp.Done, &paused, &errs)
+	if err != nil {
+		return VisualCheckpoint{}, false
+	}
+	cp.Paused = paused != 0
+	cp.Remaining = cp.Total - cp.Done
+	if json.Unmarshal([]byte(errs), &cp.Errors) != nil || cp.Errors == nil {
+		cp.Errors = []VisualError{}
+	}
+	return cp, true
+}
+
+// SaveVisualCheckpoint replaces the checkpoint of the visual analysis of cp.Root
+func (c *Cache) SaveVisualCheckpoint(cp VisualCheckpoint) error {
+	errs, err := json.Marshal(cp.Errors)
+	if err != nil {
+		return err
+	}
+	paused := 0
+	if cp.Paused {
+		paused = 1
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	root := normalizeRoot(cp.Root)
+	if _, err := tx.Exec("DELETE FROM visual_checkpoints WHERE root = ?", root); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO visual_checkpoints (root, started, updated, total, done, paused, errors) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		root, cp.Started, time.Now().Format(time.RFC3339), cp.Total, cp.Done, paused, string(errs)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClearVisualCheckpoint forgets the checkpoint of root once its visual analysis finished or was
+// canceled
+func (c *Cache) ClearVisualCheckpoint(root string) error {
+	_, err := c.db.Exec("DELETE FROM visual_checkpoints WHERE root = ?", normalizeRoot(root))
+	return err
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// groupMatch is the share of members (Jaccard index) a group must have in common with a known
+// group to inherit its ID
+const groupMatch = 0.5
+
+// knownGroup is a group an ID was handed out to, with its members when last seen
+type knownGroup struct {
+	id      string
+	members map[string]bool
+}
+
+// GroupIDs returns a stable ID for each group of member paths of one kind ("size", "similar",
+// "visual"). A group inherits the ID of the known group it shares the most members with, at
+// least half of both counted together, so adding a copy to a folder or deleting one keeps
+// the ID that ignore flags, notes and resolutions are attached to. Each ID goes to one group
+// at most; groups without a match get a new ID. The members of every group are remembered.
+func (c *Cache) GroupIDs(kind string, groups [][]string) []string {
+	ids := make([]string, len(groups))
+	if len(groups) == 0 {
+		return ids
+	}
+
+	var known []knownGroup
+	taken := make(map[string]bool)
+	byPath := make(map[string][]int)
+	rows, err := c.db.Query("SELECT id, files_json FROM group_ids WHERE kind = ?", kind)
+	if err == nil {
+		for rows.Next() {
+			var id, filesJSON string
+			var files []string
+			if rows.Scan(&id, &filesJSON) != nil || json.Unmarshal([]byte(filesJSON), &files) != nil {
+				continue
+			}
+			g := knownGroup{id: id, members: make(map[string]bool, len(files))}
+			for _, p := range files {
+				g.members[p] = true
+				byPath[p] = append(byPath[p], len(known))
+			}
+			known = append(known, g)
+			taken[id] = true
+		}
+		rows.Close()
+	}
+
+	claimed := make(map[int]bool)
+	for i, members := range groups {
+		shared := make(map[int]int)
+		for _, p := range members {
+			for _, k := range byPath[p] {
+				shared[k]++
+			}
+		}
+		best, bestScore := -1, 0.0
+		for k, n := range shared {
+			if claimed[k] {
+				continue
+			}
+			score := float64(n) / float64(len(members)+len(known[k].members)-n)
+			if score > bestScore || (score == bestScore && best >= 0 && known[k].id < known[best].id) {
+				best, bestScore = k, score
+			}
+		}
+		if best >= 0 && bestScore >= groupMatch {
+			claimed[best] = true
+			ids[i] = known[best].id
+			continue
+		}
+		ids[i] = newGroupID(kind, members, taken)
+		taken[ids[i]] = true
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return ids
+	}
+	now := time.Now().Format(time.RFC3339)
+	for i, members := range groups {
+		data, err := json.Marshal(members)
+		if err != nil {
+			continue
+		}
+		_, _ = tx.Exec("INSERT OR REPLACE INTO group_ids (id, kind, files_json, last_seen) VALUES (?, ?, ?, ?)", ids[i], kind, string(data), now)
+	}
+	_ = tx.Commit()
+	return ids
+}
+
+// newGroupID derives an ID from the kind and members of a group, avoiding the taken ones
+func newGroupID(kind string, members []string, taken map[string]bool) string {
+	sorted := append([]string(nil), members...)
+	sort.Strings(sorted)
+	for salt := 0; ; salt++ {
+		h := sha256.New()
+		fmt.Fprintf(h, "%s\x00%d", kind, salt)
+		for _, p := range sorted {
+			h.Write([]byte{0})
+			h.Write([]byte(p))
+		}
+		if id := fmt.Sprintf("%x", h.Sum(nil))[:16]; !taken[id] {
+			return id
+		}
+	}
+}
+
+// GroupReview is the triage state of a report group, kept under its stable ID (see GroupIDs)
+type GroupReview struct {
+	GroupID   string `json:"group_id"`
+	Status    string `json:"status"` // "pending", "reviewed" or "resolved"
+	Note      string `json:"note,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// GroupReviews returns the review of every group that has one, by group ID
+func (c *Cache) GroupReviews() map[string]GroupReview {
+	reviews := make(map[string]GroupReview)
+	rows, err := c.db.Query("SELECT group_id, status, note, updated_at FROM group_reviews")
+	if err != nil {
+		return reviews
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r GroupReview
+		if rows.Scan(&r.GroupID, &r.Status, &r.Note, &r.UpdatedAt) == nil {
+			reviews[r.GroupID] = r
+		}
+	}
+	return reviews
+}
+
+// SetGroupReview records the status and note of a group
+func (c *Cache) SetGroupReview(groupID, status, note string) (GroupReview, error) {
+	r := GroupReview{GroupID: groupID, Status: status, Note: note, UpdatedAt: time.Now().Format(time.RFC3339)}
+	_, err := c.db.Exec("INSERT OR REPLACE INTO group_reviews (group_id, status, note, updated_at) VALUES (?, ?, ?, ?)",
+		r.GroupID, r.Status, r.Note, r.UpdatedAt)
+	return r, err
+}
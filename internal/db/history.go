@@ -0,0 +1,47 @@
+package db
+
+import (
+	"time"
+
+	"archive-duplicate-finder/internal/reporter"
+)
+
+// Run is a finished scan recorded in the history, for trends across runs
+type Run struct {
+	ID   int64  `json:"id"`
+	Time string `json:"time"`
+	Root string `json:"root"`
+	reporter.Summary
+}
+
+// RecordRun appends the totals of a finished scan of root to the history
+func (c *Cache) RecordRun(root string, s reporter.Summary) error {
+	_, err := c.db.Exec("INSERT INTO scan_history (time, root, files, bytes, duplicate_groups, duplicate_files, reclaimable_bytes) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		time.Now().Format(time.RFC3339), normalizeRoot(root), s.Files, s.Bytes, s.DuplicateGroups, s.DuplicateFiles, s.ReclaimableBytes)
+	return err
+}
+
+// RunHistory returns the recorded scans of root, newest first, at most limit of them (0 returns all)
+func (c *Cache) RunHistory(root string, limit int) []Run {
+	query := "SELECT id, time, root, files, bytes, duplicate_groups, duplicate_files, reclaimable_bytes FROM scan_history WHERE root = ? ORDER BY id DESC"
+	args := []interface{}{normalizeRoot(root)}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var result []Run
+	for rows.Next() {
+		var r Run
+		if err := rows.Scan(&r.ID, &r.Time, &r.Root, &r.Files, &r.Bytes, &r.DuplicateGroups, &r.DuplicateFiles, &r.ReclaimableBytes); err == nil {
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,285 @@
+package db
+
+import (
+	"archive-duplicate-finder/internal/vfs"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// namespacedTables are the tables keyed by a file or directory path, with their key column
+var namespacedTables = []struct{ name, key string }{
+	{"file_hashes", "path"},
+	{"sample_hashes", "path"},
+	{"profile_keys", "path"},
+	{"payload_hashes", "path"},
+	{"archive_contents", "path"},
+	{"preview_cache", "path"},
+	{"visual_cache", "path"},
+	{"preview_overrides", "path"},
+	{"dir_listings", "dir"},
+}
+
+// normalizeRoot makes local roots absolute so entries match however the directory was typed
+func normalizeRoot(p string) string {
+	if p == "" || vfs.IsRemote(p) {
+		return strings.TrimSuffix(p, "/")
+	}
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
+
+// SetRoot attributes entries written from now on to a scan root (the directory being scanned)
+func (c *Cache) SetRoot(root string) {
+	c.rootMu.Lock()
+	defer c.rootMu.Unlock()
+	c.root = normalizeRoot(root)
+}
+
+// rootOf returns the current scan root if p lies inside it, otherwise "" (unattributed)
+func (c *Cache) rootOf(p string) string {
+	c.rootMu.RLock()
+	root := c.root
+	c.rootMu.RUnlock()
+	if root == "" {
+		return ""
+	}
+	if !vfs.IsRemote(p) && !filepath.IsAbs(p) {
+		p = normalizeRoot(p)
+	}
+	sep := string(filepath.Separator)
+	if vfs.IsRemote(root) {
+		sep = "/"
+	}
+	if p == root || strings.HasPrefix(p, strings.TrimSuffix(root, sep)+sep) {
+		return root
+	}
+	return ""
+}
+
+// RootStats counts the cached entries of one scan root ("" for entries written before roots
+// were recorded, or outside any scanned directory)
+type RootStats struct {
+	Root         string `json:"root"`
+	FileHashes   int    `json:"file_hashes"` // Including sampled hashes, profile keys and the payload hashes of compressed archives
+	Previews     int    `json:"previews"`
+	VisualHashes int    `json:"visual_hashes"`
+	Overrides    int    `json:"preview_overrides"`
+	DirListings  int    `json:"dir_listings"`
+	Contents     int    `json:"contents"` // Entry counts and uncompressed sizes of archives
+	Total        int    `json:"total"`
+}
+
+// CacheStats summarizes the cache database
+type CacheStats struct {
+	Path          string      `json:"path"`
+	SizeBytes     int64       `json:"size_bytes"`
+	Schema        int         `json:"schema_version"`
+	Roots         []RootStats `json:"roots"`
+	IgnoredGroups int         `json:"ignored_groups"`
+	Suppressed    int         `json:"suppressed_hashes"`
+	NameKeys      int         `json:"name_keys"`
+	ScanResults   int         `json:"scan_results"`
+}
+
+// Stats counts the entries of every scan root and of the tables that are not tied to paths
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{Path: c.path, Roots: []RootStats{}}
+	if err := c.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&stats.Schema); err != nil {
+		return stats, err
+	}
+	size := c.db.dialect.size
+	if c.path == MemoryDSN {
+		size = "SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()"
+	}
+	if size != "" {
+		if err := c.db.QueryRow(size).Scan(&stats.SizeBytes); err != nil {
+			return stats, err
+		}
+	} else if info, err := os.Stat(c.path); err == nil {
+		stats.SizeBytes = info.Size()
+		// Recent writes may still sit in the write-ahead log
+		if wal, err := os.Stat(c.path + "-wal"); err == nil {
+			stats.SizeBytes += wal.Size()
+		}
+	}
+
+	byRoot := make(map[string]*RootStats)
+	for _, t := range namespacedTables {
+		rows, err := c.db.Query(fmt.Sprintf("SELECT root, COUNT(*) FROM %s GROUP BY root", t.name))
+		if err != nil {
+			return stats, err
+		}
+		for rows.Next() {
+			var root string
+			var n int
+			if rows.Scan(&root, &n) != nil {
+				continue
+			}
+			rs := byRoot[root]
+			if rs == nil {
+				rs = &RootStats{Root: root}
+				byRoot[root] = rs
+			}
+			switch t.name {
+			case "file_hashes", "sample_hashes", "profile_keys", "payload_hashes":
+				rs.FileHashes += n
+			case "preview_cache":
+				rs.Previews = n
+			case "visual_cache":
+				rs.VisualHashes = n
+			case "preview_overrides":
+				rs.Overrides = n
+			case "dir_listings":
+				rs.DirListings = n
+			case "archive_contents":
+				rs.Contents = n
+			}
+			rs.Total += n
+		}
+		rows.Close()
+	}
+	for _, rs := range byRoot {
+		stats.Roots = append(stats.Roots, *rs)
+	}
+	sort.Slice(stats.Roots, func(i, j int) bool { return stats.Roots[i].Total > stats.Roots[j].Total })
+
+	counts := []struct {
+		table string
+		dst   *int
+	}{
+		{"ignored_groups", &stats.IgnoredGroups},
+		{"suppressed_hashes", &stats.Suppressed},
+		{"name_cache", &stats.NameKeys},
+		{"scan_cache", &stats.ScanResults},
+	}
+	for _, count := range counts {
+		if err := c.db.QueryRow("SELECT COUNT(*) FROM " + count.table).Scan(count.dst); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// GCResult reports what a garbage collection removed
+type GCResult struct {
+	Removed map[string]int `json:"removed"` // Entries dropped per table
+	Total   int            `json:"total"`
+	Offline []string       `json:"offline"` // Roots (or folders) that were not reachable and were left alone
+}
+
+// GC drops the entries of files and folders that no longer exist, and expired ignored groups.
+// A missing root is treated as an unmounted drive or share rather than a deletion, so its
+// entries are kept; the same goes for unattributed entries whose parent folder is gone.
+// Remote paths are not checked.
+func (c *Cache) GC() (GCResult, error) {
+	result := GCResult{Removed: make(map[string]int), Offline: []string{}}
+	reachable := make(map[string]bool)
+	isReachable := func(dir string) bool {
+		ok, seen := reachable[dir]
+		if !seen {
+			info, err := os.Stat(dir)
+			ok = err == nil && info.IsDir()
+			reachable[dir] = ok
+			if !ok {
+				result.Offline = append(result.Offline, dir)
+			}
+		}
+		return ok
+	}
+
+	for _, t := range namespacedTables {
+		rows, err := c.db.Query(fmt.Sprintf("SELECT %s, root FROM %s", t.key, t.name))
+		if err != nil {
+			return result, err
+		}
+		var stale []string
+		for rows.Next() {
+			var p, root string
+			if rows.Scan(&p, &root) != nil || p == "" || vfs.IsRemote(p) {
+				continue
+			}
+			anchor := root
+			if anchor == "" {
+				anchor = filepath.Dir(p)
+			}
+			if !isReachable(anchor) {
+				continue
+			}
+			if _, err := os.Lstat(p); os.IsNotExist(err) {
+				stale = append(stale, p)
+			}
+		}
+		rows.Close()
+
+		if err := c.deleteKeys(t.name, t.key, stale); err != nil {
+			return result, err
+		}
+		if len(stale) > 0 {
+			result.Removed[t.name] = len(stale)
+			result.Total += len(stale)
+		}
+	}
+
+	res, err := c.db.Exec("DELETE FROM ignored_groups WHERE expires_at != '' AND expires_at <= ?", time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return result, err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		result.Removed["ignored_groups"] = int(n)
+		result.Total += int(n)
+	}
+
+	sort.Strings(result.Offline)
+	return result, nil
+}
+
+func (c *Cache) deleteKeys(table, key string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, key))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, k := range keys {
+		if _, err := stmt.Exec(k); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ForgetRoot removes every entry attributed to a scan root, for libraries that are gone for good
+func (c *Cache) ForgetRoot(root string) (int, error) {
+	root = normalizeRoot(root)
+	total := 0
+	for _, t := range namespacedTables {
+		res, err := c.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE root = ?", t.name), root)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += int(n)
+	}
+	for _, table := range []string{"scan_cache", "scan_history", "visual_checkpoints"} {
+		res, err := c.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE root = ?", table), root)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += int(n)
+	}
+	return total, nil
+}
@@ -0,0 +1,417 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migration is one step of the cache schema. Steps only ever get appended: the version of a
+// database is the number of steps applied to it.
+type migration struct {
+	name string
+	up   func(tx *storeTx) error
+	down func(tx *storeTx) error // Undoes up, for `finder cache migrate -to`; nil for the first step
+}
+
+// LatestSchema is the schema version this build writes
+func LatestSchema() int {
+	return len(migrations)
+}
+
+var migrations = []migration{
+	{
+		// Databases created before versioning get the steps below replayed: every step
+		// checks what is already there
+		name: "initial tables",
+		up: func(tx *storeTx) error {
+			return execAll(tx, initialTables...)
+		},
+	},
+	{
+		name: "scan roots of path-keyed entries",
+		up: func(tx *storeTx) error {
+			for _, t := range namespacedTables {
+				if err := addColumn(tx, t.name, "root", "TEXT NOT NULL DEFAULT ''"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		down: func(tx *storeTx) error {
+			for _, t := range namespacedTables {
+				if err := dropColumn(tx, t.name, "root"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		name: "members, expiry and ID of ignored groups",
+		up: func(tx *storeTx) error {
+			for _, column := range ignoredGroupColumns {
+				if err := addColumn(tx, "ignored_groups", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		down: func(tx *storeTx) error {
+			for _, column := range ignoredGroupColumns {
+				if err := dropColumn(tx, "ignored_groups", column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		// Step 3 clusters are saved per root and settings; entries of the old layout are dropped
+		name: "similarity cache per root and settings",
+		up: func(tx *storeTx) error {
+			for _, column := range []string{"root", "settings"} {
+				if err := addColumn(tx, "scan_cache", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+					return err
+				}
+			}
+			return execAll(tx, "DELETE FROM scan_cache WHERE settings = ''")
+		},
+		down: func(tx *storeTx) error {
+			return execAll(tx, "DELETE FROM scan_cache", "ALTER TABLE scan_cache DROP COLUMN settings", "ALTER TABLE scan_cache DROP COLUMN root")
+		},
+	},
+	{
+		name: "scan history",
+		up: func(tx *storeTx) error {
+			return execAll(tx, `CREATE TABLE IF NOT EXISTS scan_history (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				time TEXT NOT NULL,
+				root TEXT NOT NULL,
+				files INTEGER NOT NULL,
+				bytes INTEGER NOT NULL,
+				duplicate_groups INTEGER NOT NULL,
+				duplicate_files INTEGER NOT NULL,
+				reclaimable_bytes INTEGER NOT NULL
+			)`)
+		},
+		down: func(tx *storeTx) error {
+			return execAll(tx, "DROP TABLE IF EXISTS scan_history")
+		},
+	},
+	{
+		// Summaries cached without their entries are read again on the next -contents run
+		name: "entries of archive contents",
+		up: func(tx *storeTx) error {
+			if err := addColumn(tx, "archive_contents", "entries", "TEXT NOT NULL DEFAULT ''"); err != nil {
+				return err
+			}
+			return execAll(tx, "DELETE FROM archive_contents WHERE entries = ''")
+		},
+		down: func(tx *storeTx) error {
+			return dropColumn(tx, "archive_contents", "entries")
+		},
+	},
+	{
+		name: "visual analysis checkpoints",
+		up: func(tx *storeTx) error {
+			return execAll(tx, `CREATE TABLE IF NOT EXISTS visual_checkpoints (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				root TEXT NOT NULL,
+				started TEXT NOT NULL,
+				updated TEXT NOT NULL,
+				total INTEGER NOT NULL,
+				done INTEGER NOT NULL,
+				paused INTEGER NOT NULL,
+				errors TEXT NOT NULL
+			)`)
+		},
+		down: func(tx *storeTx) error {
+			return execAll(tx, "DROP TABLE IF EXISTS visual_checkpoints")
+		},
+	},
+	{
+		// Keys cached before the column are read as version 0, which no normalizer has, and
+		// get computed again
+		name: "normalizer version of name keys",
+		up: func(tx *storeTx) error {
+			return addColumn(tx, "name_cache", "version", "INTEGER NOT NULL DEFAULT 0")
+		},
+		down: func(tx *storeTx) error {
+			return dropColumn(tx, "name_cache", "version")
+		},
+	},
+}
+
+var ignoredGroupColumns = []string{"files_json", "created_at", "expires_at", "group_id"}
+
+// schemaVersionTable records the migrations applied
+const schemaVersionTable = `CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY,
+	name TEXT,
+	applied_at TEXT
+)`
+
+// initialTables is the schema of the cache before versioning
+var initialTables = []string{
+
+	`CREATE TABLE IF NOT EXISTS file_metadata (
+		path TEXT PRIMARY KEY,
+		size INTEGER,
+		mod_time TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS scan_cache (
+		fingerprint TEXT PRIMARY KEY,
+		results_json TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS preview_cache (
+		path TEXT PRIMARY KEY,
+		internal_path TEXT,
+		mod_time TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS visual_cache (
+		path TEXT PRIMARY KEY,
+		phash INTEGER,
+		mod_time TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS ignored_groups (
+		hash TEXT PRIMARY KEY
+	)`,
+	`CREATE TABLE IF NOT EXISTS file_hashes (
+		path TEXT PRIMARY KEY,
+		size INTEGER,
+		mod_time TEXT,
+		sha256 TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS sample_hashes (
+		path TEXT PRIMARY KEY,
+		size INTEGER,
+		mod_time TEXT,
+		sample TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS profile_keys (
+		path TEXT,
+		method TEXT,
+		size INTEGER,
+		mod_time TEXT,
+		key TEXT,
+		PRIMARY KEY (path, method)
+	)`,
+	`CREATE TABLE IF NOT EXISTS payload_hashes (
+		path TEXT PRIMARY KEY,
+		size INTEGER,
+		mod_time TEXT,
+		sha256 TEXT,
+		payload_size INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS archive_contents (
+		path TEXT PRIMARY KEY,
+		size INTEGER,
+		mod_time TEXT,
+		file_count INTEGER,
+		uncompressed_size INTEGER
+	)`,
+	`CREATE TABLE IF NOT EXISTS suppressed_hashes (
+		hash TEXT PRIMARY KEY,
+		note TEXT,
+		created_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS benchmarks (
+		phase TEXT PRIMARY KEY,
+		units INTEGER,
+		seconds REAL
+	)`,
+	`CREATE TABLE IF NOT EXISTS name_cache (
+		name TEXT PRIMARY KEY,
+		canonical TEXT,
+		tokens TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS preview_overrides (
+		path TEXT PRIMARY KEY,
+		internal_path TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS dir_listings (
+		dir TEXT PRIMARY KEY,
+		mod_time TEXT,
+		listing_json TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS group_ids (
+		id TEXT PRIMARY KEY,
+		kind TEXT,
+		files_json TEXT,
+		last_seen TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS group_reviews (
+		group_id TEXT PRIMARY KEY,
+		status TEXT,
+		note TEXT,
+		updated_at TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS operations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time TEXT,
+		source TEXT,
+		run TEXT,
+		action TEXT,
+		path TEXT,
+		dest TEXT,
+		group_id TEXT,
+		size INTEGER,
+		sha256 TEXT,
+		kept TEXT,
+		detail TEXT
+	)`,
+	// The operations log is append-only: entries can be neither changed nor removed
+	`CREATE TRIGGER IF NOT EXISTS operations_no_update BEFORE UPDATE ON operations
+		BEGIN SELECT RAISE(ABORT, 'the operations log is append-only'); END`,
+	`CREATE TRIGGER IF NOT EXISTS operations_no_delete BEFORE DELETE ON operations
+		BEGIN SELECT RAISE(ABORT, 'the operations log is append-only'); END`,
+	`CREATE TABLE IF NOT EXISTS audit_trail (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time TEXT,
+		actor TEXT,
+		token TEXT,
+		client TEXT,
+		action TEXT,
+		target TEXT,
+		detail TEXT
+	)`,
+}
+
+// schemaVersion returns the schema version of the database: the number of migrations applied
+func schemaVersion(db *store) (int, error) {
+	if err := execAll(db, schemaVersionTable); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version)
+	return version, err
+}
+
+// migrate brings the database to a schema version, one transaction per step. Going back is
+// only allowed with downgrade, for `finder cache migrate -to`; otherwise a database written by
+// a newer build is refused rather than misread.
+func migrate(db *store, to int, downgrade bool) error {
+	current, err := schemaVersion(db)
+	if err != nil {
+		return fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	if current > LatestSchema() {
+		return fmt.Errorf("the cache has schema version %d, newer than the %d of this build: run a newer finder, or `finder cache migrate -to %d` with it before going back", current, LatestSchema(), LatestSchema())
+	}
+	if to < current && !downgrade {
+		return fmt.Errorf("the cache has schema version %d, newer than %d", current, to)
+	}
+
+	for ; current < to; current++ {
+		m := migrations[current]
+		if err := step(db, func(tx *storeTx) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec("INSERT OR REPLACE INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)",
+				current+1, m.name, time.Now().Format(time.RFC3339))
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to migrate the cache to version %d (%s): %w", current+1, m.name, err)
+		}
+	}
+	for ; current > to; current-- {
+		m := migrations[current-1]
+		if m.down == nil {
+			return fmt.Errorf("version %d (%s) cannot be undone", current, m.name)
+		}
+		if err := step(db, func(tx *storeTx) error {
+			if err := m.down(tx); err != nil {
+				return err
+			}
+			_, err := tx.Exec("DELETE FROM schema_version WHERE version >= ?", current)
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to migrate the cache back to version %d: %w", current-1, err)
+		}
+	}
+	return nil
+}
+
+// MigrateCache brings the cache database to a schema version, also back to an older one so
+// that an older build can read it again. It returns the version the database had.
+func MigrateCache(to int) (int, error) {
+	if to < 1 || to > LatestSchema() {
+		return 0, fmt.Errorf("schema version %d does not exist (1 to %d)", to, LatestSchema())
+	}
+	db, err := open()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	from, err := schemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	return from, migrate(db, to, true)
+}
+
+func step(db *store, apply func(tx *storeTx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := apply(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execer runs statements on a database or inside a transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	sqlDialect() *dialect
+}
+
+func execAll(db execer, queries ...string) error {
+	for _, q := range queries {
+		if _, err := db.Exec(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds a column to a table created by an older version, if it is missing
+func addColumn(db execer, table, column, definition string) error {
+	has, err := hasColumn(db, table, column)
+	if err != nil || has {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// dropColumn removes a column, if it is there
+func dropColumn(db execer, table, column string) error {
+	has, err := hasColumn(db, table, column)
+	if err != nil || !has {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column))
+	return err
+}
+
+func hasColumn(db execer, table, column string) (bool, error) {
+	rows, err := db.Query(db.sqlDialect().columns, table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil && strings.EqualFold(name, column) {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
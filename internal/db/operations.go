@@ -0,0 +1,78 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// Operation is an entry of the append-only log of file operations and ignores, written through
+// the journal package so the CLI and the dashboard record the same things
+type Operation struct {
+	ID      int64  `json:"id"`
+	Time    string `json:"time"`
+	Source  string `json:"source,omitempty"` // "dashboard" for actions taken in the web UI
+	Run     string `json:"run,omitempty"`
+	Action  string `json:"action"`
+	Path    string `json:"path"`               // File before the operation, or a member of the ignored group
+	Dest    string `json:"dest,omitempty"`     // Location after a trash, move, link or rename
+	GroupID string `json:"group_id,omitempty"` // Stable ID of the group the file belonged to
+	Size    int64  `json:"size,omitempty"`
+	SHA256  string `json:"sha256,omitempty"` // Content hash of the file before the operation
+	Kept    string `json:"kept,omitempty"`   // Copy preserved in place of a removed one
+	Detail  string `json:"detail,omitempty"`
+}
+
+// OperationFilter narrows Operations; empty fields match everything
+type OperationFilter struct {
+	Path   string // Substring of the path before or after the operation
+	Action string
+	Limit  int // Newest entries first; 0 returns all of them
+}
+
+// AppendOperation adds an entry to the operations log
+func (c *Cache) AppendOperation(op Operation) error {
+	if op.Time == "" {
+		op.Time = time.Now().Format(time.RFC3339)
+	}
+	_, err := c.db.Exec("INSERT INTO operations (time, source, run, action, path, dest, group_id, size, sha256, kept, detail) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		op.Time, op.Source, op.Run, op.Action, op.Path, op.Dest, op.GroupID, op.Size, op.SHA256, op.Kept, op.Detail)
+	return err
+}
+
+// Operations returns the logged operations, newest first
+func (c *Cache) Operations(filter OperationFilter) []Operation {
+	var where []string
+	var args []interface{}
+	if filter.Path != "" {
+		where = append(where, "(instr(path, ?) > 0 OR instr(dest, ?) > 0)")
+		args = append(args, filter.Path, filter.Path)
+	}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, filter.Action)
+	}
+	query := "SELECT id, time, source, run, action, path, dest, group_id, size, sha256, kept, detail FROM operations"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var result []Operation
+	for rows.Next() {
+		var op Operation
+		if err := rows.Scan(&op.ID, &op.Time, &op.Source, &op.Run, &op.Action, &op.Path, &op.Dest, &op.GroupID, &op.Size, &op.SHA256, &op.Kept, &op.Detail); err == nil {
+			result = append(result, op)
+		}
+	}
+	return result
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// dumpVersion identifies the layout of exported caches
+const dumpVersion = 1
+
+// Dump is a portable copy of the expensive parts of the cache: content hashes, chosen previews,
+// visual hashes and ignored groups. Entries stay keyed by path and modification time, so they
+// are only reused for files that are unchanged on the importing machine.
+type Dump struct {
+	Version       int           `json:"version"`
+	ExportedAt    string        `json:"exported_at"`
+	FileHashes    []DumpHash    `json:"file_hashes"`
+	Previews      []DumpPreview `json:"previews"`
+	VisualHashes  []DumpVisual  `json:"visual_hashes"`
+	IgnoredGroups []string      `json:"ignored_groups"`
+}
+
+type DumpHash struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+	SHA256  string `json:"sha256"`
+}
+
+type DumpPreview struct {
+	Path         string `json:"path"`
+	InternalPath string `json:"internal_path"`
+	ModTime      string `json:"mod_time"`
+}
+
+type DumpVisual struct {
+	Path    string `json:"path"`
+	PHash   uint64 `json:"phash"`
+	ModTime string `json:"mod_time"`
+}
+
+// ImportStats counts the rows restored from a dump
+type ImportStats struct {
+	FileHashes    int `json:"file_hashes"`
+	Previews      int `json:"previews"`
+	VisualHashes  int `json:"visual_hashes"`
+	IgnoredGroups int `json:"ignored_groups"`
+}
+
+// PathMapping rewrites path prefixes while importing, for libraries mounted at another location
+// on the importing machine (e.g. /mnt/nas/models on the desktop, /volume1/models on the NAS).
+// Ignored groups are identified by a hash of their member paths and only carry over unmapped.
+type PathMapping struct {
+	From string
+	To   string
+}
+
+// ParsePathMapping reads a "from=to" prefix mapping
+func ParsePathMapping(spec string) (PathMapping, error) {
+	from, to, ok := strings.Cut(spec, "=")
+	if !ok || from == "" || to == "" {
+		return PathMapping{}, fmt.Errorf("invalid path mapping %q (expected from=to)", spec)
+	}
+	return PathMapping{From: from, To: to}, nil
+}
+
+func (m PathMapping) apply(path string) string {
+	if m.From != "" && strings.HasPrefix(path, m.From) {
+		return m.To + strings.TrimPrefix(path, m.From)
+	}
+	return path
+}
+
+// localModTime rewrites a cached modification time in this machine's time zone, so entries
+// exported on a machine with another zone still match. Hashes use RFC 3339, previews time.String.
+func localModTime(modTime string) string {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+		if t, err := time.Parse(layout, modTime); err == nil {
+			return t.Local().Format(layout)
+		}
+	}
+	return modTime
+}
+
+// Export writes the hash, preview, visual-hash and ignored-group tables as JSON
+func (c *Cache) Export(w io.Writer) error {
+	dump := Dump{
+		Version:       dumpVersion,
+		ExportedAt:    time.Now().Format(time.RFC3339),
+		FileHashes:    []DumpHash{},
+		Previews:      []DumpPreview{},
+		VisualHashes:  []DumpVisual{},
+		IgnoredGroups: []string{},
+	}
+
+	rows, err := c.db.Query("SELECT path, size, mod_time, sha256 FROM file_hashes ORDER BY path")
+	if err != nil {
+		return fmt.Errorf("failed to read file hashes: %w", err)
+	}
+	for rows.Next() {
+		var h DumpHash
+		if err := rows.Scan(&h.Path, &h.Size, &h.ModTime, &h.SHA256); err == nil {
+			dump.FileHashes = append(dump.FileHashes, h)
+		}
+	}
+	rows.Close()
+
+	rows, err = c.db.Query("SELECT path, internal_path, mod_time FROM preview_cache ORDER BY path")
+	if err != nil {
+		return fmt.Errorf("failed to read previews: %w", err)
+	}
+	for rows.Next() {
+		var p DumpPreview
+		if err := rows.Scan(&p.Path, &p.InternalPath, &p.ModTime); err == nil {
+			dump.Previews = append(dump.Previews, p)
+		}
+	}
+	rows.Close()
+
+	rows, err = c.db.Query("SELECT path, phash, mod_time FROM visual_cache ORDER BY path")
+	if err != nil {
+		return fmt.Errorf("failed to read visual hashes: %w", err)
+	}
+	for rows.Next() {
+		var v DumpVisual
+		var phash int64
+		if err := rows.Scan(&v.Path, &phash, &v.ModTime); err == nil {
+			v.PHash = uint64(phash)
+			dump.VisualHashes = append(dump.VisualHashes, v)
+		}
+	}
+	rows.Close()
+
+	rows, err = c.db.Query("SELECT hash FROM ignored_groups ORDER BY hash")
+	if err != nil {
+		return fmt.Errorf("failed to read ignored groups: %w", err)
+	}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err == nil {
+			dump.IgnoredGroups = append(dump.IgnoredGroups, hash)
+		}
+	}
+	rows.Close()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(dump)
+}
+
+// Import merges an exported cache into this one in a single transaction. Imported rows replace
+// local rows for the same path; everything else is kept.
+func (c *Cache) Import(r io.Reader, mappings []PathMapping) (ImportStats, error) {
+	var stats ImportStats
+	var dump Dump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return stats, fmt.Errorf("invalid cache export: %w", err)
+	}
+	if dump.Version != dumpVersion {
+		return stats, fmt.Errorf("unsupported cache export version %d", dump.Version)
+	}
+
+	mapPath := func(path string) string {
+		for _, m := range mappings {
+			if mapped := m.apply(path); mapped != path {
+				return mapped
+			}
+		}
+		return path
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback()
+
+	for _, h := range dump.FileHashes {
+		path := mapPath(h.Path)
+		if _, err := tx.Exec("INSERT OR REPLACE INTO file_hashes (path, size, mod_time, sha256, root) VALUES (?, ?, ?, ?, ?)", path, h.Size, localModTime(h.ModTime), h.SHA256, c.rootOf(path)); err != nil {
+			return ImportStats{}, err
+		}
+		stats.FileHashes++
+	}
+	for _, p := range dump.Previews {
+		path := mapPath(p.Path)
+		if _, err := tx.Exec("INSERT OR REPLACE INTO preview_cache (path, internal_path, mod_time, root) VALUES (?, ?, ?, ?)", path, p.InternalPath, localModTime(p.ModTime), c.rootOf(path)); err != nil {
+			return ImportStats{}, err
+		}
+		stats.Previews++
+	}
+	for _, v := range dump.VisualHashes {
+		path := mapPath(v.Path)
+		if _, err := tx.Exec("INSERT OR REPLACE INTO visual_cache (path, phash, mod_time, root) VALUES (?, ?, ?, ?)", path, int64(v.PHash), localModTime(v.ModTime), c.rootOf(path)); err != nil {
+			return ImportStats{}, err
+		}
+		stats.VisualHashes++
+	}
+	for _, hash := range dump.IgnoredGroups {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO ignored_groups (hash) VALUES (?)", hash); err != nil {
+			return ImportStats{}, err
+		}
+		stats.IgnoredGroups++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ImportStats{}, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,127 @@
+package estimate
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/scanner"
+	"fmt"
+	"time"
+)
+
+const (
+	PhaseStep3  = "step3"
+	PhaseVisual = "visual"
+)
+
+// Default rates used until a phase has been benchmarked on this machine
+const (
+	defaultStep3PerFile  = 500 * time.Microsecond
+	defaultVisualPerFile = 250 * time.Millisecond
+)
+
+// DefaultConfirmAbove is how long a projected run may take before confirmation is required
+const DefaultConfirmAbove = 30 * time.Minute
+
+// Estimate is the projected cost of analyzing a set of archives
+type Estimate struct {
+	Archives      int     `json:"archives"`
+	TotalBytes    int64   `json:"total_bytes"`
+	Step3Seconds  float64 `json:"step3_seconds"`
+	VisualPending int     `json:"visual_pending"` // Files without a cached visual hash
+	VisualSeconds float64 `json:"visual_seconds"`
+	Benchmarked   bool    `json:"benchmarked"` // False while default rates are used
+}
+
+// Step3 returns the projected duration of the similar-name analysis
+func (e Estimate) Step3() time.Duration {
+	return time.Duration(e.Step3Seconds * float64(time.Second))
+}
+
+// Visual returns the projected duration of the visual analysis
+func (e Estimate) Visual() time.Duration {
+	return time.Duration(e.VisualSeconds * float64(time.Second))
+}
+
+// New projects Step 3 and visual durations from the benchmarks recorded by previous runs
+func New(files []scanner.ArchiveFile, cache *db.Cache) Estimate {
+	e := Estimate{Archives: len(files)}
+	for _, f := range files {
+		e.TotalBytes += f.Size
+	}
+	e.VisualPending = PendingVisual(files, cache)
+
+	step3Rate, ok3 := rate(cache, PhaseStep3, defaultStep3PerFile)
+	visualRate, okVisual := rate(cache, PhaseVisual, defaultVisualPerFile)
+	e.Benchmarked = ok3 && okVisual
+
+	e.Step3Seconds = (step3Rate * time.Duration(len(files))).Seconds()
+	e.VisualSeconds = (visualRate * time.Duration(e.VisualPending)).Seconds()
+	return e
+}
+
+// PendingVisual counts the files the visual analysis still has to fingerprint
+func PendingVisual(files []scanner.ArchiveFile, cache *db.Cache) int {
+	if cache == nil {
+		return len(files)
+	}
+	pending := 0
+	for _, f := range files {
+		if _, ok := cache.GetVisualHash(f.Path, f.ModTime.Format(time.RFC3339)); !ok {
+			pending++
+		}
+	}
+	return pending
+}
+
+// Record stores how long a phase took for a number of files, so later estimates use real rates
+func Record(cache *db.Cache, phase string, files int, elapsed time.Duration) {
+	if cache == nil || files <= 0 {
+		return
+	}
+	cache.PutBenchmark(phase, int64(files), elapsed.Seconds())
+}
+
+func rate(cache *db.Cache, phase string, fallback time.Duration) (time.Duration, bool) {
+	if cache == nil {
+		return fallback, false
+	}
+	units, seconds, ok := cache.GetBenchmark(phase)
+	if !ok {
+		return fallback, false
+	}
+	return time.Duration(seconds / float64(units) * float64(time.Second)), true
+}
+
+// FormatDuration renders a projected duration for humans ("~3h 20m", "~45s")
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "<1s"
+	case d < time.Minute:
+		return fmt.Sprintf("~%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("~%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("~%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// PreviousScanBytes returns the archive bytes found by the last scan of dir (0 when unknown),
+// used as the progress total of the next scan
+func PreviousScanBytes(cache *db.Cache, dir string) int64 {
+	if cache == nil {
+		return 0
+	}
+	bytes, _, ok := cache.GetBenchmark("scan:" + dir)
+	if !ok {
+		return 0
+	}
+	return bytes
+}
+
+// RecordScan stores the size of a finished scan of dir
+func RecordScan(cache *db.Cache, dir string, bytes int64, elapsed time.Duration) {
+	if cache == nil || bytes <= 0 {
+		return
+	}
+	cache.PutBenchmark("scan:"+dir, bytes, elapsed.Seconds())
+}
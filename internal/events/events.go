@@ -0,0 +1,114 @@
+// Package events streams the progress of an analysis as newline-delimited JSON (NDJSON), one
+// object per event, so wrappers and jq can follow a run without waiting for the final report.
+package events
+
+import (
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/reporter"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Kinds of events, in the order a run emits them
+const (
+	EventStarted  = "started"  // Analysis of Dir begins
+	EventFile     = "file"     // A file was scanned
+	EventCorrupt  = "corrupt"  // An archive failed the integrity check
+	EventGroup    = "group"    // A duplicate group was found
+	EventAction   = "action"   // A file was deleted, trashed, moved, linked or renamed, or a group ignored
+	EventFinished = "finished" // The analysis is over; Summary holds the totals
+)
+
+// Event is one line of the stream. Only the fields of its kind are set.
+type Event struct {
+	Event   string                `json:"event"`
+	Time    string                `json:"time"`
+	Dir     string                `json:"dir,omitempty"`
+	File    *reporter.FileInfo    `json:"file,omitempty"`
+	Corrupt *reporter.CorruptFile `json:"corrupt,omitempty"`
+	Kind    string                `json:"kind,omitempty"` // Group events: reporter.KindSize, KindSimilar or KindVisual
+	Hash    string                `json:"hash,omitempty"` // Group events: member hash, as in the ignore list (reporter.CalculateGroupHash)
+	Group   any                   `json:"group,omitempty"`
+	Action  *journal.Entry        `json:"action,omitempty"`
+	Summary *Summary              `json:"summary,omitempty"`
+}
+
+// Summary closes the stream
+type Summary struct {
+	TotalFiles    int     `json:"total_files"`
+	SizeGroups    int     `json:"size_groups"`
+	SimilarGroups int     `json:"similar_groups"`
+	VisualGroups  int     `json:"visual_groups"`
+	Corrupt       int     `json:"corrupt"`
+	Duration      float64 `json:"duration_seconds"`
+}
+
+// Stream writes events to w. A nil stream drops them, so callers need no checks.
+type Stream struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewStream streams events to w
+func NewStream(w io.Writer) *Stream {
+	return &Stream{enc: json.NewEncoder(w)}
+}
+
+// Emit writes one event, stamping its time
+func (s *Stream) Emit(e Event) {
+	if s == nil {
+		return
+	}
+	if e.Time == "" {
+		e.Time = time.Now().Format(time.RFC3339)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.enc.Encode(e) // Encode ends every object with a newline
+}
+
+// Started announces the analysis of dir
+func (s *Stream) Started(dir string) {
+	s.Emit(Event{Event: EventStarted, Dir: dir})
+}
+
+// File reports a scanned file
+func (s *Stream) File(f reporter.FileInfo) {
+	s.Emit(Event{Event: EventFile, File: &f})
+}
+
+// Corrupt reports an archive that failed the integrity check
+func (s *Stream) Corrupt(f reporter.CorruptFile) {
+	s.Emit(Event{Event: EventCorrupt, Corrupt: &f})
+}
+
+// SizeGroup reports a group of identical size or contents
+func (s *Stream) SizeGroup(g reporter.SizeGroup) {
+	s.Emit(Event{Event: EventGroup, Kind: reporter.KindSize, Hash: reporter.CalculateGroupHash(g.Files), Group: g})
+}
+
+// SimilarityGroups reports similar-name (reporter.KindSimilar) or visual (KindVisual) groups
+func (s *Stream) SimilarityGroups(kind string, groups []reporter.SimilarityGroup) {
+	for _, g := range groups {
+		s.Emit(Event{Event: EventGroup, Kind: kind, Hash: reporter.CalculateGroupHash(g.Files), Group: g})
+	}
+}
+
+// Action reports a journaled file operation or ignore
+func (s *Stream) Action(e journal.Entry) {
+	s.Emit(Event{Event: EventAction, Action: &e})
+}
+
+// Finished closes the run with the totals of the report and its whole duration
+func (s *Stream) Finished(report reporter.Report, elapsed time.Duration) {
+	s.Emit(Event{Event: EventFinished, Summary: &Summary{
+		TotalFiles:    report.TotalFiles,
+		SizeGroups:    len(report.SizeGroups),
+		SimilarGroups: len(report.SimilarGroups),
+		VisualGroups:  len(report.VisualGroups),
+		Corrupt:       report.CorruptCount,
+		Duration:      elapsed.Seconds(),
+	}})
+}
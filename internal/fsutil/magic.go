@@ -0,0 +1,61 @@
+package fsutil
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LooksValid reports whether a file starts with the signature expected for its extension.
+// Formats without a reliable signature (STL, OBJ) only need to be non-empty.
+func LooksValid(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, 16)
+	n, _ := io.ReadFull(f, head)
+	return MatchesSignature(head[:n], filepath.Ext(path))
+}
+
+// MatchesSignature checks the first bytes of a file against the signature of ext
+func MatchesSignature(head []byte, ext string) bool {
+	if len(head) == 0 {
+		return false
+	}
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF})
+	case ".png":
+		return bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n"))
+	case ".gif":
+		return bytes.HasPrefix(head, []byte("GIF8"))
+	case ".webp":
+		return len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP"))
+	case ".bmp":
+		return bytes.HasPrefix(head, []byte("BM"))
+	case ".mp4", ".m4v":
+		return len(head) >= 8 && bytes.Equal(head[4:8], []byte("ftyp"))
+	case ".mov":
+		if len(head) < 8 {
+			return false
+		}
+		switch string(head[4:8]) { // Older QuickTime files may start with any top-level atom
+		case "ftyp", "moov", "mdat", "wide", "free", "skip", "pnot":
+			return true
+		}
+		return false
+	case ".webm", ".mkv":
+		return bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3})
+	case ".avi":
+		return len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("AVI "))
+	case ".pdf":
+		return bytes.HasPrefix(head, []byte("%PDF-"))
+	default:
+		return true
+	}
+}
@@ -0,0 +1,40 @@
+package fsutil
+
+import (
+	"fmt"
+	"log"
+)
+
+// keepMetadata copies what a plain copy of the contents loses, extended attributes on Unix
+// (download origin, tags, Finder labels) and alternate data streams on Windows
+// (Zone.Identifier), from src to dst. Destinations that cannot hold them (FAT, some network
+// shares) do not fail the move: what could not be copied is logged.
+func keepMetadata(src, dst string) {
+	if err := copyMetadata(src, dst); err != nil {
+		log.Printf("⚠️  Metadata of %s not kept: %v", src, err)
+	}
+}
+
+// metadataError lists the attributes or streams that could not be copied
+type metadataError struct {
+	names []string
+	err   error
+}
+
+func (e *metadataError) Error() string {
+	return fmt.Sprintf("%d attributes or streams not copied (first: %v)", len(e.names), e.err)
+}
+
+func (e *metadataError) add(name string, err error) {
+	if e.err == nil {
+		e.err = fmt.Errorf("%s: %w", name, err)
+	}
+	e.names = append(e.names, name)
+}
+
+func (e *metadataError) orNil() error {
+	if len(e.names) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd || netbsd || windows)
+
+package fsutil
+
+// copyMetadata does nothing where extended attributes are not supported
+func copyMetadata(src, dst string) error {
+	return nil
+}
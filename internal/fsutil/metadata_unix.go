@@ -0,0 +1,76 @@
+//go:build linux || darwin || freebsd || netbsd
+
+package fsutil
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// copyMetadata copies the extended attributes of src to dst
+func copyMetadata(src, dst string) error {
+	names, err := listXattrs(src)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil // The source filesystem has none
+		}
+		return err
+	}
+	var failed metadataError
+	for _, name := range names {
+		value, err := getXattr(src, name)
+		if err == nil {
+			err = unix.Setxattr(dst, name, value, 0)
+		}
+		if err != nil {
+			// security.* and system.* attributes often need privileges: they are the
+			// system's to set, not part of what the user gave the file
+			if errors.Is(err, unix.EPERM) && !strings.HasPrefix(name, "user.") && strings.Contains(name, ".") {
+				continue
+			}
+			failed.add(name, err)
+		}
+	}
+	return failed.orNil()
+}
+
+// listXattrs returns the names of the extended attributes of path
+func listXattrs(path string) ([]string, error) {
+	buf, err := readGrowing(func(b []byte) (int, error) { return unix.Listxattr(path, b) })
+	if err != nil || len(buf) == 0 {
+		return nil, err
+	}
+	var names []string
+	for _, name := range strings.Split(strings.TrimRight(string(buf), "\x00"), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	return readGrowing(func(b []byte) (int, error) { return unix.Getxattr(path, name, b) })
+}
+
+// readGrowing calls read with a nil buffer to learn the size, then with a buffer of that size,
+// retrying if the value grew in between
+func readGrowing(read func([]byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := read(nil)
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := read(buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
@@ -0,0 +1,91 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32             = windows.NewLazySystemDLL("kernel32.dll")
+	procFindFirstStreamW = kernel32.NewProc("FindFirstStreamW")
+	procFindNextStreamW  = kernel32.NewProc("FindNextStreamW")
+)
+
+// findStreamData is WIN32_FIND_STREAM_DATA
+type findStreamData struct {
+	size int64
+	name [windows.MAX_PATH + 36]uint16
+}
+
+// copyMetadata copies the alternate data streams of src (the Zone.Identifier of downloads,
+// tags written by file managers) to dst
+func copyMetadata(src, dst string) error {
+	streams, err := listStreams(src)
+	if err != nil {
+		return err
+	}
+	var failed metadataError
+	for _, name := range streams {
+		if err := copyStream(src+name, dst+name); err != nil {
+			failed.add(name, err)
+		}
+	}
+	return failed.orNil()
+}
+
+// listStreams returns the alternate data streams of path as ":name", leaving out the file's
+// own contents ("::$DATA")
+func listStreams(path string) ([]string, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	var data findStreamData
+	h, _, err := procFindFirstStreamW.Call(uintptr(unsafe.Pointer(p)), 0, uintptr(unsafe.Pointer(&data)), 0)
+	if windows.Handle(h) == windows.InvalidHandle {
+		if errors.Is(err, windows.ERROR_HANDLE_EOF) || errors.Is(err, windows.ERROR_INVALID_PARAMETER) {
+			return nil, nil // No streams, or a filesystem without them
+		}
+		return nil, err
+	}
+	defer windows.FindClose(windows.Handle(h))
+
+	var names []string
+	for {
+		name := windows.UTF16ToString(data.name[:])
+		if name != "::$DATA" {
+			names = append(names, strings.TrimSuffix(name, ":$DATA"))
+		}
+		r, _, err := procFindNextStreamW.Call(h, uintptr(unsafe.Pointer(&data)))
+		if r == 0 {
+			if errors.Is(err, windows.ERROR_HANDLE_EOF) {
+				return names, nil
+			}
+			return names, err
+		}
+	}
+}
+
+func copyStream(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
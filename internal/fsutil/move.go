@@ -0,0 +1,129 @@
+package fsutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+)
+
+// errNotSameDevice is ERROR_NOT_SAME_DEVICE, what Windows returns instead of EXDEV
+const errNotSameDevice = syscall.Errno(17)
+
+// copyChunk is how much is copied between two progress reports
+const copyChunk = 4 << 20
+
+// IsCrossDevice reports whether err is a rename that failed because source and destination
+// are on different filesystems
+func IsCrossDevice(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.EXDEV || (runtime.GOOS == "windows" && errno == errNotSameDevice)
+}
+
+// MoveFile renames src to dst. When they are on different filesystems the file is copied
+// instead, read back and compared with the original, and only then is src removed; a failed
+// copy, or one refused for lack of space (see CheckSpace), leaves src untouched and no partial
+// dst behind. The copy keeps the permissions, mod time and extended attributes or alternate
+// data streams of src. onProgress (optional) receives the bytes copied so far.
+func MoveFile(src, dst string, onProgress func(done, total int64)) error {
+	err := os.Rename(src, dst)
+	if err == nil || !IsCrossDevice(err) {
+		return err
+	}
+	return copyVerifyRemove(src, dst, onProgress)
+}
+
+func copyVerifyRemove(src, dst string, onProgress func(done, total int64)) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	dir, base := filepath.Split(dst)
+	if dir == "" {
+		dir = "."
+	}
+	// A full destination is reported before a byte is written, not as a truncated copy
+	if err := CheckSpace(dir, info.Size()); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+tempMarker+"*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	h := sha256.New()
+	var done int64
+	for {
+		n, copyErr := io.CopyN(io.MultiWriter(tmp, h), in, copyChunk)
+		done += n
+		if onProgress != nil && n > 0 {
+			onProgress(done, info.Size())
+		}
+		if copyErr == io.EOF {
+			break
+		}
+		if copyErr != nil {
+			return fmt.Errorf("copy to %s failed: %w", dir, copyErr)
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	// Read the copy back from disk rather than trusting the writes
+	if err = verifyCopy(tmp.Name(), done, h.Sum(nil)); err != nil {
+		return fmt.Errorf("could not copy %s to %s: %w", filepath.Base(src), dir, err)
+	}
+	os.Chmod(tmp.Name(), info.Mode().Perm())
+	keepMetadata(src, tmp.Name())
+	os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime())
+	if err = os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	in.Close()
+	if err := os.Remove(src); err != nil {
+		return fmt.Errorf("copied to %s but could not remove the original: %w", dst, err)
+	}
+	return nil
+}
+
+// verifyCopy checks that the file at path has size bytes hashing to sum
+func verifyCopy(path string, size int64, sum []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if n != size || !bytes.Equal(h.Sum(nil), sum) {
+		return errors.New("the copy does not match the original")
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// spaceMargin is left free on a destination, for the filesystem and whatever else writes there
+const spaceMargin = 16 << 20
+
+// errSpaceUnknown is returned where the free space of a filesystem cannot be read
+var errSpaceUnknown = errors.New("free space not available on this platform")
+
+// NoSpaceError is a copy refused before writing anything because its destination lacks room
+type NoSpaceError struct {
+	Dir  string `json:"dir"`  // Destination folder
+	Need int64  `json:"need"` // Bytes to be written there
+	Free int64  `json:"free"` // Bytes available
+}
+
+func (e *NoSpaceError) Error() string {
+	return fmt.Sprintf("not enough free space in %s: %s needed, %s free", e.Dir, formatBytes(e.Need), formatBytes(e.Free))
+}
+
+// CheckSpace returns a *NoSpaceError when the filesystem of dir (or of its nearest existing
+// parent, for folders not created yet) has less than need bytes free, plus a margin.
+// Filesystems whose free space cannot be read pass.
+func CheckSpace(dir string, need int64) error {
+	existing := nearestExisting(dir)
+	free, err := freeSpace(existing)
+	if err != nil {
+		return nil
+	}
+	if free < need+spaceMargin {
+		return &NoSpaceError{Dir: filepath.Clean(dir), Need: need, Free: free}
+	}
+	return nil
+}
+
+// Transfer is a file about to be moved into a folder
+type Transfer struct {
+	Path string
+	Dir  string
+	Size int64
+}
+
+// Preflight adds up, per destination filesystem, the bytes of the transfers that will be copied
+// because their source is on another filesystem (renames need no room), and returns a
+// *NoSpaceError for every destination that cannot take them all. Run before a bulk move, it
+// refuses the batch up front instead of failing partway through.
+func Preflight(transfers []Transfer) []*NoSpaceError {
+	type dest struct {
+		dir  string
+		need int64
+	}
+	byDevice := make(map[string]*dest)
+	var order []string
+	for _, t := range transfers {
+		existing := nearestExisting(t.Dir)
+		dstDev, ok := deviceOf(existing)
+		if !ok {
+			continue
+		}
+		if srcDev, ok := deviceOf(t.Path); ok && srcDev == dstDev {
+			continue
+		}
+		d, seen := byDevice[dstDev]
+		if !seen {
+			d = &dest{dir: t.Dir}
+			byDevice[dstDev] = d
+			order = append(order, dstDev)
+		}
+		d.need += t.Size
+	}
+
+	var short []*NoSpaceError
+	for _, dev := range order {
+		d := byDevice[dev]
+		if err := CheckSpace(d.dir, d.need); err != nil {
+			var noSpace *NoSpaceError
+			if errors.As(err, &noSpace) {
+				short = append(short, noSpace)
+			}
+		}
+	}
+	sort.Slice(short, func(i, j int) bool { return short[i].Dir < short[j].Dir })
+	return short
+}
+
+// nearestExisting returns dir, or its closest parent that exists
+func nearestExisting(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
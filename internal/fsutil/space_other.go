@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || windows)
+
+package fsutil
+
+func freeSpace(path string) (int64, error) {
+	return 0, errSpaceUnknown
+}
+
+func deviceOf(path string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,244 @@
+package hashing
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// FileSHA256 returns the hex encoded SHA-256 of a file's contents
+func FileSHA256(path string) (string, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// FileHash returns the content hash of a file, served from the cache while its size and mod time are unchanged
+func FileHash(cache *db.Cache, path string) (string, error) {
+	info, err := vfs.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	modTime := info.ModTime.Format(time.RFC3339)
+
+	if cache != nil {
+		if hash, ok := cache.GetFileHash(path, info.Size, modTime); ok {
+			return hash, nil
+		}
+	}
+
+	hash, err := FileSHA256(path)
+	if err != nil {
+		return "", err
+	}
+	if cache != nil {
+		cache.PutFileHash(path, info.Size, modTime, hash)
+	}
+	return hash, nil
+}
+
+// CachedFileHash returns the content hash of a file when the cache holds it for the file's
+// current size and mod time, and "" otherwise. The file is not read.
+func CachedFileHash(cache *db.Cache, path string) string {
+	if cache == nil {
+		return ""
+	}
+	info, err := vfs.Stat(path)
+	if err != nil {
+		return ""
+	}
+	hash, _ := cache.GetFileHash(path, info.Size, info.ModTime.Format(time.RFC3339))
+	return hash
+}
+
+// GroupContentHash returns the shared content hash when every file of the group is byte-identical.
+// It returns false as soon as sizes or hashes differ, or a file cannot be read.
+func GroupContentHash(cache *db.Cache, paths []string) (string, bool) {
+	if len(paths) == 0 {
+		return "", false
+	}
+
+	// Cheap size check before reading any content
+	var size int64 = -1
+	for _, p := range paths {
+		info, err := vfs.Stat(p)
+		if err != nil {
+			return "", false
+		}
+		if size >= 0 && info.Size != size {
+			return "", false
+		}
+		size = info.Size
+	}
+
+	// Sampled hashes rule out most different files without reading them whole
+	var sample string
+	for _, p := range paths {
+		hash, err := SampleHash(cache, p)
+		if err != nil || (sample != "" && hash != sample) {
+			return "", false
+		}
+		sample = hash
+	}
+
+	var shared string
+	for _, p := range paths {
+		hash, err := FileHash(cache, p)
+		if err != nil {
+			return "", false
+		}
+		if shared != "" && hash != shared {
+			return "", false
+		}
+		shared = hash
+	}
+	return shared, true
+}
+
+// IsSuppressed reports whether a group consists solely of one content hash the user has suppressed
+func IsSuppressed(cache *db.Cache, paths []string) bool {
+	if cache == nil || len(paths) < 2 || !cache.HasSuppressedHashes() {
+		return false
+	}
+	hash, ok := GroupContentHash(cache, paths)
+	return ok && cache.IsHashSuppressed(hash)
+}
+
+// PayloadHash returns the SHA-256 and size of the decompressed payload of a compressed archive
+// (".tar.gz", ".zst"...), served from the cache while the archive is unchanged
+func PayloadHash(cache *db.Cache, path string) (string, int64, error) {
+	info, err := vfs.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	modTime := info.ModTime.Format(time.RFC3339)
+
+	if cache != nil {
+		if hash, size, ok := cache.GetPayloadHash(path, info.Size, modTime); ok {
+			return hash, size, nil
+		}
+	}
+
+	payload, err := archive.OpenPayload(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer payload.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, payload)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	hash := fmt.Sprintf("%x", h.Sum(nil))
+	if cache != nil {
+		cache.PutPayloadHash(path, info.Size, modTime, hash, size)
+	}
+	return hash, size, nil
+}
+
+// PayloadGroup is a set of compressed archives holding the same decompressed payload
+type PayloadGroup struct {
+	Hash        string
+	PayloadSize int64
+	Files       []scanner.ArchiveFile
+}
+
+// GroupByPayload groups compressed archives by their decompressed payload, so that the same
+// tarball compressed with gzip and zstd is found although the archives differ in size. Groups
+// whose members all have the same size are left out: identical copies are already size groups.
+// onProgress receives the number of archives hashed so far.
+func GroupByPayload(cache *db.Cache, files []scanner.ArchiveFile, onProgress func(done int)) []PayloadGroup {
+	var candidates []scanner.ArchiveFile
+	for _, f := range files {
+		if len(f.Volumes) == 0 && archive.IsCompressed(f.Path) {
+			candidates = append(candidates, f)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	type result struct {
+		file scanner.ArchiveFile
+		hash string
+		size int64
+	}
+	results := make([]result, len(candidates))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	done := 0
+	for w := 0; w < archive.GetLimits().Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f := candidates[i]
+				hash, size, err := PayloadHash(cache, f.Path)
+				if err != nil {
+					log.Printf("⚠️  Could not decompress %s: %v", f.Name, err)
+				}
+
+				mu.Lock()
+				results[i] = result{file: f, hash: hash, size: size}
+				done++
+				if onProgress != nil {
+					onProgress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range candidates {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	byHash := make(map[string]*PayloadGroup)
+	var order []string
+	for _, r := range results {
+		if r.hash == "" {
+			continue
+		}
+		g, ok := byHash[r.hash]
+		if !ok {
+			g = &PayloadGroup{Hash: r.hash, PayloadSize: r.size}
+			byHash[r.hash] = g
+			order = append(order, r.hash)
+		}
+		g.Files = append(g.Files, r.file)
+	}
+
+	var groups []PayloadGroup
+	for _, hash := range order {
+		g := byHash[hash]
+		if len(g.Files) < 2 {
+			continue
+		}
+		sameSize := true
+		for _, f := range g.Files[1:] {
+			sameSize = sameSize && f.Size == g.Files[0].Size
+		}
+		if !sameSize {
+			groups = append(groups, *g)
+		}
+	}
+	return groups
+}
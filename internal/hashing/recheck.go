@@ -0,0 +1,70 @@
+package hashing
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/vfs"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// What is checked again right before a file is removed (verify_before_delete); "" checks nothing
+const (
+	RecheckStat = "stat" // Size and modification time must be those of the scan
+	RecheckHash = "hash" // The file is also read whole and must still hash as when it was compared
+)
+
+// ErrModified reports a file that changed between the scan and its removal
+var ErrModified = errors.New("changed since the scan")
+
+// IsValidRecheck reports whether mode is a recheck mode ("" means off)
+func IsValidRecheck(mode string) bool {
+	return mode == "" || mode == RecheckStat || mode == RecheckHash
+}
+
+// Recheck verifies, right before an archive is removed, that it is still the one scanned:
+// paths are its parts, size and modTime those of the scan (the sum of the parts and the most
+// recent part for a multi-volume set). In RecheckHash mode every part is read again and
+// compared with the hash the cache holds for it; parts never hashed, as in a name-only scan,
+// only get the size and time check. The error wraps ErrModified and says what changed.
+func Recheck(cache *db.Cache, mode string, paths []string, size int64, modTime time.Time) error {
+	if mode == "" {
+		return nil
+	}
+	var total int64
+	var latest time.Time
+	for _, p := range paths {
+		info, err := vfs.Stat(p)
+		if err != nil {
+			return fmt.Errorf("%s is gone: %w", p, err)
+		}
+		total += info.Size
+		if info.ModTime.After(latest) {
+			latest = info.ModTime
+		}
+	}
+	if total != size {
+		return fmt.Errorf("%s %w: %d bytes, %d when scanned", paths[0], ErrModified, total, size)
+	}
+	// Reports keep whole seconds
+	if !modTime.IsZero() && !latest.Truncate(time.Second).Equal(modTime.Truncate(time.Second)) {
+		return fmt.Errorf("%s %w: modified %s, scanned at %s", paths[0], ErrModified, latest.Format(time.RFC3339), modTime.Format(time.RFC3339))
+	}
+	if mode != RecheckHash {
+		return nil
+	}
+	for _, p := range paths {
+		cached := CachedFileHash(cache, p)
+		if cached == "" {
+			continue
+		}
+		hash, err := FileSHA256(p)
+		if err != nil {
+			return err
+		}
+		if hash != cached {
+			return fmt.Errorf("%s %w: contents differ", p, ErrModified)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package hashing
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// sampleSpan is how much of the start, the middle and the end of a file the sampled hash reads
+const sampleSpan = 1 << 20
+
+// FileSample hashes the size and three 1 MB samples of a file: its head, its middle and its
+// tail. Files whose samples differ cannot be identical; equal samples still need the full
+// hash. Files up to three samples long are read whole.
+func FileSample(path string) (string, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	size := f.Size()
+	h := sha256.New()
+	binary.Write(h, binary.LittleEndian, size)
+	spans := [][2]int64{{0, size}}
+	if size > 3*sampleSpan {
+		spans = [][2]int64{{0, sampleSpan}, {size/2 - sampleSpan/2, sampleSpan}, {size - sampleSpan, sampleSpan}}
+	}
+	for _, s := range spans {
+		if _, err := io.Copy(h, io.NewSectionReader(f, s[0], s[1])); err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// SampleHash returns the sampled hash of a file, served from the cache while its size and mod time are unchanged
+func SampleHash(cache *db.Cache, path string) (string, error) {
+	info, err := vfs.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	modTime := info.ModTime.Format(time.RFC3339)
+
+	if cache != nil {
+		if hash, ok := cache.GetSampleHash(path, info.Size, modTime); ok {
+			return hash, nil
+		}
+	}
+
+	hash, err := FileSample(path)
+	if err != nil {
+		return "", err
+	}
+	if cache != nil {
+		cache.PutSampleHash(path, info.Size, modTime, hash)
+	}
+	return hash, nil
+}
+
+// ContentGroup is a set of files confirmed byte-identical
+type ContentGroup struct {
+	Hash  string
+	Files []scanner.ArchiveFile
+}
+
+// VerifyContents splits a group of same-size files into the sets whose contents are identical,
+// whatever their names. Candidates are narrowed by SampleHash first, so the full hash is only
+// computed for files whose samples match; both tiers are cached. Files without a copy are
+// dropped. A multi-volume set is compared part by part.
+func VerifyContents(cache *db.Cache, files []scanner.ArchiveFile) []ContentGroup {
+	if len(files) < 2 {
+		return nil
+	}
+
+	var groups []ContentGroup
+	for _, candidates := range splitBy(files, func(f scanner.ArchiveFile) (string, error) {
+		return combinedHash(f, func(p string) (string, error) { return SampleHash(cache, p) })
+	}) {
+		full := splitBy(candidates, func(f scanner.ArchiveFile) (string, error) {
+			return combinedHash(f, func(p string) (string, error) { return FileHash(cache, p) })
+		})
+		for hash, same := range full {
+			groups = append(groups, ContentGroup{Hash: hash, Files: same})
+		}
+	}
+	return groups
+}
+
+// splitBy buckets files by key, keeping the buckets with at least two files
+func splitBy(files []scanner.ArchiveFile, key func(scanner.ArchiveFile) (string, error)) map[string][]scanner.ArchiveFile {
+	buckets := make(map[string][]scanner.ArchiveFile)
+	for _, f := range files {
+		k, err := key(f)
+		if err != nil {
+			log.Printf("⚠️  Could not read %s: %v", f.Name, err)
+			continue
+		}
+		buckets[k] = append(buckets[k], f)
+	}
+	for k, b := range buckets {
+		if len(b) < 2 {
+			delete(buckets, k)
+		}
+	}
+	return buckets
+}
+
+// combinedHash hashes every part of an entry; a single file keeps its own hash
+func combinedHash(f scanner.ArchiveFile, hash func(path string) (string, error)) (string, error) {
+	paths := f.AllPaths()
+	hashes := make([]string, len(paths))
+	for i, p := range paths {
+		h, err := hash(p)
+		if err != nil {
+			return "", err
+		}
+		hashes[i] = h
+	}
+	if len(hashes) == 1 {
+		return hashes[0], nil
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(hashes, "\n")))), nil
+}
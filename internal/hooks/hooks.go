@@ -0,0 +1,187 @@
+// Package hooks runs user commands when archives are about to be removed, were removed, or a
+// scan finished, handing them the event as JSON on stdin so catalogs and scripts can follow the
+// library without code changes
+package hooks
+
+import (
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/reporter"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Events a hook can run on
+const (
+	EventPreDelete  = "pre_delete"  // Before an archive is removed; a failing hook keeps it
+	EventPostDelete = "post_delete" // After an archive was deleted, trashed or quarantined
+	EventPostScan   = "post_scan"   // After a scan finished, with its report
+)
+
+// Events lists the hook events
+var Events = []string{EventPreDelete, EventPostDelete, EventPostScan}
+
+// defaultTimeout bounds a hook that sets no timeout
+const defaultTimeout = 30 * time.Second
+
+// Hook is a command run on an event, e.g. {"event": "post_delete", "command": ["catalog", "forget"]}
+type Hook struct {
+	Event   string   `json:"event"`
+	Command []string `json:"command"`                   // Program and arguments, run without a shell
+	Timeout int      `json:"timeout_seconds,omitempty"` // 0 allows 30 seconds
+}
+
+// Event is what a hook reads on stdin. Removals describe one archive (every volume of a split
+// set in Paths); post_scan carries the report.
+type Event struct {
+	Event  string `json:"event"`
+	Time   string `json:"time"`
+	Source string `json:"source,omitempty"` // "dashboard", or empty for the CLI
+	Run    string `json:"run,omitempty"`    // Cleanup run, as in the journal
+
+	Path    string   `json:"path,omitempty"`
+	Paths   []string `json:"paths,omitempty"`
+	Size    int64    `json:"size,omitempty"`
+	Kept    string   `json:"kept,omitempty"`     // Copy kept in its place
+	GroupID string   `json:"group_id,omitempty"` // Stable ID of the group
+	Action  string   `json:"action,omitempty"`   // post_delete: delete, trash or quarantine
+	Dest    []string `json:"dest,omitempty"`     // post_delete: where the trash or quarantine holds the parts
+
+	Directory string           `json:"directory,omitempty"` // post_scan
+	Report    *reporter.Report `json:"report,omitempty"`    // post_scan
+}
+
+// PostDelete returns the post_delete event following the pre_delete ev, once the parts were
+// removed as the journal entries say: the action of the first part, and where the trash or
+// quarantine holds them
+func (ev Event) PostDelete(entries []*journal.Entry) Event {
+	ev.Event, ev.Action, ev.Dest = EventPostDelete, journal.ActionDelete, nil
+	for i, e := range entries {
+		if i == 0 {
+			ev.Action = e.Action
+		}
+		if e.Dest != "" {
+			ev.Dest = append(ev.Dest, e.Dest)
+		}
+	}
+	return ev
+}
+
+var (
+	mu    sync.RWMutex
+	hooks []Hook
+)
+
+// Set replaces the configured hooks
+func Set(list []Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = list
+}
+
+// Validate checks the hooks of the settings
+func Validate(list []Hook) error {
+	for i, h := range list {
+		known := false
+		for _, e := range Events {
+			known = known || h.Event == e
+		}
+		if !known {
+			return fmt.Errorf("hook %d: event must be one of %s", i+1, strings.Join(Events, ", "))
+		}
+		if len(h.Command) == 0 || h.Command[0] == "" {
+			return fmt.Errorf("hook %d: command is required", i+1)
+		}
+		if h.Timeout < 0 {
+			return fmt.Errorf("hook %d: timeout_seconds cannot be negative", i+1)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any hook runs on event, so callers can skip building its payload
+func Enabled(event string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, h := range hooks {
+		if h.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the hooks of ev.Event one after the other, each with ev as JSON on stdin and
+// ADF_EVENT set. Failures (a non-zero exit, a timeout) are logged and the first one returned:
+// for pre_delete it vetoes the removal, and the remaining hooks are not run.
+func Run(ev Event) error {
+	mu.RLock()
+	var list []Hook
+	for _, h := range hooks {
+		if h.Event == ev.Event {
+			list = append(list, h)
+		}
+	}
+	mu.RUnlock()
+	if len(list) == 0 {
+		return nil
+	}
+
+	if ev.Time == "" {
+		ev.Time = time.Now().Format(time.RFC3339)
+	}
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, h := range list {
+		if err := h.run(ev.Event, payload); err != nil {
+			log.Printf("⚠️  %s hook failed: %v", ev.Event, err)
+			if first == nil {
+				first = err
+			}
+			if ev.Event == EventPreDelete {
+				break
+			}
+		}
+	}
+	return first
+}
+
+func (h Hook) run(event string, payload []byte) error {
+	timeout := defaultTimeout
+	if h.Timeout > 0 {
+		timeout = time.Duration(h.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "ADF_EVENT="+event)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = time.Second // Children left holding the output do not outlive the timeout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s timed out after %s", h.Command[0], timeout)
+		}
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+				msg = strings.TrimSpace(msg[i+1:])
+			}
+			return fmt.Errorf("%s: %w: %s", h.Command[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", h.Command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package i18n
+
+// spanish holds the Spanish messages. Emojis, spacing and format verbs follow the English
+// message, so the output keeps its alignment.
+var spanish = map[string]string{
+	// Scan and analysis
+	"📂 Scanning directory: %s":                                       "📂 Escaneando directorio: %s",
+	"📂 Loading saved configuration: %s":                              "📂 Cargando la configuración guardada: %s",
+	"⚠️ Saved directory no longer exists: %s. Starting web setup...": "⚠️ El directorio guardado ya no existe: %s. Iniciando la configuración web...",
+	"🌐 No configuration found. Starting the web setup wizard...":     "🌐 No hay configuración. Iniciando el asistente de configuración web...",
+	"❌ Directory does not exist: %s":                                 "❌ El directorio no existe: %s",
+	"❌ Failed to scan directory: %v":                                 "❌ No se pudo escanear el directorio: %v",
+	"🎯 Similarity threshold: %d%%":                                   "🎯 Umbral de similitud: %d%%",
+	"🗑️  Cleanup Mode: %s (Auto: %v)":                                "🗑️  Modo de limpieza: %s (Auto: %v)",
+	"🐛 DEBUG MODE: Enabled (Detailed Tracing)":                       "🐛 MODO DEPURACIÓN: Activado (trazas detalladas)",
+	"🔧 Mode: %s":                                                                   "🔧 Modo: %s",
+	"🗣️  Phonetic matching: %s":                                                    "🗣️  Coincidencia fonética: %s",
+	"📦 Step 1: Scanning for archive files...":                                      "📦 Paso 1: Buscando archivos comprimidos...",
+	"✅ Found %d archive files":                                                     "✅ Encontrados %d archivos comprimidos",
+	"✅ Found %d files":                                                             "✅ Encontrados %d archivos",
+	"✅ Found %d archives in the source and %d in the library":                      "✅ Encontrados %d archivos en el origen y %d en la biblioteca",
+	"🔄 Step 2: Analyzing identical sizes...":                                       "🔄 Paso 2: Analizando tamaños idénticos...",
+	"❌ The primary and backup folders must not contain each other":                 "❌ Las carpetas principal y de copia no deben contenerse entre sí",
+	"❌ Use either -trash or -quarantine":                                           "❌ Usa -trash o -quarantine, no ambos",
+	"✅ Found %d archives in the backup and %d in the primary folder":               "✅ Encontrados %d archivos en la copia y %d en la carpeta principal",
+	"  🛡️  %s (protected, kept)\n":                                                 "  🛡️  %s (protegido, se conserva)\n",
+	"📊 %d backup archives are exact copies of primary ones (%s to reclaim)\n\n":    "📊 %d archivos de la copia son copias exactas de los principales (%s a recuperar)\n\n",
+	"     Prune these %d backup archives? (y/N): ":                                 "     ¿Podar estos %d archivos de la copia? (s/N): ",
+	"     ⏭️  Backup left untouched.":                                              "     ⏭️  Copia sin tocar.",
+	"     ⚠️  No longer identical to %s, kept\n":                                   "     ⚠️  Ya no es idéntico a %s, se conserva\n",
+	"📊 Pruned %d backup archives (%s), %d skipped\n":                               "📊 Podados %d archivos de la copia (%s), %d omitidos\n",
+	"❌ Cannot prune: %v":                                                           "❌ No se puede podar: %v",
+	"🔍 Comparing sizes and content hashes...":                                      "🔍 Comparando tamaños y hashes de contenido...",
+	"🔐 Hashing archives of the same size...":                                       "🔐 Calculando hashes de archivos del mismo tamaño...",
+	"🔍 Scanning %s...":                                                             "🔍 Escaneando %s...",
+	"🔍 Comparing names (threshold %d%%)...":                                        "🔍 Comparando nombres (umbral %d%%)...",
+	"🖼️  Comparing preview images...":                                              "🖼️  Comparando imágenes de vista previa...",
+	"🧬 Comparing %d files by %s...":                                                "🧬 Comparando %d archivos por %s...",
+	"🩺 Verifying archive integrity...":                                             "🩺 Verificando la integridad de los archivos...",
+	"✅ All archives passed the integrity check":                                    "✅ Todos los archivos superaron la comprobación de integridad",
+	"📊 Found %d groups with %d total files\n":                                      "📊 Encontrados %d grupos con %d archivos en total\n",
+	"📊 Found %d groups with the same %s\n":                                         "📊 Encontrados %d grupos con el mismo %s\n",
+	"📊 Found %d groups of compressed archives with the same payload\n":             "📊 Encontrados %d grupos de archivos comprimidos con el mismo contenido\n",
+	"✅ No files with identical size and different names found":                     "✅ No se encontraron archivos con el mismo tamaño y distinto nombre",
+	"📦 Group %d (Size: %s)\n":                                                      "📦 Grupo %d (Tamaño: %s)\n",
+	"🧬 Same %s (%s)\n":                                                             "🧬 Mismo %s (%s)\n",
+	"🗜️  Same decompressed contents (%s)\n":                                        "🗜️  Mismo contenido descomprimido (%s)\n",
+	"  📄 %s (Mod: %v)\n":                                                           "  📄 %s (Mod: %v)\n",
+	"... (Use --verbose to see all groups)":                                        "... (Usa --verbose para ver todos los grupos)",
+	"  🔕 Skipping suppressed content (%d copies of %s)\n":                          "  🔕 Omitiendo contenido suprimido (%d copias de %s)\n",
+	"💔 Found %d unreadable/corrupt archives (excluded from duplicate analysis):\n": "💔 Encontrados %d archivos ilegibles o corruptos (excluidos del análisis de duplicados):\n",
+	"🧨 %d archives look like zip bombs; their oversized entries were skipped:":     "🧨 %d archivos parecen bombas zip; se omitieron sus entradas desmesuradas:",
+	"🖼️  %d loose images match the preview of an archive in their folder":          "🖼️  %d imágenes sueltas coinciden con la vista previa de un archivo de su carpeta",
+	"📈 Total processing time: %.2fs":                                               "📈 Tiempo total de procesamiento: %.2fs",
+	"\r🔍 Sweeping: [%-20s] %.1f%%":                                                 "\r🔍 Barriendo: [%-20s] %.1f%%",
+	"\r📑 Reading contents: %d/%d archives   ":                                      "\r📑 Leyendo contenidos: %d/%d archivos   ",
+
+	"📂 Scanning: %d archives (%s)":                              "📂 Escaneando: %d archivos (%s)",
+	"  • Archives: %d files\n":                                  "  • Archivos comprimidos: %d archivos\n",
+	"  • Multi-volume sets: %d (counted as one archive each)\n": "  • Multivolúmenes: %d (cada uno cuenta como un archivo)\n",
+	"  • Comics & e-books: %d files\n":                          "  • Cómics y libros electrónicos: %d archivos\n",
+	"  • 3D Models: %d files\n":                                 "  • Modelos 3D: %d archivos\n",
+	"  • Videos: %d files\n":                                    "  • Vídeos: %d archivos\n",
+	"  • Images: %d files\n":                                    "  • Imágenes: %d archivos\n",
+	"  • Other files: %d files\n":                               "  • Otros archivos: %d archivos\n",
+	"  • Total size: %s\n":                                      "  • Tamaño total: %s\n",
+	"📄 Exporting":                                               "📄 Exportando",
+	"📜 Exporting":                                               "📜 Exportando",
+	"🔍 Similar Names":                                           "🔍 Nombres similares",
+	"🌆 Visual Hashing":                                          "🌆 Hashes visuales",
+	"🔄 Identical sizes":                                         "🔄 Tamaños idénticos",
+	"🗜️  Decompressing":                                         "🗜️  Descomprimiendo",
+	"🩺 Integrity":                                               "🩺 Integridad",
+	"Threshold":                                                 "Umbral",
+	"Groups":                                                    "Grupos",
+	"Files":                                                     "Archivos",
+	"Flagged":                                                   "Señalado",
+
+	// Estimates and sampling
+	"📊 Estimate: %d archives, %s total":                                                   "📊 Estimación: %d archivos, %s en total",
+	"  • Step 3 (similar names): %s\n":                                                    "  • Paso 3 (nombres similares): %s\n",
+	"  • Visual analysis: %s (%d files not yet fingerprinted)\n":                          "  • Análisis visual: %s (%d archivos aún sin huella)\n",
+	"  ℹ️  Based on default rates; estimates are calibrated after the first run":          "  ℹ️  Basado en velocidades por defecto; las estimaciones se calibran tras la primera ejecución",
+	"⚠️  Step 3 is projected to take %s (limit: %v). Continue? (y/N): ":                   "⚠️  Se prevé que el paso 3 tarde %s (límite: %v). ¿Continuar? (s/N): ",
+	"⚠️  Comparing preview images is projected to take %s (limit: %v). Continue? (y/N): ": "⚠️  Se prevé que comparar las imágenes de vista previa tarde %s (límite: %v). ¿Continuar? (s/N): ",
+	"⏹️  Preview images not compared. Raise -confirm-above to compare them.":              "⏹️  No se han comparado las imágenes de vista previa. Sube -confirm-above para compararlas.",
+	"⏹️  Run cancelled. Narrow the directory or raise -confirm-above to proceed.":         "⏹️  Ejecución cancelada. Reduce el directorio o sube -confirm-above para continuar.",
+	"🔬 Sample verification: %d kept files re-read byte for byte, all match the journal":   "🔬 Verificación por muestreo: %d archivos conservados releídos byte a byte, todos coinciden con el diario",
+	"❌ Sample verification: %d of %d kept files do not match the journal (%s)":            "❌ Verificación por muestreo: %d de %d archivos conservados no coinciden con el diario (%s)",
+	"⚠️  Sample verification skipped: %v":                                                 "⚠️  Verificación por muestreo omitida: %v",
+	"⚠️ Could not record the run in the scan history: %v":                                 "⚠️ No se pudo registrar la ejecución en el historial de escaneos: %v",
+	"  ✅ Verified: %s":             "  ✅ Verificado: %s",
+	"  ❌ Verification %s: %s (%s)": "  ❌ Verificación %s: %s (%s)",
+
+	// Similar names and visual matching
+	"📝 Step 3: Similar name analysis started...":                                         "📝 Paso 3: Análisis de nombres similares iniciado...",
+	"📝 Step 3: Similar name analysis STARTED (Clustering Mode)...":                       "📝 Paso 3: Análisis de nombres similares INICIADO (modo agrupación)...",
+	"📝 Step 3: Similar name analysis started in BACKGROUND...":                           "📝 Paso 3: Análisis de nombres similares iniciado en SEGUNDO PLANO...",
+	"📝 Step 3: Similar name analysis (Interactive Mode)":                                 "📝 Paso 3: Análisis de nombres similares (modo interactivo)",
+	"✅ Step 3 analysis FINISHED. Found %d similarity clusters.":                          "✅ Análisis del paso 3 TERMINADO. Encontrados %d grupos de similitud.",
+	"ℹ️  Step 3 (Similarity Check) skipped. Use --check-similar or Dashboard to run it.": "ℹ️  Paso 3 (comprobación de similitud) omitido. Usa --check-similar o el panel para ejecutarlo.",
+	"ℹ️  Step 3 is already running.":                                                     "ℹ️  El paso 3 ya está en marcha.",
+	"ℹ️  You can check the dashboard while Step 3 works.":                                "ℹ️  Puedes consultar el panel mientras trabaja el paso 3.",
+	"🚀 Optimized Clustering Engine: Active (O(N) Speed)":                                 "🚀 Motor de agrupación optimizado: activo (velocidad O(N))",
+	"🎨 Step 4: Visual Fingerprinting STARTED (Incremental Mode)...":                      "🎨 Paso 4: Huellas visuales INICIADO (modo incremental)...",
+	"✅ Visual analysis FINISHED. Found %d visual duplicate groups total.":                "✅ Análisis visual TERMINADO. Encontrados %d grupos de duplicados visuales en total.",
+	"ℹ️  Visual analysis is already running.":                                            "ℹ️  El análisis visual ya está en marcha.",
+	"⚠️  Visual matching needs the cache, skipped":                                       "⚠️  La coincidencia visual necesita la caché, omitida",
+	"🔍 Cluster: '%s' (%d files)\n":                                                       "🔍 Grupo: '%s' (%d archivos)\n",
+	"📚 Cluster: '%s' (%d files) — probable series, not duplicates\n":                     "📚 Grupo: '%s' (%d archivos) — probable serie, no duplicados\n",
+	"  • %s (%s) — %.1f%% match\n":                                                       "  • %s (%s) — %.1f%% de coincidencia\n",
+	"  🔗 %s ↔ %s (distance %d)\n":                                                        "  🔗 %s ↔ %s (distancia %d)\n",
+	"♻️  Similarity cache hit: no file changed, %d clusters reused":                      "♻️  Caché de similitud: ningún archivo cambió, %d grupos reutilizados",
+	"♻️  Similarity cache: %d clusters reused, %d rebuilt (%d files changed)":            "♻️  Caché de similitud: %d grupos reutilizados, %d reconstruidos (%d archivos cambiaron)",
+	"❌ Migration failed: %v":                                                             "❌ La migración falló: %v",
+	"✅ The cache is already at schema version %d":                                        "✅ La caché ya está en la versión de esquema %d",
+	"🔄 Cache schema migrated from version %d to %d":                                      "🔄 Esquema de la caché migrado de la versión %d a la %d",
+	"🗄️  %s (%s, schema version %d)\n":                                                   "🗄️  %s (%s, versión de esquema %d)\n",
+	"📐 Cluster quality: cohesion %.1f%%, separation %.1f%%, silhouette %.2f":             "📐 Calidad de los grupos: cohesión %.1f%%, separación %.1f%%, silueta %.2f",
+	"💡 %s; try -threshold %d":                                                            "💡 %s; prueba -threshold %d",
+	"📐 Threshold sweep: %s":                                                              "📐 Barrido de umbrales: %s",
+	"❌ Invalid sweep: %v":                                                                "❌ Barrido no válido: %v",
+	"❌ Invalid scorers: %v":                                                              "❌ Puntuadores no válidos: %v",
+	"❌ Invalid profiles: %v":                                                             "❌ Perfiles no válidos: %v",
+	"❌ Unknown phonetic algorithm %q":                                                    "❌ Algoritmo fonético desconocido %q",
+	"ℹ️  Flagged = bytes in each cluster except its largest file.":                       "ℹ️  Señalado = bytes de cada grupo salvo su archivo más grande.",
+
+	// Interactive cleanup
+	"  🤔 Interactive choice Required:\n":                               "  🤔 Se requiere una elección:\n",
+	"  📊 Name similarity: %.1f%%\n":                                    "  📊 Similitud de nombre: %.1f%%\n",
+	"  🔐 CONFIRMED: Identical contents":                                "  🔐 CONFIRMADO: Contenido idéntico",
+	"  ⚠️  HIGH PROBABILITY: Likely renamed duplicate":                 "  ⚠️  PROBABILIDAD ALTA: Seguramente un duplicado renombrado",
+	"  ⚠️  MEDIUM PROBABILITY: Possible variant or version":            "  ⚠️  PROBABILIDAD MEDIA: Posible variante o versión",
+	"  🗑️  Candidate for deletion: %s (%s)\n":                          "  🗑️  Candidato a eliminar: %s (%s)\n",
+	"  ℹ️  No clear candidate for deletion.":                           "  ℹ️  No hay un candidato claro a eliminar.",
+	"  ℹ️  Skipping cleanup: Multi-volume parts detected (%s or %s)\n": "  ℹ️  Limpieza omitida: se detectaron partes de un multivolumen (%s o %s)\n",
+	"  ⏩ Skipping multi-volume set parts: %s vs %s\n":                  "  ⏩ Omitiendo partes de un multivolumen: %s frente a %s\n",
+	"  🛡️  Skipping cleanup: both files are protected (%s, %s)\n":      "  🛡️  Limpieza omitida: ambos archivos están protegidos (%s, %s)\n",
+	"     [%d] Delete: %s (%s, %v)\n":                                  "     [%d] Eliminar: %s (%s, %v)\n",
+	"     [%d] 🛡️  Protected: %s (%s, %v)\n":                           "     [%d] 🛡️  Protegido: %s (%s, %v)\n",
+	"     [k] Keep both files\n":                                       "     [k] Conservar ambos archivos\n",
+	"     Choice (1/2/k): ":                                            "     Elección (1/2/k): ",
+	"     Delete/Move this file? (y/N): ":                              "     ¿Eliminar/mover este archivo? (s/N): ",
+	"     ✅ Keeping both files.":                                       "     ✅ Se conservan ambos archivos.",
+	"     ⏭️  Skipping (invalid choice)":                               "     ⏭️  Omitido (elección no válida)",
+	"     🛡️  Protected file, keeping both.":                           "     🛡️  Archivo protegido, se conservan ambos.",
+	"     🛡️  Refusing to remove protected file: %s\n":                 "     🛡️  No se elimina el archivo protegido: %s\n",
+	"     ✅ File deleted successfully.":                                "     ✅ Archivo eliminado correctamente.",
+	"     ❌ Archive kept: %v\n":                                        "     ❌ Archivo conservado: %v\n",
+	"     ⚠️  %v, file kept\n":                                         "     ⚠️  %v, archivo conservado\n",
+	"⚠️  %v, file kept":                                                "⚠️  %v, archivo conservado",
+	"     ⚠️  Kept by the pre_delete hook: %v\n":                       "     ⚠️  Conservado por el hook pre_delete: %v\n",
+	"⚠️  Kept by the pre_delete hook: %v":                              "⚠️  Conservado por el hook pre_delete: %v",
+	"     ✅ Moved to trash: %s\n":                                      "     ✅ Movido a la papelera: %s\n",
+	"     ❌ Error moving to trash: %v (Attempting delete instead)\n":   "     ❌ Error al mover a la papelera: %v (se intenta eliminar)\n",
+	"     ✅ Quarantined: %s\n":                                         "     ✅ En cuarentena: %s\n",
+	"❌ Could not read the quarantine: %v":                              "❌ No se pudo leer la cuarentena: %v",
+	"The quarantine is empty.":                                         "La cuarentena está vacía.",
+	"🔒 %d quarantined files, %s\n":                                     "🔒 %d archivos en cuarentena, %s\n",
+	"  ♻️  Restored %s\n":                                              "  ♻️  Restaurado %s\n",
+	"❌ Some files were not restored: %v":                               "❌ Algunos archivos no se restauraron: %v",
+	"❌ -older-than: %v":                                                "❌ -older-than: %v",
+	"🔥 Would delete %d quarantined files (%s)\n":                       "🔥 Se eliminarían %d archivos en cuarentena (%s)\n",
+	"🔥 Deleted %d quarantined files for good (%s)\n":                   "🔥 Eliminados definitivamente %d archivos en cuarentena (%s)\n",
+	"❌ Some files could not be deleted: %v":                            "❌ Algunos archivos no se pudieron eliminar: %v",
+	"❌ No quarantine folder: pass -dir or set quarantine_path":         "❌ No hay carpeta de cuarentena: usa -dir o configura quarantine_path",
+	"     ❌ Could not read the kept file, skipping: %v\n":              "     ❌ No se pudo leer el archivo conservado, se omite: %v\n",
+	"     ⚠️  %s has different contents, left untouched\n":             "     ⚠️  %s tiene otro contenido, no se toca\n",
+	"     📝 Reference note created: %s\n":                              "     📝 Nota de referencia creada: %s\n",
+	"     ⚠️  Could not create reference file: %v\n":                   "     ⚠️  No se pudo crear el archivo de referencia: %v\n",
+	"     ⚠️  Could not write the cleanup journal: %v\n":               "     ⚠️  No se pudo escribir el diario de limpieza: %v\n",
+	"\r     📦 Copying to trash: %s / %s":                               "\r     📦 Copiando a la papelera: %s / %s",
+	"⚠️  Could not create reference note: %v":                          "⚠️  No se pudo crear la nota de referencia: %v",
+	"⚠️  Could not write the cleanup journal: %v":                      "⚠️  No se pudo escribir el diario de limpieza: %v",
+	"⚠️  Could not empty old trash folders: %v":                        "⚠️  No se pudieron vaciar las papeleras antiguas: %v",
+	"🧹 Emptied %d trash folders older than %d days (%d files, %s)":     "🧹 Vaciadas %d papeleras de más de %d días (%d archivos, %s)",
+	"♻️  Put back %s, set aside by a cleanup that did not finish":      "♻️  Restaurado %s, apartado por una limpieza que no terminó",
+
+	// Reports and dashboard
+	"💾 JSON report written: %s":                                     "💾 Informe JSON escrito: %s",
+	"❌ Could not write JSON report: %v":                             "❌ No se pudo escribir el informe JSON: %v",
+	"📜 Cleanup script written: %s (review it before running)":       "📜 Script de limpieza escrito: %s (revísalo antes de ejecutarlo)",
+	"❌ Could not write exclusion list: %v":                          "❌ No se pudo escribir la lista de exclusión: %v",
+	"🚫 Exclusion list written: %s":                                  "🚫 Lista de exclusión escrita: %s",
+	"❌ Could not write cleanup script: %v":                          "❌ No se pudo escribir el script de limpieza: %v",
+	"\n📄 [BETA] Generating Step 2 PDF: %s\n":                        "\n📄 [BETA] Generando el PDF del paso 2: %s\n",
+	"🌍 Opening dashboard at %s ...":                                 "🌍 Abriendo el panel en %s ...",
+	"⚠️  Could not open browser: %v":                                "⚠️  No se pudo abrir el navegador: %v",
+	"📡 Dashboard is ACTIVE. Press Ctrl+C to shutdown.":              "📡 El panel está ACTIVO. Pulsa Ctrl+C para salir.",
+	"❌ Web server error: %v":                                        "❌ Error del servidor web: %v",
+	"⚠️  Could not initialize cache: %v":                            "⚠️  No se pudo inicializar la caché: %v",
+	"🧠 In-memory cache: nothing is written to disk for this run":    "🧠 Caché en memoria: en esta ejecución no se escribe nada en disco",
+	"⚠️  Cache not available: %v":                                   "⚠️  Caché no disponible: %v",
+	"🔍 Scanning %s in the running dashboard":                        "🔍 Escaneando %s en el panel en ejecución",
+	"❌ The dashboard asks for its access token: add -token <token>": "❌ El panel pide su token de acceso: añade -token <token>",
+	"✅ Context menu entry removed":                                  "✅ Entrada del menú contextual eliminada",
+	"✅ \"%s\" added to the context menu of folders\n":               "✅ \"%s\" añadido al menú contextual de las carpetas\n",
+
+	// Tray
+	"❌ This build has no tray support (macOS builds need cgo); use finder serve instead": "❌ Esta versión no admite la bandeja del sistema (en macOS requiere cgo); usa finder serve",
+	"Not set up yet: open the dashboard":                                                 "Sin configurar: abre el panel",
+	"Idle":                                                                               "En espera",
+	"No scan yet":                                                                        "Sin escanear todavía",
+	"Scan":                                                                               "Escaneo",
+	"Similar names":                                                                      "Nombres similares",
+	"Visual analysis":                                                                    "Análisis visual",
+	"%s: queued":                                                                         "%s: en cola",
+	"%s: paused at %.0f%%":                                                               "%s: en pausa al %.0f%%",
+	"%s: stopping":                                                                       "%s: deteniéndose",
+	"No duplicates found yet":                                                            "Aún no hay duplicados",
+	"%d identical (%s reclaimable), %d similar, %d visual": "%d idénticos (%s recuperables), %d similares, %d visuales",
+	"Open dashboard":  "Abrir el panel",
+	"Scan now":        "Escanear ahora",
+	"Pause analysis":  "Pausar el análisis",
+	"Resume analysis": "Reanudar el análisis",
+	"Quit":            "Salir",
+
+	// Organize and rename
+	"🗂️  Organizing kept files into: %s (%s)":                            "🗂️  Organizando los archivos conservados en: %s (%s)",
+	"🗂️  Nothing to organize: no resolved duplicate groups":              "🗂️  Nada que organizar: no hay grupos de duplicados resueltos",
+	"🗂️  Move and merge: %d groups into %s":                              "🗂️  Mover y fusionar: %d grupos en %s",
+	"       • Changes: %s\n":                                             "       • Cambios: %s\n",
+	"     📌 %s (stays)\n":                                                "     📌 %s (se queda)\n",
+	"     Apply this plan? (y/N): ":                                      "     ¿Aplicar este plan? (s/N): ",
+	"     ⏭️  Library left untouched.":                                   "     ⏭️  La biblioteca no se ha tocado.",
+	"📊 %s: %d kept files moved, %d copies %s, %d groups with errors\n\n": "📊 %s: %d archivos conservados movidos, %d copias %s, %d grupos con errores\n\n",
+	"Organized":                              "Organizado",
+	"Planned":                                "Planificado",
+	"deleted":                                "eliminadas",
+	"moved to the trash":                     "movidas a la papelera",
+	"replaced by links":                      "sustituidas por enlaces",
+	"❌ Cannot organize: %v":                  "❌ No se puede organizar: %v",
+	"❌ Invalid layout: %v":                   "❌ Estructura no válida: %v",
+	"✏️  Rename suggestions: %d":             "✏️  Sugerencias de renombrado: %d",
+	"     Rename these files? (y/N): ":       "     ¿Renombrar estos archivos? (s/N): ",
+	"     ⏭️  Names left untouched.":         "     ⏭️  Los nombres no se han tocado.",
+	"✏️  Renamed %d files":                   "✏️  Renombrados %d archivos",
+	"✅ Variant names are already consistent": "✅ Los nombres de las variantes ya son coherentes",
+	"ℹ️  Dry run. Use -rename apply to rename the files.": "ℹ️  Simulación. Usa -rename apply para renombrar los archivos.",
+
+	// Review
+	"identical":                "idénticos",
+	"similar names":            "nombres similares",
+	"similar previews":         "vistas previas similares",
+	"%s each":                  "%s cada uno",
+	"%.0f%% similar":           "%.0f%% similar",
+	"%d entries":               "%d entradas",
+	"%d entries · %s unpacked": "%d entradas · %s descomprimido",
+	"🛡️ protected":             "🛡️ protegido",
+	" (y/N)":                   " (s/N)",
+	"Canceled":                 "Cancelado",
+	"Keep %s and remove %d other files (%s)?":                    "¿Conservar %s y eliminar los otros %d archivos (%s)?",
+	"Remove %s (%s)?":                                            "¿Eliminar %s (%s)?",
+	"🗑️  Removed %s":                                             "🗑️  Eliminado %s",
+	"❌ Could not delete %s: %v":                                  "❌ No se pudo eliminar %s: %v",
+	"❌ Could not move %s to the trash: %v":                       "❌ No se pudo mover %s a la papelera: %v",
+	"🛡️  Protected file, not removed":                            "🛡️  Archivo protegido, no se elimina",
+	"🛡️  The other files are protected":                          "🛡️  Los otros archivos están protegidos",
+	"👍 Group ignored":                                            "👍 Grupo ignorado",
+	"👍 Group skipped for this session (no cache to remember it)": "👍 Grupo omitido en esta sesión (no hay caché para recordarlo)",
+	"✅ No duplicate groups to review":                            "✅ No hay grupos de duplicados que revisar",
+	"📋 Review finished: %d files removed (%s), %d groups ignored, %d groups left": "📋 Revisión terminada: %d archivos eliminados (%s), %d grupos ignorados, %d grupos pendientes",
+	"❌ finder review: %v": "❌ finder review: %v",
+	" 📋 Group %d/%d · %s · %s · %d files · up to %s reclaimable":                                                          " 📋 Grupo %d/%d · %s · %s · %d archivos · hasta %s recuperables",
+	" Press c to list the contents of the archives side by side.":                                                         " Pulsa c para listar el contenido de los archivos lado a lado.",
+	" ←/→ group · tab/1-9 file · k keep selected · d delete selected · i ignore group · c contents · ↑/↓ scroll · q quit": " ←/→ grupo · tab/1-9 archivo · k conservar selección · d eliminar selección · i ignorar grupo · c contenido · ↑/↓ desplazar · q salir",
+
+	// Settings
+	"❌ %s already exists (use -force to overwrite it)": "❌ %s ya existe (usa -force para sobrescribirlo)",
+	"❌ Could not write the settings: %v":               "❌ No se pudieron escribir los ajustes: %v",
+	"❌ Could not read the settings: %v":                "❌ No se pudieron leer los ajustes: %v",
+	"📝 Settings written to %s":                         "📝 Ajustes escritos en %s",
+	"✅ %s set in %s":                                   "✅ %s guardado en %s",
+	"⚠️  %s is set and overrides this setting":         "⚠️  %s está definida y reemplaza este ajuste",
+	"👋 Shutting down":                                  "👋 Cerrando",
+	"📦 Moved %s to %s":                                 "📦 Movido %s a %s",
+	"⚠️  Could not move the files of an earlier version (still using them): %v": "⚠️  No se pudieron mover los archivos de una versión anterior (se siguen usando): %v",
+
+	// Cache, diff and extract
+	"(unattributed)": "(sin atribuir)",
+	"%d hashes, %d previews, %d visual hashes, %d preview overrides, %d folder listings, %d archive contents": "%d hashes, %d vistas previas, %d hashes visuales, %d vistas previas elegidas, %d listados de carpetas, %d contenidos de archivos",
+	"   %d ignored groups, %d suppressed hashes, %d name keys, %d cached scan results\n":                      "   %d grupos ignorados, %d hashes suprimidos, %d claves de nombre, %d escaneos en caché\n",
+	"📥 Imported %d file hashes, %d previews, %d visual hashes and %d ignored groups from %s":                  "📥 Importados %d hashes de archivos, %d vistas previas, %d hashes visuales y %d grupos ignorados de %s",
+	"       • Vertices: %d → %d (%+d)\n":                                                                      "       • Vértices: %d → %d (%+d)\n",
+	"       • Triangles: %d → %d (%+d)\n":                                                                     "       • Triángulos: %d → %d (%+d)\n",
+	"❌ Could not open cache: %v":                                                                              "❌ No se pudo abrir la caché: %v",
+	"❌ Could not read cache: %v":                                                                              "❌ No se pudo leer la caché: %v",
+	"❌ Could not forget %s: %v":                                                                               "❌ No se pudo olvidar %s: %v",
+	"❌ Garbage collection failed: %v":                                                                         "❌ Falló la recolección de basura: %v",
+	"❌ Export failed: %v":                                                                                     "❌ Falló la exportación: %v",
+	"❌ Import failed: %v":                                                                                     "❌ Falló la importación: %v",
+	"📤 Cache exported to %s":                                                                                  "📤 Caché exportada a %s",
+	"🧹 Removed %d cache entries of %s":                                                                        "🧹 Eliminadas %d entradas de la caché de %s",
+	"No content is suppressed.":                                                                               "No hay contenido suprimido.",
+	"❌ The files do not have identical contents":                                                              "❌ Los archivos no tienen contenido idéntico",
+	"🔕 Suppressed content hash %s (%d copies)":                                                                "🔕 Hash de contenido %s suprimido (%d copias)",
+	"❌ Content hash %s is not suppressed":                                                                     "❌ El hash de contenido %s no está suprimido",
+	"🔔 Content hash %s is reported again":                                                                     "🔔 El hash de contenido %s vuelve a informarse",
+	"🧹 Removed %d stale cache entries":                                                                        "🧹 Eliminadas %d entradas obsoletas de la caché",
+	"⚠️  Not reachable, entries kept: %s":                                                                     "⚠️  No accesible, entradas conservadas: %s",
+	"❌ Could not open %s: %v":                                                                                 "❌ No se pudo abrir %s: %v",
+	"❌ Could not create %s: %v":                                                                               "❌ No se pudo crear %s: %v",
+	"❌ Could not import %s: %v":                                                                               "❌ No se pudo importar %s: %v",
+	"❌ Manifests are written for local folders only":                                                          "❌ Los manifiestos solo se escriben para carpetas locales",
+	"❌ Could not read manifest: %v":                                                                           "❌ No se pudo leer el manifiesto: %v",
+	"❌ Could not write manifest: %v":                                                                          "❌ No se pudo escribir el manifiesto: %v",
+	"🔍 Verifying %d files of %s...":                                                                           "🔍 Verificando %d archivos de %s...",
+	"  ❌ FAILED   %s\n":                                                                                       "  ❌ FALLO    %s\n",
+	"  ⚠️  MISSING  %s\n":                                                                                     "  ⚠️  FALTA    %s\n",
+	"🧾 %d verified, %d failed, %d missing":                                                                    "🧾 %d verificados, %d fallidos, %d ausentes",
+	"⚠️  Could not read %s":                                                                                   "⚠️  No se pudo leer %s",
+	"🧾 Manifest written: %s (%d files, %d new)":                                                               "🧾 Manifiesto escrito: %s (%d archivos, %d nuevos)",
+	"❌ Error scanning directory: %v":                                                                          "❌ Error al escanear el directorio: %v",
+	"⚠️  Listed but not found: %s":                                                                            "⚠️  En la lista pero no encontrado: %s",
+	"📥 Imported %d groups of identical files (%d files, %s reclaimable) from %s":                              "📥 Importados %d grupos de archivos idénticos (%d archivos, %s recuperables) de %s",
+	"💾 Diff report exported to %s":                                                                            "💾 Informe de diferencias exportado a %s",
+	"    ✅ %s - IDENTICAL\n":                                                                                  "    ✅ %s - IDÉNTICO\n",
+	"    ⚠️  %s - MODIFIED\n":                                                                                 "    ⚠️  %s - MODIFICADO\n",
+	"    ❌ %s - ONLY IN ARCHIVE 1\n":                                                                          "    ❌ %s - SOLO EN EL ARCHIVO 1\n",
+	"    ❌ %s - ONLY IN ARCHIVE 2\n":                                                                          "    ❌ %s - SOLO EN EL ARCHIVO 2\n",
+	"    ℹ️  %s - Not an STL file (skipped)\n":                                                                "    ℹ️  %s - No es un archivo STL (omitido)\n",
+	"📤 Extracted %d of %d entries from %s":                                                                    "📤 Extraídas %d de %d entradas de %s",
+	"⚠️  Extraction stopped: %v":                                                                              "⚠️  Extracción detenida: %v",
+
+	// PDF report
+	"Archive Duplicate Finder Report":                 "Informe de Archive Duplicate Finder",
+	"Analysis Summary":                                "Resumen del análisis",
+	"Timestamp:":                                      "Fecha:",
+	"Total Files Analyzed:":                           "Archivos analizados:",
+	"Identical Size Groups:":                          "Grupos de igual tamaño:",
+	"Similar Groups:":                                 "Grupos similares:",
+	"Corrupt Archives:":                               "Archivos corruptos:",
+	"Analysis Duration:":                              "Duración del análisis:",
+	"Files with Identical Size":                       "Archivos con el mismo tamaño",
+	"Group %d - Size: %s":                             "Grupo %d - Tamaño: %s",
+	"Group %d - Same decompressed contents: %s":       "Grupo %d - Mismo contenido descomprimido: %s",
+	"Files with Similar Names (Clusters)":             "Archivos con nombres similares (grupos)",
+	"Cluster %d - Base: '%s'":                         "Grupo %d - Base: '%s'",
+	" (probable series, not duplicates)":              " (probable serie, no duplicados)",
+	"Unreadable / Corrupt Archives":                   "Archivos ilegibles / corruptos",
+	"Page %d | Generated by Archive Duplicate Finder": "Página %d | Generado por Archive Duplicate Finder",
+	"Note: ":   "Nota: ",
+	"pending":  "pendiente",
+	"reviewed": "revisado",
+	"resolved": "resuelto",
+
+	// HTML report
+	"Visual Groups:":                        "Grupos visuales:",
+	"Visually Similar Files":                "Archivos visualmente similares",
+	"Generated by Archive Duplicate Finder": "Generado por Archive Duplicate Finder",
+
+	// Digest
+	"Archive library digest: %s (%s)":                                               "Resumen de la biblioteca: %s (%s)",
+	"%d archives | %d duplicate groups | %s reclaimable | %d similar-name clusters": "%d archivos | %d grupos duplicados | %s recuperables | %d grupos de nombres similares",
+	" | %d corrupt":               " | %d corruptos",
+	"No duplicates found.":        "No se encontraron duplicados.",
+	"RECLAIMABLE":                 "RECUPERABLE",
+	"GROUPS":                      "GRUPOS",
+	"SIMILAR":                     "SIMILARES",
+	"DIRECTORY":                   "DIRECTORIO",
+	"... and %d more directories": "... y %d directorios más",
+	"Duplicates by directory (subdirectories included):": "Duplicados por directorio (con sus subdirectorios):",
+	"REDUNDANT":                         "REDUNDANTE",
+	"FILES":                             "ARCHIVOS",
+	"[every file has a copy elsewhere]": "[todos sus archivos tienen copia en otro lugar]",
+	"Worst offenders:":                  "Peores casos:",
+	"%d copies of %s":                   "%d copias de %s",
+
+	// Reference note (refnote.DefaultTemplate)
+	`Archive Duplicate Finder
+-----------------------
+Action: Removed as duplicate
+Date: {{.Date.Format "2006-01-02 15:04:05"}}
+Original kept: {{.Kept}}
+Original size: {{bytes .KeptSize}}
+`: `Archive Duplicate Finder
+-----------------------
+Acción: Eliminado por duplicado
+Fecha: {{.Date.Format "2006-01-02 15:04:05"}}
+Original conservado: {{.Kept}}
+Tamaño del original: {{bytes .KeptSize}}
+`,
+}
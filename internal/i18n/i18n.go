@@ -0,0 +1,114 @@
+// Package i18n translates the user-facing messages of the CLI, the reports and the reference
+// notes. Messages are looked up by their English text (the format string passed to T), so a
+// message missing from a catalog simply shows up in English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Languages with a catalog
+const (
+	English = "en"
+	Spanish = "es"
+)
+
+// catalogs maps a language to its translations, keyed by the English message
+var catalogs = map[string]map[string]string{
+	English: nil,
+	Spanish: spanish,
+}
+
+var (
+	mu      sync.RWMutex
+	current = English
+)
+
+// Languages lists the supported language codes
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Normalize reduces a locale such as "es_ES.UTF-8" or "es-AR" to its language code
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// IsSupported reports whether a language (or locale) has a catalog; empty means the default
+func IsSupported(lang string) bool {
+	if lang == "" {
+		return true
+	}
+	_, ok := catalogs[Normalize(lang)]
+	return ok
+}
+
+// SetLanguage selects the language of the messages. An empty language uses the environment
+// (LC_ALL, LC_MESSAGES, LANG), falling back to English.
+func SetLanguage(lang string) error {
+	if lang == "" {
+		lang = fromEnvironment()
+	} else if !IsSupported(lang) {
+		return fmt.Errorf("unsupported language %q (available: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = Normalize(lang)
+	return nil
+}
+
+// Language returns the selected language code
+func Language() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// fromEnvironment returns the first supported language of the locale variables
+func fromEnvironment() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if lang := os.Getenv(name); lang != "" {
+			if IsSupported(lang) {
+				return Normalize(lang)
+			}
+			break // The first variable set decides, like the C library does
+		}
+	}
+	return English
+}
+
+// T translates a message and formats it with args like fmt.Sprintf. Without args the message
+// is returned as is.
+func T(message string, args ...any) string {
+	mu.RLock()
+	if translated, ok := catalogs[current][message]; ok && translated != "" {
+		message = translated
+	}
+	mu.RUnlock()
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// IsYes reports whether the answer to a y/N question accepts it, in any supported language
+func IsYes(answer string) bool {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "s", "si", "sí":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+// Package importer reads the results of other duplicate finders (rdfind, fdupes, czkawka) into
+// reports, so duplicates they already found can be reviewed and cleaned up here
+package importer
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats read by Parse
+const (
+	FormatRdfind  = "rdfind"  // results.txt
+	FormatFdupes  = "fdupes"  // Default output, with or without -S (jdupes writes the same)
+	FormatCzkawka = "czkawka" // Text (-f) or JSON (-C, -p) results of the duplicate tool
+)
+
+// Formats lists the formats Parse reads
+var Formats = []string{FormatRdfind, FormatFdupes, FormatCzkawka}
+
+// Parse reads the duplicate groups of another tool's results, as lists of paths
+func Parse(format string, r io.Reader) ([][]string, error) {
+	switch format {
+	case FormatRdfind:
+		return parseRdfind(r)
+	case FormatFdupes:
+		return parseFdupes(r)
+	case FormatCzkawka:
+		return parseCzkawka(r)
+	}
+	return nil, fmt.Errorf("unknown format %q (use %s)", format, strings.Join(Formats, ", "))
+}
+
+// parseRdfind reads results.txt: "duptype id depth size device inode priority name" lines. The
+// first occurrence of a file carries its id and its duplicates the same id negated.
+func parseRdfind(r io.Reader) ([][]string, error) {
+	byID := make(map[int64][]string)
+	var order []int64
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// The name is the rest of the line, spaces included
+		fields := strings.SplitN(line, " ", 8)
+		if len(fields) < 8 || !strings.HasPrefix(fields[0], "DUPTYPE_") {
+			return nil, fmt.Errorf("not an rdfind results line: %q", line)
+		}
+		id, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad id in rdfind results line: %q", line)
+		}
+		if id < 0 {
+			id = -id
+		}
+		if _, seen := byID[id]; !seen {
+			order = append(order, id)
+		}
+		byID[id] = append(byID[id], fields[7])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	groups := make([][]string, 0, len(order))
+	for _, id := range order {
+		groups = append(groups, byID[id])
+	}
+	return groups, nil
+}
+
+// fdupesSize is the line -S prints before a group
+var fdupesSize = regexp.MustCompile(`^\d+ bytes? each:$`)
+
+// parseFdupes reads groups of paths, one per line, separated by blank lines
+func parseFdupes(r io.Reader) ([][]string, error) {
+	var groups [][]string
+	var current []string
+	end := func() {
+		if len(current) > 0 {
+			groups = append(groups, current)
+			current = nil
+		}
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		switch {
+		case line == "":
+			end()
+		case fdupesSize.MatchString(line):
+			end()
+		default:
+			current = append(current, line)
+		}
+	}
+	end()
+	return groups, sc.Err()
+}
+
+// parseCzkawka reads the JSON results (every list of objects with a "path") or the text ones,
+// where the quoted paths of a group follow a "---- Size ..." or "Size ..." heading
+func parseCzkawka(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		var v any
+		if err := json.Unmarshal(trimmed, &v); err != nil {
+			return nil, fmt.Errorf("not czkawka JSON results: %w", err)
+		}
+		var groups [][]string
+		collectJSONGroups(v, &groups)
+		return groups, nil
+	}
+
+	var groups [][]string
+	var current []string
+	end := func() {
+		if len(current) > 0 {
+			groups = append(groups, current)
+			current = nil
+		}
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, `"`) {
+			if p, err := strconv.Unquote(line); err == nil {
+				current = append(current, p)
+				continue
+			}
+			current = append(current, strings.Trim(line, `"`))
+			continue
+		}
+		end() // Headings, summaries and blank lines close the group
+	}
+	end()
+	return groups, sc.Err()
+}
+
+// collectJSONGroups finds the lists of {"path": ...} objects anywhere in a JSON document
+func collectJSONGroups(v any, groups *[][]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		// Keys are sizes or hashes: keep the output in a stable order
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			collectJSONGroups(v[k], groups)
+		}
+	case []any:
+		var paths []string
+		for _, item := range v {
+			if obj, ok := item.(map[string]any); ok {
+				if p, ok := obj["path"].(string); ok {
+					paths = append(paths, p)
+					continue
+				}
+			}
+			collectJSONGroups(item, groups)
+		}
+		if len(paths) > 0 {
+			*groups = append(*groups, paths)
+		}
+	}
+}
+
+// Report turns imported groups into a report of identical files. Each file is looked up on disk:
+// missing ones are returned apart, and members whose size differs from the rest of their group
+// are split off, so every group left holds at least two files of one size.
+func Report(groups [][]string) (reporter.Report, []string) {
+	report := reporter.Report{
+		SizeGroups:    []reporter.SizeGroup{},
+		SimilarGroups: []reporter.SimilarityGroup{},
+		VisualGroups:  []reporter.SimilarityGroup{},
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Status:        "finished",
+		Progress:      100,
+	}
+	var missing []string
+	seen := make(map[string]bool)
+	for _, paths := range groups {
+		bySize := make(map[int64][]reporter.FileInfo)
+		var sizes []int64
+		for _, p := range paths {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			info, err := vfs.Stat(p)
+			if err != nil {
+				missing = append(missing, p)
+				continue
+			}
+			if _, ok := bySize[info.Size]; !ok {
+				sizes = append(sizes, info.Size)
+			}
+			bySize[info.Size] = append(bySize[info.Size], reporter.FileInfo{
+				Name:    filepath.Base(p),
+				Path:    p,
+				Size:    info.Size,
+				Type:    scanner.FileType(p),
+				ModTime: info.ModTime.Format(time.RFC3339),
+			})
+		}
+		for _, size := range sizes {
+			if files := bySize[size]; len(files) >= 2 {
+				// The other tool compared the contents
+				report.SizeGroups = append(report.SizeGroups, reporter.SizeGroup{Size: size, Files: files, Verified: true})
+				report.TotalFiles += len(files)
+			}
+		}
+	}
+	return report, missing
+}
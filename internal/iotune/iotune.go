@@ -0,0 +1,206 @@
+package iotune
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/vfs"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Disk kinds told apart by Benchmark
+const (
+	KindNVMe    = "nvme"
+	KindSSD     = "ssd"
+	KindHDD     = "hdd"
+	KindNetwork = "network" // Remote libraries are not benchmarked
+	KindUnknown = "unknown" // Too few files to measure, or results in between
+)
+
+const (
+	minFileSize    = 1 << 20  // Files sampled by the benchmark are at least this large
+	maxSampleFiles = 16       // Files the random reads are spread over
+	maxWalk        = 5000     // Entries looked at while picking the sample
+	sequentialRead = 32 << 20 // Bytes read front to back from the largest file
+	blockSize      = 4096     // Size of every random read
+	randomReads    = 64       // Random reads made one at a time
+	parallelism    = 8        // Readers of the parallel random pass
+	hddLatency     = 2 * time.Millisecond
+	ssdLatency     = 300 * time.Microsecond
+	nvmeSequential = 1000 // MB/s above which a fast disk is taken for NVMe
+)
+
+// Profile is what a short read benchmark found out about the disk holding a library
+type Profile struct {
+	Kind          string        `json:"kind"`
+	SequentialMBs float64       `json:"sequential_mbs"` // Reading the largest sampled file front to back
+	RandomMBs     float64       `json:"random_mbs"`     // 4 KB reads at random offsets, one at a time
+	ParallelMBs   float64       `json:"parallel_mbs"`   // The same with 8 readers at once
+	Latency       time.Duration `json:"latency"`        // Mean time of one random read
+	Workers       int           `json:"workers"`        // Suggested size of the archive worker pool
+}
+
+func (p Profile) String() string {
+	if p.Kind == KindNetwork || p.Latency == 0 {
+		return fmt.Sprintf("%s disk, %d workers", p.Kind, p.Workers)
+	}
+	return fmt.Sprintf("%s disk (%.0f MB/s sequential, %.1f MB/s random, %.1f MB/s with %d readers, %v per read), %d workers",
+		p.Kind, p.SequentialMBs, p.RandomMBs, p.ParallelMBs, parallelism, p.Latency.Round(time.Microsecond), p.Workers)
+}
+
+// Benchmark reads a sample of the files under root, sequentially and at random offsets, to tell
+// fast disks that profit from many archive workers from spinning ones that thrash with them. It
+// takes well under a second; files read shortly before (in the page cache) make a disk look
+// faster than it is.
+func Benchmark(root string) Profile {
+	p := Profile{Kind: KindUnknown, Workers: archive.DefaultLimits.Workers}
+	if vfs.IsRemote(root) {
+		p.Kind = KindNetwork
+		return p
+	}
+	files := sample(root)
+	if len(files) == 0 {
+		return p
+	}
+
+	// Random reads first: the sequential pass leaves the start of a file in the page cache
+	elapsed, n := randomPass(files, randomReads, 1)
+	if n == 0 {
+		return p
+	}
+	p.Latency = elapsed / time.Duration(n)
+	p.RandomMBs = mbPerSecond(int64(n)*blockSize, elapsed)
+	elapsed, n = randomPass(files, randomReads, parallelism)
+	p.ParallelMBs = mbPerSecond(int64(n)*blockSize, elapsed)
+	p.SequentialMBs = sequential(files[0].path)
+
+	cpus := min(max(runtime.NumCPU(), archive.DefaultLimits.Workers), 16)
+	switch {
+	case p.Latency >= hddLatency:
+		// Every extra reader adds seeks; two keep the disk busy while the other decompresses
+		p.Kind, p.Workers = KindHDD, 2
+	case p.Latency <= ssdLatency || p.ParallelMBs >= 3*p.RandomMBs:
+		p.Kind, p.Workers = KindSSD, cpus
+		if p.SequentialMBs >= nvmeSequential {
+			p.Kind = KindNVMe
+		}
+	}
+	return p
+}
+
+// Tune benchmarks the disk holding root and makes the result the archive worker pool size of
+// the limits that do not set one (see archive.SetAutoWorkers). It logs and returns the profile.
+func Tune(root string) Profile {
+	p := Benchmark(root)
+	archive.SetAutoWorkers(p.Workers)
+	log.Printf("⚡ I/O tuning: %s", p)
+	return p
+}
+
+type sampleFile struct {
+	path string
+	size int64
+}
+
+// sample picks up to maxSampleFiles files of at least minFileSize under root, largest first
+func sample(root string) []sampleFile {
+	var files []sampleFile
+	seen := 0
+	errDone := errors.New("done")
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if seen++; seen > maxWalk {
+			return errDone
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Size() >= minFileSize {
+			files = append(files, sampleFile{path, info.Size()})
+			if len(files) >= maxSampleFiles {
+				return errDone
+			}
+		}
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].size > files[j].size })
+	return files
+}
+
+// sequential reads up to sequentialRead bytes of path in 1 MB chunks and returns MB/s
+func sequential(path string) float64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	buf := make([]byte, 1<<20)
+	start := time.Now()
+	n, _ := io.CopyBuffer(io.Discard, io.LimitReader(f, sequentialRead), buf)
+	return mbPerSecond(n, time.Since(start))
+}
+
+// randomPass spreads reads of blockSize bytes at random offsets of files over readers
+// goroutines, and returns how long they took and how many succeeded
+func randomPass(files []sampleFile, reads, readers int) (time.Duration, int) {
+	handles := make([]*os.File, 0, len(files))
+	var sizes []int64
+	for _, sf := range files {
+		if f, err := os.Open(sf.path); err == nil {
+			handles = append(handles, f)
+			sizes = append(sizes, sf.size)
+		}
+	}
+	defer func() {
+		for _, f := range handles {
+			f.Close()
+		}
+	}()
+	if len(handles) == 0 {
+		return 0, 0
+	}
+
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	start := time.Now()
+	for r := 0; r < readers; r++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			buf := make([]byte, blockSize)
+			ok := 0
+			for i := 0; i < reads/readers; i++ {
+				k := rng.Intn(len(handles))
+				off := rng.Int63n(sizes[k]/blockSize) * blockSize
+				if _, err := handles[k].ReadAt(buf, off); err == nil || err == io.EOF {
+					ok++
+				}
+			}
+			mu.Lock()
+			done += ok
+			mu.Unlock()
+		}(time.Now().UnixNano() + int64(r))
+	}
+	wg.Wait()
+	return time.Since(start), done
+}
+
+func mbPerSecond(bytes int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(bytes) / (1 << 20) / elapsed.Seconds()
+}
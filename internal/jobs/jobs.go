@@ -0,0 +1,352 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job states
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCanceling = "canceling" // Canceled while running, not stopped yet
+	StatusPaused    = "paused"    // Held at its next checkpoint (see Wait), or kept from starting
+	StatusFinished  = "finished"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+// maxHistory is how many ended jobs are remembered
+const maxHistory = 50
+
+var (
+	ErrNotFound    = errors.New("job not found")
+	ErrEnded       = errors.New("job has already ended") // Canceling a job that is no longer queued or running
+	ErrNotPausable = errors.New("job cannot be paused")
+)
+
+// Job is a snapshot of a long-running operation
+type Job struct {
+	ID       string     `json:"id"`
+	Kind     string     `json:"kind"` // "scan", "step3", "visual"...
+	Status   string     `json:"status"`
+	Phase    string     `json:"phase,omitempty"`
+	Progress float64    `json:"progress"` // 0.0 to 100.0, of the current phase
+	Pausable bool       `json:"pausable,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Ended    *time.Time `json:"ended,omitempty"`
+}
+
+// Active reports whether the job is queued or running
+func (j Job) Active() bool {
+	return j.Status == StatusQueued || j.Status == StatusRunning || j.Status == StatusCanceling || j.Status == StatusPaused
+}
+
+// Func is the work of a job. Once ctx is canceled it should return ctx.Err() at its next
+// checkpoint; phases that cannot be interrupted are allowed to finish first.
+type Func func(ctx context.Context) error
+
+type entry struct {
+	job    Job
+	run    Func
+	ctx    context.Context
+	cancel context.CancelFunc
+	resume chan struct{} // Closed by Resume; nil unless paused
+}
+
+// gateKey carries the queue and entry of a pausable job in its context, for Wait
+type gateKey struct{}
+
+type gate struct {
+	q *Queue
+	e *entry
+}
+
+// Queue runs jobs one at a time, in the order they were submitted. The operations it runs all
+// rewrite the same report, so they never overlap.
+type Queue struct {
+	mu      sync.Mutex
+	entries []*entry // Oldest first
+	next    int
+	working bool
+	current *entry
+}
+
+// NewQueue returns an empty queue
+func NewQueue() *Queue {
+	return &Queue{}
+}
+
+// Submit queues a job. A job of the same kind that is still queued or running is returned
+// instead of a new one (and false), so repeated requests do not pile up.
+func (q *Queue) Submit(kind string, run Func) (Job, bool) {
+	return q.submit(kind, run, false)
+}
+
+// SubmitPausable queues a job that can be paused: run calls Wait at its checkpoints
+func (q *Queue) SubmitPausable(kind string, run Func) (Job, bool) {
+	return q.submit(kind, run, true)
+}
+
+func (q *Queue) submit(kind string, run Func, pausable bool) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		if e.job.Kind == kind && (e.job.Status == StatusQueued || e.job.Status == StatusRunning || e.job.Status == StatusPaused) {
+			return e.job, false
+		}
+	}
+
+	q.next++
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &entry{
+		job:    Job{ID: fmt.Sprintf("job-%d", q.next), Kind: kind, Status: StatusQueued, Pausable: pausable, Created: time.Now()},
+		run:    run,
+		cancel: cancel,
+	}
+	e.ctx = context.WithValue(ctx, gateKey{}, &gate{q, e})
+	q.entries = append(q.entries, e)
+	q.trim()
+	q.start()
+	return e.job, true
+}
+
+// start runs the worker unless it is running already. Caller holds q.mu.
+func (q *Queue) start() {
+	if !q.working {
+		q.working = true
+		go q.work()
+	}
+}
+
+// work runs queued jobs until none is left
+func (q *Queue) work() {
+	for {
+		q.mu.Lock()
+		var e *entry
+		for _, candidate := range q.entries {
+			if candidate.job.Status == StatusQueued {
+				e = candidate
+				break
+			}
+		}
+		if e == nil {
+			q.working = false
+			q.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		e.job.Status, e.job.Started = StatusRunning, &now
+		q.current = e
+		q.mu.Unlock()
+
+		log.Printf("⚙️  Job %s (%s) started", e.job.ID, e.job.Kind)
+		err := q.runSafely(e)
+
+		q.mu.Lock()
+		ended := time.Now()
+		e.job.Ended = &ended
+		switch {
+		case err != nil && e.ctx.Err() != nil:
+			e.job.Status = StatusCanceled
+		case err != nil:
+			e.job.Status, e.job.Error = StatusFailed, err.Error()
+		default:
+			e.job.Status, e.job.Progress = StatusFinished, 100
+		}
+		e.cancel()
+		q.current = nil
+		q.mu.Unlock()
+		log.Printf("⚙️  Job %s (%s) %s", e.job.ID, e.job.Kind, e.job.Status)
+	}
+}
+
+// runSafely keeps a panicking job from taking the queue down with it
+func (q *Queue) runSafely(e *entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("🔥 CRITICAL RECOVERY: Job %s recovered from panic: %v", e.job.ID, r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return e.run(e.ctx)
+}
+
+// trim forgets the oldest ended jobs beyond maxHistory. Caller holds q.mu.
+func (q *Queue) trim() {
+	ended := 0
+	for _, e := range q.entries {
+		if !e.job.Active() {
+			ended++
+		}
+	}
+	kept := q.entries[:0]
+	for _, e := range q.entries {
+		if !e.job.Active() && ended > maxHistory {
+			ended--
+			continue
+		}
+		kept = append(kept, e)
+	}
+	q.entries = kept
+}
+
+// SetProgress records the progress of the running job
+func (q *Queue) SetProgress(phase string, percent float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.current != nil {
+		q.current.job.Phase, q.current.job.Progress = phase, percent
+	}
+}
+
+// List returns every remembered job, newest first
+func (q *Queue) List() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list := make([]Job, 0, len(q.entries))
+	for i := len(q.entries) - 1; i >= 0; i-- {
+		list = append(list, q.entries[i].job)
+	}
+	return list
+}
+
+// Get returns a job by id
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		if e.job.ID == id {
+			return e.job, true
+		}
+	}
+	return Job{}, false
+}
+
+// Cancel stops a job: a queued job never starts, a running one has its context canceled and
+// ends at its next checkpoint
+func (q *Queue) Cancel(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		if e.job.ID != id {
+			continue
+		}
+		switch e.job.Status {
+		case StatusQueued:
+			now := time.Now()
+			e.job.Status, e.job.Ended = StatusCanceled, &now
+			e.cancel()
+		case StatusPaused:
+			e.resume = nil
+			if e.job.Started == nil {
+				now := time.Now()
+				e.job.Status, e.job.Ended = StatusCanceled, &now
+			} else {
+				log.Printf("🛑 Canceling job %s (%s)", e.job.ID, e.job.Kind)
+				e.job.Status = StatusCanceling
+			}
+			e.cancel()
+		case StatusRunning:
+			log.Printf("🛑 Canceling job %s (%s)", e.job.ID, e.job.Kind)
+			e.job.Status = StatusCanceling
+			e.cancel()
+		case StatusCanceling: // Already stopping
+		default:
+			return e.job, ErrEnded
+		}
+		return e.job, nil
+	}
+	return Job{}, ErrNotFound
+}
+
+// Pause holds a pausable job: a running one stops at its next checkpoint (see Wait) until
+// Resume, a queued one does not start and lets the jobs behind it run first
+func (q *Queue) Pause(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := q.find(id)
+	switch {
+	case e == nil:
+		return Job{}, ErrNotFound
+	case !e.job.Pausable:
+		return e.job, ErrNotPausable
+	case e.job.Status == StatusPaused:
+		return e.job, nil
+	case e.job.Status != StatusQueued && e.job.Status != StatusRunning:
+		return e.job, ErrEnded
+	}
+	log.Printf("⏸️  Pausing job %s (%s)", e.job.ID, e.job.Kind)
+	e.job.Status, e.resume = StatusPaused, make(chan struct{})
+	return e.job, nil
+}
+
+// Resume lets a paused job go on, or start when it had not yet
+func (q *Queue) Resume(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := q.find(id)
+	switch {
+	case e == nil:
+		return Job{}, ErrNotFound
+	case e.job.Status == StatusQueued || e.job.Status == StatusRunning:
+		return e.job, nil
+	case e.job.Status != StatusPaused:
+		return e.job, ErrEnded
+	}
+	log.Printf("▶️  Resuming job %s (%s)", e.job.ID, e.job.Kind)
+	close(e.resume)
+	e.resume = nil
+	if e.job.Started == nil {
+		e.job.Status = StatusQueued
+		q.start()
+	} else {
+		e.job.Status = StatusRunning
+	}
+	return e.job, nil
+}
+
+// find returns the entry of a job, nil when there is none. Caller holds q.mu.
+func (q *Queue) find(id string) *entry {
+	for _, e := range q.entries {
+		if e.job.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// Wait is the checkpoint of a pausable job: it blocks while the job is paused and returns
+// ctx.Err(), so that a job canceled meanwhile stops. Outside of a queued job it only returns
+// ctx.Err().
+func Wait(ctx context.Context) error {
+	if g, ok := ctx.Value(gateKey{}).(*gate); ok {
+		g.q.mu.Lock()
+		resume := g.e.resume
+		g.q.mu.Unlock()
+		if resume != nil {
+			select {
+			case <-resume:
+			case <-ctx.Done():
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// Paused reports whether the job running with ctx is paused
+func Paused(ctx context.Context) bool {
+	g, ok := ctx.Value(gateKey{}).(*gate)
+	if !ok {
+		return false
+	}
+	g.q.mu.Lock()
+	defer g.q.mu.Unlock()
+	return g.e.resume != nil
+}
@@ -0,0 +1,245 @@
+package journal
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/paths"
+	"archive-duplicate-finder/internal/reporter"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the journal
+const (
+	ActionTrash  = "trash"
+	ActionDelete = "delete"
+	ActionVerify = "verify"
+	ActionMove   = "move"   // Kept file moved into the organized library
+	ActionLink   = "link"   // Removed copy replaced by a link to the kept file
+	ActionRename = "rename" // Variant renamed to its canonical name
+
+	ActionQuarantine = "quarantine" // Removed copy moved into the quarantine
+	ActionRestore    = "restore"    // Quarantined file moved back to its original location
+	ActionPurge      = "purge"      // Quarantined file deleted for good
+
+	ActionIgnore   = "ignore"   // Group marked as good, hidden from later reports
+	ActionUnignore = "unignore" // Ignored group brought back
+)
+
+// SourceDashboard marks the entries of actions taken in the web UI
+const SourceDashboard = "dashboard"
+
+// Results of a sample verification
+const (
+	ResultOK       = "ok"
+	ResultMismatch = "mismatch"
+	ResultError    = "error"
+)
+
+// Entry is one line of the cleanup journal
+type Entry struct {
+	Time       string `json:"time"`
+	Run        string `json:"run"` // Identifies the cleanup run the entry belongs to
+	Action     string `json:"action"`
+	Path       string `json:"path"`                  // File removed (or, for verify, the kept file checked)
+	Dest       string `json:"dest,omitempty"`        // Trash location, new location of a moved file, or link target
+	Size       int64  `json:"size,omitempty"`        // Size of the removed file
+	Kept       string `json:"kept,omitempty"`        // Copy that was preserved
+	KeptSHA256 string `json:"kept_sha256,omitempty"` // Content hash of the preserved copy when the decision was made
+	Auto       bool   `json:"auto,omitempty"`        // Resolved without confirmation (-yes)
+	Result     string `json:"result,omitempty"`      // Verify entries: ok, mismatch or error
+	Detail     string `json:"detail,omitempty"`
+	Source     string `json:"source,omitempty"`   // SourceDashboard, or empty for the CLI
+	GroupID    string `json:"group_id,omitempty"` // Stable ID of the group the file belonged to
+	SHA256     string `json:"sha256,omitempty"`   // Content hash of the removed file, read before it went away
+}
+
+var (
+	mu sync.Mutex
+
+	// Operations log: every entry but verifications is copied there (see SetOperationsLog)
+	opsCache *db.Cache
+	opsFile  string
+
+	// observer sees every entry appended (see SetObserver)
+	observer func(Entry)
+)
+
+// SetOperationsLog copies every file operation and ignore appended from now on into the
+// append-only operations table of the cache (when not nil) and, when file is set, into a JSONL
+// file of its own that can be kept anywhere, e.g. next to the library
+func SetOperationsLog(cache *db.Cache, file string) {
+	mu.Lock()
+	defer mu.Unlock()
+	opsCache, opsFile = cache, file
+}
+
+// SetObserver calls fn with every entry appended from now on, verifications included, e.g. to
+// stream the actions of a run. fn must not append entries itself.
+func SetObserver(fn func(Entry)) {
+	mu.Lock()
+	defer mu.Unlock()
+	observer = fn
+}
+
+// Path returns the location of the journal, next to the cache database
+func Path() string {
+	return paths.Journal()
+}
+
+// NewRun returns an identifier for the entries of one cleanup run
+func NewRun() string {
+	return time.Now().Format("20060102-150405.000")
+}
+
+// Append adds an entry to the journal. The file is only ever appended to.
+func Append(e Entry) error {
+	if e.Time == "" {
+		e.Time = time.Now().Format(time.RFC3339)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if observer != nil {
+		observer(e)
+	}
+	err = appendLine(Path(), data)
+	if e.Action == ActionVerify {
+		return err
+	}
+	if opsFile != "" {
+		if opsErr := appendLine(opsFile, data); err == nil {
+			err = opsErr
+		}
+	}
+	if opsCache != nil {
+		opsErr := opsCache.AppendOperation(db.Operation{
+			Time: e.Time, Source: e.Source, Run: e.Run, Action: e.Action, Path: e.Path, Dest: e.Dest,
+			GroupID: e.GroupID, Size: e.Size, SHA256: e.SHA256, Kept: e.Kept, Detail: e.Detail,
+		})
+		if err == nil {
+			err = opsErr
+		}
+	}
+	return err
+}
+
+// AppendGroup journals an ignore or unignore of a group, one entry per member so the log can
+// be searched by path. hash is the member hash the ignore is stored under.
+func AppendGroup(action, source, groupID, hash string, files []reporter.FileInfo) error {
+	if len(files) == 0 { // Ignored before members were remembered
+		return Append(Entry{Action: action, Source: source, GroupID: groupID, Detail: "group " + hash})
+	}
+	var err error
+	for _, f := range files {
+		e := Entry{Action: action, Source: source, Path: f.Path, Size: f.Size, GroupID: groupID, Detail: fmt.Sprintf("group %s (%d files)", hash, len(files))}
+		if appendErr := Append(e); err == nil {
+			err = appendErr
+		}
+	}
+	return err
+}
+
+// appendLine appends one JSON line to a file, creating it and its folder if needed
+func appendLine(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load returns the journal entries of a run (all entries when run is empty)
+func Load(run string) ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	f, err := os.Open(Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue // A torn line from an interrupted write
+		}
+		if run == "" || e.Run == run {
+			entries = append(entries, e)
+		}
+	}
+	return entries, sc.Err()
+}
+
+// Verification is the outcome of one sampled check
+type Verification struct {
+	Kept   string
+	Result string
+	Detail string
+}
+
+// VerifySample re-reads the kept file of a random percentage of the automatically resolved
+// decisions of a run and compares it with the hash journaled when the decision was made.
+// Every result is journaled as well. At least one decision is checked when percent > 0.
+func VerifySample(run string, percent float64) ([]Verification, error) {
+	entries, err := Load(run)
+	if err != nil {
+		return nil, err
+	}
+
+	// One check per kept file: a group with several removed copies shares the same survivor
+	var candidates []Entry
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if !e.Auto || e.KeptSHA256 == "" || e.Action == ActionVerify || seen[e.Kept] {
+			continue
+		}
+		seen[e.Kept] = true
+		candidates = append(candidates, e)
+	}
+	if len(candidates) == 0 || percent <= 0 {
+		return nil, nil
+	}
+
+	n := max(1, min(int(math.Ceil(float64(len(candidates))*percent/100)), len(candidates)))
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+	results := make([]Verification, 0, n)
+	for _, e := range candidates[:n] {
+		v := Verification{Kept: e.Kept, Result: ResultOK}
+		hash, err := hashing.FileSHA256(e.Kept) // Full read, never the hash cache
+		switch {
+		case err != nil:
+			v.Result, v.Detail = ResultError, err.Error()
+		case hash != e.KeptSHA256:
+			v.Result, v.Detail = ResultMismatch, fmt.Sprintf("expected %s, found %s", e.KeptSHA256, hash)
+		}
+		results = append(results, v)
+		_ = Append(Entry{Run: run, Action: ActionVerify, Path: e.Kept, KeptSHA256: e.KeptSHA256, Result: v.Result, Detail: v.Detail})
+	}
+	return results, nil
+}
@@ -0,0 +1,233 @@
+// Package manifest writes and verifies checksum manifests of a library in the formats of
+// sha256sum, md5sum and SFV, so archives kept after a cleanup can be checked for bit rot later
+package manifest
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/vfs"
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats of the manifests
+const (
+	FormatSHA256 = "sha256" // SHA256SUMS, checked by sha256sum -c
+	FormatMD5    = "md5"    // MD5SUMS, checked by md5sum -c
+	FormatSFV    = "sfv"    // Simple File Verification: "name CRC32" lines
+)
+
+// Formats lists the manifest formats
+var Formats = []string{FormatSHA256, FormatMD5, FormatSFV}
+
+// Entry is a file listed in a manifest: its path relative to the manifest's folder, with
+// forward slashes, and its checksum in lowercase hex
+type Entry struct {
+	Path string
+	Sum  string
+}
+
+// Result is the outcome of checking a manifest against the files on disk
+type Result struct {
+	Verified int      // Files whose checksum still matches
+	Failed   []string // Files whose contents changed or could not be read
+	Missing  []string // Files listed but gone
+}
+
+// FileName is the conventional name of a manifest of the format
+func FileName(format string) string {
+	switch format {
+	case FormatMD5:
+		return "MD5SUMS"
+	case FormatSFV:
+		return "checksums.sfv"
+	}
+	return "SHA256SUMS"
+}
+
+// Valid reports whether format is one of Formats
+func Valid(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func newHash(format string) hash.Hash {
+	switch format {
+	case FormatMD5:
+		return md5.New()
+	case FormatSFV:
+		return crc32.NewIEEE()
+	}
+	return sha256.New()
+}
+
+// Sum reads a file whole and returns its checksum in the format
+func Sum(format, path string) (string, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := newHash(format)
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Read parses a manifest. Comments (";" in SFV, "#" in the others) and blank lines are
+// skipped; sha256sum's binary marker and escaped names are understood.
+func Read(filename, format string) ([]Entry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var e Entry
+		if format == FormatSFV {
+			i := strings.LastIndexByte(line, ' ')
+			if i <= 0 {
+				return nil, fmt.Errorf("%s:%d: not an SFV line", filename, n)
+			}
+			e = Entry{Path: strings.TrimRight(line[:i], " "), Sum: strings.ToLower(line[i+1:])}
+		} else {
+			escaped := strings.HasPrefix(line, `\`)
+			if escaped {
+				line = line[1:]
+			}
+			sum, name, ok := strings.Cut(line, " ")
+			if !ok || len(name) == 0 {
+				return nil, fmt.Errorf("%s:%d: not a checksum line", filename, n)
+			}
+			// " name" in text mode, "*name" in binary mode
+			name = name[1:]
+			if escaped {
+				name = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(name)
+			}
+			e = Entry{Path: name, Sum: strings.ToLower(sum)}
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Write replaces a manifest with the entries, sorted by path
+func Write(filename, format string, entries []Entry) error {
+	sorted := append([]Entry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	var b strings.Builder
+	if format == FormatSFV {
+		b.WriteString(fmt.Sprintf("; Generated by Archive Duplicate Finder on %s\n", time.Now().Format(time.RFC3339)))
+	}
+	for _, e := range sorted {
+		switch {
+		case format == FormatSFV:
+			b.WriteString(fmt.Sprintf("%s %s\n", e.Path, strings.ToUpper(e.Sum)))
+		case strings.ContainsAny(e.Path, "\\\n"):
+			// sha256sum's escaping of names holding a backslash or a line break
+			name := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(e.Path)
+			b.WriteString(fmt.Sprintf("\\%s  %s\n", e.Sum, name))
+		default:
+			b.WriteString(fmt.Sprintf("%s  %s\n", e.Sum, e.Path))
+		}
+	}
+	if err := fsutil.WriteFileAtomic(filename, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// Add computes the entries of new files under root with sum, on workers goroutines. Files that
+// cannot be read are returned apart.
+func Add(root string, paths []string, workers int, sum func(path string) (string, error)) ([]Entry, []string) {
+	var entries []Entry
+	var failed []string
+	var mu sync.Mutex
+	each(paths, workers, func(path string) {
+		rel, err := filepath.Rel(root, path)
+		var s string
+		if err == nil {
+			s, err = sum(path)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed = append(failed, path)
+			return
+		}
+		entries = append(entries, Entry{Path: filepath.ToSlash(rel), Sum: s})
+	})
+	sort.Strings(failed)
+	return entries, failed
+}
+
+// Check reads every listed file under root again and compares it with its checksum, on workers
+// goroutines. Cached hashes are never used: the point is to notice files that changed on disk.
+func Check(root, format string, entries []Entry, workers int) Result {
+	var res Result
+	var mu sync.Mutex
+	each(entries, workers, func(e Entry) {
+		sum, err := Sum(format, filepath.Join(root, filepath.FromSlash(e.Path)))
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case os.IsNotExist(err):
+			res.Missing = append(res.Missing, e.Path)
+		case err != nil || sum != e.Sum:
+			res.Failed = append(res.Failed, e.Path)
+		default:
+			res.Verified++
+		}
+	})
+	sort.Strings(res.Failed)
+	sort.Strings(res.Missing)
+	return res
+}
+
+// each runs fn on every item, on workers goroutines
+func each[T any](items []T, workers int, fn func(T)) {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan T)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				fn(item)
+			}
+		}()
+	}
+	for _, item := range items {
+		jobs <- item
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,176 @@
+// Package membudget accounts for the large buffers of extraction-heavy work (archive entries,
+// decoded previews, parsed models) against a global memory budget. Operations that would take
+// the process past the budget wait for others to finish, or spill what they read to a temporary
+// file, instead of getting it killed on a small machine.
+package membudget
+
+import (
+	"archive-duplicate-finder/internal/paths"
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// chunk is how much ReadAll reserves at a time while the size of what it reads is unknown
+const chunk = 1 << 20
+
+var (
+	mu     sync.Mutex
+	room   = sync.NewCond(&mu)
+	budget int64 // 0 is unlimited
+	inUse  int64
+	peak   int64
+	waits  int64
+	spills int64
+)
+
+// Usage is the state of the memory budget
+type Usage struct {
+	BudgetBytes int64 `json:"budget_bytes"` // 0 is unlimited
+	InUseBytes  int64 `json:"in_use_bytes"`
+	PeakBytes   int64 `json:"peak_bytes"`
+	Waits       int64 `json:"waits"`  // Operations that waited for memory to be given back
+	Spills      int64 `json:"spills"` // Entries read through a temporary file
+}
+
+// SetBudget sets how many bytes the accounted buffers may take together; 0 lifts the limit.
+// Memory already handed out is not taken back.
+func SetBudget(bytes int64) {
+	mu.Lock()
+	budget = max(bytes, 0)
+	mu.Unlock()
+	room.Broadcast()
+}
+
+// Stats returns the budget, what is accounted now and the most ever accounted at once
+func Stats() Usage {
+	mu.Lock()
+	defer mu.Unlock()
+	return Usage{BudgetBytes: budget, InUseBytes: inUse, PeakBytes: peak, Waits: waits, Spills: spills}
+}
+
+// fits reports whether n more bytes stay within the budget. Nothing accounted means anything
+// fits: a request larger than the whole budget runs alone rather than never.
+func fits(n int64) bool {
+	return budget <= 0 || inUse == 0 || inUse+n <= budget
+}
+
+func take(n int64) {
+	inUse += n
+	peak = max(peak, inUse)
+}
+
+func give(n int64) {
+	if n <= 0 {
+		return
+	}
+	mu.Lock()
+	inUse -= n
+	mu.Unlock()
+	room.Broadcast()
+}
+
+// Acquire accounts for n bytes, waiting until they fit in the budget, and returns the function
+// that gives them back. Callers must not wait for another reservation while holding one.
+func Acquire(n int64) (release func()) {
+	if n <= 0 {
+		return func() {}
+	}
+	mu.Lock()
+	if !fits(n) {
+		waits++
+		for !fits(n) {
+			room.Wait()
+		}
+	}
+	take(n)
+	mu.Unlock()
+	var once sync.Once
+	return func() { once.Do(func() { give(n) }) }
+}
+
+// tryTake accounts for n bytes only if they fit now
+func tryTake(n int64) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if !fits(n) {
+		return false
+	}
+	take(n)
+	return true
+}
+
+// ReadAll reads r to the end like io.ReadAll, accounting for the buffer as it grows. When the
+// budget runs out it moves what it has read to a temporary file and goes on reading there
+// without holding memory, then waits for room for the whole content and loads it back. The
+// memory is accounted while it is read; the result belongs to the caller.
+func ReadAll(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	var held int64
+	defer func() { give(held) }()
+	for {
+		if !tryTake(chunk) {
+			return spill(r, &buf, &held)
+		}
+		held += chunk
+		_, err := io.CopyN(&buf, r, chunk)
+		if err == io.EOF {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// spill writes buf and the rest of r to a temporary file in the cache folder (the system temp
+// folder may live in memory), gives back the memory held for buf, and reads the file back
+// once its size fits in the budget
+func spill(r io.Reader, buf *bytes.Buffer, held *int64) ([]byte, error) {
+	dir := filepath.Join(paths.CacheDir(), "spill")
+	os.MkdirAll(dir, 0755)
+	f, err := os.CreateTemp(dir, "entry-*")
+	if err != nil {
+		return nil, fmt.Errorf("memory budget exhausted and no temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	mu.Lock()
+	spills++
+	mu.Unlock()
+
+	size, err := buf.WriteTo(f)
+	if err != nil {
+		return nil, err
+	}
+	*buf = bytes.Buffer{} // Let the collector have it
+	give(*held)
+	*held = 0
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return nil, err
+	}
+	size += n
+
+	release := Acquire(size)
+	defer release()
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ImageBytes estimates the memory an encoded image takes once decoded (4 bytes per pixel),
+// reading only its header; data it cannot read counts as its own size
+func ImageBytes(data []byte) int64 {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return int64(len(data))
+	}
+	return int64(cfg.Width) * int64(cfg.Height) * 4
+}
@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Events a target can subscribe to
+const (
+	EventScanFinished   = "scan_finished"
+	EventStep3Finished  = "step3_finished"
+	EventVisualFinished = "visual_finished"
+)
+
+// Target is one configured notification destination. Title and Template are Go templates
+// rendered with an Event; empty values use the built-in summary.
+type Target struct {
+	Type     string   `json:"type"`               // Registered provider: webhook, smtp, discord, telegram, ntfy, gotify
+	URL      string   `json:"url,omitempty"`      // Webhook / Discord webhook / ntfy topic / Gotify server / Telegram API base
+	Token    string   `json:"token,omitempty"`    // Telegram bot token, Gotify app token, ntfy access token
+	ChatID   string   `json:"chat_id,omitempty"`  // Telegram chat
+	Host     string   `json:"host,omitempty"`     // SMTP server
+	Port     int      `json:"port,omitempty"`     // SMTP port (default 587)
+	Username string   `json:"username,omitempty"` // SMTP login
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Template string   `json:"template,omitempty"`
+	Events   []string `json:"events,omitempty"` // Empty subscribes to every event
+}
+
+// Event is the data available to message templates
+type Event struct {
+	Name      string // One of the Event* constants
+	Directory string
+	Report    reporter.Report
+}
+
+// Message is a rendered notification
+type Message struct {
+	Event string
+	Title string
+	Body  string
+}
+
+// Provider delivers rendered messages to one destination
+type Provider interface {
+	Send(msg Message) error
+}
+
+// ProviderFactory builds a provider from its configuration, rejecting incomplete settings
+type ProviderFactory func(t Target) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ProviderFactory{}
+)
+
+// RegisterProvider makes a provider available by name to notification targets.
+// Registering an existing name replaces the previous implementation.
+func RegisterProvider(name string, factory ProviderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// ProviderNames returns the names of all registered providers
+func ProviderNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that every target uses a known provider with complete settings and valid templates
+func Validate(targets []Target) error {
+	for i, t := range targets {
+		if _, err := newProvider(t); err != nil {
+			return fmt.Errorf("notification %d: %w", i+1, err)
+		}
+		if _, err := t.render(Event{}); err != nil {
+			return fmt.Errorf("notification %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Dispatch renders the event for every subscribed target and sends it. Failures are logged,
+// never returned: a broken chat integration must not fail an analysis.
+func Dispatch(targets []Target, ev Event) {
+	for _, t := range targets {
+		if !t.subscribed(ev.Name) {
+			continue
+		}
+		p, err := newProvider(t)
+		if err != nil {
+			log.Printf("⚠️  Notification skipped: %v", err)
+			continue
+		}
+		msg, err := t.render(ev)
+		if err != nil {
+			log.Printf("⚠️  Notification template error (%s): %v", t.Type, err)
+			continue
+		}
+		if err := p.Send(msg); err != nil {
+			log.Printf("⚠️  Notification via %s failed: %v", t.Type, err)
+			continue
+		}
+		log.Printf("🔔 Notification sent via %s (%s)", t.Type, ev.Name)
+	}
+}
+
+func newProvider(t Target) (Provider, error) {
+	registryMu.RLock()
+	factory, ok := registry[t.Type]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown notification type '%s' (available: %s)", t.Type, strings.Join(ProviderNames(), ", "))
+	}
+	return factory(t)
+}
+
+func (t Target) subscribed(event string) bool {
+	if len(t.Events) == 0 {
+		return true
+	}
+	for _, e := range t.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	defaultTitle = `Archive Duplicate Finder: {{eventLabel .Name}}`
+	defaultBody  = `{{.Directory}}
+{{.Report.TotalFiles}} archives | {{len .Report.SizeGroups}} identical-size groups | {{len .Report.SimilarGroups}} similar-name clusters | {{len .Report.VisualGroups}} visual matches
+{{- if .Report.CorruptCount}} | {{.Report.CorruptCount}} corrupt{{end}}
+Reclaimable: {{bytes (reclaimable .Report)}}`
+)
+
+var templateFuncs = template.FuncMap{
+	"bytes":       formatBytes,
+	"reclaimable": reclaimable,
+	"eventLabel":  eventLabel,
+}
+
+func (t Target) render(ev Event) (Message, error) {
+	title, err := renderTemplate("title", t.Title, defaultTitle, ev)
+	if err != nil {
+		return Message{}, err
+	}
+	body, err := renderTemplate("template", t.Template, defaultBody, ev)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Event: ev.Name, Title: title, Body: body}, nil
+}
+
+func renderTemplate(name, text, fallback string, ev Event) (string, error) {
+	if text == "" {
+		text = fallback
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ev); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// reclaimable is the space freed by keeping one file of every identical-size group
+func reclaimable(r reporter.Report) int64 {
+	var total int64
+	for _, g := range r.SizeGroups {
+		if len(g.Files) > 1 {
+			total += g.Size * int64(len(g.Files)-1)
+		}
+	}
+	return total
+}
+
+func eventLabel(event string) string {
+	switch event {
+	case EventScanFinished:
+		return "scan finished"
+	case EventStep3Finished:
+		return "similar name analysis finished"
+	case EventVisualFinished:
+		return "visual analysis finished"
+	default:
+		return event
+	}
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
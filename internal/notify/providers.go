@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+func init() {
+	RegisterProvider("webhook", newWebhook)
+	RegisterProvider("discord", newDiscord)
+	RegisterProvider("telegram", newTelegram)
+	RegisterProvider("ntfy", newNtfy)
+	RegisterProvider("gotify", newGotify)
+	RegisterProvider("smtp", newSMTP)
+}
+
+// post sends a request and turns non-2xx answers into errors
+func post(url, contentType string, body []byte, header map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func postJSON(url string, payload any, header map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return post(url, "application/json", body, header)
+}
+
+// requireFields checks name/value pairs in order and reports the first empty one
+func requireFields(kind string, pairs ...string) error {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			return fmt.Errorf("%s notification needs '%s'", kind, pairs[i])
+		}
+	}
+	return nil
+}
+
+// webhook posts the rendered message as JSON to any URL
+type webhook struct{ url string }
+
+func newWebhook(t Target) (Provider, error) {
+	if err := requireFields("webhook", "url", t.URL); err != nil {
+		return nil, err
+	}
+	return webhook{url: t.URL}, nil
+}
+
+func (w webhook) Send(msg Message) error {
+	return postJSON(w.url, map[string]string{"event": msg.Event, "title": msg.Title, "message": msg.Body}, nil)
+}
+
+// discord posts to a channel webhook URL
+type discord struct{ url string }
+
+const discordMaxContent = 2000
+
+func newDiscord(t Target) (Provider, error) {
+	if err := requireFields("discord", "url", t.URL); err != nil {
+		return nil, err
+	}
+	return discord{url: t.URL}, nil
+}
+
+func (d discord) Send(msg Message) error {
+	content := "**" + msg.Title + "**\n" + msg.Body
+	if len(content) > discordMaxContent {
+		content = content[:discordMaxContent-3] + "..."
+	}
+	return postJSON(d.url, map[string]string{"content": content}, nil)
+}
+
+// telegram sends through the Bot API; URL overrides the API base for self-hosted servers
+type telegram struct{ base, token, chatID string }
+
+func newTelegram(t Target) (Provider, error) {
+	if err := requireFields("telegram", "token", t.Token, "chat_id", t.ChatID); err != nil {
+		return nil, err
+	}
+	base := t.URL
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+	return telegram{base: strings.TrimSuffix(base, "/"), token: t.Token, chatID: t.ChatID}, nil
+}
+
+func (tg telegram) Send(msg Message) error {
+	return postJSON(tg.base+"/bot"+tg.token+"/sendMessage", map[string]string{
+		"chat_id": tg.chatID,
+		"text":    msg.Title + "\n\n" + msg.Body,
+	}, nil)
+}
+
+// ntfy publishes to a topic URL such as https://ntfy.sh/my-library
+type ntfy struct{ url, token string }
+
+func newNtfy(t Target) (Provider, error) {
+	if err := requireFields("ntfy", "url", t.URL); err != nil {
+		return nil, err
+	}
+	return ntfy{url: t.URL, token: t.Token}, nil
+}
+
+func (n ntfy) Send(msg Message) error {
+	header := map[string]string{"Title": msg.Title, "Tags": "package"}
+	if n.token != "" {
+		header["Authorization"] = "Bearer " + n.token
+	}
+	return post(n.url, "text/plain; charset=utf-8", []byte(msg.Body), header)
+}
+
+// gotify posts to the /message endpoint of a Gotify server with an application token
+type gotify struct{ url, token string }
+
+func newGotify(t Target) (Provider, error) {
+	if err := requireFields("gotify", "url", t.URL, "token", t.Token); err != nil {
+		return nil, err
+	}
+	return gotify{url: strings.TrimSuffix(t.URL, "/"), token: t.Token}, nil
+}
+
+func (g gotify) Send(msg Message) error {
+	return postJSON(g.url+"/message", map[string]any{
+		"title":    msg.Title,
+		"message":  msg.Body,
+		"priority": 5,
+	}, map[string]string{"X-Gotify-Key": g.token})
+}
+
+// smtpMail sends a plain-text e-mail, authenticating when a username is set
+type smtpMail struct {
+	addr, host, username, password, from string
+	to                                   []string
+}
+
+func newSMTP(t Target) (Provider, error) {
+	if err := requireFields("smtp", "host", t.Host, "from", t.From); err != nil {
+		return nil, err
+	}
+	if len(t.To) == 0 {
+		return nil, fmt.Errorf("smtp notification needs 'to'")
+	}
+	port := t.Port
+	if port == 0 {
+		port = 587
+	}
+	return smtpMail{
+		addr:     net.JoinHostPort(t.Host, strconv.Itoa(port)),
+		host:     t.Host,
+		username: t.Username,
+		password: t.Password,
+		from:     t.From,
+		to:       t.To,
+	}, nil
+}
+
+func (m smtpMail) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	var body strings.Builder
+	body.WriteString("From: " + m.from + "\r\n")
+	body.WriteString("To: " + strings.Join(m.to, ", ") + "\r\n")
+	body.WriteString("Subject: " + strings.ReplaceAll(msg.Title, "\n", " ") + "\r\n")
+	body.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n") + "\r\n")
+	return smtp.SendMail(m.addr, auth, m.from, m.to, []byte(body.String()))
+}
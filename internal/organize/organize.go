@@ -0,0 +1,408 @@
+package organize
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/trash"
+	"archive-duplicate-finder/internal/txn"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// What happens to the copies that are not kept
+const (
+	RestDelete = "delete"
+	RestTrash  = "trash"
+	RestLink   = "link" // Replaced by a link to the kept file
+)
+
+// DefaultLayout puts every kept archive directly in the library root under its own name
+const DefaultLayout = "{name}"
+
+// Options controls a move-and-merge run
+type Options struct {
+	Root      string // Canonical library directory
+	Layout    string // Path of the kept file under Root, e.g. "{initial}/{token1}/{name}"
+	Rest      string // RestDelete, RestTrash or RestLink
+	TrashPath string // Required for RestTrash
+	DryRun    bool   // Plan only, touch nothing
+	Run       string // Journal run of the actions
+	Auto      bool   // Journal the actions as unattended (eligible for sample verification)
+	Source    string // Journal source of the actions (journal.SourceDashboard), empty for the CLI
+}
+
+// Result is the outcome of one group
+type Result struct {
+	Group   string   `json:"group"`
+	Kept    string   `json:"kept"`              // Original location of the kept file
+	Dest    string   `json:"dest"`              // Location of the kept file after the run
+	Removed []string `json:"removed,omitempty"` // Copies deleted, trashed or replaced by links
+	Differs []string `json:"differs,omitempty"` // Same size but different content: left untouched
+	Error   string   `json:"error,omitempty"`
+}
+
+var tokenPattern = regexp.MustCompile(`\{([a-z]+)(\d*)\}`)
+
+// Validate checks the options before anything is moved
+func (o Options) Validate() error {
+	if o.Root == "" {
+		return fmt.Errorf("no library directory given")
+	}
+	switch o.Rest {
+	case RestDelete, RestLink:
+	case RestTrash:
+		if o.TrashPath == "" {
+			return fmt.Errorf("moving the other copies to the trash needs a trash folder")
+		}
+	default:
+		return fmt.Errorf("unknown action %q for the other copies (expected delete, trash or link)", o.Rest)
+	}
+	return ValidateLayout(o.Layout)
+}
+
+// ValidateLayout checks that a layout only uses known tokens
+func ValidateLayout(layout string) error {
+	if layout == "" {
+		return nil
+	}
+	for _, m := range tokenPattern.FindAllStringSubmatch(layout, -1) {
+		switch m[1] {
+		case "name", "stem", "ext", "type", "initial", "parent", "year":
+			if m[2] != "" {
+				return fmt.Errorf("layout token {%s%s} does not take a number", m[1], m[2])
+			}
+		case "token":
+			if n, _ := strconv.Atoi(m[2]); n < 1 {
+				return fmt.Errorf("layout token {token} needs a word number, e.g. {token1}")
+			}
+		default:
+			return fmt.Errorf("unknown layout token {%s%s}", m[1], m[2])
+		}
+	}
+	return nil
+}
+
+// Target returns where a kept file goes under root. Tokens: {name} file name, {stem} name
+// without extension, {ext}, {type}, {initial} first letter of the name, {tokenN} Nth word of
+// the name, {parent} current folder and {year} of modification. The extension is appended
+// when the layout does not end with it.
+func Target(root, layout string, f reporter.FileInfo) (string, error) {
+	if layout == "" {
+		layout = DefaultLayout
+	}
+	ext := strings.TrimPrefix(filepath.Ext(f.Name), ".")
+	stem := strings.TrimSuffix(f.Name, filepath.Ext(f.Name))
+	words := strings.FieldsFunc(stem, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+
+	rel := tokenPattern.ReplaceAllStringFunc(layout, func(tok string) string {
+		m := tokenPattern.FindStringSubmatch(tok)
+		var v string
+		switch m[1] {
+		case "name":
+			v = f.Name
+		case "stem":
+			v = stem
+		case "ext":
+			v = strings.ToLower(ext)
+		case "type":
+			v = f.Type
+		case "initial":
+			v = "#"
+			for _, r := range stem {
+				if unicode.IsLetter(r) {
+					v = strings.ToUpper(string(r))
+					break
+				}
+				if unicode.IsDigit(r) {
+					break
+				}
+			}
+		case "token":
+			if n, _ := strconv.Atoi(m[2]); n >= 1 && n <= len(words) {
+				v = words[n-1]
+			}
+		case "parent":
+			v = filepath.Base(filepath.Dir(f.Path))
+		case "year":
+			if t, err := time.Parse(time.RFC3339, f.ModTime); err == nil {
+				v = strconv.Itoa(t.Year())
+			}
+		}
+		return sanitize(v)
+	})
+
+	if ext != "" && !strings.HasSuffix(strings.ToLower(rel), "."+strings.ToLower(ext)) {
+		rel += "." + ext
+	}
+	root = filepath.Clean(root)
+	target := filepath.Join(root, filepath.FromSlash(rel))
+	if target == root || !strings.HasPrefix(target, root+string(filepath.Separator)) {
+		return "", fmt.Errorf("layout %q leaves the library directory for %s", layout, f.Name)
+	}
+	return target, nil
+}
+
+// sanitize makes a token value usable as (part of) a single path component
+func sanitize(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, v)
+	v = strings.TrimSpace(v)
+	if v == "" || v == "." || v == ".." {
+		return "_"
+	}
+	return v
+}
+
+// Preflight checks that the library, and the trash when the other copies go there, can take
+// the files Apply will copy into them (those coming from another filesystem), so a run that
+// would fill a disk is refused before anything is moved
+func Preflight(groups []reporter.ResolvedGroup, opts Options) []*fsutil.NoSpaceError {
+	var transfers []fsutil.Transfer
+	add := func(f reporter.FileInfo, dir string) {
+		for _, p := range paths(f) {
+			if info, err := os.Stat(p); err == nil {
+				transfers = append(transfers, fsutil.Transfer{Path: p, Dir: dir, Size: info.Size()})
+			}
+		}
+	}
+	for _, g := range groups {
+		if !g.Keep.Protected {
+			if dest, err := Target(opts.Root, opts.Layout, g.Keep); err == nil {
+				add(g.Keep, filepath.Dir(dest))
+			}
+		}
+		if opts.Rest == RestTrash {
+			for _, f := range g.Remove {
+				add(f, opts.TrashPath)
+			}
+		}
+	}
+	return fsutil.Preflight(transfers)
+}
+
+// Apply moves the kept file of every group into the library layout and deletes, trashes or
+// links the other copies. Copies are only removed when their content matches the kept file.
+// Each group runs as a transaction (see txn): a group where a file changed, a destination is
+// taken or a step fails is left as it was. Protected kept files stay where they are.
+func Apply(groups []reporter.ResolvedGroup, opts Options, cache *db.Cache) []Result {
+	results := make([]Result, 0, len(groups))
+	for _, g := range groups {
+		results = append(results, applyGroup(g, opts, cache))
+	}
+	return results
+}
+
+func applyGroup(g reporter.ResolvedGroup, opts Options, cache *db.Cache) Result {
+	res := Result{Group: g.Title, Kept: g.Keep.Path, Dest: g.Keep.Path}
+
+	// Sampled hashes tell most differing copies apart before anything is read whole
+	sampled := func(p string) (string, error) { return hashing.SampleHash(cache, p) }
+	full := func(p string) (string, error) { return hashing.FileHash(cache, p) }
+	keptSample, ok := contentHash(g.Keep, sampled)
+	if !ok {
+		res.Error = "could not read the kept file"
+		return res
+	}
+	keptHash := ""
+	var remove []reporter.FileInfo
+	for _, f := range g.Remove {
+		if sample, ok := contentHash(f, sampled); !ok || sample != keptSample {
+			res.Differs = append(res.Differs, f.Path)
+			continue
+		}
+		if keptHash == "" {
+			if keptHash, ok = contentHash(g.Keep, full); !ok {
+				res.Error = "could not read the kept file"
+				return res
+			}
+		}
+		if hash, ok := contentHash(f, full); ok && hash == keptHash {
+			remove = append(remove, f)
+		} else {
+			res.Differs = append(res.Differs, f.Path)
+		}
+	}
+
+	// The kept file moves and the copies go as one transaction: checked before anything is
+	// touched, and put back as they were when a step fails halfway
+	tx := txn.New(full)
+	var entries []*journal.Entry
+	if !g.Keep.Protected {
+		dest, err := Target(opts.Root, opts.Layout, g.Keep)
+		if err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		if !samePath(dest, g.Keep.Path) {
+			var targets []string
+			dest, targets, err = planKept(g.Keep, dest)
+			if err != nil {
+				res.Error = err.Error()
+				return res
+			}
+			for i, p := range targets {
+				part := paths(g.Keep)[i]
+				hash := ""
+				if keptHash != "" {
+					hash, _ = full(part) // Read while the copies were compared
+				}
+				tx.Move(part, p, hash)
+				entries = append(entries, &journal.Entry{Run: opts.Run, Source: opts.Source, Action: journal.ActionMove, Path: part, Dest: p, Auto: opts.Auto})
+			}
+		}
+		res.Dest = dest
+	}
+
+	var removed []string
+	for _, f := range remove {
+		for _, path := range paths(f) {
+			hash, _ := full(path) // Read while the copy was compared, so served from the cache
+			entries = append(entries, removeCopy(tx, path, hash, res.Dest, keptHash, opts))
+			removed = append(removed, path)
+		}
+	}
+	if !opts.DryRun {
+		if err := tx.Run(); err != nil {
+			res.Error = err.Error()
+			res.Dest = g.Keep.Path
+			return res
+		}
+		for _, e := range entries {
+			appendJournal(*e)
+		}
+	}
+	res.Removed = removed
+	return res
+}
+
+// planKept picks where every part of the kept archive goes next to dest, a free name on
+// collision, and returns the new location of the archive with the part targets (none when
+// the parts stay where they are)
+func planKept(keep reporter.FileInfo, dest string) (string, []string, error) {
+	parts := paths(keep)
+	dir := filepath.Dir(dest)
+	targets := make([]string, len(parts))
+	if len(parts) == 1 {
+		dest = freeName(dest)
+		targets[0] = dest
+		return dest, targets, nil
+	}
+	// Split sets keep their part names; the layout only chooses the folder
+	if samePath(dir, filepath.Dir(parts[0])) {
+		return parts[0], nil, nil
+	}
+	for i, p := range parts {
+		targets[i] = filepath.Join(dir, filepath.Base(p))
+		if _, err := os.Lstat(targets[i]); err == nil {
+			return "", nil, fmt.Errorf("destination already exists: %s", targets[i])
+		}
+	}
+	return targets[0], targets, nil
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// freeName returns path, or "name (2).ext", "name (3).ext"... if it is taken
+func freeName(path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	candidate := path
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
+
+// removeCopy adds the steps deleting, trashing or linking one part of a duplicate copy to
+// tx, and returns its journal entry, completed as the steps run
+func removeCopy(tx *txn.Tx, path, hash, kept, keptHash string, opts Options) *journal.Entry {
+	entry := &journal.Entry{Run: opts.Run, Source: opts.Source, Action: journal.ActionDelete, Path: path, SHA256: hash, Kept: kept, KeptSHA256: keptHash, Auto: opts.Auto}
+	if info, err := os.Stat(path); err == nil {
+		entry.Size = info.Size()
+	}
+
+	switch opts.Rest {
+	case RestTrash:
+		tx.Require(path, hash)
+		tx.Step(func() error {
+			dest, err := trash.Move(opts.TrashPath, path, nil)
+			if err != nil {
+				return fmt.Errorf("could not move %s to the trash: %w", path, err)
+			}
+			entry.Action, entry.Dest = journal.ActionTrash, dest
+			return nil
+		}, func() error {
+			return fsutil.MoveFile(entry.Dest, path, nil)
+		})
+	case RestLink:
+		target, err := filepath.Abs(kept)
+		if err != nil {
+			target = kept
+		}
+		tx.Delete(path, hash)
+		tx.Step(func() error {
+			// Symbolic links need extra rights on Windows; a hard link works on the same volume
+			if err := os.Symlink(target, path); err != nil {
+				if err := os.Link(target, path); err != nil {
+					return fmt.Errorf("could not link %s: %w", path, err)
+				}
+			}
+			entry.Action, entry.Dest = journal.ActionLink, target
+			return nil
+		}, func() error {
+			return os.Remove(path)
+		})
+	default:
+		tx.Delete(path, hash)
+	}
+	return entry
+}
+
+func appendJournal(e journal.Entry) {
+	if err := journal.Append(e); err != nil {
+		fmt.Printf("     ⚠️  Could not write the cleanup journal: %v\n", err)
+	}
+}
+
+// paths returns every part of an archive (all volumes of a split set)
+func paths(f reporter.FileInfo) []string {
+	if len(f.Volumes) > 0 {
+		return f.Volumes
+	}
+	return []string{f.Path}
+}
+
+// contentHash hashes every part of an archive; false when a part cannot be read
+func contentHash(f reporter.FileInfo, hash func(path string) (string, error)) (string, bool) {
+	var hashes []string
+	for _, p := range paths(f) {
+		h, err := hash(p)
+		if err != nil {
+			return "", false
+		}
+		hashes = append(hashes, h)
+	}
+	return strings.Join(hashes, ","), true
+}
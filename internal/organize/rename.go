@@ -0,0 +1,80 @@
+package organize
+
+import (
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/similarity"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Rename is a suggested canonical name for one member of a cluster of variants
+type Rename struct {
+	Group     string `json:"group"`
+	Path      string `json:"path"`
+	Suggested string `json:"suggested"` // New file name
+	Dest      string `json:"dest"`      // New full path, in the same folder
+	Version   string `json:"version,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SuggestRenames proposes canonical names for the members of similar-name clusters so that
+// variants converge on "<base name> <version>.<ext>". Series, split sets, protected files,
+// members that already follow the scheme and members whose name would collide are left out.
+func SuggestRenames(groups []reporter.SimilarityGroup) []Rename {
+	var renames []Rename
+	for _, g := range groups {
+		if g.Series {
+			continue // Parts of a series already differ by their part number
+		}
+		names := make([]string, len(g.Files))
+		for i, f := range g.Files {
+			names[i] = f.Name
+		}
+		for i, s := range similarity.SuggestCanonicalNames(names) {
+			f := g.Files[i]
+			if s.Conflict || s.Suggested == f.Name || len(f.Volumes) > 0 || f.Protected {
+				continue
+			}
+			renames = append(renames, Rename{
+				Group:     g.BaseName,
+				Path:      f.Path,
+				Suggested: s.Suggested,
+				Dest:      filepath.Join(filepath.Dir(f.Path), s.Suggested),
+				Version:   s.Version,
+			})
+		}
+	}
+	return renames
+}
+
+// ApplyRenames renames the files, never overwriting an existing one. With dryRun it only
+// reports the renames that would fail. run and source are journaled with the renames.
+func ApplyRenames(renames []Rename, dryRun bool, run, source string) []Rename {
+	results := make([]Rename, len(renames))
+	for i, r := range renames {
+		if err := renameFile(r.Path, r.Dest, dryRun); err != nil {
+			r.Error = err.Error()
+		} else if !dryRun {
+			appendJournal(journal.Entry{Run: run, Source: source, Action: journal.ActionRename, Path: r.Path, Dest: r.Dest})
+		}
+		results[i] = r
+	}
+	return results
+}
+
+func renameFile(from, to string, dryRun bool) error {
+	src, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	// A case-only rename finds the file itself on case-insensitive file systems
+	if dst, err := os.Lstat(to); err == nil && !os.SameFile(src, dst) {
+		return fmt.Errorf("destination already exists: %s", to)
+	}
+	if dryRun {
+		return nil
+	}
+	return os.Rename(from, to)
+}
@@ -0,0 +1,166 @@
+// Package paths places the files of the finder in the per-user locations of each platform
+// (XDG base directories on Linux), so system-wide installs and containers work without
+// writing next to the executable.
+package paths
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appDir is the folder of the finder inside each base directory
+const appDir = "archive-duplicate-finder"
+
+// File names, unchanged from the locations of earlier versions
+const (
+	settingsFile = "archive-finder-settings.json"
+	cacheFile    = "archive-finder-cache.db"
+	journalFile  = "archive-finder-journal.jsonl"
+)
+
+// ConfigDir holds the settings: $XDG_CONFIG_HOME (~/.config), %AppData% or
+// ~/Library/Application Support
+func ConfigDir() string {
+	return under(os.UserConfigDir)
+}
+
+// DataDir holds what the finder cannot rebuild, the cache database (ignored groups) and the
+// operations journal: $XDG_DATA_HOME (~/.local/share), %LocalAppData% or
+// ~/Library/Application Support
+func DataDir() string {
+	return under(userDataDir)
+}
+
+// UserDataHome is the base folder of per-user application data, where the desktop entries and
+// icons of Linux go: $XDG_DATA_HOME (~/.local/share), %LocalAppData% or ~/Library/Application
+// Support
+func UserDataHome() (string, error) {
+	return userDataDir()
+}
+
+// CacheDir holds what can be deleted at any time, e.g. extracted previews: $XDG_CACHE_HOME
+// (~/.cache), %LocalAppData% or ~/Library/Caches
+func CacheDir() string {
+	return under(os.UserCacheDir)
+}
+
+// Settings is the default settings file
+func Settings() string {
+	return current(filepath.Join(ConfigDir(), settingsFile), legacySettings())
+}
+
+// Cache is the cache database
+func Cache() string {
+	return current(filepath.Join(DataDir(), cacheFile), legacyData(cacheFile))
+}
+
+// Journal is the operations journal
+func Journal() string {
+	return current(filepath.Join(DataDir(), journalFile), legacyData(journalFile))
+}
+
+// Trash is the trash folder suggested to new installs
+func Trash() string {
+	return filepath.Join(DataDir(), "trash")
+}
+
+// Move is a file moved by Migrate
+type Move struct {
+	From, To string
+}
+
+// Migrate moves the settings, cache database and journal of earlier versions (next to the
+// executable and at the top of the user config folder) to their current locations. Files
+// already in place are never overwritten. A failed move leaves the old file in use.
+func Migrate() ([]Move, error) {
+	var moves []Move
+	var errs []error
+	move := func(from, to string) bool {
+		if from == "" || !exists(from) || exists(to) {
+			return false
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		if err := fsutil.MoveFile(from, to, nil); err != nil {
+			errs = append(errs, err)
+			return false
+		}
+		moves = append(moves, Move{from, to})
+		return true
+	}
+
+	move(legacySettings(), filepath.Join(ConfigDir(), settingsFile))
+	if move(legacyData(cacheFile), filepath.Join(DataDir(), cacheFile)) {
+		// SQLite keeps recent writes in its -wal file: it goes along with the database
+		for _, suffix := range []string{"-wal", "-shm"} {
+			move(legacyData(cacheFile+suffix), filepath.Join(DataDir(), cacheFile+suffix))
+		}
+	}
+	move(legacyData(journalFile), filepath.Join(DataDir(), journalFile))
+	return moves, errors.Join(errs...)
+}
+
+// current returns path, or the location of an earlier version while the file is still there
+func current(path, legacy string) string {
+	if legacy != "" && !exists(path) && exists(legacy) {
+		return legacy
+	}
+	return path
+}
+
+func legacySettings() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(exePath), settingsFile)
+}
+
+func legacyData(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// under returns the finder's folder in the base directory of base, falling back to the
+// working directory
+func under(base func() (string, error)) string {
+	dir, err := base()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(dir, appDir)
+}
+
+// userDataDir is the counterpart of os.UserConfigDir for application data
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%LocalAppData% is not defined")
+	case "darwin", "ios":
+		return os.UserConfigDir() // ~/Library/Application Support
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,80 @@
+package profile
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"context"
+	"log"
+	"sort"
+)
+
+// groupByOverlap groups the archives sharing at least minOverlap percent of their entries
+// (paths, sizes, CRCs) with the first archive of the group, the way the visual analysis
+// groups previews: a repack that added a readme or dropped a thumbnail still pairs with the
+// original. Only groups whose listings are all the same are verified.
+func groupByOverlap(ctx context.Context, files []scanner.ArchiveFile, minOverlap int) []reporter.SizeGroup {
+	type listing struct {
+		file    scanner.ArchiveFile
+		entries map[string]bool
+	}
+	var listings []listing
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return nil
+		}
+		lines, err := manifestLines(f.Path)
+		if err != nil {
+			log.Printf("⚠️  Could not read %s: %v", f.Name, err)
+			continue
+		}
+		entries := make(map[string]bool, len(lines))
+		for _, line := range lines {
+			entries[line] = true
+		}
+		listings = append(listings, listing{file: f, entries: entries})
+	}
+	// Fewest entries first: once an archive has too many more entries than the first of the
+	// group to reach the overlap, so do all the ones after it
+	sort.SliceStable(listings, func(i, j int) bool { return len(listings[i].entries) < len(listings[j].entries) })
+
+	grouped := make([]bool, len(listings))
+	var groups []reporter.SizeGroup
+	for i := range listings {
+		if grouped[i] {
+			continue
+		}
+		same := []scanner.ArchiveFile{listings[i].file}
+		identical := true
+		for j := i + 1; j < len(listings); j++ {
+			if len(listings[i].entries)*100 < minOverlap*len(listings[j].entries) {
+				break
+			}
+			if grouped[j] {
+				continue
+			}
+			shared := overlap(listings[i].entries, listings[j].entries)
+			if shared < float64(minOverlap) {
+				continue
+			}
+			grouped[j] = true
+			same = append(same, listings[j].file)
+			identical = identical && shared == 100
+		}
+		if len(same) > 1 {
+			groups = append(groups, newGroup(Manifest, largest(same), same, identical))
+		}
+	}
+	return groups
+}
+
+// overlap returns the entries two listings have in common, in percent of the entries in
+// either of them
+func overlap(a, b map[string]bool) float64 {
+	common := 0
+	for entry := range a {
+		if b[entry] {
+			common++
+		}
+	}
+	return float64(common*100) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,271 @@
+package profile
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/stl"
+	"archive-duplicate-finder/internal/vfs"
+	"archive-duplicate-finder/internal/visual"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Methods Step 2 can use to find the duplicates of a file type
+const (
+	Name     = "name"     // Same size and a similar name (the default)
+	Content  = "content"  // Same size and byte-identical contents, whatever the names
+	Manifest = "manifest" // Same list of entries (paths, sizes, CRCs), whatever the container bytes
+	Geometry = "geometry" // Same mesh, whatever the encoding (binary or ASCII STL, OBJ)
+	Cover    = "cover"    // Similar cover or first image (perceptual hash)
+)
+
+// Methods lists the available methods
+var Methods = []string{Name, Content, Manifest, Geometry, Cover}
+
+// Defaults routes loose models to geometry comparison: an ASCII re-export of a binary STL
+// differs in size and name, so the size and name pass cannot pair them
+func Defaults() map[string]string {
+	return map[string]string{
+		".stl": Geometry,
+		".obj": Geometry,
+	}
+}
+
+// Resolve returns the defaults overridden by the configured profiles
+func Resolve(configured map[string]string) map[string]string {
+	profiles := Defaults()
+	for ext, method := range configured {
+		profiles[normalizeExt(ext)] = method
+	}
+	return profiles
+}
+
+// Validate checks that every profile names a known method
+func Validate(profiles map[string]string) error {
+	for ext, method := range profiles {
+		if !isMethod(method) {
+			return fmt.Errorf("unknown method '%s' for %s (available: %s)", method, ext, strings.Join(Methods, ", "))
+		}
+		if method == Geometry && !stl.IsSTLFile("x"+normalizeExt(ext)) && !stl.IsOBJFile("x"+normalizeExt(ext)) {
+			return fmt.Errorf("geometry comparison only reads .stl and .obj files, not %s", ext)
+		}
+	}
+	return nil
+}
+
+// Parse parses ".stl=geometry,.zip=manifest" into a profile map
+func Parse(spec string) (map[string]string, error) {
+	profiles := make(map[string]string)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		ext, method, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("expected extension=method, got '%s'", part)
+		}
+		profiles[normalizeExt(ext)] = strings.TrimSpace(method)
+	}
+	return profiles, Validate(profiles)
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+func isMethod(method string) bool {
+	for _, m := range Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Method returns the method used for a file: the profile of its extension, otherwise Content
+// for loose files (see scanner.SetLooseFiles), whose names say little about their contents,
+// and Name for the rest
+func Method(profiles map[string]string, f scanner.ArchiveFile) string {
+	if method, ok := profiles[strings.ToLower(filepath.Ext(f.Path))]; ok {
+		return method
+	}
+	if scanner.IsLoose(f) {
+		return Content
+	}
+	return Name
+}
+
+// Split sorts files by the method their profile routes them to
+func Split(profiles map[string]string, files []scanner.ArchiveFile) map[string][]scanner.ArchiveFile {
+	byMethod := make(map[string][]scanner.ArchiveFile)
+	for _, f := range files {
+		method := Method(profiles, f)
+		byMethod[method] = append(byMethod[method], f)
+	}
+	return byMethod
+}
+
+// Options tune how close files must be for the methods that allow some difference
+type Options struct {
+	MinOverlap     int // Manifest: share of entries (1-100) two archives have in common; 0 or 100 asks for the same list
+	VisualDistance int // Cover: largest Hamming distance between two covers (see visual.HammingThreshold)
+}
+
+// Analyze finds the duplicate groups among files with one of the methods other than Name,
+// which is the regular size and name pass of the callers. Groups are tagged with the method.
+func Analyze(ctx context.Context, cache *db.Cache, method string, files []scanner.ArchiveFile, opts Options) []reporter.SizeGroup {
+	if len(files) < 2 {
+		return nil
+	}
+	var groups []reporter.SizeGroup
+	switch method {
+	case Content:
+		for size, sameSize := range scanner.GroupBySize(files) {
+			if ctx.Err() != nil {
+				return groups
+			}
+			for _, g := range hashing.VerifyContents(cache, sameSize) {
+				groups = append(groups, newGroup(Content, size, g.Files, true))
+			}
+		}
+	case Manifest:
+		if opts.MinOverlap > 0 && opts.MinOverlap < 100 {
+			groups = groupByOverlap(ctx, files, opts.MinOverlap)
+		} else {
+			groups = groupByKey(ctx, cache, Manifest, files, manifestKey, true)
+		}
+	case Geometry:
+		groups = groupByKey(ctx, cache, Geometry, files, geometryKey, false)
+	case Cover:
+		visual.ProcessVisualHashes(ctx, files, cache, false, nil)
+		byPath := make(map[string]scanner.ArchiveFile, len(files))
+		for _, f := range files {
+			byPath[f.Path] = f
+		}
+		for _, g := range visual.FindVisualDuplicates(files, cache, opts.VisualDistance) {
+			var matched []scanner.ArchiveFile
+			for _, f := range g.Files {
+				matched = append(matched, byPath[f.Path])
+			}
+			groups = append(groups, newGroup(Cover, largest(matched), matched, false))
+		}
+	}
+	return groups
+}
+
+// groupByKey groups files whose key for the method is equal. Keys are cached per file.
+func groupByKey(ctx context.Context, cache *db.Cache, method string, files []scanner.ArchiveFile, key func(path string) (string, error), verified bool) []reporter.SizeGroup {
+	byKey := make(map[string][]scanner.ArchiveFile)
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return nil
+		}
+		k, err := cachedKey(cache, method, f.Path, key)
+		if err != nil {
+			log.Printf("⚠️  Could not read %s: %v", f.Name, err)
+			continue
+		}
+		byKey[k] = append(byKey[k], f)
+	}
+
+	var groups []reporter.SizeGroup
+	for _, same := range byKey {
+		if len(same) > 1 {
+			groups = append(groups, newGroup(method, largest(same), same, verified))
+		}
+	}
+	return groups
+}
+
+func cachedKey(cache *db.Cache, method, path string, key func(path string) (string, error)) (string, error) {
+	info, err := vfs.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	modTime := info.ModTime.Format(time.RFC3339)
+	if cache != nil {
+		if k, ok := cache.GetProfileKey(path, method, info.Size, modTime); ok {
+			return k, nil
+		}
+	}
+	k, err := key(path)
+	if err != nil {
+		return "", err
+	}
+	if cache != nil {
+		cache.PutProfileKey(path, method, info.Size, modTime, k)
+	}
+	return k, nil
+}
+
+// manifestKey hashes the entry list of an archive: two archives holding the same files are
+// duplicates even when compression level or entry order make their bytes differ
+func manifestKey(path string) (string, error) {
+	lines, err := manifestLines(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(lines, "\n")))), nil
+}
+
+// manifestLines lists the entries of an archive as sorted "path\tsize\tcrc" lines
+func manifestLines(path string) ([]string, error) {
+	entries, err := archive.ListArchiveFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no entries")
+	}
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = fmt.Sprintf("%s\t%d\t%08x", e.Path, e.Size, e.CRC32)
+	}
+	sort.Strings(lines)
+	return lines, nil
+}
+
+// geometryKey fingerprints the mesh of a loose model file
+func geometryKey(path string) (string, error) {
+	f, err := vfs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return stl.Fingerprint(path, data)
+}
+
+func newGroup(method string, size int64, files []scanner.ArchiveFile, verified bool) reporter.SizeGroup {
+	g := reporter.SizeGroup{Size: size, Method: method, Verified: verified}
+	for _, f := range files {
+		g.Files = append(g.Files, reporter.NewFileInfo(f))
+	}
+	return g
+}
+
+func largest(files []scanner.ArchiveFile) int64 {
+	var size int64
+	for _, f := range files {
+		size = max(size, f.Size)
+	}
+	return size
+}
@@ -0,0 +1,126 @@
+package progress
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase names used in reporter.Report.Phases
+const (
+	PhaseScan    = "scan"
+	PhaseVerify  = "verify"
+	PhaseStep2   = "step2"
+	PhasePayload = "payload" // Decompressed payloads of compressed archives, part of Step 2
+	PhaseStep3   = "step3"
+	PhaseVisual  = "visual"
+	PhaseExport  = "export"
+)
+
+// Tracker measures byte-weighted progress of one phase and projects its remaining time
+type Tracker struct {
+	mu       sync.Mutex
+	total    int64
+	done     int64
+	start    time.Time
+	finished bool
+	onUpdate func(reporter.PhaseProgress)
+}
+
+// New starts tracking a phase of totalBytes work. onUpdate receives every change.
+func New(totalBytes int64, onUpdate func(reporter.PhaseProgress)) *Tracker {
+	t := &Tracker{total: totalBytes, start: time.Now(), onUpdate: onUpdate}
+	t.emit()
+	return t
+}
+
+// Add records n more bytes of completed work
+func (t *Tracker) Add(n int64) {
+	t.mu.Lock()
+	t.done += n
+	t.mu.Unlock()
+	t.emit()
+}
+
+// Set records the total amount of completed work so far
+func (t *Tracker) Set(doneBytes int64) {
+	t.mu.Lock()
+	t.done = doneBytes
+	t.mu.Unlock()
+	t.emit()
+}
+
+// SetFraction records progress for phases that only know a percentage (0-100)
+func (t *Tracker) SetFraction(percent float64) {
+	t.mu.Lock()
+	t.done = int64(percent / 100 * float64(t.total))
+	t.mu.Unlock()
+	t.emit()
+}
+
+// Finish marks the phase as complete
+func (t *Tracker) Finish() {
+	t.mu.Lock()
+	if t.done > t.total {
+		// Estimated totals (e.g. the previous scan size) may be exceeded
+		t.total = t.done
+	}
+	t.done = t.total
+	t.finished = true
+	t.mu.Unlock()
+	t.emit()
+}
+
+// Snapshot returns the current progress and ETA
+func (t *Tracker) Snapshot() reporter.PhaseProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elapsed := time.Since(t.start).Seconds()
+	p := reporter.PhaseProgress{
+		DoneBytes:      t.done,
+		TotalBytes:     t.total,
+		ElapsedSeconds: elapsed,
+		Finished:       t.finished,
+	}
+	switch {
+	case t.finished:
+		p.Progress = 100
+	case t.total > 0:
+		p.Progress = min(float64(t.done)/float64(t.total)*100, 100)
+	}
+	if !t.finished && t.done > 0 && t.total > t.done {
+		p.ETASeconds = elapsed * float64(t.total-t.done) / float64(t.done)
+	}
+	return p
+}
+
+func (t *Tracker) emit() {
+	if t.onUpdate != nil {
+		t.onUpdate(t.Snapshot())
+	}
+}
+
+// Bar renders a CLI progress bar line, e.g. "[=====     ] 45.0% · ETA 2m10s"
+func Bar(p reporter.PhaseProgress) string {
+	line := fmt.Sprintf("[%-20s] %.1f%%", strings.Repeat("=", int(p.Progress/5)), p.Progress)
+	if p.ETASeconds > 0 {
+		line += " · ETA " + FormatETA(p.ETASeconds)
+	}
+	return line
+}
+
+// FormatETA renders a remaining time in seconds ("45s", "2m10s", "1h05m")
+func FormatETA(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
@@ -0,0 +1,64 @@
+package protect
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Rules decide which files are protected: they are never suggested or accepted for deletion,
+// but still take part in duplicate groups (as the copy that is kept). A nil *Rules protects nothing.
+type Rules struct {
+	patterns []string
+}
+
+// New compiles protection patterns. A pattern with a slash is a glob over full paths
+// ("/library/originals", "D:/Archives/*/masters"); one without is a glob over file and folder
+// names ("*.blend.zip", "keep"). A matching folder protects everything below it.
+func New(patterns []string) (*Rules, error) {
+	r := &Rules{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(filepath.ToSlash(p))
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid protection pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, strings.TrimSuffix(p, "/"))
+	}
+	return r, nil
+}
+
+// Patterns returns the compiled patterns
+func (r *Rules) Patterns() []string {
+	if r == nil {
+		return nil
+	}
+	return append([]string(nil), r.patterns...)
+}
+
+// Match reports whether a file, or one of the folders containing it, is protected
+func (r *Rules) Match(p string) bool {
+	if r == nil || len(r.patterns) == 0 {
+		return false
+	}
+	p = filepath.ToSlash(p)
+	for {
+		for _, pattern := range r.patterns {
+			subject := p
+			if !strings.Contains(pattern, "/") {
+				subject = path.Base(p)
+			}
+			if ok, _ := path.Match(pattern, subject); ok {
+				return true
+			}
+		}
+		parent := path.Dir(p)
+		if parent == p || parent == "." || parent == "/" {
+			return false
+		}
+		p = parent
+	}
+}
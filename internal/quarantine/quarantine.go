@@ -0,0 +1,313 @@
+package quarantine
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"bufio"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestName is the file listing what a batch of the quarantine holds, one JSON item per line
+const manifestName = "manifest.jsonl"
+
+// mu serializes the changes to manifests, e.g. dashboard requests quarantining files meanwhile
+var mu sync.Mutex
+
+// Item is a file held in quarantine, as recorded in the manifest of its batch
+type Item struct {
+	ID       string `json:"id"`    // Stable within the quarantine, for restore
+	Batch    string `json:"batch"` // Folder of the quarantine the file was moved into with others
+	Time     string `json:"time"`  // When it was quarantined (RFC 3339)
+	Original string `json:"original"`
+	Path     string `json:"path"` // Location inside the quarantine
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256,omitempty"`   // Content hash, read before the move
+	Kept     string `json:"kept,omitempty"`     // Copy kept in place of the file
+	GroupID  string `json:"group_id,omitempty"` // Stable ID of the group the file belonged to
+}
+
+// Move moves path into batch of the quarantine dir, under its original location
+// (dir/batch/files/home/me/models/x.zip), and records it in the manifest of the batch. Size,
+// SHA256, Kept and GroupID are taken from item; an empty batch is today's date. A quarantine on
+// another filesystem is filled by copying, reported through onProgress (optional, see
+// fsutil.MoveFile).
+func Move(dir, batch, path string, item Item, onProgress func(done, total int64)) (Item, error) {
+	original, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	if batch == "" {
+		batch = time.Now().Format("2006-01-02")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	dest := freeName(filepath.Join(dir, batch, "files", mirror(original)))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return Item{}, err
+	}
+	if err := fsutil.MoveFile(path, dest, onProgress); err != nil {
+		return Item{}, err
+	}
+
+	item.Batch, item.Original, item.Path = batch, original, dest
+	item.Time = time.Now().Format(time.RFC3339)
+	item.ID = fmt.Sprintf("%x", sha1.Sum([]byte(dest)))[:12]
+	data, err := json.Marshal(item)
+	if err != nil {
+		return item, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, batch, manifestName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return item, err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return item, err
+	}
+	return item, f.Close()
+}
+
+// mirror turns an absolute path into one relative to the quarantine, keeping the drive letter
+// of Windows paths as a folder ("C:\models\x.zip" becomes "C/models/x.zip")
+func mirror(path string) string {
+	volume := filepath.VolumeName(path)
+	rest := strings.TrimLeft(path[len(volume):], `\/`)
+	volume = strings.Trim(strings.NewReplacer(":", "", `\\`, "", `\`, "_", "/", "_").Replace(volume), "_")
+	return filepath.Join(volume, rest)
+}
+
+// freeName returns path, or one with a counter appended when a file already took it
+func freeName(path string) string {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), i, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// List returns the files held in the quarantine dir, most recently quarantined first
+func List(dir string) ([]Item, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	items, err := load(dir)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Time > items[j].Time })
+	return items, err
+}
+
+// load reads the manifests of every batch of dir
+func load(dir string) ([]Item, error) {
+	batches, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var items []Item
+	for _, b := range batches {
+		if !b.IsDir() {
+			continue
+		}
+		batch, err := readManifest(filepath.Join(dir, b.Name(), manifestName))
+		if err != nil {
+			return items, err
+		}
+		items = append(items, batch...)
+	}
+	return items, nil
+}
+
+func readManifest(path string) ([]Item, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []Item
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var item Item
+		if json.Unmarshal(sc.Bytes(), &item) == nil {
+			items = append(items, item)
+		}
+	}
+	return items, sc.Err()
+}
+
+// drop rewrites the manifests of the batches holding the items so they no longer list them, and
+// removes the batches left empty
+func drop(dir string, gone []Item) error {
+	byBatch := make(map[string]map[string]bool)
+	for _, item := range gone {
+		if byBatch[item.Batch] == nil {
+			byBatch[item.Batch] = make(map[string]bool)
+		}
+		byBatch[item.Batch][item.ID] = true
+	}
+
+	var errs []error
+	for batch, ids := range byBatch {
+		path := filepath.Join(dir, batch, manifestName)
+		items, err := readManifest(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var lines []byte
+		left := 0
+		for _, item := range items {
+			if ids[item.ID] {
+				continue
+			}
+			data, _ := json.Marshal(item)
+			lines = append(append(lines, data...), '\n')
+			left++
+		}
+		if left == 0 {
+			errs = append(errs, os.RemoveAll(filepath.Join(dir, batch)))
+			continue
+		}
+		errs = append(errs, fsutil.WriteFileAtomic(path, lines, 0644))
+	}
+	return errors.Join(errs...)
+}
+
+// Restore moves the items with the given IDs back to where they were quarantined from. An item
+// whose original location was taken in the meantime stays in quarantine and is reported in the
+// error; the others are restored and returned. Nothing is restored when the original
+// filesystems lack the room for the items that must be copied back.
+func Restore(dir string, ids []string) ([]Item, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	items, err := load(dir)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Item, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	var transfers []fsutil.Transfer
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			transfers = append(transfers, fsutil.Transfer{Path: item.Path, Dir: filepath.Dir(item.Original), Size: item.Size})
+		}
+	}
+	if short := fsutil.Preflight(transfers); len(short) > 0 {
+		errs := make([]error, len(short))
+		for i, s := range short {
+			errs[i] = s
+		}
+		return nil, errors.Join(errs...)
+	}
+
+	var restored []Item
+	var errs []error
+	for _, id := range ids {
+		item, ok := byID[id]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: not in quarantine", id))
+			continue
+		}
+		if _, err := os.Lstat(item.Original); err == nil {
+			errs = append(errs, fmt.Errorf("%s: %s already exists", id, item.Original))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(item.Original), 0755); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		if err := fsutil.MoveFile(item.Path, item.Original, nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		restored = append(restored, item)
+	}
+	errs = append(errs, drop(dir, restored))
+	return restored, errors.Join(errs...)
+}
+
+// Expired returns the items quarantined longer ago than age
+func Expired(dir string, age time.Duration) ([]Item, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return expired(dir, age)
+}
+
+func expired(dir string, age time.Duration) ([]Item, error) {
+	items, err := load(dir)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-age)
+	var old []Item
+	for _, item := range items {
+		if t, err := time.Parse(time.RFC3339, item.Time); err == nil && t.Before(cutoff) {
+			old = append(old, item)
+		}
+	}
+	return old, nil
+}
+
+// Purge permanently deletes the items quarantined longer ago than age and returns them
+func Purge(dir string, age time.Duration) ([]Item, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	items, err := expired(dir, age)
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []Item
+	var errs []error
+	for _, item := range items {
+		if err := os.Remove(item.Path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+			continue
+		}
+		purged = append(purged, item)
+	}
+	errs = append(errs, drop(dir, purged))
+	return purged, errors.Join(errs...)
+}
+
+// ParseAge parses the age of -older-than and older_than: a number of days ("30d"), weeks ("2w")
+// or a Go duration ("12h")
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			days, err := strconv.Atoi(n)
+			if err != nil || days < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(days) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (e.g. 30d, 2w or 12h)", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,113 @@
+package refnote
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/i18n"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// Formats of the note left next to a removed duplicate
+const (
+	FormatText = "text" // name.zip.duplicate.txt, rendered from a template
+	FormatJSON = "json" // name.zip.duplicate.json, for other tools to follow
+)
+
+// DefaultTemplate renders the text note when no template is configured
+const DefaultTemplate = `Archive Duplicate Finder
+-----------------------
+Action: Removed as duplicate
+Date: {{.Date.Format "2006-01-02 15:04:05"}}
+Original kept: {{.Kept}}
+Original size: {{bytes .KeptSize}}
+`
+
+// Note describes a removed duplicate and the copy that was kept in its place. It is the data
+// of the text template and the contents of the JSON sidecar.
+type Note struct {
+	Removed       string    `json:"removed"`
+	RemovedSize   int64     `json:"removed_size"`
+	RemovedSHA256 string    `json:"removed_sha256,omitempty"`
+	Kept          string    `json:"kept"`
+	KeptSize      int64     `json:"kept_size"`
+	KeptSHA256    string    `json:"kept_sha256,omitempty"` // Equals RemovedSHA256 for identical duplicates
+	GroupHash     string    `json:"group_hash,omitempty"`  // Report group of the two files, as in the ignore list (reporter.CalculateGroupHash)
+	Similarity    float64   `json:"similarity,omitempty"`  // Name similarity (0-100) that matched the two files, when known
+	Action        string    `json:"action"`                // "deleted", "trashed" or "quarantined"
+	Trash         string    `json:"trash,omitempty"`       // Where the removed copy went (trash or quarantine)
+	Date          time.Time `json:"date"`
+}
+
+var templateFuncs = template.FuncMap{
+	"bytes": formatBytes,
+}
+
+// Path is where the note for removed is written
+func Path(removed, format string) string {
+	if format == FormatJSON {
+		return removed + ".duplicate.json"
+	}
+	return removed + ".duplicate.txt"
+}
+
+// Validate checks a configured format and template before they are used
+func Validate(format, text string) error {
+	if format != "" && format != FormatText && format != FormatJSON {
+		return fmt.Errorf("unknown reference note format %q (expected text or json)", format)
+	}
+	_, err := Render(Note{Date: time.Now()}, format, text)
+	return err
+}
+
+// Render returns the contents of the note. An empty text uses DefaultTemplate, in the selected
+// language.
+func Render(n Note, format, text string) ([]byte, error) {
+	if format == FormatJSON {
+		data, err := json.MarshalIndent(n, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+	if text == "" {
+		text = i18n.T(DefaultTemplate)
+	}
+	tmpl, err := template.New("ref_template").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference note template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, n); err != nil {
+		return nil, fmt.Errorf("invalid reference note template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Write leaves the note next to the removed file and returns its path
+func Write(n Note, format, text string) (string, error) {
+	data, err := Render(n, format, text)
+	if err != nil {
+		return "", err
+	}
+	path := Path(n.Removed, format)
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,224 @@
+package reporter
+
+import (
+	"archive-duplicate-finder/internal/i18n"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GroupByDir is the value of -group-by and group_by that rolls the report up per directory
+const GroupByDir = "dir"
+
+// DirGroup rolls up the duplicates stored under a directory, its subdirectories included, to
+// tell which folders can go as a whole
+type DirGroup struct {
+	Dir            string `json:"dir"`
+	Groups         int    `json:"groups"`          // Identical groups with a member under the directory
+	Similar        int    `json:"similar"`         // Similar-name clusters (series excluded) with a member under it
+	Files          int    `json:"files"`           // Members of those identical groups stored under it
+	Bytes          int64  `json:"bytes"`           // Their size
+	Redundant      int    `json:"redundant_files"` // Unprotected members with a copy outside: deleting the directory loses none of them
+	RedundantBytes int64  `json:"redundant_bytes"`
+	Reclaimable    int64  `json:"reclaimable_bytes"`     // Bytes the cleanup plan frees under the directory
+	TotalFiles     int    `json:"total_files,omitempty"` // Every scanned file under it, when the scan's files are known
+	Deletable      bool   `json:"deletable"`             // Each of those files has a copy outside the directory
+}
+
+// GroupByDirs aggregates the identical groups of the report per directory, rolling every
+// directory up into its parents below root, most redundant bytes first. A member is redundant
+// in a directory when its group keeps a copy outside of it. With the scanned files, a
+// directory whose every file is redundant is marked deletable. Parents that only hold one
+// listed directory and no duplicates of their own are left out.
+func GroupByDirs(report Report, files []FileInfo, root, deleteMode string) []DirGroup {
+	root = filepath.Clean(root)
+	byDir := make(map[string]*DirGroup)
+	children := make(map[string]map[string]bool) // Directory -> listed subdirectories
+	direct := make(map[string]bool)              // Directories with duplicates of their own
+
+	// ancestors returns the directory of path and its parents up to root
+	ancestors := func(path string) []string {
+		var dirs []string
+		for dir := filepath.Dir(path); ; {
+			dirs = append(dirs, dir)
+			parent := filepath.Dir(dir)
+			if dir == root || parent == dir || !inside(root, parent) {
+				return dirs
+			}
+			dir = parent
+		}
+	}
+	get := func(dir string) *DirGroup {
+		d, ok := byDir[dir]
+		if !ok {
+			d = &DirGroup{Dir: dir}
+			byDir[dir] = d
+		}
+		return d
+	}
+
+	for _, g := range report.SizeGroups {
+		touched := make(map[string]bool)
+		for _, f := range g.Files {
+			dirs := ancestors(f.Path)
+			direct[dirs[0]] = true
+			for i, dir := range dirs {
+				if i > 0 {
+					if children[dir] == nil {
+						children[dir] = make(map[string]bool)
+					}
+					children[dir][dirs[i-1]] = true
+				}
+				d := get(dir)
+				d.Files++
+				d.Bytes += f.Size
+				if !f.Protected && hasCopyOutside(g.Files, f, dir) {
+					d.Redundant++
+					d.RedundantBytes += f.Size
+				}
+				touched[dir] = true
+			}
+		}
+		for dir := range touched {
+			byDir[dir].Groups++
+		}
+	}
+
+	for _, g := range buildPlan(report, deleteMode) {
+		if g.reviewed {
+			continue
+		}
+		for _, f := range g.remove {
+			for _, dir := range ancestors(f.Path) {
+				if d, ok := byDir[dir]; ok {
+					d.Reclaimable += f.Size
+				}
+			}
+		}
+	}
+
+	for _, g := range report.SimilarGroups {
+		if g.Series {
+			continue
+		}
+		touched := make(map[string]bool)
+		for _, f := range g.Files {
+			for _, dir := range ancestors(f.Path) {
+				touched[dir] = true
+			}
+		}
+		for dir := range touched {
+			if d, ok := byDir[dir]; ok {
+				d.Similar++
+			}
+		}
+	}
+
+	for _, f := range files {
+		for _, dir := range ancestors(f.Path) {
+			if d, ok := byDir[dir]; ok {
+				d.TotalFiles++
+			}
+		}
+	}
+
+	dirs := make([]DirGroup, 0, len(byDir))
+	for dir, d := range byDir {
+		if !direct[dir] && len(children[dir]) == 1 {
+			continue
+		}
+		d.Deletable = d.TotalFiles > 0 && d.Redundant == d.TotalFiles
+		dirs = append(dirs, *d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].RedundantBytes != dirs[j].RedundantBytes {
+			return dirs[i].RedundantBytes > dirs[j].RedundantBytes
+		}
+		if dirs[i].Reclaimable != dirs[j].Reclaimable {
+			return dirs[i].Reclaimable > dirs[j].Reclaimable
+		}
+		return dirs[i].Dir < dirs[j].Dir
+	})
+	return dirs
+}
+
+// hasCopyOutside reports whether a member of the group other than f lies outside dir
+func hasCopyOutside(group []FileInfo, f FileInfo, dir string) bool {
+	for _, other := range group {
+		if other.Path != f.Path && !inside(dir, other.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByDir returns a copy of the report keeping the groups with a file in dir, at any depth
+func FilterByDir(report Report, dir string) Report {
+	touches := func(files []FileInfo) bool {
+		for _, f := range files {
+			if inside(dir, f.Path) {
+				return true
+			}
+		}
+		return false
+	}
+	var sizeGroups []SizeGroup
+	for _, g := range report.SizeGroups {
+		if touches(g.Files) {
+			sizeGroups = append(sizeGroups, g)
+		}
+	}
+	filter := func(groups []SimilarityGroup) []SimilarityGroup {
+		var kept []SimilarityGroup
+		for _, g := range groups {
+			if touches(g.Files) {
+				kept = append(kept, g)
+			}
+		}
+		return kept
+	}
+	report.SizeGroups = sizeGroups
+	report.SimilarGroups = filter(report.SimilarGroups)
+	report.VisualGroups = filter(report.VisualGroups)
+	return report
+}
+
+// inside reports whether path is dir or lies under it
+func inside(dir, path string) bool {
+	if dir == "." || dir == "" {
+		return true
+	}
+	sep := string(filepath.Separator)
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, sep)+sep)
+}
+
+// WriteDirGroups prints the directories of GroupByDirs as a table, at most max of them (0
+// prints all)
+func WriteDirGroups(w io.Writer, dirs []DirGroup, root string, max int) {
+	if len(dirs) == 0 {
+		fmt.Fprintln(w, i18n.T("No duplicates found."))
+		return
+	}
+	fmt.Fprintln(w, i18n.T("Duplicates by directory (subdirectories included):"))
+	fmt.Fprintf(w, "%12s %12s %11s %7s  %s\n", i18n.T("REDUNDANT"), i18n.T("RECLAIMABLE"), i18n.T("FILES"), i18n.T("GROUPS"), i18n.T("DIRECTORY"))
+	shown := dirs
+	if max > 0 && len(shown) > max {
+		shown = shown[:max]
+	}
+	for _, d := range shown {
+		files := fmt.Sprint(d.Redundant, "/", d.Files)
+		if d.TotalFiles > 0 {
+			files = fmt.Sprint(d.Redundant, "/", d.TotalFiles)
+		}
+		line := fmt.Sprintf("%12s %12s %11s %7d  %s", formatBytes(d.RedundantBytes), formatBytes(d.Reclaimable), files, d.Groups, relativeDir(root, d.Dir))
+		if d.Deletable {
+			line += "  " + i18n.T("[every file has a copy elsewhere]")
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(shown) < len(dirs) {
+		fmt.Fprintf(w, "%12s %12s %11s %7s  %s\n", "", "", "", "", i18n.T("... and %d more directories", len(dirs)-len(shown)))
+	}
+}
@@ -0,0 +1,81 @@
+package reporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// csvHeader names the columns of WriteCSV: one row per group member, then per corrupt archive
+var csvHeader = []string{
+	"kind", "group", "group_id", "group_label", "review", "note",
+	"name", "path", "size", "type", "mod_time", "score", "protected", "suspicious",
+	"file_count", "uncompressed_size", "error",
+}
+
+// WriteCSV writes the report as a spreadsheet: a row for every member of the identical
+// ("size"), similar-name ("similar") and visual ("visual") groups, numbered from 1 within their
+// kind, and one for every corrupt archive ("corrupt")
+func WriteCSV(w io.Writer, report Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+
+	row := func(kind string, group int, id, label, review, note string, f FileInfo, errMsg string) {
+		score := ""
+		if f.Score != nil {
+			score = strconv.FormatFloat(*f.Score, 'f', 1, 64)
+		}
+		groupNo := ""
+		if group > 0 {
+			groupNo = strconv.Itoa(group)
+		}
+		cw.Write([]string{
+			kind, groupNo, id, cell(label), review, cell(note),
+			cell(f.Name), cell(f.Path), strconv.FormatInt(f.Size, 10), f.Type, f.ModTime, score,
+			strconv.FormatBool(f.Protected), cell(f.Suspicious),
+			countCell(int64(f.FileCount)), countCell(f.UncompressedSize), cell(errMsg),
+		})
+	}
+
+	for i, g := range report.SizeGroups {
+		label := strconv.FormatInt(g.Size, 10)
+		if g.Method != "" {
+			label = g.Method
+		}
+		for _, f := range g.Files {
+			row("size", i+1, g.ID, label, g.Review, g.Note, f, "")
+		}
+	}
+	for i, g := range report.SimilarGroups {
+		for _, f := range g.Files {
+			row("similar", i+1, g.ID, g.BaseName, g.Review, g.Note, f, "")
+		}
+	}
+	for i, g := range report.VisualGroups {
+		for _, f := range g.Files {
+			row("visual", i+1, g.ID, g.BaseName, g.Review, g.Note, f, "")
+		}
+	}
+	for _, c := range report.CorruptFiles {
+		row("corrupt", 0, "", "", "", "", c.FileInfo, c.Error)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// cell keeps spreadsheets from running a file name or note that starts like a formula
+func cell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func countCell(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
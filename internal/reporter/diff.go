@@ -0,0 +1,145 @@
+package reporter
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Reasons a source archive is considered to be already in the library
+const (
+	MatchIdentical   = "identical"    // Same size and content hash
+	MatchSimilarName = "similar_name" // Name similarity at or above the threshold
+	MatchVisual      = "visual"       // Preview images within the visual Hamming threshold
+)
+
+// DiffReport lists which archives of a source folder (e.g. new downloads) already exist in a library
+type DiffReport struct {
+	Source           string      `json:"source"`
+	Library          string      `json:"library"`
+	SourceFiles      int         `json:"source_files"`
+	LibraryFiles     int         `json:"library_files"`
+	Matches          []DiffMatch `json:"matches"`
+	Unique           []FileInfo  `json:"unique"`          // Source archives with no counterpart in the library
+	RedundantBytes   int64       `json:"redundant_bytes"` // Size of the identical source archives
+	AnalysisDuration float64     `json:"analysis_duration_seconds"`
+	Timestamp        string      `json:"timestamp"`
+}
+
+// DiffMatch pairs a source archive with its counterpart in the library
+type DiffMatch struct {
+	Source  FileInfo `json:"source"`
+	Library FileInfo `json:"library"`
+	Reason  string   `json:"reason"` // MatchIdentical, MatchSimilarName or MatchVisual
+	Score   float64  `json:"score"`  // 0-100: name similarity, visual similarity, or 100 when identical
+}
+
+// Count returns the number of matches found for a reason
+func (d DiffReport) Count(reason string) int {
+	n := 0
+	for _, m := range d.Matches {
+		if m.Reason == reason {
+			n++
+		}
+	}
+	return n
+}
+
+// ExportDiffJSON exports the diff report to a JSON file
+func ExportDiffJSON(diff DiffReport, filename string) error {
+	data, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if err := fsutil.WriteFileAtomic(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// PrintDiff prints the matches and the archives that are new to the library
+func PrintDiff(diff DiffReport) {
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("🔀 ALREADY IN LIBRARY")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("📥 Source:  %s (%d archives)\n", diff.Source, diff.SourceFiles)
+	fmt.Printf("📚 Library: %s (%d archives)\n", diff.Library, diff.LibraryFiles)
+	fmt.Println()
+
+	labels := []struct{ reason, title string }{
+		{MatchIdentical, "✅ Identical (safe to purge)"},
+		{MatchSimilarName, "📝 Similar name (review)"},
+		{MatchVisual, "🖼️  Visual match (review)"},
+	}
+	for _, l := range labels {
+		if diff.Count(l.reason) == 0 {
+			continue
+		}
+		fmt.Printf("%s: %d\n", l.title, diff.Count(l.reason))
+		for _, m := range diff.Matches {
+			if m.Reason != l.reason {
+				continue
+			}
+			note := ""
+			if m.Reason != MatchIdentical {
+				note = fmt.Sprintf(" [%.0f%%]", m.Score)
+			}
+			if m.Source.Protected {
+				note += " [protected]"
+			}
+			fmt.Printf("   %s (%s)%s\n      = %s\n", m.Source.Path, formatBytes(m.Source.Size), note, m.Library.Path)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("🆕 New to the library: %d\n", len(diff.Unique))
+	for _, f := range diff.Unique {
+		fmt.Printf("   %s (%s)\n", f.Path, formatBytes(f.Size))
+	}
+	fmt.Println()
+	fmt.Printf("💾 Identical re-downloads: %s\n", formatBytes(diff.RedundantBytes))
+	fmt.Printf("⏱️  Analysis duration: %.2fs\n", diff.AnalysisDuration)
+	fmt.Println()
+}
+
+// ExportDiffScript writes a script that removes source archives already in the library. The
+// library copy is always kept; identical matches are active commands, name and visual matches
+// are commented out for review. Protected source archives are left out.
+func ExportDiffScript(diff DiffReport, filename string, opts ScriptOptions) error {
+	var plan []planGroup
+	for _, m := range diff.Matches {
+		if m.Source.Protected {
+			continue
+		}
+		title := "Identical to library archive"
+		switch m.Reason {
+		case MatchSimilarName:
+			title = fmt.Sprintf("Similar name to library archive (%.0f%%)", m.Score)
+		case MatchVisual:
+			title = fmt.Sprintf("Visual match with library archive (%.0f%%)", m.Score)
+		}
+		plan = append(plan, planGroup{
+			title:    title,
+			keep:     m.Library,
+			remove:   []FileInfo{m.Source},
+			reviewed: m.Reason != MatchIdentical,
+		})
+	}
+
+	w := scriptWriter{opts: opts}
+	w.header(Report{Timestamp: diff.Timestamp}, plan)
+	for i, g := range plan {
+		w.group(i+1, g)
+	}
+	w.footer()
+
+	mode := os.FileMode(0644)
+	if opts.Shell != "powershell" {
+		mode = 0755
+	}
+	if err := fsutil.WriteFileAtomic(filename, []byte(w.String()), mode); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package reporter
+
+import (
+	"archive-duplicate-finder/internal/i18n"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DigestOptions controls the per-directory summary
+type DigestOptions struct {
+	Root         string // Scanned directory; listed directories are shown relative to it
+	DeleteMode   string // Decides which copy counts as kept, as in the cleanup plan
+	MaxDirs      int    // Directories listed (0 lists all)
+	MaxOffenders int    // Largest duplicate groups listed
+}
+
+// DirDigest summarizes the duplicates found in one directory
+type DirDigest struct {
+	Dir         string
+	Groups      int   // Identical-size groups with a copy in this directory
+	Similar     int   // Similar-name clusters (series excluded) with a member in this directory
+	Reclaimable int64 // Bytes freed by removing the redundant copies stored here
+}
+
+// Digest aggregates the report per directory, largest reclaimable space first.
+// Only identical-size groups count as reclaimable; similar names need a review first.
+func Digest(report Report, deleteMode string) []DirDigest {
+	byDir := make(map[string]*DirDigest)
+	get := func(path string) *DirDigest {
+		dir := filepath.Dir(path)
+		d, ok := byDir[dir]
+		if !ok {
+			d = &DirDigest{Dir: dir}
+			byDir[dir] = d
+		}
+		return d
+	}
+
+	for _, g := range buildPlan(report, deleteMode) {
+		if g.reviewed {
+			continue
+		}
+		touched := map[*DirDigest]bool{get(g.keep.Path): true}
+		for _, f := range g.remove {
+			d := get(f.Path)
+			d.Reclaimable += f.Size
+			touched[d] = true
+		}
+		for d := range touched {
+			d.Groups++
+		}
+	}
+
+	for _, g := range report.SimilarGroups {
+		if g.Series {
+			continue
+		}
+		touched := make(map[*DirDigest]bool)
+		for _, f := range g.Files {
+			touched[get(f.Path)] = true
+		}
+		for d := range touched {
+			d.Similar++
+		}
+	}
+
+	dirs := make([]DirDigest, 0, len(byDir))
+	for _, d := range byDir {
+		dirs = append(dirs, *d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].Reclaimable != dirs[j].Reclaimable {
+			return dirs[i].Reclaimable > dirs[j].Reclaimable
+		}
+		if dirs[i].Groups+dirs[i].Similar != dirs[j].Groups+dirs[j].Similar {
+			return dirs[i].Groups+dirs[i].Similar > dirs[j].Groups+dirs[j].Similar
+		}
+		return dirs[i].Dir < dirs[j].Dir
+	})
+	return dirs
+}
+
+// WriteDigest prints a compact, plain-text health summary of the library: totals, one line per
+// directory and the largest duplicate groups. It is meant for terminals and MOTD/status scripts.
+func WriteDigest(w io.Writer, report Report, opts DigestOptions) {
+	dirs := Digest(report, opts.DeleteMode)
+	plan := buildPlan(report, opts.DeleteMode)
+
+	var groups int
+	var reclaimable int64
+	var offenders []planGroup
+	for _, g := range plan {
+		if g.reviewed {
+			continue
+		}
+		groups++
+		offenders = append(offenders, g)
+		for _, f := range g.remove {
+			reclaimable += f.Size
+		}
+	}
+	similar := 0
+	for _, g := range report.SimilarGroups {
+		if !g.Series {
+			similar++
+		}
+	}
+
+	fmt.Fprintln(w, i18n.T("Archive library digest: %s (%s)", opts.Root, report.Timestamp))
+	fmt.Fprint(w, i18n.T("%d archives | %d duplicate groups | %s reclaimable | %d similar-name clusters",
+		report.TotalFiles, groups, formatBytes(reclaimable), similar))
+	if report.CorruptCount > 0 {
+		fmt.Fprint(w, i18n.T(" | %d corrupt", report.CorruptCount))
+	}
+	fmt.Fprintln(w)
+
+	if len(dirs) == 0 {
+		fmt.Fprintln(w, i18n.T("No duplicates found."))
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%12s %7s %8s  %s\n", i18n.T("RECLAIMABLE"), i18n.T("GROUPS"), i18n.T("SIMILAR"), i18n.T("DIRECTORY"))
+	shown := dirs
+	if opts.MaxDirs > 0 && len(shown) > opts.MaxDirs {
+		shown = shown[:opts.MaxDirs]
+	}
+	for _, d := range shown {
+		fmt.Fprintf(w, "%12s %7d %8d  %s\n", formatBytes(d.Reclaimable), d.Groups, d.Similar, relativeDir(opts.Root, d.Dir))
+	}
+	if len(shown) < len(dirs) {
+		fmt.Fprintf(w, "%12s %7s %8s  %s\n", "", "", "", i18n.T("... and %d more directories", len(dirs)-len(shown)))
+	}
+
+	if opts.MaxOffenders <= 0 || len(offenders) == 0 {
+		return
+	}
+	sort.SliceStable(offenders, func(i, j int) bool {
+		return redundantBytes(offenders[i]) > redundantBytes(offenders[j])
+	})
+	if len(offenders) > opts.MaxOffenders {
+		offenders = offenders[:opts.MaxOffenders]
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, i18n.T("Worst offenders:"))
+	for _, g := range offenders {
+		fmt.Fprintf(w, "%12s  %s\n", formatBytes(redundantBytes(g)), i18n.T("%d copies of %s", len(g.remove)+1, relativeDir(opts.Root, g.keep.Path)))
+	}
+}
+
+func redundantBytes(g planGroup) int64 {
+	var total int64
+	for _, f := range g.remove {
+		total += f.Size
+	}
+	return total
+}
+
+// relativeDir shortens path to be relative to root when it lies inside it
+func relativeDir(root, path string) string {
+	if root == "" {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,128 @@
+package reporter
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Formats of the exclusion lists written by RenderExcludes
+const (
+	ExcludeRsync  = "rsync"  // rsync --exclude-from: one pattern per line
+	ExcludeRclone = "rclone" // rclone --filter-from: "- pattern" rules
+)
+
+// ExcludeOptions controls how the files to remove are rendered as an exclusion list
+type ExcludeOptions struct {
+	Format     string // ExcludeRsync (default) or ExcludeRclone
+	Root       string // Source folder of the backup; the patterns are anchored to it
+	DeleteMode string // Picks the kept file as in the cleanup script
+}
+
+// ExcludeFormat picks the exclusion list format from the output file name: rclone for names
+// containing "rclone" or "filter", rsync otherwise
+func ExcludeFormat(filename string) string {
+	name := strings.ToLower(filepath.Base(filename))
+	if strings.Contains(name, "rclone") || strings.Contains(name, "filter") {
+		return ExcludeRclone
+	}
+	return ExcludeRsync
+}
+
+// ExportExcludes writes the exclusion list to a file
+func ExportExcludes(report Report, filename string, opts ExcludeOptions) error {
+	if err := fsutil.WriteFileAtomic(filename, []byte(RenderExcludes(report, opts)), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// RenderExcludes lists the files the cleanup plan would remove as patterns anchored to the
+// backup source, so the next rsync or rclone run leaves the duplicates out instead of deleting
+// them. As in the script, copies from similar-name and visual groups are only suggestions and
+// are written commented out. Files outside the root, and names no pattern can match (line
+// breaks), are counted in the header and left out.
+func RenderExcludes(report Report, opts ExcludeOptions) string {
+	var body strings.Builder
+	var count, skipped int
+	var bytes int64
+	for _, g := range buildPlan(report, opts.DeleteMode) {
+		var lines []string
+		for _, f := range g.remove {
+			for _, p := range filePaths(f) {
+				pattern, ok := excludePattern(opts.Root, p, opts.Format)
+				if !ok {
+					skipped++
+					continue
+				}
+				if g.reviewed {
+					pattern = "# " + pattern
+				}
+				lines = append(lines, pattern)
+			}
+			if !g.reviewed {
+				bytes += f.Size
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if !g.reviewed {
+			count += len(lines)
+		}
+		body.WriteString(fmt.Sprintf("\n# %s (keeping %s)\n", strings.ReplaceAll(g.title, "\n", " "), strings.ReplaceAll(g.keep.Name, "\n", " ")))
+		if g.reviewed {
+			body.WriteString("# Suggestion: review, then uncomment to leave these out\n")
+		}
+		for _, line := range lines {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# Archive Duplicate Finder exclusion list, %s\n", time.Now().Format(time.RFC3339)))
+	b.WriteString(fmt.Sprintf("# %d duplicates left out (%s) of %s\n", count, formatBytes(bytes), opts.Root))
+	if skipped > 0 {
+		b.WriteString(fmt.Sprintf("# %d files outside that folder or with unmatchable names are not listed\n", skipped))
+	}
+	root := strings.TrimSuffix(filepath.ToSlash(opts.Root), "/")
+	if opts.Format == ExcludeRclone {
+		b.WriteString(fmt.Sprintf("# Usage: rclone sync --filter-from <this file> %s <destination>\n", root))
+	} else {
+		b.WriteString(fmt.Sprintf("# Usage: rsync -a --exclude-from=<this file> %s/ <destination>\n", root))
+	}
+	b.WriteString(body.String())
+	return b.String()
+}
+
+// excludePattern anchors path to root ("/sub/file.zip") and escapes the characters the format
+// reads as wildcards
+func excludePattern(root, path, format string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") || strings.ContainsAny(rel, "\r\n") {
+		return "", false
+	}
+	rel = "/" + filepath.ToSlash(rel)
+	if format == ExcludeRclone {
+		return "- " + escapeGlob(rel, `\*?[]{}`), true
+	}
+	// rsync only reads backslashes as escapes in patterns holding a wildcard
+	if strings.ContainsAny(rel, "*?[") {
+		rel = escapeGlob(rel, `\*?[`)
+	}
+	return rel, true
+}
+
+func escapeGlob(s, special string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
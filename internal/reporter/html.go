@@ -0,0 +1,133 @@
+package reporter
+
+import (
+	"archive-duplicate-finder/internal/i18n"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// htmlGroup is a group as the HTML report draws it
+type htmlGroup struct {
+	Title  string
+	Review string
+	Note   string
+	Files  []FileInfo
+}
+
+// htmlSection is a kind of group: identical, similar names or visually similar
+type htmlSection struct {
+	Title  string
+	Groups []htmlGroup
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"t":     func(msg string, args ...any) string { return i18n.T(msg, args...) },
+	"bytes": formatBytes,
+	"thumb": func(uri string) template.URL {
+		// Only the JPEG data URIs made for exports are trusted as image sources
+		if strings.HasPrefix(uri, "data:image/jpeg;base64,") {
+			return template.URL(uri)
+		}
+		return ""
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{t "Archive Duplicate Finder Report"}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em auto; max-width: 1100px; color: #222; }
+h1 { color: #036; }
+h2 { background: #e6e6e6; padding: .3em .5em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+td, th { padding: .25em .5em; text-align: left; vertical-align: middle; }
+tr:nth-child(even) { background: #f6f6f6; }
+.path, .note { color: #666; font-size: .85em; }
+.error { color: #b00; }
+.size { white-space: nowrap; text-align: right; }
+img { max-width: 80px; max-height: 80px; }
+</style>
+</head>
+<body>
+<h1>{{t "Archive Duplicate Finder Report"}}</h1>
+<h3>{{t "Analysis Summary"}}</h3>
+<table>
+<tr><th>{{t "Timestamp:"}}</th><td>{{.Report.Timestamp}}</td></tr>
+<tr><th>{{t "Total Files Analyzed:"}}</th><td>{{.Report.TotalFiles}}</td></tr>
+<tr><th>{{t "Identical Size Groups:"}}</th><td>{{len .Report.SizeGroups}}</td></tr>
+<tr><th>{{t "Similar Groups:"}}</th><td>{{len .Report.SimilarGroups}}</td></tr>
+{{- if .Report.VisualGroups}}
+<tr><th>{{t "Visual Groups:"}}</th><td>{{len .Report.VisualGroups}}</td></tr>
+{{- end}}
+{{- if .Report.CorruptCount}}
+<tr><th>{{t "Corrupt Archives:"}}</th><td>{{.Report.CorruptCount}}</td></tr>
+{{- end}}
+<tr><th>{{t "Analysis Duration:"}}</th><td>{{printf "%.2fs" .Report.AnalysisDuration}}</td></tr>
+</table>
+{{range .Sections}}{{if .Groups}}
+<h2>{{.Title}}</h2>
+{{range .Groups}}
+<h4>{{.Title}}{{if .Review}} [{{t .Review}}]{{end}}</h4>
+{{if .Note}}<p class="note">{{t "Note: "}}{{.Note}}</p>{{end}}
+<table>
+{{- range .Files}}
+<tr>
+<td>{{with thumb .Thumbnail}}<img src="{{.}}" alt="">{{end}}</td>
+<td>{{.Name}}{{if .Protected}} 🔒{{end}}<div class="path">{{.Path}}</div>{{if .Suspicious}}<div class="error">{{.Suspicious}}</div>{{end}}</td>
+<td class="size">{{bytes .Size}}</td>
+<td>{{.ModTime}}</td>
+</tr>
+{{- end}}
+</table>
+{{end}}{{end}}{{end}}
+{{- if .Report.CorruptFiles}}
+<h2>{{t "Unreadable / Corrupt Archives"}}</h2>
+<table>
+{{- range .Report.CorruptFiles}}
+<tr><td>{{.Name}}<div class="path">{{.Path}}</div><div class="error">{{.Error}}</div></td><td class="size">{{bytes .Size}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<p class="note">{{t "Generated by Archive Duplicate Finder"}}</p>
+</body>
+</html>
+`))
+
+// WriteHTML renders the report as a single self-contained HTML page. Thumbnails embedded by the
+// export (see visual.AttachPreviews) are shown next to their files.
+func WriteHTML(w io.Writer, report Report) error {
+	sections := []htmlSection{
+		{Title: i18n.T("Files with Identical Size")},
+		{Title: i18n.T("Files with Similar Names (Clusters)")},
+		{Title: i18n.T("Visually Similar Files")},
+	}
+	for i, g := range report.SizeGroups {
+		title := i18n.T("Group %d - Size: %s", i+1, formatBytes(g.Size))
+		if g.Payload {
+			title = i18n.T("Group %d - Same decompressed contents: %s", i+1, formatBytes(g.Size))
+		}
+		sections[0].Groups = append(sections[0].Groups, htmlGroup{title, g.Review, g.Note, g.Files})
+	}
+	for i, g := range report.SimilarGroups {
+		title := i18n.T("Cluster %d - Base: '%s'", i+1, g.BaseName)
+		if g.Series {
+			title += i18n.T(" (probable series, not duplicates)")
+		}
+		sections[1].Groups = append(sections[1].Groups, htmlGroup{title, g.Review, g.Note, g.Files})
+	}
+	for i, g := range report.VisualGroups {
+		title := i18n.T("Cluster %d - Base: '%s'", i+1, g.BaseName)
+		sections[2].Groups = append(sections[2].Groups, htmlGroup{title, g.Review, g.Note, g.Files})
+	}
+
+	err := htmlReport.Execute(w, struct {
+		Report   Report
+		Sections []htmlSection
+	}{report, sections})
+	if err != nil {
+		return fmt.Errorf("failed to render HTML: %w", err)
+	}
+	return nil
+}
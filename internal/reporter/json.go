@@ -1,11 +1,13 @@
 package reporter
 
 import (
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/scanner"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"os"
 	"sort"
+	"time"
 )
 
 // CalculateHash returns a unique hash for the group based on member file paths
@@ -23,6 +25,136 @@ func CalculateGroupHash(files []FileInfo) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// Kinds of groups, each with their own stable IDs (see WithGroupIDs)
+const (
+	KindSize    = "size"
+	KindSimilar = "similar"
+	KindVisual  = "visual"
+)
+
+// WithGroupIDs returns a copy of the report whose groups carry a stable ID. assign is given
+// the member paths of every group of one kind and returns an ID for each; it is only called
+// for the kinds with a group that has no ID yet (published groups keep theirs when files are
+// removed or moved).
+func WithGroupIDs(report Report, assign func(kind string, groups [][]string) []string) Report {
+	if needsIDs(len(report.SizeGroups), func(i int) string { return report.SizeGroups[i].ID }) {
+		paths := make([][]string, len(report.SizeGroups))
+		for i, g := range report.SizeGroups {
+			paths[i] = memberPaths(g.Files)
+		}
+		ids := assign(KindSize, paths)
+		report.SizeGroups = append([]SizeGroup(nil), report.SizeGroups...)
+		for i := range report.SizeGroups {
+			report.SizeGroups[i].ID = ids[i]
+		}
+	}
+	withIDs := func(kind string, groups []SimilarityGroup) []SimilarityGroup {
+		if !needsIDs(len(groups), func(i int) string { return groups[i].ID }) {
+			return groups
+		}
+		paths := make([][]string, len(groups))
+		for i, g := range groups {
+			paths[i] = memberPaths(g.Files)
+		}
+		ids := assign(kind, paths)
+		groups = append([]SimilarityGroup(nil), groups...)
+		for i := range groups {
+			groups[i].ID = ids[i]
+		}
+		return groups
+	}
+	report.SimilarGroups = withIDs(KindSimilar, report.SimilarGroups)
+	report.VisualGroups = withIDs(KindVisual, report.VisualGroups)
+	return report
+}
+
+// Review statuses of a group, for triage over several sessions
+const (
+	ReviewPending  = "pending" // Not looked at yet (groups without a status)
+	ReviewReviewed = "reviewed"
+	ReviewResolved = "resolved"
+)
+
+// IsReviewStatus reports whether status is one of the review statuses
+func IsReviewStatus(status string) bool {
+	return status == ReviewPending || status == ReviewReviewed || status == ReviewResolved
+}
+
+// WithReviews returns a copy of the report whose groups carry the review status and note
+// recorded for their ID; review returns "" for groups without one
+func WithReviews(report Report, review func(id string) (status, note string)) Report {
+	if report.SizeGroups != nil {
+		sizeGroups := make([]SizeGroup, len(report.SizeGroups))
+		for i, g := range report.SizeGroups {
+			if g.ID != "" {
+				g.Review, g.Note = review(g.ID)
+			}
+			sizeGroups[i] = g
+		}
+		report.SizeGroups = sizeGroups
+	}
+	withReviews := func(groups []SimilarityGroup) []SimilarityGroup {
+		if groups == nil {
+			return nil
+		}
+		reviewed := make([]SimilarityGroup, len(groups))
+		for i, g := range groups {
+			if g.ID != "" {
+				g.Review, g.Note = review(g.ID)
+			}
+			reviewed[i] = g
+		}
+		return reviewed
+	}
+	report.SimilarGroups = withReviews(report.SimilarGroups)
+	report.VisualGroups = withReviews(report.VisualGroups)
+	return report
+}
+
+// FilterByReview returns a copy of the report keeping the groups with a review status;
+// ReviewPending also keeps the groups without one
+func FilterByReview(report Report, status string) Report {
+	matches := func(review string) bool {
+		return review == status || (status == ReviewPending && review == "")
+	}
+	var sizeGroups []SizeGroup
+	for _, g := range report.SizeGroups {
+		if matches(g.Review) {
+			sizeGroups = append(sizeGroups, g)
+		}
+	}
+	filter := func(groups []SimilarityGroup) []SimilarityGroup {
+		var kept []SimilarityGroup
+		for _, g := range groups {
+			if matches(g.Review) {
+				kept = append(kept, g)
+			}
+		}
+		return kept
+	}
+	report.SizeGroups = sizeGroups
+	report.SimilarGroups = filter(report.SimilarGroups)
+	report.VisualGroups = filter(report.VisualGroups)
+	return report
+}
+
+func needsIDs(n int, id func(i int) string) bool {
+	for i := 0; i < n; i++ {
+		if id(i) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func memberPaths(files []FileInfo) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return paths
+}
+
 func (g SizeGroup) Hash() string {
 	return CalculateGroupHash(g.Files)
 }
@@ -33,38 +165,247 @@ func (g SimilarityGroup) Hash() string {
 
 // Report represents the analysis results
 type Report struct {
-	TotalFiles       int               `json:"total_files"`
-	SizeGroups       []SizeGroup       `json:"size_groups"`
-	SimilarGroups    []SimilarityGroup `json:"similar_groups"`
-	SimilarCount     int               `json:"similar_count"`
-	VisualGroups     []SimilarityGroup `json:"visual_groups"`
-	VisualCount      int               `json:"visual_count"`
-	AnalysisDuration float64           `json:"analysis_duration_seconds"`
-	Timestamp        string            `json:"timestamp"`
-	Status           string            `json:"status"`   // "analyzing", "finished"
-	Progress         float64           `json:"progress"` // 0.0 to 100.0
+	TotalFiles       int                      `json:"total_files"`
+	SizeGroups       []SizeGroup              `json:"size_groups"`
+	SimilarGroups    []SimilarityGroup        `json:"similar_groups"`
+	SimilarCount     int                      `json:"similar_count"`
+	VisualGroups     []SimilarityGroup        `json:"visual_groups"`
+	VisualCount      int                      `json:"visual_count"`
+	CorruptFiles     []CorruptFile            `json:"corrupt_files,omitempty"`   // Only filled by the integrity check (--verify)
+	PreviewLinks     []PreviewLink            `json:"preview_links,omitempty"`   // Loose images showing the same render as an archive's preview (visual analysis)
+	ClusterMetrics   *ClusterMetrics          `json:"cluster_metrics,omitempty"` // Quality of the Step 3 clusters, with a threshold suggestion
+	ByDir            []DirGroup               `json:"by_dir,omitempty"`          // Duplicates rolled up per directory, when asked for (see GroupByDirs)
+	CorruptCount     int                      `json:"corrupt_count"`
+	AnalysisDuration float64                  `json:"analysis_duration_seconds"`
+	Timestamp        string                   `json:"timestamp"`
+	Status           string                   `json:"status"`           // "analyzing", "finished"
+	Progress         float64                  `json:"progress"`         // 0.0 to 100.0
+	Phases           map[string]PhaseProgress `json:"phases,omitempty"` // Per-phase progress and ETA ("scan", "step3", "visual"...)
+}
+
+// PhaseProgress is the byte-weighted progress of one analysis phase
+type PhaseProgress struct {
+	Progress       float64 `json:"progress"` // 0.0 to 100.0
+	DoneBytes      int64   `json:"done_bytes"`
+	TotalBytes     int64   `json:"total_bytes"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	ETASeconds     float64 `json:"eta_seconds"`
+	Finished       bool    `json:"finished"`
+}
+
+// SetPhase records the progress of a phase. The map is replaced rather than mutated so that
+// a dashboard serializing the report never sees it change underneath.
+func (r *Report) SetPhase(name string, p PhaseProgress) {
+	phases := make(map[string]PhaseProgress, len(r.Phases)+1)
+	for k, v := range r.Phases {
+		phases[k] = v
+	}
+	phases[name] = p
+	r.Phases = phases
+}
+
+// PreviewLink relates an archive to a loose image next to it that shows the same picture as
+// its preview, typically the render saved beside the download
+type PreviewLink struct {
+	Archive  FileInfo `json:"archive"`
+	Image    FileInfo `json:"image"`
+	Distance int      `json:"distance"` // Hamming distance between the two perceptual hashes (0 = same picture)
+}
+
+// ClusterMetrics rates the similar-name clusters of Step 3 and suggests a threshold when they
+// look too loose (members joined through chains of names) or too tight (near misses left out)
+type ClusterMetrics struct {
+	Threshold  int     `json:"threshold"`
+	Clusters   int     `json:"clusters"`
+	Files      int     `json:"files"`
+	Cohesion   float64 `json:"cohesion"`    // Average similarity (0-100) of the members of a cluster to its base name
+	Separation float64 `json:"separation"`  // Average similarity (0-100) of a cluster to the closest name left out of it, over the clusters with one
+	Silhouette float64 `json:"silhouette"`  // Average (cohesion - separation) / max of both, from -1 (mixed up) to 1 (well apart)
+	Chained    float64 `json:"chained"`     // Share of clustered files less similar to their base name than the threshold
+	NearMisses int     `json:"near_misses"` // Name pairs left unmerged within 10 points below the threshold
+
+	UnscoredPairs int `json:"unscored_pairs,omitempty"` // At most this many candidate pairs of buckets shared by too many names were not scored
+
+	SuggestedThreshold int    `json:"suggested_threshold,omitempty"` // Threshold that would fix a loose or tight clustering
+	Advice             string `json:"advice,omitempty"`              // Why the suggestion is made, for the summary
+
+	Cache *ClusterCache `json:"cache,omitempty"` // How much of the clustering came from the similarity cache
+}
+
+// ClusterCache tells how Step 3 used the clusters saved by the previous run on the same files
+// and settings
+type ClusterCache struct {
+	Hit          bool `json:"hit"`           // No file changed: every cluster was reused
+	Reused       int  `json:"reused"`        // Clusters taken from the cache
+	Rebuilt      int  `json:"rebuilt"`       // Clusters built again because a file near them changed
+	ChangedFiles int  `json:"changed_files"` // Files added, removed or modified since the saved clusters
 }
 
 // SizeGroup represents files with identical size
 type SizeGroup struct {
-	Size  int64      `json:"size"`
-	Files []FileInfo `json:"files"`
+	ID       string     `json:"id,omitempty"` // Stable across re-scans while most members stay (see WithGroupIDs)
+	Size     int64      `json:"size"`
+	Files    []FileInfo `json:"files"`
+	Payload  bool       `json:"payload,omitempty"`  // Compressed archives with the same decompressed contents; Size is the payload size
+	Verified bool       `json:"verified,omitempty"` // Contents confirmed identical by hash, not only the size
+	Method   string     `json:"method,omitempty"`   // Analysis profile that matched the files ("manifest", "geometry"...); empty for size and name; Size is the largest file when sizes differ
+	Review   string     `json:"review,omitempty"`   // Review status: "pending", "reviewed" or "resolved"
+	Note     string     `json:"note,omitempty"`     // Free-text note of the review
 }
 
 // SimilarityGroup represents a cluster of similar files
 type SimilarityGroup struct {
+	ID       string     `json:"id,omitempty"` // Stable across re-scans while most members stay (see WithGroupIDs)
 	BaseName string     `json:"base_name"`
 	Files    []FileInfo `json:"files"`
+	Series   bool       `json:"series,omitempty"` // Probable series ("Chapter 01", "Chapter 02"), not duplicates
+	Review   string     `json:"review,omitempty"` // Review status: "pending", "reviewed" or "resolved"
+	Note     string     `json:"note,omitempty"`   // Free-text note of the review
 }
 
 // FileInfo represents basic file information
 type FileInfo struct {
-	Name    string `json:"name"`
-	Path    string `json:"path"`
-	Size    int64  `json:"size"`
-	Type    string `json:"type"`
-	ModTime string `json:"mod_time"`
-	PHash   uint64 `json:"p_hash,omitempty"`
+	Name       string   `json:"name"`
+	Path       string   `json:"path"`
+	Size       int64    `json:"size"`
+	Type       string   `json:"type"`
+	ModTime    string   `json:"mod_time"`
+	PHash      uint64   `json:"p_hash,omitempty"`
+	Score      *float64 `json:"score,omitempty"`      // Similarity (0-100) against the cluster centroid; nil outside similarity groups
+	Volumes    []string `json:"volumes,omitempty"`    // All part paths of a multi-volume set
+	Protected  bool     `json:"protected,omitempty"`  // Matches a protection rule: kept, never a deletion candidate
+	Suspicious string   `json:"suspicious,omitempty"` // Why the archive looks like a zip bomb; its oversized entries were skipped
+
+	FileCount        int   `json:"file_count,omitempty"`        // Entries inside (pages for comics and EPUBs), when known
+	UncompressedSize int64 `json:"uncompressed_size,omitempty"` // Total size of the contents, when known
+
+	Preview     string `json:"preview,omitempty"`      // Entry of the archive it is shown by
+	PreviewFile string `json:"preview_file,omitempty"` // Preview on disk: the cached copy of that entry, or a loose image itself
+	Thumbnail   string `json:"thumbnail,omitempty"`    // Small JPEG of the preview as a data URI, when requested
+}
+
+// Similarity returns the score of the file, 0 when it has none
+func (f FileInfo) Similarity() float64 {
+	if f.Score == nil {
+		return 0
+	}
+	return *f.Score
+}
+
+// NewFileInfo describes a scanned file for a report
+func NewFileInfo(f scanner.ArchiveFile) FileInfo {
+	return FileInfo{
+		Name:             f.Name,
+		Path:             f.Path,
+		Size:             f.Size,
+		Type:             f.Type,
+		ModTime:          f.ModTime.Format(time.RFC3339),
+		Volumes:          f.Volumes,
+		FileCount:        f.FileCount,
+		UncompressedSize: f.UncompressedSize,
+	}
+}
+
+// WithProtected returns a copy of the report whose group members carry the Protected flag
+func WithProtected(report Report, isProtected func(path string) bool) Report {
+	return markFiles(report, func(f *FileInfo) { f.Protected = isProtected(f.Path) })
+}
+
+// WithSuspicious returns a copy of the report whose group members carry the reason they were
+// flagged as possible zip bombs
+func WithSuspicious(report Report, reason func(path string) string) Report {
+	return markFiles(report, func(f *FileInfo) { f.Suspicious = reason(f.Path) })
+}
+
+// WithPreviews returns a copy of the report whose group members carry their preview (see
+// FileInfo.Preview, PreviewFile and Thumbnail)
+func WithPreviews(report Report, preview func(f FileInfo) (entry, file, thumbnail string)) Report {
+	return markFiles(report, func(f *FileInfo) { f.Preview, f.PreviewFile, f.Thumbnail = preview(*f) })
+}
+
+// markFiles returns a copy of the report with set applied to every group member
+func markFiles(report Report, set func(f *FileInfo)) Report {
+	mark := func(files []FileInfo) []FileInfo {
+		marked := make([]FileInfo, len(files))
+		for i, f := range files {
+			set(&f)
+			marked[i] = f
+		}
+		return marked
+	}
+	markGroups := func(groups []SimilarityGroup) []SimilarityGroup {
+		if groups == nil {
+			return nil
+		}
+		marked := make([]SimilarityGroup, len(groups))
+		for i, g := range groups {
+			g.Files = mark(g.Files)
+			marked[i] = g
+		}
+		return marked
+	}
+
+	if report.SizeGroups != nil {
+		sizeGroups := make([]SizeGroup, len(report.SizeGroups))
+		for i, g := range report.SizeGroups {
+			g.Files = mark(g.Files)
+			sizeGroups[i] = g
+		}
+		report.SizeGroups = sizeGroups
+	}
+	report.SimilarGroups = markGroups(report.SimilarGroups)
+	report.VisualGroups = markGroups(report.VisualGroups)
+	return report
+}
+
+// Suppressed reports whether a group is made only of copies of one content the user suppressed,
+// as told by suppressed from the paths. Files of different sizes are never handed to it, so
+// similarity clusters are not hashed to find out.
+func Suppressed(files []FileInfo, suppressed func(paths []string) bool) bool {
+	if len(files) < 2 {
+		return false
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		if f.Size != files[0].Size {
+			return false
+		}
+		paths[i] = f.Path
+	}
+	return suppressed(paths)
+}
+
+// WithoutSuppressed returns a copy of the report without the groups of suppressed content (see
+// Suppressed), for the exports
+func WithoutSuppressed(report Report, suppressed func(paths []string) bool) Report {
+	keep := func(groups []SimilarityGroup) []SimilarityGroup {
+		var kept []SimilarityGroup
+		for _, g := range groups {
+			if !Suppressed(g.Files, suppressed) {
+				kept = append(kept, g)
+			}
+		}
+		return kept
+	}
+
+	var sizeGroups []SizeGroup
+	for _, g := range report.SizeGroups {
+		if !Suppressed(g.Files, suppressed) {
+			sizeGroups = append(sizeGroups, g)
+		}
+	}
+	report.SizeGroups = sizeGroups
+	report.SimilarGroups = keep(report.SimilarGroups)
+	report.VisualGroups = keep(report.VisualGroups)
+	report.SimilarCount = len(report.SimilarGroups)
+	report.VisualCount = len(report.VisualGroups)
+	return report
+}
+
+// CorruptFile is an archive that failed the integrity check
+type CorruptFile struct {
+	FileInfo
+	Error string `json:"error"`
 }
 
 // ExportJSON exports the report to a JSON file
@@ -74,7 +415,7 @@ func ExportJSON(report Report, filename string) error {
 		return fmt.Errorf("failed to marshal JSON: %w", err)
 	}
 
-	err = os.WriteFile(filename, data, 0644)
+	err = fsutil.WriteFileAtomic(filename, data, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
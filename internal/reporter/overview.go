@@ -0,0 +1,150 @@
+package reporter
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Summary is the totals of a run, as kept in the scan history to follow a library over time
+type Summary struct {
+	Files            int   `json:"files"`
+	Bytes            int64 `json:"bytes"`
+	DuplicateGroups  int   `json:"duplicate_groups"`  // Identical groups the cleanup plan acts on
+	DuplicateFiles   int   `json:"duplicate_files"`   // Copies it removes
+	ReclaimableBytes int64 `json:"reclaimable_bytes"` // Their size
+}
+
+// Summarize totals the scanned files and the cleanup plan of the report
+func Summarize(report Report, files []FileInfo, deleteMode string) Summary {
+	s := Summary{Files: len(files)}
+	for _, f := range files {
+		s.Bytes += f.Size
+	}
+	for _, g := range buildPlan(report, deleteMode) {
+		if g.reviewed {
+			continue
+		}
+		s.DuplicateGroups++
+		s.DuplicateFiles += len(g.remove)
+		s.ReclaimableBytes += redundantBytes(g)
+	}
+	return s
+}
+
+// Sub returns the change from an earlier summary to s
+func (s Summary) Sub(earlier Summary) Summary {
+	return Summary{
+		Files:            s.Files - earlier.Files,
+		Bytes:            s.Bytes - earlier.Bytes,
+		DuplicateGroups:  s.DuplicateGroups - earlier.DuplicateGroups,
+		DuplicateFiles:   s.DuplicateFiles - earlier.DuplicateFiles,
+		ReclaimableBytes: s.ReclaimableBytes - earlier.ReclaimableBytes,
+	}
+}
+
+// TypeStats is the share of one file extension in the library and in its duplicates
+type TypeStats struct {
+	Ext            string `json:"ext"` // ".zip", ".tar.gz"... ("" for files without one)
+	Files          int    `json:"files"`
+	Bytes          int64  `json:"bytes"`
+	DuplicateFiles int    `json:"duplicate_files"` // Copies the cleanup plan removes
+	DuplicateBytes int64  `json:"duplicate_bytes"`
+}
+
+// GroupStats describes an identical group of the cleanup plan by the space it wastes
+type GroupStats struct {
+	ID               string     `json:"id,omitempty"`
+	Name             string     `json:"name"` // Of the copy kept
+	Size             int64      `json:"size"`
+	Copies           int        `json:"copies"`
+	ReclaimableBytes int64      `json:"reclaimable_bytes"`
+	Files            []FileInfo `json:"files"` // The copy kept first
+}
+
+// Overview breaks the library down for the overview page: per extension, its largest files and
+// the groups wasting the most space
+type Overview struct {
+	ByType        []TypeStats  `json:"by_type"` // Most bytes first
+	LargestFiles  []FileInfo   `json:"largest_files"`
+	LargestGroups []GroupStats `json:"largest_groups"`
+}
+
+// NewOverview builds the overview of the scanned files and the report, listing top of the
+// largest files and groups
+func NewOverview(report Report, files []FileInfo, deleteMode string, top int) Overview {
+	o := Overview{ByType: []TypeStats{}, LargestFiles: []FileInfo{}, LargestGroups: []GroupStats{}}
+
+	byExt := make(map[string]*TypeStats)
+	stats := func(path string) *TypeStats {
+		ext := fileExt(path)
+		t, ok := byExt[ext]
+		if !ok {
+			t = &TypeStats{Ext: ext}
+			byExt[ext] = t
+		}
+		return t
+	}
+	for _, f := range files {
+		t := stats(f.Path)
+		t.Files++
+		t.Bytes += f.Size
+	}
+
+	ids := make(map[string]string) // Path -> ID of its identical group
+	for _, g := range report.SizeGroups {
+		for _, f := range g.Files {
+			ids[f.Path] = g.ID
+		}
+	}
+	for _, g := range buildPlan(report, deleteMode) {
+		if g.reviewed {
+			continue
+		}
+		for _, f := range g.remove {
+			t := stats(f.Path)
+			t.DuplicateFiles++
+			t.DuplicateBytes += f.Size
+		}
+		o.LargestGroups = append(o.LargestGroups, GroupStats{
+			ID:               ids[g.keep.Path],
+			Name:             g.keep.Name,
+			Size:             g.keep.Size,
+			Copies:           len(g.remove) + 1,
+			ReclaimableBytes: redundantBytes(g),
+			Files:            append([]FileInfo{g.keep}, g.remove...),
+		})
+	}
+
+	for _, t := range byExt {
+		o.ByType = append(o.ByType, *t)
+	}
+	sort.Slice(o.ByType, func(i, j int) bool {
+		if o.ByType[i].Bytes != o.ByType[j].Bytes {
+			return o.ByType[i].Bytes > o.ByType[j].Bytes
+		}
+		return o.ByType[i].Ext < o.ByType[j].Ext
+	})
+
+	o.LargestFiles = append(o.LargestFiles, files...)
+	sort.SliceStable(o.LargestFiles, func(i, j int) bool { return o.LargestFiles[i].Size > o.LargestFiles[j].Size })
+	if len(o.LargestFiles) > top {
+		o.LargestFiles = o.LargestFiles[:top]
+	}
+	sort.SliceStable(o.LargestGroups, func(i, j int) bool {
+		return o.LargestGroups[i].ReclaimableBytes > o.LargestGroups[j].ReclaimableBytes
+	})
+	if len(o.LargestGroups) > top {
+		o.LargestGroups = o.LargestGroups[:top]
+	}
+	return o
+}
+
+// fileExt is the lowercase extension of a file, keeping ".tar" in front of a compression one
+func fileExt(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if stem := strings.ToLower(strings.TrimSuffix(path, filepath.Ext(path))); strings.HasSuffix(stem, ".tar") {
+		return ".tar" + ext
+	}
+	return ext
+}
@@ -1,42 +1,77 @@
 package reporter
 
 import (
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/i18n"
+	"bytes"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/go-pdf/fpdf"
 )
 
 // ExportPDF generates a PDF report based on the analysis results
 func ExportPDF(report Report, filename string) error {
+	return ExportPDFWithProgress(report, filename, nil)
+}
+
+// ExportPDFWithProgress generates the PDF report, calling onProgress after every rendered group
+func ExportPDFWithProgress(report Report, filename string, onProgress func(done, total int)) error {
+	return fsutil.WriteAtomic(filename, 0644, func(w io.Writer) error {
+		return WritePDF(w, report, onProgress)
+	})
+}
+
+// WritePDF renders the PDF report to w, calling onProgress (if not nil) after every group
+func WritePDF(w io.Writer, report Report, onProgress func(done, total int)) error {
+	totalGroups := len(report.SizeGroups) + len(report.SimilarGroups)
+	doneGroups := 0
+	groupRendered := func() {
+		doneGroups++
+		if onProgress != nil {
+			onProgress(doneGroups, totalGroups)
+		}
+	}
+
 	pdf := fpdf.New("P", "mm", "A4", "")
 	pdf.AddPage()
+	// The core fonts are in cp1252: accented labels and file names are converted to it
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	label := func(message string, args ...any) string { return tr(i18n.T(message, args...)) }
 
 	// Header
 	pdf.SetFont("Arial", "B", 20)
 	pdf.SetTextColor(0, 51, 102)
-	pdf.Cell(190, 15, "Archive Duplicate Finder Report")
+	pdf.Cell(190, 15, label("Archive Duplicate Finder Report"))
 	pdf.Ln(15)
 
 	// Summary Section
 	pdf.SetFont("Arial", "B", 14)
 	pdf.SetTextColor(0, 0, 0)
-	pdf.Cell(190, 10, "Analysis Summary")
+	pdf.Cell(190, 10, label("Analysis Summary"))
 	pdf.Ln(10)
 
 	pdf.SetFont("Arial", "", 11)
-	pdf.Cell(50, 8, "Timestamp:")
+	pdf.Cell(50, 8, label("Timestamp:"))
 	pdf.Cell(140, 8, report.Timestamp)
 	pdf.Ln(8)
-	pdf.Cell(50, 8, "Total Files Analyzed:")
+	pdf.Cell(50, 8, label("Total Files Analyzed:"))
 	pdf.Cell(140, 8, fmt.Sprintf("%d", report.TotalFiles))
 	pdf.Ln(8)
-	pdf.Cell(50, 8, "Identical Size Groups:")
+	pdf.Cell(50, 8, label("Identical Size Groups:"))
 	pdf.Cell(140, 8, fmt.Sprintf("%d", len(report.SizeGroups)))
 	pdf.Ln(8)
-	pdf.Cell(50, 8, "Similar Groups:")
+	pdf.Cell(50, 8, label("Similar Groups:"))
 	pdf.Cell(140, 8, fmt.Sprintf("%d", len(report.SimilarGroups)))
 	pdf.Ln(8)
-	pdf.Cell(50, 8, "Analysis Duration:")
+	if report.CorruptCount > 0 {
+		pdf.Cell(50, 8, label("Corrupt Archives:"))
+		pdf.Cell(140, 8, fmt.Sprintf("%d", report.CorruptCount))
+		pdf.Ln(8)
+	}
+	pdf.Cell(50, 8, label("Analysis Duration:"))
 	pdf.Cell(140, 8, fmt.Sprintf("%.2fs", report.AnalysisDuration))
 	pdf.Ln(15)
 
@@ -44,22 +79,29 @@ func ExportPDF(report Report, filename string) error {
 	if len(report.SizeGroups) > 0 {
 		pdf.SetFont("Arial", "B", 14)
 		pdf.SetFillColor(230, 230, 230)
-		pdf.CellFormat(190, 10, "Files with Identical Size", "1", 1, "L", true, 0, "")
+		pdf.CellFormat(190, 10, label("Files with Identical Size"), "1", 1, "L", true, 0, "")
 
 		for i, group := range report.SizeGroups {
 			pdf.SetFont("Arial", "I", 11)
-			pdf.Cell(190, 8, fmt.Sprintf("Group %d - Size: %s", i+1, formatBytes(group.Size)))
+			if group.Payload {
+				pdf.Cell(190, 8, label("Group %d - Same decompressed contents: %s", i+1, formatBytes(group.Size))+tr(reviewLabel(group.Review)))
+			} else {
+				pdf.Cell(190, 8, label("Group %d - Size: %s", i+1, formatBytes(group.Size))+tr(reviewLabel(group.Review)))
+			}
 			pdf.Ln(8)
+			writeNote(pdf, tr, group.Note)
 
 			pdf.SetFont("Arial", "", 10)
 			for _, file := range group.Files {
+				rowHeight, width := drawThumbnail(pdf, file, 180)
 				pdf.SetTextColor(100, 100, 100)
 				pdf.Cell(10, 6, "[-] ")
 				pdf.SetTextColor(0, 0, 0)
-				pdf.Cell(180, 6, file.Name)
-				pdf.Ln(6)
+				pdf.Cell(width, 6, tr(file.Name))
+				pdf.Ln(rowHeight)
 			}
 			pdf.Ln(4)
+			groupRendered()
 
 			if pdf.GetY() > 250 {
 				pdf.AddPage()
@@ -72,23 +114,30 @@ func ExportPDF(report Report, filename string) error {
 	if len(report.SimilarGroups) > 0 {
 		pdf.SetFont("Arial", "B", 14)
 		pdf.SetFillColor(230, 230, 230)
-		pdf.CellFormat(190, 10, "Files with Similar Names (Clusters)", "1", 1, "L", true, 0, "")
+		pdf.CellFormat(190, 10, label("Files with Similar Names (Clusters)"), "1", 1, "L", true, 0, "")
 
 		for i, group := range report.SimilarGroups {
 			pdf.SetFont("Arial", "I", 11)
-			pdf.Cell(190, 8, fmt.Sprintf("Cluster %d - Base: '%s'", i+1, group.BaseName))
+			title := label("Cluster %d - Base: '%s'", i+1, group.BaseName)
+			if group.Series {
+				title += label(" (probable series, not duplicates)")
+			}
+			pdf.Cell(190, 8, title+tr(reviewLabel(group.Review)))
 			pdf.Ln(8)
+			writeNote(pdf, tr, group.Note)
 
 			pdf.SetFont("Arial", "", 10)
 			for _, file := range group.Files {
 				pdf.SetTextColor(0, 0, 0)
-				pdf.Cell(130, 6, file.Name)
+				rowHeight, width := drawThumbnail(pdf, file, 130)
+				pdf.Cell(width, 6, tr(file.Name))
 				pdf.SetTextColor(100, 100, 100)
 				pdf.Cell(50, 6, formatBytes(file.Size))
-				pdf.Ln(6)
+				pdf.Ln(rowHeight)
 			}
 			pdf.SetTextColor(0, 0, 0)
 			pdf.Ln(4)
+			groupRendered()
 
 			if pdf.GetY() > 250 {
 				pdf.AddPage()
@@ -96,13 +145,97 @@ func ExportPDF(report Report, filename string) error {
 		}
 	}
 
+	// Corrupt Archives Section
+	if len(report.CorruptFiles) > 0 {
+		if pdf.GetY() > 230 {
+			pdf.AddPage()
+		}
+		pdf.Ln(10)
+		pdf.SetFont("Arial", "B", 14)
+		pdf.SetFillColor(250, 220, 220)
+		pdf.CellFormat(190, 10, label("Unreadable / Corrupt Archives"), "1", 1, "L", true, 0, "")
+		pdf.Ln(2)
+
+		for _, file := range report.CorruptFiles {
+			pdf.SetFont("Arial", "", 10)
+			pdf.SetTextColor(0, 0, 0)
+			pdf.Cell(130, 6, tr(file.Name))
+			pdf.SetTextColor(100, 100, 100)
+			pdf.Cell(50, 6, formatBytes(file.Size))
+			pdf.Ln(6)
+			pdf.SetFont("Arial", "I", 9)
+			pdf.SetTextColor(180, 0, 0)
+			pdf.MultiCell(190, 5, tr(file.Error), "", "L", false)
+			pdf.Ln(2)
+
+			if pdf.GetY() > 250 {
+				pdf.AddPage()
+			}
+		}
+		pdf.SetTextColor(0, 0, 0)
+	}
+
 	// Footer
 	pdf.SetY(-15)
 	pdf.SetFont("Arial", "I", 8)
 	pdf.SetTextColor(128, 128, 128)
-	pdf.CellFormat(0, 10, fmt.Sprintf("Page %d | Generated by Archive Duplicate Finder", pdf.PageNo()), "", 0, "C", false, 0, "")
+	pdf.CellFormat(0, 10, label("Page %d | Generated by Archive Duplicate Finder", pdf.PageNo()), "", 0, "C", false, 0, "")
+
+	return pdf.Output(w)
+}
 
-	return pdf.OutputFileAndClose(filename)
+// reviewLabel tags a group title with its review status
+func reviewLabel(status string) string {
+	if status == "" {
+		return ""
+	}
+	return " [" + i18n.T(status) + "]"
+}
+
+// writeNote prints the review note of a group under its title
+func writeNote(pdf *fpdf.Fpdf, tr func(string) string, note string) {
+	if note == "" {
+		return
+	}
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetTextColor(90, 90, 140)
+	pdf.MultiCell(190, 5, tr(i18n.T("Note: ")+note), "", "L", false)
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// thumbnailBox is the side of the square a file thumbnail is fitted in, in mm
+const thumbnailBox = 12.0
+
+// drawThumbnail draws the embedded thumbnail of a file (FileInfo.Thumbnail) at the start of
+// its row and moves past it, breaking the page first if the row would not fit. It returns the height of the row and the width left of the given cell width.
+func drawThumbnail(pdf *fpdf.Fpdf, f FileInfo, width float64) (float64, float64) {
+	encoded, ok := strings.CutPrefix(f.Thumbnail, "data:image/jpeg;base64,")
+	if !ok {
+		return 6, width
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return 6, width
+	}
+	if pdf.GetY()+thumbnailBox > 280 {
+		pdf.AddPage()
+	}
+
+	options := fpdf.ImageOptions{ImageType: "JPG"}
+	info := pdf.RegisterImageOptionsReader(f.Path, options, bytes.NewReader(data))
+	if info == nil {
+		return 6, width
+	}
+	w, h := thumbnailBox, thumbnailBox
+	if info.Width() > info.Height() {
+		h = thumbnailBox * info.Height() / info.Width()
+	} else {
+		w = thumbnailBox * info.Width() / info.Height()
+	}
+	x, y := pdf.GetXY()
+	pdf.ImageOptions(f.Path, x, y, w, h, false, options, 0, "")
+	pdf.SetX(x + thumbnailBox + 2)
+	return thumbnailBox + 2, width - thumbnailBox - 2
 }
 
 func formatBytes(bytes int64) string {
@@ -0,0 +1,415 @@
+package reporter
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/refnote"
+	"archive-duplicate-finder/internal/scanner"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ScriptOptions controls how the cleanup plan is rendered as a script
+type ScriptOptions struct {
+	Shell      string // "sh" or "powershell"
+	DeleteMode string // "oldest" keeps the newest file, "contents" the one with most entries and data; default "oldest"
+	TrashPath  string // Move duplicates here instead of deleting them
+	LeaveRef   bool   // Leave a .duplicate.txt note pointing to the preserved original
+
+	RefFormat   string // Note format, see refnote.FormatText and refnote.FormatJSON
+	RefTemplate string // Template of text notes; empty uses refnote.DefaultTemplate
+}
+
+// ScriptShell picks the script dialect from the output file name (.ps1 -> PowerShell)
+func ScriptShell(filename string) string {
+	if strings.EqualFold(filepath.Ext(filename), ".ps1") {
+		return "powershell"
+	}
+	return "sh"
+}
+
+// ExportScript writes the cleanup plan as a reviewed, commented shell or PowerShell script.
+// Nothing is touched on disk; administrators run the script through their own process.
+func ExportScript(report Report, filename string, opts ScriptOptions) error {
+	script := RenderScript(report, opts)
+	mode := os.FileMode(0644)
+	if opts.Shell != "powershell" {
+		mode = 0755
+	}
+	if err := fsutil.WriteFileAtomic(filename, []byte(script), mode); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// planGroup is one group of the cleanup plan: a preserved file and the files to remove
+type planGroup struct {
+	title    string
+	keep     FileInfo
+	remove   []FileInfo
+	reviewed bool // Fuzzy matches: actions are emitted commented out
+}
+
+// RenderScript renders the cleanup plan. Identical-size groups produce active commands;
+// similar-name and visual groups are only suggestions and are emitted commented out.
+func RenderScript(report Report, opts ScriptOptions) string {
+	plan := buildPlan(report, opts.DeleteMode)
+	w := scriptWriter{opts: opts}
+
+	w.header(report, plan)
+	for i, g := range plan {
+		w.group(i+1, g)
+	}
+	w.footer()
+	return w.String()
+}
+
+// ResolvedGroup is a group of the cleanup plan whose outcome needs no review: the file to keep
+// and the copies to remove
+type ResolvedGroup struct {
+	Title  string
+	Keep   FileInfo
+	Remove []FileInfo
+}
+
+// ResolveGroups returns the groups the cleanup plan would act on without review (identical
+// sizes), with the kept file chosen as in the script. Protected files are never removed.
+func ResolveGroups(report Report, deleteMode string) []ResolvedGroup {
+	var resolved []ResolvedGroup
+	for _, g := range buildPlan(report, deleteMode) {
+		if !g.reviewed {
+			resolved = append(resolved, ResolvedGroup{Title: g.title, Keep: g.keep, Remove: g.remove})
+		}
+	}
+	return resolved
+}
+
+func buildPlan(report Report, deleteMode string) []planGroup {
+	kept := make(map[string]bool)
+	handled := make(map[string]bool)
+	var plan []planGroup
+
+	add := func(title string, files []FileInfo, reviewed bool) {
+		var candidates []FileInfo
+		for _, f := range files {
+			if handled[f.Path] || isStrayVolume(f) {
+				continue
+			}
+			candidates = append(candidates, f)
+		}
+		if len(candidates) < 2 {
+			return
+		}
+
+		keep := pickKeeper(candidates, kept, deleteMode)
+		g := planGroup{title: title, keep: keep, reviewed: reviewed}
+		for _, f := range candidates {
+			if f.Path == keep.Path || kept[f.Path] || f.Protected {
+				continue
+			}
+			g.remove = append(g.remove, f)
+			if !reviewed {
+				handled[f.Path] = true
+			}
+		}
+		if len(g.remove) == 0 {
+			return
+		}
+		kept[keep.Path] = true
+		plan = append(plan, g)
+	}
+
+	for _, g := range report.SizeGroups {
+		title := fmt.Sprintf("Identical size (%s)", formatBytes(g.Size))
+		if g.Payload {
+			title = fmt.Sprintf("Same decompressed contents (%s)", formatBytes(g.Size))
+		}
+		add(title, g.Files, false)
+	}
+	for _, g := range report.SimilarGroups {
+		if g.Series {
+			continue // Parts of a series are not duplicates
+		}
+		add(fmt.Sprintf("Similar names: '%s'", g.BaseName), g.Files, true)
+	}
+	for _, g := range report.VisualGroups {
+		add(fmt.Sprintf("Visual match: '%s'", g.BaseName), g.Files, true)
+	}
+	return plan
+}
+
+// pickKeeper prefers a file already preserved by another group, then a protected file, then
+// applies the delete mode
+func pickKeeper(files []FileInfo, kept map[string]bool, deleteMode string) FileInfo {
+	for _, f := range files {
+		if kept[f.Path] {
+			return f
+		}
+	}
+	for _, f := range files {
+		if f.Protected {
+			return f
+		}
+	}
+
+	sorted := append([]FileInfo(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if deleteMode == "contents" {
+			a, b := sorted[i], sorted[j]
+			switch {
+			case a.FileCount > 0 && b.FileCount > 0 && a.FileCount != b.FileCount:
+				return a.FileCount > b.FileCount
+			case a.UncompressedSize > 0 && b.UncompressedSize > 0 && a.UncompressedSize != b.UncompressedSize:
+				return a.UncompressedSize > b.UncompressedSize
+			case a.Size != b.Size:
+				return a.Size > b.Size
+			}
+		}
+		return sorted[i].ModTime > sorted[j].ModTime // RFC3339 sorts chronologically
+	})
+	return sorted[0]
+}
+
+func isStrayVolume(f FileInfo) bool {
+	if len(f.Volumes) > 0 {
+		return false
+	}
+	isPart, _, _ := scanner.ArchiveFile{Name: f.Name}.IsMultiVolumePart()
+	return isPart
+}
+
+func filePaths(f FileInfo) []string {
+	if len(f.Volumes) > 0 {
+		return f.Volumes
+	}
+	return []string{f.Path}
+}
+
+type scriptWriter struct {
+	strings.Builder
+	opts ScriptOptions
+}
+
+func (w *scriptWriter) ps() bool {
+	return w.opts.Shell == "powershell"
+}
+
+func (w *scriptWriter) line(format string, args ...any) {
+	fmt.Fprintf(w, format+"\n", args...)
+}
+
+// psQuotes are the characters PowerShell ends a single-quoted string at: the ASCII quote and
+// the typographic single quotes
+var psQuotes = strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019", "\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b")
+
+// quote returns a literal string for the target shell (no variable expansion)
+func (w *scriptWriter) quote(s string) string {
+	if w.ps() {
+		return "'" + psQuotes.Replace(s) + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// isLineBreaking reports whether r is a control character, which could end a comment line or
+// split a commented-out command over lines
+func isLineBreaking(r rune) bool {
+	return unicode.IsControl(r) || r == '\u2028' || r == '\u2029'
+}
+
+// hasControlChars reports whether a name cannot be written safely into the script
+func hasControlChars(s string) bool {
+	return strings.IndexFunc(s, isLineBreaking) >= 0
+}
+
+// printable escapes the control characters of s (a newline becomes \n) for a comment line
+func printable(s string) string {
+	if !hasControlChars(s) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if isLineBreaking(r) {
+			b.WriteString(strings.Trim(strconv.QuoteRune(r), "'"))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (w *scriptWriter) header(report Report, plan []planGroup) {
+	active, suggested := 0, 0
+	for _, g := range plan {
+		if g.reviewed {
+			suggested += len(g.remove)
+		} else {
+			active += len(g.remove)
+		}
+	}
+
+	action := "DELETE"
+	if w.opts.TrashPath != "" {
+		action = "MOVE TO TRASH"
+	}
+
+	if w.ps() {
+		w.line("# Archive Duplicate Finder - cleanup plan (PowerShell)")
+	} else {
+		w.line("#!/bin/sh")
+		w.line("# Archive Duplicate Finder - cleanup plan")
+	}
+	w.line("# Generated: %s (report from %s)", time.Now().Format("2006-01-02 15:04:05"), report.Timestamp)
+	w.line("# Action: %s", action)
+	w.line("# Active commands: %d | Commented suggestions (similar/visual matches): %d", active, suggested)
+	w.line("#")
+	w.line("# Nothing has been changed on disk yet. Review every command before running this script.")
+	w.line("# Suggestions for fuzzy matches are commented out: uncomment the ones you approve.")
+	w.line("")
+
+	if w.ps() {
+		w.line("$ErrorActionPreference = 'Continue'")
+		if w.opts.TrashPath != "" {
+			w.line("$Trash = %s", w.quote(w.opts.TrashPath))
+			w.line("New-Item -ItemType Directory -Force -Path $Trash | Out-Null")
+			w.line("# Move-ToTrash numbers the name (name-2.zip) when the trash has a file of that name already")
+			w.line("function Move-ToTrash($Path) {")
+			w.line("    $name = [IO.Path]::GetFileNameWithoutExtension($Path)")
+			w.line("    $ext = [IO.Path]::GetExtension($Path)")
+			w.line("    $dest = Join-Path $Trash ($name + $ext)")
+			w.line("    for ($i = 2; Test-Path -LiteralPath $dest; $i++) { $dest = Join-Path $Trash ($name + '-' + $i + $ext) }")
+			w.line("    Move-Item -LiteralPath $Path -Destination $dest")
+			w.line("}")
+		}
+		w.line("$answer = Read-Host 'Run %d cleanup actions? [y/N]'", active)
+		w.line("if ($answer -ne 'y') { Write-Host 'Aborted.'; exit 1 }")
+	} else {
+		if w.opts.TrashPath != "" {
+			w.line("TRASH=%s", w.quote(w.opts.TrashPath))
+			w.line(`mkdir -p "$TRASH" || exit 1`)
+			w.line("# trash numbers the name (name-2.zip) when the trash has a file of that name already")
+			w.line("trash() {")
+			w.line(`    name=$(basename -- "$1")`)
+			w.line(`    dest="$TRASH/$name"`)
+			w.line("    i=2")
+			w.line(`    while [ -e "$dest" ] || [ -L "$dest" ]; do`)
+			w.line(`        case "$name" in`)
+			w.line(`            ?*.*) dest="$TRASH/${name%%.*}-$i.${name##*.}" ;;`)
+			w.line(`            *) dest="$TRASH/$name-$i" ;;`)
+			w.line("        esac")
+			w.line("        i=$((i + 1))")
+			w.line("    done")
+			w.line(`    mv -n -- "$1" "$dest"`)
+			w.line(`    [ ! -e "$1" ] || echo "Not moved: $1" >&2`)
+			w.line("}")
+		}
+		w.line(`printf 'Run %d cleanup actions? [y/N] '`, active)
+		w.line("read answer")
+		w.line(`[ "$answer" = "y" ] || { echo "Aborted."; exit 1; }`)
+	}
+	w.line("")
+}
+
+// group writes the commands of one group. Names are only written inside quotes: files whose
+// name has a newline or another control character, which would end a comment line, are left
+// for the administrator to remove by hand.
+func (w *scriptWriter) group(n int, g planGroup) {
+	w.line("# ── Group %d: %s", n, printable(g.title))
+	w.line("#    keep: %s (%s, %s)", printable(g.keep.Path), formatBytes(g.keep.Size), g.keep.ModTime)
+	if hasControlChars(g.keep.Path) {
+		w.line("#    skipped: the name of the kept file has control characters, clean this group up by hand")
+		w.line("")
+		return
+	}
+	if g.reviewed {
+		w.line("#    review: fuzzy match, commands are commented out")
+	}
+
+	prefix := ""
+	if g.reviewed {
+		prefix = "# "
+	}
+	for _, f := range g.remove {
+		if hasControlChars(strings.Join(filePaths(f), "")) {
+			w.line("#    skipped: %s has control characters in its name, remove it by hand", printable(f.Path))
+			continue
+		}
+		for _, path := range filePaths(f) {
+			for _, cmd := range w.removeCommands(path) {
+				w.line("%s%s", prefix, cmd)
+			}
+		}
+		if w.opts.LeaveRef {
+			w.line("%s%s", prefix, w.refCommand(f, g))
+		}
+	}
+	w.line("")
+}
+
+func (w *scriptWriter) removeCommands(path string) []string {
+	p := w.quote(path)
+	if w.ps() {
+		if w.opts.TrashPath != "" {
+			return []string{
+				fmt.Sprintf("Write-Host %s", w.quote("Moving "+path)),
+				fmt.Sprintf("Move-ToTrash %s", p),
+			}
+		}
+		return []string{
+			fmt.Sprintf("Write-Host %s", w.quote("Deleting "+path)),
+			fmt.Sprintf("Remove-Item -LiteralPath %s", p),
+		}
+	}
+
+	if w.opts.TrashPath != "" {
+		return []string{
+			fmt.Sprintf("echo %s", w.quote("Moving "+path)),
+			fmt.Sprintf("trash %s", p),
+		}
+	}
+	return []string{
+		fmt.Sprintf("echo %s", w.quote("Deleting "+path)),
+		fmt.Sprintf("rm -- %s", p),
+	}
+}
+
+func (w *scriptWriter) refCommand(removed FileInfo, g planGroup) string {
+	// Content hashes are left out: the script is generated without reading the files
+	kept := g.keep
+	note := refnote.Note{Removed: removed.Path, RemovedSize: removed.Size, Kept: kept.Path, KeptSize: kept.Size, Similarity: removed.Similarity(), Action: "deleted", Date: time.Now()}
+	note.GroupHash = CalculateGroupHash(append([]FileInfo{kept}, g.remove...))
+	if w.opts.TrashPath != "" {
+		note.Action, note.Trash = "trashed", w.opts.TrashPath
+	}
+	content, err := refnote.Render(note, w.opts.RefFormat, w.opts.RefTemplate)
+	if err != nil {
+		content, _ = refnote.Render(note, refnote.FormatText, "")
+	}
+
+	// One argument per line keeps the command on a single line, so it can be commented out
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	quoted := make([]string, len(lines))
+	for i, l := range lines {
+		quoted[i] = w.quote(l)
+	}
+
+	// The note is only left once the file is gone, so that it never describes a failed removal
+	removedPath := w.quote(removed.Path)
+	refPath := w.quote(refnote.Path(removed.Path, w.opts.RefFormat))
+	if w.ps() {
+		return fmt.Sprintf("if (-not (Test-Path -LiteralPath %s)) { Set-Content -LiteralPath %s -Value @(%s) }", removedPath, refPath, strings.Join(quoted, ", "))
+	}
+	return fmt.Sprintf("[ -e %s ] || printf '%%s\\n' %s > %s", removedPath, strings.Join(quoted, " "), refPath)
+}
+
+func (w *scriptWriter) footer() {
+	if w.ps() {
+		w.line("Write-Host 'Cleanup plan finished.'")
+	} else {
+		w.line("echo 'Cleanup plan finished.'")
+	}
+}
@@ -0,0 +1,194 @@
+package reporter
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"archive-duplicate-finder/internal/refnote"
+)
+
+func file(path, modTime string) FileInfo {
+	return FileInfo{Name: filepath.Base(path), Path: path, Size: 100, ModTime: modTime}
+}
+
+// hostileReport has names that end comments and strings when written into a script unquoted
+func hostileReport() Report {
+	return Report{
+		SizeGroups: []SizeGroup{
+			{Size: 100, Files: []FileInfo{
+				file("/lib/keep.zip", "2026-02-01T00:00:00Z"),
+				file("/lib/evil\nrm -rf ~\n.zip", "2026-01-01T00:00:00Z"),
+				file("/lib/it's.zip", "2026-01-01T00:00:00Z"),
+				file("/lib/smart\u2019; Remove-Item -Recurse C:\\; \u2019.zip", "2026-01-01T00:00:00Z"),
+			}},
+			{Size: 100, Files: []FileInfo{
+				file("/lib/kept\ntouch kept-pwned.zip", "2026-02-01T00:00:00Z"),
+				file("/lib/other.zip", "2026-01-01T00:00:00Z"),
+			}},
+		},
+		SimilarGroups: []SimilarityGroup{
+			{BaseName: "title\ntouch title-pwned", Files: []FileInfo{
+				file("/lib/a.zip", "2026-02-01T00:00:00Z"),
+				file("/lib/b\ntouch fuzzy-pwned.zip", "2026-01-01T00:00:00Z"),
+				file("/lib/c\rRemove-Item fuzzy.zip", "2026-01-01T00:00:00Z"),
+				file("/lib/d.zip", "2026-01-01T00:00:00Z"),
+			}},
+		},
+	}
+}
+
+// lines splits a script the way either shell ends lines
+func lines(script string) []string {
+	return strings.FieldsFunc(script, func(r rune) bool { return r == '\n' || r == '\r' })
+}
+
+func checkNoInjection(t *testing.T, script string) {
+	t.Helper()
+	for _, l := range lines(script) {
+		l = strings.TrimSpace(l)
+		for _, injected := range []string{"rm -rf", "touch", "Remove-Item fuzzy", "Remove-Item -Recurse"} {
+			if strings.HasPrefix(l, injected) {
+				t.Errorf("a file name runs as a command: %q", l)
+			}
+		}
+	}
+}
+
+// checkReviewedCommented checks that every line of the fuzzy group is a comment
+func checkReviewedCommented(t *testing.T, script string) {
+	t.Helper()
+	start := strings.Index(script, "Similar names:")
+	if start < 0 {
+		t.Fatal("similar-name group missing")
+	}
+	section, _, _ := strings.Cut(script[start:], "\n\n")
+	for _, l := range lines(section)[1:] {
+		if !strings.HasPrefix(l, "#") {
+			t.Errorf("uncommented line in a reviewed group: %q", l)
+		}
+	}
+	if !strings.Contains(section, "# rm -- '/lib/d.zip'") && !strings.Contains(section, "# Remove-Item -LiteralPath '/lib/d.zip'") {
+		t.Errorf("the safe file of the reviewed group is not suggested:\n%s", section)
+	}
+}
+
+func TestScriptHostileNamesShell(t *testing.T) {
+	script := RenderScript(hostileReport(), ScriptOptions{Shell: "sh", LeaveRef: true})
+
+	checkNoInjection(t, script)
+	checkReviewedCommented(t, script)
+	for _, want := range []string{
+		`rm -- '/lib/it'\''s.zip'`,
+		`#    skipped: /lib/evil\nrm -rf ~\n.zip has control characters in its name, remove it by hand`,
+		`#    keep: /lib/kept\ntouch kept-pwned.zip`,
+		`Similar names: 'title\ntouch title-pwned'`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script lacks %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "/lib/other.zip") && strings.Contains(script, "rm -- '/lib/other.zip'") {
+		t.Error("a group whose kept file cannot be written is still cleaned up")
+	}
+
+	if sh, err := exec.LookPath("sh"); err == nil {
+		path := filepath.Join(t.TempDir(), "cleanup.sh")
+		if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command(sh, "-n", path).CombinedOutput(); err != nil {
+			t.Errorf("sh -n: %v\n%s", err, out)
+		}
+	}
+}
+
+func TestScriptHostileNamesPowerShell(t *testing.T) {
+	script := RenderScript(hostileReport(), ScriptOptions{Shell: "powershell", LeaveRef: true})
+
+	checkNoInjection(t, script)
+	checkReviewedCommented(t, script)
+	for _, want := range []string{
+		`Remove-Item -LiteralPath '/lib/it''s.zip'`,
+		"Remove-Item -LiteralPath '/lib/smart\u2019\u2019; Remove-Item -Recurse C:\\; \u2019\u2019.zip'",
+		`#    skipped: /lib/c\rRemove-Item fuzzy.zip has control characters in its name, remove it by hand`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script lacks %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestScriptQuotePowerShell(t *testing.T) {
+	w := scriptWriter{opts: ScriptOptions{Shell: "powershell"}}
+	for in, want := range map[string]string{
+		"plain":          "'plain'",
+		"it's":           "'it''s'",
+		"a\u2018b":       "'a\u2018\u2018b'",
+		"a\u2019b":       "'a\u2019\u2019b'",
+		"a\u201ab\u201b": "'a\u201a\u201ab\u201b\u201b'",
+	} {
+		if got := w.quote(in); got != want {
+			t.Errorf("quote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestScriptTrashNameTaken runs the script: copies sharing a name with each other and with a
+// file already in the trash must all be moved, under numbered names
+func TestScriptTrashNameTaken(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	trash := filepath.Join(dir, "trash")
+	var files []FileInfo
+	for i, sub := range []string{"a", "b", "c"} {
+		path := filepath.Join(dir, sub, "dup.zip")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(sub), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file(path, []string{"2026-03-01T00:00:00Z", "2026-02-01T00:00:00Z", "2026-01-01T00:00:00Z"}[i]))
+	}
+	if err := os.MkdirAll(trash, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(trash, "dup.zip"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := RenderScript(Report{SizeGroups: []SizeGroup{{Size: 100, Files: files}}}, ScriptOptions{Shell: "sh", TrashPath: trash, LeaveRef: true})
+	path := filepath.Join(dir, "cleanup.sh")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(sh, path)
+	cmd.Stdin = strings.NewReader("y\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+
+	for name, want := range map[string]string{"dup.zip": "old", "dup-2.zip": "b", "dup-3.zip": "c"} {
+		data, err := os.ReadFile(filepath.Join(trash, name))
+		if err != nil || string(data) != want {
+			t.Errorf("trash/%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+	if _, err := os.Stat(files[0].Path); err != nil {
+		t.Errorf("kept file: %v", err)
+	}
+	for _, f := range files[1:] {
+		if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+			t.Errorf("%s was not moved", f.Path)
+		}
+		if _, err := os.Stat(refnote.Path(f.Path, "")); err != nil {
+			t.Errorf("no reference note for %s: %v", f.Path, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package reporter
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// SizeNode is a directory of the size tree: what is stored below it and how much of it the
+// cleanup plan would free, for treemaps of where the duplicates live
+type SizeNode struct {
+	Name           string      `json:"name"`
+	Path           string      `json:"path"`
+	Size           int64       `json:"size"`            // Every scanned file below
+	Files          int         `json:"files"`           // Scanned files below
+	Archives       int         `json:"archives"`        // Those that are archives, comics or e-books
+	Duplicates     int         `json:"duplicates"`      // Copies the cleanup plan removes below
+	DuplicateBytes int64       `json:"duplicate_bytes"` // Their size
+	Children       []*SizeNode `json:"children,omitempty"`
+
+	parent *SizeNode
+	level  int // Below root
+}
+
+// SizeTree nests the scanned files by directory under root, largest directories first.
+// Directories deeper than depth below root are folded into their ancestor (0 keeps them all).
+// Duplicates are counted as in the cleanup plan; similar names are not, as they need a review.
+func SizeTree(report Report, files []FileInfo, root, deleteMode string, depth int) *SizeNode {
+	root = filepath.Clean(root)
+	tree := &SizeNode{Name: filepath.Base(root), Path: root}
+	nodes := map[string]*SizeNode{root: tree}
+
+	// nodeOf returns the node a directory is counted in, creating it and its parents
+	var nodeOf func(dir string) *SizeNode
+	nodeOf = func(dir string) *SizeNode {
+		if n, ok := nodes[dir]; ok {
+			return n
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || !inside(root, dir) {
+			return tree // Outside root: counted at the top
+		}
+		p := nodeOf(parent)
+		if depth > 0 && p.level >= depth {
+			nodes[dir] = p
+			return p
+		}
+		n := &SizeNode{Name: filepath.Base(dir), Path: dir, parent: p, level: p.level + 1}
+		p.Children = append(p.Children, n)
+		nodes[dir] = n
+		return n
+	}
+	// along applies add to the directory of path and every one above it
+	along := func(path string, add func(n *SizeNode)) {
+		for n := nodeOf(filepath.Dir(path)); n != nil; n = n.parent {
+			add(n)
+		}
+	}
+
+	for _, f := range files {
+		along(f.Path, func(n *SizeNode) {
+			n.Size += f.Size
+			n.Files++
+			if f.Type == "archive" || f.Type == "book" {
+				n.Archives++
+			}
+		})
+	}
+	for _, g := range buildPlan(report, deleteMode) {
+		if g.reviewed {
+			continue
+		}
+		for _, f := range g.remove {
+			along(f.Path, func(n *SizeNode) {
+				n.Duplicates++
+				n.DuplicateBytes += f.Size
+			})
+		}
+	}
+
+	sortSizeTree(tree)
+	return tree
+}
+
+func sortSizeTree(n *SizeNode) {
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].Size != n.Children[j].Size {
+			return n.Children[i].Size > n.Children[j].Size
+		}
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	for _, c := range n.Children {
+		sortSizeTree(c)
+	}
+}
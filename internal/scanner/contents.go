@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"sync"
+	"time"
+)
+
+// ContentSummary is what an archive holds, read from its directory without extracting it
+type ContentSummary struct {
+	FileCount        int      // Entries (pages for comics and EPUBs)
+	UncompressedSize int64    // Total size of the entries
+	Entries          []string // Paths of the entries, for searching what the library holds
+}
+
+// ContentStore remembers content summaries between runs (implemented by db.Cache). Entries are
+// only valid while the archive keeps its size and mod time.
+type ContentStore interface {
+	GetContents(path string, size int64, modTime string) (ContentSummary, bool)
+	PutContents(path string, size int64, modTime string, summary ContentSummary)
+}
+
+// EnrichContents fills FileCount and UncompressedSize for every archive and book by reading its
+// directory (central directory for ZIP, headers for RAR and 7Z). Unreadable archives are left
+// at zero. store may be nil; onProgress receives the number of archives done so far.
+func EnrichContents(files []ArchiveFile, store ContentStore, onProgress func(done int)) {
+	var mu sync.Mutex
+	done := 0
+	forEachArchive(files, func(f ArchiveFile) bool { return f.Type == "archive" || f.Type == "book" }, func(f *ArchiveFile) {
+		modTime := f.ModTime.Format(time.RFC3339)
+		summary, ok := ContentSummary{}, false
+		if store != nil {
+			summary, ok = store.GetContents(f.Path, f.Size, modTime)
+		}
+		if !ok {
+			count, size, entries, err := archive.ContentSummary(f.Path)
+			if err == nil {
+				summary = ContentSummary{FileCount: count, UncompressedSize: size, Entries: entries}
+				if store != nil {
+					store.PutContents(f.Path, f.Size, modTime, summary)
+				}
+			}
+		}
+		f.FileCount, f.UncompressedSize = summary.FileCount, summary.UncompressedSize
+
+		mu.Lock()
+		done++
+		if onProgress != nil {
+			onProgress(done)
+		}
+		mu.Unlock()
+	})
+}
+
+// CountBookPages fills FileCount with the page count of comic books and EPUBs. Books are
+// opened in parallel, sized by the global archive limits; unreadable ones keep a count of 0.
+func CountBookPages(files []ArchiveFile) {
+	forEachArchive(files, func(f ArchiveFile) bool { return f.Type == "book" }, func(f *ArchiveFile) {
+		if n, err := archive.PageCount(f.Path); err == nil {
+			f.FileCount = n
+		}
+	})
+}
+
+// forEachArchive runs fn on the selected files in parallel, sized by the global archive limits
+func forEachArchive(files []ArchiveFile, selected func(ArchiveFile) bool, fn func(*ArchiveFile)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < archive.GetLimits().Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(&files[i])
+			}
+		}()
+	}
+	for i, f := range files {
+		if selected(f) {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// looseFiles makes the scanner pick up every file, not only archives, books, models and videos
+var looseFiles atomic.Bool
+
+// SetLooseFiles switches loose-file mode on or off for the scans that follow. In loose-file
+// mode every regular file is collected: images get the type "image" and anything else "file".
+// Empty files, hidden files, system clutter (Thumbs.db, desktop.ini) and the reference notes
+// left by cleanups are still skipped.
+func SetLooseFiles(on bool) {
+	looseFiles.Store(on)
+}
+
+// LooseFiles reports whether loose-file mode is on
+func LooseFiles() bool {
+	return looseFiles.Load()
+}
+
+// IsLoose reports whether f is a loose file (an image or another non-archive file collected in
+// loose-file mode). Loose files are compared by content and previewed directly.
+func IsLoose(f ArchiveFile) bool {
+	return f.Type == "image" || f.Type == "file"
+}
+
+// IsImage reports whether a file name has the extension of an image
+func IsImage(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".gif", ".bmp", ".tif", ".tiff", ".heic":
+		return true
+	}
+	return false
+}
+
+// isClutter reports files loose-file mode never collects
+func isClutter(filename string) bool {
+	name := strings.ToLower(filepath.Base(filename))
+	switch {
+	case strings.HasPrefix(name, "."), name == "thumbs.db", name == "desktop.ini":
+		return true
+	case strings.HasSuffix(name, ".duplicate.txt"), strings.HasSuffix(name, ".duplicate.json"):
+		return true
+	}
+	return false
+}
+
+// skipLoose drops empty loose files: they are all identical to each other and free nothing
+func skipLoose(fileType string, size int64) bool {
+	return size == 0 && (fileType == "image" || fileType == "file")
+}
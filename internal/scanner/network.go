@@ -0,0 +1,217 @@
+package scanner
+
+import (
+	"archive-duplicate-finder/internal/txn"
+	"archive-duplicate-finder/internal/vfs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// On SMB/NFS mounts every stat is a network round trip, so a plain walk (one lstat per entry,
+// directories and non-archives included) spends most of its time waiting. The network scan
+// reads each directory in one batch, stats only archive candidates, runs those stats in
+// parallel and can reuse the listing of folders whose modification time has not changed.
+
+const (
+	defaultStatWorkers = 16
+	defaultListingAge  = 24 * time.Hour
+)
+
+// NetworkOptions tunes the network share scan
+type NetworkOptions struct {
+	StatWorkers int           // Parallel stat calls (default 16)
+	Listings    ListingCache  // Optional cache of directory listings
+	MaxAge      time.Duration // Cached listings older than this are read again (default 24h)
+}
+
+// ListingEntry is an archive found in a directory listing
+type ListingEntry struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// DirListing is what the scan keeps of one directory: its subdirectories and archives
+type DirListing struct {
+	Subdirs  []string       `json:"subdirs"`
+	Files    []ListingEntry `json:"files"`
+	ListedAt time.Time      `json:"listed_at"`
+}
+
+// ListingCache stores directory listings keyed by directory and its modification time.
+// Creating, deleting or renaming an entry changes the directory's time and invalidates it.
+type ListingCache interface {
+	GetDirListing(dir string, modTime time.Time) (DirListing, bool)
+	PutDirListing(dir string, modTime time.Time, listing DirListing)
+}
+
+// ScanNetworkShare scans a mounted network share for archive files. It returns the same files
+// as ScanDirectoryWithProgress; remote stores are listed through the vfs package as usual.
+func ScanNetworkShare(dir string, recursive bool, opts NetworkOptions, onFile func(files int, bytes int64)) ([]ArchiveFile, error) {
+	if vfs.IsRemote(dir) {
+		return ScanDirectoryWithProgress(dir, recursive, onFile)
+	}
+	if opts.StatWorkers <= 0 {
+		opts.StatWorkers = defaultStatWorkers
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = defaultListingAge
+	}
+
+	ns := &networkScan{opts: opts, onFile: onFile, stats: make(chan struct{}, opts.StatWorkers)}
+	pending := []string{dir}
+	for len(pending) > 0 && ns.err == nil {
+		// Directories of one level are listed concurrently
+		var next []string
+		var nextMu sync.Mutex
+		var wg sync.WaitGroup
+		for _, d := range pending {
+			wg.Add(1)
+			go func(d string) {
+				defer wg.Done()
+				subdirs := ns.scanDir(d)
+				if recursive {
+					nextMu.Lock()
+					next = append(next, subdirs...)
+					nextMu.Unlock()
+				}
+			}(d)
+		}
+		wg.Wait()
+		pending = next
+	}
+
+	sort.Slice(ns.files, func(i, j int) bool { return ns.files[i].Path < ns.files[j].Path })
+	return ns.files, ns.err
+}
+
+type networkScan struct {
+	opts   NetworkOptions
+	onFile func(files int, bytes int64)
+	stats  chan struct{} // Limits the stat and directory calls in flight
+
+	mu         sync.Mutex
+	files      []ArchiveFile
+	totalBytes int64
+	err        error
+}
+
+// scanDir adds the archives of one directory and returns its subdirectories
+func (ns *networkScan) scanDir(dir string) []string {
+	ns.stats <- struct{}{}
+	info, err := os.Stat(dir)
+	<-ns.stats
+	if err != nil {
+		ns.fail(err)
+		return nil
+	}
+
+	// Cached listings only hold archives, so loose-file mode always reads the directory
+	listing, ok := DirListing{}, false
+	if ns.opts.Listings != nil && !LooseFiles() {
+		listing, ok = ns.opts.Listings.GetDirListing(dir, info.ModTime())
+		ok = ok && time.Since(listing.ListedAt) < ns.opts.MaxAge
+	}
+	if !ok {
+		listing, err = ns.readDir(dir)
+		if err != nil {
+			ns.fail(err)
+			return nil
+		}
+		if ns.opts.Listings != nil && !LooseFiles() {
+			ns.opts.Listings.PutDirListing(dir, info.ModTime(), listing)
+		}
+	}
+
+	ns.mu.Lock()
+	for _, e := range listing.Files {
+		path := filepath.Join(dir, e.Name)
+		fileType := getArchiveType(path)
+		if skipLoose(fileType, e.Size) {
+			continue
+		}
+		ns.files = append(ns.files, ArchiveFile{
+			Name:    e.Name,
+			Path:    path,
+			Size:    e.Size,
+			Type:    fileType,
+			ModTime: e.ModTime,
+		})
+		ns.totalBytes += e.Size
+		if ns.onFile != nil {
+			ns.onFile(len(ns.files), ns.totalBytes)
+		}
+	}
+	ns.mu.Unlock()
+
+	subdirs := make([]string, len(listing.Subdirs))
+	for i, name := range listing.Subdirs {
+		subdirs[i] = filepath.Join(dir, name)
+	}
+	return subdirs
+}
+
+// readDir lists a directory in one batch and stats its archive candidates in parallel.
+// Entry types come from the listing itself, so directories and other files are never stat'ed.
+func (ns *networkScan) readDir(dir string) (DirListing, error) {
+	ns.stats <- struct{}{}
+	entries, err := os.ReadDir(dir)
+	<-ns.stats
+	if err != nil {
+		return DirListing{}, err
+	}
+
+	listing := DirListing{ListedAt: time.Now()}
+	var candidates []string
+	for _, e := range entries {
+		switch {
+		case e.IsDir() && txn.IsStaging(e.Name()):
+			continue // Files removed by a group that has not ended
+		case e.IsDir():
+			listing.Subdirs = append(listing.Subdirs, e.Name())
+		case getArchiveType(e.Name()) != "":
+			candidates = append(candidates, e.Name())
+		}
+	}
+
+	files := make([]ListingEntry, len(candidates))
+	errs := make([]error, len(candidates))
+	var wg sync.WaitGroup
+	for i, name := range candidates {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			ns.stats <- struct{}{}
+			info, err := os.Lstat(filepath.Join(dir, name))
+			<-ns.stats
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			files[i] = ListingEntry{Name: name, Size: info.Size(), ModTime: info.ModTime()}
+		}(i, name)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if os.IsNotExist(err) {
+			continue // Removed while listing
+		}
+		if err != nil {
+			return DirListing{}, err
+		}
+		listing.Files = append(listing.Files, files[i])
+	}
+	return listing, nil
+}
+
+func (ns *networkScan) fail(err error) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ns.err == nil {
+		ns.err = err
+	}
+}
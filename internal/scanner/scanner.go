@@ -1,8 +1,9 @@
 package scanner
 
 import (
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/vfs"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,9 +14,20 @@ type ArchiveFile struct {
 	Name      string
 	Path      string
 	Size      int64
-	Type      string    // "zip", "rar", "7z"
+	Type      string    // "archive", "book", "model", "video", and in loose-file mode "image" or "file"
 	ModTime   time.Time // Modification time
-	FileCount int       // Number of files inside
+	FileCount int       // Number of files inside (pages for comics and EPUBs)
+	Volumes   []string  // All part paths when this entry represents a multi-volume set
+
+	UncompressedSize int64 // Total size of the contents; 0 until the contents are enriched
+}
+
+// AllPaths returns every path on disk belonging to this entry (all volumes for a multi-volume set)
+func (f ArchiveFile) AllPaths() []string {
+	if len(f.Volumes) > 0 {
+		return f.Volumes
+	}
+	return []string{f.Path}
 }
 
 // IsMultiVolumePart returns true if the file looks like a part of a multi-volume archive.
@@ -80,32 +92,31 @@ func (f ArchiveFile) IsMultiVolumePart() (bool, string, string) {
 
 // ScanDirectory scans a directory for archive files
 func ScanDirectory(dir string, recursive bool) ([]ArchiveFile, error) {
-	var files []ArchiveFile
-
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	return ScanDirectoryWithProgress(dir, recursive, nil)
+}
 
-		// Skip directories
-		if info.IsDir() {
-			// If not recursive and not the root directory, skip
-			if !recursive && path != dir {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+// ScanDirectoryWithProgress scans a directory for archive files, calling onFile with the
+// number of archives and bytes found so far after every match
+func ScanDirectoryWithProgress(dir string, recursive bool, onFile func(files int, bytes int64)) ([]ArchiveFile, error) {
+	var files []ArchiveFile
+	var totalBytes int64
 
+	// Local folders and remote stores (s3://bucket/prefix) are listed the same way
+	err := vfs.Walk(dir, recursive, func(entry vfs.Entry) error {
 		// Check if file is an archive
-		archiveType := getArchiveType(path)
-		if archiveType != "" {
+		archiveType := getArchiveType(entry.Path)
+		if archiveType != "" && !skipLoose(archiveType, entry.Size) {
 			files = append(files, ArchiveFile{
-				Name:    info.Name(),
-				Path:    path,
-				Size:    info.Size(),
+				Name:    entry.Name,
+				Path:    entry.Path,
+				Size:    entry.Size,
 				Type:    archiveType,
-				ModTime: info.ModTime(),
+				ModTime: entry.ModTime,
 			})
+			totalBytes += entry.Size
+			if onFile != nil {
+				onFile(len(files), totalBytes)
+			}
 		}
 
 		return nil
@@ -114,19 +125,63 @@ func ScanDirectory(dir string, recursive bool) ([]ArchiveFile, error) {
 	return files, err
 }
 
+// FileType classifies a file that was not found by a scan (e.g. one listed by another tool):
+// its archive, book, model or video type, otherwise "image" or "file" as in loose-file mode
+func FileType(filename string) string {
+	if t := getArchiveType(filename); t != "" {
+		return t
+	}
+	if IsImage(filename) {
+		return "image"
+	}
+	return "file"
+}
+
 // getArchiveType returns the archive type based on file extension
 func getArchiveType(filename string) string {
 	ext := strings.ToLower(filepath.Ext(filename))
+
+	// Numbered volumes of split archives (name.7z.001, name.zip.002)
+	if isNumericExt(ext) {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(filename, filepath.Ext(filename))))
+		if ext != ".zip" && ext != ".rar" && ext != ".7z" {
+			if looseFiles.Load() && !isClutter(filename) {
+				return "file"
+			}
+			return ""
+		}
+	}
+
 	switch ext {
-	case ".zip", ".rar", ".7z", ".tar", ".gz", ".bz2", ".xz", ".iso", ".cab":
+	case ".zip", ".rar", ".7z", ".tar", ".gz", ".bz2", ".xz", ".zst", ".tgz", ".tbz", ".tbz2", ".txz", ".tzst", ".iso", ".img", ".cab":
 		return "archive"
+	case ".cbz", ".cbr", ".cb7", ".epub":
+		return "book"
 	case ".stl", ".obj", ".3ds", ".fbx", ".blend", ".step", ".stp", ".iges", ".igs", ".ply", ".off", ".3mf", ".glb", ".gltf":
 		return "model"
 	case ".mp4", ".webm", ".mkv", ".avi", ".mov", ".wmv", ".flv":
 		return "video"
-	default:
+	}
+
+	if !looseFiles.Load() || isClutter(filename) {
 		return ""
 	}
+	if IsImage(filename) {
+		return "image"
+	}
+	return "file"
+}
+
+func isNumericExt(ext string) bool {
+	if len(ext) < 2 {
+		return false
+	}
+	for _, c := range ext[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // GroupBySize groups files by their size
@@ -145,15 +200,29 @@ func PrintFileStats(files []ArchiveFile) {
 	stats := make(map[string]int)
 	var totalSize int64
 
+	volumeSets := 0
 	for _, file := range files {
 		stats[file.Type]++
 		totalSize += file.Size
+		if len(file.Volumes) > 0 {
+			volumeSets++
+		}
 	}
 
-	fmt.Printf("  • Archives: %d files\n", stats["archive"])
-	fmt.Printf("  • 3D Models: %d files\n", stats["model"])
-	fmt.Printf("  • Videos: %d files\n", stats["video"])
-	fmt.Printf("  • Total size: %s\n", formatBytes(totalSize))
+	fmt.Print(i18n.T("  • Archives: %d files\n", stats["archive"]))
+	if volumeSets > 0 {
+		fmt.Print(i18n.T("  • Multi-volume sets: %d (counted as one archive each)\n", volumeSets))
+	}
+	if stats["book"] > 0 {
+		fmt.Print(i18n.T("  • Comics & e-books: %d files\n", stats["book"]))
+	}
+	fmt.Print(i18n.T("  • 3D Models: %d files\n", stats["model"]))
+	fmt.Print(i18n.T("  • Videos: %d files\n", stats["video"]))
+	if LooseFiles() {
+		fmt.Print(i18n.T("  • Images: %d files\n", stats["image"]))
+		fmt.Print(i18n.T("  • Other files: %d files\n", stats["file"]))
+	}
+	fmt.Print(i18n.T("  • Total size: %s\n", formatBytes(totalSize)))
 }
 
 func formatBytes(bytes int64) string {
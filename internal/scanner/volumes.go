@@ -0,0 +1,98 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// VolumeSet is a multi-volume archive split across several part files
+type VolumeSet struct {
+	BaseName  string
+	Dir       string
+	Parts     []ArchiveFile // Sorted by part number
+	TotalSize int64
+}
+
+// GroupVolumeSets separates multi-volume parts (part1/part2, .001/.002) into logical sets.
+// Parts are only grouped when they live in the same directory; a lone part is returned as a single file.
+func GroupVolumeSets(files []ArchiveFile) ([]VolumeSet, []ArchiveFile) {
+	type setKey struct{ dir, base string }
+	bySet := make(map[setKey][]ArchiveFile)
+	var order []setKey
+	var singles []ArchiveFile
+
+	for _, f := range files {
+		isPart, base, _ := f.IsMultiVolumePart()
+		if !isPart || IsLoose(f) {
+			singles = append(singles, f)
+			continue
+		}
+		key := setKey{dir: filepath.Dir(f.Path), base: base}
+		if _, ok := bySet[key]; !ok {
+			order = append(order, key)
+		}
+		bySet[key] = append(bySet[key], f)
+	}
+
+	var sets []VolumeSet
+	for _, key := range order {
+		parts := bySet[key]
+		if len(parts) < 2 {
+			singles = append(singles, parts...)
+			continue
+		}
+
+		sort.Slice(parts, func(i, j int) bool {
+			return partNumber(parts[i]) < partNumber(parts[j])
+		})
+
+		set := VolumeSet{BaseName: key.base, Dir: key.dir, Parts: parts}
+		for _, p := range parts {
+			set.TotalSize += p.Size
+		}
+		sets = append(sets, set)
+	}
+	return sets, singles
+}
+
+// AsArchiveFile represents the whole set as a single file: it is opened through its first
+// volume, sized as the sum of its parts and dated by its most recent part.
+func (s VolumeSet) AsArchiveFile() ArchiveFile {
+	first := s.Parts[0]
+	f := ArchiveFile{
+		Name:    first.Name,
+		Path:    first.Path,
+		Size:    s.TotalSize,
+		Type:    first.Type,
+		ModTime: first.ModTime,
+	}
+	for _, p := range s.Parts {
+		f.Volumes = append(f.Volumes, p.Path)
+		if p.ModTime.After(f.ModTime) {
+			f.ModTime = p.ModTime
+		}
+	}
+	return f
+}
+
+// CollapseVolumeSets replaces the parts of every multi-volume set with a single entry,
+// so duplicate detection treats each set as one unit.
+func CollapseVolumeSets(files []ArchiveFile) []ArchiveFile {
+	sets, singles := GroupVolumeSets(files)
+	result := make([]ArchiveFile, 0, len(singles)+len(sets))
+	result = append(result, singles...)
+	for _, set := range sets {
+		result = append(result, set.AsArchiveFile())
+	}
+	return result
+}
+
+func partNumber(f ArchiveFile) int {
+	_, _, part := f.IsMultiVolumePart()
+	n, err := strconv.Atoi(part)
+	if err != nil {
+		return 0
+	}
+	return n
+}
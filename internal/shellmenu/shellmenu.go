@@ -0,0 +1,14 @@
+// Package shellmenu adds `finder here` to the context menu of folders in the file manager: a
+// registry entry for Windows Explorer, desktop entries for the file managers of Linux
+package shellmenu
+
+import "errors"
+
+// Label is the name of the menu entry
+const Label = "Find duplicate archives here"
+
+// id names the registry keys and desktop files of the entry
+const id = "archive-duplicate-finder"
+
+// ErrUnsupported is returned on the platforms without a known context menu
+var ErrUnsupported = errors.New("context menu entries can only be installed on Windows and Linux")
@@ -0,0 +1,13 @@
+//go:build !(windows || (linux && !android) || freebsd || openbsd || netbsd)
+
+package shellmenu
+
+// Install returns ErrUnsupported
+func Install(command []string) ([]string, error) {
+	return nil, ErrUnsupported
+}
+
+// Uninstall returns ErrUnsupported
+func Uninstall() ([]string, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,89 @@
+//go:build windows
+
+package shellmenu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"archive-duplicate-finder/assets"
+	"archive-duplicate-finder/internal/paths"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Explorer shows the entry on folders, and on the background of an open folder
+var menuKeys = []string{
+	`Software\Classes\Directory\shell\` + id,
+	`Software\Classes\Directory\Background\shell\` + id,
+}
+
+// Install registers command for the current user, with the folder as its last argument, and
+// returns the registry keys and files it wrote
+func Install(command []string) ([]string, error) {
+	icon := filepath.Join(paths.DataDir(), id+".ico")
+	if err := os.MkdirAll(filepath.Dir(icon), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(icon, assets.Icon, 0o644); err != nil {
+		return nil, err
+	}
+	written := []string{icon}
+
+	args := make([]string, len(command))
+	for i, a := range command {
+		args[i] = syscall.EscapeArg(a)
+	}
+	line := strings.Join(args, " ") + ` "%V"`
+	for _, path := range menuKeys {
+		if err := setKey(path, map[string]string{"": Label, "Icon": icon}); err != nil {
+			return written, err
+		}
+		if err := setKey(path+`\command`, map[string]string{"": line}); err != nil {
+			return written, err
+		}
+		written = append(written, `HKCU\`+path)
+	}
+	return written, nil
+}
+
+// Uninstall removes the entries and the icon, returning what it removed
+func Uninstall() ([]string, error) {
+	var removed []string
+	for _, path := range menuKeys {
+		if err := registry.DeleteKey(registry.CURRENT_USER, path+`\command`); err != nil {
+			if err == registry.ErrNotExist {
+				continue
+			}
+			return removed, err
+		}
+		if err := registry.DeleteKey(registry.CURRENT_USER, path); err != nil && err != registry.ErrNotExist {
+			return removed, err
+		}
+		removed = append(removed, `HKCU\`+path)
+	}
+	icon := filepath.Join(paths.DataDir(), id+".ico")
+	if err := os.Remove(icon); err == nil {
+		removed = append(removed, icon)
+	} else if !os.IsNotExist(err) {
+		return removed, err
+	}
+	return removed, nil
+}
+
+// setKey creates a key of the current user and sets its string values ("" is the default one)
+func setKey(path string, values map[string]string) error {
+	k, _, err := registry.CreateKey(registry.CURRENT_USER, path, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	for name, value := range values {
+		if err := k.SetStringValue(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+//go:build (linux && !android) || freebsd || openbsd || netbsd
+
+package shellmenu
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"archive-duplicate-finder/assets"
+	"archive-duplicate-finder/internal/paths"
+)
+
+// entry is a file Install writes under the user's data folder
+type entry struct {
+	path    string // Relative to the data folder
+	mode    os.FileMode
+	content func(exec string) []byte
+}
+
+// entries are the icon, an application offered in "Open With" on folders (GNOME Files, Nemo,
+// Thunar...), and a service menu whose action Dolphin lists on folders
+var entries = []entry{
+	{"icons/hicolor/64x64/apps/" + id + ".png", 0o644, func(string) []byte { return assets.IconPNG }},
+	{"applications/" + id + "-here.desktop", 0o644, func(exec string) []byte {
+		return fmt.Appendf(nil, `[Desktop Entry]
+Type=Application
+Name=%s
+Comment=Scan the folder for duplicate archives and open the dashboard on it
+Icon=%s
+Exec=%s %%f
+MimeType=inode/directory;
+NoDisplay=true
+Terminal=false
+`, Label, id, exec)
+	}},
+	// Plasma runs service menus only when they are executable
+	{"kio/servicemenus/" + id + "-here.desktop", 0o755, func(exec string) []byte {
+		return fmt.Appendf(nil, `[Desktop Entry]
+Type=Service
+MimeType=inode/directory;
+Actions=here;
+X-KDE-ServiceTypes=KonqPopupMenu/Plugin
+
+[Desktop Action here]
+Name=%s
+Icon=%s
+Exec=%s %%f
+`, Label, id, exec)
+	}},
+}
+
+// Install writes the desktop entries running command, with the folder as its last argument,
+// and returns the files it wrote
+func Install(command []string) ([]string, error) {
+	base, err := paths.UserDataHome()
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, len(command))
+	for i, a := range command {
+		args[i] = quote(a)
+	}
+	line := strings.Join(args, " ")
+
+	var written []string
+	for _, e := range entries {
+		path := filepath.Join(base, e.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, e.content(line), e.mode); err != nil {
+			return written, err
+		}
+		os.Chmod(path, e.mode) // An earlier install may have left other permissions
+		written = append(written, path)
+	}
+	refresh(base)
+	return written, nil
+}
+
+// Uninstall removes the desktop entries and the icon, returning what it removed
+func Uninstall() ([]string, error) {
+	base, err := paths.UserDataHome()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, e := range entries {
+		path := filepath.Join(base, e.path)
+		if err := os.Remove(path); err == nil {
+			removed = append(removed, path)
+		} else if !os.IsNotExist(err) {
+			return removed, err
+		}
+	}
+	refresh(base)
+	return removed, nil
+}
+
+// refresh updates the MIME cache of the applications, where the tool is installed; file
+// managers pick the entries up without it, only later
+func refresh(base string) {
+	if tool, err := exec.LookPath("update-desktop-database"); err == nil {
+		exec.Command(tool, filepath.Join(base, "applications")).Run()
+	}
+}
+
+// quote quotes an argument of Exec as the Desktop Entry Specification asks: within double
+// quotes, with ", `, $ and \ escaped, and the escapes of string values on top
+func quote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\><~|&;$*?#()`%") {
+		return arg
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range arg {
+		switch r {
+		case '"', '`', '$', '\\':
+			b.WriteByte('\\')
+		case '%':
+			b.WriteByte('%') // Field codes start with %
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return strings.ReplaceAll(b.String(), `\`, `\\`)
+}
@@ -0,0 +1,271 @@
+package similarity
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cacheVersion is part of the settings saved clusters are keyed by. Bump it with any change to
+// normalization, candidate generation, scoring or refinement, so that the clusters of an
+// earlier version are built again.
+const cacheVersion = 3
+
+// clusterCache is what the similarity cache keeps of a Step 3 run: the files it saw, the scored
+// candidate pairs and the clusters of every component
+type clusterCache struct {
+	Files      map[string]fileStamp    `json:"files"`
+	Pairs      []cachedPair            `json:"pairs"`
+	Components []cachedComponent       `json:"components"` // Only those holding clusters
+	Metrics    reporter.ClusterMetrics `json:"metrics"`
+
+	scores   map[keyPair]float64
+	byKeys   map[string]int // Joined keys of a component -> index in Components
+	byPath   map[string]scanner.ArchiveFile
+	affected map[string]bool // Group keys of the files added, removed or modified since
+	changed  int
+}
+
+type fileStamp struct {
+	Name    string `json:"n"`
+	Size    int64  `json:"s"`
+	ModTime int64  `json:"m"`
+}
+
+type cachedPair struct {
+	A     string  `json:"a"`
+	B     string  `json:"b"`
+	Score float64 `json:"s"`
+}
+
+type cachedComponent struct {
+	Keys   []string      `json:"keys"`
+	Groups []cachedGroup `json:"groups"`
+}
+
+type cachedGroup struct {
+	BaseName string    `json:"base"`
+	Paths    []string  `json:"paths"`
+	Scores   []float64 `json:"scores"`
+	Series   bool      `json:"series,omitempty"`
+}
+
+func stampOf(f scanner.ArchiveFile) fileStamp {
+	return fileStamp{Name: f.Name, Size: f.Size, ModTime: f.ModTime.UnixNano()}
+}
+
+// nameScorers only read the file names, which the fingerprint of the files covers. Other
+// scorers (visual, manifest, registered ones) read inputs that can change while the files stay
+// the same, such as preview hashes computed by a later visual analysis.
+var nameScorers = map[string]bool{"name": true, "token": true}
+
+// cacheable reports whether the clusters built with the options depend only on the files and
+// the settings, so that they can be saved and reused
+func cacheable(opts Options) bool {
+	return opts.Cache != nil && opts.namesOnly()
+}
+
+// namesOnly reports whether every weighted scorer only reads the file names
+func (opts Options) namesOnly() bool {
+	for name, weight := range opts.Scorers {
+		if weight > 0 && !nameScorers[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheSettings describes everything besides the files that the clusters depend on
+func cacheSettings(opts Options) string {
+	scorers := make([]string, 0, len(opts.Scorers))
+	for name, weight := range opts.Scorers {
+		scorers = append(scorers, fmt.Sprintf("%s=%g", name, weight))
+	}
+	sort.Strings(scorers)
+	return fmt.Sprintf("v%d threshold=%d phonetic=%s scorers=%s max_cluster=%d",
+		cacheVersion, opts.Threshold, opts.Phonetic, strings.Join(scorers, ","), opts.MaxClusterSize)
+}
+
+// loadClusterCache returns the fingerprint of the files and the clusters saved for their root
+// with the same settings, if any, and whether those were built from exactly these files
+func loadClusterCache(files []scanner.ArchiveFile, opts Options) (string, *clusterCache, bool) {
+	if !cacheable(opts) {
+		return "", nil, false
+	}
+	fingerprint := opts.Cache.CalculateFingerprint(files)
+	data, exact, ok := opts.Cache.GetClusters(fingerprint, cacheSettings(opts))
+	if !ok {
+		return fingerprint, nil, false
+	}
+	var c clusterCache
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fingerprint, nil, false
+	}
+
+	c.scores = make(map[keyPair]float64, len(c.Pairs))
+	for _, p := range c.Pairs {
+		c.scores[orderedPair(p.A, p.B)] = p.Score
+	}
+	c.byKeys = make(map[string]int, len(c.Components))
+	for i, comp := range c.Components {
+		c.byKeys[strings.Join(comp.Keys, "\x00")] = i
+	}
+	return fingerprint, &c, exact
+}
+
+// saveClusterCache saves the clusters built at the threshold of the options for the next run
+func saveClusterCache(fingerprint string, in *clusterInput, components []component, metrics reporter.ClusterMetrics) {
+	cache := in.opts.Cache
+	if !cacheable(in.opts) {
+		return
+	}
+	c := clusterCache{
+		Files:   make(map[string]fileStamp),
+		Pairs:   make([]cachedPair, len(in.candidates)),
+		Metrics: metrics,
+	}
+	for _, files := range in.grouped {
+		for _, f := range files {
+			c.Files[f.Path] = stampOf(f)
+		}
+	}
+	for i, pair := range in.candidates {
+		c.Pairs[i] = cachedPair{A: pair.a, B: pair.b, Score: in.pairScores[i]}
+	}
+	for _, comp := range components {
+		if len(comp.groups) == 0 {
+			continue
+		}
+		cc := cachedComponent{Keys: comp.keys}
+		for _, g := range comp.groups {
+			paths := make([]string, len(g.Files))
+			for i, f := range g.Files {
+				paths[i] = f.Path
+			}
+			cc.Groups = append(cc.Groups, cachedGroup{BaseName: g.BaseName, Paths: paths, Scores: g.Scores, Series: g.Series})
+		}
+		c.Components = append(c.Components, cc)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	cache.PutClusters(fingerprint, cacheSettings(in.opts), data)
+}
+
+// restore returns the saved clusters and metrics as they are, for the files they were built from
+func (c *clusterCache) restore(files []scanner.ArchiveFile) ([]SimilarityGroup, reporter.ClusterMetrics, bool) {
+	c.byPath = make(map[string]scanner.ArchiveFile, len(files))
+	for _, f := range files {
+		c.byPath[f.Path] = f
+	}
+	components := make([]component, len(c.Components))
+	for i, comp := range c.Components {
+		groups, ok := c.groupsOf(comp)
+		if !ok {
+			return nil, reporter.ClusterMetrics{}, false
+		}
+		components[i] = component{keys: comp.Keys, groups: groups, reused: true}
+	}
+
+	groups := flatten(components)
+	metrics := c.Metrics
+	metrics.Cache = &reporter.ClusterCache{Hit: true, Reused: len(groups)}
+	return groups, metrics, true
+}
+
+// invalidate compares the files with those of the saved clusters and marks the group keys of
+// the files added, removed or modified since (under their old and new names)
+func (c *clusterCache) invalidate(files []scanner.ArchiveFile, in *clusterInput) {
+	if c == nil {
+		return
+	}
+	c.affected = make(map[string]bool)
+	c.byPath = make(map[string]scanner.ArchiveFile, len(files))
+	for _, f := range files {
+		c.byPath[f.Path] = f
+		old, ok := c.Files[f.Path]
+		if ok && old == stampOf(f) {
+			continue
+		}
+		c.changed++
+		c.affected[in.groupKey(f)] = true
+		if ok {
+			c.affected[in.nameKey(old.Name)] = true
+		}
+	}
+	for path, old := range c.Files {
+		if _, ok := c.byPath[path]; !ok {
+			c.changed++
+			c.affected[in.nameKey(old.Name)] = true
+		}
+	}
+}
+
+// score returns the saved score of a candidate pair whose files did not change
+func (c *clusterCache) score(pair keyPair) (float64, bool) {
+	if c == nil || c.affected[pair.a] || c.affected[pair.b] {
+		return 0, false
+	}
+	score, ok := c.scores[orderedPair(pair.a, pair.b)]
+	return score, ok
+}
+
+// reuse returns the saved clusters of a component when the previous run built the very same
+// component and none of its files changed: refining it again would give the same clusters
+func (c *clusterCache) reuse(keys []string, in *clusterInput) ([]SimilarityGroup, bool) {
+	if c == nil {
+		return nil, false
+	}
+	i, ok := c.byKeys[strings.Join(keys, "\x00")]
+	if !ok {
+		return nil, false
+	}
+	for _, key := range keys {
+		if c.affected[key] {
+			return nil, false
+		}
+	}
+	return c.groupsOf(c.Components[i])
+}
+
+// groupsOf turns the saved clusters of a component back into clusters of the current files
+func (c *clusterCache) groupsOf(comp cachedComponent) ([]SimilarityGroup, bool) {
+	groups := make([]SimilarityGroup, 0, len(comp.Groups))
+	for _, g := range comp.Groups {
+		files := make([]scanner.ArchiveFile, len(g.Paths))
+		for i, path := range g.Paths {
+			f, ok := c.byPath[path]
+			if !ok {
+				return nil, false
+			}
+			files[i] = f
+		}
+		groups = append(groups, SimilarityGroup{BaseName: g.BaseName, Files: files, Scores: g.Scores, Series: g.Series})
+	}
+	return groups, true
+}
+
+// stats counts the clusters reused from the cache and those built again
+func (c *clusterCache) stats(components []component) *reporter.ClusterCache {
+	s := &reporter.ClusterCache{ChangedFiles: c.changed}
+	for _, comp := range components {
+		if comp.reused {
+			s.Reused += len(comp.groups)
+		} else {
+			s.Rebuilt += len(comp.groups)
+		}
+	}
+	return s
+}
+
+func orderedPair(a, b string) keyPair {
+	if a > b {
+		a, b = b, a
+	}
+	return keyPair{a: a, b: b}
+}
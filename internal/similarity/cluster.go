@@ -0,0 +1,240 @@
+package similarity
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/scanner"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// LSH buckets shared by more than maxBlockSize keys (e.g. names that are mostly "model" or
+// "stl") would bring back quadratic comparisons: their keys are sorted and each is only paired
+// with the blockWindow keys after it.
+const (
+	maxBlockSize = 200
+	blockWindow  = 50
+)
+
+type keyPair struct {
+	a, b string
+}
+
+// candidateMemo keeps the last generated candidate pairs so that re-running Step 3
+// with a different threshold only has to re-score them.
+var candidateMemo struct {
+	sync.Mutex
+	fingerprint string
+	pairs       []keyPair
+	unscored    int
+}
+
+// normalizeNames computes the canonical key and token fingerprint of every file name,
+// reading and populating the name cache when one is provided.
+func normalizeNames(files []scanner.ArchiveFile, cache *db.Cache) map[string]db.NameKeys {
+	names := make([]string, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if !seen[f.Name] {
+			seen[f.Name] = true
+			names = append(names, f.Name)
+		}
+	}
+
+	keys := make(map[string]db.NameKeys, len(names))
+	if cache != nil {
+		keys = cache.GetNameKeys(names, normalizerVersion)
+	}
+
+	missing := make(map[string]db.NameKeys)
+	for _, name := range names {
+		if _, ok := keys[name]; ok {
+			continue
+		}
+		canonical := generateCanonicalKey(name)
+		k := db.NameKeys{Canonical: canonical, Tokens: tokenFingerprint(canonical)}
+		keys[name] = k
+		missing[name] = k
+	}
+
+	if cache != nil {
+		cache.PutNameKeys(missing, normalizerVersion)
+	}
+	return keys
+}
+
+// tokenFingerprint returns the sorted unique tokens of a canonical key
+func tokenFingerprint(canonical string) string {
+	words := strings.Fields(canonical)
+	sort.Strings(words)
+	unique := words[:0]
+	for i, w := range words {
+		if i == 0 || w != words[i-1] {
+			unique = append(unique, w)
+		}
+	}
+	return strings.Join(unique, " ")
+}
+
+// generateCandidates returns the pairs of group keys that land in the same MinHash/LSH bucket,
+// so only names sharing a good part of their character trigrams get a full score, and how many
+// pairs of crowded buckets were left out (see blockPairs).
+func generateCandidates(tokensOf map[string]string) ([]keyPair, int) {
+	keys := make([]string, 0, len(tokensOf))
+	for key := range tokensOf {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+	}
+	fingerprint := fmt.Sprintf("%x", h.Sum(nil))
+
+	candidateMemo.Lock()
+	defer candidateMemo.Unlock()
+	if candidateMemo.fingerprint == fingerprint {
+		return candidateMemo.pairs, candidateMemo.unscored
+	}
+
+	seen := make(map[keyPair]bool)
+	var pairs []keyPair
+	unscored := 0
+	for _, block := range lshBuckets(keys, tokensOf) {
+		unscored += blockPairs(block, func(a, b string) {
+			p := orderedPair(a, b)
+			if !seen[p] {
+				seen[p] = true
+				pairs = append(pairs, p)
+			}
+		})
+	}
+
+	candidateMemo.fingerprint = fingerprint
+	candidateMemo.pairs = pairs
+	candidateMemo.unscored = unscored
+	return pairs, unscored
+}
+
+// blockPairs calls pair for every two keys of a bucket, or for a bucket of more than
+// maxBlockSize keys, for every key and the blockWindow keys after it in sorted order. It
+// returns the number of pairs left out; a pair left out of one bucket may still come from
+// another.
+func blockPairs(block []string, pair func(a, b string)) int {
+	window := len(block)
+	if len(block) > maxBlockSize {
+		block = slices.Clone(block)
+		sort.Strings(block)
+		window = blockWindow
+	}
+	visited := 0
+	for i := 0; i < len(block); i++ {
+		for j := i + 1; j < len(block) && j <= i+window; j++ {
+			pair(block[i], block[j])
+			visited++
+		}
+	}
+	return len(block)*(len(block)-1)/2 - visited
+}
+
+// blockCandidates adds to the name candidates the pairs of group keys holding files that share
+// a bucket of the scorer, and returns for each such pair the files that did, and how many pairs
+// of crowded buckets were left out
+func blockCandidates(names []keyPair, grouped map[string][]scanner.ArchiveFile, blocker Blocker) ([]keyPair, map[keyPair][2]scanner.ArchiveFile, int) {
+	keys := make([]string, 0, len(grouped))
+	for key := range grouped {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// The first file of each key in a bucket stands for the key there
+	var order []string
+	members := make(map[string][]string)
+	fileOf := make(map[[2]string]scanner.ArchiveFile)
+	for _, key := range keys {
+		for _, f := range grouped[key] {
+			for _, block := range blocker.Blocks(f) {
+				if _, ok := fileOf[[2]string{block, key}]; ok {
+					continue
+				}
+				if len(members[block]) == 0 {
+					order = append(order, block)
+				}
+				members[block] = append(members[block], key)
+				fileOf[[2]string{block, key}] = f
+			}
+		}
+	}
+
+	seen := make(map[keyPair]bool, len(names))
+	for _, p := range names {
+		seen[p] = true
+	}
+	pairs := slices.Clone(names)
+	matched := make(map[keyPair][2]scanner.ArchiveFile)
+	unscored := 0
+	for _, block := range order {
+		unscored += blockPairs(members[block], func(a, b string) {
+			p := orderedPair(a, b)
+			if !seen[p] {
+				seen[p] = true
+				pairs = append(pairs, p)
+			}
+			if _, ok := matched[p]; !ok {
+				matched[p] = [2]scanner.ArchiveFile{fileOf[[2]string{block, p.a}], fileOf[[2]string{block, p.b}]}
+			}
+		})
+	}
+	return pairs, matched, unscored
+}
+
+// unionFind merges group keys into clusters
+type unionFind struct {
+	parent map[string]string
+}
+
+func newUnionFind() *unionFind {
+	return &unionFind{parent: make(map[string]string)}
+}
+
+func (u *unionFind) add(key string) {
+	if _, ok := u.parent[key]; !ok {
+		u.parent[key] = key
+	}
+}
+
+func (u *unionFind) find(key string) string {
+	for u.parent[key] != key {
+		u.parent[key] = u.parent[u.parent[key]]
+		key = u.parent[key]
+	}
+	return key
+}
+
+func (u *unionFind) union(a, b string) {
+	ra, rb := u.find(a), u.find(b)
+	if ra != rb {
+		u.parent[rb] = ra
+	}
+}
+
+// groups returns the members of every cluster, sorted for deterministic output
+func (u *unionFind) groups() [][]string {
+	byRoot := make(map[string][]string)
+	for key := range u.parent {
+		root := u.find(key)
+		byRoot[root] = append(byRoot[root], key)
+	}
+
+	result := make([][]string, 0, len(byRoot))
+	for _, members := range byRoot {
+		sort.Strings(members)
+		result = append(result, members)
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package similarity
+
+import (
+	"fmt"
+	"testing"
+
+	"archive-duplicate-finder/internal/scanner"
+)
+
+// contentScorer rates files by the contents of its table: the same contents score 100
+type contentScorer map[string]string
+
+func (c contentScorer) Score(a, b scanner.ArchiveFile) float64 {
+	if c[a.Path] != "" && c[a.Path] == c[b.Path] {
+		return 100
+	}
+	return 0
+}
+
+func (c contentScorer) Blocks(f scanner.ArchiveFile) []string {
+	if c[f.Path] == "" {
+		return nil
+	}
+	return []string{c[f.Path]}
+}
+
+func TestBlockerLinksUnrelatedNames(t *testing.T) {
+	contents := contentScorer{
+		"/lib/dragon_knight.zip":   "knight",
+		"/lib/dragon_knight_2.zip": "tower",
+		"/lib/castle_keep.zip":     "keep",
+		"/lib/upload_4421.zip":     "tower",
+	}
+	RegisterScorer("test-contents", func(Options) Scorer { return contents })
+	defer func() {
+		registryMu.Lock()
+		delete(registry, "test-contents")
+		registryMu.Unlock()
+	}()
+
+	var files []scanner.ArchiveFile
+	for path := range contents {
+		files = append(files, scanner.ArchiveFile{Name: path[len("/lib/"):], Path: path})
+	}
+	groups := FindSimilarGroups(files, Options{Threshold: 90, Scorers: map[string]float64{"test-contents": 1}}, nil)
+
+	// The names share nothing, and "upload_4421" matches the second file of the "dragon knight" key
+	found := make(map[string]bool)
+	for _, g := range groups {
+		for _, f := range g.Files {
+			found[f.Name] = true
+		}
+	}
+	if !found["upload_4421.zip"] {
+		t.Errorf("upload_4421.zip was not clustered with the archive holding the same contents: %+v", groups)
+	}
+	if found["castle_keep.zip"] {
+		t.Errorf("castle_keep.zip was clustered without a match: %+v", groups)
+	}
+}
+
+func TestBlockPairsWindowsCrowdedBuckets(t *testing.T) {
+	small := []string{"c", "a", "b"}
+	var pairs int
+	if unscored := blockPairs(small, func(a, b string) { pairs++ }); unscored != 0 || pairs != 3 {
+		t.Errorf("small bucket: %d pairs, %d unscored, want 3 and 0", pairs, unscored)
+	}
+
+	// Every key of a crowded bucket is still compared, with the keys next to it in sorted order
+	crowded := make([]string, maxBlockSize+50)
+	for i := range crowded {
+		crowded[i] = fmt.Sprintf("model %03d", len(crowded)-i)
+	}
+	compared := make(map[string]int)
+	pairs = 0
+	unscored := blockPairs(crowded, func(a, b string) {
+		pairs++
+		compared[a]++
+		compared[b]++
+	})
+	n := len(crowded)
+	if pairs+unscored != n*(n-1)/2 {
+		t.Errorf("%d pairs + %d unscored, want %d in all", pairs, unscored, n*(n-1)/2)
+	}
+	if pairs > n*blockWindow {
+		t.Errorf("%d pairs compared, want at most %d", pairs, n*blockWindow)
+	}
+	if len(compared) != n {
+		t.Errorf("%d of %d keys compared", len(compared), n)
+	}
+	if crowded[0] != fmt.Sprintf("model %03d", n) {
+		t.Error("the bucket was sorted in place")
+	}
+}
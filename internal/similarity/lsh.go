@@ -0,0 +1,110 @@
+package similarity
+
+import (
+	"hash/fnv"
+	"strings"
+)
+
+// MinHash / LSH parameters: 20 bands of 3 rows. Two keys become candidates when any band
+// matches, which happens with ~93% probability at 50% trigram Jaccard and ~15% at 20%.
+const (
+	lshBands = 20
+	lshRows  = 3
+)
+
+// lshSeeds are fixed odd multipliers so signatures are stable across runs
+var lshSeeds = func() [lshBands * lshRows]uint64 {
+	var seeds [lshBands * lshRows]uint64
+	x := uint64(0x9E3779B97F4A7C15)
+	for i := range seeds {
+		// splitmix64
+		x += 0x9E3779B97F4A7C15
+		z := x
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		seeds[i] = (z ^ (z >> 31)) | 1
+	}
+	return seeds
+}()
+
+// trigrams returns the character 3-grams of every token, padded so short tokens still produce grams
+func trigrams(tokens string) []string {
+	var grams []string
+	for _, token := range strings.Fields(tokens) {
+		padded := []rune(" " + token + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			grams = append(grams, string(padded[i:i+3]))
+		}
+	}
+	return grams
+}
+
+// minHashSignature computes the MinHash signature of a token fingerprint
+func minHashSignature(tokens string) ([lshBands * lshRows]uint64, bool) {
+	return minHash(trigrams(tokens))
+}
+
+// minHash computes the MinHash signature of a set of strings
+func minHash(items []string) ([lshBands * lshRows]uint64, bool) {
+	var sig [lshBands * lshRows]uint64
+	if len(items) == 0 {
+		return sig, false
+	}
+
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, item := range items {
+		h := fnv.New64a()
+		h.Write([]byte(item))
+		base := h.Sum64()
+		for i, seed := range lshSeeds {
+			v := base * seed
+			v ^= v >> 29
+			if v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig, true
+}
+
+// bandHashes returns the hash of every band of a signature: two signatures agreeing on a band
+// land in the same bucket
+func bandHashes(sig [lshBands * lshRows]uint64) [lshBands]uint64 {
+	var bands [lshBands]uint64
+	for b := range bands {
+		h := fnv.New64a()
+		for r := 0; r < lshRows; r++ {
+			v := sig[b*lshRows+r]
+			for shift := 0; shift < 64; shift += 8 {
+				h.Write([]byte{byte(v >> shift)})
+			}
+		}
+		bands[b] = h.Sum64()
+	}
+	return bands
+}
+
+// lshBuckets groups keys whose signatures agree on at least one band
+func lshBuckets(keys []string, tokensOf map[string]string) [][]string {
+	buckets := make(map[[2]uint64][]string)
+	for _, key := range keys {
+		sig, ok := minHashSignature(tokensOf[key])
+		if !ok {
+			continue
+		}
+		for b, band := range bandHashes(sig) {
+			bucket := [2]uint64{uint64(b), band}
+			buckets[bucket] = append(buckets[bucket], key)
+		}
+	}
+
+	result := make([][]string, 0, len(buckets))
+	for _, members := range buckets {
+		if len(members) > 1 {
+			result = append(result, members)
+		}
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package similarity
+
+// NameIndex looks up the closest name of a fixed set, e.g. the archives of a library that
+// files from another folder are compared against. Names are normalized once.
+type NameIndex struct {
+	opts  Options
+	keys  []string
+	exact map[string]int // canonical key -> first index with that key
+}
+
+// NewNameIndex normalizes names for repeated Closest lookups
+func NewNameIndex(names []string, opts Options) *NameIndex {
+	ix := &NameIndex{opts: opts, keys: make([]string, len(names)), exact: make(map[string]int)}
+	for i, name := range names {
+		key := generateCanonicalKey(name)
+		ix.keys[i] = key
+		if _, ok := ix.exact[key]; !ok {
+			ix.exact[key] = i
+		}
+	}
+	return ix
+}
+
+// Closest returns the index of the best-scoring name and its 0-100 score, or -1 when no name
+// reaches opts.Threshold
+func (ix *NameIndex) Closest(name string) (int, float64) {
+	key := generateCanonicalKey(name)
+	if i, ok := ix.exact[key]; ok {
+		return i, 100
+	}
+
+	best, bestScore := -1, 0.0
+	for i, k := range ix.keys {
+		if score := scoreKeys(key, k, ix.opts); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if bestScore < float64(ix.opts.Threshold) {
+		return -1, 0
+	}
+	return best, bestScore
+}
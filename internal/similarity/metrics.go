@@ -0,0 +1,152 @@
+package similarity
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"fmt"
+	"math"
+)
+
+const (
+	// looseChained is the share of chained members above which clusters look too loose
+	looseChained = 0.25
+	// acceptableChained is the share of chained members a suggested threshold must get under
+	acceptableChained = 0.10
+	// nearMissRange is how far below the threshold an unmerged pair counts as a near miss
+	nearMissRange = 10.0
+	// minSuggestedThreshold keeps suggestions out of the range where unrelated names merge
+	minSuggestedThreshold = 50
+)
+
+// FindSimilarGroupsWithMetrics clusters like FindSimilarGroups and rates the clusters,
+// suggesting a threshold when they look too loose or too tight. Candidate pairs are scored
+// once: the thresholds tried for the suggestion only rebuild the clusters.
+// With a cache, the clusters are saved for the next run, which reuses them as they are when
+// no file changed, and otherwise rebuilds only the clusters near the files that did.
+func FindSimilarGroupsWithMetrics(files []scanner.ArchiveFile, opts Options, onProgress func(float64)) ([]SimilarityGroup, reporter.ClusterMetrics) {
+	if len(files) < 2 {
+		return nil, reporter.ClusterMetrics{Threshold: opts.Threshold}
+	}
+
+	fingerprint, prev, exact := loadClusterCache(files, opts)
+	if exact {
+		if groups, metrics, ok := prev.restore(files); ok {
+			if onProgress != nil {
+				onProgress(100.0)
+			}
+			return groups, metrics
+		}
+	}
+
+	in := prepareClusters(files, opts, prev, onProgress)
+	components := in.components(opts.Threshold, prev, onProgress)
+	groups := flatten(components)
+	metrics := in.metrics(opts.Threshold, groups)
+	metrics.SuggestedThreshold, metrics.Advice = in.suggestThreshold(metrics)
+	metrics.UnscoredPairs = in.unscored
+	saveClusterCache(fingerprint, in, components, metrics)
+	if prev != nil {
+		metrics.Cache = prev.stats(components)
+	}
+	return groups, metrics
+}
+
+// metrics rates the clusters built at a threshold
+func (in *clusterInput) metrics(threshold int, groups []SimilarityGroup) reporter.ClusterMetrics {
+	m := reporter.ClusterMetrics{Threshold: threshold, Clusters: len(groups)}
+	if len(groups) == 0 {
+		return m
+	}
+
+	clusterOf := make(map[string]int)
+	var chained int
+	cohesion := make([]float64, len(groups))
+	for i, g := range groups {
+		var total float64
+		for j, f := range g.Files {
+			clusterOf[in.groupKey(f)] = i
+			total += g.Scores[j]
+			if g.Scores[j] < float64(threshold) {
+				chained++
+			}
+		}
+		cohesion[i] = total / float64(len(g.Files))
+		m.Files += len(g.Files)
+	}
+
+	// The closest name outside a cluster is the best scored candidate pair leaving it
+	nearest := make([]float64, len(groups))
+	hasNeighbour := make([]bool, len(groups))
+	for i, pair := range in.candidates {
+		score := in.pairScores[i]
+		ca, okA := clusterOf[pair.a]
+		cb, okB := clusterOf[pair.b]
+		if okA && okB && ca == cb {
+			continue
+		}
+		if score < float64(threshold) && score >= float64(threshold)-nearMissRange {
+			m.NearMisses++
+		}
+		for _, c := range []struct {
+			cluster int
+			ok      bool
+		}{{ca, okA}, {cb, okB}} {
+			if c.ok {
+				nearest[c.cluster] = math.Max(nearest[c.cluster], score)
+				hasNeighbour[c.cluster] = true
+			}
+		}
+	}
+
+	var silhouette float64
+	var neighboured int
+	for i := range groups {
+		m.Cohesion += cohesion[i]
+		if !hasNeighbour[i] {
+			silhouette += 1 // Nothing comparable was left out
+			continue
+		}
+		neighboured++
+		m.Separation += nearest[i]
+		if spread := math.Max(cohesion[i], nearest[i]); spread > 0 {
+			silhouette += (cohesion[i] - nearest[i]) / spread
+		}
+	}
+	n := float64(len(groups))
+	m.Cohesion = round1(m.Cohesion / n)
+	if neighboured > 0 {
+		m.Separation = round1(m.Separation / float64(neighboured))
+	}
+	m.Silhouette = math.Round(silhouette/n*100) / 100
+	m.Chained = math.Round(float64(chained)/float64(m.Files)*100) / 100
+	return m
+}
+
+// suggestThreshold proposes a threshold when the clusters look too loose (many members only
+// reached their cluster through a chain of other names) or too tight (many near misses). The
+// suggestion is the closest threshold, in steps of 5, whose clusters are not chained.
+func (in *clusterInput) suggestThreshold(m reporter.ClusterMetrics) (int, string) {
+	if m.Chained > looseChained {
+		for t := m.Threshold + 5; t <= 100; t += 5 {
+			if in.metrics(t, in.build(t, nil)).Chained <= acceptableChained {
+				return t, fmt.Sprintf("%.0f%% of the clustered files are less similar than %d%% to their cluster's name: clusters look too loose", m.Chained*100, m.Threshold)
+			}
+		}
+		return 0, ""
+	}
+
+	if m.NearMisses > max(m.Clusters/2, 1) {
+		t := m.Threshold - 5
+		if t < minSuggestedThreshold {
+			return 0, ""
+		}
+		if lower := in.metrics(t, in.build(t, nil)); lower.Chained <= acceptableChained && lower.Clusters > 0 {
+			return t, fmt.Sprintf("%d name pairs missed the threshold by less than %.0f points: clusters look too tight", m.NearMisses, nearMissRange)
+		}
+	}
+	return 0, ""
+}
+
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
@@ -1,102 +1,314 @@
 package similarity
 
 import (
+	"archive-duplicate-finder/internal/db"
 	"archive-duplicate-finder/internal/scanner"
+	"log"
 	"regexp"
 	"sort"
 	"strings"
-	"sync"
 )
 
 // SimilarityGroup represents a cluster of files that share a similar canonical name
 type SimilarityGroup struct {
 	BaseName string
 	Files    []scanner.ArchiveFile
+	Scores   []float64 // Per-member similarity (0-100) against the cluster centroid, parallel to Files
+	Series   bool      // Probable parts of a series ("Vol 1", "Vol 2"), not duplicates
+}
+
+// Options controls how names are compared and clustered
+type Options struct {
+	Threshold int
+	Debug     bool
+	Phonetic  string             // "", "soundex" or "metaphone"
+	Cache     *db.Cache          // Optional: persists normalized names between runs
+	Scorers   map[string]float64 // Optional: registered scorer name -> weight. Empty means name-only scoring
+
+	MaxClusterSize int // Clusters with more files are split at stricter thresholds (0 = 100)
 }
 
 // FindSimilarGroups uses an aggressive normalization strategy to cluster files efficiently (O(N))
 // instead of comparing every file with every other file (O(N^2)).
-// FindSimilarGroups uses an aggressive normalization strategy to cluster files efficiently (O(N))
-// instead of comparing every file with every other file (O(N^2)).
-func FindSimilarGroups(files []scanner.ArchiveFile, _ int, _ bool, onProgress func(float64)) []SimilarityGroup {
+// Files sharing a canonical key are grouped directly; distinct keys that share a token are
+// then scored against each other and merged when they reach the threshold. Clusters only keep
+// names that all reach the threshold against each other (see refine).
+func FindSimilarGroups(files []scanner.ArchiveFile, opts Options, onProgress func(float64)) []SimilarityGroup {
 	if len(files) < 2 {
 		return nil
 	}
 
-	// 1. Group by "Canonical Key"
+	in := prepareClusters(files, opts, nil, onProgress)
+	return in.build(opts.Threshold, onProgress)
+}
+
+// clusterInput holds everything that does not depend on the threshold, so that
+// clusters can be rebuilt for several thresholds from a single preparation pass.
+type clusterInput struct {
+	opts        Options
+	keys        map[string]db.NameKeys
+	grouped     map[string][]scanner.ArchiveFile
+	canonicalOf map[string]string // group key -> canonical key used for scoring
+	candidates  []keyPair
+	pairScores  []float64 // parallel to candidates
+	scorer      Scorer    // nil means default name scoring on cached keys
+	scoreMemo   map[keyPair]float64
+	matched     map[keyPair][2]scanner.ArchiveFile // Files of the pairs a Blocker scorer put in one bucket
+	unscored    int                                // Pairs of crowded buckets left out (see blockPairs)
+}
+
+// maxRepresentatives is the number of files of each group key scored against the files of
+// another when the scorers read more than names, which the files of a key do not share
+const maxRepresentatives = 5
+
+// prepareClusters normalizes names, groups exact keys, generates candidate pairs and scores them.
+// Pairs the previous run scored are not scored again while their files are unchanged.
+// Progress is reported from 0 to 90%.
+func prepareClusters(files []scanner.ArchiveFile, opts Options, prev *clusterCache, onProgress func(float64)) *clusterInput {
+	in := &clusterInput{
+		opts:        opts,
+		grouped:     make(map[string][]scanner.ArchiveFile),
+		canonicalOf: make(map[string]string),
+		scorer:      opts.combinedScorer(),
+	}
+
+	// 1. Normalize names (served from the cache when possible)
+	in.keys = normalizeNames(files, opts.Cache)
+	if onProgress != nil {
+		onProgress(40.0)
+	}
+
+	// 2. Group by "Canonical Key"
 	// We map: CanonicalKey -> []ArchiveFile
-	grouped := make(map[string][]scanner.ArchiveFile)
-	var mu sync.Mutex
-
-	totalFiles := len(files)
-	batchSize := 1000 // Update progress every N files
-
-	// Parallelize the normalization step if N is huge, but usually simple loop is fine.
-	// For 70k files, a single thread map insert is ~50ms.
-	for i, f := range files {
-		key := generateCanonicalKey(f.Name)
-		mu.Lock()
-		grouped[key] = append(grouped[key], f)
-		mu.Unlock()
-
-		if i%batchSize == 0 && onProgress != nil {
-			progress := (float64(i) / float64(totalFiles)) * 100
-			onProgress(progress)
+	tokensOf := make(map[string]string) // group key -> token fingerprint
+	for _, f := range files {
+		k := in.keys[f.Name]
+		key := in.groupKey(f)
+		in.grouped[key] = append(in.grouped[key], f)
+		if _, ok := in.canonicalOf[key]; !ok {
+			in.canonicalOf[key] = k.Canonical
+			tokensOf[key] = k.Tokens
 		}
 	}
 
 	if onProgress != nil {
-		onProgress(90.0) // Generating keys done
+		onProgress(60.0) // Generating keys done
 	}
 
-	// 2. Filter groups
-	var results []SimilarityGroup
+	prev.invalidate(files, in)
 
-	totalGroups := len(grouped)
-	processedGroups := 0
+	// 3. Candidate generation (cached between runs)
+	in.candidates, in.unscored = generateCandidates(tokensOf)
+	if blocker, ok := in.scorer.(Blocker); ok && !opts.namesOnly() {
+		// Scorers reading the contents also compare files whose names have nothing in common
+		var unscored int
+		in.candidates, in.matched, unscored = blockCandidates(in.candidates, in.grouped, blocker)
+		in.unscored += unscored
+	}
+	if in.unscored > 0 {
+		log.Printf("⚠️  Some names are shared by too many files to compare them all: %d pairs were not scored", in.unscored)
+	}
+	if onProgress != nil {
+		onProgress(75.0)
+	}
 
-	for key, group := range grouped {
-		processedGroups++
-		// Simple progress check for filtering phase
-		if processedGroups%100 == 0 && onProgress != nil {
-			// Map remaining 10% to filtering phase
-			baseProgress := 90.0
-			phaseProgress := (float64(processedGroups) / float64(totalGroups)) * 10.0
-			onProgress(baseProgress + phaseProgress)
+	// 4. Score candidates once; thresholds are applied in build
+	in.pairScores = make([]float64, len(in.candidates))
+	for i, pair := range in.candidates {
+		if score, ok := prev.score(pair); ok {
+			in.pairScores[i] = score
+		} else {
+			in.pairScores[i] = in.scoreGroups(pair.a, pair.b)
 		}
+	}
 
-		if len(group) < 2 {
-			continue
+	if onProgress != nil {
+		onProgress(90.0)
+	}
+	return in
+}
+
+// groupKey returns the key a file is grouped under: its canonical name. Phonetic matching only
+// weighs in the score of two keys (see scoreKeys), so that names sounding alike still have to
+// reach the threshold.
+func (in *clusterInput) groupKey(f scanner.ArchiveFile) string {
+	return in.nameKey(f.Name)
+}
+
+// nameKey returns the group key of a file name, also for names missing from this run
+func (in *clusterInput) nameKey(name string) string {
+	if k, ok := in.keys[name]; ok {
+		return k.Canonical
+	}
+	return generateCanonicalKey(name)
+}
+
+// scoreGroups scores two group keys. Default name scoring works on the cached keys;
+// custom scorers give the best score between representative files of both, and the files a
+// Blocker scorer matched them by.
+func (in *clusterInput) scoreGroups(a, b string) float64 {
+	if in.scorer == nil {
+		return scoreKeys(in.canonicalOf[a], in.canonicalOf[b], in.opts)
+	}
+	var best float64
+	for _, fa := range in.representatives(a) {
+		for _, fb := range in.representatives(b) {
+			best = max(best, in.scorer.Score(fa, fb))
 		}
+	}
+	if files, ok := in.matched[orderedPair(a, b)]; ok {
+		best = max(best, in.scorer.Score(files[0], files[1]))
+	}
+	return best
+}
 
-		// Sort by name for consistency
-		sort.Slice(group, func(i, j int) bool {
-			return group[i].Name < group[j].Name
-		})
+// representatives returns the files of a group key that are scored for it: only the first
+// when the scorers read nothing but the names, which the files of a key share
+func (in *clusterInput) representatives(key string) []scanner.ArchiveFile {
+	files := in.grouped[key]
+	if in.opts.namesOnly() {
+		return files[:1]
+	}
+	return files[:min(maxRepresentatives, len(files))]
+}
 
-		// Check if they are just multi-volume parts of the SAME archive
-		if areAllMultiVolumePartsOfSameSet(group) {
-			continue
+// build merges the candidate pairs reaching the threshold and returns the resulting clusters.
+// Progress is reported from 90 to 100%.
+func (in *clusterInput) build(threshold int, onProgress func(float64)) []SimilarityGroup {
+	return flatten(in.components(threshold, nil, onProgress))
+}
+
+// component is a set of group keys linked by candidate pairs reaching the threshold, with the
+// clusters refined out of it
+type component struct {
+	keys   []string
+	groups []SimilarityGroup
+	reused bool // Clusters taken from the similarity cache
+}
+
+// components merges the candidate pairs reaching the threshold and refines every resulting
+// component into clusters. Components the previous run saw unchanged reuse its clusters.
+func (in *clusterInput) components(threshold int, prev *clusterCache, onProgress func(float64)) []component {
+	clusters := newUnionFind()
+	for key := range in.grouped {
+		clusters.add(key)
+	}
+	for i, pair := range in.candidates {
+		if in.pairScores[i] >= float64(threshold) {
+			if in.opts.Debug {
+				log.Printf("[STEP3] Merging '%s' ~ '%s'", in.canonicalOf[pair.a], in.canonicalOf[pair.b])
+			}
+			clusters.union(pair.a, pair.b)
 		}
+	}
 
-		results = append(results, SimilarityGroup{
-			BaseName: key,
-			Files:    group,
-		})
+	merged := clusters.groups()
+	result := make([]component, 0, len(merged))
+	for i, members := range merged {
+		// Simple progress check for filtering phase
+		if (i+1)%100 == 0 && onProgress != nil {
+			// Map remaining 10% to filtering phase
+			onProgress(90.0 + float64(i+1)/float64(len(merged))*10.0)
+		}
+
+		c := component{keys: members}
+		if groups, ok := prev.reuse(members, in); ok {
+			c.groups, c.reused = groups, true
+		} else {
+			// Chains of merged pairs are split into clusters whose names all match each other
+			for _, keys := range in.refine(members, threshold) {
+				if g, ok := in.group(keys); ok {
+					c.groups = append(c.groups, g)
+				}
+			}
+		}
+		result = append(result, c)
 	}
 
 	if onProgress != nil {
 		onProgress(100.0)
 	}
+	return result
+}
 
-	// Sort results by group size (descending) to show biggest clusters first
-	sort.Slice(results, func(i, j int) bool {
-		return len(results[i].Files) > len(results[j].Files)
+// group turns refined group keys into a cluster, unless it holds a single file or the parts
+// of one multi-volume archive
+func (in *clusterInput) group(members []string) (SimilarityGroup, bool) {
+	// The biggest exact-key subgroup names the cluster
+	var group []scanner.ArchiveFile
+	baseKey := members[0]
+	for _, key := range members {
+		group = append(group, in.grouped[key]...)
+		if len(in.grouped[key]) > len(in.grouped[baseKey]) {
+			baseKey = key
+		}
+	}
+
+	if len(group) < 2 {
+		return SimilarityGroup{}, false
+	}
+
+	// Sort by name for consistency
+	sort.Slice(group, func(i, j int) bool {
+		return group[i].Name < group[j].Name
 	})
 
+	// Check if they are just multi-volume parts of the SAME archive
+	if areAllMultiVolumePartsOfSameSet(group) {
+		return SimilarityGroup{}, false
+	}
+
+	// Score every member against the centroid (the canonical key naming the cluster)
+	centroid := in.canonicalOf[baseKey]
+	scores := make([]float64, len(group))
+	for i, f := range group {
+		if in.scorer == nil {
+			scores[i] = scoreKeys(in.keys[f.Name].Canonical, centroid, in.opts)
+		} else {
+			scores[i] = in.scorer.Score(f, in.grouped[baseKey][0])
+		}
+	}
+
+	return SimilarityGroup{
+		BaseName: centroid,
+		Files:    group,
+		Scores:   scores,
+		Series:   IsProbableSeries(group),
+	}, true
+}
+
+// flatten returns the clusters of the components, the biggest first and probable series last
+func flatten(components []component) []SimilarityGroup {
+	var results []SimilarityGroup
+	for _, c := range components {
+		results = append(results, c.groups...)
+	}
+
+	// Sort results by group size (descending) to show biggest clusters first; probable series go last
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Series != results[j].Series {
+			return !results[i].Series
+		}
+		return len(results[i].Files) > len(results[j].Files)
+	})
 	return results
 }
 
+var (
+	reVersion = regexp.MustCompile(`\bv\d+(\.\d+)*\b`)
+	reNumbers = regexp.MustCompile(`\b\d+\b`)
+)
+
+// CanonicalName is the normalized form of a file name Step 3 compares: lowercase, without
+// extension, separators, version numbers and noise words
+func CanonicalName(name string) string {
+	return generateCanonicalKey(name)
+}
+
+// normalizerVersion tags the keys generateCanonicalKey and tokenFingerprint put in the name
+// cache: bump it whenever either changes its output, so keys computed before are not reused
+const normalizerVersion = 1
+
 // generateCanonicalKey reduces a filename to its "essence" to find matches.
 func generateCanonicalKey(name string) string {
 	// 1. Lowercase
@@ -120,11 +332,9 @@ func generateCanonicalKey(name string) string {
 	// 4. Remove common "noise" words using Regex
 	// We want to remove version numbers (v1, 1.0, etc), "copy", "backup", date stamps somewhat.
 	// Regex: Remove "v" followed by digits
-	reVersion := regexp.MustCompile(`\bv\d+(\.\d+)*\b`)
 	s = reVersion.ReplaceAllString(s, "")
 
 	// Remove isolated numbers
-	reNumbers := regexp.MustCompile(`\b\d+\b`)
 	s = reNumbers.ReplaceAllString(s, "")
 
 	// Remove specific keywords
@@ -151,6 +361,10 @@ func generateCanonicalKey(name string) string {
 func areAllMultiVolumePartsOfSameSet(files []scanner.ArchiveFile) bool {
 	countPart := 0
 	for _, f := range files {
+		if len(f.Volumes) > 0 {
+			// Collapsed sets are whole archives, not stray parts
+			continue
+		}
 		lower := strings.ToLower(f.Name)
 		if strings.Contains(lower, ".part") || strings.Contains(lower, ".z0") || strings.Contains(lower, ".00") {
 			countPart++
@@ -167,3 +381,57 @@ func CalculateNameSimilarity(name1, name2 string, debug bool) float64 {
 	}
 	return 0
 }
+
+// CalculateNormalizedSimilarity returns a 0-100 score comparing the canonical keys of two names.
+// The base score is the Levenshtein ratio; when opts.Phonetic is set, the phonetic match of
+// the words is weighted in so that fat-fingered names still score high.
+func CalculateNormalizedSimilarity(name1, name2 string, opts Options) float64 {
+	return scoreKeys(generateCanonicalKey(name1), generateCanonicalKey(name2), opts)
+}
+
+// scoreKeys scores two already-normalized canonical keys
+func scoreKeys(key1, key2 string, opts Options) float64 {
+	if key1 == key2 {
+		return 100
+	}
+
+	score := levenshteinRatio(key1, key2)
+	if opts.Phonetic != "" {
+		score = (1-PhoneticWeight)*score + PhoneticWeight*phoneticSimilarity(key1, key2, opts.Phonetic)
+	}
+	return score
+}
+
+// levenshteinRatio returns the edit distance between two strings as a 0-100 similarity
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 100
+	}
+	return (1 - float64(levenshtein(ra, rb))/float64(longest)) * 100
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
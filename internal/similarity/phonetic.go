@@ -0,0 +1,246 @@
+package similarity
+
+import (
+	"strings"
+)
+
+// Supported phonetic algorithms
+const (
+	PhoneticSoundex   = "soundex"
+	PhoneticMetaphone = "metaphone"
+)
+
+// PhoneticWeight is the share of the final score contributed by the phonetic
+// component when phonetic matching is enabled.
+const PhoneticWeight = 0.3
+
+// IsValidPhonetic reports whether the given algorithm name is supported ("" means disabled)
+func IsValidPhonetic(algorithm string) bool {
+	return algorithm == "" || algorithm == PhoneticSoundex || algorithm == PhoneticMetaphone
+}
+
+// phoneticKey converts every word of a canonical key to its phonetic code
+func phoneticKey(canonical, algorithm string) string {
+	words := strings.Fields(canonical)
+	codes := make([]string, 0, len(words))
+	for _, w := range words {
+		if code := phoneticCode(w, algorithm); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return strings.Join(codes, " ")
+}
+
+func phoneticCode(word, algorithm string) string {
+	switch algorithm {
+	case PhoneticSoundex:
+		return Soundex(word)
+	case PhoneticMetaphone:
+		return Metaphone(word)
+	default:
+		return word
+	}
+}
+
+// phoneticSimilarity returns the percentage of words whose phonetic codes match position by position
+func phoneticSimilarity(key1, key2, algorithm string) float64 {
+	w1 := strings.Fields(phoneticKey(key1, algorithm))
+	w2 := strings.Fields(phoneticKey(key2, algorithm))
+	longest := len(w1)
+	if len(w2) > longest {
+		longest = len(w2)
+	}
+	if longest == 0 {
+		return 0
+	}
+
+	matches := 0
+	for i := 0; i < len(w1) && i < len(w2); i++ {
+		if w1[i] == w2[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(longest) * 100
+}
+
+// Soundex returns the American Soundex code (letter + 3 digits) of a word
+func Soundex(word string) string {
+	codes := map[byte]byte{
+		'b': '1', 'f': '1', 'p': '1', 'v': '1',
+		'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+		'd': '3', 't': '3',
+		'l': '4',
+		'm': '5', 'n': '5',
+		'r': '6',
+	}
+
+	w := onlyLetters(word)
+	if w == "" {
+		return ""
+	}
+
+	result := []byte{w[0] - 'a' + 'A'}
+	last := codes[w[0]]
+	for i := 1; i < len(w) && len(result) < 4; i++ {
+		c := w[i]
+		code, ok := codes[c]
+		if !ok {
+			// Vowels separate identical codes, 'h' and 'w' do not
+			if c != 'h' && c != 'w' {
+				last = 0
+			}
+			continue
+		}
+		if code != last {
+			result = append(result, code)
+		}
+		last = code
+	}
+
+	for len(result) < 4 {
+		result = append(result, '0')
+	}
+	return string(result)
+}
+
+// Metaphone returns the (original, Lawrence Philips) Metaphone code of a word
+func Metaphone(word string) string {
+	w := onlyLetters(word)
+	if w == "" {
+		return ""
+	}
+
+	// Initial letter exceptions
+	switch {
+	case strings.HasPrefix(w, "kn"), strings.HasPrefix(w, "gn"), strings.HasPrefix(w, "pn"),
+		strings.HasPrefix(w, "ae"), strings.HasPrefix(w, "wr"):
+		w = w[1:]
+	case w[0] == 'x':
+		w = "s" + w[1:]
+	case strings.HasPrefix(w, "wh"):
+		w = "w" + w[2:]
+	}
+
+	isVowel := func(c byte) bool {
+		return c == 'a' || c == 'e' || c == 'i' || c == 'o' || c == 'u'
+	}
+	at := func(i int) byte {
+		if i < 0 || i >= len(w) {
+			return 0
+		}
+		return w[i]
+	}
+
+	var out strings.Builder
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+
+		// Drop duplicate adjacent letters, except C
+		if c != 'c' && i > 0 && at(i-1) == c {
+			continue
+		}
+
+		switch c {
+		case 'a', 'e', 'i', 'o', 'u':
+			if i == 0 {
+				out.WriteByte(c - 'a' + 'A')
+			}
+		case 'b':
+			// Silent if after M at the end of the word
+			if !(i == len(w)-1 && at(i-1) == 'm') {
+				out.WriteByte('B')
+			}
+		case 'c':
+			switch {
+			case at(i+1) == 'i' && at(i+2) == 'a', at(i+1) == 'h':
+				if at(i-1) == 's' && at(i+1) == 'h' {
+					out.WriteByte('K')
+				} else {
+					out.WriteByte('X')
+				}
+			case at(i+1) == 'i' || at(i+1) == 'e' || at(i+1) == 'y':
+				if at(i-1) != 's' {
+					out.WriteByte('S')
+				}
+			default:
+				out.WriteByte('K')
+			}
+		case 'd':
+			if at(i+1) == 'g' && (at(i+2) == 'e' || at(i+2) == 'y' || at(i+2) == 'i') {
+				out.WriteByte('J')
+			} else {
+				out.WriteByte('T')
+			}
+		case 'g':
+			switch {
+			case at(i+1) == 'h' && !isVowel(at(i+2)) && i+2 < len(w):
+				// Silent as in "night"
+			case at(i+1) == 'n' && (i+2 == len(w) || (at(i+2) == 'e' && at(i+3) == 'd' && i+4 == len(w))):
+				// Silent as in "sign", "signed"
+			case (at(i+1) == 'i' || at(i+1) == 'e' || at(i+1) == 'y') && at(i-1) != 'g':
+				out.WriteByte('J')
+			default:
+				out.WriteByte('K')
+			}
+		case 'h':
+			prev := at(i - 1)
+			if isVowel(at(i+1)) && prev != 'c' && prev != 's' && prev != 'p' && prev != 't' && prev != 'g' {
+				out.WriteByte('H')
+			}
+		case 'k':
+			if at(i-1) != 'c' {
+				out.WriteByte('K')
+			}
+		case 'p':
+			if at(i+1) == 'h' {
+				out.WriteByte('F')
+			} else {
+				out.WriteByte('P')
+			}
+		case 'q':
+			out.WriteByte('K')
+		case 's':
+			if at(i+1) == 'h' || (at(i+1) == 'i' && (at(i+2) == 'o' || at(i+2) == 'a')) {
+				out.WriteByte('X')
+			} else {
+				out.WriteByte('S')
+			}
+		case 't':
+			switch {
+			case at(i+1) == 'i' && (at(i+2) == 'o' || at(i+2) == 'a'):
+				out.WriteByte('X')
+			case at(i+1) == 'h':
+				out.WriteByte('0') // "th"
+			case at(i+1) == 'c' && at(i+2) == 'h':
+				// Silent
+			default:
+				out.WriteByte('T')
+			}
+		case 'v':
+			out.WriteByte('F')
+		case 'w', 'y':
+			if isVowel(at(i + 1)) {
+				out.WriteByte(c - 'a' + 'A')
+			}
+		case 'x':
+			out.WriteString("KS")
+		case 'z':
+			out.WriteByte('S')
+		default:
+			// f, j, l, m, n, r map to themselves
+			out.WriteByte(c - 'a' + 'A')
+		}
+	}
+
+	return out.String()
+}
+
+func onlyLetters(word string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
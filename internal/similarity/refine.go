@@ -0,0 +1,115 @@
+package similarity
+
+import (
+	"log"
+	"sort"
+)
+
+const (
+	// defaultMaxClusterSize is the number of files above which a cluster is split at stricter
+	// thresholds when Options.MaxClusterSize is not set
+	defaultMaxClusterSize = 100
+	// maxLinkageKeys bounds complete linkage: bigger components only compare every key with
+	// the first key of each cluster, so a giant chain does not cost a score per key pair
+	maxLinkageKeys = 300
+)
+
+// refine splits a component of the merged candidate pairs into clusters whose keys all reach
+// the threshold against each other (complete linkage). Merging pairs is transitive, so a
+// chain A~B~C would otherwise put A and C together even when they have nothing in common.
+// Clusters still holding more files than the maximum size are split again at stricter
+// thresholds.
+func (in *clusterInput) refine(members []string, threshold int) [][]string {
+	if len(members) < 3 {
+		return [][]string{members}
+	}
+
+	// The biggest exact-key groups seed the clusters, as they name them
+	sorted := append([]string(nil), members...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(in.grouped[sorted[i]]) != len(in.grouped[sorted[j]]) {
+			return len(in.grouped[sorted[i]]) > len(in.grouped[sorted[j]])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	var clusters [][]string
+	for _, key := range sorted {
+		placed := false
+		for i, cluster := range clusters {
+			if in.linked(key, cluster, threshold) {
+				clusters[i] = append(cluster, key)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			clusters = append(clusters, []string{key})
+		}
+	}
+
+	var refined [][]string
+	for _, cluster := range clusters {
+		if in.fileCount(cluster) > in.maxClusterSize() && len(cluster) > 1 && threshold < 100 {
+			refined = append(refined, in.refine(cluster, min(threshold+5, 100))...)
+		} else {
+			refined = append(refined, cluster)
+		}
+	}
+	if in.opts.Debug && len(refined) > 1 {
+		log.Printf("[STEP3] Split chained cluster '%s' (%d names) into %d clusters", in.canonicalOf[sorted[0]], len(members), len(refined))
+	}
+	return refined
+}
+
+// linked reports whether a key reaches the threshold against every key of a cluster, or only
+// against its first key once the component is too big for complete linkage
+func (in *clusterInput) linked(key string, cluster []string, threshold int) bool {
+	if len(cluster) > maxLinkageKeys {
+		cluster = cluster[:1]
+	}
+	for _, other := range cluster {
+		if in.pairScore(key, other) < float64(threshold) {
+			return false
+		}
+	}
+	return true
+}
+
+// pairScore scores two group keys, reusing the candidate scores and remembering the others
+func (in *clusterInput) pairScore(a, b string) float64 {
+	if a > b {
+		a, b = b, a
+	}
+	p := keyPair{a: a, b: b}
+	if in.scoreMemo == nil {
+		in.scoreMemo = make(map[keyPair]float64, len(in.candidates))
+		for i, c := range in.candidates {
+			if c.a > c.b {
+				c.a, c.b = c.b, c.a
+			}
+			in.scoreMemo[c] = in.pairScores[i]
+		}
+	}
+	if score, ok := in.scoreMemo[p]; ok {
+		return score
+	}
+	score := in.scoreGroups(a, b)
+	in.scoreMemo[p] = score
+	return score
+}
+
+func (in *clusterInput) fileCount(keys []string) int {
+	var n int
+	for _, key := range keys {
+		n += len(in.grouped[key])
+	}
+	return n
+}
+
+func (in *clusterInput) maxClusterSize() int {
+	if in.opts.MaxClusterSize > 0 {
+		return in.opts.MaxClusterSize
+	}
+	return defaultMaxClusterSize
+}
@@ -0,0 +1,131 @@
+package similarity
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"testing"
+
+	"archive-duplicate-finder/internal/scanner"
+)
+
+// tableScorer scores two files by the names in its table; pairs missing from it score 0
+type tableScorer map[[2]string]float64
+
+func (t tableScorer) Score(a, b scanner.ArchiveFile) float64 {
+	if score, ok := t[[2]string{a.Name, b.Name}]; ok {
+		return score
+	}
+	return t[[2]string{b.Name, a.Name}]
+}
+
+// syntheticInput builds what refine works on: one group key per name, holding as many files as
+// counts says, scored by the table
+func syntheticInput(scores tableScorer, counts map[string]int, maxCluster int) (*clusterInput, []string) {
+	in := &clusterInput{
+		opts:        Options{MaxClusterSize: maxCluster},
+		grouped:     make(map[string][]scanner.ArchiveFile),
+		canonicalOf: make(map[string]string),
+		scorer:      scores,
+	}
+	var keys []string
+	for name, n := range counts {
+		for i := 0; i < n; i++ {
+			in.grouped[name] = append(in.grouped[name], scanner.ArchiveFile{Name: name, Path: fmt.Sprintf("/lib/%s/%d.zip", name, i)})
+		}
+		in.canonicalOf[name] = name
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return in, keys
+}
+
+// allPairs scores every pair of names with score
+func allPairs(scores tableScorer, names []string, score float64) {
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			scores[[2]string{names[i], names[j]}] = score
+		}
+	}
+}
+
+func clusterOf(clusters [][]string, key string) int {
+	return slices.IndexFunc(clusters, func(c []string) bool { return slices.Contains(c, key) })
+}
+
+func TestRefineSplitsChain(t *testing.T) {
+	// "dragon" ~ "dragon king" ~ "king" merge pair by pair, but the ends have little in common
+	scores := tableScorer{
+		{"dragon", "dragon king"}: 90,
+		{"dragon king", "king"}:   90,
+		{"dragon", "king"}:        20,
+	}
+	in, keys := syntheticInput(scores, map[string]int{"dragon": 1, "dragon king": 1, "king": 1}, 0)
+
+	clusters := in.refine(keys, 70)
+	if len(clusters) != 2 {
+		t.Fatalf("chain refined into %v, want 2 clusters", clusters)
+	}
+	if clusterOf(clusters, "dragon") == clusterOf(clusters, "king") {
+		t.Errorf("the ends of the chain share a cluster: %v", clusters)
+	}
+	for _, c := range clusters {
+		for _, a := range c {
+			for _, b := range c {
+				if a != b && in.pairScore(a, b) < 70 {
+					t.Errorf("%q and %q share a cluster at %.0f", a, b, in.pairScore(a, b))
+				}
+			}
+		}
+	}
+}
+
+func TestRefineCutsOversizedCluster(t *testing.T) {
+	// Five names of 30 files each all reach 70, but only two tighter sets reach 75
+	ships := []string{"ship a", "ship b", "ship c"}
+	boats := []string{"boat a", "boat b"}
+	scores := tableScorer{}
+	allPairs(scores, append(append([]string(nil), ships...), boats...), 72)
+	allPairs(scores, ships, 90)
+	allPairs(scores, boats, 90)
+	counts := make(map[string]int)
+	for _, name := range append(append([]string(nil), ships...), boats...) {
+		counts[name] = 30
+	}
+	in, keys := syntheticInput(scores, counts, 100)
+
+	clusters := in.refine(keys, 70)
+	if len(clusters) != 2 {
+		t.Fatalf("150 files refined into %v, want 2 clusters", clusters)
+	}
+	for _, c := range clusters {
+		if n := in.fileCount(c); n > 100 {
+			t.Errorf("cluster %v keeps %d files, above the maximum of 100", c, n)
+		}
+	}
+	if clusterOf(clusters, "ship a") != clusterOf(clusters, "ship c") || clusterOf(clusters, "boat a") != clusterOf(clusters, "boat b") {
+		t.Errorf("the tighter sets were split: %v", clusters)
+	}
+
+	// Under the maximum, the same names stay together
+	in, keys = syntheticInput(scores, counts, 200)
+	if clusters := in.refine(keys, 70); len(clusters) != 1 {
+		t.Errorf("150 files under a maximum of 200 refined into %v, want 1 cluster", clusters)
+	}
+}
+
+func TestRefineKeepsTightCluster(t *testing.T) {
+	names := []string{"castle ruins", "castle ruin", "castle-ruins v2", "castle ruins final"}
+	scores := tableScorer{}
+	allPairs(scores, names, 95)
+	counts := make(map[string]int)
+	for _, name := range names {
+		counts[name] = 2
+	}
+	in, keys := syntheticInput(scores, counts, 0)
+
+	clusters := in.refine(keys, 70)
+	if len(clusters) != 1 || len(clusters[0]) != len(names) {
+		t.Errorf("tight cluster refined into %v, want it whole", clusters)
+	}
+}
@@ -0,0 +1,104 @@
+package similarity
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	reVersionToken = regexp.MustCompile(`(?i)(^|[\s_\-.(\[])(v|ver|version|rev)[\s_.]?(\d+(?:\.\d+)*)($|[\s_\-.)\]])`)
+	reNoiseWords   = regexp.MustCompile(`(?i)(^|\s)(copy|backup|final|new|old|temp|tmp)(\s|$)`)
+	reSpaces       = regexp.MustCompile(`\s+`)
+	reCopyMarkerCI = regexp.MustCompile(`(?i)` + reCopyMarker.String())
+)
+
+// NameSuggestion is the canonical name proposed for one member of a cluster
+type NameSuggestion struct {
+	Original  string `json:"original"`
+	Suggested string `json:"suggested"`         // Equal to Original when the name already follows the scheme; empty when ambiguous
+	Version   string `json:"version,omitempty"` // Detected version token, normalized ("v2", "v1.5")
+	Conflict  bool   `json:"conflict,omitempty"`
+}
+
+// SuggestCanonicalNames proposes consistent names for the members of a cluster of variants:
+// the most common base name of the cluster followed by each member's own version token
+// ("Dragon Bust v1.zip", "Dragon Bust v2.zip"). Copy markers and noise words ("copy", "final")
+// are dropped and extensions lowercased. Members that would end up with the same name are
+// true duplicates rather than variants and get no suggestion (Conflict).
+func SuggestCanonicalNames(names []string) []NameSuggestion {
+	type parsed struct {
+		base, version, ext string
+	}
+	parts := make([]parsed, len(names))
+	counts := make(map[string]int)
+	spelling := make(map[string]string) // lowercased base -> first spelling seen
+	for i, name := range names {
+		base, version, ext := splitVariantName(name)
+		parts[i] = parsed{base, version, ext}
+		key := strings.ToLower(base)
+		counts[key]++
+		if _, ok := spelling[key]; !ok {
+			spelling[key] = base
+		}
+	}
+
+	// The base shared by most members wins; ties go to the first member's spelling
+	bestKey := ""
+	for i := range parts {
+		key := strings.ToLower(parts[i].base)
+		if bestKey == "" || counts[key] > counts[bestKey] {
+			bestKey = key
+		}
+	}
+	base := spelling[bestKey]
+
+	suggestions := make([]NameSuggestion, len(names))
+	taken := make(map[string]int)
+	for i, p := range parts {
+		suggested := base
+		if p.version != "" {
+			suggested += " " + p.version
+		}
+		suggested += p.ext
+		suggestions[i] = NameSuggestion{Original: names[i], Suggested: suggested, Version: p.version}
+		taken[strings.ToLower(suggested)]++
+	}
+	for i := range suggestions {
+		if base == "" || taken[strings.ToLower(suggestions[i].Suggested)] > 1 {
+			suggestions[i].Suggested = ""
+			suggestions[i].Conflict = true
+		}
+	}
+	return suggestions
+}
+
+// splitVariantName splits a file name into its cleaned base name, normalized version token and
+// lowercased extension
+func splitVariantName(name string) (base, version, ext string) {
+	ext = strings.ToLower(filepath.Ext(name))
+	s := strings.TrimSuffix(name, filepath.Ext(name))
+
+	if m := reVersionToken.FindStringSubmatchIndex(s); m != nil {
+		version = "v" + s[m[6]:m[7]]
+		s = s[:m[0]] + s[m[2]:m[3]] + " " + s[m[8]:m[9]] + s[m[1]:]
+	}
+
+	for { // "Model - Copy (2)" carries two markers
+		stripped := strings.TrimSpace(reCopyMarkerCI.ReplaceAllString(s, ""))
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	s = strings.NewReplacer("_", " ", ".", " ", "+", " ").Replace(s)
+	for {
+		stripped := reNoiseWords.ReplaceAllString(s, " ")
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	s = strings.Trim(reSpaces.ReplaceAllString(s, " "), " -()[]")
+	return s, version, ext
+}
@@ -0,0 +1,288 @@
+package similarity
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/scanner"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scorer rates how similar two files are, from 0 (unrelated) to 100 (identical)
+type Scorer interface {
+	Score(a, b scanner.ArchiveFile) float64
+}
+
+// Blocker is implemented by scorers that rate files by what they hold rather than by their
+// names. Step 3 only scores pairs of files whose names land in the same bucket, so such a
+// scorer also names buckets of its own: files sharing one are scored against each other too.
+type Blocker interface {
+	Scorer
+	// Blocks returns the buckets of a file, none when the scorer has nothing to go on (no
+	// listing, no preview hash)
+	Blocks(f scanner.ArchiveFile) []string
+}
+
+// ScorerFactory builds a scorer for the given clustering options
+type ScorerFactory func(opts Options) Scorer
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]ScorerFactory{}
+)
+
+func init() {
+	RegisterScorer("name", func(opts Options) Scorer { return nameScorer{opts: opts} })
+	RegisterScorer("token", func(opts Options) Scorer { return tokenScorer{} })
+	RegisterScorer("manifest", func(opts Options) Scorer { return newManifestScorer() })
+	RegisterScorer("visual", func(opts Options) Scorer { return visualScorer{opts: opts} })
+}
+
+// RegisterScorer makes a scorer available by name to the clustering engine.
+// Registering an existing name replaces the previous implementation.
+func RegisterScorer(name string, factory ScorerFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// ScorerNames returns the names of all registered scorers
+func ScorerNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return scorerNamesLocked()
+}
+
+// ValidateScorers checks that every configured scorer exists and has a positive weight
+func ValidateScorers(weights map[string]float64) error {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for name, weight := range weights {
+		if _, ok := registry[name]; !ok {
+			return fmt.Errorf("unknown scorer '%s' (available: %s)", name, strings.Join(scorerNamesLocked(), ", "))
+		}
+		if weight <= 0 {
+			return fmt.Errorf("scorer '%s' must have a positive weight", name)
+		}
+	}
+	return nil
+}
+
+// scorerNamesLocked is ScorerNames for callers already holding the registry lock
+func scorerNamesLocked() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseScorerWeights parses "name=0.7,token=0.3" into a weight map. A missing weight defaults to 1.
+func ParseScorerWeights(spec string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, found := strings.Cut(part, "=")
+		weight := 1.0
+		if found {
+			if _, err := fmt.Sscanf(value, "%g", &weight); err != nil {
+				return nil, fmt.Errorf("invalid weight for scorer '%s': %s", name, value)
+			}
+		}
+		weights[strings.TrimSpace(name)] = weight
+	}
+	return weights, ValidateScorers(weights)
+}
+
+// weightedScorer combines several scorers into a weighted average
+type weightedScorer struct {
+	scorers []Scorer
+	weights []float64
+	total   float64
+}
+
+func (w weightedScorer) Score(a, b scanner.ArchiveFile) float64 {
+	var sum float64
+	for i, s := range w.scorers {
+		sum += w.weights[i] * s.Score(a, b)
+	}
+	return sum / w.total
+}
+
+// Blocks returns the buckets of the weighted scorers that are Blockers, told apart by scorer
+func (w weightedScorer) Blocks(f scanner.ArchiveFile) []string {
+	var blocks []string
+	for i, s := range w.scorers {
+		if b, ok := s.(Blocker); ok {
+			for _, block := range b.Blocks(f) {
+				blocks = append(blocks, fmt.Sprintf("%d/%s", i, block))
+			}
+		}
+	}
+	return blocks
+}
+
+// combinedScorer builds the weighted scorer configured in opts.Scorers,
+// or returns nil when the default name scoring should be used.
+func (opts Options) combinedScorer() Scorer {
+	if len(opts.Scorers) == 0 {
+		return nil
+	}
+
+	// Stable order keeps floating point sums reproducible
+	names := make([]string, 0, len(opts.Scorers))
+	for name := range opts.Scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var combined weightedScorer
+	for _, name := range names {
+		factory, ok := registry[name]
+		weight := opts.Scorers[name]
+		if !ok || weight <= 0 {
+			log.Printf("⚠️  Ignoring scorer '%s' (unknown or non-positive weight)", name)
+			continue
+		}
+		combined.scorers = append(combined.scorers, factory(opts))
+		combined.weights = append(combined.weights, weight)
+		combined.total += weight
+	}
+
+	if combined.total == 0 {
+		return nil
+	}
+	return combined
+}
+
+// nameScorer compares normalized names (Levenshtein, optionally phonetic)
+type nameScorer struct {
+	opts Options
+}
+
+func (s nameScorer) Score(a, b scanner.ArchiveFile) float64 {
+	return CalculateNormalizedSimilarity(a.Name, b.Name, s.opts)
+}
+
+// tokenScorer is the Jaccard index of the canonical name tokens
+type tokenScorer struct{}
+
+func (tokenScorer) Score(a, b scanner.ArchiveFile) float64 {
+	return jaccard(strings.Fields(generateCanonicalKey(a.Name)), strings.Fields(generateCanonicalKey(b.Name)))
+}
+
+// manifestScorer compares the listings (path + size) of the archives' contents
+type manifestScorer struct {
+	mu        sync.Mutex
+	manifests map[string][]string
+}
+
+func newManifestScorer() *manifestScorer {
+	return &manifestScorer{manifests: make(map[string][]string)}
+}
+
+func (s *manifestScorer) manifest(path string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.manifests[path]; ok {
+		return m
+	}
+
+	var entries []string
+	if files, err := archive.ListArchiveFiles(path); err == nil {
+		for _, f := range files {
+			entries = append(entries, fmt.Sprintf("%s|%d", strings.ToLower(strings.ReplaceAll(f.Path, "\\", "/")), f.Size))
+		}
+	}
+	s.manifests[path] = entries
+	return entries
+}
+
+func (s *manifestScorer) Score(a, b scanner.ArchiveFile) float64 {
+	return jaccard(s.manifest(a.Path), s.manifest(b.Path))
+}
+
+// Blocks puts archives in the MinHash/LSH buckets of their listing, so that archives sharing
+// most of their entries are compared whatever their names
+func (s *manifestScorer) Blocks(f scanner.ArchiveFile) []string {
+	sig, ok := minHash(s.manifest(f.Path))
+	if !ok {
+		return nil
+	}
+	var blocks []string
+	for b, band := range bandHashes(sig) {
+		blocks = append(blocks, fmt.Sprintf("%d:%x", b, band))
+	}
+	return blocks
+}
+
+// visualScorer compares the cached perceptual hashes of the archives' previews
+type visualScorer struct {
+	opts Options
+}
+
+func (s visualScorer) Score(a, b scanner.ArchiveFile) float64 {
+	if s.opts.Cache == nil {
+		return 0
+	}
+	h1, ok1 := s.opts.Cache.GetVisualHash(a.Path, a.ModTime.Format(time.RFC3339))
+	h2, ok2 := s.opts.Cache.GetVisualHash(b.Path, b.ModTime.Format(time.RFC3339))
+	if !ok1 || !ok2 {
+		return 0
+	}
+	return (1 - float64(archive.CalculateHammingDistance(h1, h2))/64) * 100
+}
+
+// visualBands splits preview hashes into bands of 16 bits: hashes at most 3 bits apart (a
+// score of 95 or more) always share one, and more distant ones often do
+const visualBands = 4
+
+// Blocks puts archives in the buckets of the bands of their cached preview hash
+func (s visualScorer) Blocks(f scanner.ArchiveFile) []string {
+	if s.opts.Cache == nil {
+		return nil
+	}
+	h, ok := s.opts.Cache.GetVisualHash(f.Path, f.ModTime.Format(time.RFC3339))
+	if !ok {
+		return nil
+	}
+	blocks := make([]string, visualBands)
+	for b := range blocks {
+		blocks[b] = fmt.Sprintf("%d:%04x", b, uint16(h>>(16*b)))
+	}
+	return blocks
+}
+
+// jaccard returns the 0-100 Jaccard index of two string sets
+func jaccard(a, b []string) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	setA := make(map[string]bool, len(a))
+	for _, x := range a {
+		setA[x] = true
+	}
+	setB := make(map[string]bool, len(b))
+	intersection := 0
+	for _, x := range b {
+		if setB[x] {
+			continue
+		}
+		setB[x] = true
+		if setA[x] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	return float64(intersection) / float64(union) * 100
+}
@@ -0,0 +1,77 @@
+package similarity
+
+import (
+	"archive-duplicate-finder/internal/scanner"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reSeriesVersion = regexp.MustCompile(`\b(v|ver|version|rev)\s*\d+(\.\d+)*\b`)
+	reCopyMarker    = regexp.MustCompile(`([\s_-]*\(\d+\)|[\s_-]+copy(\s*\d+)?)$`) // "Model (2)", "Model - Copy 3"
+	reDigits        = regexp.MustCompile(`\d+`)
+	reSeparators    = regexp.MustCompile(`[\s_\-.+\[\]()]+`)
+)
+
+// IsProbableSeries reports whether a cluster looks like the parts of a series ("Chapter 01",
+// "Chapter 02", "Vol 3") rather than copies of one file: once the numbers are masked every name
+// is identical, and every member carries different numbers. Version markers ("v2", "rev 3")
+// and copy markers ("(1)", "copy 2") are not part numbers, so re-downloads still count as duplicates.
+func IsProbableSeries(files []scanner.ArchiveFile) bool {
+	if len(files) < 2 {
+		return false
+	}
+
+	template := ""
+	seen := make(map[string]bool, len(files))
+	for i, f := range files {
+		t, numbers := seriesParts(f.Name)
+		if numbers == "" || !strings.ContainsFunc(t, isLetter) {
+			return false
+		}
+		if i == 0 {
+			template = t
+		} else if t != template {
+			return false
+		}
+		if seen[numbers] {
+			// Two members with the same part number are real duplicates
+			return false
+		}
+		seen[numbers] = true
+	}
+	return true
+}
+
+// seriesParts splits a file name into its normalized text with numbers masked out and the
+// numbers themselves (leading zeros dropped, so "01" and "1" are the same part)
+func seriesParts(name string) (template, numbers string) {
+	s := strings.TrimSpace(strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))))
+	for { // "Model - Copy (2)" carries two markers
+		stripped := strings.TrimSpace(reCopyMarker.ReplaceAllString(s, ""))
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	s = reSeparators.ReplaceAllString(s, " ")
+	s = reSeriesVersion.ReplaceAllString(s, " ")
+
+	var nums []string
+	template = reDigits.ReplaceAllStringFunc(s, func(d string) string {
+		n, err := strconv.ParseUint(d, 10, 64)
+		if err != nil {
+			nums = append(nums, d)
+		} else {
+			nums = append(nums, strconv.FormatUint(n, 10))
+		}
+		return "#"
+	})
+	return strings.Join(strings.Fields(template), " "), strings.Join(nums, ",")
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r > 127
+}
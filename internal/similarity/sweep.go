@@ -0,0 +1,82 @@
+package similarity
+
+import (
+	"archive-duplicate-finder/internal/scanner"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SweepResult summarizes the clusters obtained at one threshold
+type SweepResult struct {
+	Threshold    int   `json:"threshold"`
+	Groups       int   `json:"groups"`
+	Files        int   `json:"files"`
+	FlaggedBytes int64 `json:"flagged_bytes"` // Bytes in every cluster except its largest file (the likely keeper)
+}
+
+// SweepThresholds prepares candidates once and reports the clusters found at each threshold
+func SweepThresholds(files []scanner.ArchiveFile, opts Options, thresholds []int, onProgress func(float64)) []SweepResult {
+	results := make([]SweepResult, 0, len(thresholds))
+	if len(files) < 2 {
+		for _, t := range thresholds {
+			results = append(results, SweepResult{Threshold: t})
+		}
+		return results
+	}
+
+	in := prepareClusters(files, opts, nil, onProgress)
+	for i, t := range thresholds {
+		res := SweepResult{Threshold: t}
+		for _, g := range in.build(t, nil) {
+			res.Groups++
+			res.Files += len(g.Files)
+
+			var total, largest int64
+			for _, f := range g.Files {
+				total += f.Size
+				if f.Size > largest {
+					largest = f.Size
+				}
+			}
+			res.FlaggedBytes += total - largest
+		}
+		results = append(results, res)
+
+		if onProgress != nil {
+			onProgress(90 + float64(i+1)/float64(len(thresholds))*10)
+		}
+	}
+	return results
+}
+
+// ParseSweep parses a "start:end:step" specification such as "60:90:5"
+func ParseSweep(spec string) ([]int, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("expected start:end[:step], got '%s'", spec)
+	}
+
+	values := []int{0, 0, 5}
+	for i, p := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' in sweep", p)
+		}
+		values[i] = v
+	}
+
+	start, end, step := values[0], values[1], values[2]
+	if start < 0 || end > 100 || start > end {
+		return nil, fmt.Errorf("sweep range must be within 0-100 with start <= end")
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("sweep step must be positive")
+	}
+
+	var thresholds []int
+	for t := start; t <= end; t += step {
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, nil
+}
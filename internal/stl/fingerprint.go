@@ -0,0 +1,121 @@
+package stl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// geometryPrecision is the grid vertices are snapped to before fingerprinting, so the float
+// rounding of an ASCII export does not tell it apart from the binary original
+const geometryPrecision = 1e-4
+
+// IsOBJFile checks if a filename is a Wavefront OBJ model
+func IsOBJFile(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ".obj")
+}
+
+// Fingerprint hashes the geometry of a model: its triangles, whatever the file encoding (binary
+// or ASCII STL, OBJ), the order they are stored in and the vertex a triangle starts from. Two
+// files with the same fingerprint describe the same mesh. name picks the format by extension.
+func Fingerprint(name string, data []byte) (string, error) {
+	// Triangles, then a text key for each of them and the joined keys
+	release := reserve(4, data)
+	defer release()
+	var triangles [][9]float32
+	var err error
+	switch {
+	case IsOBJFile(name):
+		triangles, err = readOBJTriangles(data)
+	case isBinarySTL(data):
+		triangles, err = readBinaryTriangles(data)
+	default:
+		triangles, err = readASCIITriangles(data)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	keys := make([]string, len(triangles))
+	for i, t := range triangles {
+		var v [3]string
+		for j := range v {
+			v[j] = fmt.Sprintf("%d,%d,%d", snap(t[j*3]), snap(t[j*3+1]), snap(t[j*3+2]))
+		}
+		// Start from the smallest vertex, keeping the winding (and so the facing) of the triangle
+		first := 0
+		for j := 1; j < 3; j++ {
+			if v[j] < v[first] {
+				first = j
+			}
+		}
+		keys[i] = v[first] + ";" + v[(first+1)%3] + ";" + v[(first+2)%3]
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(keys, "\n")))), nil
+}
+
+func snap(f float32) int64 {
+	return int64(math.Round(float64(f) / geometryPrecision))
+}
+
+// readOBJTriangles reads the faces of an OBJ file as triangles. Polygons are fanned into
+// triangles; texture coordinates, normals, groups and materials are ignored.
+func readOBJTriangles(data []byte) ([][9]float32, error) {
+	var vertices [][3]float32
+	var triangles [][9]float32
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				continue
+			}
+			var v [3]float32
+			for i := range v {
+				f, err := strconv.ParseFloat(fields[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("invalid OBJ vertex: %s", line)
+				}
+				v[i] = float32(f)
+			}
+			vertices = append(vertices, v)
+		case "f":
+			var face [][3]float32
+			for _, ref := range fields[1:] {
+				index, _, _ := strings.Cut(ref, "/")
+				n, err := strconv.Atoi(index)
+				if err != nil {
+					return nil, fmt.Errorf("invalid OBJ face: %s", line)
+				}
+				if n < 0 {
+					n += len(vertices) + 1 // Negative indices count back from the latest vertex
+				}
+				if n < 1 || n > len(vertices) {
+					return nil, fmt.Errorf("OBJ face refers to missing vertex %d", n)
+				}
+				face = append(face, vertices[n-1])
+			}
+			for i := 1; i+1 < len(face); i++ {
+				var t [9]float32
+				copy(t[0:3], face[0][:])
+				copy(t[3:6], face[i][:])
+				copy(t[6:9], face[i+1][:])
+				triangles = append(triangles, t)
+			}
+		}
+	}
+
+	if len(triangles) == 0 {
+		return nil, fmt.Errorf("no faces found in OBJ")
+	}
+	return triangles, nil
+}
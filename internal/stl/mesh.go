@@ -0,0 +1,113 @@
+package stl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// DefaultMaxTriangles is the triangle budget used when decimating meshes for display
+const DefaultMaxTriangles = 20000
+
+// Mesh is a flat triangle list ready to be loaded into a WebGL buffer
+type Mesh struct {
+	Positions         []float32 `json:"positions"` // x, y, z for each vertex, 3 vertices per triangle
+	TriangleCount     int       `json:"triangle_count"`
+	OriginalTriangles int       `json:"original_triangles"`
+	Decimated         bool      `json:"decimated"`
+}
+
+// ParseInfo returns triangle/vertex counts and bounds of an STL file
+func ParseInfo(data []byte) (*STLInfo, error) {
+	release := reserve(1, data)
+	defer release()
+	return parseSTL(data)
+}
+
+// ParseMesh reads the triangles of an STL file, keeping at most maxTriangles of them.
+// Decimation uses uniform sampling, which keeps the overall silhouette for display purposes.
+func ParseMesh(data []byte, maxTriangles int) (*Mesh, error) {
+	if maxTriangles <= 0 {
+		maxTriangles = DefaultMaxTriangles
+	}
+	release := reserve(1, data)
+	defer release()
+
+	var triangles [][9]float32
+	var err error
+	if isBinarySTL(data) {
+		triangles, err = readBinaryTriangles(data)
+	} else {
+		triangles, err = readASCIITriangles(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mesh := &Mesh{OriginalTriangles: len(triangles)}
+	stride := 1
+	if len(triangles) > maxTriangles {
+		stride = int(math.Ceil(float64(len(triangles)) / float64(maxTriangles)))
+		mesh.Decimated = true
+	}
+
+	mesh.Positions = make([]float32, 0, (len(triangles)/stride+1)*9)
+	for i := 0; i < len(triangles); i += stride {
+		mesh.Positions = append(mesh.Positions, triangles[i][:]...)
+		mesh.TriangleCount++
+	}
+	return mesh, nil
+}
+
+func readBinaryTriangles(data []byte) ([][9]float32, error) {
+	if len(data) < 84 {
+		return nil, fmt.Errorf("file too small for binary STL")
+	}
+	count := int(binary.LittleEndian.Uint32(data[80:84]))
+	if len(data) < 84+count*50 {
+		return nil, fmt.Errorf("invalid binary STL: expected %d bytes, got %d", 84+count*50, len(data))
+	}
+
+	triangles := make([][9]float32, count)
+	offset := 84
+	for i := 0; i < count; i++ {
+		// Skip normal vector (12 bytes)
+		offset += 12
+		for v := 0; v < 9; v++ {
+			triangles[i][v] = math.Float32frombits(binary.LittleEndian.Uint32(data[offset : offset+4]))
+			offset += 4
+		}
+		// Skip attribute byte count (2 bytes)
+		offset += 2
+	}
+	return triangles, nil
+}
+
+func readASCIITriangles(data []byte) ([][9]float32, error) {
+	var triangles [][9]float32
+	var current [9]float32
+	vertex := 0
+
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if !bytes.HasPrefix(trimmed, []byte("vertex")) {
+			continue
+		}
+		var x, y, z float32
+		if _, err := fmt.Sscanf(string(trimmed), "vertex %f %f %f", &x, &y, &z); err != nil {
+			continue
+		}
+		current[vertex*3], current[vertex*3+1], current[vertex*3+2] = x, y, z
+		vertex++
+		if vertex == 3 {
+			triangles = append(triangles, current)
+			vertex = 0
+		}
+	}
+
+	if len(triangles) == 0 {
+		return nil, fmt.Errorf("no triangles found in ASCII STL")
+	}
+	return triangles, nil
+}
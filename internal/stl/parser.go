@@ -1,6 +1,7 @@
 package stl
 
 import (
+	"archive-duplicate-finder/internal/membudget"
 	"bytes"
 	"encoding/binary"
 	"fmt"
@@ -10,11 +11,11 @@ import (
 
 // STLDiff represents differences between two STL files
 type STLDiff struct {
-	Vertices1   int
-	Vertices2   int
-	Triangles1  int
-	Triangles2  int
-	Description string
+	Vertices1   int    `json:"vertices_1"`
+	Vertices2   int    `json:"vertices_2"`
+	Triangles1  int    `json:"triangles_1"`
+	Triangles2  int    `json:"triangles_2"`
+	Description string `json:"description"`
 }
 
 // IsSTLFile checks if a filename is an STL file
@@ -31,6 +32,8 @@ func CompareSTL(data1, data2 []byte) (identical bool, diff *STLDiff) {
 	}
 
 	// Parse both STL files
+	release := reserve(1, data1, data2)
+	defer release()
 	info1, err1 := parseSTL(data1)
 	info2, err2 := parseSTL(data2)
 
@@ -74,17 +77,31 @@ func CompareSTL(data1, data2 []byte) (identical bool, diff *STLDiff) {
 
 // STLInfo contains information about an STL file
 type STLInfo struct {
-	TriangleCount int
-	VertexCount   int
-	Bounds        Bounds
-	IsBinary      bool
+	TriangleCount int    `json:"triangle_count"`
+	VertexCount   int    `json:"vertex_count"`
+	Bounds        Bounds `json:"bounds"`
+	IsBinary      bool   `json:"is_binary"`
 }
 
 // Bounds represents the bounding box of an STL model
 type Bounds struct {
-	MinX, MaxX float32
-	MinY, MaxY float32
-	MinZ, MaxZ float32
+	MinX float32 `json:"min_x"`
+	MaxX float32 `json:"max_x"`
+	MinY float32 `json:"min_y"`
+	MaxY float32 `json:"max_y"`
+	MinZ float32 `json:"min_z"`
+	MaxZ float32 `json:"max_z"`
+}
+
+// reserve accounts for parsing models against the memory budget: the triangle list of a binary
+// file or the line index of a text one take about the size of the file, times factor for what
+// is built from them
+func reserve(factor int64, files ...[]byte) func() {
+	var n int64
+	for _, data := range files {
+		n += int64(len(data)) * factor
+	}
+	return membudget.Acquire(n)
 }
 
 // parseSTL parses an STL file and extracts information
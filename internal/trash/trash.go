@@ -0,0 +1,101 @@
+package trash
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// dayLayout names the per-day folders files are trashed into
+const dayLayout = "2006-01-02"
+
+// Move moves path into today's folder of the trash (trash/2026-01-31/name.zip) and returns its
+// new location. A name already taken there gets the time of the move appended
+// (name.153012.zip), so nothing in the trash is ever overwritten. A trash on another filesystem
+// is filled by copying, reported through onProgress (optional, see fsutil.MoveFile).
+func Move(trash, path string, onProgress func(done, total int64)) (string, error) {
+	now := time.Now()
+	dir := filepath.Join(trash, now.Format(dayLayout))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	dest := freeName(filepath.Join(dir, filepath.Base(path)), now)
+	if err := fsutil.MoveFile(path, dest, onProgress); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// freeName returns path, or a name with the time (and a counter if needed) appended when path exists
+func freeName(path string, now time.Time) string {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext) + "." + now.Format("150405")
+	candidate := base + ext
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+}
+
+// PurgeResult summarizes an emptied trash
+type PurgeResult struct {
+	Folders int   // Day folders removed
+	Files   int   // Files inside them
+	Bytes   int64 // Space freed
+}
+
+// Purge empties the day folders of the trash older than days, keeping the latest ones. Files
+// outside day folders (trashed before they existed, or put there by hand) are left alone.
+// A days of 0 or less keeps everything.
+func Purge(trash string, days int) (PurgeResult, error) {
+	var res PurgeResult
+	if days <= 0 {
+		return res, nil
+	}
+	entries, err := os.ReadDir(trash)
+	if os.IsNotExist(err) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+
+	now := time.Now()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, -days)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		day, err := time.ParseInLocation(dayLayout, e.Name(), time.Local)
+		if err != nil || !day.Before(cutoff) {
+			continue
+		}
+		dir := filepath.Join(trash, e.Name())
+		files, bytes := 0, int64(0)
+		filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				if info, err := d.Info(); err == nil {
+					files++
+					bytes += info.Size()
+				}
+			}
+			return nil
+		})
+		if err := os.RemoveAll(dir); err != nil {
+			return res, err
+		}
+		res.Folders++
+		res.Files += files
+		res.Bytes += bytes
+	}
+	return res, nil
+}
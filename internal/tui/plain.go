@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// asciiRunes replaces the arrows, bullets and box drawing that have an ASCII equivalent
+var asciiRunes = map[rune]string{
+	'━': "-", '─': "-", '═': "-", '│': "|", '┃': "|",
+	'•': "-", '—': "-", '–': "-", '…': "...",
+	'→': "->", '←': "<-", '↔': "<->", '↳': "->", '↑': "^", '↓': "v", '➡': "->",
+}
+
+// IsTerminal reports whether f is an interactive terminal rather than a file or a pipe
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// PlainWriter turns terminal output into clean lines for log files and legacy code pages:
+// emoji are dropped with the spaces after them, arrows and box drawing become ASCII, and
+// lines redrawn with a carriage return (progress bars) are left out.
+type PlainWriter struct {
+	w       io.Writer
+	partial []byte // Incomplete UTF-8 sequence at the end of the previous write
+	redraw  bool   // Inside a carriage-return line, dropped up to its newline
+	trim    bool   // An emoji was just dropped: the spaces after it go too
+}
+
+// NewPlainWriter filters everything written to w
+func NewPlainWriter(w io.Writer) *PlainWriter {
+	return &PlainWriter{w: w}
+}
+
+func (p *PlainWriter) Write(b []byte) (int, error) {
+	data := append(p.partial, b...)
+	p.partial = nil
+	out := make([]byte, 0, len(data))
+	for len(data) > 0 {
+		if !utf8.FullRune(data) {
+			p.partial = append([]byte(nil), data...)
+			break
+		}
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+
+		switch {
+		case r == '\r':
+			p.redraw = true
+		case r == '\n':
+			if p.redraw {
+				p.redraw = false // The redrawn line goes with its newline
+				continue
+			}
+			p.trim = false
+			out = append(out, '\n')
+		case p.redraw:
+		case r == ' ' && p.trim:
+		case r == 0xFE0F || r == 0x200D: // Emoji presentation and joiners
+		case asciiRunes[r] != "":
+			p.trim = false
+			out = append(out, asciiRunes[r]...)
+		case isEmoji(r):
+			p.trim = true
+		default:
+			p.trim = false
+			out = utf8.AppendRune(out, r)
+		}
+	}
+	if _, err := p.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// isEmoji covers the pictographs, symbols and dingbats the CLI decorates its messages with,
+// and the box drawing without an ASCII replacement
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // Pictographs, emoticons, transport, flags
+		r >= 0x2600 && r <= 0x27BF, // Miscellaneous symbols and dingbats (✅ ❌ ⚠ ✏)
+		r >= 0x2300 && r <= 0x23FF, // Miscellaneous technical (⏩ ⏭ ⏹ ⏳)
+		r >= 0x2B00 && r <= 0x2BFF, // Arrows and stars (⬆ ⭐)
+		r >= 0x2190 && r <= 0x21FF, // Arrows without an ASCII replacement
+		r >= 0x2500 && r <= 0x257F, // Box drawing without an ASCII replacement
+		r == 0x2139:                // ℹ
+		return true
+	}
+	return false
+}
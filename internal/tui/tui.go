@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// Special keys returned by ReadKey; other keys are returned as the typed character
+const (
+	KeyUp       = "up"
+	KeyDown     = "down"
+	KeyLeft     = "left"
+	KeyRight    = "right"
+	KeyPageUp   = "pgup"
+	KeyPageDown = "pgdown"
+	KeyEnter    = "enter"
+	KeyTab      = "tab"
+	KeyEscape   = "esc"
+	KeyCtrlC    = "ctrl+c"
+)
+
+// ErrNotTerminal is returned by Open when stdin or stdout is redirected
+var ErrNotTerminal = errors.New("an interactive terminal is required")
+
+// Terminal is a full-screen terminal in raw mode: keys arrive one at a time, unechoed, and
+// every Draw repaints the whole screen
+type Terminal struct {
+	in    *os.File
+	out   *bufio.Writer
+	state *term.State
+}
+
+// Open switches the terminal to raw mode and the alternate screen. Close restores it.
+func Open() (*Terminal, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return nil, ErrNotTerminal
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	t := &Terminal{in: os.Stdin, out: bufio.NewWriter(os.Stdout), state: state}
+	t.out.WriteString("\x1b[?1049h\x1b[?25l\x1b[?7l") // Alternate screen, hidden cursor, no line wrapping
+	t.out.Flush()
+	return t, nil
+}
+
+// Close leaves the alternate screen and restores the terminal mode
+func (t *Terminal) Close() {
+	t.out.WriteString("\x1b[?7h\x1b[?25h\x1b[?1049l")
+	t.out.Flush()
+	term.Restore(int(t.in.Fd()), t.state)
+}
+
+// Size returns the width and height of the terminal
+func (t *Terminal) Size() (int, int) {
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+	return w, h
+}
+
+// ReadKey waits for the next key press
+func (t *Terminal) ReadKey() (string, error) {
+	buf := make([]byte, 16)
+	n, err := t.in.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return parseKey(buf[:n]), nil
+}
+
+func parseKey(b []byte) string {
+	switch s := string(b); s {
+	case "\x1b[A", "\x1bOA":
+		return KeyUp
+	case "\x1b[B", "\x1bOB":
+		return KeyDown
+	case "\x1b[C", "\x1bOC":
+		return KeyRight
+	case "\x1b[D", "\x1bOD":
+		return KeyLeft
+	case "\x1b[5~":
+		return KeyPageUp
+	case "\x1b[6~":
+		return KeyPageDown
+	case "\r", "\n":
+		return KeyEnter
+	case "\t":
+		return KeyTab
+	case "\x1b":
+		return KeyEscape
+	case "\x03":
+		return KeyCtrlC
+	default:
+		if strings.HasPrefix(s, "\x1b") {
+			return "" // Unsupported sequence
+		}
+		return s
+	}
+}
+
+// Draw repaints the screen with lines. Lines longer than the terminal are clipped by it (wrapping
+// is off), so styled lines need no measuring.
+func (t *Terminal) Draw(lines []string) {
+	_, h := t.Size()
+	t.out.WriteString("\x1b[H")
+	for i := 0; i < h; i++ {
+		if i < len(lines) {
+			t.out.WriteString(lines[i])
+		}
+		t.out.WriteString("\x1b[K")
+		if i < h-1 {
+			t.out.WriteString("\r\n")
+		}
+	}
+	t.out.Flush()
+}
+
+// Fit cuts s to width display columns
+func Fit(s string, width int) string {
+	return runewidth.Truncate(s, width, "…")
+}
+
+// Pad cuts or pads s to exactly width display columns
+func Pad(s string, width int) string {
+	return runewidth.FillRight(Fit(s, width), width)
+}
+
+// Columns lays cells out side by side, each width columns wide, separated by " │ ". The cell
+// at selected (-1 for none) is shown in reverse video.
+func Columns(cells []string, width, selected int) string {
+	parts := make([]string, len(cells))
+	for i, c := range cells {
+		parts[i] = Pad(c, width)
+		if i == selected {
+			parts[i] = Reverse(parts[i])
+		}
+	}
+	return strings.Join(parts, " │ ")
+}
+
+// Reverse renders s in reverse video, for the selected item
+func Reverse(s string) string {
+	return fmt.Sprintf("\x1b[7m%s\x1b[0m", s)
+}
+
+// Bold renders s in bold
+func Bold(s string) string {
+	return fmt.Sprintf("\x1b[1m%s\x1b[0m", s)
+}
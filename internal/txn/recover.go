@@ -0,0 +1,88 @@
+package txn
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recover puts back the files of the staging folders under root that a transaction left when
+// the process ended before it did, e.g. killed or out of power. Whether their group finished
+// is unknown, so they return to the folder they were removed from, where the next scan finds
+// them again, rather than being deleted. Folders of a transaction still running, or younger
+// than maxAge (one of another process may be), are left alone. It returns the files put back,
+// and the errors of those that could not be, which stay in their staging folder.
+func Recover(root string, recursive bool, maxAge time.Duration) (restored []string, stuck []error) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if !IsStaging(d.Name()) {
+			if !recursive && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if abandoned(d.Name(), maxAge) {
+			r, s := restore(path)
+			restored, stuck = append(restored, r...), append(stuck, s...)
+		}
+		return filepath.SkipDir
+	})
+	return restored, stuck
+}
+
+// abandoned reports whether the staging folder name belongs to no running transaction and is
+// older than maxAge. Its ID is the time the transaction started.
+func abandoned(name string, maxAge time.Duration) bool {
+	id := strings.TrimPrefix(name, stagePrefix)
+	if _, ok := running.Load(id); ok {
+		return false
+	}
+	started, err := strconv.ParseInt(id, 36, 64)
+	return err != nil || time.Since(time.Unix(0, started)) >= maxAge
+}
+
+// restore moves the files of the staging folder dir back into its parent, under their name
+func restore(dir string) (restored []string, stuck []error) {
+	parent := filepath.Dir(dir)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			stuck = append(stuck, err)
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		original := filepath.Join(parent, d.Name())
+		if _, err := os.Lstat(original); err == nil {
+			stuck = append(stuck, fmt.Errorf("could not put %s back: %s already exists", path, original))
+			return nil
+		}
+		if err := os.Rename(path, original); err != nil {
+			stuck = append(stuck, fmt.Errorf("could not put %s back: %w", path, err))
+			return nil
+		}
+		restored = append(restored, original)
+		return nil
+	})
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.IsDir() {
+			os.Remove(filepath.Join(dir, e.Name())) // Numbered subfolders, once empty
+		}
+	}
+	os.Remove(dir)
+	return restored, stuck
+}
+
+// removeEmpty removes the folder dir and, while they are empty, its parents up to top
+func removeEmpty(dir, top string) {
+	for os.Remove(dir) == nil && dir != top {
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,218 @@
+// Package txn runs the file operations resolving one duplicate group as a unit, so a failure
+// halfway never leaves a group half cleaned: every precondition is checked before the first
+// step, and the steps already done are undone when a later one fails
+package txn
+
+import (
+	"archive-duplicate-finder/internal/fsutil"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stagePrefix names the hidden folders deleted files wait in until their transaction ends
+const stagePrefix = ".adf-staged-"
+
+// running holds the IDs of the transactions of this process that have not ended, whose staging
+// folders Recover must leave alone
+var running sync.Map
+
+// IsStaging reports whether name is that of a folder deleted files wait in. Scans skip them:
+// their files are copies already removed from the library.
+func IsStaging(name string) bool {
+	return strings.HasPrefix(name, stagePrefix)
+}
+
+// ErrChanged reports a file whose contents differ from those it was compared with
+var ErrChanged = errors.New("changed since it was compared")
+
+// Tx is a list of steps run together by Run
+type Tx struct {
+	id     string
+	hash   func(path string) (string, error)
+	checks []check
+	steps  []step
+	staged []string // Files moved aside by Stage, deleted once every step succeeded
+}
+
+// check is a precondition verified before any step runs
+type check struct {
+	path   string // Must exist
+	sha256 string // Content path must still have; "" skips the comparison
+	dest   string // Must not exist
+}
+
+type step struct {
+	do   func() error
+	undo func() error // nil when there is nothing to reverse
+}
+
+// New starts a transaction. hash computes the SHA-256 the files are checked against, typically
+// hashing.FileHash with the cache, which reads a file again once its size or time changed.
+func New(hash func(path string) (string, error)) *Tx {
+	return &Tx{id: strconv.FormatInt(time.Now().UnixNano(), 36), hash: hash}
+}
+
+// Require makes Run check, before the first step, that path still exists and, unless sha256
+// is empty, still has that content
+func (t *Tx) Require(path, sha256 string) {
+	t.checks = append(t.checks, check{path: path, sha256: sha256})
+}
+
+// Step appends an operation. undo, which may be nil, reverses it when a later step fails.
+func (t *Tx) Step(do, undo func() error) {
+	t.steps = append(t.steps, step{do: do, undo: undo})
+}
+
+// Move stages moving src to dst: renamed, or copied, verified and removed across filesystems.
+// dst must still be free when Run starts; its folder is created if needed.
+func (t *Tx) Move(src, dst, sha256 string) {
+	t.checks = append(t.checks, check{path: src, sha256: sha256, dest: dst})
+	t.Step(func() error {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := fsutil.MoveFile(src, dst, nil); err != nil {
+			return fmt.Errorf("could not move %s: %w", src, err)
+		}
+		return nil
+	}, func() error {
+		return fsutil.MoveFile(dst, src, nil)
+	})
+}
+
+// Delete stages the removal of path. Run only moves it aside, next to where it is; it is
+// deleted for good once every step succeeded.
+func (t *Tx) Delete(path, sha256 string) {
+	t.Require(path, sha256)
+	var undo func() error
+	t.Step(func() (err error) {
+		undo, err = t.Stage(path)
+		return err
+	}, func() error {
+		return undo()
+	})
+}
+
+// Stage moves path aside into a hidden folder of its own directory, a rename that cannot run
+// out of space, and returns how to put it back. The file is deleted when the transaction
+// commits. Steps use it to remove a file they may have to restore, e.g. a copy that could not
+// be trashed.
+func (t *Tx) Stage(path string) (func() error, error) {
+	dir := filepath.Join(filepath.Dir(path), stagePrefix+t.id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not remove %s: %w", path, err)
+	}
+	// A file keeps its name, so Recover knows where it goes back; another of the same name
+	// waits in a numbered subfolder
+	holder := dir
+	for i := 2; ; i++ {
+		if _, err := os.Lstat(filepath.Join(holder, filepath.Base(path))); os.IsNotExist(err) {
+			break
+		}
+		holder = filepath.Join(dir, strconv.Itoa(i))
+	}
+	if err := os.MkdirAll(holder, 0700); err != nil {
+		return nil, fmt.Errorf("could not remove %s: %w", path, err)
+	}
+	aside := filepath.Join(holder, filepath.Base(path))
+	if err := os.Rename(path, aside); err != nil {
+		removeEmpty(holder, dir)
+		return nil, fmt.Errorf("could not remove %s: %w", path, err)
+	}
+	t.staged = append(t.staged, aside)
+	return func() error {
+		if err := os.Rename(aside, path); err != nil {
+			return err
+		}
+		removeEmpty(holder, dir)
+		return nil
+	}, nil
+}
+
+// Run checks every precondition, touching nothing when one fails, then runs the steps in
+// order. When a step fails, those done are undone in reverse order and its error is returned,
+// along with the undos that failed too, which name what is left to put back by hand.
+func (t *Tx) Run() error {
+	running.Store(t.id, true)
+	defer running.Delete(t.id)
+	if err := t.verify(); err != nil {
+		return err
+	}
+	for i, s := range t.steps {
+		if err := s.do(); err != nil {
+			return t.rollback(i, err)
+		}
+	}
+	t.commit()
+	return nil
+}
+
+// verify checks the preconditions, and that moves to another disk fit
+func (t *Tx) verify() error {
+	var transfers []fsutil.Transfer
+	for _, c := range t.checks {
+		info, err := os.Stat(c.path)
+		if err != nil {
+			return fmt.Errorf("%s is gone: %w", c.path, err)
+		}
+		if c.sha256 != "" {
+			hash, err := t.hash(c.path)
+			if err != nil {
+				return fmt.Errorf("could not read %s: %w", c.path, err)
+			}
+			if hash != c.sha256 {
+				return fmt.Errorf("%s %w", c.path, ErrChanged)
+			}
+		}
+		if c.dest != "" {
+			if _, err := os.Lstat(c.dest); err == nil {
+				return fmt.Errorf("destination already exists: %s", c.dest)
+			}
+			transfers = append(transfers, fsutil.Transfer{Path: c.path, Dir: filepath.Dir(c.dest), Size: info.Size()})
+		}
+	}
+	if short := fsutil.Preflight(transfers); len(short) > 0 {
+		return short[0]
+	}
+	return nil
+}
+
+// rollback undoes the steps before the one at failed, latest first
+func (t *Tx) rollback(failed int, err error) error {
+	var stuck []error
+	for i := failed - 1; i >= 0; i-- {
+		if undo := t.steps[i].undo; undo != nil {
+			if uerr := undo(); uerr != nil {
+				stuck = append(stuck, uerr)
+			}
+		}
+	}
+	if len(stuck) > 0 {
+		return fmt.Errorf("%w; could not undo: %w", err, errors.Join(stuck...))
+	}
+	return fmt.Errorf("%w (everything was put back)", err)
+}
+
+// commit deletes the staged files. They already left their place, so a file that cannot be
+// deleted only keeps its space, and is reported.
+func (t *Tx) commit() {
+	for _, p := range t.staged {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️  Could not delete %s: %v", p, err)
+		}
+		holder := filepath.Dir(p)
+		dir := holder
+		if !IsStaging(filepath.Base(dir)) {
+			dir = filepath.Dir(dir) // A numbered subfolder
+		}
+		removeEmpty(holder, dir)
+	}
+	t.staged = nil
+}
@@ -0,0 +1,60 @@
+package vfs
+
+import (
+	"os"
+	"path/filepath"
+
+	"archive-duplicate-finder/internal/txn"
+)
+
+// localFS is the local disk (and mounted network shares)
+type localFS struct{}
+
+func (localFS) Walk(root string, recursive bool, fn func(Entry) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// If not recursive and not the root directory, skip. Files removed by a group
+			// that has not ended wait in a staging folder: they are not part of the library.
+			if (!recursive || txn.IsStaging(info.Name())) && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(localEntry(path, info))
+	})
+}
+
+func (localFS) Stat(path string) (Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	return localEntry(path, info), nil
+}
+
+func (localFS) Open(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return localFile{File: f, size: info.Size()}, nil
+}
+
+func localEntry(path string, info os.FileInfo) Entry {
+	return Entry{Path: path, Name: info.Name(), Size: info.Size(), ModTime: info.ModTime()}
+}
+
+type localFile struct {
+	*os.File
+	size int64
+}
+
+func (f localFile) Size() int64 { return f.size }
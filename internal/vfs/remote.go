@@ -0,0 +1,112 @@
+package vfs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const (
+	remoteBlockSize = 256 << 10 // Random reads are served in blocks so a ZIP directory costs few requests
+	remoteMaxBlocks = 16
+)
+
+// rangeFile is a remote object read over HTTP: sequential reads stream a single GET, ReadAt
+// fetches cached blocks with ranged GETs. Used by the S3 and WebDAV backends.
+type rangeFile struct {
+	name string
+	size int64
+	get  func(header http.Header) (io.ReadCloser, error) // Sends a GET with the given Range header
+
+	body io.ReadCloser // Open GET for sequential reads
+	pos  int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	order  []int64 // Block eviction order
+}
+
+func newRangeFile(name string, size int64, get func(header http.Header) (io.ReadCloser, error)) *rangeFile {
+	return &rangeFile{name: name, size: size, get: get, blocks: make(map[int64][]byte)}
+}
+
+func (f *rangeFile) Size() int64 { return f.size }
+
+func (f *rangeFile) Read(p []byte) (int, error) {
+	if f.pos >= f.size {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		body, err := f.get(rangeHeader(f.pos, f.size-1))
+		if err != nil {
+			return 0, err
+		}
+		f.body = body
+	}
+	n, err := f.body.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *rangeFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	read := 0
+	for read < len(p) {
+		pos := off + int64(read)
+		if pos >= f.size {
+			return read, io.EOF
+		}
+		block, err := f.block(pos / remoteBlockSize)
+		if err != nil {
+			return read, err
+		}
+		read += copy(p[read:], block[pos%remoteBlockSize:])
+	}
+	return read, nil
+}
+
+func (f *rangeFile) block(index int64) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if b, ok := f.blocks[index]; ok {
+		return b, nil
+	}
+
+	start := index * remoteBlockSize
+	end := min(start+remoteBlockSize, f.size) - 1
+	body, err := f.get(rangeHeader(start, end))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	b, err := io.ReadAll(io.LimitReader(body, end-start+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) != end-start+1 {
+		return nil, fmt.Errorf("short ranged read of %s", f.name)
+	}
+
+	if len(f.order) >= remoteMaxBlocks {
+		delete(f.blocks, f.order[0])
+		f.order = f.order[1:]
+	}
+	f.blocks[index] = b
+	f.order = append(f.order, index)
+	return b, nil
+}
+
+func (f *rangeFile) Close() error {
+	if f.body != nil {
+		return f.body.Close()
+	}
+	return nil
+}
+
+func rangeHeader(start, end int64) http.Header {
+	return http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", start, end)}}
+}
@@ -0,0 +1,223 @@
+package vfs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Config holds the connection settings of S3 and S3-compatible stores (MinIO, Ceph, R2...).
+// Empty fields fall back to the standard AWS environment variables.
+type S3Config struct {
+	Endpoint     string `json:"endpoint,omitempty"`   // e.g. "http://localhost:9000"; empty means AWS
+	Region       string `json:"region,omitempty"`     // Default "us-east-1"
+	AccessKey    string `json:"access_key,omitempty"` // Empty (here and in the environment) for anonymous access
+	SecretKey    string `json:"secret_key,omitempty"`
+	SessionToken string `json:"session_token,omitempty"`
+	PathStyle    bool   `json:"path_style,omitempty"` // Address buckets as endpoint/bucket (implied by a custom endpoint)
+}
+
+var (
+	s3Mu     sync.RWMutex
+	s3Config S3Config
+	s3Client = &http.Client{Timeout: 5 * time.Minute}
+)
+
+// SetS3Config replaces the S3 settings used for s3:// paths
+func SetS3Config(c S3Config) {
+	s3Mu.Lock()
+	defer s3Mu.Unlock()
+	s3Config = c
+}
+
+// resolvedS3Config merges the configured settings with the environment
+func resolvedS3Config() S3Config {
+	s3Mu.RLock()
+	c := s3Config
+	s3Mu.RUnlock()
+
+	fallback := func(v *string, envs ...string) {
+		for _, e := range envs {
+			if *v != "" {
+				return
+			}
+			*v = os.Getenv(e)
+		}
+	}
+	fallback(&c.Endpoint, "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	fallback(&c.Region, "AWS_REGION", "AWS_DEFAULT_REGION")
+	fallback(&c.AccessKey, "AWS_ACCESS_KEY_ID")
+	fallback(&c.SecretKey, "AWS_SECRET_ACCESS_KEY")
+	fallback(&c.SessionToken, "AWS_SESSION_TOKEN")
+	if c.Region == "" {
+		c.Region = "us-east-1"
+	}
+	if c.Endpoint != "" {
+		c.PathStyle = true
+	}
+	return c
+}
+
+// splitS3Path splits "s3://bucket/some/key" into bucket and key
+func splitS3Path(p string) (bucket, key string, err error) {
+	rest := strings.TrimPrefix(p, s3Scheme)
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid S3 path (expected s3://bucket/prefix): %s", p)
+	}
+	return bucket, key, nil
+}
+
+// s3Request sends a signed GET or HEAD request for a bucket (key "") or an object
+func s3Request(method, bucket, key string, query url.Values, header http.Header) (*http.Response, error) {
+	c := resolvedS3Config()
+
+	u := &url.URL{Scheme: "https", Host: "s3." + c.Region + ".amazonaws.com"}
+	if c.Endpoint != "" {
+		parsed, err := url.Parse(c.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+		}
+		u.Scheme, u.Host = parsed.Scheme, parsed.Host
+	}
+	if c.PathStyle {
+		u.Path = "/" + bucket + "/" + key
+	} else {
+		u.Host = bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = canonicalURI(u) // Send the path exactly as it is signed
+	u.RawQuery = strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if c.AccessKey != "" {
+		signV4(req, c.AccessKey, c.SecretKey, c.SessionToken, c.Region, time.Now())
+	}
+
+	resp, err := s3Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("s3://%s/%s: %w", bucket, key, os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("s3 %s s3://%s/%s: %s %s", method, bucket, key, resp.Status, s3ErrorMessage(body))
+	}
+	return resp, nil
+}
+
+func s3ErrorMessage(body []byte) string {
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &e) != nil || e.Code == "" {
+		return ""
+	}
+	return "(" + e.Code + ": " + e.Message + ")"
+}
+
+// s3FS lists buckets with ListObjectsV2 and reads objects with (ranged) GET requests
+type s3FS struct{}
+
+func (s3FS) Walk(root string, recursive bool, fn func(Entry) error) error {
+	bucket, prefix, err := splitS3Path(root)
+	if err != nil {
+		return err
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if !recursive {
+		query.Set("delimiter", "/")
+	}
+
+	for {
+		resp, err := s3Request(http.MethodGet, bucket, "", query, nil)
+		if err != nil {
+			return err
+		}
+		var page struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to parse S3 listing: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			if strings.HasSuffix(obj.Key, "/") {
+				continue // Folder placeholder
+			}
+			err := fn(Entry{
+				Path:    s3Scheme + bucket + "/" + obj.Key,
+				Name:    path.Base(obj.Key),
+				Size:    obj.Size,
+				ModTime: obj.LastModified,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", page.NextContinuationToken)
+	}
+}
+
+func (s3FS) Stat(p string) (Entry, error) {
+	bucket, key, err := splitS3Path(p)
+	if err != nil {
+		return Entry{}, err
+	}
+	resp, err := s3Request(http.MethodHead, bucket, key, nil, nil)
+	if err != nil {
+		return Entry{}, err
+	}
+	resp.Body.Close()
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Entry{Path: p, Name: path.Base(key), Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+func (fs s3FS) Open(p string) (File, error) {
+	entry, err := fs.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key, _ := splitS3Path(p)
+	return newRangeFile(p, entry.Size, func(header http.Header) (io.ReadCloser, error) {
+		resp, err := s3Request(http.MethodGet, bucket, key, nil, header)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Body, nil
+	}), nil
+}
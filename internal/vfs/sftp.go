@@ -0,0 +1,286 @@
+package vfs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPConfig holds the login used for sftp:// paths. The user can also be given in the path
+// (sftp://user@host/dir); keys from ssh-agent and ~/.ssh are tried when no password is set.
+type SFTPConfig struct {
+	User                  string `json:"user,omitempty"`
+	Password              string `json:"password,omitempty"`
+	KeyFile               string `json:"key_file,omitempty"`                 // Private key (unencrypted); default ~/.ssh/id_ed25519, id_ecdsa, id_rsa
+	KnownHosts            string `json:"known_hosts,omitempty"`              // Default ~/.ssh/known_hosts
+	InsecureIgnoreHostKey bool   `json:"insecure_ignore_host_key,omitempty"` // Skip host key checking (trusted networks only)
+}
+
+var (
+	sftpMu      sync.Mutex
+	sftpConfig  SFTPConfig
+	sftpClients = map[string]*sftp.Client{} // One connection per user@host:port, shared by all reads
+)
+
+// SetSFTPConfig replaces the SFTP login and closes connections opened with the previous one
+func SetSFTPConfig(c SFTPConfig) {
+	sftpMu.Lock()
+	defer sftpMu.Unlock()
+	sftpConfig = c
+	for key, client := range sftpClients {
+		client.Close()
+		delete(sftpClients, key)
+	}
+}
+
+// sftpTarget is a parsed sftp:// path
+type sftpTarget struct {
+	user, addr string
+	path       string // Absolute path on the server
+}
+
+func parseSFTPPath(p string) (sftpTarget, error) {
+	authority, filePath := splitRemotePath(p, sftpScheme)
+	user, host, hasUser := strings.Cut(authority, "@")
+	if !hasUser {
+		user, host = "", authority
+	}
+	if host == "" {
+		return sftpTarget{}, fmt.Errorf("invalid SFTP path (expected sftp://user@host/dir): %s", p)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+	return sftpTarget{user: user, addr: host, path: path.Clean(filePath)}, nil
+}
+
+// prefix is how paths on this server are written in reports (never with a password)
+func (t sftpTarget) prefix() string {
+	host := t.addr
+	if h, port, err := net.SplitHostPort(t.addr); err == nil && port == "22" {
+		host = h
+	}
+	if t.user != "" {
+		return sftpScheme + t.user + "@" + host
+	}
+	return sftpScheme + host
+}
+
+// withSFTP runs fn with a connection to the server of p, reconnecting once if it was lost
+func withSFTP(p string, fn func(c *sftp.Client, t sftpTarget) error) error {
+	t, err := parseSFTPPath(p)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		c, err := sftpClient(t)
+		if err != nil {
+			return err
+		}
+		err = fn(c, t)
+		if attempt == 0 && (errors.Is(err, sftp.ErrSSHFxConnectionLost) || errors.Is(err, net.ErrClosed)) {
+			dropSFTPClient(c)
+			continue
+		}
+		return err
+	}
+}
+
+func sftpClient(t sftpTarget) (*sftp.Client, error) {
+	sftpMu.Lock()
+	defer sftpMu.Unlock()
+	cfg := sftpConfig
+	if t.user == "" {
+		t.user = cfg.User
+	}
+	if t.user == "" {
+		t.user = os.Getenv("USER")
+	}
+	key := t.user + "@" + t.addr
+	if c, ok := sftpClients[key]; ok {
+		return c, nil
+	}
+
+	auth, err := sftpAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := sftpHostKeyCallback(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ssh.Dial("tcp", t.addr, &ssh.ClientConfig{
+		User:            t.user,
+		Auth:            auth,
+		HostKeyCallback: hostKey,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sftp %s: %w", t.addr, err)
+	}
+	c, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("sftp %s: %w", t.addr, err)
+	}
+	sftpClients[key] = c
+	return c, nil
+}
+
+func dropSFTPClient(c *sftp.Client) {
+	sftpMu.Lock()
+	defer sftpMu.Unlock()
+	for key, cached := range sftpClients {
+		if cached == c {
+			delete(sftpClients, key)
+		}
+	}
+	c.Close()
+}
+
+func sftpAuth(cfg SFTPConfig) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if cfg.Password != "" {
+		methods = append(methods, ssh.Password(cfg.Password))
+	}
+
+	var signers []ssh.Signer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			if agentSigners, err := agent.NewClient(conn).Signers(); err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+	keyFiles := []string{cfg.KeyFile}
+	if cfg.KeyFile == "" {
+		home, _ := os.UserHomeDir()
+		keyFiles = []string{
+			filepath.Join(home, ".ssh", "id_ed25519"),
+			filepath.Join(home, ".ssh", "id_ecdsa"),
+			filepath.Join(home, ".ssh", "id_rsa"),
+		}
+	}
+	for _, file := range keyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if cfg.KeyFile != "" {
+				return nil, fmt.Errorf("cannot read SFTP key: %w", err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			if cfg.KeyFile != "" {
+				return nil, fmt.Errorf("cannot use SFTP key %s: %w", file, err)
+			}
+			continue // Passphrase-protected defaults are left to ssh-agent
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("no SFTP credentials: set a password or key file, or load a key into ssh-agent")
+	}
+	return methods, nil
+}
+
+func sftpHostKeyCallback(cfg SFTPConfig) (ssh.HostKeyCallback, error) {
+	if cfg.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	file := cfg.KnownHosts
+	if file == "" {
+		home, _ := os.UserHomeDir()
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot verify SFTP host keys (%w); connect once with ssh to record the host, or set insecure_ignore_host_key", err)
+	}
+	return callback, nil
+}
+
+// sftpFS reads directories and files over an SSH connection
+type sftpFS struct{}
+
+func (sftpFS) Walk(root string, recursive bool, fn func(Entry) error) error {
+	return withSFTP(root, func(c *sftp.Client, t sftpTarget) error {
+		walker := c.Walk(t.path)
+		for walker.Step() {
+			if err := walker.Err(); err != nil {
+				return err
+			}
+			info := walker.Stat()
+			if info.IsDir() {
+				// If not recursive and not the root directory, skip
+				if !recursive && walker.Path() != t.path {
+					walker.SkipDir()
+				}
+				continue
+			}
+			err := fn(Entry{
+				Path:    t.prefix() + walker.Path(),
+				Name:    info.Name(),
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (sftpFS) Stat(p string) (Entry, error) {
+	var entry Entry
+	err := withSFTP(p, func(c *sftp.Client, t sftpTarget) error {
+		info, err := c.Stat(t.path)
+		if err != nil {
+			return err
+		}
+		entry = Entry{Path: p, Name: info.Name(), Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	return entry, err
+}
+
+func (sftpFS) Open(p string) (File, error) {
+	var file File
+	err := withSFTP(p, func(c *sftp.Client, t sftpTarget) error {
+		f, err := c.Open(t.path)
+		if err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		file = sftpFile{File: f, size: info.Size()}
+		return nil
+	})
+	return file, err
+}
+
+type sftpFile struct {
+	*sftp.File
+	size int64
+}
+
+func (f sftpFile) Size() int64 { return f.size }
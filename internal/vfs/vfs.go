@@ -0,0 +1,82 @@
+package vfs
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// Entry describes a file found by Walk or Stat
+type Entry struct {
+	Path    string
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// File is an open file that can be streamed (hashing, RAR) or read at random offsets (ZIP and
+// 7Z directories). Remote backends turn ReadAt into ranged requests.
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Closer
+	Size() int64
+}
+
+// FS is a storage backend holding a scanned library
+type FS interface {
+	// Walk calls fn for every file under root; subfolders are only entered when recursive is set
+	Walk(root string, recursive bool, fn func(Entry) error) error
+	Stat(path string) (Entry, error)
+	Open(path string) (File, error)
+}
+
+const (
+	s3Scheme      = "s3://"
+	sftpScheme    = "sftp://"
+	webdavScheme  = "webdav://"  // WebDAV over HTTP
+	webdavsScheme = "webdavs://" // WebDAV over HTTPS (Nextcloud, ownCloud...)
+)
+
+// For returns the backend serving path: S3 for "s3://bucket/prefix", SFTP for
+// "sftp://user@host/dir", WebDAV for "webdav(s)://host/dir" and the local disk otherwise
+func For(path string) FS {
+	switch {
+	case strings.HasPrefix(path, s3Scheme):
+		return s3FS{}
+	case strings.HasPrefix(path, sftpScheme):
+		return sftpFS{}
+	case strings.HasPrefix(path, webdavScheme), strings.HasPrefix(path, webdavsScheme):
+		return webdavFS{}
+	}
+	return localFS{}
+}
+
+// IsRemote reports whether path lives on a remote backend (and cannot be opened with os.Open)
+func IsRemote(path string) bool {
+	_, local := For(path).(localFS)
+	return !local
+}
+
+// splitRemotePath splits "scheme://authority/some/path" into the authority and the path. File
+// names are kept verbatim, so characters such as '#' or '?' are not taken for URL syntax.
+func splitRemotePath(p, scheme string) (authority, filePath string) {
+	rest := strings.TrimPrefix(p, scheme)
+	authority, filePath, _ = strings.Cut(rest, "/")
+	return authority, "/" + filePath
+}
+
+// Walk lists the files under root on the backend serving it
+func Walk(root string, recursive bool, fn func(Entry) error) error {
+	return For(root).Walk(root, recursive, fn)
+}
+
+// Stat describes a single file on the backend serving it
+func Stat(path string) (Entry, error) {
+	return For(path).Stat(path)
+}
+
+// Open opens a file on the backend serving it
+func Open(path string) (File, error) {
+	return For(path).Open(path)
+}
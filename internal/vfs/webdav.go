@@ -0,0 +1,227 @@
+package vfs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebDAVConfig holds the login used for webdav:// and webdavs:// paths (for Nextcloud, an app password)
+type WebDAVConfig struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+var (
+	webdavMu     sync.RWMutex
+	webdavConfig WebDAVConfig
+	webdavClient = &http.Client{Timeout: 5 * time.Minute}
+)
+
+// SetWebDAVConfig replaces the WebDAV login
+func SetWebDAVConfig(c WebDAVConfig) {
+	webdavMu.Lock()
+	defer webdavMu.Unlock()
+	webdavConfig = c
+}
+
+// webdavURL turns "webdavs://host/dir" into "https://host/dir"
+func webdavURL(p string) (*url.URL, error) {
+	u := &url.URL{Scheme: "http"}
+	scheme := webdavScheme
+	if strings.HasPrefix(p, webdavsScheme) {
+		u.Scheme, scheme = "https", webdavsScheme
+	}
+	u.Host, u.Path = splitRemotePath(p, scheme)
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid WebDAV path (expected webdavs://host/dir): %s", p)
+	}
+	return u, nil
+}
+
+// webdavRequest sends an authenticated request and turns error statuses into errors
+func webdavRequest(method, p string, header http.Header, body string) (*http.Response, error) {
+	u, err := webdavURL(p)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	webdavMu.RLock()
+	c := webdavConfig
+	webdavMu.RUnlock()
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := webdavClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s: %w", p, os.ErrNotExist)
+		}
+		return nil, fmt.Errorf("webdav %s %s: %s", method, p, resp.Status)
+	}
+	return resp, nil
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getcontentlength/><d:getlastmodified/></d:prop></d:propfind>`
+
+// davResource is one resource of a PROPFIND answer
+type davResource struct {
+	Path    string // Unescaped path on the server
+	Dir     bool
+	Size    int64
+	ModTime time.Time
+}
+
+// propfind lists a collection (depth 1) or describes a single resource (depth 0)
+func propfind(p string, depth int) ([]davResource, error) {
+	header := http.Header{"Depth": {strconv.Itoa(depth)}, "Content-Type": {"application/xml; charset=utf-8"}}
+	resp, err := webdavRequest("PROPFIND", p, header, propfindBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var ms struct {
+		Responses []struct {
+			Href     string `xml:"href"`
+			Propstat []struct {
+				Status string `xml:"status"`
+				Prop   struct {
+					ResourceType struct {
+						Collection *struct{} `xml:"collection"`
+					} `xml:"resourcetype"`
+					ContentLength string `xml:"getcontentlength"`
+					LastModified  string `xml:"getlastmodified"`
+				} `xml:"prop"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to parse WebDAV listing: %w", err)
+	}
+
+	var resources []davResource
+	for _, r := range ms.Responses {
+		href := r.Href
+		if u, err := url.Parse(href); err == nil {
+			href = u.Path // Servers may answer with absolute URLs; Path is already unescaped
+		}
+		res := davResource{Path: href}
+		for _, ps := range r.Propstat {
+			if !strings.Contains(ps.Status, " 200") {
+				continue
+			}
+			res.Dir = res.Dir || ps.Prop.ResourceType.Collection != nil
+			if n, err := strconv.ParseInt(ps.Prop.ContentLength, 10, 64); err == nil {
+				res.Size = n
+			}
+			if t, err := http.ParseTime(ps.Prop.LastModified); err == nil {
+				res.ModTime = t
+			}
+		}
+		resources = append(resources, res)
+	}
+	return resources, nil
+}
+
+// webdavPath rebuilds a webdav(s):// path for a server path found in a listing
+func webdavPath(base, serverPath string) string {
+	scheme := webdavScheme
+	if strings.HasPrefix(base, webdavsScheme) {
+		scheme = webdavsScheme
+	}
+	host, _ := splitRemotePath(base, scheme)
+	return scheme + host + serverPath
+}
+
+// webdavFS lists collections with PROPFIND and reads files with (ranged) GET requests
+type webdavFS struct{}
+
+func (webdavFS) Walk(root string, recursive bool, fn func(Entry) error) error {
+	u, err := webdavURL(root)
+	if err != nil {
+		return err
+	}
+	pending := []string{strings.TrimSuffix(u.Path, "/") + "/"}
+	for len(pending) > 0 {
+		dir := pending[0]
+		pending = pending[1:]
+
+		resources, err := propfind(webdavPath(root, dir), 1)
+		if err != nil {
+			return err
+		}
+		for _, r := range resources {
+			if strings.TrimSuffix(r.Path, "/") == strings.TrimSuffix(dir, "/") {
+				continue // The collection itself
+			}
+			if r.Dir {
+				if recursive {
+					pending = append(pending, strings.TrimSuffix(r.Path, "/")+"/")
+				}
+				continue
+			}
+			err := fn(Entry{
+				Path:    webdavPath(root, r.Path),
+				Name:    path.Base(r.Path),
+				Size:    r.Size,
+				ModTime: r.ModTime,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (webdavFS) Stat(p string) (Entry, error) {
+	resources, err := propfind(p, 0)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(resources) == 0 {
+		return Entry{}, fmt.Errorf("%s: %w", p, os.ErrNotExist)
+	}
+	r := resources[0]
+	return Entry{Path: p, Name: path.Base(r.Path), Size: r.Size, ModTime: r.ModTime}, nil
+}
+
+func (fs webdavFS) Open(p string) (File, error) {
+	entry, err := fs.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	return newRangeFile(p, entry.Size, func(header http.Header) (io.ReadCloser, error) {
+		resp, err := webdavRequest(http.MethodGet, p, header, "")
+		if err != nil {
+			return nil, err
+		}
+		// A server ignoring Range answers 200 with the whole file, which is only usable from the start
+		if resp.StatusCode != http.StatusPartialContent && !strings.HasPrefix(header.Get("Range"), "bytes=0-") {
+			resp.Body.Close()
+			return nil, fmt.Errorf("webdav server does not support ranged reads of %s", p)
+		}
+		return resp.Body, nil
+	}), nil
+}
@@ -0,0 +1,106 @@
+package visual
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ImagesBeside returns the loose images in the folders of the given archives, typed "image".
+// Images the scan already collected (loose-file mode) are taken from files instead of listing
+// their folder again.
+func ImagesBeside(files []scanner.ArchiveFile) []scanner.ArchiveFile {
+	var images []scanner.ArchiveFile
+	if scanner.LooseFiles() {
+		for _, f := range files {
+			if f.Type == "image" {
+				images = append(images, f)
+			}
+		}
+		return images
+	}
+
+	listed := make(map[string]bool)
+	for _, f := range files {
+		dir := parentDir(f.Path)
+		if !isPreviewed(f) || listed[dir] {
+			continue
+		}
+		listed[dir] = true
+		vfs.Walk(dir, false, func(e vfs.Entry) error {
+			if scanner.IsImage(e.Name) && e.Size > 0 {
+				images = append(images, scanner.ArchiveFile{Name: e.Name, Path: e.Path, Size: e.Size, Type: "image", ModTime: e.ModTime})
+			}
+			return nil
+		})
+	}
+	return images
+}
+
+// LinkPreviews fingerprints the images (cached like archive previews) and pairs every archive
+// with the images of its folder whose picture is within HammingThreshold of its preview.
+// Archive previews must have been hashed already (ProcessVisualHashes).
+func LinkPreviews(ctx context.Context, files, images []scanner.ArchiveFile, cache *db.Cache) []reporter.PreviewLink {
+	if cache == nil || len(images) == 0 {
+		return nil
+	}
+	ProcessVisualHashes(ctx, images, cache, false, nil)
+
+	type hashed struct {
+		file scanner.ArchiveFile
+		hash uint64
+	}
+	byDir := make(map[string][]hashed)
+	for _, img := range images {
+		if h, ok := cache.GetVisualHash(img.Path, img.ModTime.Format(time.RFC3339)); ok {
+			dir := parentDir(img.Path)
+			byDir[dir] = append(byDir[dir], hashed{img, h})
+		}
+	}
+
+	var links []reporter.PreviewLink
+	for _, f := range files {
+		if !isPreviewed(f) {
+			continue
+		}
+		candidates := byDir[parentDir(f.Path)]
+		if len(candidates) == 0 {
+			continue
+		}
+		h, ok := cache.GetVisualHash(f.Path, f.ModTime.Format(time.RFC3339))
+		if !ok {
+			continue
+		}
+		for _, img := range candidates {
+			if dist := archive.CalculateHammingDistance(h, img.hash); dist <= HammingThreshold {
+				links = append(links, reporter.PreviewLink{Archive: reporter.NewFileInfo(f), Image: reporter.NewFileInfo(img.file), Distance: dist})
+			}
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Archive.Path != links[j].Archive.Path {
+			return links[i].Archive.Path < links[j].Archive.Path
+		}
+		return links[i].Distance < links[j].Distance
+	})
+	return links
+}
+
+// isPreviewed reports whether a file is fingerprinted by a preview found inside it
+func isPreviewed(f scanner.ArchiveFile) bool {
+	return f.Type == "archive" || f.Type == "book"
+}
+
+// parentDir returns the folder of a local or remote path, keeping remote schemes intact
+func parentDir(p string) string {
+	if i := strings.LastIndexAny(p, `/\`); i > 0 {
+		return p[:i]
+	}
+	return "."
+}
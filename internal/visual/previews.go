@@ -0,0 +1,141 @@
+package visual
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/membudget"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/vfs"
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+
+	"golang.org/x/image/draw"
+)
+
+// thumbnailSize is the longest side of the thumbnails embedded in exported reports, in pixels
+const thumbnailSize = 160
+
+// AttachPreviews returns a copy of the report whose group members point at their preview:
+// the archive entry they are shown by and its copy in the preview cache the web UI serves
+// (extracted when missing), or the file itself for a loose image. With thumbnails, a small
+// JPEG of the preview is embedded too, so the export can be read without the cache. While the
+// preview cache is off, archives only get their entry and a thumbnail made in memory.
+func AttachPreviews(report reporter.Report, cache *db.Cache, thumbnails bool) reporter.Report {
+	type preview struct{ entry, file, thumbnail string }
+	resolved := make(map[string]preview)
+
+	return reporter.WithPreviews(report, func(f reporter.FileInfo) (string, string, string) {
+		if p, ok := resolved[f.Path]; ok {
+			return p.entry, p.file, p.thumbnail
+		}
+		var p preview
+		switch f.Type {
+		case "image":
+			p.file = f.Path
+		case "archive", "book":
+			p.entry, p.file = cachedPreview(cache, f.Path)
+		}
+		if thumbnails && (p.file != "" || (p.entry != "" && !archive.PreviewCacheEnabled())) {
+			var thumb string
+			var err error
+			if p.file != "" {
+				thumb, err = Thumbnail(p.file)
+			} else {
+				thumb, err = entryThumbnail(f.Path, p.entry)
+			}
+			if err != nil {
+				log.Printf("⚠️  No thumbnail for %s: %v", f.Name, err)
+			}
+			p.thumbnail = thumb
+		}
+		resolved[f.Path] = p
+		return p.entry, p.file, p.thumbnail
+	})
+}
+
+// cachedPreview finds the preview entry of an archive like the web UI does (a preview picked
+// by the user first, then the remembered choice, then the heuristics) and extracts it into
+// the preview cache
+func cachedPreview(cache *db.Cache, path string) (entry, file string) {
+	modTime := ""
+	if info, err := vfs.Stat(path); err == nil {
+		modTime = info.ModTime.String()
+	}
+
+	var found bool
+	if cache != nil {
+		entry, found = cache.GetPreviewOverride(path)
+		if !found {
+			entry, found = cache.GetPreviewPath(path, modTime)
+		}
+	}
+	if !found {
+		var err error
+		if entry, err = archive.FindPreviewPathInArchive(path); err != nil {
+			return "", ""
+		}
+		if cache != nil {
+			cache.PutPreviewPath(path, entry, modTime)
+		}
+	}
+
+	file, err := archive.CachePreview(path, entry)
+	if err != nil {
+		return entry, ""
+	}
+	return entry, file
+}
+
+// entryThumbnail makes the thumbnail of an archive entry without caching the entry on disk
+func entryThumbnail(path, entry string) (string, error) {
+	data, err := archive.PreviewFile(path, entry)
+	if err != nil {
+		return "", err
+	}
+	return thumbnailOf(data)
+}
+
+// Thumbnail scales an image down to thumbnailSize and returns it as a JPEG data URI
+func Thumbnail(path string) (string, error) {
+	r, err := vfs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return thumbnailOf(data)
+}
+
+func thumbnailOf(data []byte) (string, error) {
+	release := membudget.Acquire(membudget.ImageBytes(data))
+	defer release()
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > thumbnailSize || h > thumbnailSize {
+		if w >= h {
+			w, h = thumbnailSize, max(1, h*thumbnailSize/w)
+		} else {
+			w, h = max(1, w*thumbnailSize/h), thumbnailSize
+		}
+	}
+	thumb := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(thumb, thumb.Bounds(), img, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 75}); err != nil {
+		return "", err
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -3,26 +3,76 @@ package visual
 import (
 	"archive-duplicate-finder/internal/archive"
 	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/jobs"
 	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/vfs"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"sync"
 	"time"
 )
 
-// ProcessVisualHashes iterates over files and computes visual hashes if they are missing
-func ProcessVisualHashes(files []scanner.ArchiveFile, cache *db.Cache, debug bool, onProgress func(float64)) {
+// ProcessVisualHashes iterates over files and computes visual hashes if they are missing.
+// Once ctx is canceled the remaining files are skipped.
+func ProcessVisualHashes(ctx context.Context, files []scanner.ArchiveFile, cache *db.Cache, debug bool, onProgress func(float64)) {
+	HashPreviews(ctx, files, cache, Options{Debug: debug, OnProgress: onProgress})
+}
+
+// Options tune HashPreviews
+type Options struct {
+	Debug      bool
+	OnProgress func(float64) // Percent done, weighted by file size
+	// OnFile is called for every file once it is done with, hashed now or before; err tells
+	// why no preview could be hashed (nil for files that hold no image at all)
+	OnFile func(f scanner.ArchiveFile, err error)
+}
+
+// HashPreviews computes the missing visual hashes of files, as ProcessVisualHashes does. Files
+// are read within the rate set with SetReadRate, and a paused job (see jobs.Wait) stops before
+// its next file until it is resumed.
+func HashPreviews(ctx context.Context, files []scanner.ArchiveFile, cache *db.Cache, opts Options) {
 	if cache == nil {
 		return
 	}
+	debug, onProgress := opts.Debug, opts.OnProgress
+	onFile := func(f scanner.ArchiveFile, err error) {
+		if opts.OnFile != nil {
+			opts.OnFile(f, err)
+		}
+	}
 
+	// Progress is weighted by archive size: big archives take longer to open
 	total := len(files)
-	var processed int
+	var totalBytes, processedBytes int64
+	for _, f := range files {
+		totalBytes += f.Size
+	}
+	reportProgress := func(f scanner.ArchiveFile) {
+		processedBytes += f.Size
+		if onProgress != nil && totalBytes > 0 {
+			onProgress(float64(processedBytes) / float64(totalBytes) * 100)
+		}
+	}
 	var mu sync.Mutex
 
-	// Use a worker pool to avoid resource exhaustion
-	workerCount := 4
-	jobs := make(chan scanner.ArchiveFile, total)
+	// Hashes are written in batches, one transaction each, rather than one per file
+	var pending []db.VisualHash
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := cache.PutVisualHashBatch(pending); err != nil {
+			log.Printf("⚠️  Could not save %d visual hashes: %v", len(pending), err)
+		}
+		pending = pending[:0]
+	}
+
+	// Use a worker pool to avoid resource exhaustion (sized by the global archive limits)
+	workerCount := archive.GetLimits().Workers
+	queue := make(chan scanner.ArchiveFile, total)
 	var wg sync.WaitGroup
 
 	for w := 1; w <= workerCount; w++ {
@@ -34,17 +84,24 @@ func ProcessVisualHashes(files []scanner.ArchiveFile, cache *db.Cache, debug boo
 					log.Printf("🔥 CRITICAL RECOVERY: Analysis worker recovered from panic: %v", r)
 				}
 			}()
-			for f := range jobs {
+			for f := range queue {
+				if jobs.Paused(ctx) {
+					// Save the hashes so far: a paused run may well be stopped for good
+					mu.Lock()
+					flush()
+					mu.Unlock()
+				}
+				if jobs.Wait(ctx) != nil {
+					continue
+				}
 				modTime := f.ModTime.Format(time.RFC3339)
 
 				// Check cache first
 				if _, ok := cache.GetVisualHash(f.Path, modTime); ok {
 					mu.Lock()
-					processed++
-					if onProgress != nil {
-						onProgress(float64(processed) / float64(total) * 100)
-					}
+					reportProgress(f)
 					mu.Unlock()
+					onFile(f, nil)
 					continue
 				}
 
@@ -53,43 +110,81 @@ func ProcessVisualHashes(files []scanner.ArchiveFile, cache *db.Cache, debug boo
 				}
 
 				// Try to extract preview
-				data, _, err := archive.FindPreviewInArchive(f.Path)
+				data, err := previewData(f)
 				if err != nil {
 					if debug {
 						log.Printf("[VISUAL] Skipped %s: %v", f.Name, err)
 					}
+					if errors.Is(err, errNoImage) {
+						err = nil
+					}
 				} else {
+					throttle(ctx, int64(len(data)))
 					// Generate pHash
-					phash, err := archive.GeneratePHash(data)
+					var phash uint64
+					phash, err = archive.GeneratePHash(data)
 					if err != nil {
 						if debug {
 							log.Printf("[VISUAL] Hash error %s: %v", f.Name, err)
 						}
 					} else {
 						// Store in cache
-						cache.PutVisualHash(f.Path, phash, modTime)
+						mu.Lock()
+						pending = append(pending, db.VisualHash{Path: f.Path, PHash: phash, ModTime: modTime})
+						if len(pending) >= visualBatchSize {
+							flush()
+						}
+						mu.Unlock()
 					}
 				}
 
 				mu.Lock()
-				processed++
-				if onProgress != nil {
-					onProgress(float64(processed) / float64(total) * 100)
-				}
+				reportProgress(f)
 				mu.Unlock()
+				onFile(f, err)
 			}
 		}()
 	}
 
 	for _, f := range files {
-		jobs <- f
+		queue <- f
 	}
-	close(jobs)
+	close(queue)
 	wg.Wait()
+	flush()
 }
 
-// FindVisualDuplicates groups files that are visually similar using Hamming distance
-func FindVisualDuplicates(files []scanner.ArchiveFile, cache *db.Cache, threshold int) []SimilarityGroup {
+// visualBatchSize is the number of visual hashes saved per transaction
+const visualBatchSize = 64
+
+// errNoImage is returned for files that are neither images nor archives
+var errNoImage = errors.New("not an image or archive")
+
+// previewData returns the image a file is fingerprinted by: a loose image itself, the best
+// preview inside an archive
+func previewData(f scanner.ArchiveFile) ([]byte, error) {
+	switch f.Type {
+	case "image":
+		r, err := vfs.Open(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case "file":
+		return nil, errNoImage
+	}
+	data, _, err := archive.FindPreviewInArchive(f.Path)
+	return data, err
+}
+
+// HammingThreshold is the default largest Hamming distance between two 64-bit preview hashes
+// that still counts as a visual match (e.g., 5 means highly similar)
+const HammingThreshold = 8
+
+// FindVisualDuplicates groups files that are visually similar: previews whose hashes are at
+// most maxDistance bits apart (see HammingThreshold)
+func FindVisualDuplicates(files []scanner.ArchiveFile, cache *db.Cache, maxDistance int) []SimilarityGroup {
 	if cache == nil || len(files) < 2 {
 		return nil
 	}
@@ -113,8 +208,6 @@ func FindVisualDuplicates(files []scanner.ArchiveFile, cache *db.Cache, threshol
 	}
 
 	// 2. Cluster using Hamming Distance (Simple Greedy Clustering)
-	// threshold for Hamming distance (e.g., 5 means highly similar for a 64-bit hash)
-	hammingThreshold := 8
 	visited := make(map[string]bool)
 	var groups []SimilarityGroup
 
@@ -132,7 +225,7 @@ func FindVisualDuplicates(files []scanner.ArchiveFile, cache *db.Cache, threshol
 			}
 
 			dist := archive.CalculateHammingDistance(hashes[i].hash, hashes[j].hash)
-			if dist <= hammingThreshold {
+			if dist <= maxDistance {
 				currentGroup = append(currentGroup, hashes[j].file)
 				visited[hashes[j].file.Path] = true
 			}
@@ -0,0 +1,46 @@
+package visual
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	rateMu   sync.Mutex
+	readRate int64     // Bytes per second; 0 is unlimited
+	nextRead time.Time // When the bytes read so far fit in the rate
+)
+
+// SetReadRate limits how many bytes of previews per second the visual analysis reads, so a run
+// over a large library leaves the disks to others; 0 removes the limit
+func SetReadRate(bytesPerSecond int64) {
+	rateMu.Lock()
+	defer rateMu.Unlock()
+	readRate = bytesPerSecond
+	nextRead = time.Time{}
+}
+
+// throttle waits until the n bytes just read fit in the read rate, or ctx is canceled. Workers
+// share the budget: each one waits for the reads booked before its own.
+func throttle(ctx context.Context, n int64) {
+	rateMu.Lock()
+	if readRate <= 0 {
+		rateMu.Unlock()
+		return
+	}
+	now := time.Now()
+	if nextRead.Before(now) {
+		nextRead = now
+	}
+	nextRead = nextRead.Add(time.Duration(float64(n) / float64(readRate) * float64(time.Second)))
+	wait := nextRead.Sub(now)
+	rateMu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
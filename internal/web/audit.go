@@ -0,0 +1,109 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// clientCookie holds the name a dashboard user logged in with
+const clientCookie = "adf_client"
+
+// maxClientName keeps client names readable in the audit trail
+const maxClientName = 64
+
+// registerAuditRoutes exposes who deleted, ignored or reviewed what
+func (s *Server) registerAuditRoutes(api fiber.Router) {
+	api.Get("/audit", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.JSON(fiber.Map{"entries": []db.AuditEntry{}})
+		}
+		entries := s.cache.AuditTrail(db.AuditFilter{
+			Actor:  c.Query("actor"),
+			Action: c.Query("action"),
+			Limit:  c.QueryInt("limit", 200),
+		})
+		if entries == nil {
+			entries = []db.AuditEntry{}
+		}
+		return c.JSON(fiber.Map{"entries": entries})
+	})
+
+	// The append-only log of file operations and ignores, from the CLI and the dashboard alike
+	api.Get("/operations", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.JSON(fiber.Map{"operations": []db.Operation{}})
+		}
+		ops := s.cache.Operations(db.OperationFilter{
+			Path:   c.Query("path"),
+			Action: c.Query("action"),
+			Limit:  c.QueryInt("limit", 200),
+		})
+		if ops == nil {
+			ops = []db.Operation{}
+		}
+		return c.JSON(fiber.Map{"operations": ops})
+	})
+
+	// Name the browser session, for dashboards without an access token to log in with
+	api.Post("/identify", func(c *fiber.Ctx) error {
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		setClientCookie(c, req.Name)
+		return c.SendStatus(200)
+	})
+}
+
+// setClientCookie remembers the name of a dashboard user; an empty name forgets it
+func setClientCookie(c *fiber.Ctx, name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		c.ClearCookie(clientCookie)
+		return
+	}
+	c.Cookie(&fiber.Cookie{Name: clientCookie, Value: name, SameSite: fiber.CookieSameSiteStrictMode})
+}
+
+// audit records an action performed by the client of a request. Failures are only logged:
+// the action itself already happened.
+func (s *Server) audit(c *fiber.Ctx, action, target, detail string) {
+	if s.cache == nil {
+		return
+	}
+	entry := db.AuditEntry{
+		Actor:  clientName(c),
+		Client: c.IP(),
+		Action: action,
+		Target: target,
+		Detail: detail,
+	}
+	if token := requestToken(c); token != "" {
+		entry.Token = config.HashToken(token)[:8]
+	}
+	if err := s.cache.RecordAudit(entry); err != nil {
+		log.Printf("⚠️ Could not record %s of %s in the audit trail: %v", action, target, err)
+	}
+}
+
+// clientName is the name a client identifies itself with: the "X-Client-Name" header, or the
+// name given when logging in
+func clientName(c *fiber.Ctx) string {
+	name := strings.TrimSpace(c.Get("X-Client-Name"))
+	if name == "" {
+		name = strings.TrimSpace(c.Cookies(clientCookie))
+	}
+	if name == "" {
+		return "anonymous"
+	}
+	if len(name) > maxClientName {
+		name = name[:maxClientName]
+	}
+	return name
+}
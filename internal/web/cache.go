@@ -0,0 +1,94 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"bytes"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerCacheRoutes exposes cache statistics and maintenance, and lets a cache built on one
+// machine be moved to another
+func (s *Server) registerCacheRoutes(api fiber.Router) {
+	api.Get("/cache/stats", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		stats, err := s.cache.Stats()
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return c.JSON(stats)
+	})
+
+	// Drop entries of files that no longer exist
+	api.Post("/cache/gc", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		result, err := s.cache.GC()
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		log.Printf("🧹 Cache GC removed %d stale entries", result.Total)
+		return c.JSON(result)
+	})
+
+	// Drop every entry of a scan root that is gone for good
+	api.Delete("/cache/roots", func(c *fiber.Ctx) error {
+		root := c.Query("root")
+		if root == "" {
+			return c.Status(400).SendString("root is required")
+		}
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		n, err := s.cache.ForgetRoot(root)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		log.Printf("🧹 Forgot %d cache entries of %s", n, root)
+		return c.JSON(fiber.Map{"removed": n})
+	})
+
+	// Hashes, previews, visual hashes and ignored groups as a JSON download
+	api.Get("/cache/export", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		var buf bytes.Buffer
+		if err := s.cache.Export(&buf); err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		filename := fmt.Sprintf("archive-finder-cache-%s.json", time.Now().Format("20060102-150405"))
+		c.Set("Content-Type", "application/json")
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		return c.Send(buf.Bytes())
+	})
+
+	// Merge an export into the local cache; ?map=from=to rewrites path prefixes (repeatable)
+	api.Post("/cache/import", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		var mappings []db.PathMapping
+		for _, spec := range c.Context().QueryArgs().PeekMulti("map") {
+			mapping, err := db.ParsePathMapping(string(spec))
+			if err != nil {
+				return c.Status(400).SendString(err.Error())
+			}
+			mappings = append(mappings, mapping)
+		}
+
+		stats, err := s.cache.Import(bytes.NewReader(c.Body()), mappings)
+		if err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		log.Printf("📥 Cache imported: %d file hashes, %d previews, %d visual hashes, %d ignored groups",
+			stats.FileHashes, stats.Previews, stats.VisualHashes, stats.IgnoredGroups)
+		return c.JSON(stats)
+	})
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/vfs"
+	"log"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerContentsRoutes serves the file tree of an archive for the dashboard's file browser and
+// pulls selected entries out of it
+func (s *Server) registerContentsRoutes(api fiber.Router) {
+	// Endpoint: /api/v1/archive-contents?path=...&compare=...
+	// With compare, both trees are returned and every entry is marked "same", "changed" or "only"
+	api.Get("/archive-contents", func(c *fiber.Ctx) error {
+		path := c.Query("path")
+		if path == "" {
+			return c.Status(400).SendString("Path is required")
+		}
+
+		s.previewSem <- struct{}{}
+		defer func() { <-s.previewSem }()
+
+		tree, err := archive.ListArchiveTree(path)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		compare := c.Query("compare")
+		if compare == "" {
+			return c.JSON(fiber.Map{"path": path, "tree": tree})
+		}
+
+		other, err := archive.ListArchiveTree(compare)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		archive.DiffTrees(tree, other)
+		return c.JSON(fiber.Map{"path": path, "tree": tree, "compare": compare, "compare_tree": other})
+	})
+
+	// Extract some entries (or folders) of an archive into a local folder, e.g. to rescue a few
+	// files from a copy about to be deleted
+	api.Post("/extract", func(c *fiber.Ctx) error {
+		var req struct {
+			Path          string   `json:"path"`
+			InternalPaths []string `json:"internal_paths"`
+			Dest          string   `json:"dest"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		if req.Path == "" || req.Dest == "" || len(req.InternalPaths) == 0 {
+			return c.Status(400).SendString("path, internal_paths and dest are required")
+		}
+		if vfs.IsRemote(req.Dest) {
+			return c.Status(400).SendString("dest must be a local folder")
+		}
+
+		results, err := archive.ExtractEntries(req.Path, req.InternalPaths, req.Dest)
+		if err != nil && results == nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		extracted := 0
+		for _, r := range results {
+			if r.Error == "" {
+				extracted++
+			}
+		}
+		log.Printf("📤 Dashboard Request: extracted %d files from %s into %s", extracted, filepath.Base(req.Path), req.Dest)
+		response := fiber.Map{"extracted": extracted, "results": results}
+		if err != nil {
+			response["error"] = err.Error()
+		}
+		return c.JSON(response)
+	})
+}
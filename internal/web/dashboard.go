@@ -0,0 +1,81 @@
+package web
+
+import (
+	"archive-duplicate-finder/ui"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+// notBuiltPage is served when there is no dashboard to serve; the API keeps working
+const notBuiltPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Archive Duplicate Finder</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 4em auto">
+<h1>Dashboard not built</h1>
+<p>Build it with <code>npm install && npm run build</code> in <code>ui/</code>, then either run the
+finder from the repository folder, pass <code>-ui-dir ui/out</code>, or build a self-contained
+binary with <code>go build -tags embedui ./cmd/finder</code>.</p>
+<p>The REST API is available under <a href="/api/v1/openapi.json">/api/v1</a>.</p>
+</body></html>`
+
+// SetUIDir serves the dashboard from dir instead of the copy embedded in the binary, e.g. ui/out
+// while working on the UI
+func (s *Server) SetUIDir(dir string) {
+	s.uiDir = dir
+}
+
+// dashboardFiles picks the dashboard to serve: the folder given to SetUIDir, then the copy
+// embedded in the binary, then ui/out in the working directory or next to the executable.
+// It returns nil when there is none.
+func (s *Server) dashboardFiles() (fs.FS, string) {
+	if s.uiDir != "" {
+		return os.DirFS(s.uiDir), s.uiDir
+	}
+	if ui.Files != nil {
+		return ui.Files, "embedded"
+	}
+	candidates := []string{filepath.Join("ui", "out")}
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), "ui", "out"))
+	}
+	for _, dir := range candidates {
+		if _, err := os.Stat(filepath.Join(dir, "index.html")); err == nil {
+			return os.DirFS(dir), dir
+		}
+	}
+	return nil, ""
+}
+
+// serveDashboard serves the static dashboard after every other route. Any non-API path that is
+// not a file gets index.html, so that browser reloads on routes like /gallery work.
+func (s *Server) serveDashboard(app *fiber.App) {
+	files, source := s.dashboardFiles()
+	if files == nil {
+		log.Printf("⚠️ Dashboard not built: serving the API only")
+	} else if s.debug {
+		log.Printf("🖥️  Dashboard files: %s", source)
+	}
+
+	var root http.FileSystem
+	if files != nil {
+		root = http.FS(files)
+		app.Use(filesystem.New(filesystem.Config{Root: root}))
+	}
+	app.Use(func(c *fiber.Ctx) error {
+		// API routes that matched nothing stay a 404
+		if strings.HasPrefix(c.Path(), "/api") {
+			return c.Next()
+		}
+		if root == nil {
+			c.Type("html")
+			return c.Status(fiber.StatusOK).SendString(notBuiltPage)
+		}
+		return filesystem.SendFile(c, root, "index.html")
+	})
+}
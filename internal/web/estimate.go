@@ -0,0 +1,91 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/estimate"
+	"archive-duplicate-finder/internal/scanner"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerEstimateRoutes serves the projected cost of the on-demand analyses
+func (s *Server) registerEstimateRoutes(api fiber.Router) {
+	api.Get("/estimate", func(c *fiber.Ctx) error {
+		e := estimate.New(s.archiveFiles(), s.cache)
+		return c.JSON(fiber.Map{
+			"estimate":              e,
+			"confirm_above_seconds": s.confirmAbove().Seconds(),
+		})
+	})
+}
+
+// requireConfirmation answers 409 with the estimate when a phase is projected to run longer
+// than the configured limit and the request did not carry ?confirm=1. It returns true when
+// the response has been sent and the analysis must not start.
+func (s *Server) requireConfirmation(c *fiber.Ctx, phase string) (bool, error) {
+	if c.Query("confirm") == "1" {
+		return false, nil
+	}
+	if over := s.overLimit(phase); over != nil {
+		return true, c.Status(409).JSON(over)
+	}
+	return false, nil
+}
+
+// overLimit returns the estimate of a phase projected to run longer than the configured limit,
+// as answered with 409, or nil when it may start right away
+func (s *Server) overLimit(phase string) fiber.Map {
+	limit := s.confirmAbove()
+	if limit <= 0 {
+		return nil
+	}
+
+	e := estimate.New(s.archiveFiles(), s.cache)
+	projected := e.Step3()
+	label := "Similar name analysis"
+	if phase == estimate.PhaseVisual {
+		projected = e.Visual()
+		label = "Visual analysis"
+	}
+	if projected <= limit {
+		return nil
+	}
+
+	return fiber.Map{
+		"phase":             phase,
+		"projected_seconds": projected.Seconds(),
+		"estimate":          e,
+		"message": fmt.Sprintf("%s is projected to take %s for %d archives. Start anyway?",
+			label, estimate.FormatDuration(projected), e.Archives),
+	}
+}
+
+func (s *Server) confirmAbove() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.config == nil {
+		return estimate.DefaultConfirmAbove
+	}
+	return time.Duration(s.config.ConfirmAbove) * time.Minute
+}
+
+// archiveFiles rebuilds scanner entries from the gallery listing of the last scan
+func (s *Server) archiveFiles() []scanner.ArchiveFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := make([]scanner.ArchiveFile, 0, len(s.allFiles))
+	for _, f := range s.allFiles {
+		modTime, _ := time.Parse(time.RFC3339, f.ModTime)
+		files = append(files, scanner.ArchiveFile{
+			Name:    f.Name,
+			Path:    f.Path,
+			Size:    f.Size,
+			Type:    f.Type,
+			ModTime: modTime,
+			Volumes: f.Volumes,
+		})
+	}
+	return files
+}
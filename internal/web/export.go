@@ -0,0 +1,142 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/visual"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerExportRoutes adds downloadable renderings of the current report
+func (s *Server) registerExportRoutes(api fiber.Router) {
+	// Cleanup plan as a reviewed script: ?format=sh (default) or ?format=ps1
+	api.Get("/export/script", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(404).SendString("No report available")
+		}
+		report := s.filteredReport()
+		opts := reporter.ScriptOptions{
+			Shell:     "sh",
+			TrashPath: s.trashPath,
+			LeaveRef:  s.leaveRef,
+		}
+		if s.config != nil {
+			opts.DeleteMode = s.config.DeleteMode
+			opts.RefFormat, opts.RefTemplate = s.config.RefFormat, s.config.RefTemplate
+		}
+		s.mu.Unlock()
+
+		ext := "sh"
+		if c.Query("format") == "ps1" {
+			opts.Shell = "powershell"
+			ext = "ps1"
+		}
+
+		filename := fmt.Sprintf("cleanup-%s.%s", time.Now().Format("20060102-150405"), ext)
+		c.Set("Content-Type", "text/plain; charset=utf-8")
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		return c.SendString(reporter.RenderScript(report, opts))
+	})
+
+	// Files to remove as a backup exclusion list: ?format=rsync (default) or ?format=rclone
+	api.Get("/export/excludes", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(404).SendString("No report available")
+		}
+		report := s.filteredReport()
+		opts := reporter.ExcludeOptions{Format: reporter.ExcludeRsync, Root: s.scanDir}
+		if s.config != nil {
+			opts.DeleteMode = s.config.DeleteMode
+		}
+		s.mu.Unlock()
+
+		switch c.Query("format", reporter.ExcludeRsync) {
+		case reporter.ExcludeRsync:
+		case reporter.ExcludeRclone:
+			opts.Format = reporter.ExcludeRclone
+		default:
+			return c.Status(400).SendString("format must be rsync or rclone")
+		}
+
+		filename := fmt.Sprintf("%s-excludes-%s.txt", opts.Format, time.Now().Format("20060102-150405"))
+		c.Set("Content-Type", "text/plain; charset=utf-8")
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		return c.SendString(reporter.RenderExcludes(report, opts))
+	})
+
+	// Report as JSON, groups pointing at their cached previews; ?thumbs=1 embeds thumbnails
+	api.Get("/export/json", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(404).SendString("No report available")
+		}
+		report := s.filteredReport()
+		s.mu.Unlock()
+
+		report = visual.AttachPreviews(report, s.cache, c.QueryBool("thumbs"))
+		filename := fmt.Sprintf("report-%s.json", time.Now().Format("20060102-150405"))
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		return c.JSON(report)
+	})
+
+	// Report rendered on demand and downloaded: ?format=json (default), csv, pdf or html;
+	// ?thumbs=1 draws a thumbnail of each preview (json, pdf and html)
+	api.Get("/export", func(c *fiber.Ctx) error {
+		format := c.Query("format", "json")
+		write, ok := reportWriters[format]
+		if !ok {
+			return c.Status(400).SendString("format must be json, csv, pdf or html")
+		}
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(404).SendString("No report available")
+		}
+		report := s.filteredReport()
+		s.mu.Unlock()
+
+		thumbs := c.QueryBool("thumbs") && format != "csv"
+		if format == "json" || thumbs {
+			// JSON files point at their previews, as in /export/json; the others only draw thumbnails
+			report = visual.AttachPreviews(report, s.cache, thumbs)
+		}
+		filename := fmt.Sprintf("report-%s.%s", time.Now().Format("20060102-150405"), format)
+		c.Set("Content-Type", write.contentType)
+		c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			if err := write.render(w, report); err != nil {
+				log.Printf("⚠️  Export of %s failed: %v", filename, err)
+			}
+			w.Flush()
+		})
+		return nil
+	})
+}
+
+// reportWriters render a report in the formats of GET /export
+var reportWriters = map[string]struct {
+	contentType string
+	render      func(w io.Writer, report reporter.Report) error
+}{
+	"json": {"application/json", func(w io.Writer, report reporter.Report) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}},
+	"csv": {"text/csv; charset=utf-8", reporter.WriteCSV},
+	"pdf": {"application/pdf", func(w io.Writer, report reporter.Report) error {
+		return reporter.WritePDF(w, report, nil)
+	}},
+	"html": {"text/html; charset=utf-8", reporter.WriteHTML},
+}
@@ -0,0 +1,92 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/reporter"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerGroupRoutes lets groups be triaged over several sessions: a review status and a note
+// are kept per group under its stable ID
+func (s *Server) registerGroupRoutes(api fiber.Router) {
+	api.Patch("/groups/:id", func(c *fiber.Ctx) error {
+		var req struct {
+			Status *string `json:"status"` // "pending", "reviewed" or "resolved"
+			Note   *string `json:"note"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		if req.Status != nil && !reporter.IsReviewStatus(*req.Status) {
+			return c.Status(400).SendString("status must be pending, reviewed or resolved")
+		}
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+
+		id := c.Params("id")
+		s.mu.Lock()
+		found := s.report != nil && hasGroup(*s.report, id)
+		s.mu.Unlock()
+		if !found {
+			return c.Status(404).SendString("No such group in the report")
+		}
+
+		// Fields left out of the request keep their value
+		review := s.cache.GroupReviews()[id]
+		status, note := review.Status, review.Note
+		if status == "" {
+			status = reporter.ReviewPending
+		}
+		if req.Status != nil {
+			status = *req.Status
+		}
+		if req.Note != nil {
+			note = *req.Note
+		}
+		updated, err := s.cache.SetGroupReview(id, status, note)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		log.Printf("📝 Group %s marked %s", id, status)
+		s.audit(c, db.AuditReview, id, strings.TrimSpace(status+" "+note))
+		return c.JSON(updated)
+	})
+}
+
+// hasGroup reports whether the report holds a group with the ID
+func hasGroup(report reporter.Report, id string) bool {
+	for _, g := range report.SizeGroups {
+		if g.ID == id {
+			return true
+		}
+	}
+	for _, groups := range [][]reporter.SimilarityGroup{report.SimilarGroups, report.VisualGroups} {
+		for _, g := range groups {
+			if g.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupOf returns the ID of the first group a file belongs to, or "" when it is in none
+func groupOf(report reporter.Report, path string) string {
+	for _, g := range report.SizeGroups {
+		if containsPath(g.Files, path) {
+			return g.ID
+		}
+	}
+	for _, groups := range [][]reporter.SimilarityGroup{report.SimilarGroups, report.VisualGroups} {
+		for _, g := range groups {
+			if containsPath(g.Files, path) {
+				return g.ID
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/vfs"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerHealthRoutes serves the probes of container orchestrators and load balancers. They
+// live outside the API, so they answer without the access token and reveal no paths.
+func (s *Server) registerHealthRoutes(app *fiber.App) {
+	// Liveness: the process is up and serving requests
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"status": "ok"})
+	})
+
+	// Readiness: the cache database is open and the saved scan directory is reachable. A
+	// fresh install waiting for the setup wizard is ready, so that the wizard can be reached.
+	app.Get("/ready", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		cacheOpen := s.cache != nil
+		dir := s.scanDir
+		setupRequired := s.config == nil || s.config.Directory == ""
+		s.mu.Unlock()
+
+		checks := fiber.Map{"cache": "ok", "directory": "ok"}
+		ready := true
+		if !cacheOpen {
+			checks["cache"], ready = "unavailable", false
+		}
+		if dir == "" {
+			checks["directory"] = "not configured"
+		} else if !vfs.IsRemote(dir) { // Remote stores are only checked when they are listed
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				checks["directory"], ready = "missing", false
+			}
+		}
+
+		status := fiber.StatusOK
+		if !ready {
+			status = fiber.StatusServiceUnavailable
+		}
+		return c.Status(status).JSON(fiber.Map{"ready": ready, "setup_required": setupRequired, "checks": checks})
+	})
+}
@@ -0,0 +1,184 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/scanner"
+	"archive-duplicate-finder/internal/similarity"
+	"archive-duplicate-finder/internal/vfs"
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxHookMatches caps how many existing files the pre-download hook and the pre-check list
+const maxHookMatches = 10
+
+// hookMatch is an existing file that the announced download would likely duplicate
+type hookMatch struct {
+	Name   string  `json:"name"`
+	Path   string  `json:"path"`
+	Size   int64   `json:"size"`
+	Score  float64 `json:"score"`  // Name similarity (0-100)
+	Reason string  `json:"reason"` // "same_hash", "same_name_and_size", "similar_name" or "same_size"
+}
+
+// hookRank orders the reasons of a match, strongest evidence first
+var hookRank = map[string]int{"same_hash": 0, "same_name_and_size": 1, "similar_name": 2, "same_size": 3}
+
+// registerHookRoutes adds endpoints meant to be called by other tools rather than the dashboard
+func (s *Server) registerHookRoutes(api fiber.Router) {
+	// Download managers (or *arr-style tools) ask before fetching a file whether the library
+	// already holds it. Only the last scan is consulted; nothing is read from disk.
+	api.Post("/hook/pre-download", func(c *fiber.Ctx) error {
+		var req struct {
+			Filename string `json:"filename"`
+			Size     int64  `json:"size"` // Expected size in bytes; 0 when unknown
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		name := filepath.Base(req.Filename)
+		if req.Filename == "" || name == "." || name == string(filepath.Separator) {
+			return c.Status(400).SendString("Filename is required")
+		}
+
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(503).SendString("No scan available yet")
+		}
+		s.mu.Unlock()
+
+		matches := s.libraryMatches(name, req.Size, "")
+		return c.JSON(fiber.Map{
+			"filename":         name,
+			"likely_duplicate": len(matches) > 0,
+			"matches":          matches,
+		})
+	})
+
+	// Pre-check for browser extensions and download managers: a name, size and/or SHA-256 are
+	// looked up in the last scan and in the hash cache, which answers for hashed files even
+	// before a scan ran in this session
+	api.Post("/check", func(c *fiber.Ctx) error {
+		var req struct {
+			Filename string `json:"filename"`
+			Size     int64  `json:"size"`   // 0 when unknown
+			SHA256   string `json:"sha256"` // Hex digest of the whole file; empty when unknown
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		name := ""
+		if req.Filename != "" {
+			name = filepath.Base(req.Filename)
+		}
+		if name == "." || name == string(filepath.Separator) || (name == "" && req.SHA256 == "") {
+			return c.Status(400).SendString("filename or sha256 is required")
+		}
+		if req.SHA256 != "" && !isSHA256(req.SHA256) {
+			return c.Status(400).SendString("sha256 must be 64 hexadecimal characters")
+		}
+
+		s.mu.Lock()
+		scanned := s.report != nil
+		s.mu.Unlock()
+		if !scanned && (req.SHA256 == "" || s.cache == nil) {
+			return c.Status(503).SendString("No scan available yet")
+		}
+
+		matches := s.libraryMatches(name, req.Size, req.SHA256)
+		exists := false
+		for _, m := range matches {
+			if m.Reason == "same_hash" || m.Reason == "same_name_and_size" {
+				exists = true
+			}
+		}
+		return c.JSON(fiber.Map{
+			"filename":         name,
+			"exists":           exists,
+			"likely_duplicate": len(matches) > 0,
+			"matches":          matches,
+		})
+	})
+}
+
+// isSHA256 reports whether s looks like a hex SHA-256 digest
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// libraryMatches lists the files of the library a file named name would likely duplicate, best
+// first: those of the last scan by name and size (0 when unknown) and, with a SHA-256, the
+// files of the hash cache holding the same bytes that are still in place
+func (s *Server) libraryMatches(name string, size int64, sha256 string) []hookMatch {
+	s.mu.Lock()
+	files := s.allFiles
+	opts := similarity.Options{Threshold: 70}
+	if s.config != nil {
+		opts.Threshold = s.config.ThresholdName
+		opts.Phonetic = s.config.Phonetic
+	}
+	s.mu.Unlock()
+
+	matches := []hookMatch{}
+	sameHash := make(map[string]bool)
+	if sha256 != "" && s.cache != nil {
+		for path, cachedSize := range s.cache.FilesWithHash(sha256) {
+			if info, err := vfs.Stat(path); err != nil || info.Size != cachedSize {
+				continue
+			}
+			sameHash[path] = true
+			score := 0.0
+			if name != "" {
+				score = similarity.CalculateNormalizedSimilarity(name, filepath.Base(path), opts)
+			}
+			matches = append(matches, hookMatch{Name: filepath.Base(path), Path: path, Size: cachedSize, Score: score, Reason: "same_hash"})
+		}
+	}
+
+	for _, f := range files {
+		if sameHash[f.Path] {
+			continue
+		}
+		score := 0.0
+		if name != "" {
+			score = similarity.CalculateNormalizedSimilarity(name, f.Name, opts)
+		}
+		sameSize := size > 0 && f.Size == size
+		similarName := name != "" && score >= float64(opts.Threshold) &&
+			!similarity.IsProbableSeries([]scanner.ArchiveFile{{Name: name}, {Name: f.Name}})
+
+		reason := ""
+		switch {
+		case sameSize && similarName:
+			reason = "same_name_and_size"
+		case similarName:
+			reason = "similar_name"
+		case sameSize:
+			reason = "same_size"
+		default:
+			continue
+		}
+		matches = append(matches, hookMatch{Name: f.Name, Path: f.Path, Size: f.Size, Score: score, Reason: reason})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if hookRank[matches[i].Reason] != hookRank[matches[j].Reason] {
+			return hookRank[matches[i].Reason] < hookRank[matches[j].Reason]
+		}
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Path < matches[j].Path
+	})
+	if len(matches) > maxHookMatches {
+		matches = matches[:maxHookMatches]
+	}
+	return matches
+}
@@ -0,0 +1,111 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/reporter"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerIgnoredRoutes lets groups marked as good be reviewed and brought back
+func (s *Server) registerIgnoredRoutes(api fiber.Router) {
+	api.Get("/ignored-groups", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(200).JSON(fiber.Map{"groups": []interface{}{}})
+		}
+		groups := s.cache.ListIgnoredGroups()
+		if groups == nil {
+			return c.Status(200).JSON(fiber.Map{"groups": []interface{}{}})
+		}
+		return c.Status(200).JSON(fiber.Map{"groups": groups})
+	})
+
+	// Un-ignore a group; it shows up again in the current report right away
+	api.Delete("/ignored-groups/:hash", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		hash := c.Params("hash")
+		var ignored db.IgnoredGroup
+		for _, g := range s.cache.ListIgnoredGroups() {
+			if g.Hash == hash {
+				ignored = g
+			}
+		}
+		if !s.cache.RemoveIgnoredGroup(hash) {
+			return c.Status(404).SendString("Group is not ignored")
+		}
+		log.Printf("👀 Group no longer ignored: %s", hash)
+		s.audit(c, db.AuditUnignore, hash, "")
+		members := make([]reporter.FileInfo, len(ignored.Files))
+		for i, p := range ignored.Files {
+			members[i] = reporter.FileInfo{Path: p}
+		}
+		if err := journal.AppendGroup(journal.ActionUnignore, journal.SourceDashboard, ignored.GroupID, hash, members); err != nil {
+			log.Printf("⚠️ Could not write the cleanup journal: %v", err)
+		}
+		return c.SendStatus(200)
+	})
+}
+
+// groupTarget names a group in the audit trail by its stable ID, or its member hash without one
+func groupTarget(id, hash string) string {
+	if id != "" {
+		return id
+	}
+	return hash
+}
+
+// ignoredMatcher returns a check for groups marked as good. A group stays hidden while it keeps
+// the stable ID it was ignored with, or while its members are the ignored ones or a subset of
+// them (copies deleted since); one whose ignore expired shows up again.
+func (s *Server) ignoredMatcher() func(id string, files []reporter.FileInfo) bool {
+	if s.cache == nil {
+		return func(string, []reporter.FileInfo) bool { return false }
+	}
+
+	var members []map[string]bool
+	byPath := make(map[string][]int)
+	ids := make(map[string]bool)
+	for _, g := range s.cache.ListIgnoredGroups() {
+		if g.Expired {
+			continue
+		}
+		if g.GroupID != "" {
+			ids[g.GroupID] = true
+		}
+		if len(g.Files) == 0 {
+			continue
+		}
+		set := make(map[string]bool, len(g.Files))
+		for _, p := range g.Files {
+			set[p] = true
+			byPath[p] = append(byPath[p], len(members))
+		}
+		members = append(members, set)
+	}
+
+	return func(id string, files []reporter.FileInfo) bool {
+		if len(files) == 0 {
+			return false
+		}
+		if ids[id] || s.cache.IsGroupIgnored(reporter.CalculateGroupHash(files), "") {
+			return true
+		}
+		for _, i := range byPath[files[0].Path] {
+			covered := true
+			for _, f := range files[1:] {
+				if !members[i][f.Path] {
+					covered = false
+					break
+				}
+			}
+			if covered {
+				return true
+			}
+		}
+		return false
+	}
+}
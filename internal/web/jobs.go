@@ -0,0 +1,156 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/jobs"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/vfs"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Kinds of jobs run by the dashboard
+const (
+	jobScan   = "scan"
+	jobStep3  = "step3"
+	jobVisual = "visual"
+)
+
+var errNoReport = errors.New("no report available: run a scan first")
+
+// startScan queues a full scan of cfg's directory
+func (s *Server) startScan(cfg *config.AppConfig) jobs.Job {
+	job, _ := s.jobs.Submit(jobScan, func(ctx context.Context) error {
+		return s.performFullScan(ctx, cfg)
+	})
+	return job
+}
+
+// StartScan queues a scan of the saved directory, as the dashboard's scan button does. It
+// reports false when no directory has been set up yet.
+func (s *Server) StartScan() bool {
+	s.mu.Lock()
+	cfg := s.config
+	s.mu.Unlock()
+	if cfg == nil || cfg.Directory == "" {
+		return false
+	}
+	s.startScan(cfg)
+	return true
+}
+
+// ScanFolder queues a scan of dir with the saved settings, without making it the configured
+// directory, as finder here does
+func (s *Server) ScanFolder(dir string) error {
+	cfg, err := s.scanConfig(dir)
+	if err != nil {
+		return err
+	}
+	s.startScan(cfg)
+	return nil
+}
+
+// scanConfig returns the settings of a scan of dir: the saved ones with dir as the directory,
+// or as they are when dir is empty
+func (s *Server) scanConfig(dir string) (*config.AppConfig, error) {
+	s.mu.Lock()
+	cfg := s.config
+	s.mu.Unlock()
+	if cfg == nil {
+		return nil, errors.New("No configuration set")
+	}
+	if dir == "" {
+		return cfg, nil
+	}
+	if !vfs.IsRemote(dir) {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("not a folder: %s", dir)
+		}
+	}
+	scoped := *cfg
+	scoped.Directory = dir
+	return &scoped, nil
+}
+
+// canceled ends an analysis stopped through its job, leaving the report in status
+func (s *Server) canceled(ctx context.Context, status string) error {
+	log.Printf("🛑 Analysis canceled")
+	s.mu.Lock()
+	s.updateReport(func(r *reporter.Report) { r.Status = status })
+	s.mu.Unlock()
+	return ctx.Err()
+}
+
+// registerJobRoutes lists the scans and analyses started from the dashboard and cancels them
+func (s *Server) registerJobRoutes(api fiber.Router) {
+	api.Get("/jobs", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"jobs": s.jobs.List()})
+	})
+
+	api.Get("/jobs/:id", func(c *fiber.Ctx) error {
+		job, ok := s.jobs.Get(c.Params("id"))
+		if !ok {
+			return c.Status(404).SendString(jobs.ErrNotFound.Error())
+		}
+		return c.JSON(job)
+	})
+
+	api.Delete("/jobs/:id", func(c *fiber.Ctx) error {
+		job, err := s.jobs.Cancel(c.Params("id"))
+		switch {
+		case errors.Is(err, jobs.ErrNotFound):
+			return c.Status(404).SendString(err.Error())
+		case errors.Is(err, jobs.ErrEnded):
+			return c.Status(409).SendString(err.Error())
+		}
+		// A visual analysis clears its checkpoint when it stops; one that never started cannot
+		if job.Kind == jobVisual && job.Started == nil && s.cache != nil {
+			s.mu.Lock()
+			dir := s.scanDir
+			s.mu.Unlock()
+			s.cache.ClearVisualCheckpoint(dir)
+		}
+		return c.JSON(job)
+	})
+
+	// Pausable jobs (the visual analysis) hold at their next checkpoint until resumed
+	api.Post("/jobs/:id/pause", func(c *fiber.Ctx) error {
+		return jobResponse(c, s.jobs.Pause)
+	})
+
+	api.Post("/jobs/:id/resume", func(c *fiber.Ctx) error {
+		return jobResponse(c, s.jobs.Resume)
+	})
+
+	// State of an unfinished visual analysis of the scan directory: files left, failures
+	api.Get("/visual/checkpoint", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(404).SendString("No visual analysis in progress")
+		}
+		s.mu.Lock()
+		dir := s.scanDir
+		s.mu.Unlock()
+		cp, ok := s.cache.VisualCheckpoint(dir)
+		if !ok {
+			return c.Status(404).SendString("No visual analysis in progress")
+		}
+		return c.JSON(cp)
+	})
+}
+
+// jobResponse applies a pause or resume to the job of the :id parameter
+func jobResponse(c *fiber.Ctx, apply func(id string) (jobs.Job, error)) error {
+	job, err := apply(c.Params("id"))
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		return c.Status(404).SendString(err.Error())
+	case err != nil:
+		return c.Status(409).SendString(err.Error())
+	}
+	return c.JSON(job)
+}
@@ -0,0 +1,226 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/reporter"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mcpProtocolVersions are the Model Context Protocol revisions the assistant endpoint speaks,
+// latest first
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// Pages of list_duplicate_groups
+const (
+	mcpDefaultLimit = 20
+	mcpMaxLimit     = 100
+)
+
+const mcpInstructions = "Tools to triage the duplicate archives found by the last scan. List the groups, " +
+	"inspect one to compare its copies (path, size, modification time), and suggest which copy to keep. " +
+	"Protected files are never removed. Resolving moves the other copies into the quarantine, from where " +
+	"they can be restored; it is only available when the library owner allowed it."
+
+// mcpTool is a tool offered to assistants
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+	Annotations map[string]any `json:"annotations"`
+
+	writes bool // Moves files: only offered with assistant_access "quarantine"
+	call   func(s *Server, c *fiber.Ctx, args json.RawMessage) (any, error)
+}
+
+// mcpGroupSummary is a group as list_duplicate_groups returns it, without its files
+type mcpGroupSummary struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Files       int    `json:"files"`
+	Protected   int    `json:"protected,omitempty"` // Files that are never removed
+	Reclaimable int64  `json:"reclaimable_bytes"`
+	Review      string `json:"review,omitempty"`
+	Note        string `json:"note,omitempty"`
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:  "list_duplicate_groups",
+		Title: "List duplicate groups",
+		Description: "Duplicate groups of the last scan, the ones freeing the most space first. kind narrows them to identical " +
+			"sizes (size), similar names (similar) or similar previews (visual). Returns the total and one page of groups. " +
+			"Series (numbered parts of one work) are not duplicates and are left out.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"kind":   map[string]any{"type": "string", "enum": []string{reporter.KindSize, reporter.KindSimilar, reporter.KindVisual}},
+			"offset": map[string]any{"type": "integer", "minimum": 0},
+			"limit":  map[string]any{"type": "integer", "minimum": 1, "maximum": mcpMaxLimit, "description": "Groups per page, 20 by default"},
+		}},
+		Annotations: map[string]any{"readOnlyHint": true},
+		call: func(s *Server, c *fiber.Ctx, args json.RawMessage) (any, error) {
+			var p struct {
+				Kind   string `json:"kind"`
+				Offset int    `json:"offset"`
+				Limit  int    `json:"limit"`
+			}
+			if err := decodeParams(args, &p); err != nil {
+				return nil, err
+			}
+			if p.Limit <= 0 {
+				p.Limit = mcpDefaultLimit
+			}
+			p.Limit = min(p.Limit, mcpMaxLimit)
+			report, err := s.rpcReport()
+			if err != nil {
+				return nil, err
+			}
+			groups := []mcpGroupSummary{}
+			for _, g := range rpcGroups(report) {
+				if g.Series || (p.Kind != "" && g.Kind != p.Kind) {
+					continue // Series are not duplicates and cannot be resolved
+				}
+				summary := mcpGroupSummary{ID: g.ID, Kind: g.Kind, Name: g.Name, Files: len(g.Files), Reclaimable: g.Reclaimable, Review: g.Review, Note: g.Note}
+				for _, f := range g.Files {
+					if f.Protected {
+						summary.Protected++
+					}
+				}
+				groups = append(groups, summary)
+			}
+			sort.SliceStable(groups, func(i, j int) bool { return groups[i].Reclaimable > groups[j].Reclaimable })
+			total := len(groups)
+			groups = groups[min(max(p.Offset, 0), total):]
+			groups = groups[:min(p.Limit, len(groups))]
+			return fiber.Map{"total": total, "groups": groups}, nil
+		},
+	},
+	{
+		Name:  "get_duplicate_group",
+		Title: "Get a duplicate group",
+		Description: "One duplicate group by id, with the path, size, modification time and similarity score of every copy. " +
+			"Protected copies are marked and are never removed.",
+		InputSchema: map[string]any{"type": "object", "required": []string{"id"}, "properties": map[string]any{
+			"id": map[string]any{"type": "string", "description": "Group id from list_duplicate_groups"},
+		}},
+		Annotations: map[string]any{"readOnlyHint": true},
+		call: func(s *Server, c *fiber.Ctx, args json.RawMessage) (any, error) {
+			return rpcMethods["groups.get"].call(s, c, args)
+		},
+	},
+	{
+		Name:  "resolve_duplicate_group",
+		Title: "Resolve a duplicate group",
+		Description: "Keep one copy of a group and move the others into the quarantine, from where they can be restored. " +
+			"Protected copies stay, and series cannot be resolved. Confirm the choice with the user first.",
+		InputSchema: map[string]any{"type": "object", "required": []string{"id", "keep"}, "properties": map[string]any{
+			"id":   map[string]any{"type": "string", "description": "Group id"},
+			"keep": map[string]any{"type": "string", "description": "Path of the copy to keep"},
+		}},
+		Annotations: map[string]any{"readOnlyHint": false, "destructiveHint": false, "idempotentHint": true},
+		writes:      true,
+		call: func(s *Server, c *fiber.Ctx, args json.RawMessage) (any, error) {
+			var p struct {
+				ID   string `json:"id"`
+				Keep string `json:"keep"`
+			}
+			if err := decodeParams(args, &p); err != nil {
+				return nil, err
+			}
+			params, _ := json.Marshal(map[string]string{"id": p.ID, "keep": p.Keep, "action": "quarantine"})
+			return rpcMethods["groups.resolve"].call(s, c, params)
+		},
+	},
+}
+
+// mcpMethods are the Model Context Protocol requests the assistant endpoint answers
+var mcpMethods = map[string]rpcMethod{
+	"initialize": {"Start a session", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(params, &p) // Unknown or missing versions get the latest
+		version := mcpProtocolVersions[0]
+		if slices.Contains(mcpProtocolVersions, p.ProtocolVersion) {
+			version = p.ProtocolVersion
+		}
+		return fiber.Map{
+			"protocolVersion": version,
+			"capabilities":    fiber.Map{"tools": fiber.Map{}},
+			"serverInfo":      fiber.Map{"name": "archive-duplicate-finder", "version": apiVersion},
+			"instructions":    mcpInstructions,
+		}, nil
+	}},
+	"notifications/initialized": {"Session started", func(s *Server, c *fiber.Ctx, _ json.RawMessage) (any, error) {
+		return nil, nil
+	}},
+	"ping": {"Check the connection", func(s *Server, c *fiber.Ctx, _ json.RawMessage) (any, error) {
+		return fiber.Map{}, nil
+	}},
+	"tools/list": {"Tools the assistant may call", func(s *Server, c *fiber.Ctx, _ json.RawMessage) (any, error) {
+		tools := []mcpTool{}
+		for _, t := range mcpTools {
+			if !t.writes || s.assistantWrites() {
+				tools = append(tools, t)
+			}
+		}
+		return fiber.Map{"tools": tools}, nil
+	}},
+	"tools/call": {"Call a tool", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "Invalid params: " + err.Error()}
+		}
+		i := slices.IndexFunc(mcpTools, func(t mcpTool) bool { return t.Name == p.Name })
+		if i < 0 {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "Unknown tool: " + p.Name}
+		}
+		tool := mcpTools[i]
+		if tool.writes && !s.assistantWrites() {
+			return mcpResult(nil, errors.New("resolving groups is disabled: the library owner can allow it with assistant_access \"quarantine\" in the settings file"))
+		}
+		return mcpResult(tool.call(s, c, p.Arguments))
+	}},
+}
+
+// registerMCPRoutes adds the assistant endpoint: the Model Context Protocol over HTTP (JSON
+// responses, no server-initiated messages), for LLM agents helping to triage duplicates. Its
+// tools only read the report unless assistant_access allows quarantining.
+func (s *Server) registerMCPRoutes(api fiber.Router) {
+	api.Post("/mcp", func(c *fiber.Ctx) error {
+		resp, ok := s.rpcCall(c, c.Body(), mcpMethods)
+		if !ok {
+			return c.SendStatus(202)
+		}
+		return c.JSON(resp)
+	})
+}
+
+// assistantWrites reports whether assistant_access lets the tools quarantine files
+func (s *Server) assistantWrites() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config != nil && s.config.AssistantAccess == config.AssistantQuarantine
+}
+
+// mcpResult turns the outcome of a tool into its MCP result. Failures of the tool, invalid
+// arguments included, are results flagged isError, which the assistant reads and can correct,
+// rather than protocol errors.
+func mcpResult(result any, err error) (any, error) {
+	if err != nil {
+		return fiber.Map{"content": []fiber.Map{{"type": "text", "text": err.Error()}}, "isError": true}, nil
+	}
+	text, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return fiber.Map{"content": []fiber.Map{{"type": "text", "text": string(text)}}, "structuredContent": result}, nil
+}
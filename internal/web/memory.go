@@ -0,0 +1,15 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/membudget"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerMemoryRoutes reports how much of the memory budget (memory_budget_mb) extractions,
+// previews and models take, and how often they had to wait or spill to disk
+func (s *Server) registerMemoryRoutes(api fiber.Router) {
+	api.Get("/memory", func(c *fiber.Ctx) error {
+		return c.JSON(membudget.Stats())
+	})
+}
@@ -0,0 +1,98 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/stl"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// modelRef points to an STL file, either loose on disk or inside an archive
+type modelRef struct {
+	Path         string `json:"path"`
+	InternalPath string `json:"internal_path"`
+}
+
+// modelSide is one half of a model comparison response
+type modelSide struct {
+	Path         string       `json:"path"`
+	InternalPath string       `json:"internal_path"`
+	Info         *stl.STLInfo `json:"info"`
+	Mesh         *stl.Mesh    `json:"mesh"`
+}
+
+// registerModelRoutes adds the 3D model comparison endpoints
+func (s *Server) registerModelRoutes(api fiber.Router) {
+	// Endpoint: POST /api/v1/compare-models
+	// Body: {"a": {"path", "internal_path"}, "b": {...}, "max_triangles": 20000}
+	api.Post("/compare-models", func(c *fiber.Ctx) error {
+		type compareRequest struct {
+			A            modelRef `json:"a"`
+			B            modelRef `json:"b"`
+			MaxTriangles int      `json:"max_triangles"`
+		}
+		var req compareRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		if req.A.Path == "" || req.B.Path == "" {
+			return c.Status(400).SendString("Both models need a path")
+		}
+
+		log.Printf("🔬 Comparing models: %s [%s] vs %s [%s]", req.A.Path, req.A.InternalPath, req.B.Path, req.B.InternalPath)
+
+		data1, err := s.loadModel(req.A)
+		if err != nil {
+			return c.Status(404).SendString(err.Error())
+		}
+		data2, err := s.loadModel(req.B)
+		if err != nil {
+			return c.Status(404).SendString(err.Error())
+		}
+
+		identical, diff := stl.CompareSTL(data1, data2)
+
+		sides := make([]modelSide, 2)
+		for i, item := range []struct {
+			ref  modelRef
+			data []byte
+		}{{req.A, data1}, {req.B, data2}} {
+			info, err := stl.ParseInfo(item.data)
+			if err != nil {
+				return c.Status(422).SendString(fmt.Sprintf("%s: %v", item.ref.Path, err))
+			}
+			mesh, err := stl.ParseMesh(item.data, req.MaxTriangles)
+			if err != nil {
+				return c.Status(422).SendString(fmt.Sprintf("%s: %v", item.ref.Path, err))
+			}
+			sides[i] = modelSide{Path: item.ref.Path, InternalPath: item.ref.InternalPath, Info: info, Mesh: mesh}
+		}
+
+		return c.Status(200).JSON(fiber.Map{
+			"identical": identical,
+			"diff":      diff,
+			"a":         sides[0],
+			"b":         sides[1],
+		})
+	})
+}
+
+// loadModel reads an STL either directly from disk or from inside an archive
+func (s *Server) loadModel(ref modelRef) ([]byte, error) {
+	if ref.InternalPath == "" {
+		if !stl.IsSTLFile(ref.Path) {
+			return nil, fmt.Errorf("internal_path is required for archives")
+		}
+		return os.ReadFile(ref.Path)
+	}
+	if !stl.IsSTLFile(ref.InternalPath) {
+		return nil, fmt.Errorf("only STL models can be compared: %s", ref.InternalPath)
+	}
+
+	s.previewSem <- struct{}{}
+	defer func() { <-s.previewSem }()
+	return archive.GetFileFromArchive(ref.Path, ref.InternalPath)
+}
@@ -0,0 +1,592 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/estimate"
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/jobs"
+	"archive-duplicate-finder/internal/membudget"
+	"archive-duplicate-finder/internal/organize"
+	"archive-duplicate-finder/internal/quarantine"
+	"archive-duplicate-finder/internal/reporter"
+	"cmp"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiVersion is the version of the REST API; its endpoints live under apiPrefix
+const (
+	apiVersion = "1.0.0"
+	apiPrefix  = "/api/v1"
+)
+
+// apiParam is a query parameter of an endpoint
+type apiParam struct {
+	Name        string
+	Description string
+	Required    bool
+	Type        string // "string" (default), "integer" or "boolean"
+}
+
+// apiOperation documents an endpoint for the OpenAPI document. Request and response shapes are
+// Go values whose types are turned into JSON schemas, so they follow the json tags of the
+// structs the handlers use.
+type apiOperation struct {
+	Method   string
+	Path     string // Relative to apiPrefix, Fiber syntax (":hash")
+	Tag      string
+	Summary  string
+	Query    []apiParam
+	Body     any    // Request body; nil when there is none
+	Response any    // JSON response; nil for an empty 200
+	Status   int    // Success status; 200 unless set
+	Produces string // Content type of a non-JSON response ("image/*", "text/plain")
+	Public   bool   // Reachable without the access token
+}
+
+var (
+	pathParam     = apiParam{Name: "path", Description: "Path of the archive", Required: true}
+	internalParam = apiParam{Name: "internal_path", Description: "Path of an entry inside the archive"}
+	confirmParam  = apiParam{Name: "confirm", Description: "1 starts the analysis even when it is projected to run longer than confirm_above_minutes"}
+)
+
+// apiOperations is the documentation of every endpoint, in the order the OpenAPI document lists them
+var apiOperations = []apiOperation{
+	// Analysis
+	{Method: "GET", Path: "/report", Tag: "analysis", Summary: "Current report, filtered by ignored groups, suppressions and protection. Without a scan: {status: idle, setup_required}. " +
+		"Carries an ETag; a request with a matching If-None-Match gets 304 Not Modified.",
+		Query: []apiParam{{Name: "exclude_similar", Description: "true leaves out similar_groups", Type: "boolean"},
+			{Name: "review", Description: "Only groups with this review status: pending (including groups never reviewed), reviewed or resolved"},
+			{Name: "dir", Description: "Only groups with a file in this folder, at any depth"},
+			{Name: "group_by", Description: "dir adds by_dir: the duplicates rolled up per directory, subdirectories included, most redundant bytes first"}}, Response: reporter.Report{}},
+	{Method: "GET", Path: "/stats", Tag: "analysis", Summary: "Totals of the current report, broken down by file type, with its largest files and duplicate groups and the change since the previous run", Response: struct {
+		TotalFiles     int                      `json:"totalFiles"`
+		Duplicates     int                      `json:"duplicates"`
+		Similar        int                      `json:"similar"`
+		Duration       float64                  `json:"duration"`
+		ClusterMetrics *reporter.ClusterMetrics `json:"clusterMetrics"` // Quality of the Step 3 clusters and the suggested threshold, once Step 3 ran
+		ByType         []reporter.TypeStats     `json:"byType"`         // Per extension, most bytes first
+		LargestFiles   []reporter.FileInfo      `json:"largestFiles"`   // The 20 largest scanned files
+		LargestGroups  []reporter.GroupStats    `json:"largestGroups"`  // The 20 duplicate groups freeing the most bytes
+		Trend          *struct {
+			Current  db.Run           `json:"current"`
+			Previous db.Run           `json:"previous"`
+			Delta    reporter.Summary `json:"delta"` // Current minus previous
+		} `json:"trend,omitempty"` // Once the scan history holds two runs of the directory
+	}{}},
+	{Method: "GET", Path: "/all-files", Tag: "analysis", Summary: "Every scanned archive", Response: struct {
+		Files []reporter.FileInfo `json:"files"`
+		Total int                 `json:"total"`
+	}{}},
+	{Method: "GET", Path: "/tree", Tag: "analysis", Summary: "Scanned directory as nested folders with their size, file and archive counts and the bytes the cleanup plan frees, largest first, for treemaps",
+		Query: []apiParam{{Name: "depth", Description: "Fold folders deeper than this many levels into their parent (0 keeps them all)", Type: "integer"}}, Response: reporter.SizeNode{}},
+	{Method: "GET", Path: "/search", Tag: "analysis", Summary: "Scanned files whose name, normalized name or (with contents) archive entries match a query, with the groups they belong to; 503 before the first scan",
+		Query: []apiParam{
+			{Name: "q", Description: "Text to look for, case-insensitive", Required: true},
+			{Name: "contents", Description: "Also search the entries of archives read by a -contents scan", Type: "boolean"},
+			{Name: "limit", Description: "Most matches returned (default 100)", Type: "integer"},
+		}, Response: struct {
+			Query   string        `json:"query"`
+			Total   int           `json:"total"` // Matches before the limit
+			Matches []searchMatch `json:"matches"`
+		}{}},
+	{Method: "POST", Path: "/start-scan", Tag: "analysis", Summary: "Queue a full scan of the configured directory, or of another folder with the saved settings",
+		Query: []apiParam{{Name: "dir", Description: "Folder to scan instead of the configured directory, which stays unchanged (finder here)"}}, Response: jobs.Job{}, Status: 202},
+	{Method: "POST", Path: "/run-step-3", Tag: "analysis", Summary: "Queue the similar-name analysis; 409 with the estimate when it would run too long",
+		Query: []apiParam{confirmParam}, Response: jobs.Job{}, Status: 202},
+	{Method: "POST", Path: "/run-visual", Tag: "analysis", Summary: "Queue the visual analysis; 409 with the estimate when it would run too long",
+		Query: []apiParam{confirmParam}, Response: jobs.Job{}, Status: 202},
+	{Method: "GET", Path: "/jobs", Tag: "analysis", Summary: "Queued, running and recently ended scans and analyses, newest first", Response: struct {
+		Jobs []jobs.Job `json:"jobs"`
+	}{}},
+	{Method: "GET", Path: "/jobs/:id", Tag: "analysis", Summary: "One job", Response: jobs.Job{}},
+	{Method: "DELETE", Path: "/jobs/:id", Tag: "analysis", Summary: "Cancel a queued or running job; 409 when it has already ended", Response: jobs.Job{}},
+	{Method: "POST", Path: "/jobs/:id/pause", Tag: "analysis", Summary: "Pause a pausable job (the visual analysis) at its next checkpoint; 409 when it cannot be paused or has ended", Response: jobs.Job{}},
+	{Method: "POST", Path: "/jobs/:id/resume", Tag: "analysis", Summary: "Resume a paused job; 409 when it has ended", Response: jobs.Job{}},
+	{Method: "GET", Path: "/visual/checkpoint", Tag: "analysis", Summary: "Files left and failures of an unfinished visual analysis of the scan directory, resumed after a restart; 404 when there is none", Response: db.VisualCheckpoint{}},
+	{Method: "POST", Path: "/reset", Tag: "analysis", Summary: "Forget the current report"},
+	{Method: "GET", Path: "/estimate", Tag: "analysis", Summary: "Projected duration of the on-demand analyses", Response: struct {
+		Estimate            estimate.Estimate `json:"estimate"`
+		ConfirmAboveSeconds float64           `json:"confirm_above_seconds"`
+	}{}},
+
+	// Files
+	{Method: "POST", Path: "/delete", Tag: "files", Summary: "Delete a file, or move it to the trash folder; the volumes of a split set go together or not at all; 409 when verify_before_delete finds it changed since the scan or a pre_delete hook vetoes it", Body: struct {
+		Path string `json:"path"`
+		Kept string `json:"kept,omitempty"`
+	}{}},
+	{Method: "GET", Path: "/quarantine", Tag: "files", Summary: "Files held in the quarantine folder, most recent first; 400 when none is configured", Response: struct {
+		Dir   string            `json:"dir"`
+		Items []quarantine.Item `json:"items"`
+	}{}},
+	{Method: "POST", Path: "/quarantine", Tag: "files", Summary: "Move files into the quarantine, recorded in its manifest, all or none; 403 for protected files, 409 when a pre_delete hook vetoes one, 507 when its disk cannot take them all", Body: struct {
+		Paths []string `json:"paths"`
+		Kept  string   `json:"kept,omitempty"`
+	}{}, Response: struct {
+		Items []quarantine.Item      `json:"items"`
+		Error string                 `json:"error,omitempty"`
+		Space []*fsutil.NoSpaceError `json:"space,omitempty"`
+	}{}},
+	{Method: "POST", Path: "/quarantine/restore", Tag: "files", Summary: "Move quarantined files back to where they were; 409 when some could not be", Body: struct {
+		IDs []string `json:"ids"`
+	}{}, Response: struct {
+		Restored []quarantine.Item `json:"restored"`
+		Error    string            `json:"error,omitempty"`
+	}{}},
+	{Method: "POST", Path: "/quarantine/purge", Tag: "files", Summary: "Delete for good the files quarantined longer ago than older_than (30d, 2w, 12h)", Body: struct {
+		OlderThan string `json:"older_than"`
+	}{}, Response: struct {
+		Purged int    `json:"purged"`
+		Bytes  int64  `json:"bytes"`
+		Error  string `json:"error,omitempty"`
+	}{}},
+	{Method: "POST", Path: "/mark-as-good", Tag: "files", Summary: "Ignore a group of files in future reports", Body: struct {
+		Files   []reporter.FileInfo `json:"files"`
+		GroupID string              `json:"group_id,omitempty"`
+		TTLDays *int                `json:"ttl_days,omitempty"`
+	}{}},
+	{Method: "GET", Path: "/open", Tag: "files", Summary: "Reveal a file in the file manager or open it with its application",
+		Query: []apiParam{{Name: "path", Required: true}, {Name: "mode", Description: "reveal (default) or launch"}}},
+	{Method: "POST", Path: "/open-directory", Tag: "files", Summary: "Open a folder in the file manager", Query: []apiParam{{Name: "path", Description: "Defaults to the scan directory"}}},
+	{Method: "POST", Path: "/organize", Tag: "files", Summary: "Move the keeper of each group into a library layout and remove or link the other copies; 507 when the library or trash disk cannot take the copies", Body: struct {
+		Root   string `json:"root"`
+		Layout string `json:"layout"`
+		Rest   string `json:"rest"`
+		DryRun bool   `json:"dry_run"`
+	}{}, Response: struct {
+		DryRun  bool                   `json:"dry_run"`
+		Rest    string                 `json:"rest"`
+		Results []organize.Result      `json:"results"`
+		Space   []*fsutil.NoSpaceError `json:"space,omitempty"`
+	}{}},
+	{Method: "GET", Path: "/rename-suggestions", Tag: "files", Summary: "Canonical names for the variants of similar-name clusters", Response: []organize.Rename{}},
+	{Method: "POST", Path: "/rename", Tag: "files", Summary: "Apply rename suggestions (all of them when paths is empty)", Body: struct {
+		Paths  []string `json:"paths"`
+		DryRun bool     `json:"dry_run"`
+	}{}, Response: []organize.Rename{}},
+	{Method: "GET", Path: "/export/script", Tag: "files", Summary: "Cleanup plan as a shell or PowerShell script",
+		Query: []apiParam{{Name: "format", Description: "sh (default) or ps1"}}, Produces: "text/plain"},
+	{Method: "GET", Path: "/export/excludes", Tag: "files", Summary: "Files to remove as an rsync exclude or rclone filter list, anchored to the scan directory",
+		Query: []apiParam{{Name: "format", Description: "rsync (default, for --exclude-from) or rclone (for --filter-from)"}}, Produces: "text/plain"},
+	{Method: "GET", Path: "/export/json", Tag: "files", Summary: "Report whose files point at their previews",
+		Query: []apiParam{{Name: "thumbs", Description: "1 embeds a base64 JPEG thumbnail of each preview", Type: "boolean"}}, Response: reporter.Report{}},
+	{Method: "GET", Path: "/export", Tag: "files", Summary: "Report rendered and downloaded as a file; 400 for an unknown format, 404 before a scan",
+		Query: []apiParam{
+			{Name: "format", Description: "json (default), csv (one row per group member), pdf or html"},
+			{Name: "thumbs", Description: "1 draws a thumbnail of each preview (json, pdf and html)", Type: "boolean"},
+		}, Produces: "application/octet-stream"},
+
+	// Groups
+	{Method: "GET", Path: "/ignored-groups", Tag: "groups", Summary: "Groups marked as good", Response: struct {
+		Groups []db.IgnoredGroup `json:"groups"`
+	}{}},
+	{Method: "DELETE", Path: "/ignored-groups/:hash", Tag: "groups", Summary: "Bring an ignored group back"},
+	{Method: "PATCH", Path: "/groups/:id", Tag: "groups", Summary: "Set the review status and note of a group of the report; fields left out keep their value", Body: struct {
+		Status string `json:"status,omitempty"` // pending, reviewed or resolved
+		Note   string `json:"note,omitempty"`
+	}{}, Response: db.GroupReview{}},
+	{Method: "GET", Path: "/audit", Tag: "groups", Summary: "Who deleted, ignored, suppressed or reviewed what, newest first",
+		Query: []apiParam{{Name: "actor", Description: "Only the actions of this client name"},
+			{Name: "action", Description: "delete, ignore, unignore, suppress, unsuppress or review"},
+			{Name: "limit", Description: "Number of entries (default 200, 0 for all)", Type: "integer"}},
+		Response: struct {
+			Entries []db.AuditEntry `json:"entries"`
+		}{}},
+	{Method: "GET", Path: "/operations", Tag: "groups", Summary: "Append-only log of every delete, trash, move, link, rename and ignore, newest first",
+		Query: []apiParam{{Name: "path", Description: "Only operations whose path before or after contains this"},
+			{Name: "action", Description: "delete, trash, move, link, rename, ignore or unignore"},
+			{Name: "limit", Description: "Number of entries (default 200, 0 for all)", Type: "integer"}},
+		Response: struct {
+			Operations []db.Operation `json:"operations"`
+		}{}},
+	{Method: "POST", Path: "/identify", Tag: "groups", Summary: "Name the session in the audit trail (an empty name forgets it); API clients can send X-Client-Name instead", Body: struct {
+		Name string `json:"name"`
+	}{}},
+	{Method: "POST", Path: "/suppress", Tag: "groups", Summary: "Never report a content hash again", Body: struct {
+		Files []reporter.FileInfo `json:"files"`
+		Note  string              `json:"note"`
+	}{}, Response: struct {
+		Hash string `json:"hash"`
+	}{}},
+	{Method: "GET", Path: "/suppressed", Tag: "groups", Summary: "Suppressed content hashes", Response: struct {
+		Hashes []db.SuppressedHash `json:"hashes"`
+	}{}},
+	{Method: "DELETE", Path: "/suppressed/:hash", Tag: "groups", Summary: "Lift a content hash suppression"},
+	{Method: "GET", Path: "/protected", Tag: "groups", Summary: "Protected files, folders and globs", Response: struct {
+		Patterns    []string `json:"patterns"`
+		CommandLine []string `json:"command_line"`
+	}{}},
+	{Method: "POST", Path: "/protected", Tag: "groups", Summary: "Protect a file, folder or glob", Body: struct {
+		Pattern string `json:"pattern"`
+	}{}},
+	{Method: "DELETE", Path: "/protected", Tag: "groups", Summary: "Stop protecting a pattern", Query: []apiParam{{Name: "pattern", Required: true}}},
+
+	// Archives
+	{Method: "GET", Path: "/preview", Tag: "archives", Summary: "Preview image of an archive, or one of its entries",
+		Query: []apiParam{pathParam, internalParam, {Name: "type", Description: "model renders an STL entry"}}, Produces: "image/*"},
+	{Method: "GET", Path: "/list-previews", Tag: "archives", Summary: "Image entries of an archive", Query: []apiParam{pathParam}, Response: struct {
+		Previews []archive.PreviewInfo `json:"previews"`
+	}{}},
+	{Method: "GET", Path: "/preview/ranking", Tag: "archives", Summary: "Ranked preview candidates and the preview in use", Query: []apiParam{pathParam}, Response: struct {
+		Path       string                     `json:"path"`
+		Selected   string                     `json:"selected"`
+		Override   string                     `json:"override"`
+		Candidates []archive.PreviewCandidate `json:"candidates"`
+	}{}},
+	{Method: "PUT", Path: "/preview/override", Tag: "archives", Summary: "Pin the preview of an archive to one of its entries", Body: struct {
+		Path         string `json:"path"`
+		InternalPath string `json:"internal_path"`
+	}{}},
+	{Method: "DELETE", Path: "/preview/override", Tag: "archives", Summary: "Return an archive to the automatic preview", Query: []apiParam{pathParam}},
+	{Method: "GET", Path: "/preview-text", Tag: "archives", Summary: "Beginning of a README or other text file inside an archive",
+		Query: []apiParam{pathParam, internalParam, {Name: "max_kb", Description: "How much to read, up to 256 KB", Type: "integer"}}, Response: archive.TextPreview{}},
+	{Method: "GET", Path: "/archive-contents", Tag: "archives", Summary: "Contents of an archive as a folder tree, diffed against a second archive when compare is set",
+		Query: []apiParam{pathParam, {Name: "compare", Description: "Path of an archive to compare with"}}, Response: struct {
+			Path        string            `json:"path"`
+			Tree        *archive.TreeNode `json:"tree"`
+			Compare     string            `json:"compare,omitempty"`
+			CompareTree *archive.TreeNode `json:"compare_tree,omitempty"`
+		}{}},
+	{Method: "POST", Path: "/extract", Tag: "archives", Summary: "Extract entries or folders of an archive into a local folder", Body: struct {
+		Path          string   `json:"path"`
+		InternalPaths []string `json:"internal_paths"`
+		Dest          string   `json:"dest"`
+	}{}, Response: struct {
+		Extracted int                 `json:"extracted"`
+		Results   []archive.Extracted `json:"results"`
+		Error     string              `json:"error,omitempty"`
+	}{}},
+	{Method: "POST", Path: "/compare-models", Tag: "archives", Summary: "Compare two STL models and return their meshes", Body: struct {
+		A            modelRef `json:"a"`
+		B            modelRef `json:"b"`
+		MaxTriangles int      `json:"max_triangles"`
+	}{}, Response: struct {
+		Identical bool      `json:"identical"`
+		Diff      string    `json:"diff"`
+		A         modelSide `json:"a"`
+		B         modelSide `json:"b"`
+	}{}},
+
+	// Integrations
+	{Method: "POST", Path: "/hook/pre-download", Tag: "integrations", Summary: "Ask whether a file about to be downloaded is already in the library", Body: struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+	}{}, Response: struct {
+		Filename        string      `json:"filename"`
+		LikelyDuplicate bool        `json:"likely_duplicate"`
+		Matches         []hookMatch `json:"matches"`
+	}{}},
+	{Method: "POST", Path: "/check", Tag: "integrations", Summary: "Ask whether a file is already in the library by name, size and/or SHA-256, looking up the last scan and the hash cache; 503 when neither can answer", Body: struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+		SHA256   string `json:"sha256"`
+	}{}, Response: struct {
+		Filename        string      `json:"filename"`
+		Exists          bool        `json:"exists"` // A match holds the same bytes, or the same name and size
+		LikelyDuplicate bool        `json:"likely_duplicate"`
+		Matches         []hookMatch `json:"matches"`
+	}{}},
+	{Method: "POST", Path: "/rpc", Tag: "integrations", Summary: "JSON-RPC 2.0 machine API, single calls or batches: scan.start, analysis.start, jobs.*, report.get, groups.list, groups.get, groups.resolve (rpc.methods lists them); operation errors carry the HTTP status as code",
+		Body: rpcRequest{}, Response: rpcResponse{}},
+	{Method: "GET", Path: "/rpc/events", Tag: "integrations", Summary: "Server-sent JSON-RPC notifications: job.progress when a job changes, report.changed when a report snapshot is published", Produces: "text/event-stream"},
+	{Method: "POST", Path: "/mcp", Tag: "integrations", Summary: "Model Context Protocol endpoint for assistants: tools list_duplicate_groups, get_duplicate_group and, when assistant_access is quarantine, resolve_duplicate_group; 202 for notifications",
+		Body: rpcRequest{}, Response: rpcResponse{}},
+
+	// Cache
+	{Method: "GET", Path: "/cache/stats", Tag: "cache", Summary: "Size and contents of the cache", Response: db.CacheStats{}},
+	{Method: "POST", Path: "/cache/gc", Tag: "cache", Summary: "Drop the entries of files that no longer exist", Response: db.GCResult{}},
+	{Method: "DELETE", Path: "/cache/roots", Tag: "cache", Summary: "Drop every entry of a scan root", Query: []apiParam{{Name: "root", Required: true}}, Response: struct {
+		Removed int64 `json:"removed"`
+	}{}},
+	{Method: "GET", Path: "/cache/export", Tag: "cache", Summary: "Hashes, previews and ignored groups as a JSON download", Produces: "application/json"},
+	{Method: "POST", Path: "/cache/import", Tag: "cache", Summary: "Merge a cache export; map=from=to rewrites path prefixes",
+		Query: []apiParam{{Name: "map", Description: "Path prefix rewrite, from=to (repeatable)"}}, Body: map[string]any{}, Response: db.ImportStats{}},
+
+	// Settings
+	{Method: "GET", Path: "/config", Tag: "settings", Summary: "Active configuration (null before setup)", Response: config.AppConfig{}},
+	{Method: "POST", Path: "/config", Tag: "settings", Summary: "Replace the configuration; the access token, preview_converters, hooks and assistant_access keep their saved values", Body: config.AppConfig{}},
+	{Method: "GET", Path: "/setup", Tag: "settings", Summary: "State of the first-run wizard", Response: setupStatus{}},
+	{Method: "POST", Path: "/setup", Tag: "settings", Summary: "Answer the current wizard step", Body: setupRequest{}, Response: setupStatus{}},
+	{Method: "POST", Path: "/login", Tag: "settings", Summary: "Exchange the access token for a session cookie; the name is recorded with the actions of the session", Body: struct {
+		Token string `json:"token"`
+		Name  string `json:"name,omitempty"`
+	}{}, Public: true},
+	{Method: "POST", Path: "/logout", Tag: "settings", Summary: "Clear the session cookie"},
+	{Method: "GET", Path: "/memory", Tag: "settings", Summary: "Memory budget of extractions, decoded previews and parsed models: in use, peak, waits and spills to disk", Response: membudget.Usage{}},
+	{Method: "GET", Path: "/openapi.json", Tag: "settings", Summary: "This document", Produces: "application/json", Public: true},
+	{Method: "GET", Path: "/swagger.json", Tag: "settings", Summary: "Alias of openapi.json", Produces: "application/json", Public: true},
+}
+
+// setupStatus documents the wizard state returned by setupView
+type setupStatus struct {
+	Required    bool             `json:"required"`
+	Step        string           `json:"step"`
+	Steps       []string         `json:"steps"`
+	Draft       config.AppConfig `json:"draft"`
+	AuthEnabled bool             `json:"auth_enabled"`
+}
+
+// registerOpenAPIRoutes serves the OpenAPI document of the API. It is built from the routes
+// actually registered, so an endpoint is never missing from it.
+func (s *Server) registerOpenAPIRoutes(api fiber.Router) {
+	serve := func(c *fiber.Ctx) error {
+		return c.JSON(openAPIDocument(c.App().GetRoutes(true)))
+	}
+	api.Get("/openapi.json", serve)
+	api.Get("/swagger.json", serve)
+}
+
+// openAPIDocument builds the OpenAPI 3 document of the routes registered under apiPrefix.
+// Routes missing from apiOperations are still listed, without schemas.
+func openAPIDocument(routes []fiber.Route) fiber.Map {
+	documented := make(map[string]apiOperation, len(apiOperations))
+	for _, op := range apiOperations {
+		documented[op.Method+" "+op.Path] = op
+	}
+
+	var ops []apiOperation
+	seen := make(map[string]bool)
+	for _, r := range routes {
+		if !strings.HasPrefix(r.Path, apiPrefix+"/") || r.Method == fiber.MethodHead || r.Method == fiber.MethodOptions {
+			continue
+		}
+		key := r.Method + " " + strings.TrimPrefix(r.Path, apiPrefix)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		op, ok := documented[key]
+		if !ok {
+			op = apiOperation{Method: r.Method, Path: strings.TrimPrefix(r.Path, apiPrefix), Tag: "other"}
+		}
+		ops = append(ops, op)
+	}
+	order := make(map[string]int, len(apiOperations))
+	for i, op := range apiOperations {
+		order[op.Method+" "+op.Path] = i
+	}
+	sort.SliceStable(ops, func(i, j int) bool {
+		oi, iok := order[ops[i].Method+" "+ops[i].Path]
+		oj, jok := order[ops[j].Method+" "+ops[j].Path]
+		if iok != jok {
+			return iok
+		}
+		return oi < oj
+	})
+
+	schemas := newSchemaBuilder()
+	paths := make(map[string]fiber.Map)
+	for _, op := range ops {
+		path, params := openAPIPath(op.Path)
+		for _, q := range op.Query {
+			params = append(params, fiber.Map{
+				"name":        q.Name,
+				"in":          "query",
+				"required":    q.Required,
+				"description": q.Description,
+				"schema":      fiber.Map{"type": cmp.Or(q.Type, "string")},
+			})
+		}
+
+		operation := fiber.Map{
+			"operationId": operationID(op),
+			"tags":        []string{op.Tag},
+			"summary":     op.Summary,
+			"responses":   operationResponses(op, schemas),
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Body != nil {
+			operation["requestBody"] = fiber.Map{
+				"required": true,
+				"content":  fiber.Map{"application/json": fiber.Map{"schema": schemas.schema(reflect.TypeOf(op.Body))}},
+			}
+		}
+		if op.Public {
+			operation["security"] = []fiber.Map{}
+		}
+
+		if paths[path] == nil {
+			paths[path] = fiber.Map{}
+		}
+		paths[path][strings.ToLower(op.Method)] = operation
+	}
+
+	return fiber.Map{
+		"openapi": "3.0.3",
+		"info": fiber.Map{
+			"title":       "Archive Duplicate Finder API",
+			"version":     apiVersion,
+			"description": "REST API of the dashboard. Unversioned /api/... paths are deprecated aliases of /api/v1/....",
+		},
+		"servers": []fiber.Map{{"url": apiPrefix}},
+		"paths":   paths,
+		"components": fiber.Map{
+			"schemas": schemas.components,
+			"securitySchemes": fiber.Map{
+				"bearerAuth": fiber.Map{"type": "http", "scheme": "bearer"},
+				"cookieAuth": fiber.Map{"type": "apiKey", "in": "cookie", "name": authCookie},
+			},
+		},
+		// Only enforced when an access token is configured
+		"security": []fiber.Map{{"bearerAuth": []string{}}, {"cookieAuth": []string{}}},
+	}
+}
+
+// openAPIPath turns Fiber parameters (":hash") into OpenAPI ones ("{hash}")
+func openAPIPath(path string) (string, []fiber.Map) {
+	var params []fiber.Map
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			name := strings.TrimSuffix(seg[1:], "?")
+			segments[i] = "{" + name + "}"
+			params = append(params, fiber.Map{"name": name, "in": "path", "required": true, "schema": fiber.Map{"type": "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID is a stable name for code generators: "GET /cache/stats" becomes "getCacheStats"
+func operationID(op apiOperation) string {
+	id := strings.ToLower(op.Method)
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool { return r == '/' || r == '-' || r == '.' || r == ':' }) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
+
+func operationResponses(op apiOperation, schemas *schemaBuilder) fiber.Map {
+	status := op.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	success := fiber.Map{"description": "OK"}
+	switch {
+	case op.Produces != "":
+		success["content"] = fiber.Map{op.Produces: fiber.Map{"schema": fiber.Map{"type": "string", "format": "binary"}}}
+	case op.Response != nil:
+		success["content"] = fiber.Map{"application/json": fiber.Map{"schema": schemas.schema(reflect.TypeOf(op.Response))}}
+	}
+
+	responses := fiber.Map{
+		strconv.Itoa(status): success,
+		"400": fiber.Map{
+			"description": "Invalid request",
+			"content":     fiber.Map{"text/plain": fiber.Map{"schema": fiber.Map{"type": "string"}}},
+		},
+	}
+	if !op.Public {
+		responses["401"] = fiber.Map{"description": "Access token missing or wrong"}
+	}
+	return responses
+}
+
+// schemaBuilder turns Go types into JSON schemas. Named structs become shared components.
+type schemaBuilder struct {
+	components fiber.Map
+	names      map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: fiber.Map{}, names: make(map[reflect.Type]string)}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (b *schemaBuilder) schema(t reflect.Type) fiber.Map {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return fiber.Map{"type": "string", "format": "date-time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return fiber.Map{"type": "integer", "description": "nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return fiber.Map{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fiber.Map{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return fiber.Map{"type": "number"}
+	case reflect.String:
+		return fiber.Map{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return fiber.Map{"type": "string", "format": "byte"}
+		}
+		return fiber.Map{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return fiber.Map{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = b.componentName(t)
+			b.names[t] = name
+			b.components[name] = fiber.Map{} // Placeholder for recursive types
+			b.components[name] = b.object(t)
+		}
+		return fiber.Map{"$ref": "#/components/schemas/" + name}
+	}
+	return fiber.Map{}
+}
+
+// componentName is the type name, prefixed with its package when two packages share it
+func (b *schemaBuilder) componentName(t reflect.Type) string {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, taken := b.components[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+func (b *schemaBuilder) object(t reflect.Type) fiber.Map {
+	properties := fiber.Map{}
+	b.addFields(t, properties)
+	return fiber.Map{"type": "object", "properties": properties}
+}
+
+// addFields adds the JSON fields of a struct, flattening embedded structs like encoding/json
+func (b *schemaBuilder) addFields(t reflect.Type, properties fiber.Map) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(ft, properties)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = b.schema(f.Type)
+	}
+}
@@ -0,0 +1,141 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/organize"
+	"archive-duplicate-finder/internal/reporter"
+	"log"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerOrganizeRoutes adds the move-and-merge action (the kept file of every resolved group
+// is moved into a canonical library layout and the other copies are removed or linked) and the
+// rename suggestions for variants
+func (s *Server) registerOrganizeRoutes(api fiber.Router) {
+	api.Post("/organize", func(c *fiber.Ctx) error {
+		var req struct {
+			Root   string `json:"root"`
+			Layout string `json:"layout"`
+			Rest   string `json:"rest"` // "delete", "trash" or "link"; defaults to trash when a trash folder is configured
+			DryRun bool   `json:"dry_run"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(404).SendString("No report available")
+		}
+		deleteMode := ""
+		if s.config != nil {
+			deleteMode = s.config.DeleteMode
+		}
+		groups := reporter.ResolveGroups(s.filteredReport(), deleteMode)
+		opts := organize.Options{
+			Root:      req.Root,
+			Layout:    req.Layout,
+			Rest:      req.Rest,
+			TrashPath: s.trashPath,
+			DryRun:    req.DryRun,
+			Run:       journal.NewRun(),
+			Source:    journal.SourceDashboard,
+		}
+		s.mu.Unlock()
+
+		if opts.Rest == "" {
+			opts.Rest = organize.RestDelete
+			if opts.TrashPath != "" {
+				opts.Rest = organize.RestTrash
+			}
+		}
+		if err := opts.Validate(); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+
+		// A disk that cannot take the copies refuses the whole run; a dry run just reports it
+		short := organize.Preflight(groups, opts)
+		if len(short) > 0 && !req.DryRun {
+			return c.Status(507).JSON(fiber.Map{"error": short[0].Error(), "space": short})
+		}
+
+		// Hashing and moving can take a while: the report is only locked to apply the outcome
+		results := organize.Apply(groups, opts, s.cache)
+		if !req.DryRun {
+			log.Printf("🗂️ Dashboard Request: organized %d groups into %s", len(results), req.Root)
+			s.mu.Lock()
+			s.applyOrganized(results)
+			s.mu.Unlock()
+		}
+		resp := fiber.Map{"dry_run": req.DryRun, "rest": opts.Rest, "results": results}
+		if len(short) > 0 {
+			resp["space"] = short
+		}
+		return c.JSON(resp)
+	})
+
+	// Canonical names for the variants of similar-name clusters
+	api.Get("/rename-suggestions", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(404).SendString("No report available")
+		}
+		renames := organize.SuggestRenames(s.filteredReport().SimilarGroups)
+		s.mu.Unlock()
+		return c.JSON(organize.ApplyRenames(renames, true, "", ""))
+	})
+
+	// Applies the suggestions for the given paths (all of them when omitted)
+	api.Post("/rename", func(c *fiber.Ctx) error {
+		var req struct {
+			Paths  []string `json:"paths"`
+			DryRun bool     `json:"dry_run"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.report == nil {
+			return c.Status(404).SendString("No report available")
+		}
+		renames := organize.SuggestRenames(s.filteredReport().SimilarGroups)
+		if len(req.Paths) > 0 {
+			renames = slices.DeleteFunc(renames, func(r organize.Rename) bool { return !slices.Contains(req.Paths, r.Path) })
+		}
+
+		results := organize.ApplyRenames(renames, req.DryRun, journal.NewRun(), journal.SourceDashboard)
+		if !req.DryRun {
+			moved := make(map[string]string)
+			for _, r := range results {
+				if r.Error == "" {
+					moved[r.Path] = r.Dest
+				}
+			}
+			log.Printf("✏️ Dashboard Request: renamed %d files", len(moved))
+			s.relocateFiles(nil, moved)
+		}
+		return c.JSON(results)
+	})
+}
+
+// applyOrganized updates the report after a move-and-merge run: removed copies leave their
+// groups and moved files get their new location. The caller must hold s.mu.
+func (s *Server) applyOrganized(results []organize.Result) {
+	removed := make(map[string]bool)
+	moved := make(map[string]string)
+	for _, r := range results {
+		for _, p := range r.Removed {
+			removed[p] = true
+		}
+		if r.Dest != r.Kept {
+			moved[r.Kept] = r.Dest
+		}
+	}
+	s.relocateFiles(removed, moved)
+}
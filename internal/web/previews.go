@@ -0,0 +1,116 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxTextPreviewKB caps how much of a text file /api/v1/preview-text returns
+const maxTextPreviewKB = 256
+
+// registerPreviewRoutes exposes how the preview of an archive was chosen, lets users pick another
+// one and serves the README (or another text file) shown next to it
+func (s *Server) registerPreviewRoutes(api fiber.Router) {
+	// Endpoint: /api/v1/preview-text?path=...&internal_path=...&max_kb=...
+	// Without internal_path the most relevant text file of the archive is returned
+	api.Get("/preview-text", func(c *fiber.Ctx) error {
+		path := c.Query("path")
+		if path == "" {
+			return c.Status(400).SendString("Path is required")
+		}
+		limit := int64(archive.DefaultTextPreviewBytes)
+		if kb := c.QueryInt("max_kb"); kb > 0 {
+			limit = int64(min(kb, maxTextPreviewKB)) << 10
+		}
+
+		s.previewSem <- struct{}{}
+		preview, err := archive.ReadTextFromArchive(path, c.Query("internal_path"), limit)
+		<-s.previewSem
+		if err != nil {
+			return c.Status(404).SendString(err.Error())
+		}
+		return c.JSON(preview)
+	})
+
+	// Ranked image candidates with the reasons behind each score, plus the preview in use
+	api.Get("/preview/ranking", func(c *fiber.Ctx) error {
+		path := c.Query("path")
+		if path == "" {
+			return c.Status(400).SendString("Path is required")
+		}
+
+		s.previewSem <- struct{}{}
+		ranked, err := archive.RankPreviewsInArchive(path)
+		<-s.previewSem
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		if ranked == nil {
+			ranked = []archive.PreviewCandidate{}
+		}
+
+		selected, override := "", ""
+		if len(ranked) > 0 {
+			selected = ranked[0].Path
+		}
+		if s.cache != nil {
+			if o, ok := s.cache.GetPreviewOverride(path); ok {
+				selected, override = o, o
+			}
+		}
+		return c.JSON(fiber.Map{
+			"path":       path,
+			"selected":   selected,
+			"override":   override,
+			"candidates": ranked,
+		})
+	})
+
+	// Pin the preview of an archive to one of its entries
+	api.Put("/preview/override", func(c *fiber.Ctx) error {
+		var req struct {
+			Path         string `json:"path"`
+			InternalPath string `json:"internal_path"`
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		if req.Path == "" || req.InternalPath == "" {
+			return c.Status(400).SendString("path and internal_path are required")
+		}
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+
+		previews, err := archive.ListPreviewsInArchive(req.Path)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		known := false
+		for _, p := range previews {
+			known = known || p.Path == req.InternalPath
+		}
+		if !known {
+			return c.Status(400).SendString("internal_path is not a previewable entry of the archive")
+		}
+
+		log.Printf("🖼️  Preview override: %s -> %s", req.Path, req.InternalPath)
+		s.cache.PutPreviewOverride(req.Path, req.InternalPath)
+		return c.SendStatus(200)
+	})
+
+	// Return an archive to the automatic choice
+	api.Delete("/preview/override", func(c *fiber.Ctx) error {
+		path := c.Query("path")
+		if path == "" {
+			return c.Status(400).SendString("Path is required")
+		}
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		s.cache.RemovePreviewOverride(path)
+		return c.SendStatus(200)
+	})
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/protect"
+	"log"
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetExtraProtection adds protection patterns given on the command line to the configured ones
+func (s *Server) SetExtraProtection(patterns []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.protectFlags = patterns
+	s.rebuildProtection()
+}
+
+// rebuildProtection compiles the configured and command-line patterns. The caller must hold s.mu
+// (or own the server exclusively).
+func (s *Server) rebuildProtection() {
+	var patterns []string
+	if s.config != nil {
+		patterns = append(patterns, s.config.Protected...)
+	}
+	patterns = append(patterns, s.protectFlags...)
+	rules, err := protect.New(patterns)
+	if err != nil {
+		log.Printf("⚠️ Ignoring protection patterns: %v", err)
+		rules = nil
+	}
+	s.protected = rules
+}
+
+// registerProtectionRoutes manages the files and folders that are never suggested for deletion
+func (s *Server) registerProtectionRoutes(api fiber.Router) {
+	api.Get("/protected", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		patterns := []string{}
+		if s.config != nil {
+			patterns = append(patterns, s.config.Protected...)
+		}
+		return c.JSON(fiber.Map{
+			"patterns":     patterns,
+			"command_line": append([]string{}, s.protectFlags...), // Read-only, from -protect
+		})
+	})
+
+	// Protect a file, a folder or a glob; it is saved with the settings
+	api.Post("/protected", func(c *fiber.Ctx) error {
+		var req struct {
+			Pattern string `json:"pattern"`
+		}
+		if err := c.BodyParser(&req); err != nil || req.Pattern == "" {
+			return c.Status(400).SendString("pattern is required")
+		}
+		if _, err := protect.New([]string{req.Pattern}); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+
+		s.mu.Lock()
+		if s.config == nil {
+			s.mu.Unlock()
+			return c.Status(400).SendString("No configuration set")
+		}
+		cfg := *s.config
+		s.mu.Unlock()
+		if !slices.Contains(cfg.Protected, req.Pattern) {
+			cfg.Protected = append(slices.Clone(cfg.Protected), req.Pattern)
+		}
+
+		log.Printf("🛡️ Protecting %s", req.Pattern)
+		if err := s.applyConfig(&cfg); err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return c.SendStatus(200)
+	})
+
+	api.Delete("/protected", func(c *fiber.Ctx) error {
+		pattern := c.Query("pattern")
+		s.mu.Lock()
+		if s.config == nil {
+			s.mu.Unlock()
+			return c.Status(400).SendString("No configuration set")
+		}
+		cfg := *s.config
+		s.mu.Unlock()
+		i := slices.Index(cfg.Protected, pattern)
+		if i < 0 {
+			return c.Status(404).SendString("Pattern is not protected")
+		}
+		cfg.Protected = slices.Delete(slices.Clone(cfg.Protected), i, i+1)
+
+		log.Printf("🛡️ No longer protecting %s", pattern)
+		if err := s.applyConfig(&cfg); err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		return c.SendStatus(200)
+	})
+}
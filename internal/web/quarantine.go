@@ -0,0 +1,269 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/quarantine"
+	"archive-duplicate-finder/internal/txn"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerQuarantineRoutes adds the two-phase cleanup: files are quarantined with a manifest,
+// then restored or purged for good, apart from the trash of /delete
+func (s *Server) registerQuarantineRoutes(api fiber.Router) {
+	api.Get("/quarantine", func(c *fiber.Ctx) error {
+		dir := s.quarantineDir()
+		if dir == "" {
+			return c.Status(400).SendString("No quarantine folder configured (quarantine_path)")
+		}
+		items, err := quarantine.List(dir)
+		if err != nil {
+			return c.Status(500).SendString(err.Error())
+		}
+		if items == nil {
+			items = []quarantine.Item{}
+		}
+		return c.JSON(fiber.Map{"dir": dir, "items": items})
+	})
+
+	// Quarantine files of the report (multi-volume sets as a whole); protected files are refused
+	api.Post("/quarantine", func(c *fiber.Ctx) error {
+		var req struct {
+			Paths []string `json:"paths"`
+			Kept  string   `json:"kept,omitempty"` // Copy kept in their place; defaults to another file of their group
+		}
+		if err := c.BodyParser(&req); err != nil || len(req.Paths) == 0 {
+			return c.Status(400).SendString("paths is required")
+		}
+		dir := s.quarantineDir()
+		if dir == "" {
+			return c.Status(400).SendString("No quarantine folder configured (quarantine_path)")
+		}
+
+		items, err := s.quarantineArchives(c, dir, req.Paths, req.Kept)
+		if err == nil {
+			return c.JSON(fiber.Map{"items": items})
+		}
+		var ae *actionError
+		if errors.As(err, &ae) && ae.status == 403 {
+			return c.Status(403).SendString(err.Error())
+		}
+		resp := fiber.Map{"items": []quarantine.Item{}, "error": err.Error()}
+		if ae != nil && ae.space != nil {
+			resp["space"] = ae.space
+		}
+		return c.Status(statusOf(err)).JSON(resp)
+	})
+
+	// Move quarantined files back; they show up again with the next scan
+	api.Post("/quarantine/restore", func(c *fiber.Ctx) error {
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		if err := c.BodyParser(&req); err != nil || len(req.IDs) == 0 {
+			return c.Status(400).SendString("ids is required")
+		}
+		dir := s.quarantineDir()
+		if dir == "" {
+			return c.Status(400).SendString("No quarantine folder configured (quarantine_path)")
+		}
+
+		restored, err := quarantine.Restore(dir, req.IDs)
+		for _, item := range restored {
+			log.Printf("♻️ Restored from quarantine: %s", item.Original)
+			s.audit(c, db.AuditRestore, item.Original, "restored from: "+item.Path)
+			s.journalQuarantine(journal.ActionRestore, item)
+		}
+		if restored == nil {
+			restored = []quarantine.Item{}
+		}
+		resp := fiber.Map{"restored": restored}
+		if err != nil {
+			resp["error"] = err.Error()
+			return c.Status(409).JSON(resp)
+		}
+		return c.JSON(resp)
+	})
+
+	// Delete for good what was quarantined longer ago than older_than ("30d", "2w", "12h")
+	api.Post("/quarantine/purge", func(c *fiber.Ctx) error {
+		var req struct {
+			OlderThan string `json:"older_than"`
+		}
+		if err := c.BodyParser(&req); err != nil || req.OlderThan == "" {
+			return c.Status(400).SendString("older_than is required")
+		}
+		age, err := quarantine.ParseAge(req.OlderThan)
+		if err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		dir := s.quarantineDir()
+		if dir == "" {
+			return c.Status(400).SendString("No quarantine folder configured (quarantine_path)")
+		}
+
+		purged, err := quarantine.Purge(dir, age)
+		var bytes int64
+		for _, item := range purged {
+			bytes += item.Size
+			s.audit(c, db.AuditPurge, item.Original, "deleted from quarantine: "+item.Path)
+			s.journalQuarantine(journal.ActionPurge, item)
+		}
+		log.Printf("🔥 Purged %d quarantined files older than %s", len(purged), req.OlderThan)
+		resp := fiber.Map{"purged": len(purged), "bytes": bytes}
+		if err != nil {
+			resp["error"] = err.Error()
+			return c.Status(500).JSON(resp)
+		}
+		return c.JSON(resp)
+	})
+}
+
+// SetQuarantine sets the quarantine folder given on the command line, which takes over from the
+// configured one
+func (s *Server) SetQuarantine(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quarantineFlag = dir
+}
+
+// quarantineDir is the quarantine folder of the command line or the settings, empty when there
+// is none
+func (s *Server) quarantineDir() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.quarantineFlag != "" {
+		return s.quarantineFlag
+	}
+	if s.config == nil {
+		return ""
+	}
+	return s.config.QuarantinePath
+}
+
+// quarantineArchives moves archives of the report (multi-volume sets as a whole) into the
+// quarantine folder dir, all or none, and drops them from the report. kept names the copy left
+// in their place; it defaults to another file of their group.
+func (s *Server) quarantineArchives(c *fiber.Ctx, dir string, paths []string, kept string) ([]quarantine.Item, error) {
+	type target struct{ path, file, kept, groupID string }
+	var targets []target
+	var events []hooks.Event // One per requested archive
+	s.mu.Lock()
+	for _, path := range paths {
+		parts := []string{path}
+		var size int64
+		for _, f := range s.allFiles {
+			if f.Path == path {
+				if len(f.Volumes) > 0 {
+					parts = f.Volumes
+				}
+				size = f.Size
+				break
+			}
+		}
+		keptPath, groupID := s.refNote(path, kept).Kept, ""
+		if s.report != nil {
+			groupID = groupOf(*s.report, path)
+		}
+		for _, part := range parts {
+			if s.protected.Match(part) {
+				s.mu.Unlock()
+				log.Printf("🛡️ Refusing to quarantine protected file: %s", part)
+				return nil, &actionError{status: 403, err: errors.New("File is protected: " + part)}
+			}
+			targets = append(targets, target{part, path, keptPath, groupID})
+		}
+		events = append(events, hooks.Event{Event: hooks.EventPreDelete, Source: journal.SourceDashboard, Path: path, Paths: parts, Size: size, Kept: keptPath, GroupID: groupID})
+	}
+	s.mu.Unlock()
+
+	// The whole batch is refused up front when the quarantine's disk cannot take it
+	transfers := make([]fsutil.Transfer, 0, len(targets))
+	for _, t := range targets {
+		if info, err := os.Stat(t.path); err == nil {
+			transfers = append(transfers, fsutil.Transfer{Path: t.path, Dir: dir, Size: info.Size()})
+		}
+	}
+	if short := fsutil.Preflight(transfers); len(short) > 0 {
+		log.Printf("❌ Could not quarantine: %v", short[0])
+		return nil, &actionError{status: 507, err: short[0], space: short}
+	}
+	// Like a failing file, a pre_delete hook vetoing one archive keeps the whole batch
+	for _, ev := range events {
+		if err := hooks.Run(ev); err != nil {
+			log.Printf("⚠️ Kept by the pre_delete hook: %v", err)
+			return nil, &actionError{status: 409, err: fmt.Errorf("Kept by the pre_delete hook: %w", err)}
+		}
+	}
+
+	// The batch goes as a whole: a file that cannot be quarantined puts the others back
+	tx := txn.New(func(p string) (string, error) { return hashing.FileHash(s.cache, p) })
+	items := make([]quarantine.Item, len(targets))
+	for i, t := range targets {
+		item := quarantine.Item{Kept: t.kept, GroupID: t.groupID}
+		if info, err := os.Stat(t.path); err == nil {
+			item.Size = info.Size()
+		}
+		item.SHA256, _ = hashing.FileHash(s.cache, t.path) // Read while the file is still there
+		tx.Require(t.path, "")
+		tx.Step(func() (err error) {
+			items[i], err = quarantine.Move(dir, "", t.path, item, logCopyProgress(t.path))
+			if err != nil {
+				return fmt.Errorf("could not quarantine %s: %w", t.path, err)
+			}
+			log.Printf("🔒 Quarantined: %s -> %s", t.path, items[i].Path)
+			return nil
+		}, func() error {
+			_, err := quarantine.Restore(dir, []string{items[i].ID})
+			return err
+		})
+	}
+	if err := tx.Run(); err != nil {
+		log.Printf("❌ %v", err)
+		return nil, err
+	}
+	removed := make(map[string]bool)
+	for i, t := range targets {
+		removed[t.file] = true
+		s.audit(c, db.AuditQuarantine, t.path, "quarantined: "+items[i].Path)
+		s.journalQuarantine(journal.ActionQuarantine, items[i])
+	}
+	for _, ev := range events {
+		ev.Event, ev.Action = hooks.EventPostDelete, journal.ActionQuarantine
+		for i, t := range targets {
+			if t.file == ev.Path {
+				ev.Dest = append(ev.Dest, items[i].Path)
+			}
+		}
+		hooks.Run(ev)
+	}
+	s.dropFromReport(removed)
+	return items, nil
+}
+
+// dropFromReport removes quarantined files from the report
+func (s *Server) dropFromReport(removed map[string]bool) {
+	if len(removed) == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.relocateFiles(removed, nil)
+	s.mu.Unlock()
+}
+
+// journalQuarantine records a quarantine, restore or purge made from the dashboard in the journal
+func (s *Server) journalQuarantine(action string, item quarantine.Item) {
+	entry := journal.Entry{Source: journal.SourceDashboard, Action: action, Path: item.Original, Dest: item.Path,
+		Size: item.Size, SHA256: item.SHA256, Kept: item.Kept, GroupID: item.GroupID}
+	if err := journal.Append(entry); err != nil {
+		log.Printf("⚠️ Could not write the cleanup journal: %v", err)
+	}
+}
@@ -0,0 +1,514 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/estimate"
+	"archive-duplicate-finder/internal/jobs"
+	"archive-duplicate-finder/internal/reporter"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JSON-RPC 2.0 error codes. Errors of the operations themselves carry the HTTP status the REST
+// API answers with for the same failure (400, 404, 409, 503, 507...), and 500 otherwise.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// How often /rpc/events looks for changes, and how long it stays silent before a keep-alive
+const (
+	rpcEventInterval  = 500 * time.Millisecond
+	rpcEventKeepAlive = 15 * time.Second
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications, which get no answer
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// rpcNotification is a message of /rpc/events
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// rpcMethod is an operation of the machine API. params is the raw "params" member, decoded
+// with decodeParams.
+type rpcMethod struct {
+	summary string
+	call    func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error)
+}
+
+// rpcGroup is a duplicate group of any kind, as the machine API returns it
+type rpcGroup struct {
+	ID          string              `json:"id"`
+	Kind        string              `json:"kind"` // "size", "similar" or "visual"
+	Name        string              `json:"name"` // Base name of a cluster, name of the first file of a size group
+	Files       []reporter.FileInfo `json:"files"`
+	Verified    bool                `json:"verified,omitempty"` // Contents confirmed identical by hash
+	Series      bool                `json:"series,omitempty"`   // Probable series, not duplicates
+	Reclaimable int64               `json:"reclaimable"`        // Bytes freed by keeping only the largest file
+	Review      string              `json:"review,omitempty"`
+	Note        string              `json:"note,omitempty"`
+}
+
+// rpcMethods is the machine API. Names and parameters stay stable; fields may be added to the
+// results.
+var rpcMethods = map[string]rpcMethod{
+	"scan.start": {"Queue a full scan of the configured directory, or of another folder (dir) with the saved settings; returns the job", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		var p struct {
+			Dir string `json:"dir"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		cfg, err := s.scanConfig(p.Dir)
+		if err != nil {
+			return nil, &rpcError{Code: 400, Message: err.Error()}
+		}
+		return s.startScan(cfg), nil
+	}},
+	"analysis.start": {"Queue the similar-name (kind step3) or visual (kind visual) analysis; error 409 with the estimate when it would run too long, unless confirm is set", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		var p struct {
+			Kind    string `json:"kind"`
+			Confirm bool   `json:"confirm"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		phase := estimate.PhaseStep3
+		switch p.Kind {
+		case jobStep3:
+		case jobVisual:
+			phase = estimate.PhaseVisual
+		default:
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "kind must be step3 or visual"}
+		}
+		if !p.Confirm {
+			if over := s.overLimit(phase); over != nil {
+				return nil, &rpcError{Code: 409, Message: fmt.Sprint(over["message"]), Data: over}
+			}
+		}
+		var job jobs.Job
+		if p.Kind == jobVisual {
+			job, _ = s.jobs.SubmitPausable(jobVisual, s.RunVisual)
+		} else {
+			job, _ = s.jobs.Submit(jobStep3, s.RunStep3)
+		}
+		return job, nil
+	}},
+
+	"jobs.list": {"Queued, running and recently ended scans and analyses, newest first", func(s *Server, c *fiber.Ctx, _ json.RawMessage) (any, error) {
+		return fiber.Map{"jobs": s.jobs.List()}, nil
+	}},
+	"jobs.get": {"One job, by id", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		return jobCall(params, func(id string) (jobs.Job, error) {
+			job, ok := s.jobs.Get(id)
+			if !ok {
+				return job, jobs.ErrNotFound
+			}
+			return job, nil
+		})
+	}},
+	"jobs.cancel": {"Cancel a queued or running job, by id", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		return jobCall(params, s.jobs.Cancel)
+	}},
+	"jobs.pause": {"Pause a pausable job at its next checkpoint, by id", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		return jobCall(params, s.jobs.Pause)
+	}},
+	"jobs.resume": {"Resume a paused job, by id", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		return jobCall(params, s.jobs.Resume)
+	}},
+
+	"report.get": {"Current report, filtered like GET /report; error 404 before a scan", func(s *Server, c *fiber.Ctx, _ json.RawMessage) (any, error) {
+		report, err := s.rpcReport()
+		if err != nil {
+			return nil, err
+		}
+		return report, nil
+	}},
+	"groups.list": {"Duplicate groups of the report, optionally of one kind (size, similar, visual), paged with offset and limit", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		var p struct {
+			Kind   string `json:"kind"`
+			Offset int    `json:"offset"`
+			Limit  int    `json:"limit"` // 0 returns every group
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Offset < 0 || p.Limit < 0 {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "offset and limit cannot be negative"}
+		}
+		report, err := s.rpcReport()
+		if err != nil {
+			return nil, err
+		}
+		var groups []rpcGroup
+		for _, g := range rpcGroups(report) {
+			if p.Kind == "" || g.Kind == p.Kind {
+				groups = append(groups, g)
+			}
+		}
+		total := len(groups)
+		groups = groups[min(p.Offset, total):]
+		if p.Limit > 0 && len(groups) > p.Limit {
+			groups = groups[:p.Limit]
+		}
+		if groups == nil {
+			groups = []rpcGroup{}
+		}
+		return fiber.Map{"total": total, "groups": groups}, nil
+	}},
+	"groups.get": {"One group of the report, by id", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		var p struct {
+			ID string `json:"id"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		report, err := s.rpcReport()
+		if err != nil {
+			return nil, err
+		}
+		g, ok := findRPCGroup(report, p.ID)
+		if !ok {
+			return nil, &rpcError{Code: 404, Message: "No such group in the report"}
+		}
+		return g, nil
+	}},
+	"groups.resolve": {"Keep one file of a group and remove the others (protected ones stay): action delete (moved to the trash folder when one is set) or quarantine. Series cannot be resolved", func(s *Server, c *fiber.Ctx, params json.RawMessage) (any, error) {
+		var p struct {
+			ID     string `json:"id"`
+			Keep   string `json:"keep"`
+			Action string `json:"action"`
+		}
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Action != "delete" && p.Action != "quarantine" {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "action must be delete or quarantine"}
+		}
+		report, err := s.rpcReport()
+		if err != nil {
+			return nil, err
+		}
+		g, ok := findRPCGroup(report, p.ID)
+		if !ok {
+			return nil, &rpcError{Code: 404, Message: "No such group in the report"}
+		}
+		if g.Series {
+			// Parts of a series are not duplicates: removing all but one would lose the others
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "the group is a series, not duplicates: it cannot be resolved"}
+		}
+		if !containsPath(g.Files, p.Keep) {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "keep must be a file of the group"}
+		}
+		var remove []string
+		for _, f := range g.Files {
+			if f.Path != p.Keep && !f.Protected {
+				remove = append(remove, f.Path)
+			}
+		}
+		return s.resolveGroup(c, p.Keep, remove, p.Action)
+	}},
+}
+
+func init() {
+	rpcMethods["rpc.methods"] = rpcMethod{"Names and summaries of the methods", func(s *Server, c *fiber.Ctx, _ json.RawMessage) (any, error) {
+		names := make([]string, 0, len(rpcMethods))
+		for name := range rpcMethods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		methods := make([]fiber.Map, 0, len(names))
+		for _, name := range names {
+			methods = append(methods, fiber.Map{"name": name, "summary": rpcMethods[name].summary})
+		}
+		return fiber.Map{"methods": methods}, nil
+	}}
+}
+
+// registerRPCRoutes adds the machine API: JSON-RPC 2.0 calls on POST /rpc, single or batched,
+// and their progress as a stream of JSON-RPC notifications on GET /rpc/events
+func (s *Server) registerRPCRoutes(api fiber.Router) {
+	api.Post("/rpc", func(c *fiber.Ctx) error {
+		body := bytes.TrimSpace(c.Body())
+		if len(body) > 0 && body[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(body, &batch); err != nil {
+				return c.JSON(rpcFailure(nil, &rpcError{Code: rpcParseError, Message: "Parse error"}))
+			}
+			if len(batch) == 0 {
+				return c.JSON(rpcFailure(nil, &rpcError{Code: rpcInvalidRequest, Message: "Invalid request: empty batch"}))
+			}
+			answers := []rpcResponse{}
+			for _, raw := range batch {
+				if resp, ok := s.rpcCall(c, raw, rpcMethods); ok {
+					answers = append(answers, resp)
+				}
+			}
+			if len(answers) == 0 {
+				return c.SendStatus(204)
+			}
+			return c.JSON(answers)
+		}
+		resp, ok := s.rpcCall(c, body, rpcMethods)
+		if !ok {
+			return c.SendStatus(204)
+		}
+		return c.JSON(resp)
+	})
+
+	// Server-sent events: job.progress with a job whenever its status, phase or progress
+	// changes, report.changed whenever a new report snapshot is published
+	api.Get("/rpc/events", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			seen := make(map[string]jobs.Job)
+			var version uint64
+			quiet := time.Now()
+			for {
+				for _, n := range s.rpcChanges(seen, &version) {
+					data, err := json.Marshal(n)
+					if err != nil {
+						log.Printf("⚠️ Could not encode %s: %v", n.Method, err)
+						continue
+					}
+					fmt.Fprintf(w, "data: %s\n\n", data)
+					quiet = time.Now()
+				}
+				if time.Since(quiet) >= rpcEventKeepAlive {
+					fmt.Fprint(w, ": keep-alive\n\n")
+					quiet = time.Now()
+				}
+				if err := w.Flush(); err != nil {
+					return // The client went away
+				}
+				time.Sleep(rpcEventInterval)
+			}
+		})
+		return nil
+	})
+}
+
+// rpcCall runs one request with a method of methods. It returns false for notifications,
+// which get no answer.
+func (s *Server) rpcCall(c *fiber.Ctx, raw []byte, methods map[string]rpcMethod) (rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			return rpcFailure(nil, &rpcError{Code: rpcParseError, Message: "Parse error"}), true
+		}
+		return rpcFailure(nil, &rpcError{Code: rpcInvalidRequest, Message: "Invalid request"}), true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcFailure(req.ID, &rpcError{Code: rpcInvalidRequest, Message: `Invalid request: jsonrpc must be "2.0" and method is required`}), true
+	}
+
+	var result any
+	var err error
+	if m, ok := methods[req.Method]; ok {
+		result, err = m.call(s, c, req.Params)
+	} else {
+		err = &rpcError{Code: rpcMethodNotFound, Message: "Method not found: " + req.Method}
+	}
+	if req.ID == nil {
+		if err != nil {
+			log.Printf("⚠️ RPC notification %s failed: %v", req.Method, err)
+		}
+		return rpcResponse{}, false
+	}
+	if err != nil {
+		return rpcFailure(req.ID, err), true
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}, true
+}
+
+// rpcFailure answers a request with an error: an rpcError as it is, the refusal of a file
+// action with its HTTP status, anything else with 500
+func rpcFailure(id json.RawMessage, err error) rpcResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	var re *rpcError
+	if !errors.As(err, &re) {
+		re = &rpcError{Code: statusOf(err), Message: err.Error()}
+		var ae *actionError
+		if errors.As(err, &ae) && ae.space != nil {
+			re.Data = fiber.Map{"space": ae.space}
+		}
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: id, Error: re}
+}
+
+// decodeParams decodes the params of a call into v; missing params leave v as it is
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: "Invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+// jobCall applies a job operation to the job of the id param
+func jobCall(params json.RawMessage, apply func(id string) (jobs.Job, error)) (any, error) {
+	var p struct {
+		ID string `json:"id"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	job, err := apply(p.ID)
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		return nil, &rpcError{Code: 404, Message: err.Error()}
+	case err != nil:
+		return nil, &rpcError{Code: 409, Message: err.Error()}
+	}
+	return job, nil
+}
+
+// rpcReport returns the filtered report, or error 404 before a scan
+func (s *Server) rpcReport() (reporter.Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.report == nil {
+		return reporter.Report{}, &rpcError{Code: 404, Message: errNoReport.Error()}
+	}
+	return s.filteredReport(), nil
+}
+
+// resolveGroup removes the files of a group other than the one kept
+func (s *Server) resolveGroup(c *fiber.Ctx, keep string, remove []string, action string) (any, error) {
+	if action == "quarantine" {
+		dir := s.quarantineDir()
+		if dir == "" {
+			return nil, &rpcError{Code: 400, Message: "No quarantine folder configured (quarantine_path)"}
+		}
+		items, err := s.quarantineArchives(c, dir, remove, keep)
+		if err != nil {
+			return nil, err
+		}
+		return fiber.Map{"kept": keep, "removed": remove, "items": items}, nil
+	}
+
+	// Each file goes as a whole or not at all; a failure stops before the next one
+	removed := []string{}
+	for _, path := range remove {
+		if err := s.removeArchive(c, path, keep); err != nil {
+			resp := rpcFailure(nil, err).Error
+			resp.Data = fiber.Map{"removed": removed, "failed": path}
+			return nil, resp
+		}
+		removed = append(removed, path)
+	}
+	return fiber.Map{"kept": keep, "removed": removed}, nil
+}
+
+// rpcChanges returns the notifications for what changed since the previous call: jobs differing
+// from seen, and a report snapshot newer than version. Both are updated.
+func (s *Server) rpcChanges(seen map[string]jobs.Job, version *uint64) []rpcNotification {
+	var changes []rpcNotification
+	list := s.jobs.List()
+	for i := len(list) - 1; i >= 0; i-- { // Oldest first
+		job := list[i]
+		prev, ok := seen[job.ID]
+		if ok && prev.Status == job.Status && prev.Phase == job.Phase && prev.Progress == job.Progress && prev.Error == job.Error {
+			continue
+		}
+		seen[job.ID] = job
+		changes = append(changes, rpcNotification{JSONRPC: "2.0", Method: "job.progress", Params: job})
+	}
+
+	s.mu.Lock()
+	current := s.reportVersion
+	var params fiber.Map
+	if current != *version {
+		params = fiber.Map{"version": current}
+		if s.report != nil {
+			params["status"], params["progress"], params["phases"] = s.report.Status, s.report.Progress, s.report.Phases
+		}
+	}
+	s.mu.Unlock()
+	if params != nil {
+		*version = current
+		changes = append(changes, rpcNotification{JSONRPC: "2.0", Method: "report.changed", Params: params})
+	}
+	return changes
+}
+
+// rpcGroups lists the groups of a report: size groups, then similar names, then visual
+func rpcGroups(report reporter.Report) []rpcGroup {
+	var groups []rpcGroup
+	for _, g := range report.SizeGroups {
+		name := ""
+		if len(g.Files) > 0 {
+			name = g.Files[0].Name
+		}
+		groups = append(groups, rpcGroup{ID: g.ID, Kind: reporter.KindSize, Name: name, Files: g.Files, Verified: g.Verified,
+			Reclaimable: reclaimable(g.Files), Review: g.Review, Note: g.Note})
+	}
+	similar := func(kind string, list []reporter.SimilarityGroup) {
+		for _, g := range list {
+			groups = append(groups, rpcGroup{ID: g.ID, Kind: kind, Name: g.BaseName, Files: g.Files, Series: g.Series,
+				Reclaimable: reclaimable(g.Files), Review: g.Review, Note: g.Note})
+		}
+	}
+	similar(reporter.KindSimilar, report.SimilarGroups)
+	similar(reporter.KindVisual, report.VisualGroups)
+	return groups
+}
+
+// findRPCGroup returns the group of a report with the ID
+func findRPCGroup(report reporter.Report, id string) (rpcGroup, bool) {
+	for _, g := range rpcGroups(report) {
+		if g.ID == id && id != "" {
+			return g, true
+		}
+	}
+	return rpcGroup{}, false
+}
+
+// reclaimable returns the bytes freed by keeping only the largest file
+func reclaimable(files []reporter.FileInfo) int64 {
+	var total, largest int64
+	for _, f := range files {
+		total += f.Size
+		largest = max(largest, f.Size)
+	}
+	return total - largest
+}
@@ -0,0 +1,138 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/similarity"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultSearchLimit = 100
+	maxSearchEntries   = 10 // Matching entries listed per archive
+)
+
+// searchMatch is a scanned file matching a search, with the groups it belongs to
+type searchMatch struct {
+	File      reporter.FileInfo `json:"file"`
+	MatchedBy []string          `json:"matched_by"`        // "name", "normalized" and/or "contents"
+	Entries   []string          `json:"entries,omitempty"` // Entries of the archive matching the query
+	Groups    []searchGroup     `json:"groups"`
+}
+
+// searchGroup is a group of the current report a match belongs to
+type searchGroup struct {
+	ID   string `json:"id,omitempty"`
+	Kind string `json:"kind"`           // "identical", "similar" or "visual"
+	Name string `json:"name,omitempty"` // Base name of similar and visual clusters
+}
+
+// registerSearchRoutes adds the search across the scanned files
+func (s *Server) registerSearchRoutes(api fiber.Router) {
+	// "Do I already have this model?" answered from the last scan: file names, their normalized
+	// form and, with contents=true, the entries of archives read by a -contents scan
+	api.Get("/search", func(c *fiber.Ctx) error {
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			return c.Status(400).SendString("q is required")
+		}
+		limit := c.QueryInt("limit", defaultSearchLimit)
+		if limit <= 0 {
+			return c.Status(400).SendString("limit must be positive")
+		}
+
+		s.mu.Lock()
+		if s.report == nil {
+			s.mu.Unlock()
+			return c.Status(503).SendString("No scan available yet")
+		}
+		report, files := s.filteredReport(), s.allFiles
+		s.mu.Unlock()
+
+		var entries map[string][]string
+		if c.QueryBool("contents") && s.cache != nil {
+			entries = s.cache.SearchContents(query)
+		}
+		lower := strings.ToLower(query)
+		words := strings.Fields(similarity.CanonicalName(query))
+		groups := groupsByPath(report)
+
+		matches := []searchMatch{}
+		for _, f := range files {
+			m := searchMatch{File: f, Groups: groups[f.Path]}
+			if strings.Contains(strings.ToLower(f.Name), lower) {
+				m.MatchedBy = append(m.MatchedBy, "name")
+			}
+			if len(words) > 0 && hasWords(similarity.CanonicalName(f.Name), words) {
+				m.MatchedBy = append(m.MatchedBy, "normalized")
+			}
+			if found := entries[f.Path]; len(found) > 0 {
+				m.MatchedBy = append(m.MatchedBy, "contents")
+				m.Entries = found
+				if len(m.Entries) > maxSearchEntries {
+					m.Entries = m.Entries[:maxSearchEntries]
+				}
+			}
+			if len(m.MatchedBy) == 0 {
+				continue
+			}
+			if m.Groups == nil {
+				m.Groups = []searchGroup{}
+			}
+			matches = append(matches, m)
+		}
+
+		// Name matches first, then normalized ones, then those only found inside archives
+		rank := map[string]int{"name": 0, "normalized": 1, "contents": 2}
+		sort.SliceStable(matches, func(i, j int) bool {
+			if ri, rj := rank[matches[i].MatchedBy[0]], rank[matches[j].MatchedBy[0]]; ri != rj {
+				return ri < rj
+			}
+			return matches[i].File.Path < matches[j].File.Path
+		})
+		total := len(matches)
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+
+		return c.JSON(fiber.Map{
+			"query":   query,
+			"total":   total,
+			"matches": matches,
+		})
+	})
+}
+
+// groupsByPath lists the groups of the report every file belongs to
+func groupsByPath(report reporter.Report) map[string][]searchGroup {
+	groups := make(map[string][]searchGroup)
+	for _, g := range report.SizeGroups {
+		for _, f := range g.Files {
+			groups[f.Path] = append(groups[f.Path], searchGroup{ID: g.ID, Kind: "identical"})
+		}
+	}
+	clusters := func(kind string, similar []reporter.SimilarityGroup) {
+		for _, g := range similar {
+			for _, f := range g.Files {
+				groups[f.Path] = append(groups[f.Path], searchGroup{ID: g.ID, Kind: kind, Name: g.BaseName})
+			}
+		}
+	}
+	clusters("similar", report.SimilarGroups)
+	clusters("visual", report.VisualGroups)
+	return groups
+}
+
+// hasWords reports whether every one of words is a word of name
+func hasWords(name string, words []string) bool {
+	have := strings.Fields(name)
+	for _, w := range words {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
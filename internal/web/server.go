@@ -4,10 +4,29 @@ import (
 	"archive-duplicate-finder/internal/archive"
 	"archive-duplicate-finder/internal/config"
 	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/estimate"
+	"archive-duplicate-finder/internal/fsutil"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/hooks"
+	"archive-duplicate-finder/internal/i18n"
+	"archive-duplicate-finder/internal/iotune"
+	"archive-duplicate-finder/internal/jobs"
+	"archive-duplicate-finder/internal/journal"
+	"archive-duplicate-finder/internal/membudget"
+	"archive-duplicate-finder/internal/notify"
+	"archive-duplicate-finder/internal/profile"
+	"archive-duplicate-finder/internal/progress"
+	"archive-duplicate-finder/internal/protect"
+	"archive-duplicate-finder/internal/refnote"
 	"archive-duplicate-finder/internal/reporter"
 	"archive-duplicate-finder/internal/scanner"
 	"archive-duplicate-finder/internal/similarity"
+	"archive-duplicate-finder/internal/trash"
+	"archive-duplicate-finder/internal/txn"
+	"archive-duplicate-finder/internal/vfs"
 	"archive-duplicate-finder/internal/visual"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -23,38 +42,61 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
+// overviewTop is how many of the largest files and groups /stats lists
+const overviewTop = 20
+
 // Server represents the web dashboard server
 type Server struct {
-	addr          string
-	report        *reporter.Report
-	trashPath     string
-	leaveRef      bool
-	debug         bool
-	runStep3Func  func()
-	runVisualFunc func()
-	allFiles      []reporter.FileInfo
-	cache         *db.Cache
-	previewSem    chan struct{}
-	scanDir       string
-	config        *config.AppConfig
-	mu            sync.Mutex
+	addr           string
+	report         *reporter.Report
+	trashPath      string
+	leaveRef       bool
+	debug          bool
+	runStep3Func   func()
+	runVisualFunc  func()
+	jobs           *jobs.Queue // Scans and analyses, run one at a time
+	reportVersion  uint64      // Bumped every time a new report snapshot is published
+	relocations    []relocation
+	allFiles       []reporter.FileInfo
+	cache          *db.Cache
+	previewSem     chan struct{}
+	scanDir        string
+	config         *config.AppConfig
+	setup          *setupState    // First-run wizard progress; nil until the wizard is opened
+	protectFlags   []string       // Protection patterns given on the command line
+	quarantineFlag string         // Quarantine folder given on the command line
+	protected      *protect.Rules // Configured and command-line protection, rebuilt when either changes
+	uiDir          string         // Dashboard files on disk instead of the embedded ones (see SetUIDir)
+	mu             sync.Mutex
 }
 
 // NewServer creates a new web dashboard server
 func NewServer(port int, report *reporter.Report, trashPath string, leaveRef bool, runStep3Func func(), runVisualFunc func(), allFiles []reporter.FileInfo, cache *db.Cache, scanDir string, appConfig *config.AppConfig) *Server {
-	return &Server{
+	s := &Server{
 		addr:          fmt.Sprintf(":%d", port),
-		report:        report,
 		trashPath:     trashPath,
 		leaveRef:      leaveRef,
 		runStep3Func:  runStep3Func,
 		runVisualFunc: runVisualFunc,
 		allFiles:      allFileInfos(allFiles),
 		cache:         cache,
-		previewSem:    make(chan struct{}, 4), // Allow 4 concurrent extractions
+		jobs:          jobs.NewQueue(),
+		previewSem:    make(chan struct{}, previewWorkers(appConfig)), // Concurrent extractions
 		scanDir:       scanDir,
 		config:        appConfig,
 	}
+	s.setReport(report)
+	s.rebuildProtection()
+	return s
+}
+
+// previewWorkers is how many previews the dashboard extracts at the same time: the configured
+// count, else one per archive worker
+func previewWorkers(cfg *config.AppConfig) int {
+	if cfg != nil && cfg.PreviewWorkers > 0 {
+		return cfg.PreviewWorkers
+	}
+	return archive.GetLimits().Workers
 }
 
 func allFileInfos(files []reporter.FileInfo) []reporter.FileInfo {
@@ -64,6 +106,11 @@ func allFileInfos(files []reporter.FileInfo) []reporter.FileInfo {
 	return files
 }
 
+// URL is the address of the dashboard on this machine
+func (s *Server) URL() string {
+	return "http://localhost" + s.addr
+}
+
 // SetDebug enables or disables debug mode
 func (s *Server) SetDebug(enabled bool) {
 	s.debug = enabled
@@ -72,11 +119,14 @@ func (s *Server) SetDebug(enabled bool) {
 // Start starts the web server
 func (s *Server) Start() error {
 	app := fiber.New(fiber.Config{
-		AppName: "Archive Duplicate Finder Dashboard",
+		AppName:   "Archive Duplicate Finder Dashboard",
+		BodyLimit: 256 << 20, // Cache imports carry every hash of a library
 	})
 
 	// Enable CORS
-	app.Use(cors.New())
+	app.Use(cors.New(cors.Config{
+		ExposeHeaders: "ETag, X-Report-Version", // Lets the dashboard dev server skip unchanged reports
+	}))
 
 	// Add detailed logging in debug mode
 	if s.debug {
@@ -85,17 +135,37 @@ func (s *Server) Start() error {
 		}))
 	}
 
+	s.registerHealthRoutes(app)
+
+	// Unversioned paths predate /api/v1 and keep working as deprecated aliases
+	app.Use("/api", func(c *fiber.Ctx) error {
+		if c.Path() == apiPrefix || strings.HasPrefix(c.Path(), apiPrefix+"/") {
+			return c.Next()
+		}
+		versioned := apiPrefix + strings.TrimPrefix(c.Path(), "/api")
+		c.Set("Deprecation", "true")
+		c.Set(fiber.HeaderLink, fmt.Sprintf("<%s>; rel=\"successor-version\"", versioned))
+		c.Path(versioned)
+		return c.RestartRouting()
+	})
+
 	// API Routes
-	api := app.Group("/api")
+	api := app.Group(apiPrefix, s.requireAuth)
 
 	api.Post("/run-step-3", func(c *fiber.Ctx) error {
-		go s.RunStep3()
-		return c.SendStatus(202)
+		if stop, err := s.requireConfirmation(c, estimate.PhaseStep3); stop {
+			return err
+		}
+		job, _ := s.jobs.Submit(jobStep3, s.RunStep3)
+		return c.Status(202).JSON(job)
 	})
 
 	api.Post("/run-visual", func(c *fiber.Ctx) error {
-		go s.RunVisual()
-		return c.SendStatus(202)
+		if stop, err := s.requireConfirmation(c, estimate.PhaseVisual); stop {
+			return err
+		}
+		job, _ := s.jobs.SubmitPausable(jobVisual, s.RunVisual)
+		return c.Status(202).JSON(job)
 	})
 
 	api.Post("/open-directory", func(c *fiber.Ctx) error {
@@ -134,48 +204,62 @@ func (s *Server) Start() error {
 	})
 
 	api.Get("/config", func(c *fiber.Ctx) error {
-		return c.JSON(s.config)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.config == nil {
+			return c.JSON(nil)
+		}
+		// Anyone who can reach the dashboard may read this: passwords and tokens stay here
+		return c.JSON(s.config.Redacted())
 	})
 
 	api.Post("/config", func(c *fiber.Ctx) error {
-		var cfg config.AppConfig
-		if err := c.BodyParser(&cfg); err != nil {
+		// Settings missing from the body keep their default, older names such as "threshold" are
+		// renamed and unknown ones refused, as in a settings file
+		cfg := *config.Default()
+		if err := config.DecodeJSON(c.Body(), &cfg); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		// Passwords and tokens come back as GET /config shows them
+		s.mu.Lock()
+		if s.config != nil {
+			cfg.KeepSecrets(s.config)
+		}
+		s.mu.Unlock()
+		if err := cfg.Validate(); err != nil {
 			return c.Status(400).SendString(err.Error())
 		}
+		// The access token is managed by the setup wizard, not by plain settings updates; the
+		// commands run for previews and hooks, and what assistants may do, only come from the
+		// settings file
 		s.mu.Lock()
-		s.config = &cfg
-		s.scanDir = cfg.Directory
-		s.trashPath = cfg.TrashPath
-		s.leaveRef = cfg.LeaveRef
+		if s.config != nil {
+			cfg.AuthHash = s.config.AuthHash
+			cfg.PreviewConverters = s.config.PreviewConverters
+			cfg.Hooks = s.config.Hooks
+			cfg.AssistantAccess = s.config.AssistantAccess
+		} else {
+			cfg.PreviewConverters, cfg.Hooks, cfg.AssistantAccess = nil, nil, ""
+		}
 		s.mu.Unlock()
 
-		if err := config.SaveConfig(&cfg); err != nil {
+		if err := s.applyConfig(&cfg); err != nil {
 			return c.Status(500).SendString(err.Error())
 		}
 		return c.SendStatus(200)
 	})
 
 	api.Post("/start-scan", func(c *fiber.Ctx) error {
-		s.mu.Lock()
-		if s.report != nil && (s.report.Status == "analyzing" || s.report.Status == "analyzing_step3" || s.report.Status == "analyzing_visual") {
-			s.mu.Unlock()
-			return c.Status(400).SendString("Scan already in progress")
-		}
-
-		cfg := s.config
-		if cfg == nil {
-			s.mu.Unlock()
-			return c.Status(400).SendString("No configuration set")
+		cfg, err := s.scanConfig(c.Query("dir"))
+		if err != nil {
+			return c.Status(400).SendString(err.Error())
 		}
-		s.mu.Unlock()
-
-		go s.performFullScan(cfg)
-		return c.SendStatus(202)
+		return c.Status(202).JSON(s.startScan(cfg))
 	})
 
 	api.Post("/reset", func(c *fiber.Ctx) error {
 		s.mu.Lock()
-		s.report = nil
+		s.setReport(nil)
 		s.allFiles = []reporter.FileInfo{}
 		s.mu.Unlock()
 		return c.SendStatus(200)
@@ -183,52 +267,50 @@ func (s *Server) Start() error {
 
 	api.Get("/report", func(c *fiber.Ctx) error {
 		s.mu.Lock()
-		defer s.mu.Unlock()
-
+		version := s.reportVersion
 		if s.report == nil {
-			return c.Status(200).JSON(fiber.Map{"status": "idle"})
-		}
-
-		// Filter out ignored groups
-		var filteredSizeGroups []reporter.SizeGroup
-		for _, g := range s.report.SizeGroups {
-			if s.cache != nil && s.cache.IsGroupIgnored(g.Hash()) {
-				continue
+			idle := fiber.Map{
+				"status":         "idle",
+				"setup_required": s.config == nil || s.config.Directory == "",
 			}
-			filteredSizeGroups = append(filteredSizeGroups, g)
-		}
-
-		var filteredSimilarGroups []reporter.SimilarityGroup
-		for _, g := range s.report.SimilarGroups {
-			if s.cache != nil && s.cache.IsGroupIgnored(g.Hash()) {
-				continue
-			}
-			filteredSimilarGroups = append(filteredSimilarGroups, g)
+			s.mu.Unlock()
+			return sendReport(c, idle, version)
 		}
-
-		var filteredVisualGroups []reporter.SimilarityGroup
-		for _, g := range s.report.VisualGroups {
-			if s.cache != nil && s.cache.IsGroupIgnored(g.Hash()) {
-				continue
-			}
-			filteredVisualGroups = append(filteredVisualGroups, g)
+		reportCopy := s.filteredReport()
+		files, root, deleteMode := s.allFiles, s.scanDir, ""
+		if s.config != nil {
+			deleteMode = s.config.DeleteMode
 		}
+		s.mu.Unlock()
 
-		reportCopy := *s.report
-		reportCopy.SizeGroups = filteredSizeGroups
-		reportCopy.SimilarGroups = filteredSimilarGroups
-		reportCopy.VisualGroups = filteredVisualGroups
-
+		// The snapshot is immutable, so it is serialized without holding the lock
 		if c.Query("exclude_similar") == "true" {
 			reportCopy.SimilarGroups = nil
-			return c.Status(200).JSON(reportCopy)
 		}
-		return c.Status(200).JSON(reportCopy)
+		if review := c.Query("review"); review != "" {
+			if !reporter.IsReviewStatus(review) {
+				return c.Status(400).SendString("review must be pending, reviewed or resolved")
+			}
+			reportCopy = reporter.FilterByReview(reportCopy, review)
+		}
+		if dir := c.Query("dir"); dir != "" {
+			reportCopy = reporter.FilterByDir(reportCopy, dir)
+		}
+		switch c.Query("group_by") {
+		case "":
+		case reporter.GroupByDir:
+			reportCopy.ByDir = reporter.GroupByDirs(reportCopy, files, root, deleteMode)
+		default:
+			return c.Status(400).SendString("group_by must be dir")
+		}
+		return sendReport(c, reportCopy, version)
 	})
 
 	api.Post("/mark-as-good", func(c *fiber.Ctx) error {
 		type markRequest struct {
-			Files []reporter.FileInfo `json:"files"`
+			Files   []reporter.FileInfo `json:"files"`
+			GroupID string              `json:"group_id"` // Stable ID of the group, so it stays ignored when it gains a member
+			TTLDays *int                `json:"ttl_days"` // Re-surface the group after this many days (0 = never); defaults to ignore_ttl_days
 		}
 		var req markRequest
 		if err := c.BodyParser(&req); err != nil {
@@ -242,11 +324,23 @@ func (s *Server) Start() error {
 		hash := reporter.CalculateGroupHash(req.Files)
 		log.Printf("👍 Marking group as good (ignored): %s", hash)
 
+		// With a cache the group is hidden by filteredReport, so un-ignoring brings it back
 		if s.cache != nil {
-			s.cache.AddIgnoredGroup(hash)
+			ttlDays := 0
+			if req.TTLDays != nil {
+				ttlDays = *req.TTLDays
+			} else if s.config != nil {
+				ttlDays = s.config.IgnoreTTLDays
+			}
+			s.cache.AddIgnoredGroup(hash, req.GroupID, filePaths(req.Files), time.Duration(ttlDays)*24*time.Hour)
+			s.audit(c, db.AuditIgnore, groupTarget(req.GroupID, hash), fmt.Sprintf("%d files", len(req.Files)))
+			if err := journal.AppendGroup(journal.ActionIgnore, journal.SourceDashboard, req.GroupID, hash, req.Files); err != nil {
+				log.Printf("⚠️ Could not write the cleanup journal: %v", err)
+			}
+			return c.SendStatus(200)
 		}
 
-		// Also remove it from memory immediately
+		// Without one, remove it from memory for the rest of the session
 		s.mu.Lock()
 		defer s.mu.Unlock()
 
@@ -261,25 +355,27 @@ func (s *Server) Start() error {
 			return filtered
 		}
 
-		s.report.SimilarGroups = filterGroups(s.report.SimilarGroups)
-		s.report.VisualGroups = filterGroups(s.report.VisualGroups)
+		s.updateReport(func(r *reporter.Report) {
+			r.SimilarGroups = filterGroups(r.SimilarGroups)
+			r.VisualGroups = filterGroups(r.VisualGroups)
 
-		// Filter size groups separately
-		var newSizeGroups []reporter.SizeGroup
-		for _, g := range s.report.SizeGroups {
-			if g.Hash() != hash {
-				newSizeGroups = append(newSizeGroups, g)
+			// Filter size groups separately
+			var newSizeGroups []reporter.SizeGroup
+			for _, g := range r.SizeGroups {
+				if g.Hash() != hash {
+					newSizeGroups = append(newSizeGroups, g)
+				}
 			}
-		}
-		s.report.SizeGroups = newSizeGroups
+			r.SizeGroups = newSizeGroups
+		})
 
 		return c.SendStatus(200)
 	})
 
 	api.Get("/stats", func(c *fiber.Ctx) error {
 		s.mu.Lock()
-		defer s.mu.Unlock()
 		if s.report == nil {
+			s.mu.Unlock()
 			return c.Status(200).JSON(fiber.Map{
 				"totalFiles": 0,
 				"duplicates": 0,
@@ -287,27 +383,54 @@ func (s *Server) Start() error {
 				"duration":   0,
 			})
 		}
-		return c.Status(200).JSON(fiber.Map{
-			"totalFiles": s.report.TotalFiles,
-			"duplicates": len(s.report.SizeGroups),
-			"similar":    len(s.report.SimilarGroups),
-			"duration":   s.report.AnalysisDuration,
-		})
+		stats := fiber.Map{
+			"totalFiles":     s.report.TotalFiles,
+			"duplicates":     len(s.report.SizeGroups),
+			"similar":        len(s.report.SimilarGroups),
+			"duration":       s.report.AnalysisDuration,
+			"clusterMetrics": s.report.ClusterMetrics,
+		}
+		report, files, root, deleteMode := s.filteredReport(), s.allFiles, s.scanDir, ""
+		if s.config != nil {
+			deleteMode = s.config.DeleteMode
+		}
+		s.mu.Unlock()
+
+		// Breakdowns for the overview page
+		overview := reporter.NewOverview(report, files, deleteMode, overviewTop)
+		stats["byType"] = overview.ByType
+		stats["largestFiles"] = overview.LargestFiles
+		stats["largestGroups"] = overview.LargestGroups
+		// Change since the run before the last one, from the scan history
+		if s.cache != nil {
+			if runs := s.cache.RunHistory(root, 2); len(runs) == 2 {
+				stats["trend"] = fiber.Map{
+					"current":  runs[0],
+					"previous": runs[1],
+					"delta":    runs[0].Summary.Sub(runs[1].Summary),
+				}
+			}
+		}
+		return c.Status(200).JSON(stats)
 	})
 
 	api.Get("/all-files", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		allFiles, report := s.allFiles, s.report
+		s.mu.Unlock()
+
 		// Use the full scanned list if available, otherwise fallback to map-based collection
 		var files []reporter.FileInfo
-		if len(s.allFiles) > 0 {
-			files = s.allFiles
+		if len(allFiles) > 0 || report == nil {
+			files = allFiles
 		} else {
 			fileMap := make(map[string]reporter.FileInfo)
-			for _, group := range s.report.SizeGroups {
+			for _, group := range report.SizeGroups {
 				for _, file := range group.Files {
 					fileMap[file.Path] = file
 				}
 			}
-			for _, group := range s.report.SimilarGroups {
+			for _, group := range report.SimilarGroups {
 				for _, file := range group.Files {
 					fileMap[file.Path] = file
 				}
@@ -324,7 +447,7 @@ func (s *Server) Start() error {
 		})
 	})
 
-	// Endpoint: /api/preview?path=...&internal_path=...
+	// Endpoint: /api/v1/preview?path=...&internal_path=...
 	api.Get("/preview", func(c *fiber.Ctx) error {
 		path := c.Query("path")
 		internalPath := c.Query("internal_path")
@@ -334,8 +457,8 @@ func (s *Server) Start() error {
 
 		// Determine if it's a direct file or an archive
 		isArchive := false
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".zip" || ext == ".rar" || ext == ".7z" || ext == ".tar" || ext == ".gz" {
+		ext := archive.Format(path)
+		if ext == ".zip" || ext == ".rar" || ext == ".7z" || ext == ".iso" || archive.IsCompressed(path) {
 			isArchive = true
 		}
 
@@ -345,19 +468,29 @@ func (s *Server) Start() error {
 				// Direct file (image, video, model): Send with correct content type
 				contentType := getContentType(path)
 				c.Set("Content-Type", contentType)
+				if vfs.IsRemote(path) {
+					f, err := vfs.Open(path)
+					if err != nil {
+						return c.Status(404).SendString(err.Error())
+					}
+					return c.SendStream(f, int(f.Size()))
+				}
 				return c.SendFile(path)
 			}
 
 			// Check cache first
-			info, _ := os.Stat(path)
 			modTime := ""
-			if info != nil {
-				modTime = info.ModTime().String()
+			if info, err := vfs.Stat(path); err == nil {
+				modTime = info.ModTime.String()
 			}
 
 			var found bool
 			if s.cache != nil && c.Query("type") != "model" {
-				internalPath, found = s.cache.GetPreviewPath(path, modTime)
+				// A preview picked by the user wins over the heuristics
+				internalPath, found = s.cache.GetPreviewOverride(path)
+				if !found {
+					internalPath, found = s.cache.GetPreviewPath(path, modTime)
+				}
 			}
 
 			if !found {
@@ -383,38 +516,56 @@ func (s *Server) Start() error {
 			}
 		}
 
-		// 2. Files inside archives (or found video preview from above)
-		fileExt := strings.ToLower(filepath.Ext(internalPath))
+		// 2. Files inside archives (or found video preview from above). Entries a preview
+		// provider renders are served as their PNG, except to the 3D viewer
+		extract := archive.PreviewFile
+		c.Set("X-Internal-Path", internalPath)
+		if c.Query("type") == "model" || !archive.IsRendered(internalPath) {
+			extract = archive.GetFileFromArchive
+			c.Set("Content-Type", getContentType(internalPath))
+		} else {
+			c.Set("Content-Type", "image/png")
+		}
 
-		// For images, models or videos inside archives, use disk cache
-		tempDir := filepath.Join(os.TempDir(), "archive-finder-cache")
-		os.MkdirAll(tempDir, 0755)
-
-		// Create a unique hash/filename for this specific file in the archive
-		cacheKey := fmt.Sprintf("%x_%s", path, internalPath)
-		cacheKey = strings.Map(func(r rune) rune {
-			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
-				return r
+		// Without the preview cache the entry is extracted for every request
+		if !archive.PreviewCacheEnabled() {
+			s.previewSem <- struct{}{}
+			data, err := extract(path, internalPath)
+			<-s.previewSem
+			if err != nil {
+				return c.Status(404).SendString(err.Error())
 			}
-			return '_'
-		}, cacheKey)
+			return c.Send(data)
+		}
 
-		cachePath := filepath.Join(tempDir, cacheKey+fileExt)
+		// For images, models or videos inside archives, use disk cache
+		os.MkdirAll(archive.PreviewCacheDir(), 0755)
+		cachePath := archive.PreviewCachePath(path, internalPath)
+		fileExt := strings.ToLower(filepath.Ext(cachePath))
+
+		// A cached preview that does not look like its format (e.g. truncated by a crash) is extracted again
+		if _, err := os.Stat(cachePath); err == nil && !fsutil.LooksValid(cachePath) {
+			log.Printf("⚠️  Discarding invalid cached preview: %s", filepath.Base(cachePath))
+			os.Remove(cachePath)
+		}
 
 		// If not cached, extract it (limited concurrency)
 		if _, err := os.Stat(cachePath); os.IsNotExist(err) {
 			s.previewSem <- struct{}{}
-			data, err := archive.GetFileFromArchive(path, internalPath)
+			data, err := extract(path, internalPath)
+			<-s.previewSem
 			if err != nil {
-				<-s.previewSem
 				return c.Status(404).SendString(err.Error())
 			}
-			os.WriteFile(cachePath, data, 0644)
-			<-s.previewSem
+			if !fsutil.MatchesSignature(data, fileExt) {
+				// Serve what the archive holds, but never cache it as a valid preview
+				return c.Send(data)
+			}
+			if err := fsutil.WriteFileAtomic(cachePath, data, 0644); err != nil {
+				return c.Send(data)
+			}
 		}
 
-		c.Set("X-Internal-Path", internalPath)
-		c.Set("Content-Type", getContentType(internalPath))
 		return c.SendFile(cachePath)
 	})
 
@@ -434,6 +585,29 @@ func (s *Server) Start() error {
 		})
 	})
 
+	s.registerModelRoutes(api)
+	s.registerSuppressionRoutes(api)
+	s.registerEstimateRoutes(api)
+	s.registerExportRoutes(api)
+	s.registerSetupRoutes(api)
+	s.registerHookRoutes(api)
+	s.registerPreviewRoutes(api)
+	s.registerCacheRoutes(api)
+	s.registerIgnoredRoutes(api)
+	s.registerGroupRoutes(api)
+	s.registerAuditRoutes(api)
+	s.registerProtectionRoutes(api)
+	s.registerOrganizeRoutes(api)
+	s.registerContentsRoutes(api)
+	s.registerTreeRoutes(api)
+	s.registerSearchRoutes(api)
+	s.registerQuarantineRoutes(api)
+	s.registerJobRoutes(api)
+	s.registerRPCRoutes(api)
+	s.registerMCPRoutes(api)
+	s.registerMemoryRoutes(api)
+	s.registerOpenAPIRoutes(api)
+
 	api.Get("/open", func(c *fiber.Ctx) error {
 		path := c.Query("path")
 		mode := c.Query("mode", "reveal") // "reveal" or "launch"
@@ -475,187 +649,406 @@ func (s *Server) Start() error {
 	api.Post("/delete", func(c *fiber.Ctx) error {
 		type deleteRequest struct {
 			Path string `json:"path"`
+			Kept string `json:"kept,omitempty"` // Copy kept in its place, for the reference note; defaults to another file of its group
 		}
 		var req deleteRequest
 		if err := c.BodyParser(&req); err != nil {
 			return c.Status(400).SendString("Invalid request body")
 		}
 
-		s.mu.Lock()
-		defer s.mu.Unlock()
+		if err := s.removeArchive(c, req.Path, req.Kept); err != nil {
+			return sendActionError(c, err)
+		}
+		log.Println("✅ Report state updated successfully")
+		return c.SendStatus(200)
+	})
 
-		// 1. Perform FS action
-		log.Printf("🗑️ Dashboard Request: Delete %s", req.Path)
-		if s.trashPath != "" {
-			if _, err := os.Stat(s.trashPath); os.IsNotExist(err) {
-				os.MkdirAll(s.trashPath, 0755)
-			}
-			dest := filepath.Join(s.trashPath, filepath.Base(req.Path))
-			log.Printf("📦 Moving to trash: %s -> %s", req.Path, dest)
-			if err := os.Rename(req.Path, dest); err != nil {
-				log.Printf("⚠️ Rename failed: %v. Trying Remove...", err)
-				if err := os.Remove(req.Path); err != nil {
-					log.Printf("❌ Delete failed: %v", err)
-					return c.Status(500).SendString(err.Error())
-				}
-			}
-			if s.leaveRef {
-				refPath := req.Path + ".duplicate.txt"
-				content := fmt.Sprintf("Archive Duplicate Finder\nOriginal kept: ... (Dashboard Action)\nDate: %s\n", time.Now().Format("2006-01-02 15:04:05"))
-				_ = os.WriteFile(refPath, []byte(content), 0644)
-			}
-		} else {
-			log.Printf("🔥 Permanently deleting: %s", req.Path)
-			if err := os.Remove(req.Path); err != nil {
-				log.Printf("❌ Delete failed: %v", err)
-				return c.Status(500).SendString(err.Error())
-			}
+	s.serveDashboard(app)
+
+	if archive.PreviewCacheEnabled() {
+		if n := fsutil.RemoveStaleTemps(archive.PreviewCacheDir(), time.Hour); n > 0 {
+			log.Printf("🧹 Removed %d unfinished preview files", n)
 		}
+	}
+	s.recoverStaged()
+	go s.keepTrash()
+	s.resumeVisual()
 
-		// 2. Remove from report and update stats
-		s.report.TotalFiles--
+	log.Printf("🚀 Web Dashboard available at: %s", s.URL())
+	return app.Listen(s.addr)
+}
 
-		// Remove from Similarity Groups (Clusters)
-		newGroups := make([]reporter.SimilarityGroup, 0)
-		for _, g := range s.report.SimilarGroups {
-			newFiles := make([]reporter.FileInfo, 0)
-			for _, f := range g.Files {
-				if f.Path != req.Path {
-					newFiles = append(newFiles, f)
-				}
-			}
-			// Keep group if it still has at least 2 files
-			if len(newFiles) >= 2 {
-				g.Files = newFiles
-				newGroups = append(newGroups, g)
+// removeArchive deletes an archive of the report (every volume of a split set), or moves it to
+// the trash folder, then drops it from the report. kept names the copy left in its place, for
+// the reference note; it defaults to another file of its group.
+func (s *Server) removeArchive(c *fiber.Ctx, file, kept string) error {
+	s.mu.Lock()
+	paths := []string{file}
+	var scanned *reporter.FileInfo
+	for i, f := range s.allFiles {
+		if f.Path == file {
+			if len(f.Volumes) > 0 {
+				paths = f.Volumes
 			}
+			scanned = &s.allFiles[i]
+			break
 		}
-		s.report.SimilarGroups = newGroups
-		s.report.SimilarCount = len(newGroups)
+	}
+	for _, path := range paths {
+		if s.protected.Match(path) {
+			s.mu.Unlock()
+			log.Printf("🛡️ Refusing to delete protected file: %s", path)
+			return &actionError{status: 403, err: errors.New("File is protected")}
+		}
+	}
+	trashPath, leaveRef := s.trashPath, s.leaveRef
+	orDelete := s.config != nil && s.config.DeleteIfTrashFails
+	recheck := ""
+	if s.config != nil && scanned != nil {
+		recheck = s.config.VerifyBeforeDelete
+	}
+	var scannedSize int64
+	var scannedTime time.Time
+	if scanned != nil {
+		scannedSize = scanned.Size
+		scannedTime, _ = time.Parse(time.RFC3339, scanned.ModTime)
+	}
+	note := s.refNote(file, kept)
+	groupID := ""
+	if s.report != nil {
+		groupID = groupOf(*s.report, file)
+	}
+	var refFormat, refTemplate string
+	if s.config != nil {
+		refFormat, refTemplate = s.config.RefFormat, s.config.RefTemplate
+	}
+	// Moving to a trash on another filesystem copies the file, so the report stays readable meanwhile
+	s.mu.Unlock()
+	// A file changed since the scan is no longer the duplicate the dashboard shows
+	if err := hashing.Recheck(s.cache, recheck, paths, scannedSize, scannedTime); err != nil {
+		log.Printf("⚠️ %v, file kept", err)
+		return &actionError{status: 409, err: err}
+	}
+	event := hooks.Event{Event: hooks.EventPreDelete, Source: journal.SourceDashboard, Path: file, Paths: paths, Size: scannedSize, Kept: note.Kept, GroupID: groupID}
+	if err := hooks.Run(event); err != nil {
+		log.Printf("⚠️ Kept by the pre_delete hook: %v", err)
+		return &actionError{status: 409, err: fmt.Errorf("Kept by the pre_delete hook: %w", err)}
+	}
+	if trashPath != "" && leaveRef {
+		s.hashNote(&note)
+	}
 
-		// Remove from Size Groups
-		var newSizeGroups []reporter.SizeGroup
-		for i := range s.report.SizeGroups {
-			newFiles := make([]reporter.FileInfo, 0)
-			for _, f := range s.report.SizeGroups[i].Files {
-				if f.Path != req.Path {
-					newFiles = append(newFiles, f)
+	// A part that cannot go puts back the parts already removed
+	tx := txn.New(func(p string) (string, error) { return hashing.FileHash(s.cache, p) })
+	type removal struct {
+		entry  *journal.Entry
+		detail string
+	}
+	var removals []*removal
+	for _, path := range paths {
+		log.Printf("🗑️ Dashboard Request: Delete %s", path)
+		r := &removal{entry: &journal.Entry{Source: journal.SourceDashboard, Action: journal.ActionDelete, Path: path, Kept: note.Kept, GroupID: groupID}, detail: "deleted permanently"}
+		if info, err := os.Stat(path); err == nil {
+			r.entry.Size = info.Size()
+		}
+		r.entry.SHA256, _ = hashing.FileHash(s.cache, path) // Read while the file is still there
+		removals = append(removals, r)
+		tx.Require(path, "")
+		var undo func() error
+		tx.Step(func() (err error) {
+			if trashPath != "" {
+				dest, err := trash.Move(trashPath, path, logCopyProgress(path))
+				switch {
+				case err == nil:
+					log.Printf("📦 Moved to trash: %s -> %s", path, dest)
+					r.detail = "moved to trash: " + dest
+					r.entry.Action, r.entry.Dest = journal.ActionTrash, dest
+					if note.Trash == "" {
+						note.Action, note.Trash = "trashed", dest
+					}
+					undo = func() error { return fsutil.MoveFile(dest, path, nil) }
+					return nil
+				case !orDelete:
+					return fmt.Errorf("could not move to trash: %w", err)
 				}
+				log.Printf("⚠️ Rename failed: %v. Trying Remove...", err)
+			} else {
+				log.Printf("🔥 Permanently deleting: %s", path)
 			}
-			// Only keep the group if it still has at least 2 files (a duplicate group)
-			if len(newFiles) >= 2 {
-				s.report.SizeGroups[i].Files = newFiles
-				newSizeGroups = append(newSizeGroups, s.report.SizeGroups[i])
-			}
+			undo, err = tx.Stage(path)
+			return err
+		}, func() error { return undo() })
+	}
+	if err := tx.Run(); err != nil {
+		log.Printf("❌ Delete failed, file kept: %v", err)
+		return err
+	}
+	entries := make([]*journal.Entry, 0, len(removals))
+	for _, r := range removals {
+		s.audit(c, db.AuditDelete, r.entry.Path, r.detail)
+		if err := journal.Append(*r.entry); err != nil {
+			log.Printf("⚠️ Could not write the cleanup journal: %v", err)
+		}
+		entries = append(entries, r.entry)
+	}
+	hooks.Run(event.PostDelete(entries))
+	if trashPath != "" && leaveRef {
+		note.Date = time.Now()
+		if _, err := refnote.Write(note, refFormat, refTemplate); err != nil {
+			log.Printf("⚠️ Could not create reference note: %v", err)
 		}
-		s.report.SizeGroups = newSizeGroups
+	}
 
-		log.Println("✅ Report state updated successfully")
-		return c.SendStatus(200)
-	})
+	// Remove from report and update stats
+	s.mu.Lock()
+	s.relocateFiles(map[string]bool{file: true}, nil)
+	s.mu.Unlock()
+	return nil
+}
 
-	// Serve static dashboard files
-	app.Static("/", "./ui/out")
+// actionError is a refused file action with the HTTP status it answers with; other errors of
+// file actions answer 500
+type actionError struct {
+	status int
+	err    error
+	space  []*fsutil.NoSpaceError // Disks short of space, with 507
+}
 
-	// Final fallback for SPA routing: any non-API route that 404s should serve index.html
-	// This allows browser reloads on routes like /gallery to work correctly.
-	app.Use(func(c *fiber.Ctx) error {
-		// If it's an API route, return 404
-		if strings.HasPrefix(c.Path(), "/api") {
-			return c.Next()
-		}
-		// Otherwise serve index.html from static out
-		return c.SendFile("./ui/out/index.html")
-	})
+func (e *actionError) Error() string { return e.err.Error() }
+func (e *actionError) Unwrap() error { return e.err }
 
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.Status(200).SendString("Archive Duplicate Finder Dashboard API is running")
-	})
+// statusOf returns the HTTP status answering the error of a file action
+func statusOf(err error) int {
+	var ae *actionError
+	if errors.As(err, &ae) {
+		return ae.status
+	}
+	return 500
+}
 
-	log.Printf("🚀 Web Dashboard available at: http://localhost%s", s.addr)
-	return app.Listen(s.addr)
+// sendActionError answers a failed file action with its status and message
+func sendActionError(c *fiber.Ctx, err error) error {
+	return c.Status(statusOf(err)).SendString(err.Error())
 }
 
-func (s *Server) performFullScan(cfg *config.AppConfig) {
+// applyConfig makes cfg the active configuration and persists it
+func (s *Server) applyConfig(cfg *config.AppConfig) error {
+	s.mu.Lock()
+	moved := cfg.Directory != s.scanDir
+	s.mu.Unlock()
+	if cfg.Workers == 0 && moved && cfg.Directory != "" {
+		iotune.Tune(cfg.Directory) // Another library, maybe on another disk
+	}
+	archive.SetLimits(cfg.ArchiveLimits())
+	archive.SetPreviewCommands(cfg.PreviewConverters)
+	visual.SetReadRate(cfg.VisualRateMB << 20)
+	membudget.SetBudget(cfg.MemoryBudgetMB << 20)
+	scanner.SetLooseFiles(cfg.LooseFiles)
+	vfs.SetS3Config(cfg.S3)
+	vfs.SetSFTPConfig(cfg.SFTP)
+	vfs.SetWebDAVConfig(cfg.WebDAV)
+	s.mu.Lock()
+	s.config = cfg
+	s.scanDir = cfg.Directory
+	s.trashPath = cfg.TrashPath
+	s.leaveRef = cfg.LeaveRef
+	s.rebuildProtection()
+	s.mu.Unlock()
+	journal.SetOperationsLog(s.cache, cfg.OperationsLog)
+	hooks.Set(cfg.Hooks)
+	if cfg.Language != "" {
+		_ = i18n.SetLanguage(cfg.Language) // Validated with the rest of the settings
+	}
+
+	return config.SaveConfig(cfg)
+}
+
+// scanFiles lists the archives of the configured directory (every file in loose-file mode),
+// using the network share scan when enabled
+func (s *Server) scanFiles(cfg *config.AppConfig, onFile func(files int, bytes int64)) ([]scanner.ArchiveFile, error) {
+	scanner.SetLooseFiles(cfg.LooseFiles)
+	if !cfg.NetworkShare {
+		return scanner.ScanDirectoryWithProgress(cfg.Directory, cfg.Recursive, onFile)
+	}
+	opts := scanner.NetworkOptions{}
+	if s.cache != nil {
+		opts.Listings = s.cache
+	}
+	return scanner.ScanNetworkShare(cfg.Directory, cfg.Recursive, opts, onFile)
+}
+
+func (s *Server) performFullScan(ctx context.Context, cfg *config.AppConfig) error {
 	log.Printf("🔍 Starting web-triggered scan: %s", cfg.Directory)
 	s.mu.Lock()
-	s.report = &reporter.Report{
+	s.scanDir = cfg.Directory // The report covers the folder scanned, e.g. one of finder here
+	s.setReport(&reporter.Report{
 		Status: "analyzing",
-	}
+	})
 	s.allFiles = []reporter.FileInfo{}
+	since := s.reportVersion
 	s.mu.Unlock()
 
+	if s.cache != nil {
+		s.cache.SetRoot(cfg.Directory)
+	}
+
 	startTime := time.Now()
-	files, err := scanner.ScanDirectory(cfg.Directory, cfg.Recursive)
+	scanTracker := s.phaseTracker(progress.PhaseScan, estimate.PreviousScanBytes(s.cache, cfg.Directory))
+	var scannedBytes int64
+	files, err := s.scanFiles(cfg, func(n int, bytes int64) {
+		scannedBytes = bytes
+		scanTracker.Set(bytes)
+	})
+	scanTracker.Finish()
+	estimate.RecordScan(s.cache, cfg.Directory, scannedBytes, time.Since(startTime))
 	if err != nil {
 		log.Printf("❌ Scan failed: %v", err)
 		s.mu.Lock()
-		s.report.Status = "error"
+		s.updateReport(func(r *reporter.Report) { r.Status = "error" })
 		s.mu.Unlock()
-		return
+		return err
+	}
+	if ctx.Err() != nil {
+		return s.canceled(ctx, "canceled")
+	}
+	files = scanner.CollapseVolumeSets(files)
+	if cfg.Contents {
+		log.Printf("📑 Reading archive contents...")
+		var store scanner.ContentStore
+		if s.cache != nil {
+			store = s.cache
+		}
+		scanner.EnrichContents(files, store, nil)
+	} else {
+		scanner.CountBookPages(files)
+	}
+
+	if ctx.Err() != nil {
+		return s.canceled(ctx, "canceled")
+	}
+
+	var corrupt []reporter.CorruptFile
+	if cfg.Verify {
+		log.Printf("🩺 Verifying archive integrity...")
+		files, corrupt = s.verifyFiles(files)
+		if ctx.Err() != nil {
+			return s.canceled(ctx, "canceled")
+		}
 	}
 
 	// Update allFiles for the gallery
 	var allFiles []reporter.FileInfo
 	for _, f := range files {
-		allFiles = append(allFiles, reporter.FileInfo{
-			Name:    f.Name,
-			Path:    f.Path,
-			Size:    f.Size,
-			Type:    f.Type,
-			ModTime: f.ModTime.Format(time.RFC3339),
-		})
+		allFiles = append(allFiles, reporter.NewFileInfo(f))
 	}
 
-	sizeGroups := scanner.GroupBySize(files)
+	// Each file type goes to the method of its profile; the rest to the size and name pass
+	byMethod := profile.Split(profile.Resolve(cfg.Profiles), files)
+	profileOptions := profile.Options{MinOverlap: cfg.MinContentOverlap, VisualDistance: cfg.ThresholdVisual}
+	sizeGroups := scanner.GroupBySize(byMethod[profile.Name])
 	var finalSizeGroups []reporter.SizeGroup
 	for size, group := range sizeGroups {
 		if len(group) < 2 {
 			continue
 		}
+		if cfg.VerifyContent {
+			// Only confirmed copies are reported, one group per content
+			for _, g := range hashing.VerifyContents(s.cache, group) {
+				currentGroup := reporter.SizeGroup{Size: size, Verified: true}
+				for _, f := range g.Files {
+					currentGroup.Files = append(currentGroup.Files, reporter.NewFileInfo(f))
+				}
+				finalSizeGroups = append(finalSizeGroups, currentGroup)
+			}
+			if ctx.Err() != nil {
+				return s.canceled(ctx, "canceled")
+			}
+			continue
+		}
 		var currentGroup reporter.SizeGroup
 		currentGroup.Size = size
 		for _, f := range group {
-			currentGroup.Files = append(currentGroup.Files, reporter.FileInfo{
-				Name:    f.Name,
-				Path:    f.Path,
-				Size:    f.Size,
-				Type:    f.Type,
-				ModTime: f.ModTime.Format(time.RFC3339),
-			})
+			currentGroup.Files = append(currentGroup.Files, reporter.NewFileInfo(f))
 		}
 		finalSizeGroups = append(finalSizeGroups, currentGroup)
 	}
 
+	for _, method := range []string{profile.Content, profile.Manifest, profile.Geometry, profile.Cover} {
+		if len(byMethod[method]) > 1 {
+			log.Printf("🧬 Comparing %d files by %s...", len(byMethod[method]), method)
+			finalSizeGroups = append(finalSizeGroups, profile.Analyze(ctx, s.cache, method, byMethod[method], profileOptions)...)
+		}
+	}
+	if ctx.Err() != nil {
+		return s.canceled(ctx, "canceled")
+	}
+
+	// Compressed archives with the same payload ("x.tar.gz", "x.tar.zst") differ in size
+	compressed := 0
+	for _, f := range files {
+		if archive.IsCompressed(f.Path) {
+			compressed++
+		}
+	}
+	payloadTracker := s.phaseTracker(progress.PhasePayload, int64(compressed))
+	for _, g := range hashing.GroupByPayload(s.cache, files, func(done int) { payloadTracker.Set(int64(done)) }) {
+		group := reporter.SizeGroup{Size: g.PayloadSize, Payload: true}
+		for _, f := range g.Files {
+			group.Files = append(group.Files, reporter.NewFileInfo(f))
+		}
+		finalSizeGroups = append(finalSizeGroups, group)
+	}
+	payloadTracker.Finish()
+	if ctx.Err() != nil {
+		return s.canceled(ctx, "canceled")
+	}
+
 	s.mu.Lock()
-	s.report.TotalFiles = len(files)
-	s.report.SizeGroups = finalSizeGroups
-	s.report.AnalysisDuration = time.Since(startTime).Seconds()
-	s.allFiles = allFiles
-	s.report.Status = "finished"
+	// Files deleted or moved from the dashboard while the scan ran
+	removed, moved := s.relocationsSince(since)
+	s.allFiles = relocate(allFiles, removed, moved)
+	s.updateReport(func(r *reporter.Report) {
+		r.TotalFiles = len(files) + len(corrupt) - (len(allFiles) - len(s.allFiles))
+		r.SizeGroups = relocateSizeGroups(finalSizeGroups, removed, moved)
+		r.CorruptFiles = corrupt
+		r.CorruptCount = len(corrupt)
+		r.AnalysisDuration = time.Since(startTime).Seconds()
+		r.Status = "finished"
+	})
+	summary := reporter.Summarize(s.filteredReport(), s.allFiles, cfg.DeleteMode)
 	s.mu.Unlock()
 
+	if s.cache != nil {
+		if err := s.cache.RecordRun(cfg.Directory, summary); err != nil {
+			log.Printf("⚠️ Could not record the run in the scan history: %v", err)
+		}
+	}
 	log.Printf("✅ Scan completed. Found %d files and %d size groups.", len(files), len(finalSizeGroups))
-
-	// Trigger similarity automatically if configured? (Maybe later)
+	s.notify(notify.EventScanFinished)
+	s.runScanHooks()
+	s.resumeVisual()
+	return nil
 }
 
-func (s *Server) RunStep3() {
+// RunStep3 clusters the scanned archives by name. Cancellation takes effect once the
+// directory has been listed and once the clusters are built.
+func (s *Server) RunStep3(ctx context.Context) error {
 	s.mu.Lock()
 	if s.report == nil {
 		s.mu.Unlock()
-		return
+		return errNoReport
 	}
-	if s.report.Status == "analyzing_step3" {
-		s.mu.Unlock()
-		return
-	}
-	s.report.Status = "analyzing_step3"
-	s.report.Progress = 0
+	s.updateReport(func(r *reporter.Report) {
+		r.Status = "analyzing_step3"
+		r.Progress = 0
+	})
+	since := s.reportVersion
 	scanDir := s.scanDir
-	threshold := 70
+	opts := similarity.Options{Threshold: 70, Debug: s.debug, Cache: s.cache}
 	if s.config != nil {
-		threshold = s.config.Threshold
+		opts.Threshold = s.config.ThresholdName
+		opts.Phonetic = s.config.Phonetic
+		opts.Scorers = s.config.Scorers
 	}
 	s.mu.Unlock()
 
@@ -664,69 +1057,96 @@ func (s *Server) RunStep3() {
 
 	// Need scanner.ArchiveFile objects.
 	files, _ := scanner.ScanDirectory(scanDir, true)
+	files = scanner.CollapseVolumeSets(files)
+	files = s.withoutCorrupt(files)
+	if ctx.Err() != nil {
+		return s.canceled(ctx, "finished")
+	}
 
+	step3Tracker := s.phaseTracker(progress.PhaseStep3, totalSize(files))
 	onProgress := func(p float64) {
-		s.mu.Lock()
-		s.report.Progress = p
-		s.mu.Unlock()
+		step3Tracker.SetFraction(p)
 	}
 
-	simGroups := similarity.FindSimilarGroups(files, threshold, s.debug, onProgress)
+	clusterStart := time.Now()
+	simGroups, metrics := similarity.FindSimilarGroupsWithMetrics(files, opts, onProgress)
+	step3Tracker.Finish()
+	if ctx.Err() != nil {
+		return s.canceled(ctx, "finished")
+	}
+	estimate.Record(s.cache, estimate.PhaseStep3, len(files), time.Since(clusterStart))
 
 	var results []reporter.SimilarityGroup
 	for _, g := range simGroups {
 		var fileInfos []reporter.FileInfo
-		for _, f := range g.Files {
-			fileInfos = append(fileInfos, reporter.FileInfo{
-				Name:    f.Name,
-				Path:    f.Path,
-				Size:    f.Size,
-				Type:    f.Type,
-				ModTime: f.ModTime.Format(time.RFC3339),
-			})
+		for i, f := range g.Files {
+			info := reporter.NewFileInfo(f)
+			score := g.Scores[i]
+			info.Score = &score
+			fileInfos = append(fileInfos, info)
 		}
 		results = append(results, reporter.SimilarityGroup{
 			BaseName: g.BaseName,
 			Files:    fileInfos,
+			Series:   g.Series,
 		})
 	}
 
 	s.mu.Lock()
-	s.report.SimilarGroups = results
-	s.report.SimilarCount = len(results)
-	s.report.AnalysisDuration += time.Since(startTime).Seconds()
-	s.report.Status = "finished"
+	removed, moved := s.relocationsSince(since)
+	results = relocateGroups(results, removed, moved)
+	s.updateReport(func(r *reporter.Report) {
+		r.SimilarGroups = results
+		r.SimilarCount = len(results)
+		r.ClusterMetrics = &metrics
+		r.AnalysisDuration += time.Since(startTime).Seconds()
+		r.Status = "finished"
+	})
 	s.mu.Unlock()
 	log.Printf("✅ Step 3 finished. Found %d clusters.", len(results))
+	s.notify(notify.EventStep3Finished)
+	return nil
 }
 
-func (s *Server) RunVisual() {
+// RunVisual hashes the preview of every archive and groups look-alikes. Cancellation skips
+// the archives not hashed yet; the groups found so far are kept. The job can be paused, and a
+// checkpoint in the cache lets it resume after a restart (see resumeVisual).
+func (s *Server) RunVisual(ctx context.Context) error {
 	s.mu.Lock()
-	if s.report == nil || s.report.Status == "analyzing_visual" {
+	if s.report == nil {
 		s.mu.Unlock()
-		return
+		return errNoReport
 	}
-	s.report.Status = "analyzing_visual"
-	s.report.Progress = 0
+	s.updateReport(func(r *reporter.Report) {
+		r.Status = "analyzing_visual"
+		r.Progress = 0
+	})
+	since := s.reportVersion
 	scanDir := s.scanDir
-	threshold := 70
+	maxDistance := visual.HammingThreshold
 	if s.config != nil {
-		threshold = s.config.Threshold
+		maxDistance = s.config.ThresholdVisual
 	}
 	s.mu.Unlock()
 
 	log.Printf("🎨 Web-triggered Visual analysis started...")
 
 	files, _ := scanner.ScanDirectory(scanDir, true)
+	files = scanner.CollapseVolumeSets(files)
+	files = s.withoutCorrupt(files)
+
+	run, toHash := s.startVisualRun(scanDir, files)
+	pending := estimate.PendingVisual(toHash, s.cache)
+	hashStart := time.Now()
+	visualTracker := s.phaseTracker(progress.PhaseVisual, totalSize(toHash))
 
 	hashDone := make(chan bool)
 	go func() {
-		onVisualProgress := func(p float64) {
-			s.mu.Lock()
-			s.report.Progress = p
-			s.mu.Unlock()
-		}
-		visual.ProcessVisualHashes(files, s.cache, s.debug, onVisualProgress)
+		visual.HashPreviews(ctx, toHash, s.cache, visual.Options{
+			Debug:      s.debug,
+			OnProgress: visualTracker.SetFraction,
+			OnFile:     run.fileDone,
+		})
 		hashDone <- true
 	}()
 
@@ -734,7 +1154,7 @@ func (s *Server) RunVisual() {
 	defer ticker.Stop()
 
 	updateVisualGroups := func() {
-		visualGroups := visual.FindVisualDuplicates(files, s.cache, threshold)
+		visualGroups := visual.FindVisualDuplicates(files, s.cache, maxDistance)
 		var reporterVisualGroups []reporter.SimilarityGroup
 		for _, vg := range visualGroups {
 			var fileInfos []reporter.FileInfo
@@ -754,8 +1174,12 @@ func (s *Server) RunVisual() {
 			})
 		}
 		s.mu.Lock()
-		s.report.VisualGroups = reporterVisualGroups
-		s.report.VisualCount = len(reporterVisualGroups)
+		removed, moved := s.relocationsSince(since)
+		reporterVisualGroups = relocateGroups(reporterVisualGroups, removed, moved)
+		s.updateReport(func(r *reporter.Report) {
+			r.VisualGroups = reporterVisualGroups
+			r.VisualCount = len(reporterVisualGroups)
+		})
 		s.mu.Unlock()
 	}
 
@@ -767,13 +1191,192 @@ loop:
 			break loop
 		case <-ticker.C:
 			updateVisualGroups()
+			run.save(ctx)
 		}
 	}
 
+	visualTracker.Finish()
+	failed := run.finish()
+	if ctx.Err() != nil {
+		return s.canceled(ctx, "finished")
+	}
+	if len(failed) > 0 {
+		log.Printf("⚠️ %d files had no preview that could be hashed", len(failed))
+	}
+	estimate.Record(s.cache, estimate.PhaseVisual, pending, time.Since(hashStart))
+
+	// Renders saved next to their archive are hashed directly and related to its preview
+	links := visual.LinkPreviews(ctx, files, visual.ImagesBeside(files), s.cache)
+	if len(links) > 0 {
+		log.Printf("🖼️  %d loose images match the preview of an archive in their folder", len(links))
+	}
+
 	s.mu.Lock()
-	s.report.Status = "finished"
+	removed, moved := s.relocationsSince(since)
+	links = relocateLinks(links, removed, moved)
+	s.updateReport(func(r *reporter.Report) {
+		r.PreviewLinks = links
+		r.Status = "finished"
+	})
 	s.mu.Unlock()
 	log.Printf("✅ Visual analysis finished.")
+	s.notify(notify.EventVisualFinished)
+	return nil
+}
+
+// notify sends a snapshot of the current report to the configured notification targets
+func (s *Server) notify(event string) {
+	s.mu.Lock()
+	if s.config == nil || len(s.config.Notifications) == 0 || s.report == nil {
+		s.mu.Unlock()
+		return
+	}
+	targets := s.config.Notifications
+	ev := notify.Event{Name: event, Directory: s.scanDir, Report: *s.report}
+	s.mu.Unlock()
+
+	go notify.Dispatch(targets, ev)
+}
+
+// runScanHooks runs the post_scan hooks in the background, with the report as it is now
+func (s *Server) runScanHooks() {
+	if !hooks.Enabled(hooks.EventPostScan) {
+		return
+	}
+	s.mu.Lock()
+	if s.report == nil {
+		s.mu.Unlock()
+		return
+	}
+	report := *s.report
+	ev := hooks.Event{Event: hooks.EventPostScan, Source: journal.SourceDashboard, Directory: s.scanDir, Report: &report}
+	s.mu.Unlock()
+
+	go hooks.Run(ev)
+}
+
+// phaseTracker tracks one phase of the current report; the overall progress follows the active phase
+func (s *Server) phaseTracker(phase string, totalBytes int64) *progress.Tracker {
+	return progress.New(totalBytes, func(p reporter.PhaseProgress) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.jobs.SetProgress(phase, p.Progress)
+		s.updateReport(func(r *reporter.Report) {
+			r.SetPhase(phase, p)
+			r.Progress = p.Progress
+		})
+	})
+}
+
+func totalSize(files []scanner.ArchiveFile) int64 {
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	return total
+}
+
+// filteredReport returns a copy of the report without ignored or suppressed groups.
+// The caller must hold s.mu.
+func (s *Server) filteredReport() reporter.Report {
+	ignored := s.ignoredMatcher()
+	visible := func(id string, files []reporter.FileInfo) bool {
+		if ignored(id, files) {
+			return false
+		}
+		return !s.isSuppressed(files)
+	}
+
+	var filteredSizeGroups []reporter.SizeGroup
+	for _, g := range s.report.SizeGroups {
+		if visible(g.ID, g.Files) {
+			filteredSizeGroups = append(filteredSizeGroups, g)
+		}
+	}
+
+	var filteredSimilarGroups []reporter.SimilarityGroup
+	for _, g := range s.report.SimilarGroups {
+		if visible(g.ID, g.Files) {
+			filteredSimilarGroups = append(filteredSimilarGroups, g)
+		}
+	}
+
+	var filteredVisualGroups []reporter.SimilarityGroup
+	for _, g := range s.report.VisualGroups {
+		if visible(g.ID, g.Files) {
+			filteredVisualGroups = append(filteredVisualGroups, g)
+		}
+	}
+
+	reportCopy := *s.report
+	reportCopy.SizeGroups = filteredSizeGroups
+	reportCopy.SimilarGroups = filteredSimilarGroups
+	reportCopy.VisualGroups = filteredVisualGroups
+	if s.cache != nil {
+		reviews := s.cache.GroupReviews()
+		reportCopy = reporter.WithReviews(reportCopy, func(id string) (string, string) {
+			return reviews[id].Status, reviews[id].Note
+		})
+	}
+	return reporter.WithSuspicious(reporter.WithProtected(reportCopy, s.protected.Match), archive.SuspiciousReason)
+}
+
+// verifyFiles runs the integrity check and splits files into readable ones and corrupt archives
+func (s *Server) verifyFiles(files []scanner.ArchiveFile) ([]scanner.ArchiveFile, []reporter.CorruptFile) {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	sizes := make(map[string]int64, len(files))
+	var totalBytes int64
+	for _, f := range files {
+		sizes[f.Path] = f.Size
+		totalBytes += f.Size
+	}
+	tracker := s.phaseTracker(progress.PhaseVerify, totalBytes)
+	failed := archive.VerifyArchives(paths, func(path string) {
+		tracker.Add(sizes[path])
+	})
+	tracker.Finish()
+
+	var healthy []scanner.ArchiveFile
+	var corrupt []reporter.CorruptFile
+	for _, f := range files {
+		err, bad := failed[f.Path]
+		if !bad {
+			healthy = append(healthy, f)
+			continue
+		}
+		log.Printf("💔 Corrupt archive: %s (%v)", f.Path, err)
+		corrupt = append(corrupt, reporter.CorruptFile{
+			FileInfo: reporter.NewFileInfo(f),
+			Error:    err.Error(),
+		})
+	}
+	return healthy, corrupt
+}
+
+// withoutCorrupt drops the archives reported by the last integrity check
+func (s *Server) withoutCorrupt(files []scanner.ArchiveFile) []scanner.ArchiveFile {
+	s.mu.Lock()
+	corrupt := make(map[string]bool)
+	if s.report != nil {
+		for _, f := range s.report.CorruptFiles {
+			corrupt[f.Path] = true
+		}
+	}
+	s.mu.Unlock()
+
+	if len(corrupt) == 0 {
+		return files
+	}
+	var result []scanner.ArchiveFile
+	for _, f := range files {
+		if !corrupt[f.Path] {
+			result = append(result, f)
+		}
+	}
+	return result
 }
 
 func getContentType(filename string) string {
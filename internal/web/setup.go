@@ -0,0 +1,339 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/paths"
+	"archive-duplicate-finder/internal/similarity"
+	"archive-duplicate-finder/internal/vfs"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Setup wizard steps, in the order they are answered
+const (
+	setupRoots      = "roots"
+	setupTrash      = "trash"
+	setupThresholds = "thresholds"
+	setupAuth       = "auth"
+	setupDone       = "done"
+)
+
+var setupSteps = []string{setupRoots, setupTrash, setupThresholds, setupAuth, setupDone}
+
+const authCookie = "adf_token"
+
+// minTokenLength keeps the dashboard token from being trivially guessable
+const minTokenLength = 8
+
+// setupState is the progress of the first-run wizard: the step being answered and the settings
+// collected so far. Nothing is written to disk until the last step has been answered.
+type setupState struct {
+	Step  string
+	Draft config.AppConfig
+}
+
+// setupRequest answers the current wizard step. Only the fields of that step are read.
+type setupRequest struct {
+	Step   string `json:"step"`   // Step being answered; must be the current one
+	Action string `json:"action"` // "" submits the step, "back" returns to the previous one, "restart" starts over
+
+	// roots
+	Directory string `json:"directory"`
+	Recursive *bool  `json:"recursive"`
+	Loose     bool   `json:"loose_files"`
+
+	// trash
+	TrashPath  string `json:"trash_path"`
+	LeaveRef   bool   `json:"leave_ref"`
+	DeleteMode string `json:"delete_mode"`
+
+	// thresholds
+	ThresholdName     int    `json:"threshold_name"`
+	Threshold         int    `json:"threshold"`           // Former name of threshold_name
+	ThresholdVisual   *int   `json:"threshold_visual"`    // Kept as it is when missing
+	MinContentOverlap *int   `json:"min_content_overlap"` // Kept as it is when missing
+	Phonetic          string `json:"phonetic"`
+	ConfirmAbove      *int   `json:"confirm_above_minutes"`
+	Verify            bool   `json:"verify"`
+	Contents          bool   `json:"enrich_contents"`
+	VerifyContent     bool   `json:"verify_content"`
+
+	// auth
+	Token     string `json:"token"`      // Dashboard access token; empty leaves the dashboard open
+	StartScan bool   `json:"start_scan"` // Start the first scan once the settings are saved
+}
+
+// registerSetupRoutes serves the guided setup wizard and the dashboard login
+func (s *Server) registerSetupRoutes(api fiber.Router) {
+	api.Get("/setup", func(c *fiber.Ctx) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return c.JSON(s.setupView())
+	})
+
+	api.Post("/setup", func(c *fiber.Ctx) error {
+		var req setupRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+
+		s.mu.Lock()
+		st := s.setupProgress()
+		switch req.Action {
+		case "restart":
+			s.setup = nil
+			view := s.setupView()
+			s.mu.Unlock()
+			return c.JSON(view)
+		case "back":
+			if i := stepIndex(st.Step); i > 0 && st.Step != setupDone {
+				st.Step = setupSteps[i-1]
+			}
+			view := s.setupView()
+			s.mu.Unlock()
+			return c.JSON(view)
+		case "":
+		default:
+			s.mu.Unlock()
+			return c.Status(400).SendString("Action must be 'back' or 'restart'")
+		}
+
+		if req.Step != st.Step {
+			view := s.setupView()
+			s.mu.Unlock()
+			return c.Status(409).JSON(view)
+		}
+
+		draft := st.Draft
+		if err := answerSetupStep(&draft, req); err != nil {
+			s.mu.Unlock()
+			return c.Status(400).SendString(err.Error())
+		}
+		st.Draft = draft
+		st.Step = setupSteps[stepIndex(st.Step)+1]
+		finished := st.Step == setupDone
+		s.mu.Unlock()
+
+		if finished {
+			cfg := draft
+			if err := s.applyConfig(&cfg); err != nil {
+				return c.Status(500).SendString(err.Error())
+			}
+			log.Printf("🧭 Setup complete: scanning %s", cfg.Directory)
+			if req.Token != "" {
+				setAuthCookie(c, req.Token)
+			}
+			if req.StartScan {
+				s.startScan(&cfg)
+			}
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return c.JSON(s.setupView())
+	})
+
+	api.Post("/login", func(c *fiber.Ctx) error {
+		var req struct {
+			Token string `json:"token"`
+			Name  string `json:"name"` // Who is using the dashboard, recorded in the audit trail
+		}
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString(err.Error())
+		}
+		if !s.checkToken(req.Token) {
+			return c.Status(401).SendString("Invalid access token")
+		}
+		setAuthCookie(c, req.Token)
+		if req.Name != "" {
+			setClientCookie(c, req.Name)
+		}
+		return c.SendStatus(200)
+	})
+
+	api.Post("/logout", func(c *fiber.Ctx) error {
+		c.ClearCookie(authCookie, clientCookie)
+		return c.SendStatus(200)
+	})
+}
+
+// requireAuth rejects API calls without the dashboard token once one has been set.
+// The token is accepted from the login cookie or an "Authorization: Bearer" header.
+func (s *Server) requireAuth(c *fiber.Ctx) error {
+	switch c.Path() {
+	case apiPrefix + "/login", apiPrefix + "/openapi.json", apiPrefix + "/swagger.json":
+		return c.Next()
+	}
+
+	if !s.checkToken(requestToken(c)) {
+		return c.Status(401).JSON(fiber.Map{"error": "authentication required"})
+	}
+	return c.Next()
+}
+
+// requestToken returns the access token sent with a request, if any
+func requestToken(c *fiber.Ctx) string {
+	if auth := c.Get(fiber.HeaderAuthorization); len(auth) > 7 && auth[:7] == "Bearer " {
+		return auth[7:]
+	}
+	return c.Cookies(authCookie)
+}
+
+func (s *Server) checkToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config == nil || s.config.CheckToken(token)
+}
+
+func setAuthCookie(c *fiber.Ctx, token string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     authCookie,
+		Value:    token,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+}
+
+// setupProgress returns the wizard state, starting it from the current settings (or the
+// defaults of a fresh install) the first time. Caller holds s.mu.
+func (s *Server) setupProgress() *setupState {
+	if s.setup == nil {
+		draft := *config.Default()
+		draft.TrashPath = paths.Trash() // Suggested to fresh installs; cleared to delete for good
+		if s.config != nil {
+			draft = *s.config
+		}
+		s.setup = &setupState{Step: setupRoots, Draft: draft}
+	}
+	return s.setup
+}
+
+// setupView is the wizard state as returned to the dashboard. Caller holds s.mu.
+func (s *Server) setupView() fiber.Map {
+	st := s.setupProgress()
+	return fiber.Map{
+		"required":     s.config == nil || s.config.Directory == "",
+		"step":         st.Step,
+		"steps":        setupSteps,
+		"draft":        st.Draft.Redacted(),
+		"auth_enabled": st.Draft.AuthHash != "",
+	}
+}
+
+// answerSetupStep validates the answer to one step and stores it in the draft
+func answerSetupStep(draft *config.AppConfig, req setupRequest) error {
+	switch req.Step {
+	case setupRoots:
+		if req.Directory == "" {
+			return fmt.Errorf("a scan directory is required")
+		}
+		dir := req.Directory
+		if !vfs.IsRemote(dir) { // Remote stores are checked when they are listed
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				return err
+			}
+			info, err := os.Stat(abs)
+			if err != nil || !info.IsDir() {
+				return fmt.Errorf("scan directory does not exist: %s", abs)
+			}
+			dir = abs
+		}
+		draft.Directory = dir
+		if req.Recursive != nil {
+			draft.Recursive = *req.Recursive
+		}
+		draft.LooseFiles = req.Loose
+
+	case setupTrash:
+		mode := req.DeleteMode
+		if mode == "" {
+			mode = "oldest"
+		}
+		if mode != "oldest" && mode != "contents" {
+			return fmt.Errorf("delete mode must be 'oldest' or 'contents'")
+		}
+		trash := ""
+		if req.TrashPath != "" {
+			abs, err := filepath.Abs(req.TrashPath)
+			if err != nil {
+				return err
+			}
+			if abs == draft.Directory {
+				return fmt.Errorf("the trash folder cannot be the scan directory")
+			}
+			if info, err := os.Stat(abs); err == nil && !info.IsDir() {
+				return fmt.Errorf("trash path is not a directory: %s", abs)
+			} else if err != nil {
+				if _, err := os.Stat(filepath.Dir(abs)); err != nil {
+					return fmt.Errorf("parent of the trash folder does not exist: %s", filepath.Dir(abs))
+				}
+			}
+			trash = abs
+		}
+		draft.TrashPath = trash
+		draft.LeaveRef = req.LeaveRef
+		draft.DeleteMode = mode
+
+	case setupThresholds:
+		if req.ThresholdName == 0 {
+			req.ThresholdName = req.Threshold
+		}
+		if req.ThresholdName < 1 || req.ThresholdName > 100 {
+			return fmt.Errorf("threshold_name must be between 1 and 100")
+		}
+		if req.ThresholdVisual != nil {
+			if *req.ThresholdVisual < 0 || *req.ThresholdVisual > 64 {
+				return fmt.Errorf("threshold_visual must be between 0 and 64")
+			}
+			draft.ThresholdVisual = *req.ThresholdVisual
+		}
+		if req.MinContentOverlap != nil {
+			if *req.MinContentOverlap < 1 || *req.MinContentOverlap > 100 {
+				return fmt.Errorf("min_content_overlap must be between 1 and 100")
+			}
+			draft.MinContentOverlap = *req.MinContentOverlap
+		}
+		if !similarity.IsValidPhonetic(req.Phonetic) {
+			return fmt.Errorf("phonetic must be 'soundex' or 'metaphone'")
+		}
+		if req.ConfirmAbove != nil {
+			if *req.ConfirmAbove < 0 {
+				return fmt.Errorf("confirm_above_minutes cannot be negative")
+			}
+			draft.ConfirmAbove = *req.ConfirmAbove
+		}
+		draft.ThresholdName = req.ThresholdName
+		draft.Phonetic = req.Phonetic
+		draft.Verify = req.Verify
+		draft.Contents = req.Contents
+		draft.VerifyContent = req.VerifyContent
+
+	case setupAuth:
+		if req.Token == "" {
+			draft.AuthHash = ""
+			break
+		}
+		if len(req.Token) < minTokenLength {
+			return fmt.Errorf("access token must be at least %d characters", minTokenLength)
+		}
+		draft.AuthHash = config.HashToken(req.Token)
+
+	default:
+		return fmt.Errorf("setup is already complete")
+	}
+	return nil
+}
+
+func stepIndex(step string) int {
+	for i, s := range setupSteps {
+		if s == step {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,176 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The report is treated as an immutable snapshot: every change publishes an updated copy, so a
+// report handed to a request (or to a notification) never changes underneath it and can be
+// serialized without holding s.mu. Each published copy gets a new version.
+
+// maxRelocations caps how many file removals and moves are remembered for analyses still running
+const maxRelocations = 1000
+
+// relocation is a batch of files removed from or moved within the library while the report
+// was published at version
+type relocation struct {
+	version uint64
+	removed map[string]bool
+	moved   map[string]string
+}
+
+// setReport publishes a new report; nil clears it. New groups get their stable ID (see
+// reporter.WithGroupIDs). The caller must hold s.mu.
+func (s *Server) setReport(r *reporter.Report) {
+	if r != nil && s.cache != nil {
+		withIDs := reporter.WithGroupIDs(*r, s.cache.GroupIDs)
+		r = &withIDs
+	}
+	s.report = r
+	s.reportVersion++
+}
+
+// updateReport publishes an updated copy of the report. fn must replace the slices and maps
+// it changes instead of writing into them. The caller must hold s.mu.
+func (s *Server) updateReport(fn func(r *reporter.Report)) {
+	if s.report == nil {
+		return
+	}
+	next := *s.report
+	fn(&next)
+	s.setReport(&next)
+}
+
+// relocateFiles drops removed files from the report and points moved or renamed files to their
+// new location. Analyses running meanwhile replay the change on their results before
+// publishing them. The caller must hold s.mu.
+func (s *Server) relocateFiles(removed map[string]bool, moved map[string]string) {
+	s.relocations = append(s.relocations, relocation{version: s.reportVersion, removed: removed, moved: moved})
+	if len(s.relocations) > maxRelocations {
+		s.relocations = s.relocations[len(s.relocations)-maxRelocations:]
+	}
+
+	before := len(s.allFiles)
+	s.allFiles = relocate(s.allFiles, removed, moved)
+	s.updateReport(func(r *reporter.Report) {
+		r.SizeGroups = relocateSizeGroups(r.SizeGroups, removed, moved)
+		r.SimilarGroups = relocateGroups(r.SimilarGroups, removed, moved)
+		r.SimilarCount = len(r.SimilarGroups)
+		r.VisualGroups = relocateGroups(r.VisualGroups, removed, moved)
+		r.VisualCount = len(r.VisualGroups)
+		r.PreviewLinks = relocateLinks(r.PreviewLinks, removed, moved)
+		r.TotalFiles -= before - len(s.allFiles)
+	})
+}
+
+// relocationsSince merges the removals and moves made after version. The caller must hold s.mu.
+func (s *Server) relocationsSince(version uint64) (map[string]bool, map[string]string) {
+	removed := make(map[string]bool)
+	moved := make(map[string]string)
+	for _, r := range s.relocations {
+		if r.version < version {
+			continue
+		}
+		for p := range r.removed {
+			removed[p] = true
+		}
+		for from, to := range r.moved {
+			moved[from] = to
+		}
+	}
+	return removed, moved
+}
+
+// relocate returns files without the removed ones and with moved ones at their new location
+func relocate(files []reporter.FileInfo, removed map[string]bool, moved map[string]string) []reporter.FileInfo {
+	kept := make([]reporter.FileInfo, 0, len(files))
+	for _, f := range files {
+		if removed[f.Path] {
+			continue
+		}
+		if dest, ok := moved[f.Path]; ok {
+			f.Path, f.Name = dest, filepath.Base(dest)
+			if len(f.Volumes) > 0 {
+				volumes := make([]string, len(f.Volumes))
+				for i, v := range f.Volumes {
+					volumes[i] = filepath.Join(filepath.Dir(dest), filepath.Base(v))
+				}
+				f.Volumes = volumes
+			}
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// relocateGroups applies relocate to every group, dropping groups left with a single file
+func relocateGroups(groups []reporter.SimilarityGroup, removed map[string]bool, moved map[string]string) []reporter.SimilarityGroup {
+	var out []reporter.SimilarityGroup
+	for _, g := range groups {
+		if g.Files = relocate(g.Files, removed, moved); len(g.Files) >= 2 {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func relocateSizeGroups(groups []reporter.SizeGroup, removed map[string]bool, moved map[string]string) []reporter.SizeGroup {
+	var out []reporter.SizeGroup
+	for _, g := range groups {
+		if g.Files = relocate(g.Files, removed, moved); len(g.Files) >= 2 {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// relocateLinks drops the preview links whose archive or image was removed and follows moves
+func relocateLinks(links []reporter.PreviewLink, removed map[string]bool, moved map[string]string) []reporter.PreviewLink {
+	var kept []reporter.PreviewLink
+	for _, l := range links {
+		pair := relocate([]reporter.FileInfo{l.Archive, l.Image}, removed, moved)
+		if len(pair) == 2 {
+			kept = append(kept, reporter.PreviewLink{Archive: pair[0], Image: pair[1], Distance: l.Distance})
+		}
+	}
+	return kept
+}
+
+// sendReport writes a report with an ETag of its contents. A client that already holds that
+// version (If-None-Match) gets 304 Not Modified and no body.
+func sendReport(c *fiber.Ctx, report any, version uint64) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return c.Status(500).SendString(err.Error())
+	}
+	h := fnv.New64a()
+	h.Write(body)
+	etag := fmt.Sprintf(`"%x"`, h.Sum64())
+
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Report-Version", fmt.Sprint(version))
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (weak validators included)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/jobs"
+)
+
+// Status is the state of the server at a glance, for the tray: the job at work and what the
+// report found
+type Status struct {
+	Job         *jobs.Job // Queued, running or paused scan or analysis; nil when idle
+	Configured  bool      // A directory has been set up
+	Scanned     bool      // A report is available
+	Duplicates  int       // Groups of identical archives
+	Similar     int       // Groups of similar names
+	Visual      int       // Groups of similar previews
+	Reclaimable int64     // Bytes freed by keeping one archive of each identical group
+	Pausable    int       // Active jobs that can be paused
+	Paused      int       // Jobs held by a pause
+}
+
+// Status returns the state of the server. The job shown is the one running, or else the
+// next one to run.
+func (s *Server) Status() Status {
+	var st Status
+	list := s.jobs.List() // Newest first
+	for i := len(list) - 1; i >= 0; i-- {
+		j := list[i]
+		if !j.Active() {
+			continue
+		}
+		if st.Job == nil || (st.Job.Status == jobs.StatusQueued && j.Status != jobs.StatusQueued) {
+			st.Job = &j
+		}
+		if j.Status == jobs.StatusPaused {
+			st.Paused++
+		} else if j.Pausable && j.Status != jobs.StatusCanceling {
+			st.Pausable++
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st.Configured = s.config != nil && s.config.Directory != ""
+	if s.report == nil {
+		return st
+	}
+	report := s.filteredReport()
+	st.Scanned = true
+	st.Duplicates, st.Similar, st.Visual = len(report.SizeGroups), len(report.SimilarGroups), len(report.VisualGroups)
+	for _, g := range report.SizeGroups {
+		st.Reclaimable += reclaimable(g.Files)
+	}
+	return st
+}
+
+// PauseAnalysis pauses the pausable jobs (the visual analysis), running or queued, and returns
+// how many it paused
+func (s *Server) PauseAnalysis() int {
+	return s.applyToJobs(func(j jobs.Job) bool { return j.Pausable && j.Active() }, s.jobs.Pause)
+}
+
+// ResumeAnalysis lets the paused jobs go on and returns how many it resumed
+func (s *Server) ResumeAnalysis() int {
+	return s.applyToJobs(func(j jobs.Job) bool { return j.Status == jobs.StatusPaused }, s.jobs.Resume)
+}
+
+// applyToJobs applies a pause or resume to the jobs matching, counting the ones it changed
+func (s *Server) applyToJobs(match func(jobs.Job) bool, apply func(id string) (jobs.Job, error)) int {
+	n := 0
+	for _, j := range s.jobs.List() {
+		if !match(j) {
+			continue
+		}
+		if after, err := apply(j.ID); err == nil && after.Status != j.Status {
+			n++
+		}
+	}
+	return n
+}
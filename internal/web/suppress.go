@@ -0,0 +1,73 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/reporter"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerSuppressionRoutes adds the content-hash suppression registry endpoints
+func (s *Server) registerSuppressionRoutes(api fiber.Router) {
+	// Suppress a group of byte-identical files: its content hash is never reported again
+	api.Post("/suppress", func(c *fiber.Ctx) error {
+		type suppressRequest struct {
+			Files []reporter.FileInfo `json:"files"`
+			Note  string              `json:"note"`
+		}
+		var req suppressRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).SendString("Invalid request body")
+		}
+		if len(req.Files) < 2 {
+			return c.Status(400).SendString("At least two files are required")
+		}
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+
+		hash, ok := hashing.GroupContentHash(s.cache, filePaths(req.Files))
+		if !ok {
+			return c.Status(409).SendString("Files do not share identical content")
+		}
+
+		log.Printf("🔕 Suppressing content hash %s (%d copies)", hash, len(req.Files))
+		s.cache.AddSuppressedHash(hash, req.Note)
+		s.audit(c, db.AuditSuppress, hash, fmt.Sprintf("%d copies", len(req.Files)))
+		return c.Status(200).JSON(fiber.Map{"hash": hash})
+	})
+
+	api.Get("/suppressed", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(200).JSON(fiber.Map{"hashes": []interface{}{}})
+		}
+		return c.Status(200).JSON(fiber.Map{"hashes": s.cache.ListSuppressedHashes()})
+	})
+
+	api.Delete("/suppressed/:hash", func(c *fiber.Ctx) error {
+		if s.cache == nil {
+			return c.Status(503).SendString("Cache is not available")
+		}
+		hash := c.Params("hash")
+		log.Printf("🔔 Removing content hash suppression: %s", hash)
+		s.cache.RemoveSuppressedHash(hash)
+		s.audit(c, db.AuditUnsuppress, hash, "")
+		return c.SendStatus(200)
+	})
+}
+
+// isSuppressed reports whether a group consists solely of a suppressed content hash
+func (s *Server) isSuppressed(files []reporter.FileInfo) bool {
+	return reporter.Suppressed(files, func(paths []string) bool { return hashing.IsSuppressed(s.cache, paths) })
+}
+
+func filePaths(files []reporter.FileInfo) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+	return paths
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/hashing"
+	"archive-duplicate-finder/internal/refnote"
+	"archive-duplicate-finder/internal/reporter"
+	"archive-duplicate-finder/internal/trash"
+	"archive-duplicate-finder/internal/txn"
+	"archive-duplicate-finder/internal/vfs"
+	"log"
+	"path/filepath"
+	"time"
+)
+
+// keepTrash applies the trash retention when the dashboard starts and once a day after that
+func (s *Server) keepTrash() {
+	for {
+		s.emptyTrash()
+		time.Sleep(24 * time.Hour)
+	}
+}
+
+// emptyTrash removes the trash day folders older than the configured retention
+func (s *Server) emptyTrash() {
+	s.mu.Lock()
+	dir, days := s.trashPath, 0
+	if s.config != nil {
+		days = s.config.TrashRetentionDays
+	}
+	s.mu.Unlock()
+	if dir == "" || days <= 0 {
+		return
+	}
+
+	res, err := trash.Purge(dir, days)
+	if err != nil {
+		log.Printf("⚠️ Could not empty old trash folders: %v", err)
+	}
+	if res.Folders > 0 {
+		log.Printf("🧹 Emptied %d trash folders older than %d days (%d files, %.1f MB)", res.Folders, days, res.Files, float64(res.Bytes)/(1<<20))
+	}
+}
+
+// recoverStaged puts back, when the dashboard starts, the files a group resolved by a run that
+// never ended left set aside in the scanned folder
+func (s *Server) recoverStaged() {
+	s.mu.Lock()
+	dir, recursive := s.scanDir, true
+	if s.config != nil {
+		if dir == "" {
+			dir = s.config.Directory
+		}
+		recursive = s.config.Recursive
+	}
+	s.mu.Unlock()
+	if dir == "" || vfs.IsRemote(dir) {
+		return
+	}
+
+	restored, stuck := txn.Recover(dir, recursive, time.Hour)
+	for _, p := range restored {
+		log.Printf("♻️  Put back %s, set aside by a cleanup that did not finish", p)
+	}
+	for _, err := range stuck {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// logCopyProgress logs a copy into a trash on another filesystem every 10%
+func logCopyProgress(path string) func(done, total int64) {
+	next := int64(0)
+	return func(done, total int64) {
+		if pct := done * 100 / max(total, 1); pct >= next {
+			log.Printf("📦 Copying to trash: %s (%d%%)", filepath.Base(path), pct)
+			next = pct/10*10 + 10
+		}
+	}
+}
+
+// refNote prepares the reference note for a file about to be removed. Without kept, the note
+// points to another file of the report group holding path. The caller must hold s.mu; hashes
+// are added by hashNote.
+func (s *Server) refNote(path, kept string) refnote.Note {
+	note := refnote.Note{Removed: path, Kept: kept, Action: "deleted"}
+	var group []reporter.FileInfo
+	if s.report != nil {
+		for _, g := range s.report.SizeGroups {
+			if containsPath(g.Files, path) {
+				group = g.Files
+			}
+		}
+		for _, groups := range [][]reporter.SimilarityGroup{s.report.SimilarGroups, s.report.VisualGroups} {
+			for _, g := range groups {
+				if group == nil && containsPath(g.Files, path) {
+					group = g.Files
+				}
+			}
+		}
+	}
+	if group != nil {
+		note.GroupHash = reporter.CalculateGroupHash(group)
+	}
+	for _, f := range group {
+		switch {
+		case f.Path == path:
+			note.RemovedSize, note.Similarity = f.Size, f.Similarity()
+		case note.Kept == "" || note.Kept == f.Path:
+			note.Kept, note.KeptSize = f.Path, f.Size
+		}
+	}
+	return note
+}
+
+// hashNote adds the content hashes to a note, before the removed file is gone
+func (s *Server) hashNote(note *refnote.Note) {
+	note.RemovedSHA256, _ = hashing.FileHash(s.cache, note.Removed)
+	if note.Kept != "" {
+		note.KeptSHA256, _ = hashing.FileHash(s.cache, note.Kept)
+	}
+}
+
+func containsPath(files []reporter.FileInfo, path string) bool {
+	for _, f := range files {
+		if f.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/reporter"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerTreeRoutes adds the size tree of the scanned directory, for the treemap of the dashboard
+func (s *Server) registerTreeRoutes(api fiber.Router) {
+	// Nested directories with their sizes and duplicate bytes: ?depth=N folds deeper ones
+	api.Get("/tree", func(c *fiber.Ctx) error {
+		depth := c.QueryInt("depth", 0)
+		if depth < 0 {
+			return c.Status(400).SendString("depth cannot be negative")
+		}
+
+		s.mu.Lock()
+		if s.report == nil && len(s.allFiles) == 0 {
+			s.mu.Unlock()
+			return c.Status(404).SendString("No report available")
+		}
+		var report reporter.Report
+		if s.report != nil {
+			report = s.filteredReport()
+		}
+		files, root, deleteMode := s.allFiles, s.scanDir, ""
+		if s.config != nil {
+			deleteMode = s.config.DeleteMode
+		}
+		s.mu.Unlock()
+
+		return c.JSON(reporter.SizeTree(report, files, root, deleteMode, depth))
+	})
+}
@@ -0,0 +1,122 @@
+package web
+
+import (
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/jobs"
+	"archive-duplicate-finder/internal/scanner"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// visualRun keeps the checkpoint of a running visual analysis, so that it resumes after a
+// restart instead of starting over. A nil visualRun (no cache) records nothing.
+type visualRun struct {
+	cache *db.Cache
+	mu    sync.Mutex
+	cp    db.VisualCheckpoint
+}
+
+// startVisualRun picks up the checkpoint of root, if any, and returns the files left to hash:
+// those the interrupted run failed on are skipped unless they changed since
+func (s *Server) startVisualRun(root string, files []scanner.ArchiveFile) (*visualRun, []scanner.ArchiveFile) {
+	if s.cache == nil {
+		return nil, files
+	}
+	cp, resumed := s.cache.VisualCheckpoint(root)
+	if !resumed {
+		cp = db.VisualCheckpoint{Root: root, Started: time.Now().Format(time.RFC3339)}
+	}
+	failed := make(map[string]db.VisualError, len(cp.Errors))
+	for _, e := range cp.Errors {
+		failed[e.Path] = e
+	}
+
+	var pending []scanner.ArchiveFile
+	var errs []db.VisualError
+	for _, f := range files {
+		if e, ok := failed[f.Path]; ok && e.ModTime == f.ModTime.Format(time.RFC3339) {
+			errs = append(errs, e)
+			continue
+		}
+		pending = append(pending, f)
+	}
+	if resumed {
+		log.Printf("🎨 Resuming the visual analysis started %s (%d files failed before and are skipped)", cp.Started, len(errs))
+	}
+	cp.Total, cp.Done, cp.Errors = len(files), len(errs), errs
+	if cp.Errors == nil {
+		cp.Errors = []db.VisualError{}
+	}
+	run := &visualRun{cache: s.cache, cp: cp}
+	run.save(context.Background())
+	return run, pending
+}
+
+// fileDone counts a file handled by the analysis, recording why it failed if it did
+func (r *visualRun) fileDone(f scanner.ArchiveFile, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cp.Done++
+	if err != nil {
+		r.cp.Errors = append(r.cp.Errors, db.VisualError{Path: f.Path, ModTime: f.ModTime.Format(time.RFC3339), Error: err.Error()})
+	}
+}
+
+// save writes the checkpoint, noting whether the job is paused
+func (r *visualRun) save(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	cp := r.cp
+	cp.Paused = jobs.Paused(ctx)
+	r.mu.Unlock()
+	if err := r.cache.SaveVisualCheckpoint(cp); err != nil {
+		log.Printf("⚠️ Could not save the visual analysis checkpoint: %v", err)
+	}
+}
+
+// finish forgets the checkpoint of a finished or canceled run and returns the files it
+// failed on
+func (r *visualRun) finish() []db.VisualError {
+	if r == nil {
+		return nil
+	}
+	if err := r.cache.ClearVisualCheckpoint(r.cp.Root); err != nil {
+		log.Printf("⚠️ Could not clear the visual analysis checkpoint: %v", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cp.Errors
+}
+
+// resumeVisual queues the visual analysis of the scan directory again when its checkpoint shows
+// it was interrupted, e.g. by a restart. A run that was paused is queued paused.
+func (s *Server) resumeVisual() {
+	if s.cache == nil {
+		return
+	}
+	s.mu.Lock()
+	dir, scanned := s.scanDir, s.report != nil
+	s.mu.Unlock()
+	if !scanned {
+		return
+	}
+	cp, ok := s.cache.VisualCheckpoint(dir)
+	if !ok {
+		return
+	}
+	job, queued := s.jobs.SubmitPausable(jobVisual, s.RunVisual)
+	if !queued {
+		return
+	}
+	log.Printf("🎨 Visual analysis of %s was interrupted with %d of %d files left; resuming it", dir, cp.Remaining, cp.Total)
+	if cp.Paused {
+		s.jobs.Pause(job.ID)
+	}
+}
@@ -0,0 +1,680 @@
+// Package client is a typed Go client for the dashboard REST API (/api/v1) and its JSON-RPC
+// machine API (see Call). The full API is described by the OpenAPI document the server
+// publishes at /api/v1/openapi.json.
+package client
+
+import (
+	"archive-duplicate-finder/internal/archive"
+	"archive-duplicate-finder/internal/config"
+	"archive-duplicate-finder/internal/db"
+	"archive-duplicate-finder/internal/estimate"
+	"archive-duplicate-finder/internal/jobs"
+	"archive-duplicate-finder/internal/membudget"
+	"archive-duplicate-finder/internal/quarantine"
+	"archive-duplicate-finder/internal/reporter"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// APIPrefix is where the version of the API this client speaks is served
+const APIPrefix = "/api/v1"
+
+// Types shared with the server, so callers outside this module can name them
+type (
+	Report           = reporter.Report
+	FileInfo         = reporter.FileInfo
+	ClusterMetrics   = reporter.ClusterMetrics
+	DirGroup         = reporter.DirGroup
+	SizeNode         = reporter.SizeNode
+	TypeStats        = reporter.TypeStats
+	GroupStats       = reporter.GroupStats
+	Summary          = reporter.Summary
+	Run              = db.Run
+	Config           = config.AppConfig
+	Estimate         = estimate.Estimate
+	Job              = jobs.Job
+	TreeNode         = archive.TreeNode
+	TextPreview      = archive.TextPreview
+	Extracted        = archive.Extracted
+	CacheStats       = db.CacheStats
+	GCResult         = db.GCResult
+	IgnoredGroup     = db.IgnoredGroup
+	GroupReview      = db.GroupReview
+	AuditEntry       = db.AuditEntry
+	Operation        = db.Operation
+	SuppressedHash   = db.SuppressedHash
+	QuarantineItem   = quarantine.Item
+	VisualCheckpoint = db.VisualCheckpoint
+	MemoryUsage      = membudget.Usage
+)
+
+// Client calls a running dashboard. The zero HTTP client means http.DefaultClient.
+type Client struct {
+	BaseURL string // "http://localhost:8080"
+	Token   string // Dashboard access token; empty when the dashboard is open
+	Name    string // Who the actions are recorded for in the audit trail; "anonymous" when empty
+	HTTP    *http.Client
+}
+
+// New returns a client for the dashboard at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), Token: token}
+}
+
+// Error is a response outside the 2xx range
+type Error struct {
+	Status  int
+	Message string // Body of the response
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("api: %d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+}
+
+// do sends a request and decodes a JSON response into out (skipped when out is nil)
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	resp, err := c.send(ctx, method, path, query, body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if w, ok := out.(io.Writer); ok {
+		_, err = io.Copy(w, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send sends a request with extra headers. Responses outside the 2xx and 304 range are
+// returned as an *Error; the caller closes the body of the others.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body any, header http.Header) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	u := c.BaseURL + APIPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.Name != "" {
+		req.Header.Set("X-Client-Name", c.Name)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if (resp.StatusCode < 200 || resp.StatusCode > 299) && resp.StatusCode != http.StatusNotModified {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, &Error{Status: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	return resp, nil
+}
+
+// confirmQuery skips the server's confirmation for long analyses
+func confirmQuery(confirm bool) url.Values {
+	if !confirm {
+		return nil
+	}
+	return url.Values{"confirm": {"1"}}
+}
+
+// Report returns the current report. Before any scan its Status is "idle".
+func (c *Client) Report(ctx context.Context) (*Report, error) {
+	var r Report
+	err := c.do(ctx, http.MethodGet, "/report", nil, nil, &r)
+	return &r, err
+}
+
+// DirGroups returns the duplicates of the current report rolled up per directory,
+// subdirectories included, most redundant bytes first
+func (c *Client) DirGroups(ctx context.Context) ([]DirGroup, error) {
+	var r Report
+	err := c.do(ctx, http.MethodGet, "/report", url.Values{"group_by": {reporter.GroupByDir}}, nil, &r)
+	return r.ByDir, err
+}
+
+// Tree returns the scanned directory as nested folders with their sizes and duplicate bytes.
+// Folders deeper than depth are folded into their parent; 0 keeps them all.
+func (c *Client) Tree(ctx context.Context, depth int) (*SizeNode, error) {
+	var tree SizeNode
+	err := c.do(ctx, http.MethodGet, "/tree", url.Values{"depth": {strconv.Itoa(depth)}}, nil, &tree)
+	return &tree, err
+}
+
+// Stats are the totals of the current report with the breakdowns of the overview page
+type Stats struct {
+	TotalFiles     int             `json:"totalFiles"`
+	Duplicates     int             `json:"duplicates"`
+	Similar        int             `json:"similar"`
+	Duration       float64         `json:"duration"`
+	ClusterMetrics *ClusterMetrics `json:"clusterMetrics"`
+	ByType         []TypeStats     `json:"byType"`
+	LargestFiles   []FileInfo      `json:"largestFiles"`
+	LargestGroups  []GroupStats    `json:"largestGroups"`
+	Trend          *Trend          `json:"trend"` // Nil until the scan history holds two runs of the directory
+}
+
+// Trend compares the last two recorded runs of the scanned directory
+type Trend struct {
+	Current  Run     `json:"current"`
+	Previous Run     `json:"previous"`
+	Delta    Summary `json:"delta"`
+}
+
+// Stats returns the totals of the current report, by file type, with its largest files and groups
+func (c *Client) Stats(ctx context.Context) (*Stats, error) {
+	var stats Stats
+	err := c.do(ctx, http.MethodGet, "/stats", nil, nil, &stats)
+	return &stats, err
+}
+
+// ReportIfChanged returns the current report unless it still matches etag, the ETag returned
+// with a previous report. An unchanged report comes back as nil, without being downloaded.
+func (c *Client) ReportIfChanged(ctx context.Context, etag string) (report *Report, newETag string, err error) {
+	header := http.Header{}
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	resp, err := c.send(ctx, http.MethodGet, "/report", nil, nil, header)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, nil
+	}
+	var r Report
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, "", err
+	}
+	return &r, resp.Header.Get("ETag"), nil
+}
+
+// AllFiles returns every scanned archive
+func (c *Client) AllFiles(ctx context.Context) ([]FileInfo, error) {
+	var resp struct {
+		Files []FileInfo `json:"files"`
+	}
+	err := c.do(ctx, http.MethodGet, "/all-files", nil, nil, &resp)
+	return resp.Files, err
+}
+
+// StartScan queues a full scan of the configured directory; poll Job for its progress
+func (c *Client) StartScan(ctx context.Context) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodPost, "/start-scan", nil, nil, &job)
+	return job, err
+}
+
+// ScanFolder queues a scan of another folder with the saved settings, leaving the configured
+// directory as it is; the report then covers that folder
+func (c *Client) ScanFolder(ctx context.Context, dir string) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodPost, "/start-scan", url.Values{"dir": {dir}}, nil, &job)
+	return job, err
+}
+
+// RunStep3 queues the similar-name analysis. Without confirm, an analysis projected to run
+// longer than the configured limit is refused with a 409 Error.
+func (c *Client) RunStep3(ctx context.Context, confirm bool) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodPost, "/run-step-3", confirmQuery(confirm), nil, &job)
+	return job, err
+}
+
+// RunVisual queues the visual analysis, with the same confirmation as RunStep3
+func (c *Client) RunVisual(ctx context.Context, confirm bool) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodPost, "/run-visual", confirmQuery(confirm), nil, &job)
+	return job, err
+}
+
+// Jobs lists the queued, running and recently ended jobs, newest first
+func (c *Client) Jobs(ctx context.Context) ([]Job, error) {
+	var resp struct {
+		Jobs []Job `json:"jobs"`
+	}
+	err := c.do(ctx, http.MethodGet, "/jobs", nil, nil, &resp)
+	return resp.Jobs, err
+}
+
+// Job returns one job
+func (c *Client) Job(ctx context.Context, id string) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, nil, &job)
+	return job, err
+}
+
+// CancelJob stops a queued or running job
+func (c *Client) CancelJob(ctx context.Context, id string) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodDelete, "/jobs/"+url.PathEscape(id), nil, nil, &job)
+	return job, err
+}
+
+// PauseJob holds a pausable job (Job.Pausable) at its next checkpoint
+func (c *Client) PauseJob(ctx context.Context, id string) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodPost, "/jobs/"+url.PathEscape(id)+"/pause", nil, nil, &job)
+	return job, err
+}
+
+// ResumeJob lets a paused job go on
+func (c *Client) ResumeJob(ctx context.Context, id string) (Job, error) {
+	var job Job
+	err := c.do(ctx, http.MethodPost, "/jobs/"+url.PathEscape(id)+"/resume", nil, nil, &job)
+	return job, err
+}
+
+// VisualCheckpoint returns the state of an unfinished visual analysis; the error is an *Error
+// with status 404 when there is none
+func (c *Client) VisualCheckpoint(ctx context.Context) (VisualCheckpoint, error) {
+	var cp VisualCheckpoint
+	err := c.do(ctx, http.MethodGet, "/visual/checkpoint", nil, nil, &cp)
+	return cp, err
+}
+
+// Estimate returns the projected cost of the on-demand analyses
+func (c *Client) Estimate(ctx context.Context) (Estimate, error) {
+	var resp struct {
+		Estimate Estimate `json:"estimate"`
+	}
+	err := c.do(ctx, http.MethodGet, "/estimate", nil, nil, &resp)
+	return resp.Estimate, err
+}
+
+// Delete removes a file, or moves it to the trash folder when one is configured
+func (c *Client) Delete(ctx context.Context, path string) error {
+	return c.do(ctx, http.MethodPost, "/delete", nil, map[string]string{"path": path}, nil)
+}
+
+// MarkAsGood hides a group from future reports. A nil ttlDays uses the configured default.
+func (c *Client) MarkAsGood(ctx context.Context, files []FileInfo, ttlDays *int) error {
+	return c.MarkGroupAsGood(ctx, "", files, ttlDays)
+}
+
+// MarkGroupAsGood hides a group of the report from future reports, also after it gains a
+// member, by its stable ID (SizeGroup.ID, SimilarityGroup.ID)
+func (c *Client) MarkGroupAsGood(ctx context.Context, groupID string, files []FileInfo, ttlDays *int) error {
+	body := struct {
+		Files   []FileInfo `json:"files"`
+		GroupID string     `json:"group_id,omitempty"`
+		TTLDays *int       `json:"ttl_days,omitempty"`
+	}{files, groupID, ttlDays}
+	return c.do(ctx, http.MethodPost, "/mark-as-good", nil, body, nil)
+}
+
+// ReviewGroup sets the review status ("pending", "reviewed" or "resolved") and note of a group
+// of the report, by its stable ID; a nil field keeps its value
+func (c *Client) ReviewGroup(ctx context.Context, groupID string, status, note *string) (GroupReview, error) {
+	body := struct {
+		Status *string `json:"status,omitempty"`
+		Note   *string `json:"note,omitempty"`
+	}{status, note}
+	var review GroupReview
+	err := c.do(ctx, http.MethodPatch, "/groups/"+url.PathEscape(groupID), nil, body, &review)
+	return review, err
+}
+
+// Audit returns who deleted, ignored or reviewed what, newest first. Empty actor and action
+// match everything; limit 0 uses the server default.
+func (c *Client) Audit(ctx context.Context, actor, action string, limit int) ([]AuditEntry, error) {
+	query := url.Values{}
+	if actor != "" {
+		query.Set("actor", actor)
+	}
+	if action != "" {
+		query.Set("action", action)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+	err := c.do(ctx, http.MethodGet, "/audit", query, nil, &resp)
+	return resp.Entries, err
+}
+
+// Operations searches the log of file operations and ignores, newest first. path matches the
+// location before or after an operation; empty arguments match everything.
+func (c *Client) Operations(ctx context.Context, path, action string, limit int) ([]Operation, error) {
+	query := url.Values{}
+	if path != "" {
+		query.Set("path", path)
+	}
+	if action != "" {
+		query.Set("action", action)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp struct {
+		Operations []Operation `json:"operations"`
+	}
+	err := c.do(ctx, http.MethodGet, "/operations", query, nil, &resp)
+	return resp.Operations, err
+}
+
+// IgnoredGroups lists the groups marked as good
+func (c *Client) IgnoredGroups(ctx context.Context) ([]IgnoredGroup, error) {
+	var resp struct {
+		Groups []IgnoredGroup `json:"groups"`
+	}
+	err := c.do(ctx, http.MethodGet, "/ignored-groups", nil, nil, &resp)
+	return resp.Groups, err
+}
+
+// Unignore brings an ignored group back
+func (c *Client) Unignore(ctx context.Context, hash string) error {
+	return c.do(ctx, http.MethodDelete, "/ignored-groups/"+url.PathEscape(hash), nil, nil, nil)
+}
+
+// Suppress stops a group of byte-identical files from being reported and returns their content hash
+func (c *Client) Suppress(ctx context.Context, files []FileInfo, note string) (string, error) {
+	body := struct {
+		Files []FileInfo `json:"files"`
+		Note  string     `json:"note"`
+	}{files, note}
+	var resp struct {
+		Hash string `json:"hash"`
+	}
+	err := c.do(ctx, http.MethodPost, "/suppress", nil, body, &resp)
+	return resp.Hash, err
+}
+
+// Protected returns the saved protection patterns
+func (c *Client) Protected(ctx context.Context) ([]string, error) {
+	var resp struct {
+		Patterns []string `json:"patterns"`
+	}
+	err := c.do(ctx, http.MethodGet, "/protected", nil, nil, &resp)
+	return resp.Patterns, err
+}
+
+// Protect saves a file, folder or glob that is never suggested for deletion
+func (c *Client) Protect(ctx context.Context, pattern string) error {
+	return c.do(ctx, http.MethodPost, "/protected", nil, map[string]string{"pattern": pattern}, nil)
+}
+
+// Unprotect removes a saved protection pattern
+func (c *Client) Unprotect(ctx context.Context, pattern string) error {
+	return c.do(ctx, http.MethodDelete, "/protected", url.Values{"pattern": {pattern}}, nil, nil)
+}
+
+// ArchiveContents returns the folder tree of an archive
+func (c *Client) ArchiveContents(ctx context.Context, path string) (*TreeNode, error) {
+	var resp struct {
+		Tree *TreeNode `json:"tree"`
+	}
+	err := c.do(ctx, http.MethodGet, "/archive-contents", url.Values{"path": {path}}, nil, &resp)
+	return resp.Tree, err
+}
+
+// CompareContents returns the trees of two archives with every entry marked "same", "changed" or "only"
+func (c *Client) CompareContents(ctx context.Context, path, other string) (a, b *TreeNode, err error) {
+	var resp struct {
+		Tree        *TreeNode `json:"tree"`
+		CompareTree *TreeNode `json:"compare_tree"`
+	}
+	err = c.do(ctx, http.MethodGet, "/archive-contents", url.Values{"path": {path}, "compare": {other}}, nil, &resp)
+	return resp.Tree, resp.CompareTree, err
+}
+
+// PreviewText returns the beginning of a text file inside an archive. An empty internalPath
+// picks its README or description; maxKB 0 uses the server default.
+func (c *Client) PreviewText(ctx context.Context, path, internalPath string, maxKB int) (*TextPreview, error) {
+	query := url.Values{"path": {path}}
+	if internalPath != "" {
+		query.Set("internal_path", internalPath)
+	}
+	if maxKB > 0 {
+		query.Set("max_kb", strconv.Itoa(maxKB))
+	}
+	var p TextPreview
+	err := c.do(ctx, http.MethodGet, "/preview-text", query, nil, &p)
+	return &p, err
+}
+
+// Preview writes the preview image of an archive (or of one of its entries) to w
+func (c *Client) Preview(ctx context.Context, path, internalPath string, w io.Writer) error {
+	query := url.Values{"path": {path}}
+	if internalPath != "" {
+		query.Set("internal_path", internalPath)
+	}
+	return c.do(ctx, http.MethodGet, "/preview", query, nil, w)
+}
+
+// Extract writes entries or folders of an archive into dest, a folder on the server's machine
+func (c *Client) Extract(ctx context.Context, path string, internalPaths []string, dest string) ([]Extracted, error) {
+	body := struct {
+		Path          string   `json:"path"`
+		InternalPaths []string `json:"internal_paths"`
+		Dest          string   `json:"dest"`
+	}{path, internalPaths, dest}
+	var resp struct {
+		Results []Extracted `json:"results"`
+		Error   string      `json:"error"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/extract", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return resp.Results, fmt.Errorf("%s", resp.Error)
+	}
+	return resp.Results, nil
+}
+
+// HookMatch is an existing file that an announced download would likely duplicate
+type HookMatch struct {
+	Name   string  `json:"name"`
+	Path   string  `json:"path"`
+	Size   int64   `json:"size"`
+	Score  float64 `json:"score"`
+	Reason string  `json:"reason"` // "same_hash", "same_name_and_size", "similar_name" or "same_size"
+}
+
+// PreDownload asks whether the library already holds a file about to be downloaded. size is
+// the expected size in bytes, 0 when unknown.
+func (c *Client) PreDownload(ctx context.Context, filename string, size int64) (bool, []HookMatch, error) {
+	body := struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+	}{filename, size}
+	var resp struct {
+		LikelyDuplicate bool        `json:"likely_duplicate"`
+		Matches         []HookMatch `json:"matches"`
+	}
+	err := c.do(ctx, http.MethodPost, "/hook/pre-download", nil, body, &resp)
+	return resp.LikelyDuplicate, resp.Matches, err
+}
+
+// Check asks whether the library already holds a file, by name, size and/or SHA-256 (empty
+// or 0 when unknown). exists is true when a match holds the same bytes, or the same name and size.
+func (c *Client) Check(ctx context.Context, filename string, size int64, sha256 string) (exists bool, matches []HookMatch, err error) {
+	body := struct {
+		Filename string `json:"filename,omitempty"`
+		Size     int64  `json:"size,omitempty"`
+		SHA256   string `json:"sha256,omitempty"`
+	}{filename, size, sha256}
+	var resp struct {
+		Exists  bool        `json:"exists"`
+		Matches []HookMatch `json:"matches"`
+	}
+	err = c.do(ctx, http.MethodPost, "/check", nil, body, &resp)
+	return resp.Exists, resp.Matches, err
+}
+
+// SearchMatch is a scanned file matching a search, with the groups of the report it belongs to
+type SearchMatch struct {
+	File      FileInfo `json:"file"`
+	MatchedBy []string `json:"matched_by"` // "name", "normalized" and/or "contents"
+	Entries   []string `json:"entries"`    // Entries of the archive matching the query
+	Groups    []struct {
+		ID   string `json:"id"`
+		Kind string `json:"kind"` // "identical", "similar" or "visual"
+		Name string `json:"name"`
+	} `json:"groups"`
+}
+
+// Search looks for scanned files by name and, with contents, for archives holding a matching
+// entry. It returns at most limit matches (0 for the server's default) and how many there are.
+func (c *Client) Search(ctx context.Context, query string, contents bool, limit int) ([]SearchMatch, int, error) {
+	params := url.Values{"q": {query}, "contents": {strconv.FormatBool(contents)}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	var resp struct {
+		Total   int           `json:"total"`
+		Matches []SearchMatch `json:"matches"`
+	}
+	err := c.do(ctx, http.MethodGet, "/search", params, nil, &resp)
+	return resp.Matches, resp.Total, err
+}
+
+// Quarantined lists the files held in the quarantine folder, most recently quarantined first
+func (c *Client) Quarantined(ctx context.Context) ([]QuarantineItem, error) {
+	var resp struct {
+		Items []QuarantineItem `json:"items"`
+	}
+	err := c.do(ctx, http.MethodGet, "/quarantine", nil, nil, &resp)
+	return resp.Items, err
+}
+
+// Quarantine moves files of the report into the quarantine folder. kept names the copy kept in
+// their place; empty for another file of their group.
+func (c *Client) Quarantine(ctx context.Context, paths []string, kept string) ([]QuarantineItem, error) {
+	body := struct {
+		Paths []string `json:"paths"`
+		Kept  string   `json:"kept,omitempty"`
+	}{paths, kept}
+	var resp struct {
+		Items []QuarantineItem `json:"items"`
+	}
+	err := c.do(ctx, http.MethodPost, "/quarantine", nil, body, &resp)
+	return resp.Items, err
+}
+
+// RestoreQuarantined moves quarantined files back where they were, by ID (QuarantineItem.ID)
+func (c *Client) RestoreQuarantined(ctx context.Context, ids []string) ([]QuarantineItem, error) {
+	var resp struct {
+		Restored []QuarantineItem `json:"restored"`
+	}
+	err := c.do(ctx, http.MethodPost, "/quarantine/restore", nil, map[string][]string{"ids": ids}, &resp)
+	return resp.Restored, err
+}
+
+// PurgeQuarantine deletes for good the files quarantined longer ago than olderThan ("30d", "2w"
+// or "12h") and returns how many and how many bytes
+func (c *Client) PurgeQuarantine(ctx context.Context, olderThan string) (int, int64, error) {
+	var resp struct {
+		Purged int   `json:"purged"`
+		Bytes  int64 `json:"bytes"`
+	}
+	err := c.do(ctx, http.MethodPost, "/quarantine/purge", nil, map[string]string{"older_than": olderThan}, &resp)
+	return resp.Purged, resp.Bytes, err
+}
+
+// ExportScript returns the cleanup plan as a "sh" or "ps1" script
+func (c *Client) ExportScript(ctx context.Context, format string) (string, error) {
+	var buf bytes.Buffer
+	err := c.do(ctx, http.MethodGet, "/export/script", url.Values{"format": {format}}, nil, &buf)
+	return buf.String(), err
+}
+
+// ExportExcludes returns the files to remove as an "rsync" --exclude-from or "rclone"
+// --filter-from list, so the next backup run leaves them out
+func (c *Client) ExportExcludes(ctx context.Context, format string) (string, error) {
+	var buf bytes.Buffer
+	err := c.do(ctx, http.MethodGet, "/export/excludes", url.Values{"format": {format}}, nil, &buf)
+	return buf.String(), err
+}
+
+// ExportJSON returns the current report with every group member pointing at its preview;
+// thumbnails embeds a small JPEG of each preview too
+func (c *Client) ExportJSON(ctx context.Context, thumbnails bool) (*Report, error) {
+	var r Report
+	query := url.Values{}
+	if thumbnails {
+		query.Set("thumbs", "1")
+	}
+	err := c.do(ctx, http.MethodGet, "/export/json", query, nil, &r)
+	return &r, err
+}
+
+// Export writes the current report rendered as "json", "csv", "pdf" or "html" to w; thumbnails
+// draws a small picture of each preview (not in CSV)
+func (c *Client) Export(ctx context.Context, w io.Writer, format string, thumbnails bool) error {
+	query := url.Values{"format": {format}}
+	if thumbnails {
+		query.Set("thumbs", "1")
+	}
+	return c.do(ctx, http.MethodGet, "/export", query, nil, w)
+}
+
+// CacheStats returns the size and contents of the cache
+func (c *Client) CacheStats(ctx context.Context) (CacheStats, error) {
+	var stats CacheStats
+	err := c.do(ctx, http.MethodGet, "/cache/stats", nil, nil, &stats)
+	return stats, err
+}
+
+// Memory returns how much of the memory budget extractions, previews and models take
+func (c *Client) Memory(ctx context.Context) (MemoryUsage, error) {
+	var usage MemoryUsage
+	err := c.do(ctx, http.MethodGet, "/memory", nil, nil, &usage)
+	return usage, err
+}
+
+// CacheGC drops the cache entries of files that no longer exist
+func (c *Client) CacheGC(ctx context.Context) (GCResult, error) {
+	var result GCResult
+	err := c.do(ctx, http.MethodPost, "/cache/gc", nil, nil, &result)
+	return result, err
+}
+
+// Config returns the active configuration, nil before setup
+func (c *Client) Config(ctx context.Context) (*Config, error) {
+	var cfg *Config
+	err := c.do(ctx, http.MethodGet, "/config", nil, nil, &cfg)
+	return cfg, err
+}
+
+// SaveConfig replaces the configuration
+func (c *Client) SaveConfig(ctx context.Context, cfg *Config) error {
+	return c.do(ctx, http.MethodPost, "/config", nil, cfg, nil)
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// RPCError is a JSON-RPC error. Errors of the operations carry the HTTP status the REST API
+// answers with for the same failure (404, 409, 507...) as Code.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc: %d: %s", e.Code, e.Message)
+}
+
+// Group is a duplicate group of any kind, as the machine API returns it
+type Group struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"` // "size", "similar" or "visual"
+	Name        string     `json:"name"`
+	Files       []FileInfo `json:"files"`
+	Verified    bool       `json:"verified,omitempty"`
+	Series      bool       `json:"series,omitempty"`
+	Reclaimable int64      `json:"reclaimable"` // Bytes freed by keeping only the largest file
+	Review      string     `json:"review,omitempty"`
+	Note        string     `json:"note,omitempty"`
+}
+
+// Resolution is what resolving a group removed
+type Resolution struct {
+	Kept    string           `json:"kept"`
+	Removed []string         `json:"removed"`
+	Items   []QuarantineItem `json:"items,omitempty"` // With the quarantine action
+}
+
+// Notification is a message of the event stream: Method "job.progress" with a Job as Params,
+// or "report.changed" with the version, status and progress of the new report
+type Notification struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+var rpcID atomic.Int64
+
+// Call runs a method of the JSON-RPC machine API (POST /api/v1/rpc) and decodes its result
+// into result (skipped when nil). A failed call returns an *RPCError.
+func (c *Client) Call(ctx context.Context, method string, params, result any) error {
+	req := struct {
+		JSONRPC string `json:"jsonrpc"`
+		ID      int64  `json:"id"`
+		Method  string `json:"method"`
+		Params  any    `json:"params,omitempty"`
+	}{"2.0", rpcID.Add(1), method, params}
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rpc", nil, req, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// Groups returns the duplicate groups of the report, of one kind unless kind is empty, from
+// offset on and at most limit of them (0 for all), with how many there are
+func (c *Client) Groups(ctx context.Context, kind string, offset, limit int) ([]Group, int, error) {
+	var resp struct {
+		Total  int     `json:"total"`
+		Groups []Group `json:"groups"`
+	}
+	params := map[string]any{"kind": kind, "offset": offset, "limit": limit}
+	err := c.Call(ctx, "groups.list", params, &resp)
+	return resp.Groups, resp.Total, err
+}
+
+// Group returns one group of the report by ID
+func (c *Client) Group(ctx context.Context, id string) (Group, error) {
+	var g Group
+	err := c.Call(ctx, "groups.get", map[string]string{"id": id}, &g)
+	return g, err
+}
+
+// ResolveGroup keeps one file of a group and removes the others, protected ones excepted.
+// action is "delete" (moved to the trash folder when one is set) or "quarantine".
+func (c *Client) ResolveGroup(ctx context.Context, id, keep, action string) (Resolution, error) {
+	var r Resolution
+	err := c.Call(ctx, "groups.resolve", map[string]string{"id": id, "keep": keep, "action": action}, &r)
+	return r, err
+}
+
+// Events follows the progress of jobs and reports (GET /api/v1/rpc/events), calling fn with
+// every notification until ctx is done or the server closes the stream
+func (c *Client) Events(ctx context.Context, fn func(Notification)) error {
+	resp, err := c.send(ctx, http.MethodGet, "/rpc/events", nil, nil, http.Header{"Accept": {"text/event-stream"}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20) // Report notifications carry every phase
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue // Blank separators and keep-alive comments
+		}
+		var n Notification
+		if err := json.Unmarshal([]byte(data), &n); err != nil {
+			return err
+		}
+		fn(n)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+// MCP relays one Model Context Protocol message to the assistant endpoint (POST /api/v1/mcp)
+// and returns the answer, or nil for notifications, which have none
+func (c *Client) MCP(ctx context.Context, message json.RawMessage) (json.RawMessage, error) {
+	resp, err := c.send(ctx, http.MethodPost, "/mcp", nil, message, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusAccepted {
+		return nil, nil
+	}
+	var answer json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
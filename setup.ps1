@@ -62,6 +62,6 @@ npm install
 Set-Location ..
 
 Write-Host "[DONE] Setup complete! You can now build and run the project." -ForegroundColor Cyan
-Write-Host "   Build: go build -o archive-finder.exe cmd/finder/main.go"
+Write-Host "   Build: cd ui; npm run build; cd ..; go build -tags embedui -o archive-finder.exe ./cmd/finder"
 Write-Host "   Run:   .\archive-finder.exe"
 Write-Host "   UI Development: cd ui; npm run dev"
@@ -68,6 +68,6 @@ npm install
 cd ..
 
 echo "[DONE] Setup complete! You can now build and run the project."
-echo "   Build: go build -o archive-finder cmd/finder/main.go"
+echo "   Build: (cd ui && npm run build) && go build -tags embedui -o archive-finder ./cmd/finder"
 echo "   Run:   ./archive-finder"
 echo "   UI Development: cd ui && npm run dev"
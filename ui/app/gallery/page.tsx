@@ -94,7 +94,7 @@ function GalleryItem({ file, index, onRefresh, onSelect }: { file: FileInfo, ind
         if (!isVisible) return
 
         const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-        const url = `${apiHost}/api/preview?path=${encodeURIComponent(file.path)}`
+        const url = `${apiHost}/api/v1/preview?path=${encodeURIComponent(file.path)}`
 
         if (file.type === 'video') {
             setPreviewData({ url, type: 'video' })
@@ -139,7 +139,7 @@ function GalleryItem({ file, index, onRefresh, onSelect }: { file: FileInfo, ind
     const handleOpen = (e: React.MouseEvent, mode: 'reveal' | 'launch') => {
         e.stopPropagation()
         const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-        fetch(`${apiHost}/api/open?path=${encodeURIComponent(file.path)}&mode=${mode}`)
+        fetch(`${apiHost}/api/v1/open?path=${encodeURIComponent(file.path)}&mode=${mode}`)
             .catch(err => console.error(`Failed to ${mode} file:`, err))
     }
 
@@ -149,7 +149,7 @@ function GalleryItem({ file, index, onRefresh, onSelect }: { file: FileInfo, ind
         setIsDeleting(true)
         const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
         try {
-            const response = await fetch(`${apiHost}/api/delete`, {
+            const response = await fetch(`${apiHost}/api/v1/delete`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ path: file.path })
@@ -312,7 +312,7 @@ function GlobalViewer({ files, selectedIndex, onClose, onPrev, onNext }: { files
             return
         }
 
-        fetch(`${apiHost}/api/list-previews?path=${encodeURIComponent(file.path)}`)
+        fetch(`${apiHost}/api/v1/list-previews?path=${encodeURIComponent(file.path)}`)
             .then(res => res.json())
             .then(data => {
                 if (data.previews && data.previews.length > 0) {
@@ -330,7 +330,7 @@ function GlobalViewer({ files, selectedIndex, onClose, onPrev, onNext }: { files
     useEffect(() => {
         const path = internalPreviews.length > 0 ? internalPreviews[internalIndex].path : ''
         const urlParam = path ? `&internal_path=${encodeURIComponent(path)}` : ''
-        const url = `${apiHost}/api/preview?path=${encodeURIComponent(file.path)}${urlParam}`
+        const url = `${apiHost}/api/v1/preview?path=${encodeURIComponent(file.path)}${urlParam}`
 
         // For internal videos, we also prefer direct URL to support Range requests
         const isVideo = (path || file.path).toLowerCase().match(/\.(mp4|webm|mkv|mov|avi)$/)
@@ -381,7 +381,7 @@ function GlobalViewer({ files, selectedIndex, onClose, onPrev, onNext }: { files
     const handleOpenOriginal = (e: React.MouseEvent) => {
         e.stopPropagation()
         const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-        fetch(`${apiHost}/api/open?path=${encodeURIComponent(file.path)}&mode=launch`)
+        fetch(`${apiHost}/api/v1/open?path=${encodeURIComponent(file.path)}&mode=launch`)
     }
 
     const [showInternalList, setShowInternalList] = useState(false)
@@ -589,7 +589,7 @@ export default function GalleryPage() {
     const fetchFiles = useCallback(async () => {
         try {
             const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-            const response = await fetch(`${apiHost}/api/all-files`)
+            const response = await fetch(`${apiHost}/api/v1/all-files`)
             if (!response.ok) throw new Error(`HTTP ${response.status}: ${response.statusText}`)
 
             const data: GalleryResponse = await response.json()
@@ -1,6 +1,6 @@
 "use client"
 
-import { useState, useEffect, useMemo, useCallback } from 'react'
+import { useState, useEffect, useMemo, useCallback, useRef } from 'react'
 import { motion, AnimatePresence } from 'framer-motion'
 import Link from 'next/link'
 import {
@@ -20,9 +20,15 @@ import {
   Image as ImageIcon,
   Loader2,
   Folder,
-  Grid3x3
+  Grid3x3,
+  Lock,
+  Database,
+  FolderTree,
+  ChevronRight,
+  X
 } from 'lucide-react'
 import ModelPreview from '@/components/ModelPreview'
+import ModelCompare, { ModelComparison } from '@/components/ModelCompare'
 
 interface FileInfo {
   name: string
@@ -30,16 +36,56 @@ interface FileInfo {
   size: number
   mod_time: string
   p_hash?: number
+  score?: number
+  protected?: boolean
+  file_count?: number
+  uncompressed_size?: number
+  suspicious?: string
 }
 
 interface SizeGroup {
+  id?: string // Stable across re-scans while most members stay
+  review?: 'pending' | 'reviewed' | 'resolved'
+  note?: string
   size: number
   files: FileInfo[]
+  payload?: boolean // Compressed archives with the same decompressed contents
+  verified?: boolean // Contents confirmed identical by hash
+  method?: string // Analysis profile that matched the files ("manifest", "geometry", "cover"...)
 }
 
 interface SimilarityGroup {
+  id?: string // Stable across re-scans while most members stay
+  review?: 'pending' | 'reviewed' | 'resolved'
+  note?: string
   base_name: string
   files: FileInfo[]
+  series?: boolean
+}
+
+interface CorruptFile extends FileInfo {
+  error: string
+}
+
+interface PreviewLink {
+  archive: FileInfo
+  image: FileInfo
+  distance: number // Hamming distance between the perceptual hashes (0 = same picture)
+}
+
+interface ClusterMetrics {
+  threshold: number
+  clusters: number
+  files: number
+  cohesion: number // Average similarity of members to their cluster's name (0-100)
+  separation: number // Average similarity of a cluster to the closest name left out (0-100)
+  silhouette: number // -1 (mixed up) to 1 (well apart)
+  chained: number
+  near_misses: number
+  unscored_pairs?: number // Candidate pairs of crowded name buckets left out
+  suggested_threshold?: number
+  advice?: string
+  cache?: { hit: boolean; reused: number; rebuilt: number; changed_files: number } // Clusters reused from the previous run
 }
 
 interface Report {
@@ -48,37 +94,78 @@ interface Report {
   similar_groups: SimilarityGroup[]
   visual_groups: SimilarityGroup[]
   visual_count: number
+  corrupt_files?: CorruptFile[]
+  corrupt_count?: number
+  preview_links?: PreviewLink[] // Loose images matching the preview of an archive in their folder
+  cluster_metrics?: ClusterMetrics // Quality of the Step 3 clusters
   analysis_duration_seconds: number
   status?: string
   progress?: number
+  phases?: Record<string, PhaseProgress>
+}
+
+interface PhaseProgress {
+  progress: number
+  done_bytes: number
+  total_bytes: number
+  elapsed_seconds: number
+  eta_seconds: number
+  finished: boolean
+}
+
+interface Job {
+  id: string
+  kind: string
+  status: string
+  phase?: string
+  progress: number
+  error?: string
+}
+
+function formatETA(seconds?: number): string {
+  if (!seconds || seconds <= 0) return ''
+  if (seconds < 60) return ` · ETA ${Math.round(seconds)}s`
+  if (seconds < 3600) return ` · ETA ${Math.floor(seconds / 60)}m${String(Math.round(seconds % 60)).padStart(2, '0')}s`
+  return ` · ETA ${Math.floor(seconds / 3600)}h${String(Math.floor((seconds % 3600) / 60)).padStart(2, '0')}m`
 }
 
 interface AppConfig {
   directory: string
   trash_path: string
-  threshold: number
+  threshold_name: number
+  threshold_visual: number
+  min_content_overlap: number
   recursive: boolean
+  loose_files?: boolean
   leave_ref: boolean
   delete_mode: string
+  verify?: boolean
+  enrich_contents?: boolean
+  verify_content?: boolean
+  phonetic?: string
+  confirm_above_minutes?: number
 }
 
-function SetupView({ onStart, isLoading }: { onStart: (config: AppConfig) => void, isLoading: boolean }) {
+function SetupView({ onStart, isLoading }: { onStart: (config: AppConfig, token: string) => void, isLoading: boolean }) {
+  const [token, setToken] = useState('')
   const [config, setConfig] = useState<AppConfig>({
     directory: '',
     trash_path: '',
-    threshold: 70,
+    threshold_name: 70,
+    threshold_visual: 8,
+    min_content_overlap: 100,
     recursive: true,
     leave_ref: false,
     delete_mode: 'oldest'
   })
 
   useEffect(() => {
-    // Load existing config if any
+    // Load the wizard draft (existing settings or the defaults of a fresh install)
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-    fetch(`${apiHost}/api/config`)
+    fetch(`${apiHost}/api/v1/setup`)
       .then(res => res.json())
       .then(data => {
-        if (data && data.directory) setConfig(data)
+        if (data?.draft) setConfig(data.draft)
       })
       .catch(err => console.error("Failed to load config:", err))
   }, [])
@@ -131,21 +218,53 @@ function SetupView({ onStart, isLoading }: { onStart: (config: AppConfig) => voi
             </div>
 
             <div className="space-y-3">
-              <label className="text-[10px] font-black text-gray-400 uppercase tracking-[0.2em] ml-2">Similarity threshold (%)</label>
+              <label className="text-[10px] font-black text-gray-400 uppercase tracking-[0.2em] ml-2">Name similarity (%)</label>
               <div className="relative group">
                 <Filter className="absolute left-5 top-1/2 -translate-y-1/2 w-5 h-5 text-gray-500 group-focus-within:text-cyan-500 transition-colors" />
                 <input
                   type="number"
                   min="0"
                   max="100"
-                  value={config.threshold}
-                  onChange={(e) => setConfig({ ...config, threshold: parseInt(e.target.value) || 0 })}
+                  value={config.threshold_name}
+                  onChange={(e) => setConfig({ ...config, threshold_name: parseInt(e.target.value) || 0 })}
                   className="w-full bg-white/5 border border-white/10 rounded-2xl py-5 pl-14 pr-6 text-sm font-medium focus:outline-none focus:border-cyan-500/50 focus:bg-white/[0.08] transition-all"
                 />
               </div>
             </div>
           </div>
 
+          <div className="grid grid-cols-1 md:grid-cols-2 gap-6">
+            <div className="space-y-3">
+              <label className="text-[10px] font-black text-gray-400 uppercase tracking-[0.2em] ml-2">Visual distance (0-64 bits)</label>
+              <div className="relative group">
+                <ImageIcon className="absolute left-5 top-1/2 -translate-y-1/2 w-5 h-5 text-gray-500 group-focus-within:text-purple-500 transition-colors" />
+                <input
+                  type="number"
+                  min="0"
+                  max="64"
+                  value={config.threshold_visual}
+                  onChange={(e) => setConfig({ ...config, threshold_visual: parseInt(e.target.value) || 0 })}
+                  className="w-full bg-white/5 border border-white/10 rounded-2xl py-5 pl-14 pr-6 text-sm font-medium focus:outline-none focus:border-purple-500/50 focus:bg-white/[0.08] transition-all"
+                />
+              </div>
+            </div>
+
+            <div className="space-y-3">
+              <label className="text-[10px] font-black text-gray-400 uppercase tracking-[0.2em] ml-2">Min content overlap (%)</label>
+              <div className="relative group">
+                <Layers className="absolute left-5 top-1/2 -translate-y-1/2 w-5 h-5 text-gray-500 group-focus-within:text-emerald-500 transition-colors" />
+                <input
+                  type="number"
+                  min="1"
+                  max="100"
+                  value={config.min_content_overlap}
+                  onChange={(e) => setConfig({ ...config, min_content_overlap: parseInt(e.target.value) || 0 })}
+                  className="w-full bg-white/5 border border-white/10 rounded-2xl py-5 pl-14 pr-6 text-sm font-medium focus:outline-none focus:border-emerald-500/50 focus:bg-white/[0.08] transition-all"
+                />
+              </div>
+            </div>
+          </div>
+
           <div className="flex flex-wrap gap-8 items-center bg-white/5 p-6 rounded-3xl border border-white/10">
             <label className="flex items-center gap-3 cursor-pointer group">
               <div className={`w-6 h-6 rounded-md border-2 flex items-center justify-center transition-all ${config.recursive ? 'bg-blue-600 border-blue-600 shadow-lg shadow-blue-500/20' : 'border-white/10 group-hover:border-white/30'}`} onClick={() => setConfig({ ...config, recursive: !config.recursive })}>
@@ -154,16 +273,58 @@ function SetupView({ onStart, isLoading }: { onStart: (config: AppConfig) => voi
               <span className="text-xs font-bold uppercase tracking-widest text-gray-400">Recursive Scan</span>
             </label>
 
+            <label className="flex items-center gap-3 cursor-pointer group">
+              <div className={`w-6 h-6 rounded-md border-2 flex items-center justify-center transition-all ${config.loose_files ? 'bg-blue-600 border-blue-600 shadow-lg shadow-blue-500/20' : 'border-white/10 group-hover:border-white/30'}`} onClick={() => setConfig({ ...config, loose_files: !config.loose_files })}>
+                {config.loose_files && <CheckCircle2 className="w-4 h-4 text-white" />}
+              </div>
+              <span className="text-xs font-bold uppercase tracking-widest text-gray-400">Loose Files Too</span>
+            </label>
+
             <label className="flex items-center gap-3 cursor-pointer group">
               <div className={`w-6 h-6 rounded-md border-2 flex items-center justify-center transition-all ${config.leave_ref ? 'bg-purple-600 border-purple-600 shadow-lg shadow-purple-500/20' : 'border-white/10 group-hover:border-white/30'}`} onClick={() => setConfig({ ...config, leave_ref: !config.leave_ref })}>
                 {config.leave_ref && <CheckCircle2 className="w-4 h-4 text-white" />}
               </div>
               <span className="text-xs font-bold uppercase tracking-widest text-gray-400">Leave Reference TXT</span>
             </label>
+
+            <label className="flex items-center gap-3 cursor-pointer group">
+              <div className={`w-6 h-6 rounded-md border-2 flex items-center justify-center transition-all ${config.verify ? 'bg-red-600 border-red-600 shadow-lg shadow-red-500/20' : 'border-white/10 group-hover:border-white/30'}`} onClick={() => setConfig({ ...config, verify: !config.verify })}>
+                {config.verify && <CheckCircle2 className="w-4 h-4 text-white" />}
+              </div>
+              <span className="text-xs font-bold uppercase tracking-widest text-gray-400">Verify Integrity</span>
+            </label>
+
+            <label className="flex items-center gap-3 cursor-pointer group">
+              <div className={`w-6 h-6 rounded-md border-2 flex items-center justify-center transition-all ${config.enrich_contents ? 'bg-cyan-600 border-cyan-600 shadow-lg shadow-cyan-500/20' : 'border-white/10 group-hover:border-white/30'}`} onClick={() => setConfig({ ...config, enrich_contents: !config.enrich_contents })}>
+                {config.enrich_contents && <CheckCircle2 className="w-4 h-4 text-white" />}
+              </div>
+              <span className="text-xs font-bold uppercase tracking-widest text-gray-400">Count Contents</span>
+            </label>
+
+            <label className="flex items-center gap-3 cursor-pointer group">
+              <div className={`w-6 h-6 rounded-md border-2 flex items-center justify-center transition-all ${config.verify_content ? 'bg-emerald-600 border-emerald-600 shadow-lg shadow-emerald-500/20' : 'border-white/10 group-hover:border-white/30'}`} onClick={() => setConfig({ ...config, verify_content: !config.verify_content })}>
+                {config.verify_content && <CheckCircle2 className="w-4 h-4 text-white" />}
+              </div>
+              <span className="text-xs font-bold uppercase tracking-widest text-gray-400">Verify Contents</span>
+            </label>
+          </div>
+
+          <div className="space-y-3">
+            <label className="text-[10px] font-black text-gray-400 uppercase tracking-[0.2em] ml-2">Dashboard Access Token (Optional)</label>
+            <div className="relative group">
+              <Lock className="absolute left-5 top-1/2 -translate-y-1/2 w-5 h-5 text-gray-500 group-focus-within:text-green-500 transition-colors" />
+              <input
+                type="password"
+                placeholder="At least 8 characters, leave empty for an open dashboard"
+                value={token}
+                onChange={(e) => setToken(e.target.value)}
+                className="w-full bg-white/5 border border-white/10 rounded-2xl py-5 pl-14 pr-6 text-sm font-medium focus:outline-none focus:border-green-500/50 focus:bg-white/[0.08] transition-all"
+              />
+            </div>
           </div>
 
           <button
-            onClick={() => onStart(config)}
+            onClick={() => onStart(config, token)}
             disabled={!config.directory || isLoading}
             className="w-full py-6 bg-gradient-to-r from-blue-600 to-cyan-600 hover:from-blue-500 hover:to-cyan-500 rounded-3xl text-sm font-black uppercase tracking-[0.3em] text-white shadow-2xl shadow-blue-500/20 transition-all active:scale-95 flex items-center justify-center gap-4 disabled:opacity-50 disabled:grayscale transition-all"
           >
@@ -182,12 +343,64 @@ function SetupView({ onStart, isLoading }: { onStart: (config: AppConfig) => voi
   )
 }
 
+function LoginView({ onLogin }: { onLogin: () => void }) {
+  const [token, setToken] = useState('')
+  const [name, setName] = useState('')
+  const [failed, setFailed] = useState(false)
+
+  const login = async () => {
+    const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+    const res = await fetch(`${apiHost}/api/v1/login`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ token, name })
+    })
+    if (res.ok) onLogin()
+    else setFailed(true)
+  }
+
+  return (
+    <div className="min-h-screen bg-[#0a0a0c] text-white flex items-center justify-center p-6">
+      <div className="w-full max-w-md glass-card p-10 rounded-[2.5rem] border border-blue-500/20 space-y-6">
+        <div className="flex items-center gap-3">
+          <Lock className="w-6 h-6 text-blue-400" />
+          <h1 className="text-xl font-black tracking-tight">DASHBOARD LOCKED</h1>
+        </div>
+        <input
+          type="password"
+          placeholder="Access token"
+          value={token}
+          onChange={(e) => { setToken(e.target.value); setFailed(false) }}
+          onKeyDown={(e) => e.key === 'Enter' && login()}
+          className="w-full bg-white/5 border border-white/10 rounded-2xl py-4 px-6 text-sm font-medium focus:outline-none focus:border-blue-500/50"
+        />
+        <input
+          type="text"
+          placeholder="Your name (recorded with your actions)"
+          value={name}
+          onChange={(e) => setName(e.target.value)}
+          onKeyDown={(e) => e.key === 'Enter' && login()}
+          className="w-full bg-white/5 border border-white/10 rounded-2xl py-4 px-6 text-sm font-medium focus:outline-none focus:border-blue-500/50"
+        />
+        {failed && <p className="text-xs text-red-400 font-bold">Invalid access token</p>}
+        <button
+          onClick={login}
+          disabled={!token}
+          className="w-full py-4 bg-blue-600 hover:bg-blue-500 rounded-2xl text-xs font-black uppercase tracking-[0.3em] disabled:opacity-50"
+        >
+          Unlock
+        </button>
+      </div>
+    </div>
+  )
+}
+
 function PreviewImage({ path }: { path: string }) {
   const [error, setError] = useState(false)
   const [isHovering, setIsHovering] = useState(true)
 
   const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-  const previewUrl = `${apiHost}/api/preview?path=${encodeURIComponent(path)}`
+  const previewUrl = `${apiHost}/api/v1/preview?path=${encodeURIComponent(path)}`
 
   // Basic extension check for UI hints
   const isVideo = /\.(mp4|webm|mov|mkv|avi)$/i.test(path)
@@ -235,6 +448,269 @@ function PreviewImage({ path }: { path: string }) {
   )
 }
 
+// README or description found inside an archive, shown under its preview
+function PreviewText({ path }: { path: string }) {
+  const [text, setText] = useState<{ path: string, text: string, truncated: boolean } | null>(null)
+
+  useEffect(() => {
+    let cancelled = false
+    const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+    fetch(`${apiHost}/api/v1/preview-text?path=${encodeURIComponent(path)}&max_kb=4`)
+      .then(res => res.ok ? res.json() : null)
+      .then(data => { if (!cancelled) setText(data) })
+      .catch(() => { })
+    return () => { cancelled = true }
+  }, [path])
+
+  if (!text || !text.text.trim()) return null
+  return (
+    <div className="mt-2 px-2 pb-1">
+      <div className="text-[8px] font-black text-gray-500 uppercase tracking-widest mb-1 truncate">{text.path}</div>
+      <pre className="text-[10px] text-gray-300 whitespace-pre-wrap break-words max-h-32 overflow-hidden font-mono leading-snug">
+        {text.text.slice(0, 600)}{(text.truncated || text.text.length > 600) && '…'}
+      </pre>
+    </div>
+  )
+}
+
+interface TreeNode {
+  name: string
+  path: string
+  dir?: boolean
+  size: number
+  compressed_size?: number
+  crc32?: string
+  files?: number
+  diff?: 'same' | 'changed' | 'only'
+  children?: TreeNode[]
+}
+
+const diffStyles: Record<string, string> = {
+  same: 'text-gray-500',
+  changed: 'text-amber-400',
+  only: 'text-cyan-400',
+}
+
+function TreeRow({ node, depth, selected, onSelect }: { node: TreeNode, depth: number, selected?: Set<string>, onSelect?: (path: string) => void }) {
+  const [open, setOpen] = useState(depth < 1)
+  return (
+    <div>
+      <div
+        className={`flex items-center gap-2 py-1 pr-2 rounded hover:bg-white/5 text-xs ${node.dir ? 'cursor-pointer' : ''}`}
+        style={{ paddingLeft: depth * 16 + 4 }}
+        onClick={() => node.dir && setOpen(!open)}
+      >
+        {onSelect && (
+          <input
+            type="checkbox"
+            className="shrink-0 accent-cyan-500"
+            checked={!!selected?.has(node.path)}
+            onClick={(e) => e.stopPropagation()}
+            onChange={() => onSelect(node.path)}
+          />
+        )}
+        {node.dir ? (
+          <ChevronRight className={`w-3 h-3 shrink-0 text-gray-500 transition-transform ${open ? 'rotate-90' : ''}`} />
+        ) : <span className="w-3 shrink-0" />}
+        {node.dir ? <Folder className="w-3.5 h-3.5 shrink-0 text-blue-400" /> : <FileText className="w-3.5 h-3.5 shrink-0 text-gray-500" />}
+        <span className={`truncate flex-1 ${node.diff ? diffStyles[node.diff] : 'text-gray-200'}`}>{node.name}</span>
+        {node.dir && node.files !== undefined && <span className="text-[10px] text-gray-600">{node.files} files</span>}
+        {node.crc32 && <span className="text-[10px] font-mono text-gray-600">{node.crc32}</span>}
+        {node.compressed_size !== undefined && node.compressed_size > 0 && (
+          <span className="text-[10px] text-gray-600" title="Compressed size">{formatBytes(node.compressed_size)}</span>
+        )}
+        <span className="text-[10px] font-bold text-gray-400 w-16 text-right">{formatBytes(node.size)}</span>
+      </div>
+      {node.dir && open && node.children?.map(child => (
+        <TreeRow key={child.path + (child.dir ? '/' : '')} node={child} depth={depth + 1} selected={selected} onSelect={onSelect} />
+      ))}
+    </div>
+  )
+}
+
+// stlEntries lists the paths of the STL models in an archive tree
+function stlEntries(node: TreeNode): string[] {
+  if (!node.dir) return node.path.toLowerCase().endsWith('.stl') ? [node.path] : []
+  return (node.children || []).flatMap(stlEntries)
+}
+
+// File browser for the contents of an archive, optionally side by side with another member of its group
+function ArchiveBrowser({ path, peers, onClose }: { path: string, peers: FileInfo[], onClose: () => void }) {
+  const [compare, setCompare] = useState('')
+  const [data, setData] = useState<{ tree: TreeNode, compare_tree?: TreeNode } | null>(null)
+  const [error, setError] = useState('')
+  const [selected, setSelected] = useState<Set<string>>(new Set())
+  const [dest, setDest] = useState('')
+  const [extractStatus, setExtractStatus] = useState('')
+  const [against, setAgainst] = useState('')
+  const [models, setModels] = useState<ModelComparison | null>(null)
+  const [modelStatus, setModelStatus] = useState('')
+
+  // A single selected STL entry can be compared with a model of the archive picked next to it
+  const selectedModels = [...selected].filter(p => p.toLowerCase().endsWith('.stl'))
+  const model = selectedModels.length === 1 ? selectedModels[0] : ''
+  const peerModels = useMemo(() => data?.compare_tree ? stlEntries(data.compare_tree) : [], [data])
+
+  useEffect(() => {
+    if (!model || peerModels.length === 0) { setAgainst(''); return }
+    const base = (p: string) => p.split('/').pop()!.toLowerCase()
+    setAgainst(peerModels.find(p => p === model) || peerModels.find(p => base(p) === base(model)) || peerModels[0])
+  }, [model, peerModels])
+
+  const compareModels = async () => {
+    const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+    setModelStatus('Comparing…')
+    try {
+      const res = await fetch(`${apiHost}/api/v1/compare-models`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+          a: { path, internal_path: model },
+          b: { path: compare, internal_path: against },
+          max_triangles: 20000
+        })
+      })
+      if (!res.ok) throw new Error(await res.text())
+      setModels(await res.json())
+      setModelStatus('')
+    } catch (err) {
+      setModelStatus('Error: ' + (err instanceof Error ? err.message : err))
+    }
+  }
+
+  const toggle = (p: string) => {
+    const next = new Set(selected)
+    if (next.has(p)) next.delete(p); else next.add(p)
+    setSelected(next)
+  }
+
+  const extract = async () => {
+    const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+    setExtractStatus('Extracting…')
+    try {
+      const res = await fetch(`${apiHost}/api/v1/extract`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ path, internal_paths: [...selected], dest })
+      })
+      if (!res.ok) throw new Error(await res.text())
+      const data = await res.json()
+      const failed = data.results.filter((r: { error?: string }) => r.error).length
+      setExtractStatus(`Extracted ${data.extracted} files${failed ? `, ${failed} failed` : ''}`)
+    } catch (err) {
+      setExtractStatus('Error: ' + err)
+    }
+  }
+
+  useEffect(() => {
+    const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+    let url = `${apiHost}/api/v1/archive-contents?path=${encodeURIComponent(path)}`
+    if (compare) url += `&compare=${encodeURIComponent(compare)}`
+    setData(null)
+    setError('')
+    setModels(null)
+    fetch(url)
+      .then(async res => { if (!res.ok) throw new Error(await res.text()); return res.json() })
+      .then(setData)
+      .catch(err => setError(String(err.message || err)))
+  }, [path, compare])
+
+  const panel = (title: string, tree: TreeNode, selectable: boolean) => (
+    <div className="flex-1 min-w-0 flex flex-col">
+      <div className="text-[10px] font-black text-gray-500 uppercase tracking-widest mb-2 truncate" title={title}>{title}</div>
+      <div className="flex-1 overflow-auto rounded-xl bg-black/30 border border-white/5 p-2">
+        {tree.children?.length ? tree.children.map(child => (
+          <TreeRow key={child.path + (child.dir ? '/' : '')} node={child} depth={0} selected={selected} onSelect={selectable ? toggle : undefined} />
+        )) : <div className="text-xs text-gray-500 p-2">Empty archive</div>}
+      </div>
+    </div>
+  )
+
+  return (
+    <div className="fixed inset-0 z-[200] bg-black/70 flex items-center justify-center p-8" onClick={(e) => { e.stopPropagation(); onClose() }}>
+      <div className="glass-card rounded-2xl border border-white/10 w-full max-w-5xl h-[80vh] flex flex-col p-6" onClick={(e) => e.stopPropagation()}>
+        <div className="flex items-center gap-3 mb-4">
+          <FolderTree className="w-5 h-5 text-cyan-400" />
+          <span className="text-sm font-bold text-white truncate flex-1">{path.split(/[\\/]/).pop()}</span>
+          {peers.length > 0 && (
+            <select
+              value={compare}
+              onChange={(e) => setCompare(e.target.value)}
+              className="bg-black/40 border border-white/10 rounded-lg text-xs text-gray-300 px-2 py-1 max-w-[280px]"
+            >
+              <option value="">Compare with…</option>
+              {peers.map(p => <option key={p.path} value={p.path}>{p.name}</option>)}
+            </select>
+          )}
+          <button onClick={onClose} className="p-1.5 rounded-lg hover:bg-white/10 text-gray-400"><X className="w-4 h-4" /></button>
+        </div>
+        {compare && (
+          <div className="flex gap-4 text-[10px] font-bold uppercase tracking-widest mb-3">
+            <span className="text-gray-500">Same</span>
+            <span className="text-amber-400">Changed</span>
+            <span className="text-cyan-400">Only here</span>
+          </div>
+        )}
+        {error ? (
+          <div className="text-xs text-red-400">{error}</div>
+        ) : models ? (
+          <div className="flex flex-col gap-3 flex-1 min-h-0">
+            <button onClick={() => setModels(null)} className="self-start text-[10px] font-black text-cyan-400 hover:text-cyan-300 uppercase tracking-widest">
+              ← Back to contents
+            </button>
+            <ModelCompare result={models} />
+          </div>
+        ) : !data ? (
+          <Loader2 className="w-6 h-6 animate-spin text-gray-500 m-auto" />
+        ) : (
+          <div className="flex gap-4 flex-1 min-h-0">
+            {panel(path, data.tree, true)}
+            {data.compare_tree && panel(compare, data.compare_tree, false)}
+          </div>
+        )}
+        {!models && model && against && (
+          <div className="flex items-center gap-3 mt-4">
+            <span className="text-[10px] text-gray-400 truncate max-w-[240px]" title={model}>{model.split('/').pop()}</span>
+            <span className="text-[10px] text-gray-600">vs</span>
+            <select
+              value={against}
+              onChange={(e) => setAgainst(e.target.value)}
+              className="bg-black/40 border border-white/10 rounded-lg text-xs text-gray-300 px-2 py-1 max-w-[280px]"
+            >
+              {peerModels.map(p => <option key={p} value={p}>{p}</option>)}
+            </select>
+            <button
+              onClick={compareModels}
+              className="px-4 py-2 bg-blue-600 hover:bg-blue-500 rounded-lg text-[10px] font-black text-white uppercase tracking-widest"
+            >
+              🔬 Compare models
+            </button>
+            {modelStatus && <span className="text-[10px] text-gray-400">{modelStatus}</span>}
+          </div>
+        )}
+        {selected.size > 0 && (
+          <div className="flex items-center gap-3 mt-4">
+            <input
+              value={dest}
+              onChange={(e) => setDest(e.target.value)}
+              placeholder="Destination folder"
+              className="flex-1 bg-black/40 border border-white/10 rounded-lg text-xs text-gray-200 px-3 py-2"
+            />
+            <button
+              onClick={extract}
+              disabled={!dest}
+              className="px-4 py-2 bg-cyan-600 hover:bg-cyan-500 disabled:opacity-40 rounded-lg text-[10px] font-black text-white uppercase tracking-widest"
+            >
+              Extract {selected.size}
+            </button>
+            {extractStatus && <span className="text-[10px] text-gray-400">{extractStatus}</span>}
+          </div>
+        )}
+      </div>
+    </div>
+  )
+}
+
 function formatBytes(bytes: number): string {
   if (bytes === 0) return '0 B'
   const k = 1024
@@ -243,8 +719,99 @@ function formatBytes(bytes: number): string {
   return parseFloat((bytes / Math.pow(k, i)).toFixed(1)) + ' ' + sizes[i]
 }
 
-function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void }) {
+interface CacheRootStats {
+  root: string
+  file_hashes: number
+  previews: number
+  visual_hashes: number
+  preview_overrides: number
+  dir_listings: number
+  total: number
+}
+
+interface CacheStats {
+  path: string
+  size_bytes: number
+  roots: CacheRootStats[]
+  ignored_groups: number
+}
+
+function CacheCard() {
+  const [stats, setStats] = useState<CacheStats | null>(null)
+  const [cleaning, setCleaning] = useState(false)
+  const [message, setMessage] = useState<string | null>(null)
+  const apiHost = typeof window !== 'undefined' && window.location.port === '3000' ? 'http://localhost:8080' : ''
+
+  const load = useCallback(async () => {
+    const res = await fetch(`${apiHost}/api/v1/cache/stats`)
+    if (res.ok) setStats(await res.json())
+  }, [apiHost])
+
+  useEffect(() => { load() }, [load])
+
+  const runGC = async () => {
+    setCleaning(true)
+    try {
+      const res = await fetch(`${apiHost}/api/v1/cache/gc`, { method: 'POST' })
+      if (!res.ok) throw new Error(await res.text())
+      const result = await res.json()
+      setMessage(`Removed ${result.total} stale entries` + (result.offline?.length ? ` (${result.offline.length} unreachable folders kept)` : ''))
+      load()
+    } catch (err) {
+      setMessage('Cleanup failed: ' + err)
+    } finally {
+      setCleaning(false)
+    }
+  }
+
+  const forget = async (root: string) => {
+    if (!confirm(`Forget every cached entry of ${root}?`)) return
+    await fetch(`${apiHost}/api/v1/cache/roots?root=${encodeURIComponent(root)}`, { method: 'DELETE' })
+    load()
+  }
+
+  if (!stats) return null
+
+  return (
+    <div className="glass-card p-6 rounded-3xl border border-white/10">
+      <h3 className="text-sm font-black mb-4 text-white uppercase tracking-widest flex items-center gap-3">
+        <Database className="w-4 h-4 text-emerald-400" />
+        Cache
+        <span className="ml-auto text-[10px] text-gray-500 font-bold">{formatBytes(stats.size_bytes)}</span>
+      </h3>
+      <div className="space-y-2 mb-4 max-h-48 overflow-y-auto">
+        {stats.roots.map(r => (
+          <div key={r.root} className="bg-white/5 px-3 py-2 rounded-xl border border-white/5 group">
+            <div className="flex items-center gap-2">
+              <div className="flex-1 text-[11px] font-bold text-gray-300 truncate" title={r.root}>{r.root || 'Unattributed'}</div>
+              {r.root && (
+                <button onClick={() => forget(r.root)} className="opacity-0 group-hover:opacity-100 text-gray-500 hover:text-red-400 transition-all" title="Forget this root">
+                  <Trash2 className="w-3 h-3" />
+                </button>
+              )}
+            </div>
+            <div className="text-[10px] text-gray-500 font-medium">
+              {r.file_hashes} hashes · {r.previews} previews · {r.visual_hashes} visual · {r.dir_listings} folders
+            </div>
+          </div>
+        ))}
+      </div>
+      <button
+        onClick={runGC}
+        disabled={cleaning}
+        className="w-full py-3 glass-card border-white/10 hover:border-emerald-500/40 text-gray-400 hover:text-white font-black text-[10px] uppercase tracking-[0.2em] rounded-2xl transition-all flex items-center justify-center gap-2 disabled:opacity-50"
+      >
+        {cleaning ? <Loader2 className="w-3 h-3 animate-spin" /> : <Trash2 className="w-3 h-3" />}
+        Remove Stale Entries
+      </button>
+      {message && <p className="mt-3 text-[10px] text-gray-500 font-medium">{message}</p>}
+    </div>
+  )
+}
+
+function FileItem({ file, peers, onRefresh }: { file: FileInfo, peers?: FileInfo[], onRefresh?: () => void }) {
   const [isHovered, setIsHovered] = useState(false)
+  const [showContents, setShowContents] = useState(false)
   const [isDeleting, setIsDeleting] = useState(false)
   const [showConfirm, setShowConfirm] = useState(false)
 
@@ -257,7 +824,7 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
   const handleOpen = (e: React.MouseEvent, mode: 'reveal' | 'launch') => {
     e.stopPropagation()
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-    fetch(`${apiHost}/api/open?path=${encodeURIComponent(file.path)}&mode=${mode}`)
+    fetch(`${apiHost}/api/v1/open?path=${encodeURIComponent(file.path)}&mode=${mode}`)
       .catch(err => console.error(`Failed to ${mode} file:`, err))
   }
 
@@ -267,7 +834,7 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
     setIsDeleting(true)
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
     try {
-      const response = await fetch(`${apiHost}/api/delete`, {
+      const response = await fetch(`${apiHost}/api/v1/delete`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ path: file.path })
@@ -299,10 +866,45 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
           <span className="text-[10px] font-black px-1.5 py-0.5 rounded bg-white/5 text-gray-500 uppercase tracking-tighter">
             {formatBytes(file.size)}
           </span>
+          {file.file_count !== undefined && (
+            <span
+              className="text-[10px] font-black px-1.5 py-0.5 rounded bg-cyan-500/10 text-cyan-400 uppercase tracking-tighter"
+              title={file.uncompressed_size ? `${formatBytes(file.uncompressed_size)} unpacked` : undefined}
+            >
+              {file.file_count} files
+            </span>
+          )}
+          {file.score !== undefined && (
+            <span
+              className={`text-[10px] font-black px-1.5 py-0.5 rounded uppercase tracking-tighter ${file.score >= 90 ? 'bg-green-500/10 text-green-400' : 'bg-yellow-500/10 text-yellow-400'}`}
+              title="Similarity against the cluster centroid"
+            >
+              {file.score.toFixed(0)}%
+            </span>
+          )}
+          {file.suspicious && (
+            <span className="text-[10px] font-black px-1.5 py-0.5 rounded bg-red-500/10 text-red-400 uppercase tracking-tighter flex items-center gap-1" title={file.suspicious}>
+              <AlertTriangle className="w-3 h-3" />
+              Suspicious
+            </span>
+          )}
+          {file.protected && (
+            <span className="text-[10px] font-black px-1.5 py-0.5 rounded bg-emerald-500/10 text-emerald-400 uppercase tracking-tighter flex items-center gap-1" title="Protected: never suggested for deletion">
+              <ShieldCheck className="w-3 h-3" />
+              Protected
+            </span>
+          )}
         </div>
         <p className="text-[10px] text-gray-500 font-medium truncate opacity-60 uppercase tracking-tighter">{file.path}</p>
       </div>
       <div className="flex gap-2">
+        <button
+          onClick={(e) => { e.stopPropagation(); setShowContents(true); setIsHovered(false) }}
+          className="p-2 bg-cyan-500/10 hover:bg-cyan-500/20 rounded-lg text-cyan-400 transition-all"
+          title="Browse Contents"
+        >
+          <FolderTree className="w-4 h-4" />
+        </button>
         <button
           onClick={(e) => handleOpen(e, 'reveal')}
           className="p-2 bg-blue-500/10 hover:bg-blue-500/20 rounded-lg text-blue-400 transition-all"
@@ -310,7 +912,7 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
         >
           <Folder className="w-4 h-4" />
         </button>
-        <button
+        {!file.protected && <button
           onClick={(e) => { e.stopPropagation(); setShowConfirm(true); }}
           disabled={isDeleting}
           className={`p-2 bg-red-500/10 hover:bg-red-500/20 rounded-lg text-red-400 transition-all ${isDeleting ? 'opacity-50 cursor-wait' : ''}`}
@@ -321,7 +923,7 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
           ) : (
             <Trash2 className="w-4 h-4" />
           )}
-        </button>
+        </button>}
       </div>
 
       <AnimatePresence>
@@ -355,8 +957,16 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
         )}
       </AnimatePresence>
 
+      {showContents && (
+        <ArchiveBrowser
+          path={file.path}
+          peers={(peers || []).filter(p => p.path !== file.path)}
+          onClose={() => setShowContents(false)}
+        />
+      )}
+
       <AnimatePresence>
-        {isHovered && (
+        {isHovered && !showContents && (
           <motion.div
             initial={{ opacity: 0, scale: 0.95, y: 10 }}
             animate={{ opacity: 1, scale: 1, y: 0 }}
@@ -365,6 +975,7 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
           >
             <div className="glass-card p-2 rounded-2xl shadow-2xl border border-blue-500/30">
               <PreviewImage path={file.path} />
+              <PreviewText path={file.path} />
             </div>
             <div className="w-3 h-3 bg-[#111114] rotate-45 border-r border-b border-blue-500/30 absolute -bottom-1.5 left-8 z-[-1]" />
           </motion.div>
@@ -374,16 +985,65 @@ function FileItem({ file, onRefresh }: { file: FileInfo, onRefresh?: () => void
   )
 }
 
+// GroupReview keeps the triage status and note of a group, stored under its stable ID
+function GroupReview({ group, onChange }: { group: { id?: string, review?: string, note?: string }, onChange: () => void }) {
+  if (!group.id) return null
+  const apiHost = typeof window !== 'undefined' && window.location.port === '3000' ? 'http://localhost:8080' : ''
+  const save = async (body: { status?: string, note?: string }) => {
+    try {
+      const res = await fetch(`${apiHost}/api/v1/groups/${group.id}`, {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify(body)
+      })
+      if (!res.ok) throw new Error(await res.text())
+      onChange()
+    } catch (err) {
+      console.error("Failed to save group review:", err)
+      alert("Error: " + err)
+    }
+  }
+  return (
+    <div className="flex items-center gap-1" onClick={(e) => e.stopPropagation()}>
+      <select
+        value={group.review || 'pending'}
+        onChange={(e) => save({ status: e.target.value })}
+        className="bg-white/5 border border-white/10 rounded-lg text-[9px] font-black uppercase tracking-widest text-gray-400 px-1.5 py-1"
+        title="Review status"
+      >
+        <option value="pending">Pending</option>
+        <option value="reviewed">Reviewed</option>
+        <option value="resolved">Resolved</option>
+      </select>
+      <button
+        onClick={() => {
+          const note = prompt("Note for this group", group.note || "")
+          if (note !== null) save({ note })
+        }}
+        className={`px-1.5 py-1 rounded-lg text-[9px] font-black uppercase tracking-widest transition-all ${group.note ? 'text-yellow-400 bg-yellow-500/10' : 'text-gray-500 hover:text-gray-300'}`}
+        title={group.note || "Add a note"}
+      >
+        Note
+      </button>
+    </div>
+  )
+}
+
 export default function Dashboard() {
   const [mounted, setMounted] = useState(false)
   const [data, setData] = useState<Report | null>(null)
   const [loading, setLoading] = useState(true)
   const [error, setError] = useState<string | null>(null)
+  const [locked, setLocked] = useState(false)
   const [searchQuery, setSearchQuery] = useState('')
   const [fileType, setFileType] = useState('all')
   const [status, setStatus] = useState<string | null>(null)
+  const [jobs, setJobs] = useState<Job[]>([])
+  const reportETag = useRef<string | null>(null)
   const [notified, setNotified] = useState(false)
   const [viewMode, setViewMode] = useState<'size' | 'similar' | 'visual'>('size')
+  const [reviewFilter, setReviewFilter] = useState('') // Review status the groups are filtered by
+  const [folderFilter, setFolderFilter] = useState('') // Folder the groups are narrowed to (?dir=, set by finder here)
   const [currentPage, setCurrentPage] = useState(1)
   const [itemsPerPage, setItemsPerPage] = useState(50)
   const [selectedFiles, setSelectedFiles] = useState<string[]>([])
@@ -407,6 +1067,18 @@ export default function Dashboard() {
     return () => window.removeEventListener('error', handleError)
   }, [])
 
+  // finder here opens the dashboard on the folder it scans
+  useEffect(() => {
+    const dir = new URLSearchParams(window.location.search).get('dir')
+    if (dir) setFolderFilter(dir)
+  }, [])
+
+  const clearFolderFilter = () => {
+    setFolderFilter('')
+    setCurrentPage(1)
+    window.history.replaceState(null, '', window.location.pathname)
+  }
+
 
   const requestNotificationPermission = () => {
     if ('Notification' in window) {
@@ -417,9 +1089,29 @@ export default function Dashboard() {
   const fetchData = useCallback(async () => {
     try {
       const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-      const response = await fetch(`${apiHost}/api/report`)
+      const query = new URLSearchParams()
+      if (reviewFilter) query.set('review', reviewFilter)
+      if (folderFilter) query.set('dir', folderFilter)
+      const response = await fetch(`${apiHost}/api/v1/report${query.toString() ? `?${query}` : ''}`)
+      if (response.status === 401) {
+        setLocked(true)
+        setLoading(false)
+        return
+      }
+      setLocked(false)
       if (!response.ok) throw new Error(`HTTP ${response.status}: ${response.statusText}`)
 
+      const jobsRes = await fetch(`${apiHost}/api/v1/jobs`)
+      if (jobsRes.ok) setJobs((await jobsRes.json()).jobs || [])
+
+      // Unchanged since the last poll: keep the current data instead of parsing it again
+      const etag = response.headers.get('ETag')
+      if (etag && etag === reportETag.current) {
+        setLoading(false)
+        return
+      }
+      reportETag.current = etag
+
       const report: Report = await response.json()
       console.log("📊 Data received:", {
         files: report.total_files,
@@ -446,7 +1138,7 @@ export default function Dashboard() {
       setError(err instanceof Error ? err.message : String(err))
       setLoading(false)
     }
-  }, [status, notified])
+  }, [status, notified, reviewFilter, folderFilter])
 
   useEffect(() => {
     if (!mounted) return
@@ -526,7 +1218,13 @@ export default function Dashboard() {
   const handleRunStep3 = async () => {
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
     try {
-      await fetch(`${apiHost}/api/run-step-3`, { method: 'POST' })
+      const res = await fetch(`${apiHost}/api/v1/run-step-3`, { method: 'POST' })
+      if (res.status === 409) {
+        // Projected to run longer than the configured limit
+        const info = await res.json()
+        if (!window.confirm(info.message)) return
+        await fetch(`${apiHost}/api/v1/run-step-3?confirm=1`, { method: 'POST' })
+      }
       setStatus('analyzing_step3')
     } catch (err) {
       console.error("Failed to run Step 3:", err)
@@ -537,7 +1235,13 @@ export default function Dashboard() {
   const handleRunVisual = async () => {
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
     try {
-      await fetch(`${apiHost}/api/run-visual`, { method: 'POST' })
+      const res = await fetch(`${apiHost}/api/v1/run-visual`, { method: 'POST' })
+      if (res.status === 409) {
+        // Projected to run longer than the configured limit
+        const info = await res.json()
+        if (!window.confirm(info.message)) return
+        await fetch(`${apiHost}/api/v1/run-visual?confirm=1`, { method: 'POST' })
+      }
       setStatus('analyzing_visual')
     } catch (err) {
       console.error("Failed to run Visual analysis:", err)
@@ -545,24 +1249,33 @@ export default function Dashboard() {
     }
   }
 
+  const handleCancelJob = async (id: string) => {
+    const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+    const res = await fetch(`${apiHost}/api/v1/jobs/${id}`, { method: 'DELETE' })
+    if (res.ok) {
+      const job: Job = await res.json()
+      setJobs(prev => prev.map(j => j.id === job.id ? job : j))
+    }
+  }
+
   const handleOpenDirectory = async () => {
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
     try {
-      await fetch(`${apiHost}/api/open-directory`, { method: 'POST' })
+      await fetch(`${apiHost}/api/v1/open-directory`, { method: 'POST' })
     } catch (err) {
       console.error("Failed to open directory:", err)
       alert("Error opening directory")
     }
   }
 
-  const handleMarkAsGood = async (e: React.MouseEvent, files: FileInfo[]) => {
+  const handleMarkAsGood = async (e: React.MouseEvent, files: FileInfo[], groupId?: string) => {
     e.stopPropagation()
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
     try {
-      const response = await fetch(`${apiHost}/api/mark-as-good`, {
+      const response = await fetch(`${apiHost}/api/v1/mark-as-good`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ files })
+        body: JSON.stringify({ files, group_id: groupId })
       })
       if (!response.ok) throw new Error(await response.text())
       fetchData() // Refresh data to hide the group
@@ -573,18 +1286,24 @@ export default function Dashboard() {
   }
 
   const [savingConfig, setSavingConfig] = useState(false)
-  const handleStartScan = async (config: AppConfig) => {
+  const handleStartScan = async (config: AppConfig, token: string) => {
     setSavingConfig(true)
     const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-    try {
-      // 1. Save Config
-      await fetch(`${apiHost}/api/config`, {
+    const post = async (body: object) => {
+      const res = await fetch(`${apiHost}/api/v1/setup`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify(config)
+        body: JSON.stringify(body)
       })
-      // 2. Start Scan
-      await fetch(`${apiHost}/api/start-scan`, { method: 'POST' })
+      if (!res.ok) throw new Error(await res.text())
+    }
+    try {
+      // Walk the setup wizard; the last step saves the settings and starts the scan
+      await post({ action: 'restart' })
+      await post({ step: 'roots', directory: config.directory, recursive: config.recursive, loose_files: !!config.loose_files })
+      await post({ step: 'trash', trash_path: config.trash_path, leave_ref: config.leave_ref, delete_mode: config.delete_mode })
+      await post({ step: 'thresholds', threshold_name: config.threshold_name, threshold_visual: config.threshold_visual, min_content_overlap: config.min_content_overlap, phonetic: config.phonetic || '', confirm_above_minutes: config.confirm_above_minutes, verify: !!config.verify, enrich_contents: !!config.enrich_contents, verify_content: !!config.verify_content })
+      await post({ step: 'auth', token, start_scan: true })
       fetchData()
     } catch (err) {
       console.error("Failed to start scan:", err)
@@ -620,6 +1339,10 @@ export default function Dashboard() {
     </div>
   )
 
+  if (locked) return (
+    <LoginView onLogin={() => { setLocked(false); fetchData() }} />
+  )
+
   if (data?.status === 'idle') return (
     <SetupView onStart={handleStartScan} isLoading={savingConfig} />
   )
@@ -632,7 +1355,7 @@ export default function Dashboard() {
     { label: 'Scan Time', value: `${data?.analysis_duration_seconds?.toFixed(2) || 0}s`, icon: Clock, color: 'text-green-400' },
   ]
 
-  const fileTypes = ['all', 'zip', 'rar', '7z', 'stl']
+  const fileTypes = ['all', 'zip', 'rar', '7z', 'cbz', 'cbr', 'epub', 'iso', 'stl']
 
   return (
     <div className="min-h-screen bg-[#0a0a0c] text-slate-200 p-8 md:p-12 flex flex-col items-center">
@@ -652,7 +1375,7 @@ export default function Dashboard() {
               <button
                 onClick={async () => {
                   const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
-                  await fetch(`${apiHost}/api/reset`, { method: 'POST' })
+                  await fetch(`${apiHost}/api/v1/reset`, { method: 'POST' })
                   window.location.reload()
                 }}
                 className="px-6 py-3 bg-red-500/10 hover:bg-red-500/20 rounded-2xl text-sm font-medium text-red-400 transition-all border border-red-500/20"
@@ -671,6 +1394,95 @@ export default function Dashboard() {
               >
                 🔔 Enable Notifications
               </button>
+              <button
+                onClick={() => {
+                  const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+                  const format = navigator.platform.toLowerCase().startsWith('win') ? 'ps1' : 'sh'
+                  window.location.href = `${apiHost}/api/v1/export/script?format=${format}`
+                }}
+                className="px-6 py-3 bg-white/5 hover:bg-white/10 rounded-2xl text-sm font-medium text-gray-400 transition-all border border-white/10"
+                title="Download the cleanup plan as a script to review and run yourself"
+              >
+                📜 Export Script
+              </button>
+              <button
+                onClick={() => {
+                  const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+                  window.location.href = `${apiHost}/api/v1/export/excludes?format=rsync`
+                }}
+                className="px-6 py-3 bg-white/5 hover:bg-white/10 rounded-2xl text-sm font-medium text-gray-400 transition-all border border-white/10"
+                title="Download the files to remove as an rsync exclude list, to leave them out of the next backup"
+              >
+                🚫 Export Excludes
+              </button>
+              <button
+                onClick={() => {
+                  const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+                  window.location.href = `${apiHost}/api/v1/export/json?thumbs=1`
+                }}
+                className="px-6 py-3 bg-white/5 hover:bg-white/10 rounded-2xl text-sm font-medium text-gray-400 transition-all border border-white/10"
+                title="Download the report as JSON, with a thumbnail of every file's preview"
+              >
+                💾 Export JSON
+              </button>
+              <button
+                onClick={() => {
+                  const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+                  window.location.href = `${apiHost}/api/v1/export?format=csv`
+                }}
+                className="px-6 py-3 bg-white/5 hover:bg-white/10 rounded-2xl text-sm font-medium text-gray-400 transition-all border border-white/10"
+                title="Download the groups as a spreadsheet, one row per file"
+              >
+                📊 Export CSV
+              </button>
+              <button
+                onClick={() => {
+                  const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+                  window.location.href = `${apiHost}/api/v1/export?format=pdf&thumbs=1`
+                }}
+                className="px-6 py-3 bg-white/5 hover:bg-white/10 rounded-2xl text-sm font-medium text-gray-400 transition-all border border-white/10"
+                title="Download the report as a PDF, with a thumbnail of every file's preview"
+              >
+                📄 Export PDF
+              </button>
+              <button
+                onClick={() => {
+                  const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+                  window.location.href = `${apiHost}/api/v1/export?format=html&thumbs=1`
+                }}
+                className="px-6 py-3 bg-white/5 hover:bg-white/10 rounded-2xl text-sm font-medium text-gray-400 transition-all border border-white/10"
+                title="Download the report as a web page, with a thumbnail of every file's preview"
+              >
+                🌐 Export HTML
+              </button>
+              <button
+                onClick={async () => {
+                  const name = prompt("Your name, recorded with your deletes, ignores and reviews")
+                  if (name === null) return
+                  const apiHost = window.location.port === '3000' ? 'http://localhost:8080' : ''
+                  await fetch(`${apiHost}/api/v1/identify`, {
+                    method: 'POST',
+                    headers: { 'Content-Type': 'application/json' },
+                    body: JSON.stringify({ name })
+                  })
+                }}
+                className="px-6 py-3 bg-white/5 hover:bg-white/10 rounded-2xl text-sm font-medium text-gray-400 transition-all border border-white/10"
+                title="Set who is using this dashboard; see GET /api/v1/audit"
+              >
+                👤 Who am I
+              </button>
+              {jobs.filter(j => j.status === 'queued' || j.status === 'running' || j.status === 'canceling').map(job => (
+                <div key={job.id} className="flex items-center gap-2 px-4 py-3 bg-white/5 rounded-2xl border border-white/10 text-xs font-medium text-gray-400" title={`Job ${job.id}`}>
+                  <Loader2 className={`w-3.5 h-3.5 ${job.status === 'running' ? 'animate-spin text-blue-400' : 'text-gray-600'}`} />
+                  <span className="uppercase tracking-widest">{job.kind}</span>
+                  <span className="text-gray-500">{job.status === 'running' ? `${Math.round(job.progress)}%` : job.status}</span>
+                  {job.status !== 'canceling' && (
+                    <button onClick={() => handleCancelJob(job.id)} className="p-0.5 hover:text-red-400 transition-colors" title="Cancel">
+                      <X className="w-3.5 h-3.5" />
+                    </button>
+                  )}
+                </div>
+              ))}
               <div className="flex items-center gap-3 px-6 py-3 bg-white/5 rounded-2xl border border-white/10">
                 <div className={`w-2.5 h-2.5 rounded-full ${data?.status === 'finished' ? 'bg-green-500 shadow-[0_0_8px_rgba(34,197,94,0.6)]' : 'bg-yellow-500 animate-pulse'}`} />
                 <span className="text-sm font-medium text-gray-300 uppercase tracking-widest">
@@ -791,6 +1603,88 @@ export default function Dashboard() {
           {/* Left Column: Listings */}
           <div className="lg:col-span-8 xl:col-span-9 space-y-6">
 
+            {/* Section: Corrupt Archives */}
+            {(data?.corrupt_files?.length || 0) > 0 && (
+              <section className="w-full glass-card rounded-[1.5rem] border border-red-500/20 p-6">
+                <div className="flex items-center gap-4 mb-4">
+                  <div className="p-3 rounded-xl bg-red-500/20">
+                    <AlertTriangle className="w-6 h-6 text-red-400" />
+                  </div>
+                  <div>
+                    <h2 className="text-lg font-black text-white uppercase tracking-wide">Unreadable Archives ({data?.corrupt_files?.length})</h2>
+                    <p className="text-xs text-gray-500 font-medium mt-1">
+                      Failed the integrity check. Re-download or quarantine them; they are excluded from duplicate analysis.
+                    </p>
+                  </div>
+                </div>
+                <div className="space-y-2">
+                  {data?.corrupt_files?.map(f => (
+                    <div key={f.path} className="flex flex-col sm:flex-row sm:items-center gap-2 bg-white/5 px-4 py-3 rounded-xl border border-white/5">
+                      <div className="flex-1 min-w-0">
+                        <div className="text-sm font-bold text-white truncate" title={f.path}>{f.name}</div>
+                        <div className="text-[11px] text-red-400/80 font-mono truncate" title={f.error}>{f.error}</div>
+                      </div>
+                      <div className="text-xs text-gray-500 font-bold whitespace-nowrap">{formatBytes(f.size)}</div>
+                    </div>
+                  ))}
+                </div>
+              </section>
+            )}
+
+            {/* Section: Renders saved next to their archive */}
+            {(data?.preview_links?.length || 0) > 0 && (
+              <section className="w-full glass-card rounded-[1.5rem] border border-orange-500/20 p-6">
+                <div className="flex items-center gap-4 mb-4">
+                  <div className="p-3 rounded-xl bg-orange-500/20">
+                    <ImageIcon className="w-6 h-6 text-orange-400" />
+                  </div>
+                  <div>
+                    <h2 className="text-lg font-black text-white uppercase tracking-wide">Archive Previews Beside Archives ({data?.preview_links?.length})</h2>
+                    <p className="text-xs text-gray-500 font-medium mt-1">
+                      Loose images showing the same picture as the preview inside an archive of the same folder.
+                    </p>
+                  </div>
+                </div>
+                <div className="space-y-2">
+                  {data?.preview_links?.map(l => (
+                    <div key={l.archive.path + l.image.path} className="flex flex-col sm:flex-row sm:items-center gap-2 bg-white/5 px-4 py-3 rounded-xl border border-white/5">
+                      <div className="flex-1 min-w-0 text-sm font-bold text-white truncate" title={l.archive.path}>{l.archive.name}</div>
+                      <div className="flex-1 min-w-0 text-sm text-orange-300 truncate" title={l.image.path}>{l.image.name}</div>
+                      <div className="text-xs text-gray-500 font-bold whitespace-nowrap">{l.distance === 0 ? 'Same picture' : `Distance ${l.distance}`}</div>
+                    </div>
+                  ))}
+                </div>
+              </section>
+            )}
+
+            {/* Section: Step 3 cluster quality and threshold suggestion */}
+            {viewMode === 'similar' && data?.cluster_metrics && data.cluster_metrics.clusters > 0 && (
+              <section className="w-full glass-card rounded-[1.5rem] border border-cyan-500/20 p-6">
+                <div className="flex flex-wrap items-center gap-6 text-xs text-gray-400 font-bold uppercase tracking-widest">
+                  <span>Cohesion <span className="text-white">{data.cluster_metrics.cohesion.toFixed(1)}%</span></span>
+                  <span>Separation <span className="text-white">{data.cluster_metrics.separation.toFixed(1)}%</span></span>
+                  <span>Silhouette <span className="text-white">{data.cluster_metrics.silhouette.toFixed(2)}</span></span>
+                  {data.cluster_metrics.cache && (
+                    <span title="Clusters reused from the previous run on the same files and settings">
+                      Cache <span className="text-white">{data.cluster_metrics.cache.hit
+                        ? `hit (${data.cluster_metrics.cache.reused} reused)`
+                        : `${data.cluster_metrics.cache.reused} reused, ${data.cluster_metrics.cache.rebuilt} rebuilt`}</span>
+                    </span>
+                  )}
+                  {data.cluster_metrics.unscored_pairs ? (
+                    <span title="Names shared by too many files are only compared with their closest neighbours">
+                      Unscored pairs <span className="text-amber-300">{data.cluster_metrics.unscored_pairs}</span>
+                    </span>
+                  ) : null}
+                </div>
+                {data.cluster_metrics.suggested_threshold ? (
+                  <p className="text-sm text-cyan-300 font-medium mt-3">
+                    💡 {data.cluster_metrics.advice}. Try a threshold of {data.cluster_metrics.suggested_threshold}% (now {data.cluster_metrics.threshold}%).
+                  </p>
+                ) : null}
+              </section>
+            )}
+
             {/* Section: Results */}
             <section className="w-full">
               <div className="flex flex-col sm:flex-row items-start sm:items-center gap-4 mb-6 pb-4 border-b border-white/5">
@@ -811,6 +1705,26 @@ export default function Dashboard() {
                   </p>
                 </div>
                 <div className="flex-1" />
+                {folderFilter && (
+                  <button
+                    onClick={clearFolderFilter}
+                    className="text-xs font-bold text-gray-400 tracking-wide bg-white/5 px-3 py-2 rounded-xl border border-white/5 hover:bg-white/10 transition-all max-w-xs truncate"
+                    title="Showing the groups with a file in this folder. Click to show every group"
+                  >
+                    📁 {folderFilter} ✕
+                  </button>
+                )}
+                <select
+                  value={reviewFilter}
+                  onChange={(e) => { setReviewFilter(e.target.value); setCurrentPage(1) }}
+                  className="text-xs font-bold text-gray-400 uppercase tracking-wide bg-white/5 px-3 py-2 rounded-xl border border-white/5"
+                  title="Show only the groups with this review status"
+                >
+                  <option value="">All groups</option>
+                  <option value="pending">Pending</option>
+                  <option value="reviewed">Reviewed</option>
+                  <option value="resolved">Resolved</option>
+                </select>
                 {currentItems.length > 0 && (
                   <div
                     className="text-xs font-bold text-gray-400 uppercase tracking-wide bg-white/5 px-4 py-2 rounded-xl border border-white/5 whitespace-nowrap cursor-pointer hover:bg-white/10 transition-all flex items-center group"
@@ -882,20 +1796,21 @@ export default function Dashboard() {
                               Group {((currentPage - 1) * itemsPerPage) + i + 1}
                             </span>
                             <button
-                              onClick={(e) => handleMarkAsGood(e, group.files)}
+                              onClick={(e) => handleMarkAsGood(e, group.files, group.id)}
                               className="p-1.5 hover:bg-green-500/20 rounded-lg text-green-500/40 hover:text-green-400 transition-all group/btn"
                               title="Mark as GOOD (Files are same size but NOT duplicates)"
                             >
                               <CheckCircle2 className="w-3.5 h-3.5" />
                             </button>
+                            <GroupReview group={group} onChange={fetchData} />
                           </div>
                           <span className="text-xs font-bold bg-white/5 px-3 py-1 rounded-full text-gray-400 tracking-tighter">
-                            Weight: {(group.size / (1024 * 1024)).toFixed(1)} MB
+                            {group.payload ? 'Same contents when decompressed' : group.method && group.method !== 'content' ? `Same ${group.method}` : group.verified ? 'Identical contents' : 'Weight'}: {(group.size / (1024 * 1024)).toFixed(1)} MB
                           </span>
                         </div>
                         <div className="space-y-2">
                           {group.files.map((file) => (
-                            <FileItem key={file.path} file={file} onRefresh={fetchData} />
+                            <FileItem key={file.path} file={file} peers={group.files} onRefresh={fetchData} />
                           ))}
                         </div>
                       </motion.div>
@@ -921,13 +1836,19 @@ export default function Dashboard() {
                             <span className={`text-[10px] font-black uppercase tracking-widest truncate transition-colors ${isSelected ? 'text-cyan-400' : 'text-cyan-500/60'}`}>
                               Cluster: {group.base_name || "Unknown"}
                             </span>
+                            {group.series && (
+                              <span className="px-2 py-0.5 rounded-full bg-amber-500/10 border border-amber-500/20 text-[9px] font-black uppercase tracking-widest text-amber-400 whitespace-nowrap" title="Names differ only by part number: probable series, not duplicates">
+                                Series
+                              </span>
+                            )}
                             <button
-                              onClick={(e) => handleMarkAsGood(e, group.files)}
+                              onClick={(e) => handleMarkAsGood(e, group.files, group.id)}
                               className="p-1.5 hover:bg-green-500/20 rounded-lg text-green-500/40 hover:text-green-400 transition-all"
                               title="Mark as GOOD (Files are NOT duplicates)"
                             >
                               <CheckCircle2 className="w-3.5 h-3.5" />
                             </button>
+                            <GroupReview group={group} onChange={fetchData} />
                           </div>
                           <span className="text-xs font-bold bg-white/5 px-3 py-1 rounded-full text-gray-400 tracking-tighter">
                             {group.files.length} Files
@@ -936,7 +1857,7 @@ export default function Dashboard() {
                         <div className="space-y-2">
                           {/* Sort by size descending within group for better visibility */}
                           {[...group.files].sort((a, b) => b.size - a.size).map((file) => (
-                            <FileItem key={file.path} file={file} onRefresh={fetchData} />
+                            <FileItem key={file.path} file={file} peers={group.files} onRefresh={fetchData} />
                           ))}
                         </div>
                       </motion.div>
@@ -963,12 +1884,13 @@ export default function Dashboard() {
                               Visual Perceptual Match: {group.base_name || "Unknown"}
                             </span>
                             <button
-                              onClick={(e) => handleMarkAsGood(e, group.files)}
+                              onClick={(e) => handleMarkAsGood(e, group.files, group.id)}
                               className="p-1.5 hover:bg-green-500/20 rounded-lg text-green-500/40 hover:text-green-400 transition-all"
                               title="Mark as GOOD (Files are NOT duplicates)"
                             >
                               <CheckCircle2 className="w-3.5 h-3.5" />
                             </button>
+                            <GroupReview group={group} onChange={fetchData} />
                           </div>
                           <div className="flex items-center gap-2">
                             <div className="px-2 py-0.5 rounded bg-orange-500/20 text-[10px] font-bold text-orange-400 uppercase tracking-widest border border-orange-500/30">
@@ -989,7 +1911,7 @@ export default function Dashboard() {
                           </div>
                           <div className="space-y-2">
                             {group.files.map((file) => (
-                              <FileItem key={file.path} file={file} onRefresh={fetchData} />
+                              <FileItem key={file.path} file={file} peers={group.files} onRefresh={fetchData} />
                             ))}
                           </div>
                         </div>
@@ -1077,6 +1999,8 @@ export default function Dashboard() {
           <div className="lg:col-span-4 xl:col-span-3 space-y-8">
             <ModelPreview selectedFiles={selectedFiles} />
 
+            <CacheCard />
+
             <div className="glass-card p-6 rounded-3xl border border-blue-500/20 sticky top-8">
               <h3 className="text-lg font-black mb-6 text-white uppercase tracking-widest flex items-center gap-3">
                 <Cpu className="w-5 h-5 text-blue-500" />
@@ -1109,7 +2033,7 @@ export default function Dashboard() {
                 >
                   {data?.status === 'analyzing_step3' ? (
                     <div className="flex flex-col items-center w-full px-4">
-                      <span className="mb-2">Scanning Names... {(data.progress || 0).toFixed(0)}%</span>
+                      <span className="mb-2">Scanning Names... {(data.progress || 0).toFixed(0)}%{formatETA(data.phases?.step3?.eta_seconds)}</span>
                       <div className="w-full h-1 bg-white/10 rounded-full overflow-hidden">
                         <div
                           className="h-full bg-cyan-500 transition-all duration-300 ease-out"
@@ -1132,7 +2056,7 @@ export default function Dashboard() {
                 >
                   {data?.status === 'analyzing_visual' ? (
                     <div className="flex flex-col items-center w-full px-4">
-                      <span className="mb-2">A.I. Visual Scan... {(data.progress || 0).toFixed(0)}%</span>
+                      <span className="mb-2">A.I. Visual Scan... {(data.progress || 0).toFixed(0)}%{formatETA(data.phases?.visual?.eta_seconds)}</span>
                       <div className="w-full h-1 bg-white/10 rounded-full overflow-hidden">
                         <div
                           className="h-full bg-orange-500 transition-all duration-300 ease-out"
@@ -0,0 +1,86 @@
+"use client"
+
+import { Canvas } from '@react-three/fiber'
+import { OrbitControls, Stage } from '@react-three/drei'
+import { Suspense, useMemo } from 'react'
+import * as THREE from 'three'
+
+// One side of a POST /api/v1/compare-models response
+export interface ModelSide {
+    path: string
+    internal_path: string
+    info: {
+        triangle_count: number
+        vertex_count: number
+        bounds: { min_x: number, max_x: number, min_y: number, max_y: number, min_z: number, max_z: number }
+        is_binary: boolean
+    }
+    mesh: { positions: number[], triangle_count: number, original_triangles: number, decimated: boolean }
+}
+
+export interface ModelComparison {
+    identical: boolean
+    diff: { vertices_1: number, vertices_2: number, triangles_1: number, triangles_2: number, description: string } | null
+    a: ModelSide
+    b: ModelSide
+}
+
+function MeshView({ side, color, position }: { side: ModelSide, color: string, position: [number, number, number] }) {
+    // Both models keep their own scale, so a difference in size stays visible
+    const geometry = useMemo(() => {
+        const geom = new THREE.BufferGeometry()
+        geom.setAttribute('position', new THREE.Float32BufferAttribute(side.mesh.positions, 3))
+        geom.center()
+        geom.computeVertexNormals()
+        return geom
+    }, [side])
+
+    return (
+        <mesh geometry={geometry} position={position} rotation={[-Math.PI / 2, 0, 0]} castShadow receiveShadow>
+            <meshStandardMaterial color={color} roughness={0.2} metalness={0.8} />
+        </mesh>
+    )
+}
+
+function size(side: ModelSide): string {
+    const b = side.info.bounds
+    return [b.max_x - b.min_x, b.max_y - b.min_y, b.max_z - b.min_z].map(v => v.toFixed(1)).join(' × ')
+}
+
+export default function ModelCompare({ result }: { result: ModelComparison }) {
+    const sides = [result.a, result.b]
+    const colors = ['#3b82f6', '#06b6d4']
+    const spacing = Math.max(...sides.map(s => {
+        const b = s.info.bounds
+        return b.max_x - b.min_x
+    })) * 1.2
+
+    return (
+        <div className="flex flex-col gap-3 flex-1 min-h-0">
+            <div className={`text-xs font-bold ${result.identical ? 'text-green-400' : 'text-amber-400'}`}>
+                {result.identical ? 'The models are identical' : result.diff?.description || 'The models differ'}
+            </div>
+            <div className="grid grid-cols-2 gap-4 text-[10px] text-gray-400">
+                {sides.map((s, i) => (
+                    <div key={i} className="flex flex-col gap-0.5 min-w-0">
+                        <span className="font-bold truncate" style={{ color: colors[i] }} title={`${s.path} › ${s.internal_path}`}>{s.internal_path || s.path}</span>
+                        <span>{s.info.triangle_count.toLocaleString()} triangles · {s.info.vertex_count.toLocaleString()} vertices · {s.info.is_binary ? 'binary' : 'ASCII'}</span>
+                        <span>Size {size(s)}</span>
+                        {s.mesh.decimated && <span className="text-gray-600">Showing {s.mesh.triangle_count.toLocaleString()} of {s.mesh.original_triangles.toLocaleString()} triangles</span>}
+                    </div>
+                ))}
+            </div>
+            <div className="flex-1 min-h-[240px] rounded-xl overflow-hidden bg-gradient-to-b from-[#111115] to-[#0a0a0c] border border-white/5">
+                <Canvas shadows camera={{ position: [5, 0, 10], fov: 45 }}>
+                    <Suspense fallback={null}>
+                        <Stage adjustCamera={1.2} intensity={0.5} environment="city" shadows="contact">
+                            <MeshView side={result.a} color={colors[0]} position={[-spacing / 2, 0, 0]} />
+                            <MeshView side={result.b} color={colors[1]} position={[spacing / 2, 0, 0]} />
+                        </Stage>
+                    </Suspense>
+                    <OrbitControls makeDefault />
+                </Canvas>
+            </div>
+        </div>
+    )
+}
@@ -127,7 +127,7 @@ function ComparisonScene({ files }: { files: string[] }) {
                 <ModelViewer
                     key={path}
                     path={path}
-                    url={`${apiHost}/api/preview?path=${encodeURIComponent(path)}&type=model`}
+                    url={`${apiHost}/api/v1/preview?path=${encodeURIComponent(path)}&type=model`}
                     color={colors[i % colors.length]}
                     position={[0, startY - (i * spacing), 0]}
                 />
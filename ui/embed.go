@@ -0,0 +1,15 @@
+//go:build embedui
+
+package ui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:out
+var out embed.FS
+
+func init() {
+	Files, _ = fs.Sub(out, "out")
+}
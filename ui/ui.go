@@ -0,0 +1,8 @@
+// Package ui carries the dashboard built by `npm run build` (ui/out) inside the binary when it
+// is built with -tags embedui, so that a single executable serves it from any folder.
+package ui
+
+import "io/fs"
+
+// Files is the built dashboard, or nil when the binary was built without it
+var Files fs.FS